@@ -69,6 +69,9 @@ var (
 // DeployBackend, GasEstimator, GasPricer, LogFilterer, PendingContractCaller, TransactionReader, and TransactionSender
 type SimulatedBackend struct {
 	m         *stages.MockSentry
+	fork      *forkedChain // instead of m when forked from a datadir
+	config    *chain.Config
+	engine    consensus.Engine
 	getHeader func(hash libcommon.Hash, number uint64) *types.Header
 
 	mu              sync.Mutex
@@ -77,10 +80,12 @@ type SimulatedBackend struct {
 	pendingHeader   *types.Header
 	gasPool         *core.GasPool
 	pendingBlock    *types.Block // Currently pending block that will be imported on request
-	pendingReader   *state.PlainStateReader
+	pendingReader   state.StateReader
 	pendingReaderTx kv.Tx
 	pendingState    *state.IntraBlockState // Currently pending state that will be the active on request
 
+	impersonated map[libcommon.Address]struct{} // senders of the transactions accepted without signature
+
 	rmLogsFeed event.Feed
 	chainFeed  event.Feed
 	logsFeed   event.Feed
@@ -94,6 +99,8 @@ func NewSimulatedBackendWithConfig(alloc types.GenesisAlloc, config *chain.Confi
 	m := stages.MockWithGenesisEngine(nil, &genesis, engine, false)
 	backend := &SimulatedBackend{
 		m:            m,
+		config:       m.ChainConfig,
+		engine:       m.Engine,
 		prependBlock: m.Genesis,
 		getHeader: func(hash libcommon.Hash, number uint64) (h *types.Header) {
 			if err := m.DB.View(context.Background(), func(tx kv.Tx) error {
@@ -121,19 +128,41 @@ func NewTestSimulatedBackendWithConfig(t *testing.T, alloc types.GenesisAlloc, c
 	t.Cleanup(b.Close)
 	return b
 }
-func (b *SimulatedBackend) DB() kv.RwDB               { return b.m.DB }
-func (b *SimulatedBackend) Agg() *state2.AggregatorV3 { return b.m.HistoryV3Components() }
+func (b *SimulatedBackend) DB() kv.RwDB {
+	if b.fork != nil {
+		return b.fork.db
+	}
+	return b.m.DB
+}
+func (b *SimulatedBackend) Agg() *state2.AggregatorV3 {
+	if b.fork != nil {
+		return b.fork.agg
+	}
+	return b.m.HistoryV3Components()
+}
 func (b *SimulatedBackend) BlockReader() *snapshotsync.BlockReaderWithSnapshots {
+	if b.fork != nil {
+		return b.fork.blockReader
+	}
 	return snapshotsync.NewBlockReaderWithSnapshots(b.m.BlockSnapshots, b.m.TransactionsV3)
 }
-func (b *SimulatedBackend) HistoryV3() bool          { return b.m.HistoryV3 }
-func (b *SimulatedBackend) Engine() consensus.Engine { return b.m.Engine }
+func (b *SimulatedBackend) HistoryV3() bool {
+	if b.fork != nil {
+		return b.fork.historyV3
+	}
+	return b.m.HistoryV3
+}
+func (b *SimulatedBackend) Engine() consensus.Engine { return b.engine }
 
 // Close terminates the underlying blockchain's update loop.
 func (b *SimulatedBackend) Close() {
 	if b.pendingReaderTx != nil {
 		b.pendingReaderTx.Rollback()
 	}
+	if b.fork != nil {
+		b.fork.close()
+		return
+	}
 	b.m.Close()
 }
 
@@ -142,7 +171,11 @@ func (b *SimulatedBackend) Close() {
 func (b *SimulatedBackend) Commit() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	if err := b.m.InsertChain(&core.ChainPack{
+	if b.fork != nil {
+		if err := b.commitForked(); err != nil {
+			panic(err)
+		}
+	} else if err := b.m.InsertChain(&core.ChainPack{
 		Headers:  []*types.Header{b.pendingHeader},
 		Blocks:   []*types.Block{b.pendingBlock},
 		TopBlock: b.pendingBlock,
@@ -168,7 +201,13 @@ func (b *SimulatedBackend) Rollback() {
 }
 
 func (b *SimulatedBackend) emptyPendingBlock() {
-	chain, _ := core.GenerateChain(b.m.ChainConfig, b.prependBlock, b.m.Engine, b.m.DB, 1, func(int, *core.BlockGen) {}, false /* intermediateHashes */)
+	if b.fork != nil {
+		if err := b.emptyForkedPendingBlock(); err != nil {
+			panic(err)
+		}
+		return
+	}
+	chain, _ := core.GenerateChain(b.config, b.prependBlock, b.engine, b.m.DB, 1, func(int, *core.BlockGen) {}, false /* intermediateHashes */)
 	b.pendingBlock = chain.Blocks[0]
 	b.pendingReceipts = chain.Receipts[0]
 	b.pendingHeader = chain.Headers[0]
@@ -192,6 +231,13 @@ func (b *SimulatedBackend) emptyPendingBlock() {
 
 // stateByBlockNumber retrieves a state by a given blocknumber.
 func (b *SimulatedBackend) stateByBlockNumber(db kv.Tx, blockNumber *big.Int) *state.IntraBlockState {
+	if b.fork != nil {
+		number := b.pendingBlock.NumberU64()
+		if blockNumber != nil {
+			number = blockNumber.Uint64()
+		}
+		return state.New(b.fork.stateReader(number))
+	}
 	if blockNumber == nil || blockNumber.Cmp(b.pendingBlock.Number()) == 0 {
 		return state.New(b.m.NewHistoryStateReader(b.pendingBlock.NumberU64()+1, db))
 	}
@@ -202,7 +248,7 @@ func (b *SimulatedBackend) stateByBlockNumber(db kv.Tx, blockNumber *big.Int) *s
 func (b *SimulatedBackend) CodeAt(ctx context.Context, contract libcommon.Address, blockNumber *big.Int) ([]byte, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	tx, err := b.m.DB.BeginRo(context.Background())
+	tx, err := b.DB().BeginRo(context.Background())
 	if err != nil {
 		return nil, err
 	}
@@ -215,7 +261,7 @@ func (b *SimulatedBackend) CodeAt(ctx context.Context, contract libcommon.Addres
 func (b *SimulatedBackend) BalanceAt(ctx context.Context, contract libcommon.Address, blockNumber *big.Int) (*uint256.Int, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	tx, err := b.m.DB.BeginRo(context.Background())
+	tx, err := b.DB().BeginRo(context.Background())
 	if err != nil {
 		return nil, err
 	}
@@ -228,7 +274,7 @@ func (b *SimulatedBackend) BalanceAt(ctx context.Context, contract libcommon.Add
 func (b *SimulatedBackend) NonceAt(ctx context.Context, contract libcommon.Address, blockNumber *big.Int) (uint64, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	tx, err := b.m.DB.BeginRo(context.Background())
+	tx, err := b.DB().BeginRo(context.Background())
 	if err != nil {
 		return 0, err
 	}
@@ -242,7 +288,7 @@ func (b *SimulatedBackend) NonceAt(ctx context.Context, contract libcommon.Addre
 func (b *SimulatedBackend) StorageAt(ctx context.Context, contract libcommon.Address, key libcommon.Hash, blockNumber *big.Int) ([]byte, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	tx, err := b.m.DB.BeginRo(context.Background())
+	tx, err := b.DB().BeginRo(context.Background())
 	if err != nil {
 		return nil, err
 	}
@@ -259,6 +305,9 @@ func (b *SimulatedBackend) TransactionReceipt(ctx context.Context, txHash libcom
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if b.fork != nil {
+		return b.fork.receipt(txHash)
+	}
 	tx, err := b.m.DB.BeginRo(context.Background())
 	if err != nil {
 		return nil, err
@@ -276,7 +325,7 @@ func (b *SimulatedBackend) TransactionByHash(ctx context.Context, txHash libcomm
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	tx, err := b.m.DB.BeginRo(context.Background())
+	tx, err := b.DB().BeginRo(context.Background())
 	if err != nil {
 		return nil, false, err
 	}
@@ -286,6 +335,10 @@ func (b *SimulatedBackend) TransactionByHash(ctx context.Context, txHash libcomm
 	if txn != nil {
 		return txn, true, nil
 	}
+	if b.fork != nil {
+		txn, err = b.fork.transaction(txHash)
+		return txn, false, err
+	}
 	blockNumber, err := rawdb.ReadTxLookupEntry(tx, txHash)
 	if err != nil {
 		return nil, false, err
@@ -311,6 +364,13 @@ func (b *SimulatedBackend) BlockByHash(ctx context.Context, hash libcommon.Hash)
 	if hash == b.pendingBlock.Hash() {
 		return b.pendingBlock, nil
 	}
+	if b.fork != nil {
+		block, err := b.fork.blockByHash(hash)
+		if err == nil && block == nil {
+			err = errBlockDoesNotExist
+		}
+		return block, err
+	}
 	tx, err := b.m.DB.BeginRo(context.Background())
 	if err != nil {
 		return nil, err
@@ -343,6 +403,13 @@ func (b *SimulatedBackend) blockByNumberNoLock(_ context.Context, number *big.In
 	if number == nil || number.Cmp(b.prependBlock.Number()) == 0 {
 		return b.prependBlock, nil
 	}
+	if b.fork != nil {
+		block, err := b.fork.blockByNumber(number.Uint64())
+		if err == nil && block == nil {
+			err = errBlockDoesNotExist
+		}
+		return block, err
+	}
 
 	tx, err := b.m.DB.BeginRo(context.Background())
 	if err != nil {
@@ -370,6 +437,13 @@ func (b *SimulatedBackend) HeaderByHash(ctx context.Context, hash libcommon.Hash
 	if hash == b.pendingBlock.Hash() {
 		return b.pendingBlock.Header(), nil
 	}
+	if b.fork != nil {
+		header, err := b.fork.headerByHash(hash)
+		if err == nil && header == nil {
+			err = errBlockDoesNotExist
+		}
+		return header, err
+	}
 	tx, err := b.m.DB.BeginRo(context.Background())
 	if err != nil {
 		return nil, err
@@ -393,15 +467,18 @@ func (b *SimulatedBackend) HeaderByHash(ctx context.Context, hash libcommon.Hash
 func (b *SimulatedBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	if number == nil || number.Cmp(b.prependBlock.Number()) == 0 {
+		return b.prependBlock.Header(), nil
+	}
+	if b.fork != nil {
+		return b.fork.headerByNumber(number.Uint64())
+	}
 	tx, err := b.m.DB.BeginRo(context.Background())
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
-	if number == nil || number.Cmp(b.prependBlock.Number()) == 0 {
-		return b.prependBlock.Header(), nil
-	}
 	hash, err := rawdb.ReadCanonicalHash(tx, number.Uint64())
 	if err != nil {
 		return nil, err
@@ -418,13 +495,7 @@ func (b *SimulatedBackend) TransactionCount(ctx context.Context, blockHash libco
 	if blockHash == b.pendingBlock.Hash() {
 		return uint(b.pendingBlock.Transactions().Len()), nil
 	}
-	tx, err := b.m.DB.BeginRo(context.Background())
-	if err != nil {
-		return 0, err
-	}
-	defer tx.Rollback()
-
-	block, err := rawdb.ReadBlockByHash(tx, blockHash)
+	block, err := b.readBlockByHash(blockHash)
 	if err != nil {
 		return 0, err
 	}
@@ -448,13 +519,7 @@ func (b *SimulatedBackend) TransactionInBlock(ctx context.Context, blockHash lib
 
 		return transactions[index], nil
 	}
-	tx, err := b.m.DB.BeginRo(context.Background())
-	if err != nil {
-		return nil, err
-	}
-	defer tx.Rollback()
-
-	block, err := rawdb.ReadBlockByHash(tx, blockHash)
+	block, err := b.readBlockByHash(blockHash)
 	if err != nil {
 		return nil, err
 	}
@@ -470,6 +535,19 @@ func (b *SimulatedBackend) TransactionInBlock(ctx context.Context, blockHash lib
 	return transactions[index], nil
 }
 
+// readBlockByHash returns the block of the chain, nil if there is none.
+func (b *SimulatedBackend) readBlockByHash(hash libcommon.Hash) (*types.Block, error) {
+	if b.fork != nil {
+		return b.fork.blockByHash(hash)
+	}
+	tx, err := b.m.DB.BeginRo(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	return rawdb.ReadBlockByHash(tx, hash)
+}
+
 // PendingCodeAt returns the code associated with an account in the pending state.
 func (b *SimulatedBackend) PendingCodeAt(ctx context.Context, contract libcommon.Address) ([]byte, error) {
 	b.mu.Lock()
@@ -517,7 +595,12 @@ func (b *SimulatedBackend) CallContract(ctx context.Context, call ethereum.CallM
 		return nil, errBlockNumberUnsupported
 	}
 	var res *core.ExecutionResult
-	if err := b.m.DB.View(context.Background(), func(tx kv.Tx) (err error) {
+	if b.fork != nil {
+		var err error
+		if res, err = b.callContract(ctx, call, b.pendingBlock, state.New(b.fork.head().state)); err != nil {
+			return nil, err
+		}
+	} else if err := b.m.DB.View(context.Background(), func(tx kv.Tx) (err error) {
 		s := state.New(state.NewPlainStateReader(tx))
 		res, err = b.callContract(ctx, call, b.pendingBlock, s)
 		if err != nil {
@@ -689,10 +772,10 @@ func (b *SimulatedBackend) callContract(_ context.Context, call ethereum.CallMsg
 	txContext := core.NewEVMTxContext(msg)
 	header := block.Header()
 	excessDataGas := header.ParentExcessDataGas(b.getHeader)
-	evmContext := core.NewEVMBlockContext(header, core.GetHashFn(header, b.getHeader), b.m.Engine, nil, excessDataGas)
+	evmContext := core.NewEVMBlockContext(header, core.GetHashFn(header, b.getHeader), b.engine, nil, excessDataGas)
 	// Create a new environment which holds all relevant information
 	// about the transaction and calling mechanisms.
-	vmEnv := vm.NewEVM(evmContext, txContext, statedb, b.m.ChainConfig, vm.Config{})
+	vmEnv := vm.NewEVM(evmContext, txContext, statedb, b.config, vm.Config{})
 	gasPool := new(core.GasPool).AddGas(math.MaxUint64)
 
 	return core.NewStateTransition(vmEnv, msg, gasPool).TransitionDb(true /* refunds */, false /* gasBailout */)
@@ -704,11 +787,19 @@ func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx types.Transac
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	return b.sendTransaction(tx)
+}
+
+func (b *SimulatedBackend) sendTransaction(tx types.Transaction) error {
 	// Check transaction validity.
-	signer := types.MakeSigner(b.m.ChainConfig, b.pendingBlock.NumberU64())
-	sender, senderErr := tx.Sender(*signer)
-	if senderErr != nil {
-		return fmt.Errorf("invalid transaction: %w", senderErr)
+	signer := types.MakeSigner(b.config, b.pendingBlock.NumberU64())
+	sender, cached := tx.GetSender()
+	if _, impersonated := b.impersonated[sender]; !cached || !impersonated {
+		// Only the impersonated accounts can send unsigned transactions
+		var senderErr error
+		if sender, senderErr = signer.Sender(tx); senderErr != nil {
+			return fmt.Errorf("invalid transaction: %w", senderErr)
+		}
 	}
 	nonce := b.pendingState.GetNonce(sender)
 	if tx.GetNonce() != nonce {
@@ -717,21 +808,26 @@ func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx types.Transac
 
 	b.pendingState.SetTxContext(tx.Hash(), libcommon.Hash{}, len(b.pendingBlock.Transactions()))
 	//fmt.Printf("==== Start producing block %d, header: %d\n", b.pendingBlock.NumberU64(), b.pendingHeader.Number.Uint64())
-	if _, _, err := core.ApplyTransaction(
-		b.m.ChainConfig, core.GetHashFn(b.pendingHeader, b.getHeader), b.m.Engine,
+	receipt, _, err := core.ApplyTransaction(
+		b.config, core.GetHashFn(b.pendingHeader, b.getHeader), b.engine,
 		&b.pendingHeader.Coinbase, b.gasPool,
 		b.pendingState, state.NewNoopWriter(),
 		b.pendingHeader, tx,
 		&b.pendingHeader.GasUsed, vm.Config{},
-		b.pendingHeader.ParentExcessDataGas(b.getHeader)); err != nil {
+		b.pendingHeader.ParentExcessDataGas(b.getHeader))
+	if err != nil {
 		return err
 	}
+	if b.fork != nil {
+		b.addForkedTransaction(tx, receipt)
+		return nil
+	}
 	//fmt.Printf("==== Start producing block %d\n", (b.prependBlock.NumberU64() + 1))
-	chain, err := core.GenerateChain(b.m.ChainConfig, b.prependBlock, b.m.Engine, b.m.DB, 1, func(number int, block *core.BlockGen) {
+	chain, err := core.GenerateChain(b.config, b.prependBlock, b.engine, b.m.DB, 1, func(number int, block *core.BlockGen) {
 		for _, tx := range b.pendingBlock.Transactions() {
-			block.AddTxWithChain(b.getHeader, b.m.Engine, tx)
+			block.AddTxWithChain(b.getHeader, b.engine, tx)
 		}
-		block.AddTxWithChain(b.getHeader, b.m.Engine, tx)
+		block.AddTxWithChain(b.getHeader, b.engine, tx)
 	}, false /* intermediateHashes */)
 	if err != nil {
 		return err
@@ -771,10 +867,18 @@ func (b *SimulatedBackend) AdjustTime(adjustment time.Duration) error {
 	if len(b.pendingBlock.Transactions()) != 0 {
 		return errors.New("could not adjust time on non-empty block")
 	}
+	if b.fork != nil {
+		b.pendingHeader.Time += uint64(adjustment.Seconds())
+		if err := b.engine.Prepare(b.fork, b.pendingHeader, nil); err != nil {
+			return err
+		}
+		b.pendingBlock = b.fork.newBlock(b.pendingHeader, nil, nil)
+		return nil
+	}
 
-	chain, err := core.GenerateChain(b.m.ChainConfig, b.prependBlock, b.m.Engine, b.m.DB, 1, func(number int, block *core.BlockGen) {
+	chain, err := core.GenerateChain(b.config, b.prependBlock, b.engine, b.m.DB, 1, func(number int, block *core.BlockGen) {
 		for _, tx := range b.pendingBlock.Transactions() {
-			block.AddTxWithChain(b.getHeader, b.m.Engine, tx)
+			block.AddTxWithChain(b.getHeader, b.engine, tx)
 		}
 		block.OffsetTime(int64(adjustment.Seconds()))
 	}, false /* intermediateHashes */)
@@ -0,0 +1,176 @@
+package backends
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/consensus/ethash"
+	"github.com/ledgerwatch/erigon/consensus/serenity"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+)
+
+var errUnknownSnapshot = errors.New("unknown snapshot")
+
+// SetBalance sets the balance of the account in the latest state.
+func (b *SimulatedBackend) SetBalance(address libcommon.Address, balance *uint256.Int) error {
+	return b.modifyState(func(s *state.IntraBlockState) {
+		s.SetBalance(address, balance)
+	})
+}
+
+// SetNonce sets the nonce of the account in the latest state.
+func (b *SimulatedBackend) SetNonce(address libcommon.Address, nonce uint64) error {
+	return b.modifyState(func(s *state.IntraBlockState) {
+		s.SetNonce(address, nonce)
+	})
+}
+
+// SetCode sets the code of the account in the latest state, its storage is kept.
+func (b *SimulatedBackend) SetCode(address libcommon.Address, code []byte) error {
+	return b.modifyState(func(s *state.IntraBlockState) {
+		s.SetCode(address, code)
+	})
+}
+
+// SetStorageAt sets a storage slot of the account in the latest state.
+func (b *SimulatedBackend) SetStorageAt(address libcommon.Address, key, value libcommon.Hash) error {
+	return b.modifyState(func(s *state.IntraBlockState) {
+		s.SetState(address, &key, *new(uint256.Int).SetBytes(value.Bytes()))
+	})
+}
+
+// modifyState applies the changes to the state after the latest block. The pending transactions are applied
+// again on top of it, the ones which became invalid are dropped.
+func (b *SimulatedBackend) modifyState(modify func(s *state.IntraBlockState)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.keepBlocksInMemory(); err != nil {
+		return err
+	}
+	head := b.fork.head()
+	s := state.New(head.state)
+	modify(s)
+	changes := newForkState(head.state)
+	if err := s.CommitBlock(b.config.Rules(b.pendingHeader.Number.Uint64(), b.pendingHeader.Time), changes); err != nil {
+		return err
+	}
+	b.fork.setHeadState(changes)
+
+	txs := b.pendingBlock.Transactions()
+	b.pendingHeader.GasUsed = 0
+	b.pendingReceipts = nil
+	b.pendingBlock = b.fork.newBlock(b.pendingHeader, nil, nil)
+	b.gasPool = new(core.GasPool).AddGas(b.pendingHeader.GasLimit)
+	b.pendingState = state.New(changes)
+	b.reapplyTransactions(txs)
+	return nil
+}
+
+// reapplyTransactions applies the transactions to the empty pending block, the invalid ones are dropped.
+func (b *SimulatedBackend) reapplyTransactions(txs types.Transactions) {
+	for _, tx := range txs {
+		if err := b.sendTransaction(tx); err != nil {
+			log.Debug("Dropped pending transaction", "hash", tx.Hash(), "err", err)
+		}
+	}
+}
+
+// keepBlocksInMemory switches a backend created by NewSimulatedBackendWithConfig to keep its next blocks and
+// their state in memory like a dev backend, on top of its latest block, for the cheatcodes and the snapshots.
+// The pending transactions are applied again on top of it.
+func (b *SimulatedBackend) keepBlocksInMemory() error {
+	if b.fork != nil {
+		return nil
+	}
+	blockReader := snapshotsync.NewBlockReaderWithSnapshots(b.m.BlockSnapshots, b.m.TransactionsV3)
+	fork, err := newForkedChain(b.m.DB, b.m.HistoryV3Components(), blockReader, b.prependBlock.NumberU64(), b.m.Close)
+	if err != nil {
+		return err
+	}
+	if b.pendingReaderTx != nil {
+		b.pendingReaderTx.Rollback()
+		b.pendingReaderTx = nil
+	}
+	txs, pendingTime := b.pendingBlock.Transactions(), b.pendingHeader.Time
+	b.fork, b.engine, b.getHeader = fork, serenity.New(ethash.NewFaker()), fork.GetHeader
+	b.prependBlock = fork.base
+	if err := b.emptyForkedPendingBlock(); err != nil {
+		return err
+	}
+	// Keep the time set by AdjustTime
+	if b.pendingHeader.Time != pendingTime {
+		b.pendingHeader.Time = pendingTime
+		if err := b.engine.Prepare(b.fork, b.pendingHeader, nil); err != nil {
+			return err
+		}
+		b.pendingBlock = b.fork.newBlock(b.pendingHeader, nil, nil)
+	}
+	b.reapplyTransactions(txs)
+	return nil
+}
+
+// ImpersonateAccount lets SendTransaction accept the transactions of the account without signature, their
+// sender has to be set with SetSender instead.
+func (b *SimulatedBackend) ImpersonateAccount(address libcommon.Address) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// The mock sentry would recover the senders of the committed blocks from the signatures
+	if err := b.keepBlocksInMemory(); err != nil {
+		return err
+	}
+	if b.impersonated == nil {
+		b.impersonated = map[libcommon.Address]struct{}{}
+	}
+	b.impersonated[address] = struct{}{}
+	return nil
+}
+
+// StopImpersonatingAccount requires the transactions of the account to be signed again.
+func (b *SimulatedBackend) StopImpersonatingAccount(address libcommon.Address) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.impersonated, address)
+}
+
+func (b *SimulatedBackend) isImpersonated(address libcommon.Address) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, ok := b.impersonated[address]
+	return ok
+}
+
+// Mine commits the pending block followed by empty blocks, blocks in total.
+func (b *SimulatedBackend) Mine(blocks uint64) {
+	for i := uint64(0); i < blocks; i++ {
+		b.Commit()
+	}
+}
+
+// Snapshot saves the chain and its state to be restored by Revert, the snapshots can be nested.
+func (b *SimulatedBackend) Snapshot() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.keepBlocksInMemory(); err != nil {
+		return 0, err
+	}
+	return b.fork.snapshot(), nil
+}
+
+// Revert restores the chain saved by the snapshot and drops the pending transactions. The snapshot and the
+// ones taken after it are gone, a snapshot has to be taken again to revert to the same chain twice.
+func (b *SimulatedBackend) Revert(id uint64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.fork == nil || !b.fork.revert(id) {
+		return fmt.Errorf("%w %d", errUnknownSnapshot, id)
+	}
+	b.prependBlock = b.fork.head().block
+	return b.emptyForkedPendingBlock()
+}
@@ -0,0 +1,243 @@
+package backends
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/accounts/abi"
+	"github.com/ledgerwatch/erigon/accounts/abi/bind"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/params"
+)
+
+func TestSimulatedBackend_Cheatcodes(t *testing.T) {
+	testAddr := crypto.PubkeyToAddress(testKey.PublicKey)
+	sim := NewTestDevSimulatedBackend(t, types.GenesisAlloc{testAddr: {Balance: big.NewInt(10000000000)}}, 10000000)
+	ctx := context.Background()
+	other := libcommon.HexToAddress("0x1234")
+	counter := libcommon.HexToAddress("0x5678")
+
+	// A pending transfer is applied again on top of the modified state
+	signer := types.MakeSigner(params.TestChainConfig, 1)
+	tx, err := types.SignTx(types.NewTransaction(0, other, uint256.NewInt(1000), params.TxGas, uint256.NewInt(1), nil), *signer, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.SendTransaction(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.SetBalance(other, uint256.NewInt(5)); err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.SetNonce(other, 7); err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.SetCode(counter, common.FromHex(counterRuntimeCode)); err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.SetStorageAt(counter, libcommon.Hash{}, libcommon.BigToHash(big.NewInt(41))); err != nil {
+		t.Fatal(err)
+	}
+
+	balance, err := sim.BalanceAt(ctx, other, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Uint64() != 5 {
+		t.Errorf("expected balance 5 in the latest state, got %d", balance.Uint64())
+	}
+	if nonce, err := sim.NonceAt(ctx, other, nil); err != nil || nonce != 7 {
+		t.Errorf("expected nonce 7, got %d: %v", nonce, err)
+	}
+	if v := counterValue(t, sim, counter, nil); v != 41 {
+		t.Errorf("expected counter 41, got %d", v)
+	}
+
+	sim.Commit()
+	balance, err = sim.BalanceAt(ctx, other, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Uint64() != 1005 {
+		t.Errorf("expected the pending transfer on top of the balance, got %d", balance.Uint64())
+	}
+	if receipt, err := sim.TransactionReceipt(ctx, tx.Hash()); err != nil || receipt == nil {
+		t.Errorf("expected the pending transfer to be committed: %v", err)
+	}
+
+	// The code set by the cheatcode runs
+	auth, _ := bind.NewKeyedTransactorWithChainID(testKey, big.NewInt(1337))
+	if _, err := bind.NewBoundContract(counter, abi.ABI{}, sim, sim, sim).RawTransact(auth, nil); err != nil {
+		t.Fatalf("could not call contract: %v", err)
+	}
+	sim.Commit()
+	if v := counterValue(t, sim, counter, nil); v != 42 {
+		t.Errorf("expected counter 42, got %d", v)
+	}
+	if v := counterValue(t, sim, counter, big.NewInt(1)); v != 41 {
+		t.Errorf("expected counter 41 at block 1, got %d", v)
+	}
+}
+
+func TestSimulatedBackend_SnapshotRevert(t *testing.T) {
+	testAddr := crypto.PubkeyToAddress(testKey.PublicKey)
+	sim := NewTestDevSimulatedBackend(t, types.GenesisAlloc{testAddr: {Balance: big.NewInt(10000000000)}}, 10000000)
+	ctx := context.Background()
+	other := libcommon.HexToAddress("0x1234")
+	balanceOf := func() uint64 {
+		balance, err := sim.BalanceAt(ctx, other, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return balance.Uint64()
+	}
+
+	first, err := sim.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.SetBalance(other, uint256.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	sim.Mine(3)
+	second, err := sim.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.SetBalance(other, uint256.NewInt(2)); err != nil {
+		t.Fatal(err)
+	}
+	sim.Mine(2)
+	third, err := sim.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sim.Revert(second); err != nil {
+		t.Fatal(err)
+	}
+	if b := balanceOf(); b != 1 {
+		t.Errorf("expected balance 1 at the second snapshot, got %d", b)
+	}
+	if header, _ := sim.HeaderByNumber(ctx, nil); header.Number.Uint64() != 3 {
+		t.Errorf("expected block 3 at the second snapshot, got %d", header.Number.Uint64())
+	}
+	if _, err := sim.BlockByNumber(ctx, big.NewInt(4)); err != errBlockDoesNotExist {
+		t.Errorf("expected the reverted blocks to be gone, got %v", err)
+	}
+	if err := sim.Revert(third); !errors.Is(err, errUnknownSnapshot) {
+		t.Errorf("expected the later snapshot to be gone, got %v", err)
+	}
+
+	// The chain goes on from the snapshot
+	sim.Mine(1)
+	if header, _ := sim.HeaderByNumber(ctx, nil); header.Number.Uint64() != 4 {
+		t.Errorf("expected block 4, got %d", header.Number.Uint64())
+	}
+	if err := sim.Revert(first); err != nil {
+		t.Fatal(err)
+	}
+	if b := balanceOf(); b != 0 {
+		t.Errorf("expected no balance at the first snapshot, got %d", b)
+	}
+	if header, _ := sim.HeaderByNumber(ctx, nil); header.Number.Uint64() != 0 {
+		t.Errorf("expected the genesis at the first snapshot, got %d", header.Number.Uint64())
+	}
+	if err := sim.Revert(first); !errors.Is(err, errUnknownSnapshot) {
+		t.Errorf("expected the snapshot to be gone after the revert, got %v", err)
+	}
+}
+
+func TestSimulatedBackend_Impersonate(t *testing.T) {
+	sim := NewTestDevSimulatedBackend(t, types.GenesisAlloc{}, 10000000)
+	ctx := context.Background()
+	whale := libcommon.HexToAddress("0xbeef")
+	other := libcommon.HexToAddress("0x1234")
+	if err := sim.SetBalance(whale, uint256.NewInt(1000000)); err != nil {
+		t.Fatal(err)
+	}
+
+	unsigned := func(nonce uint64) types.Transaction {
+		tx := types.NewTransaction(nonce, other, uint256.NewInt(1000), params.TxGas, uint256.NewInt(1), nil)
+		tx.SetSender(whale)
+		return tx
+	}
+	if err := sim.SendTransaction(ctx, unsigned(0)); err == nil {
+		t.Fatal("expected the unsigned transaction to be rejected")
+	}
+	if err := sim.ImpersonateAccount(whale); err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.SendTransaction(ctx, unsigned(0)); err != nil {
+		t.Fatal(err)
+	}
+	sim.Commit()
+	balance, err := sim.BalanceAt(ctx, other, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Uint64() != 1000 {
+		t.Errorf("expected balance 1000, got %d", balance.Uint64())
+	}
+
+	sim.StopImpersonatingAccount(whale)
+	if err := sim.SendTransaction(ctx, unsigned(1)); err == nil {
+		t.Fatal("expected the unsigned transaction to be rejected")
+	}
+}
+
+func TestSimulatedBackend_CheatcodesOnMockBackend(t *testing.T) {
+	testAddr := crypto.PubkeyToAddress(testKey.PublicKey)
+	sim := simTestBackend(t, testAddr)
+	ctx := context.Background()
+	other := libcommon.HexToAddress("0x1234")
+	sim.Commit()
+
+	// The pending transfer is kept when the backend switches to the in-memory blocks
+	signer := types.MakeSigner(params.TestChainConfig, 2)
+	tx, err := types.SignTx(types.NewTransaction(0, other, uint256.NewInt(1000), params.TxGas, uint256.NewInt(1), nil), *signer, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.SendTransaction(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	id, err := sim.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.SetBalance(other, uint256.NewInt(5)); err != nil {
+		t.Fatal(err)
+	}
+	sim.Mine(2)
+	if header, _ := sim.HeaderByNumber(ctx, nil); header.Number.Uint64() != 3 {
+		t.Errorf("expected block 3, got %d", header.Number.Uint64())
+	}
+	if balance, _ := sim.BalanceAt(ctx, other, nil); balance.Uint64() != 1005 {
+		t.Errorf("expected balance 1005, got %d", balance.Uint64())
+	}
+	// The blocks committed before the switch are still there
+	if header, err := sim.HeaderByNumber(ctx, big.NewInt(1)); err != nil || header == nil {
+		t.Errorf("expected block 1, got %v: %v", header, err)
+	}
+
+	if err := sim.Revert(id); err != nil {
+		t.Fatal(err)
+	}
+	if header, _ := sim.HeaderByNumber(ctx, nil); header.Number.Uint64() != 1 {
+		t.Errorf("expected block 1 after revert, got %d", header.Number.Uint64())
+	}
+	if balance, _ := sim.BalanceAt(ctx, other, nil); balance.Uint64() != 0 {
+		t.Errorf("expected balance 0 after revert, got %d", balance.Uint64())
+	}
+	if err := sim.Revert(id); !errors.Is(err, errUnknownSnapshot) {
+		t.Errorf("expected %v, got %v", errUnknownSnapshot, err)
+	}
+}
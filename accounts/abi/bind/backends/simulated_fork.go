@@ -0,0 +1,729 @@
+package backends
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/chain"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/datadir"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcfg"
+	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
+	state2 "github.com/ledgerwatch/erigon-lib/state"
+	"github.com/ledgerwatch/log/v3"
+
+	ethereum "github.com/ledgerwatch/erigon"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/consensus/ethash"
+	"github.com/ledgerwatch/erigon/consensus/serenity"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/state/historyv2read"
+	"github.com/ledgerwatch/erigon/core/state/temporal"
+	"github.com/ledgerwatch/erigon/core/systemcontracts"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync/snap"
+	stages2 "github.com/ledgerwatch/erigon/turbo/stages"
+)
+
+// NewForkedSimulatedBackend creates a simulated backend on top of the block blockNumber of an existing
+// Erigon datadir, for example to test contracts against the mainnet state without network access.
+// The datadir is opened read-only and can be used by a running Erigon: the state of the block is read
+// lazily through the history readers, the simulated blocks and their state changes are kept in memory.
+// The blocks of the datadir after blockNumber are not visible to the backend.
+func NewForkedSimulatedBackend(dataDir string, blockNumber uint64) (*SimulatedBackend, error) {
+	dirs := datadir.New(dataDir)
+	if _, err := os.Stat(dirs.Chaindata); err != nil {
+		return nil, fmt.Errorf("not an Erigon datadir: %w", err)
+	}
+	logger := log.New()
+	db, err := mdbx.NewMDBX(logger).Label(kv.ChainDB).Path(dirs.Chaindata).Readonly().Open()
+	if err != nil {
+		return nil, err
+	}
+	var snapCfg ethconfig.Snapshot
+	var chainName string
+	var historyV3 bool
+	if err := db.View(context.Background(), func(tx kv.Tx) error {
+		genesisHash, err := rawdb.ReadCanonicalHash(tx, 0)
+		if err != nil {
+			return err
+		}
+		config, err := rawdb.ReadChainConfig(tx, genesisHash)
+		if err != nil {
+			return err
+		}
+		if config == nil {
+			return fmt.Errorf("chain config not found in %s", dirs.Chaindata)
+		}
+		chainName = config.ChainName
+		if snapCfg.Enabled, err = snap.Enabled(tx); err != nil {
+			return err
+		}
+		historyV3, err = kvcfg.HistoryV3.Enabled(tx)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	snapshots := snapshotsync.NewRoSnapshots(snapCfg, dirs.Snap)
+	snapshots.OptimisticReopenWithDB(db)
+	closeAll := func() {
+		snapshots.Close()
+		db.Close()
+	}
+	var agg *state2.AggregatorV3
+	if historyV3 {
+		if agg, err = state2.NewAggregatorV3(context.Background(), dirs.SnapHistory, dirs.Tmp, ethconfig.HistoryV3AggregationStep, db); err != nil {
+			closeAll()
+			return nil, fmt.Errorf("create aggregator: %w", err)
+		}
+		if err = agg.OpenFolder(); err != nil {
+			agg.Close()
+			closeAll()
+			return nil, err
+		}
+		closeDB := closeAll
+		closeAll = func() {
+			agg.Close()
+			closeDB()
+		}
+		if db, err = temporal.New(db, agg, accounts.ConvertV3toV2, historyv2read.RestoreCodeHash, accounts.DecodeIncarnationFromStorage, systemcontracts.SystemContractCodeLookup[chainName]); err != nil {
+			closeAll()
+			return nil, err
+		}
+	}
+
+	fork, err := newForkedChain(db, agg, snapshotsync.NewBlockReaderWithSnapshots(snapshots, ethconfig.Defaults.TransactionsV3), blockNumber, closeAll)
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+	return newForkedSimulatedBackend(fork)
+}
+
+// NewTestForkedSimulatedBackend is NewForkedSimulatedBackend closed at the end of the test.
+func NewTestForkedSimulatedBackend(t *testing.T, dataDir string, blockNumber uint64) *SimulatedBackend {
+	b, err := NewForkedSimulatedBackend(dataDir, blockNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(b.Close)
+	return b
+}
+
+// NewDevSimulatedBackend creates a simulated backend from a fresh genesis like NewSimulatedBackendWithConfig,
+// keeping its blocks in memory like a forked backend from the genesis on, where a backend created by
+// NewSimulatedBackendWithConfig only switches to it with the first cheatcode or snapshot.
+func NewDevSimulatedBackend(alloc types.GenesisAlloc, config *chain.Config, gasLimit uint64) (*SimulatedBackend, error) {
+	genesis := types.Genesis{Config: config, GasLimit: gasLimit, Alloc: alloc}
+	m := stages2.MockWithGenesisEngine(nil, &genesis, ethash.NewFaker(), false)
+	blockReader := snapshotsync.NewBlockReaderWithSnapshots(m.BlockSnapshots, m.TransactionsV3)
+	fork, err := newForkedChain(m.DB, m.HistoryV3Components(), blockReader, 0, m.Close)
+	if err != nil {
+		m.Close()
+		return nil, err
+	}
+	return newForkedSimulatedBackend(fork)
+}
+
+// NewTestDevSimulatedBackend is NewDevSimulatedBackend with chainID 1337, closed at the end of the test.
+func NewTestDevSimulatedBackend(t *testing.T, alloc types.GenesisAlloc, gasLimit uint64) *SimulatedBackend {
+	b, err := NewDevSimulatedBackend(alloc, params.TestChainConfig, gasLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(b.Close)
+	return b
+}
+
+func newForkedSimulatedBackend(fork *forkedChain) (*SimulatedBackend, error) {
+	backend := &SimulatedBackend{
+		config:       fork.config,
+		engine:       serenity.New(ethash.NewFaker()),
+		fork:         fork,
+		prependBlock: fork.base,
+		getHeader:    fork.GetHeader,
+	}
+	if err := backend.emptyForkedPendingBlock(); err != nil {
+		fork.close()
+		return nil, err
+	}
+	return backend, nil
+}
+
+// emptyForkedPendingBlock starts a new pending block on top of the latest block of the forked chain.
+func (b *SimulatedBackend) emptyForkedPendingBlock() error {
+	parent := b.prependBlock.Header()
+	header := core.MakeEmptyHeader(parent, b.config, parent.Time+10, nil)
+	header.Coinbase = parent.Coinbase
+	if err := b.engine.Prepare(b.fork, header, nil); err != nil {
+		return err
+	}
+	b.pendingHeader = header
+	b.pendingReceipts = nil
+	b.pendingBlock = b.fork.newBlock(header, nil, nil)
+	b.gasPool = new(core.GasPool).AddGas(header.GasLimit)
+	b.pendingState = state.New(b.fork.head().state)
+	return nil
+}
+
+// addForkedTransaction adds an applied transaction to the pending block of the forked chain.
+func (b *SimulatedBackend) addForkedTransaction(tx types.Transaction, receipt *types.Receipt) {
+	txs := append(append(types.Transactions{}, b.pendingBlock.Transactions()...), tx)
+	b.pendingReceipts = append(b.pendingReceipts, receipt)
+	b.pendingBlock = b.fork.newBlock(b.pendingHeader, txs, b.pendingReceipts)
+}
+
+// commitForked seals the pending block of the forked chain and keeps its state changes in memory.
+func (b *SimulatedBackend) commitForked() error {
+	header := types.CopyHeader(b.pendingHeader)
+	var withdrawals []*types.Withdrawal
+	if b.config.IsShanghai(header.Time) {
+		withdrawals = []*types.Withdrawal{}
+	}
+	block, _, receipts, err := b.engine.FinalizeAndAssemble(b.config, header, b.pendingState, b.pendingBlock.Transactions(), nil, b.pendingReceipts, withdrawals, b.fork, nil, nil)
+	if err != nil {
+		return err
+	}
+	changes := newForkState(b.fork.head().state)
+	if err := b.pendingState.CommitBlock(b.config.Rules(header.Number.Uint64(), header.Time), changes); err != nil {
+		return err
+	}
+	// The receipts were created before the block hash was known
+	var logIndex uint
+	for i, receipt := range receipts {
+		receipt.BlockHash, receipt.BlockNumber, receipt.TransactionIndex = block.Hash(), block.Number(), uint(i)
+		for _, lg := range receipt.Logs {
+			lg.BlockHash, lg.Index = block.Hash(), logIndex
+			logIndex++
+		}
+	}
+	b.fork.append(block, receipts, changes)
+	b.pendingReceipts = receipts
+	b.pendingBlock = block
+	return nil
+}
+
+// forkedChain is the chain of a SimulatedBackend forked from a datadir: the blocks up to the fork block are
+// read from the datadir, the simulated blocks after it are kept in memory.
+// The datadir is read in a short transaction per call, a long-lived one would stop a running Erigon from
+// reusing the freed pages of its database.
+type forkedChain struct {
+	config      *chain.Config
+	db          kv.RwDB
+	agg         *state2.AggregatorV3
+	blockReader *snapshotsync.BlockReaderWithSnapshots
+	historyV3   bool
+	base        *types.Block
+	blocks      []*forkedBlock // the fork block followed by the simulated blocks
+	snapshots   []forkSnapshot // in the order they were taken
+	lastID      uint64
+	closeDB     func()
+}
+
+type forkSnapshot struct {
+	id     uint64
+	blocks []*forkedBlock
+}
+
+type forkedBlock struct {
+	block    *types.Block
+	receipts types.Receipts
+	state    *forkState // the state after the block
+	td       *big.Int
+}
+
+func newForkedChain(db kv.RwDB, agg *state2.AggregatorV3, blockReader *snapshotsync.BlockReaderWithSnapshots, blockNumber uint64, closeDB func()) (*forkedChain, error) {
+	fc := &forkedChain{db: db, agg: agg, blockReader: blockReader, closeDB: closeDB}
+	if err := fc.view(func(tx kv.Tx) error { return fc.init(tx, blockNumber) }); err != nil {
+		return nil, err
+	}
+	return fc, nil
+}
+
+func (fc *forkedChain) init(tx kv.Tx, blockNumber uint64) error {
+	ctx := context.Background()
+	genesisHash, err := rawdb.ReadCanonicalHash(tx, 0)
+	if err != nil {
+		return err
+	}
+	if fc.config, err = rawdb.ReadChainConfig(tx, genesisHash); err != nil {
+		return err
+	}
+	if fc.config == nil {
+		return fmt.Errorf("chain config not found")
+	}
+	if fc.config.Clique != nil || fc.config.Aura != nil || fc.config.Bor != nil {
+		return fmt.Errorf("forking %s is not supported, only the ethash and proof-of-stake chains are", fc.config.ChainName)
+	}
+	if fc.historyV3, err = kvcfg.HistoryV3.Enabled(tx); err != nil {
+		return err
+	}
+	executed, err := stages.GetStageProgress(tx, stages.Execution)
+	if err != nil {
+		return err
+	}
+	if blockNumber > executed {
+		return fmt.Errorf("block %d is not executed yet, execution is at block %d", blockNumber, executed)
+	}
+	hash, err := fc.blockReader.CanonicalHash(ctx, tx, blockNumber)
+	if err != nil {
+		return err
+	}
+	if fc.base, _, err = fc.blockReader.BlockWithSenders(ctx, tx, hash, blockNumber); err != nil {
+		return err
+	}
+	if fc.base == nil {
+		return fmt.Errorf("block %d not found", blockNumber)
+	}
+	td, err := rawdb.ReadTd(tx, hash, blockNumber)
+	if err != nil {
+		return err
+	}
+	// The history reader keeps the state of the fork block while the node executes the later blocks
+	fc.blocks = []*forkedBlock{{block: fc.base, state: &forkState{base: &historyStateReader{fc: fc, blockNumber: blockNumber}}, td: td}}
+	return nil
+}
+
+func (fc *forkedChain) view(f func(tx kv.Tx) error) error {
+	return fc.db.View(context.Background(), f)
+}
+
+func (fc *forkedChain) close() {
+	if fc.closeDB != nil {
+		fc.closeDB()
+	}
+}
+
+func (fc *forkedChain) head() *forkedBlock {
+	return fc.blocks[len(fc.blocks)-1]
+}
+
+// local returns the fork block or a simulated block, nil for the blocks of the datadir before the fork.
+func (fc *forkedChain) local(number uint64) *forkedBlock {
+	if number < fc.base.NumberU64() || number-fc.base.NumberU64() >= uint64(len(fc.blocks)) {
+		return nil
+	}
+	return fc.blocks[number-fc.base.NumberU64()]
+}
+
+func (fc *forkedChain) localByHash(hash libcommon.Hash) *forkedBlock {
+	for _, b := range fc.blocks {
+		if b.block.Hash() == hash {
+			return b
+		}
+	}
+	return nil
+}
+
+func (fc *forkedChain) append(block *types.Block, receipts types.Receipts, changes *forkState) {
+	td := new(big.Int).Add(fc.head().td, block.Difficulty())
+	fc.blocks = append(fc.blocks, &forkedBlock{block: block, receipts: receipts, state: changes, td: td})
+}
+
+// setHeadState replaces the state after the latest block, the forkedBlock are shared with the snapshots.
+func (fc *forkedChain) setHeadState(s *forkState) {
+	head := *fc.head()
+	head.state = s
+	fc.blocks[len(fc.blocks)-1] = &head
+}
+
+func (fc *forkedChain) snapshot() uint64 {
+	fc.lastID++
+	fc.snapshots = append(fc.snapshots, forkSnapshot{id: fc.lastID, blocks: append([]*forkedBlock(nil), fc.blocks...)})
+	return fc.lastID
+}
+
+// revert restores the chain of the snapshot, which is dropped with the later snapshots.
+func (fc *forkedChain) revert(id uint64) bool {
+	for i, snapshot := range fc.snapshots {
+		if snapshot.id == id {
+			fc.blocks = snapshot.blocks
+			fc.snapshots = fc.snapshots[:i]
+			return true
+		}
+	}
+	return false
+}
+
+func (fc *forkedChain) newBlock(header *types.Header, txs types.Transactions, receipts types.Receipts) *types.Block {
+	var withdrawals []*types.Withdrawal
+	if fc.config.IsShanghai(header.Time) {
+		withdrawals = []*types.Withdrawal{}
+	}
+	return types.NewBlock(header, txs, nil, receipts, withdrawals)
+}
+
+// stateReader returns the state after the block, the latest one for the blocks after it.
+func (fc *forkedChain) stateReader(number uint64) state.StateReader {
+	if number >= fc.base.NumberU64() {
+		if b := fc.local(number); b != nil {
+			return b.state
+		}
+		return fc.head().state
+	}
+	return &historyStateReader{fc: fc, blockNumber: number}
+}
+
+// canonical returns the number of a block of the datadir up to the fork block.
+func (fc *forkedChain) canonical(tx kv.Tx, hash libcommon.Hash) (uint64, bool, error) {
+	number := rawdb.ReadHeaderNumber(tx, hash)
+	if number == nil || *number > fc.base.NumberU64() {
+		return 0, false, nil
+	}
+	canonicalHash, err := fc.blockReader.CanonicalHash(context.Background(), tx, *number)
+	if err != nil {
+		return 0, false, err
+	}
+	return *number, canonicalHash == hash, nil
+}
+
+func (fc *forkedChain) blockByHash(hash libcommon.Hash) (block *types.Block, err error) {
+	if b := fc.localByHash(hash); b != nil {
+		return b.block, nil
+	}
+	err = fc.view(func(tx kv.Tx) error {
+		number, ok, err := fc.canonical(tx, hash)
+		if err != nil || !ok {
+			return err
+		}
+		block, _, err = fc.blockReader.BlockWithSenders(context.Background(), tx, hash, number)
+		return err
+	})
+	return block, err
+}
+
+func (fc *forkedChain) blockByNumber(number uint64) (block *types.Block, err error) {
+	if b := fc.local(number); b != nil {
+		return b.block, nil
+	}
+	if number > fc.base.NumberU64() {
+		return nil, nil
+	}
+	err = fc.view(func(tx kv.Tx) error {
+		hash, err := fc.blockReader.CanonicalHash(context.Background(), tx, number)
+		if err != nil {
+			return err
+		}
+		block, _, err = fc.blockReader.BlockWithSenders(context.Background(), tx, hash, number)
+		return err
+	})
+	return block, err
+}
+
+func (fc *forkedChain) headerByNumber(number uint64) (header *types.Header, err error) {
+	if b := fc.local(number); b != nil {
+		return b.block.Header(), nil
+	}
+	if number > fc.base.NumberU64() {
+		return nil, nil
+	}
+	err = fc.view(func(tx kv.Tx) error {
+		header, err = fc.blockReader.HeaderByNumber(context.Background(), tx, number)
+		return err
+	})
+	return header, err
+}
+
+func (fc *forkedChain) headerByHash(hash libcommon.Hash) (header *types.Header, err error) {
+	if b := fc.localByHash(hash); b != nil {
+		return b.block.Header(), nil
+	}
+	err = fc.view(func(tx kv.Tx) error {
+		number, ok, err := fc.canonical(tx, hash)
+		if err != nil || !ok {
+			return err
+		}
+		header, err = fc.blockReader.Header(context.Background(), tx, hash, number)
+		return err
+	})
+	return header, err
+}
+
+func (fc *forkedChain) transaction(hash libcommon.Hash) (types.Transaction, error) {
+	for _, b := range fc.blocks[1:] {
+		if txn := b.block.Transaction(hash); txn != nil {
+			return txn, nil
+		}
+	}
+	var number uint64
+	var ok bool
+	if err := fc.view(func(tx kv.Tx) (err error) {
+		number, ok, err = fc.blockReader.TxnLookup(context.Background(), tx, hash)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if !ok || number > fc.base.NumberU64() {
+		return nil, ethereum.NotFound
+	}
+	block, err := fc.blockByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	if block != nil {
+		if txn := block.Transaction(hash); txn != nil {
+			return txn, nil
+		}
+	}
+	return nil, ethereum.NotFound
+}
+
+func (fc *forkedChain) receipt(hash libcommon.Hash) (receipt *types.Receipt, err error) {
+	for _, b := range fc.blocks[1:] {
+		for _, r := range b.receipts {
+			if r.TxHash == hash {
+				return r, nil
+			}
+		}
+	}
+	var number uint64
+	if err = fc.view(func(tx kv.Tx) (err error) {
+		receipt, _, number, _, err = rawdb.ReadReceipt(tx, hash)
+		return err
+	}); err != nil || receipt == nil || number > fc.base.NumberU64() {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// forkedChain is the consensus.ChainHeaderReader of the engine sealing the simulated blocks.
+
+func (fc *forkedChain) Config() *chain.Config        { return fc.config }
+func (fc *forkedChain) CurrentHeader() *types.Header { return fc.head().block.Header() }
+
+func (fc *forkedChain) GetHeader(hash libcommon.Hash, number uint64) *types.Header {
+	if b := fc.local(number); b != nil {
+		if b.block.Hash() != hash {
+			return nil
+		}
+		return b.block.Header()
+	}
+	if number > fc.base.NumberU64() {
+		return nil
+	}
+	var h *types.Header
+	if err := fc.view(func(tx kv.Tx) (err error) {
+		h, err = fc.blockReader.Header(context.Background(), tx, hash, number)
+		return err
+	}); err != nil {
+		log.Error("GetHeader failed", "err", err)
+		return nil
+	}
+	return h
+}
+
+func (fc *forkedChain) GetHeaderByNumber(number uint64) *types.Header {
+	h, err := fc.headerByNumber(number)
+	if err != nil {
+		log.Error("GetHeaderByNumber failed", "err", err)
+		return nil
+	}
+	return h
+}
+
+func (fc *forkedChain) GetHeaderByHash(hash libcommon.Hash) *types.Header {
+	h, err := fc.headerByHash(hash)
+	if err != nil {
+		log.Error("GetHeaderByHash failed", "err", err)
+		return nil
+	}
+	return h
+}
+
+func (fc *forkedChain) GetTd(hash libcommon.Hash, number uint64) *big.Int {
+	if b := fc.local(number); b != nil && b.block.Hash() == hash {
+		return b.td
+	}
+	var td *big.Int
+	if err := fc.view(func(tx kv.Tx) (err error) {
+		td, err = rawdb.ReadTd(tx, hash, number)
+		return err
+	}); err != nil {
+		log.Error("ReadTd failed", "err", err)
+		return nil
+	}
+	return td
+}
+
+// historyStateReader is the state after a block of the datadir, read through the history readers in a
+// short transaction per read.
+type historyStateReader struct {
+	fc          *forkedChain
+	blockNumber uint64
+}
+
+func (r *historyStateReader) read(f func(reader state.StateReader) error) error {
+	return r.fc.view(func(tx kv.Tx) error {
+		reader, err := rpchelper.CreateHistoryStateReader(tx, r.blockNumber+1, 0, r.fc.historyV3, r.fc.config.ChainName)
+		if err != nil {
+			return err
+		}
+		return f(reader)
+	})
+}
+
+func (r *historyStateReader) ReadAccountData(address libcommon.Address) (acc *accounts.Account, err error) {
+	err = r.read(func(reader state.StateReader) (err error) {
+		acc, err = reader.ReadAccountData(address)
+		return err
+	})
+	return acc, err
+}
+
+// The values read from the database are only valid until the end of the transaction
+
+func (r *historyStateReader) ReadAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash) (v []byte, err error) {
+	err = r.read(func(reader state.StateReader) (err error) {
+		v, err = reader.ReadAccountStorage(address, incarnation, key)
+		v = common.CopyBytes(v)
+		return err
+	})
+	return v, err
+}
+
+func (r *historyStateReader) ReadAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) (code []byte, err error) {
+	err = r.read(func(reader state.StateReader) (err error) {
+		code, err = reader.ReadAccountCode(address, incarnation, codeHash)
+		code = common.CopyBytes(code)
+		return err
+	})
+	return code, err
+}
+
+func (r *historyStateReader) ReadAccountCodeSize(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) (size int, err error) {
+	err = r.read(func(reader state.StateReader) (err error) {
+		size, err = reader.ReadAccountCodeSize(address, incarnation, codeHash)
+		return err
+	})
+	return size, err
+}
+
+func (r *historyStateReader) ReadAccountIncarnation(address libcommon.Address) (incarnation uint64, err error) {
+	err = r.read(func(reader state.StateReader) (err error) {
+		incarnation, err = reader.ReadAccountIncarnation(address)
+		return err
+	})
+	return incarnation, err
+}
+
+// forkState is the state after a block of a forked chain: the changes made by the block kept in memory
+// on top of the state after its parent, down to the state of the fork block read from the datadir.
+// It is a state.StateReader, and a state.StateWriter for the block which creates it.
+type forkState struct {
+	parent *forkState
+	base   state.StateReader // the state of the fork block, set in the first layer only
+
+	accounts     map[libcommon.Address]*accounts.Account // nil for the deleted accounts
+	storage      map[forkStorageKey]uint256.Int
+	wiped        map[libcommon.Address]struct{} // the storage of the earlier incarnations is gone
+	code         map[libcommon.Hash][]byte
+	incarnations map[libcommon.Address]uint64 // of the deleted contracts
+}
+
+type forkStorageKey struct {
+	address     libcommon.Address
+	incarnation uint64
+	key         libcommon.Hash
+}
+
+func newForkState(parent *forkState) *forkState {
+	return &forkState{
+		parent:       parent,
+		accounts:     map[libcommon.Address]*accounts.Account{},
+		storage:      map[forkStorageKey]uint256.Int{},
+		wiped:        map[libcommon.Address]struct{}{},
+		code:         map[libcommon.Hash][]byte{},
+		incarnations: map[libcommon.Address]uint64{},
+	}
+}
+
+func (s *forkState) ReadAccountData(address libcommon.Address) (*accounts.Account, error) {
+	for ; s.base == nil; s = s.parent {
+		if acc, ok := s.accounts[address]; ok {
+			if acc == nil {
+				return nil, nil
+			}
+			return acc.SelfCopy(), nil
+		}
+	}
+	return s.base.ReadAccountData(address)
+}
+
+func (s *forkState) ReadAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash) ([]byte, error) {
+	for ; s.base == nil; s = s.parent {
+		if v, ok := s.storage[forkStorageKey{address, incarnation, *key}]; ok {
+			return v.Bytes(), nil
+		}
+		if _, ok := s.wiped[address]; ok {
+			return nil, nil
+		}
+	}
+	return s.base.ReadAccountStorage(address, incarnation, key)
+}
+
+func (s *forkState) ReadAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) ([]byte, error) {
+	for ; s.base == nil; s = s.parent {
+		if code, ok := s.code[codeHash]; ok {
+			return code, nil
+		}
+	}
+	return s.base.ReadAccountCode(address, incarnation, codeHash)
+}
+
+func (s *forkState) ReadAccountCodeSize(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) (int, error) {
+	code, err := s.ReadAccountCode(address, incarnation, codeHash)
+	return len(code), err
+}
+
+func (s *forkState) ReadAccountIncarnation(address libcommon.Address) (uint64, error) {
+	for ; s.base == nil; s = s.parent {
+		if incarnation, ok := s.incarnations[address]; ok {
+			return incarnation, nil
+		}
+	}
+	return s.base.ReadAccountIncarnation(address)
+}
+
+func (s *forkState) UpdateAccountData(address libcommon.Address, original, account *accounts.Account) error {
+	s.accounts[address] = account.SelfCopy()
+	return nil
+}
+
+func (s *forkState) UpdateAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash, code []byte) error {
+	s.code[codeHash] = common.CopyBytes(code)
+	return nil
+}
+
+func (s *forkState) DeleteAccount(address libcommon.Address, original *accounts.Account) error {
+	s.accounts[address] = nil
+	s.wiped[address] = struct{}{}
+	if original.Incarnation > 0 {
+		s.incarnations[address] = original.Incarnation
+	}
+	return nil
+}
+
+func (s *forkState) WriteAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash, original, value *uint256.Int) error {
+	s.storage[forkStorageKey{address, incarnation, *key}] = *value
+	return nil
+}
+
+func (s *forkState) CreateContract(address libcommon.Address) error {
+	s.wiped[address] = struct{}{}
+	return nil
+}
@@ -0,0 +1,218 @@
+package backends
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/accounts/abi"
+	"github.com/ledgerwatch/erigon/accounts/abi/bind"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/params"
+)
+
+// counterCode deploys a contract incrementing its storage slot 0 on every call:
+// PUSH1 0 SLOAD PUSH1 1 ADD PUSH1 0 SSTORE STOP
+const counterCode = "600a600c600039600a6000f3" + counterRuntimeCode
+const counterRuntimeCode = "60005460010160005500"
+
+// forkTestChain commits a chain of 3 blocks to the mock backend: the deployment of the counter,
+// a call to the counter and a transfer to other.
+func forkTestChain(t *testing.T) (sim *SimulatedBackend, counter, other libcommon.Address) {
+	testAddr := crypto.PubkeyToAddress(testKey.PublicKey)
+	sim = simTestBackend(t, testAddr)
+	auth, _ := bind.NewKeyedTransactorWithChainID(testKey, big.NewInt(1337))
+	counter, _, contract, err := bind.DeployContract(auth, abi.ABI{}, common.FromHex(counterCode), sim)
+	if err != nil {
+		t.Fatalf("could not deploy contract: %v", err)
+	}
+	sim.Commit()
+	if _, err := contract.RawTransact(auth, nil); err != nil {
+		t.Fatalf("could not call contract: %v", err)
+	}
+	sim.Commit()
+	other = libcommon.HexToAddress("0x1234")
+	gasPrice, _ := sim.SuggestGasPrice(context.Background())
+	var tx types.Transaction = types.NewTransaction(2, other, uint256.NewInt(1000), params.TxGas, uint256.MustFromBig(gasPrice), nil)
+	signedTx, err := types.SignTx(tx, *types.MakeSigner(params.TestChainConfig, 3), testKey)
+	if err != nil {
+		t.Fatalf("could not sign tx: %v", err)
+	}
+	if err := sim.SendTransaction(context.Background(), signedTx); err != nil {
+		t.Fatalf("could not transfer: %v", err)
+	}
+	sim.Commit()
+	return sim, counter, other
+}
+
+func forkAt(t *testing.T, sim *SimulatedBackend, blockNumber uint64) *SimulatedBackend {
+	fork, err := newForkedChain(sim.DB(), sim.Agg(), sim.BlockReader(), blockNumber, nil)
+	if err != nil {
+		t.Fatalf("could not fork at block %d: %v", blockNumber, err)
+	}
+	b, err := newForkedSimulatedBackend(fork)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(b.Close)
+	return b
+}
+
+func counterValue(t *testing.T, b *SimulatedBackend, counter libcommon.Address, blockNumber *big.Int) uint64 {
+	value, err := b.StorageAt(context.Background(), counter, libcommon.Hash{}, blockNumber)
+	if err != nil {
+		t.Fatalf("could not read storage: %v", err)
+	}
+	return new(big.Int).SetBytes(value).Uint64()
+}
+
+func TestForkedSimulatedBackend(t *testing.T) {
+	sim, counter, other := forkTestChain(t)
+	ctx := context.Background()
+	fork := forkAt(t, sim, 3)
+
+	if n := fork.pendingBlock.NumberU64(); n != 4 {
+		t.Fatalf("expected pending block 4, got %d", n)
+	}
+	code, err := fork.CodeAt(ctx, counter, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if common.Bytes2Hex(code) != counterRuntimeCode {
+		t.Errorf("unexpected code %x", code)
+	}
+	if v := counterValue(t, fork, counter, nil); v != 1 {
+		t.Errorf("expected counter 1, got %d", v)
+	}
+	balance, err := fork.BalanceAt(ctx, other, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Uint64() != 1000 {
+		t.Errorf("expected balance 1000, got %d", balance.Uint64())
+	}
+
+	auth, _ := bind.NewKeyedTransactorWithChainID(testKey, big.NewInt(1337))
+	tx, err := bind.NewBoundContract(counter, abi.ABI{}, fork, fork, fork).RawTransact(auth, nil)
+	if err != nil {
+		t.Fatalf("could not call contract: %v", err)
+	}
+	if _, pending, err := fork.TransactionByHash(ctx, tx.Hash()); err != nil || !pending {
+		t.Fatalf("expected pending transaction, got %v", err)
+	}
+	fork.Commit()
+
+	if v := counterValue(t, fork, counter, nil); v != 2 {
+		t.Errorf("expected counter 2 after the simulated block, got %d", v)
+	}
+	if v := counterValue(t, fork, counter, big.NewInt(3)); v != 1 {
+		t.Errorf("expected counter 1 at the fork block, got %d", v)
+	}
+	if v := counterValue(t, fork, counter, big.NewInt(1)); v != 0 {
+		t.Errorf("expected counter 0 before the call, got %d", v)
+	}
+	if v := counterValue(t, sim, counter, nil); v != 1 {
+		t.Errorf("expected the forked datadir to be unchanged, got counter %d", v)
+	}
+
+	receipt, err := fork.TransactionReceipt(ctx, tx.Hash())
+	if err != nil || receipt == nil {
+		t.Fatalf("could not get receipt: %v", err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful || receipt.BlockNumber.Uint64() != 4 {
+		t.Errorf("unexpected receipt %+v", receipt)
+	}
+	block, err := fork.BlockByNumber(ctx, big.NewInt(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.Hash() != receipt.BlockHash || block.ParentHash() != fork.fork.base.Hash() {
+		t.Errorf("unexpected simulated block %x", block.Hash())
+	}
+	if _, pending, err := fork.TransactionByHash(ctx, tx.Hash()); err != nil || pending {
+		t.Errorf("expected committed transaction, got %v", err)
+	}
+
+	// The blocks of the datadir are readable up to the fork block
+	header, err := fork.HeaderByNumber(ctx, big.NewInt(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := sim.HeaderByNumber(ctx, big.NewInt(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Hash() != expected.Hash() {
+		t.Errorf("expected header %x, got %x", expected.Hash(), header.Hash())
+	}
+	if header, err = fork.HeaderByHash(ctx, expected.Hash()); err != nil || header.Hash() != expected.Hash() {
+		t.Errorf("could not get header by hash: %v", err)
+	}
+	if count, err := fork.TransactionCount(ctx, expected.Hash()); err != nil || count != 1 {
+		t.Errorf("expected 1 transaction, got %d: %v", count, err)
+	}
+}
+
+func TestForkedSimulatedBackend_History(t *testing.T) {
+	sim, counter, other := forkTestChain(t)
+	ctx := context.Background()
+	fork := forkAt(t, sim, 1)
+
+	if v := counterValue(t, fork, counter, nil); v != 0 {
+		t.Errorf("expected counter 0 at block 1, got %d", v)
+	}
+	balance, err := fork.BalanceAt(ctx, other, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !balance.IsZero() {
+		t.Errorf("expected no balance at block 1, got %d", balance.Uint64())
+	}
+	if _, err := fork.BlockByNumber(ctx, big.NewInt(2)); err != errBlockDoesNotExist {
+		t.Errorf("expected the blocks after the fork to be invisible, got %v", err)
+	}
+
+	auth, _ := bind.NewKeyedTransactorWithChainID(testKey, big.NewInt(1337))
+	contract := bind.NewBoundContract(counter, abi.ABI{}, fork, fork, fork)
+	if _, err := contract.RawTransact(auth, nil); err != nil {
+		t.Fatalf("could not call contract: %v", err)
+	}
+	fork.Rollback()
+	if err := fork.AdjustTime(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := contract.RawTransact(auth, nil); err != nil {
+		t.Fatalf("could not call contract: %v", err)
+	}
+	fork.Commit()
+
+	if v := counterValue(t, fork, counter, nil); v != 1 {
+		t.Errorf("expected counter 1, got %d", v)
+	}
+	block, err := fork.BlockByNumber(ctx, big.NewInt(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, err := sim.BlockByNumber(ctx, big.NewInt(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.Hash() == original.Hash() || block.Transactions().Len() != 1 {
+		t.Errorf("expected the simulated block 2, got %x", block.Hash())
+	}
+	if block.Time() < original.Time()+3600 {
+		t.Errorf("expected the adjusted time, got %d", block.Time())
+	}
+}
+
+func TestNewForkedSimulatedBackend_NotADatadir(t *testing.T) {
+	if _, err := NewForkedSimulatedBackend(t.TempDir(), 0); err == nil {
+		t.Fatal("expected an error for an empty directory")
+	}
+}
@@ -0,0 +1,447 @@
+package backends
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+
+	ethereum "github.com/ledgerwatch/erigon"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/node"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/rpc/rpccfg"
+	"github.com/ledgerwatch/erigon/turbo/adapter/ethapi"
+)
+
+// NewRPCServer creates a JSON-RPC server for the backend, to be used like a dev node. It serves the eth and net
+// methods needed by wallets and frontends, evm_snapshot, evm_revert, evm_mine, evm_increaseTime and
+// evm_setAutomine, and the cheatcodes in both the hardhat and the anvil namespaces (hardhat_setBalance,
+// anvil_impersonateAccount, ...). The transactions sent through it are mined right away until evm_setAutomine
+// turns it off.
+func (b *SimulatedBackend) NewRPCServer() (*rpc.Server, error) {
+	automine := new(atomic.Bool)
+	automine.Store(true)
+	srv := rpc.NewServer(50, false /* traceRequests */, true)
+	cheats := &simulatedCheatAPI{b: b}
+	for name, service := range map[string]interface{}{
+		"eth":     &simulatedEthAPI{b: b, automine: automine},
+		"net":     &simulatedNetAPI{b: b},
+		"evm":     &simulatedEvmAPI{b: b, automine: automine},
+		"hardhat": cheats,
+		"anvil":   cheats,
+	} {
+		if err := srv.RegisterName(name, service); err != nil {
+			return nil, err
+		}
+	}
+	return srv, nil
+}
+
+// StartRPC serves the JSON-RPC server of the backend over HTTP, on an address like "127.0.0.1:8545".
+func (b *SimulatedBackend) StartRPC(listenAddr string) (*http.Server, net.Addr, error) {
+	srv, err := b.NewRPCServer()
+	if err != nil {
+		return nil, nil, err
+	}
+	return node.StartHTTPEndpoint(listenAddr, rpccfg.DefaultHTTPTimeouts, srv)
+}
+
+type simulatedEthAPI struct {
+	b        *SimulatedBackend
+	automine *atomic.Bool
+}
+
+// blockNumber converts the block argument of the state methods, nil for the latest block.
+func (api *simulatedEthAPI) blockNumber(ctx context.Context, blockNrOrHash *rpc.BlockNumberOrHash) (number *big.Int, pending bool, err error) {
+	if blockNrOrHash == nil {
+		return nil, false, nil
+	}
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		header, err := api.b.HeaderByHash(ctx, hash)
+		if err != nil {
+			return nil, false, err
+		}
+		return header.Number, false, nil
+	}
+	blockNr, _ := blockNrOrHash.Number()
+	switch blockNr {
+	case rpc.PendingBlockNumber:
+		return nil, true, nil
+	case rpc.LatestBlockNumber, rpc.SafeBlockNumber, rpc.FinalizedBlockNumber, rpc.LatestExecutedBlockNumber:
+		return nil, false, nil
+	}
+	return big.NewInt(blockNr.Int64()), false, nil
+}
+
+func (api *simulatedEthAPI) ChainId(ctx context.Context) (hexutil.Uint64, error) {
+	return hexutil.Uint64(api.b.config.ChainID.Uint64()), nil
+}
+
+func (api *simulatedEthAPI) BlockNumber(ctx context.Context) (hexutil.Uint64, error) {
+	header, err := api.b.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	return hexutil.Uint64(header.Number.Uint64()), nil
+}
+
+func (api *simulatedEthAPI) GasPrice(ctx context.Context) (*hexutil.Big, error) {
+	price, err := api.b.SuggestGasPrice(ctx)
+	return (*hexutil.Big)(price), err
+}
+
+func (api *simulatedEthAPI) GetBalance(ctx context.Context, address libcommon.Address, blockNrOrHash *rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	number, _, err := api.blockNumber(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := api.b.BalanceAt(ctx, address, number)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(balance.ToBig()), nil
+}
+
+func (api *simulatedEthAPI) GetTransactionCount(ctx context.Context, address libcommon.Address, blockNrOrHash *rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
+	number, pending, err := api.blockNumber(ctx, blockNrOrHash)
+	if err != nil {
+		return 0, err
+	}
+	var nonce uint64
+	if pending {
+		nonce, err = api.b.PendingNonceAt(ctx, address)
+	} else {
+		nonce, err = api.b.NonceAt(ctx, address, number)
+	}
+	return hexutil.Uint64(nonce), err
+}
+
+func (api *simulatedEthAPI) GetCode(ctx context.Context, address libcommon.Address, blockNrOrHash *rpc.BlockNumberOrHash) (hexutility.Bytes, error) {
+	number, pending, err := api.blockNumber(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return api.b.PendingCodeAt(ctx, address)
+	}
+	return api.b.CodeAt(ctx, address, number)
+}
+
+func (api *simulatedEthAPI) GetStorageAt(ctx context.Context, address libcommon.Address, index string, blockNrOrHash *rpc.BlockNumberOrHash) (string, error) {
+	number, _, err := api.blockNumber(ctx, blockNrOrHash)
+	if err != nil {
+		return "", err
+	}
+	value, err := api.b.StorageAt(ctx, address, libcommon.HexToHash(index), number)
+	if err != nil {
+		return "", err
+	}
+	return hexutility.Encode(common.LeftPadBytes(value, 32)), nil
+}
+
+func (api *simulatedEthAPI) Call(ctx context.Context, args ethapi.CallArgs, blockNrOrHash *rpc.BlockNumberOrHash) (hexutility.Bytes, error) {
+	number, pending, err := api.blockNumber(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return api.b.PendingCallContract(ctx, callMsgFromArgs(args))
+	}
+	return api.b.CallContract(ctx, callMsgFromArgs(args), number)
+}
+
+func (api *simulatedEthAPI) EstimateGas(ctx context.Context, args ethapi.CallArgs) (hexutil.Uint64, error) {
+	gas, err := api.b.EstimateGas(ctx, callMsgFromArgs(args))
+	return hexutil.Uint64(gas), err
+}
+
+func (api *simulatedEthAPI) SendRawTransaction(ctx context.Context, encodedTx hexutility.Bytes) (libcommon.Hash, error) {
+	txn, err := types.DecodeTransaction(rlp.NewStream(bytes.NewReader(encodedTx), uint64(len(encodedTx))))
+	if err != nil {
+		return libcommon.Hash{}, err
+	}
+	return api.send(ctx, txn)
+}
+
+// SendTransaction sends a transaction of an impersonated account, the simulated backend has no keys to sign
+// the transactions of the other accounts.
+func (api *simulatedEthAPI) SendTransaction(ctx context.Context, args ethapi.CallArgs) (libcommon.Hash, error) {
+	if args.From == nil {
+		return libcommon.Hash{}, errors.New("from is required")
+	}
+	if !api.b.isImpersonated(*args.From) {
+		return libcommon.Hash{}, fmt.Errorf("unknown account %x, only impersonated accounts can send unsigned transactions", *args.From)
+	}
+	msg := callMsgFromArgs(args)
+	var nonce uint64
+	var err error
+	if args.Nonce != nil {
+		nonce = uint64(*args.Nonce)
+	} else if nonce, err = api.b.PendingNonceAt(ctx, msg.From); err != nil {
+		return libcommon.Hash{}, err
+	}
+	if msg.Gas == 0 {
+		if msg.Gas, err = api.b.EstimateGas(ctx, msg); err != nil {
+			return libcommon.Hash{}, err
+		}
+	}
+	if msg.Value == nil {
+		msg.Value = new(uint256.Int)
+	}
+	var txn types.Transaction
+	if msg.FeeCap != nil {
+		tip := msg.Tip
+		if tip == nil {
+			tip = new(uint256.Int)
+		}
+		txn = &types.DynamicFeeTransaction{
+			CommonTx: types.CommonTx{
+				ChainID: uint256.MustFromBig(api.b.config.ChainID),
+				Nonce:   nonce,
+				Gas:     msg.Gas,
+				To:      msg.To,
+				Value:   msg.Value,
+				Data:    msg.Data,
+			},
+			Tip:        tip,
+			FeeCap:     msg.FeeCap,
+			AccessList: msg.AccessList,
+		}
+	} else {
+		if msg.GasPrice == nil {
+			price, err := api.b.SuggestGasPrice(ctx)
+			if err != nil {
+				return libcommon.Hash{}, err
+			}
+			msg.GasPrice = uint256.MustFromBig(price)
+		}
+		if msg.To == nil {
+			txn = types.NewContractCreation(nonce, msg.Value, msg.Gas, msg.GasPrice, msg.Data)
+		} else {
+			txn = types.NewTransaction(nonce, *msg.To, msg.Value, msg.Gas, msg.GasPrice, msg.Data)
+		}
+	}
+	txn.SetSender(msg.From)
+	return api.send(ctx, txn)
+}
+
+func (api *simulatedEthAPI) send(ctx context.Context, txn types.Transaction) (libcommon.Hash, error) {
+	if err := api.b.SendTransaction(ctx, txn); err != nil {
+		return libcommon.Hash{}, err
+	}
+	if api.automine.Load() {
+		api.b.Commit()
+	}
+	return txn.Hash(), nil
+}
+
+func (api *simulatedEthAPI) GetTransactionByHash(ctx context.Context, hash libcommon.Hash) (*ethapi.RPCTransaction, error) {
+	txn, pending, err := api.b.TransactionByHash(ctx, hash)
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return ethapi.NewRPCTransaction(txn, libcommon.Hash{}, 0, 0, nil), nil
+	}
+	receipt, err := api.b.TransactionReceipt(ctx, hash)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	header, err := api.b.HeaderByHash(ctx, receipt.BlockHash)
+	if err != nil {
+		return nil, err
+	}
+	return ethapi.NewRPCTransaction(txn, receipt.BlockHash, receipt.BlockNumber.Uint64(), uint64(receipt.TransactionIndex), header.BaseFee), nil
+}
+
+func (api *simulatedEthAPI) GetTransactionReceipt(ctx context.Context, hash libcommon.Hash) (map[string]interface{}, error) {
+	receipt, err := api.b.TransactionReceipt(ctx, hash)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	txn, _, err := api.b.TransactionByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	header, err := api.b.HeaderByHash(ctx, receipt.BlockHash)
+	if err != nil {
+		return nil, err
+	}
+	from, _ := txn.Sender(*types.MakeSigner(api.b.config, header.Number.Uint64()))
+	gasPrice := txn.GetPrice().ToBig()
+	if header.BaseFee != nil {
+		baseFee, _ := uint256.FromBig(header.BaseFee)
+		gasPrice = new(big.Int).Add(header.BaseFee, txn.GetEffectiveGasTip(baseFee).ToBig())
+	}
+	fields := map[string]interface{}{
+		"blockHash":         receipt.BlockHash,
+		"blockNumber":       hexutil.Uint64(receipt.BlockNumber.Uint64()),
+		"transactionHash":   hash,
+		"transactionIndex":  hexutil.Uint64(receipt.TransactionIndex),
+		"from":              from,
+		"to":                txn.GetTo(),
+		"type":              hexutil.Uint(txn.Type()),
+		"gasUsed":           hexutil.Uint64(receipt.GasUsed),
+		"cumulativeGasUsed": hexutil.Uint64(receipt.CumulativeGasUsed),
+		"effectiveGasPrice": (*hexutil.Big)(gasPrice),
+		"contractAddress":   nil,
+		"logs":              receipt.Logs,
+		"logsBloom":         types.CreateBloom(types.Receipts{receipt}),
+		"status":            hexutil.Uint64(receipt.Status),
+	}
+	if receipt.Logs == nil {
+		fields["logs"] = []*types.Log{}
+	}
+	if receipt.ContractAddress != (libcommon.Address{}) {
+		fields["contractAddress"] = receipt.ContractAddress
+	}
+	return fields, nil
+}
+
+func (api *simulatedEthAPI) GetBlockByNumber(ctx context.Context, number rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
+	var blockNumber *big.Int
+	if number >= 0 {
+		blockNumber = big.NewInt(number.Int64())
+	}
+	block, err := api.b.BlockByNumber(ctx, blockNumber)
+	if errors.Is(err, errBlockDoesNotExist) {
+		return nil, nil
+	}
+	if err != nil || block == nil {
+		return nil, err
+	}
+	return ethapi.RPCMarshalBlockDeprecated(block, true, fullTx)
+}
+
+func (api *simulatedEthAPI) GetBlockByHash(ctx context.Context, hash libcommon.Hash, fullTx bool) (map[string]interface{}, error) {
+	block, err := api.b.BlockByHash(ctx, hash)
+	if errors.Is(err, errBlockDoesNotExist) {
+		return nil, nil
+	}
+	if err != nil || block == nil {
+		return nil, err
+	}
+	return ethapi.RPCMarshalBlockDeprecated(block, true, fullTx)
+}
+
+func callMsgFromArgs(args ethapi.CallArgs) ethereum.CallMsg {
+	msg := ethereum.CallMsg{To: args.To}
+	if args.From != nil {
+		msg.From = *args.From
+	}
+	if args.Gas != nil {
+		msg.Gas = uint64(*args.Gas)
+	}
+	if args.GasPrice != nil {
+		msg.GasPrice = uint256.MustFromBig(args.GasPrice.ToInt())
+	}
+	if args.MaxFeePerGas != nil {
+		msg.FeeCap = uint256.MustFromBig(args.MaxFeePerGas.ToInt())
+	}
+	if args.MaxPriorityFeePerGas != nil {
+		msg.Tip = uint256.MustFromBig(args.MaxPriorityFeePerGas.ToInt())
+	}
+	if args.Value != nil {
+		msg.Value = uint256.MustFromBig(args.Value.ToInt())
+	}
+	if args.Data != nil {
+		msg.Data = *args.Data
+	}
+	if args.AccessList != nil {
+		msg.AccessList = *args.AccessList
+	}
+	return msg
+}
+
+type simulatedNetAPI struct {
+	b *SimulatedBackend
+}
+
+func (api *simulatedNetAPI) Version() string {
+	return api.b.config.ChainID.String()
+}
+
+type simulatedEvmAPI struct {
+	b        *SimulatedBackend
+	automine *atomic.Bool
+}
+
+func (api *simulatedEvmAPI) Snapshot() (hexutil.Uint64, error) {
+	id, err := api.b.Snapshot()
+	return hexutil.Uint64(id), err
+}
+
+func (api *simulatedEvmAPI) Revert(id hexutil.Uint64) (bool, error) {
+	err := api.b.Revert(uint64(id))
+	if errors.Is(err, errUnknownSnapshot) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (api *simulatedEvmAPI) Mine() {
+	api.b.Commit()
+}
+
+// IncreaseTime moves the time of the pending block, which must be empty, by the seconds.
+func (api *simulatedEvmAPI) IncreaseTime(seconds uint64) error {
+	return api.b.AdjustTime(time.Duration(seconds) * time.Second)
+}
+
+func (api *simulatedEvmAPI) SetAutomine(enabled bool) {
+	api.automine.Store(enabled)
+}
+
+// simulatedCheatAPI serves the cheatcodes of the hardhat and anvil namespaces.
+type simulatedCheatAPI struct {
+	b *SimulatedBackend
+}
+
+func (api *simulatedCheatAPI) SetBalance(address libcommon.Address, balance hexutil.Big) error {
+	return api.b.SetBalance(address, uint256.MustFromBig(balance.ToInt()))
+}
+
+func (api *simulatedCheatAPI) SetNonce(address libcommon.Address, nonce hexutil.Uint64) error {
+	return api.b.SetNonce(address, uint64(nonce))
+}
+
+func (api *simulatedCheatAPI) SetCode(address libcommon.Address, code hexutility.Bytes) error {
+	return api.b.SetCode(address, code)
+}
+
+func (api *simulatedCheatAPI) SetStorageAt(address libcommon.Address, slot hexutil.Big, value libcommon.Hash) error {
+	return api.b.SetStorageAt(address, libcommon.BigToHash(slot.ToInt()), value)
+}
+
+func (api *simulatedCheatAPI) ImpersonateAccount(address libcommon.Address) error {
+	return api.b.ImpersonateAccount(address)
+}
+
+func (api *simulatedCheatAPI) StopImpersonatingAccount(address libcommon.Address) {
+	api.b.StopImpersonatingAccount(address)
+}
+
+// Mine mines the blocks, 1 by default.
+func (api *simulatedCheatAPI) Mine(blocks *hexutil.Uint64) {
+	n := uint64(1)
+	if blocks != nil {
+		n = uint64(*blocks)
+	}
+	api.b.Mine(n)
+}
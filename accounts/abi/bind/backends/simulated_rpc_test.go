@@ -0,0 +1,106 @@
+package backends
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+func TestSimulatedBackend_RPC(t *testing.T) {
+	sim := NewTestDevSimulatedBackend(t, types.GenesisAlloc{}, 10000000)
+	srv, err := sim.NewRPCServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	client := rpc.DialInProc(srv)
+	defer client.Close()
+	ctx := context.Background()
+	whale := libcommon.HexToAddress("0xbeef")
+	other := libcommon.HexToAddress("0x1234")
+
+	call := func(result interface{}, method string, args ...interface{}) {
+		t.Helper()
+		if err := client.CallContext(ctx, result, method, args...); err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+	}
+	balanceOf := func(address libcommon.Address) uint64 {
+		t.Helper()
+		var balance hexutil.Big
+		call(&balance, "eth_getBalance", address, "latest")
+		return balance.ToInt().Uint64()
+	}
+
+	var chainID hexutil.Uint64
+	call(&chainID, "eth_chainId")
+	if chainID != 1337 {
+		t.Errorf("expected chain id 1337, got %d", chainID)
+	}
+	var snapshot hexutil.Uint64
+	call(&snapshot, "evm_snapshot")
+
+	call(nil, "hardhat_setBalance", whale, (*hexutil.Big)(big.NewInt(1000000)))
+	if b := balanceOf(whale); b != 1000000 {
+		t.Errorf("expected balance 1000000, got %d", b)
+	}
+	call(nil, "anvil_impersonateAccount", whale)
+	var hash libcommon.Hash
+	call(&hash, "eth_sendTransaction", map[string]interface{}{"from": whale, "to": other, "value": "0x3e8"})
+
+	// The transaction is mined right away
+	var receipt map[string]interface{}
+	call(&receipt, "eth_getTransactionReceipt", hash)
+	if receipt["status"] != "0x1" || receipt["blockNumber"] != "0x1" {
+		t.Errorf("unexpected receipt %v", receipt)
+	}
+	var tx map[string]interface{}
+	call(&tx, "eth_getTransactionByHash", hash)
+	if tx["from"] != hexutility.Encode(whale.Bytes()) {
+		t.Errorf("unexpected transaction %v", tx)
+	}
+	var blockNumber hexutil.Uint64
+	call(&blockNumber, "eth_blockNumber")
+	if blockNumber != 1 {
+		t.Errorf("expected block 1, got %d", blockNumber)
+	}
+	if b := balanceOf(other); b != 1000 {
+		t.Errorf("expected balance 1000, got %d", b)
+	}
+	var block map[string]interface{}
+	call(&block, "eth_getBlockByNumber", "latest", false)
+	if txs, _ := block["transactions"].([]interface{}); len(txs) != 1 || txs[0] != hash.Hex() {
+		t.Errorf("unexpected block %v", block)
+	}
+
+	call(nil, "hardhat_mine", hexutil.Uint64(3))
+	call(&blockNumber, "eth_blockNumber")
+	if blockNumber != 4 {
+		t.Errorf("expected block 4, got %d", blockNumber)
+	}
+
+	var reverted bool
+	call(&reverted, "evm_revert", snapshot)
+	if !reverted {
+		t.Fatal("expected the snapshot to be reverted")
+	}
+	if b := balanceOf(other); b != 0 {
+		t.Errorf("expected no balance after the revert, got %d", b)
+	}
+	call(&reverted, "evm_revert", snapshot)
+	if reverted {
+		t.Error("expected the snapshot to be gone")
+	}
+	var gone map[string]interface{}
+	call(&gone, "eth_getTransactionReceipt", hash)
+	if gone != nil {
+		t.Errorf("expected the receipt to be gone, got %v", gone)
+	}
+}
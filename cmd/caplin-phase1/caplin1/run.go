@@ -17,15 +17,21 @@ import (
 	"github.com/ledgerwatch/erigon/eth/stagedsync"
 )
 
-func RunCaplinPhase1(ctx context.Context, sentinel sentinel.SentinelClient, beaconConfig *clparams.BeaconChainConfig, genesisConfig *clparams.GenesisConfig, engine execution_client.ExecutionEngine, state *state.BeaconState) error {
-	beaconRpc := rpc.NewBeaconRpcP2P(ctx, sentinel, beaconConfig, genesisConfig)
-	downloader := network.NewForwardBeaconDownloader(ctx, beaconRpc)
-
+// NewForkChoice creates the fork choice store RunCaplinPhase1 follows the chain with,
+// so that the caller can also read the beacon head and finality from it.
+func NewForkChoice(engine execution_client.ExecutionEngine, state *state.BeaconState) (*forkchoice.ForkChoiceStore, error) {
 	forkChoice, err := forkchoice.NewForkChoiceStore(state, engine, true)
 	if err != nil {
 		log.Error("Could not create forkchoice", "err", err)
-		return err
+		return nil, err
 	}
+	return forkChoice, nil
+}
+
+func RunCaplinPhase1(ctx context.Context, sentinel sentinel.SentinelClient, beaconConfig *clparams.BeaconChainConfig, genesisConfig *clparams.GenesisConfig, forkChoice *forkchoice.ForkChoiceStore, state *state.BeaconState) error {
+	beaconRpc := rpc.NewBeaconRpcP2P(ctx, sentinel, beaconConfig, genesisConfig)
+	downloader := network.NewForwardBeaconDownloader(ctx, beaconRpc)
+
 	gossipManager := network.NewGossipReceiver(ctx, sentinel, forkChoice, beaconConfig, genesisConfig)
 	// start the enabling of BLS caching
 	bls.EnableCaching()
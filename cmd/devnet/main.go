@@ -1,6 +1,10 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
 	"sync"
 	"time"
 
@@ -11,7 +15,14 @@ import (
 	"github.com/ledgerwatch/erigon/cmd/devnet/services"
 )
 
+var chain = flag.String("chain", models.ChainParam, fmt.Sprintf("chain to run the devnet on: %s (clique) or %s (bor, with a local heimdall simulator)", models.ChainParam, models.BorDevnetChainParam))
+
 func main() {
+	flag.Parse()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	defer func() {
 		// unsubscribe from all the subscriptions made
 		defer services.UnsubscribeAll()
@@ -26,8 +37,16 @@ func main() {
 	// remove the old logs from previous runs
 	devnetutils.DeleteLogs()
 
+	// bor nodes fetch spans and state sync events from heimdall
+	if *chain == models.BorDevnetChainParam {
+		if _, err := node.StartHeimdall(ctx); err != nil {
+			fmt.Printf("error starting the heimdall simulator: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
 	// start the first erigon node in a go routine
-	node.Start(&wg)
+	node.Start(&wg, *chain)
 
 	// send a quit signal to the quit channels when done making checks
 	node.QuitOnSignal(&wg)
@@ -52,11 +52,15 @@ const (
 	HttpApiArg = "--http.api"
 	// WSArg is the --ws flag for rpcdaemon
 	WSArg = "--ws"
+	// HeimdallURLArg is the bor.heimdall flag
+	HeimdallURLArg = "--bor.heimdall"
 
 	// DataDirParam is the datadir parameter
 	DataDirParam = "./dev"
 	// ChainParam is the chain parameter
 	ChainParam = "dev"
+	// BorDevnetChainParam is the chain parameter for a bor devnet backed by the local heimdall simulator
+	BorDevnetChainParam = "bor-devnet"
 	// DevPeriodParam is the dev.period parameter
 	DevPeriodParam = "30"
 	// ConsoleVerbosityParam is the verbosity parameter for the console logs
@@ -71,6 +75,10 @@ const (
 	PrivateApiParamNoMine = "localhost:9091"
 	// HttpApiParam is the http.api default parameter for rpcdaemon
 	HttpApiParam = "admin,eth,erigon,web3,net,debug,trace,txpool,parity,ots"
+	// HeimdallAddrParam is the address the heimdall simulator serves its REST API on
+	HeimdallAddrParam = "localhost:1317"
+	// HeimdallGrpcAddrParam is the address the heimdall simulator serves its gRPC API on
+	HeimdallGrpcAddrParam = "localhost:1318"
 
 	// ErigonUrl is the default url for rpc connections
 	ErigonUrl = "http://localhost:8545"
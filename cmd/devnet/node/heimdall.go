@@ -0,0 +1,28 @@
+package node
+
+import (
+	"context"
+
+	"github.com/ledgerwatch/erigon/cmd/devnet/models"
+	"github.com/ledgerwatch/erigon/consensus/bor/heimdallsim"
+	"github.com/ledgerwatch/erigon/consensus/bor/valset"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/params"
+)
+
+// StartHeimdall starts a local heimdall simulator for the bor devnet, with the
+// dev account as the only validator, serving until ctx is done
+func StartHeimdall(ctx context.Context) (*heimdallsim.HeimdallSimulator, error) {
+	chainID := params.BorDevnetChainConfig.ChainID.String()
+	validators := []*valset.Validator{valset.NewValidator(core.DevnetEtherbase, 1000)}
+	validators[0].ID = 1
+
+	sim := heimdallsim.New(chainID, validators, heimdallsim.DefaultSpanLength)
+	if _, err := sim.StartHTTPServer(ctx, models.HeimdallAddrParam); err != nil {
+		return nil, err
+	}
+	if _, err := sim.StartGRPCServer(ctx, models.HeimdallGrpcAddrParam); err != nil {
+		return nil, err
+	}
+	return sim, nil
+}
@@ -22,13 +22,13 @@ import (
 // Holds the number id of each node on the network, the first node is node 0
 var nodeNumber int
 
-// Start starts the process for two erigon nodes running on the dev chain
-func Start(wg *sync.WaitGroup) {
+// Start starts the process for two erigon nodes running on the given chain
+func Start(wg *sync.WaitGroup, chain string) {
 	// add one goroutine to the wait-list
 	wg.Add(1)
 
 	// start the first node
-	go StartNode(wg, miningNodeArgs())
+	go StartNode(wg, miningNodeArgs(chain))
 
 	// sleep for a while to allow first node to start
 	time.Sleep(time.Second * 10)
@@ -44,7 +44,7 @@ func Start(wg *sync.WaitGroup) {
 	wg.Add(1)
 
 	// start the second node, connect it to the mining node with the enode
-	go StartNode(wg, nonMiningNodeArgs(2, enode))
+	go StartNode(wg, nonMiningNodeArgs(chain, 2, enode))
 }
 
 // StartNode starts an erigon node on the dev chain
@@ -98,30 +98,44 @@ func runNode(ctx *cli.Context) error {
 }
 
 // miningNodeArgs returns custom args for starting a mining node
-func miningNodeArgs() []string {
+func miningNodeArgs(chain string) []string {
 	dataDir, _ := models.ParameterFromArgument(models.DataDirArg, models.DataDirParam+fmt.Sprintf("%d", nodeNumber))
-	chainType, _ := models.ParameterFromArgument(models.ChainArg, models.ChainParam)
-	devPeriod, _ := models.ParameterFromArgument(models.DevPeriodArg, models.DevPeriodParam)
+	chainType, _ := models.ParameterFromArgument(models.ChainArg, chain)
 	privateApiAddr, _ := models.ParameterFromArgument(models.PrivateApiAddrArg, models.PrivateApiParamMine)
 	httpApi, _ := models.ParameterFromArgument(models.HttpApiArg, models.HttpApiParam)
 	ws := models.WSArg
 	consoleVerbosity, _ := models.ParameterFromArgument(models.ConsoleVerbosityArg, models.ConsoleVerbosityParam)
 	logDir, _ := models.ParameterFromArgument(models.LogDirArg, models.LogDirParam+"/node_1")
 
-	return []string{models.BuildDirArg, dataDir, chainType, privateApiAddr, models.Mine, httpApi, ws, devPeriod, consoleVerbosity, logDir}
+	args := []string{models.BuildDirArg, dataDir, chainType, privateApiAddr, models.Mine, httpApi, ws, consoleVerbosity, logDir}
+	if chain == models.BorDevnetChainParam {
+		return append(args, heimdallURLArg())
+	}
+	devPeriod, _ := models.ParameterFromArgument(models.DevPeriodArg, models.DevPeriodParam)
+	return append(args, devPeriod)
 }
 
 // nonMiningNodeArgs returns custom args for starting a non-mining node
-func nonMiningNodeArgs(nodeNumber int, enode string) []string {
+func nonMiningNodeArgs(chain string, nodeNumber int, enode string) []string {
 	dataDir, _ := models.ParameterFromArgument(models.DataDirArg, models.DataDirParam+fmt.Sprintf("%d", nodeNumber))
-	chainType, _ := models.ParameterFromArgument(models.ChainArg, models.ChainParam)
+	chainType, _ := models.ParameterFromArgument(models.ChainArg, chain)
 	privateApiAddr, _ := models.ParameterFromArgument(models.PrivateApiAddrArg, models.PrivateApiParamNoMine)
 	staticPeers, _ := models.ParameterFromArgument(models.StaticPeersArg, enode)
 	consoleVerbosity, _ := models.ParameterFromArgument(models.ConsoleVerbosityArg, models.ConsoleVerbosityParam)
 	logDir, _ := models.ParameterFromArgument(models.LogDirArg, models.LogDirParam+"/node_2")
 	torrentPort, _ := models.ParameterFromArgument(models.TorrentPortArg, models.TorrentPortParam)
 
-	return []string{models.BuildDirArg, dataDir, chainType, privateApiAddr, staticPeers, models.NoDiscover, consoleVerbosity, logDir, torrentPort}
+	args := []string{models.BuildDirArg, dataDir, chainType, privateApiAddr, staticPeers, models.NoDiscover, consoleVerbosity, logDir, torrentPort}
+	if chain == models.BorDevnetChainParam {
+		args = append(args, heimdallURLArg())
+	}
+	return args
+}
+
+// heimdallURLArg returns the arg pointing bor nodes to the heimdall simulator
+func heimdallURLArg() string {
+	heimdallURL, _ := models.ParameterFromArgument(models.HeimdallURLArg, "http://"+models.HeimdallAddrParam)
+	return heimdallURL
 }
 
 // getEnode returns the enode of the mining node
@@ -30,6 +30,9 @@ type ForkChoiceStore struct {
 	unrealizedJustifiedCheckpoint *cltypes.Checkpoint
 	unrealizedFinalizedCheckpoint *cltypes.Checkpoint
 	proposerBoostRoot             libcommon.Hash
+	// The head found by the latest GetHead
+	headHash libcommon.Hash
+	headSlot uint64
 	// Use go map because this is actually an unordered set
 	equivocatingIndicies map[uint64]struct{}
 	forkGraph            *fork_graph.ForkGraph
@@ -73,6 +76,8 @@ func NewForkChoiceStore(anchorState *state.BeaconState, engine execution_client.
 		finalizedCheckpoint:           anchorCheckpoint.Copy(),
 		unrealizedJustifiedCheckpoint: anchorCheckpoint.Copy(),
 		unrealizedFinalizedCheckpoint: anchorCheckpoint.Copy(),
+		headHash:                      anchorRoot,
+		headSlot:                      anchorState.Slot(),
 		forkGraph:                     fork_graph.New(anchorState, enabledPruning),
 		equivocatingIndicies:          map[uint64]struct{}{},
 		latestMessages:                map[uint64]*LatestMessage{},
@@ -96,6 +101,13 @@ func (f *ForkChoiceStore) Time() uint64 {
 	return f.time
 }
 
+// CachedHead returns the head found by the latest GetHead, without running the fork choice.
+func (f *ForkChoiceStore) CachedHead() (libcommon.Hash, uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.headHash, f.headSlot
+}
+
 // ProposerBoostRoot returns proposer boost root
 func (f *ForkChoiceStore) ProposerBoostRoot() libcommon.Hash {
 	f.mu.Lock()
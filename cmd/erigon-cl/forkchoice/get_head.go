@@ -39,6 +39,7 @@ func (f *ForkChoiceStore) GetHead() (libcommon.Hash, uint64, error) {
 			if !hasHeader {
 				return libcommon.Hash{}, 0, fmt.Errorf("no slot for head is stored")
 			}
+			f.headHash, f.headSlot = head, header.Slot
 			return head, header.Slot, nil
 		}
 		// Average case scenario.
@@ -53,6 +53,7 @@ import (
 	"github.com/ledgerwatch/erigon/cmd/sentry/sentry"
 	"github.com/ledgerwatch/erigon/common/debug"
 	"github.com/ledgerwatch/erigon/consensus"
+	"github.com/ledgerwatch/erigon/consensus/aura"
 	"github.com/ledgerwatch/erigon/consensus/bor"
 	"github.com/ledgerwatch/erigon/consensus/clique"
 	"github.com/ledgerwatch/erigon/consensus/ethash"
@@ -66,12 +67,14 @@ import (
 	"github.com/ledgerwatch/erigon/core/types/accounts"
 	"github.com/ledgerwatch/erigon/core/vm"
 	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/diagnostics"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	"github.com/ledgerwatch/erigon/eth/ethconsensusconfig"
 	"github.com/ledgerwatch/erigon/eth/protocols/eth"
 	"github.com/ledgerwatch/erigon/eth/stagedsync"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/ethdb/privateapi"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi/remoteproto"
 	"github.com/ledgerwatch/erigon/ethdb/prune"
 	"github.com/ledgerwatch/erigon/ethstats"
 	"github.com/ledgerwatch/erigon/node"
@@ -131,13 +134,13 @@ type Ethereum struct {
 	sentryCancel   context.CancelFunc
 	sentriesClient *sentry.MultiClient
 	sentryServers  []*sentry.GrpcServer
+	peersClients   []remoteproto.PeersClient
 
 	stagedSync *stagedsync.Sync
 
 	downloaderClient proto_downloader.DownloaderClient
 
-	notifications      *shards.Notifications
-	unsubscribeEthstat func()
+	notifications *shards.Notifications
 
 	waitForStageLoopStop chan struct{}
 	waitForMiningStop    chan struct{}
@@ -192,7 +195,7 @@ func NewBackend(stack *node.Node, config *ethconfig.Config, logger log.Logger) (
 			genesisSpec = nil
 		}
 		var genesisErr error
-		chainConfig, genesis, genesisErr = core.WriteGenesisBlock(tx, genesisSpec, config.OverrideShanghaiTime, tmpdir)
+		chainConfig, genesis, genesisErr = core.WriteGenesisBlock(tx, genesisSpec, config.OverrideShanghaiTime, config.OverrideCancunTime, tmpdir)
 		if _, ok := genesisErr.(*chain.ConfigCompatError); genesisErr != nil && !ok {
 			return genesisErr
 		}
@@ -270,6 +273,7 @@ func NewBackend(stack *node.Node, config *ethconfig.Config, logger log.Logger) (
 	if err != nil {
 		return nil, err
 	}
+	diagnostics.SetBlockReader(backend.blockReader, allSnapshots)
 	backend.agg = agg
 
 	if config.HistoryV3 {
@@ -286,13 +290,16 @@ func NewBackend(stack *node.Node, config *ethconfig.Config, logger log.Logger) (
 	backend.gasPrice, _ = uint256.FromBig(config.Miner.GasPrice)
 
 	var sentries []direct.SentryClient
+	var localTxsSentries []*sentry.LocalTxsSentryClient // the sentry clients of the transaction pool
 	if len(stack.Config().P2P.SentryAddr) > 0 {
 		for _, addr := range stack.Config().P2P.SentryAddr {
-			sentryClient, err := sentry.GrpcClient(backend.sentryCtx, addr)
+			sentryClient, peersClient, localTxsClient, err := sentry.GrpcClient(backend.sentryCtx, addr)
 			if err != nil {
 				return nil, err
 			}
 			sentries = append(sentries, sentryClient)
+			backend.peersClients = append(backend.peersClients, peersClient)
+			localTxsSentries = append(localTxsSentries, sentry.NewLocalTxsSentryClient(sentryClient, localTxsClient))
 		}
 	} else {
 		var readNodeInfo = func() *eth.NodeInfo {
@@ -319,6 +326,13 @@ func NewBackend(stack *node.Node, config *ethconfig.Config, logger log.Logger) (
 			return nil, err
 		}
 
+		var txBroadcast sentry.TxBroadcastPolicy
+		if policy := stack.Config().SentryLocalTxBroadcast; policy != "" {
+			if txBroadcast, err = sentry.ParseLocalTxBroadcast(policy); err != nil {
+				return nil, err
+			}
+		}
+
 		var pi int // points to next port to be picked from refCfg.AllowedPorts
 		for _, protocol := range refCfg.ProtocolVersion {
 			cfg := refCfg
@@ -347,8 +361,15 @@ func NewBackend(stack *node.Node, config *ethconfig.Config, logger log.Logger) (
 			cfg.ListenAddr = fmt.Sprintf("%s:%d", listenHost, listenPort)
 
 			server := sentry.NewGrpcServer(backend.sentryCtx, discovery, readNodeInfo, &cfg, protocol)
+			server.TxBroadcastPolicy = txBroadcast
+			if len(backend.sentryServers) > 0 {
+				server.ShareTxPropagation(backend.sentryServers[0])
+			}
 			backend.sentryServers = append(backend.sentryServers, server)
-			sentries = append(sentries, direct.NewSentryClientDirect(protocol, server))
+			sentryClient := direct.NewSentryClientDirect(protocol, server)
+			sentries = append(sentries, sentryClient)
+			backend.peersClients = append(backend.peersClients, remoteproto.NewPeersClientDirect(server))
+			localTxsSentries = append(localTxsSentries, sentry.NewLocalTxsSentryClient(sentryClient, remoteproto.NewLocalTxsClientDirect(server)))
 		}
 
 		go func() {
@@ -452,12 +473,20 @@ func NewBackend(stack *node.Node, config *ethconfig.Config, logger log.Logger) (
 
 		backend.newTxs2 = make(chan types2.Announcements, 1024)
 		//defer close(newTxs)
+		txPoolSentries := make([]direct.SentryClient, len(localTxsSentries))
+		for i, sentryClient := range localTxsSentries {
+			txPoolSentries[i] = sentryClient
+		}
 		backend.txPool2DB, backend.txPool2, backend.txPool2Fetch, backend.txPool2Send, backend.txPool2GrpcServer, err = txpooluitl.AllComponents(
-			ctx, config.TxPool, kvcache.NewDummy(), backend.newTxs2, backend.chainDB, backend.sentriesClient.Sentries(), stateDiffClient,
+			ctx, config.TxPool, kvcache.NewDummy(), backend.newTxs2, backend.chainDB, txPoolSentries, stateDiffClient,
 		)
 		if err != nil {
 			return nil, err
 		}
+		// The sentries apply their local transactions broadcast policy to the transactions marked by the pool
+		for _, sentryClient := range localTxsSentries {
+			sentryClient.SetIsLocal(backend.txPool2.IsLocal)
+		}
 	}
 
 	backend.notifyMiningAboutNewTxs = make(chan struct{}, 1)
@@ -509,6 +538,14 @@ func NewBackend(stack *node.Node, config *ethconfig.Config, logger log.Logger) (
 		backend.blockReader, chainConfig, assembleBlockPOS, backend.sentriesClient.Hd, config.Miner.EnabledPOS)
 	miningRPC = privateapi.NewMiningServer(ctx, backend, ethashApi)
 
+	var cliqueEngine *clique.Clique
+	if casted, ok := backend.engine.(*clique.Clique); ok {
+		cliqueEngine = casted
+	} else if cl, ok := backend.engine.(*serenity.Serenity); ok {
+		cliqueEngine, _ = cl.InnerEngine().(*clique.Clique)
+	}
+	cliqueRPC := privateapi.NewCliqueServer(cliqueEngine)
+
 	var creds credentials.TransportCredentials
 	if stack.Config().PrivateApiAddr != "" {
 		if stack.Config().TLSConnection {
@@ -522,6 +559,7 @@ func NewBackend(stack *node.Node, config *ethconfig.Config, logger log.Logger) (
 			ethBackendRPC,
 			backend.txPool2GrpcServer,
 			miningRPC,
+			cliqueRPC,
 			stack.Config().PrivateApiAddr,
 			stack.Config().PrivateApiRateLimit,
 			creds,
@@ -638,25 +676,39 @@ func NewBackend(stack *node.Node, config *ethconfig.Config, logger log.Logger) (
 		gpoParams.Default = config.Miner.GasPrice
 	}
 	//eth.APIBackend.gpo = gasprice.NewOracle(eth.APIBackend, gpoParams)
-	if config.Ethstats != "" {
-		var headCh chan [][]byte
-		headCh, backend.unsubscribeEthstat = backend.notifications.Events.AddHeaderSubscription()
-		if err := ethstats.New(stack, backend.sentryServers, chainKv, backend.engine, config.Ethstats, backend.networkID, ctx.Done(), headCh); err != nil {
-			return nil, err
-		}
-	}
 	// start HTTP API
 	httpRpcCfg := stack.Config().Http
-	ethRpcClient, txPoolRpcClient, miningRpcClient, stateCache, ff, err := cli.EmbeddedServices(ctx, chainKv, httpRpcCfg.StateCache, backend.blockReader, ethBackendRPC, backend.txPool2GrpcServer, miningRPC, stateDiffClient)
+	ethRpcClient, txPoolRpcClient, miningRpcClient, stateCache, ff, err := cli.EmbeddedServices(ctx, chainKv, httpRpcCfg.StateCache, backend.blockReader, ethBackendRPC, backend.txPool2GrpcServer, miningRPC, cliqueRPC, stateDiffClient, httpRpcCfg.RpcFiltersConfig)
 	if err != nil {
 		return nil, err
 	}
+	if config.Ethstats != "" {
+		service, err := ethstats.New(ethstats.Config{
+			URL:         config.Ethstats,
+			Backend:     ethRpcClient,
+			DB:          chainKv,
+			BlockReader: backend.blockReader,
+			TxPool:      txPoolRpcClient,
+			Snapshots:   allSnapshots,
+		})
+		if err != nil {
+			return nil, err
+		}
+		stack.RegisterLifecycle(service)
+	}
 
-	var borDb kv.RoDB
-	if casted, ok := backend.engine.(*bor.Bor); ok {
-		borDb = casted.DB
+	var consensusDb kv.RoDB
+	engine := backend.engine
+	if cl, ok := engine.(*serenity.Serenity); ok {
+		engine = cl.InnerEngine()
+	}
+	switch casted := engine.(type) {
+	case *bor.Bor:
+		consensusDb = casted.DB
+	case *clique.Clique:
+		consensusDb = casted.DB
 	}
-	apiList := commands.APIList(chainKv, borDb, ethRpcClient, txPoolRpcClient, miningRpcClient, ff, stateCache, backend.blockReader, backend.agg, httpRpcCfg, backend.engine)
+	apiList := commands.APIList(chainKv, consensusDb, ethRpcClient, txPoolRpcClient, miningRpcClient, ff, stateCache, backend.blockReader, backend.agg, httpRpcCfg, backend.engine)
 	authApiList := commands.AuthAPIList(chainKv, ethRpcClient, txPoolRpcClient, miningRpcClient, ff, stateCache, backend.blockReader, backend.agg, httpRpcCfg, backend.engine)
 	go func() {
 		if err := cli.StartRpcServer(ctx, httpRpcCfg, apiList, authApiList); err != nil {
@@ -738,6 +790,25 @@ func (s *Ethereum) StartMining(ctx context.Context, db kv.RwDB, mining *stagedsy
 		})
 	}
 
+	var auraEngine *aura.AuRa
+	if a, ok := s.engine.(*aura.AuRa); ok {
+		auraEngine = a
+	} else if cl, ok := s.engine.(*serenity.Serenity); ok {
+		if a, ok := cl.InnerEngine().(*aura.AuRa); ok {
+			auraEngine = a
+		}
+	}
+	if auraEngine != nil {
+		if cfg.SigKey == nil {
+			log.Error("Etherbase account unavailable locally", "err", err)
+			return fmt.Errorf("signer missing: %w", err)
+		}
+
+		auraEngine.Authorize(eb, func(_ libcommon.Address, mimeType string, message []byte) ([]byte, error) {
+			return crypto.Sign(crypto.Keccak256(message), cfg.SigKey)
+		})
+	}
+
 	go func() {
 		defer debug.LogPanic()
 		defer close(s.waitForMiningStop)
@@ -904,6 +975,18 @@ func (s *Ethereum) Peers(ctx context.Context) (*remote.PeersReply, error) {
 	return &reply, nil
 }
 
+func (s *Ethereum) PeersProtocols(ctx context.Context) (*remoteproto.PeersProtocolsReply, error) {
+	var reply remoteproto.PeersProtocolsReply
+	for _, peersClient := range s.peersClients {
+		peers, err := peersClient.PeersProtocols(ctx, &emptypb.Empty{})
+		if err != nil {
+			return nil, fmt.Errorf("ethereum backend PeersProtocols error: %w", err)
+		}
+		reply.Peers = append(reply.Peers, peers.Peers...)
+	}
+	return &reply, nil
+}
+
 // Protocols returns all the currently configured
 // network protocols to start.
 func (s *Ethereum) Protocols() []p2p.Protocol {
@@ -944,9 +1027,6 @@ func (s *Ethereum) Start() error {
 func (s *Ethereum) Stop() error {
 	// Stop all the peer-related stuff first.
 	s.sentryCancel()
-	if s.unsubscribeEthstat != nil {
-		s.unsubscribeEthstat()
-	}
 	if s.downloader != nil {
 		s.downloader.Close()
 	}
@@ -0,0 +1,109 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/ledgerwatch/log/v3"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ledgerwatch/erigon/tests"
+)
+
+var RunFlag = cli.StringFlag{
+	Name:  "run",
+	Value: ".*",
+	Usage: "Run only those tests matching the regular expression.",
+}
+
+var blockTestCommand = cli.Command{
+	Action:    blockTestCmd,
+	Name:      "blocktest",
+	Usage:     "executes the given blockchain tests",
+	ArgsUsage: "<file>",
+	Flags:     []cli.Flag{&RunFlag},
+}
+
+// BlocktestResult contains the execution status after running a blockchain test
+// and any error that might have occurred.
+type BlocktestResult struct {
+	Name  string `json:"name"`
+	Pass  bool   `json:"pass"`
+	Error string `json:"error,omitempty"`
+}
+
+func blockTestCmd(ctx *cli.Context) error {
+	if len(ctx.Args().First()) == 0 {
+		return errors.New("path-to-test argument required")
+	}
+	// Configure the go-ethereum logger
+	log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(ctx.Int(VerbosityFlag.Name)), log.StderrHandler))
+
+	re, err := regexp.Compile(ctx.String(RunFlag.Name))
+	if err != nil {
+		return fmt.Errorf("invalid regex -%s: %v", RunFlag.Name, err)
+	}
+	// Load the test content from the input file
+	src, err := os.ReadFile(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	var blockTests map[string]tests.BlockTest
+	if err = json.Unmarshal(src, &blockTests); err != nil {
+		return err
+	}
+	// Run them in a deterministic order
+	keys := make([]string, 0, len(blockTests))
+	for name := range blockTests {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+
+	results := make([]BlocktestResult, 0, len(keys))
+	for _, name := range keys {
+		if !re.MatchString(name) {
+			continue
+		}
+		test := blockTests[name]
+		result := BlocktestResult{Name: name, Pass: true}
+		if err := test.Run(nil, false); err != nil {
+			result.Pass, result.Error = false, err.Error()
+		}
+		results = append(results, result)
+	}
+	out, _ := json.MarshalIndent(results, "", "  ")
+	fmt.Println(string(out))
+	if failed := countFailed(results); failed > 0 {
+		return fmt.Errorf("%d of %d blockchain tests failed", failed, len(results))
+	}
+	return nil
+}
+
+func countFailed(results []BlocktestResult) (n int) {
+	for _, result := range results {
+		if !result.Pass {
+			n++
+		}
+	}
+	return n
+}
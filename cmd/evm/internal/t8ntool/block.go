@@ -0,0 +1,380 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package t8ntool
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ledgerwatch/log/v3"
+	"github.com/urfave/cli/v2"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+
+	"github.com/ledgerwatch/erigon/common/math"
+	"github.com/ledgerwatch/erigon/consensus/clique"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+// header is the JSON representation of the header given to `evm b11r`.
+// Fields which can be derived from the body are optional.
+type header struct {
+	ParentHash      libcommon.Hash        `json:"parentHash"`
+	OmmerHash       *libcommon.Hash       `json:"sha3Uncles"`
+	Coinbase        *libcommon.Address    `json:"miner"`
+	Root            libcommon.Hash        `json:"stateRoot"`
+	TxHash          *libcommon.Hash       `json:"transactionsRoot"`
+	ReceiptHash     *libcommon.Hash       `json:"receiptsRoot"`
+	Bloom           types.Bloom           `json:"logsBloom"`
+	Difficulty      *math.HexOrDecimal256 `json:"difficulty"`
+	Number          *math.HexOrDecimal256 `json:"number"`
+	GasLimit        math.HexOrDecimal64   `json:"gasLimit"`
+	GasUsed         math.HexOrDecimal64   `json:"gasUsed"`
+	Time            math.HexOrDecimal64   `json:"timestamp"`
+	Extra           hexutility.Bytes      `json:"extraData"`
+	MixDigest       libcommon.Hash        `json:"mixHash"`
+	Nonce           *types.BlockNonce     `json:"nonce"`
+	BaseFee         *math.HexOrDecimal256 `json:"baseFeePerGas"`
+	WithdrawalsHash *libcommon.Hash       `json:"withdrawalsRoot"`
+}
+
+type bbInput struct {
+	Header      *header             `json:"header,omitempty"`
+	OmmersRlp   []string            `json:"ommers,omitempty"`
+	TxRlp       string              `json:"txs,omitempty"`
+	Withdrawals []*types.Withdrawal `json:"withdrawals,omitempty"`
+	Clique      *cliqueInput        `json:"clique,omitempty"`
+
+	Txs    []types.Transaction `json:"-"`
+	Ommers []*types.Header     `json:"-"`
+}
+
+type cliqueInput struct {
+	Key       *ecdsa.PrivateKey
+	Voted     *libcommon.Address
+	Authorize *bool
+	Vanity    libcommon.Hash
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface.
+func (c *cliqueInput) UnmarshalJSON(input []byte) error {
+	var x struct {
+		Key       *libcommon.Hash    `json:"secretKey"`
+		Voted     *libcommon.Address `json:"voted"`
+		Authorize *bool              `json:"authorize"`
+		Vanity    libcommon.Hash     `json:"vanity"`
+	}
+	if err := json.Unmarshal(input, &x); err != nil {
+		return err
+	}
+	if x.Key == nil {
+		return errors.New("missing required field 'secretKey' for cliqueInput")
+	}
+	if ecdsaKey, err := crypto.ToECDSA(x.Key[:]); err != nil {
+		return err
+	} else {
+		c.Key = ecdsaKey
+	}
+	c.Voted = x.Voted
+	c.Authorize = x.Authorize
+	c.Vanity = x.Vanity
+	return nil
+}
+
+// ToBlock converts i into a *types.Block
+func (i *bbInput) ToBlock() *types.Block {
+	h := &types.Header{
+		ParentHash:      i.Header.ParentHash,
+		UncleHash:       types.EmptyUncleHash,
+		Coinbase:        libcommon.Address{},
+		Root:            i.Header.Root,
+		TxHash:          types.EmptyRootHash,
+		ReceiptHash:     types.EmptyRootHash,
+		Bloom:           i.Header.Bloom,
+		Difficulty:      new(big.Int),
+		Number:          new(big.Int),
+		GasLimit:        uint64(i.Header.GasLimit),
+		GasUsed:         uint64(i.Header.GasUsed),
+		Time:            uint64(i.Header.Time),
+		Extra:           i.Header.Extra,
+		MixDigest:       i.Header.MixDigest,
+		WithdrawalsHash: i.Header.WithdrawalsHash,
+	}
+
+	// Fill optional values.
+	if i.Header.OmmerHash != nil {
+		h.UncleHash = *i.Header.OmmerHash
+	} else if len(i.Ommers) != 0 {
+		// Calculate the ommer hash if none is provided and there are ommers to hash
+		h.UncleHash = types.CalcUncleHash(i.Ommers)
+	}
+	if i.Header.Coinbase != nil {
+		h.Coinbase = *i.Header.Coinbase
+	}
+	if i.Header.TxHash != nil {
+		h.TxHash = *i.Header.TxHash
+	} else if len(i.Txs) != 0 {
+		h.TxHash = types.DeriveSha(types.Transactions(i.Txs))
+	}
+	if i.Header.ReceiptHash != nil {
+		h.ReceiptHash = *i.Header.ReceiptHash
+	}
+	if i.Header.Difficulty != nil {
+		h.Difficulty = (*big.Int)(i.Header.Difficulty)
+	}
+	if i.Header.Number != nil {
+		h.Number = (*big.Int)(i.Header.Number)
+	}
+	if i.Header.Nonce != nil {
+		h.Nonce = *i.Header.Nonce
+	}
+	if i.Header.BaseFee != nil {
+		h.BaseFee = (*big.Int)(i.Header.BaseFee)
+	}
+	if i.Withdrawals != nil && h.WithdrawalsHash == nil {
+		wh := types.DeriveSha(types.Withdrawals(i.Withdrawals))
+		h.WithdrawalsHash = &wh
+	}
+	return types.NewBlockFromStorage(h.Hash(), h, i.Txs, i.Ommers, i.Withdrawals)
+}
+
+// SealBlock seals the given block using the configured engine.
+func (i *bbInput) SealBlock(block *types.Block) (*types.Block, error) {
+	switch {
+	case i.Clique != nil:
+		return i.sealClique(block)
+	default:
+		return block, nil
+	}
+}
+
+// sealClique seals the given block using clique.
+func (i *bbInput) sealClique(block *types.Block) (*types.Block, error) {
+	// If any clique value overwrites an explicit header value, fail
+	// to avoid silently building a block with unexpected values.
+	if i.Header.Extra != nil {
+		return nil, errors.New("extra data must be nil when sealing with clique")
+	}
+	header := block.Header()
+	if i.Clique.Voted != nil {
+		if i.Header.Coinbase != nil {
+			return nil, errors.New("sealing with clique will overwrite provided coinbase")
+		}
+		header.Coinbase = *i.Clique.Voted
+	}
+	if i.Clique.Authorize != nil {
+		if i.Header.Nonce != nil {
+			return nil, errors.New("sealing with clique and voting will overwrite provided nonce")
+		}
+		if *i.Clique.Authorize {
+			copy(header.Nonce[:], clique.NonceAuthVote)
+		} else {
+			header.Nonce = types.BlockNonce{}
+		}
+	}
+	// Extra is fixed 32 byte vanity and 65 byte signature
+	header.Extra = make([]byte, clique.ExtraVanity+crypto.SignatureLength)
+	copy(header.Extra[0:clique.ExtraVanity], i.Clique.Vanity[:])
+
+	// Sign the seal hash and fill in the rest of the extra data
+	h := clique.SealHash(header)
+	sighash, err := crypto.Sign(h[:], i.Clique.Key)
+	if err != nil {
+		return nil, err
+	}
+	copy(header.Extra[len(header.Extra)-crypto.SignatureLength:], sighash)
+	return block.WithSeal(header), nil
+}
+
+// BuildBlock constructs a block from the given inputs.
+func BuildBlock(ctx *cli.Context) error {
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StderrHandler))
+
+	baseDir, err := createBasedir(ctx)
+	if err != nil {
+		return NewError(ErrorIO, fmt.Errorf("failed creating output basedir: %v", err))
+	}
+	inputData, err := readInput(ctx)
+	if err != nil {
+		return err
+	}
+	block := inputData.ToBlock()
+	block, err = inputData.SealBlock(block)
+	if err != nil {
+		return err
+	}
+	return dispatchBlock(ctx, baseDir, block)
+}
+
+func readInput(ctx *cli.Context) (*bbInput, error) {
+	var (
+		headerStr      = ctx.String(InputHeaderFlag.Name)
+		ommersStr      = ctx.String(InputOmmersFlag.Name)
+		withdrawalsStr = ctx.String(InputWithdrawalsFlag.Name)
+		txsStr         = ctx.String(InputTxsRlpFlag.Name)
+		cliqueStr      = ctx.String(SealCliqueFlag.Name)
+		inputData      = &bbInput{}
+	)
+	if headerStr == stdinSelector || ommersStr == stdinSelector || txsStr == stdinSelector || cliqueStr == stdinSelector {
+		decoder := json.NewDecoder(os.Stdin)
+		if err := decoder.Decode(inputData); err != nil {
+			return nil, NewError(ErrorJson, fmt.Errorf("failed unmarshaling input: %v", err))
+		}
+	}
+	if cliqueStr != stdinSelector && cliqueStr != "" {
+		var clique cliqueInput
+		if err := readFile(cliqueStr, "clique", &clique); err != nil {
+			return nil, err
+		}
+		inputData.Clique = &clique
+	}
+	if headerStr != stdinSelector {
+		var env header
+		if err := readFile(headerStr, "header", &env); err != nil {
+			return nil, err
+		}
+		inputData.Header = &env
+	}
+	if inputData.Header == nil {
+		return nil, NewError(ErrorJson, errors.New("missing header"))
+	}
+	if ommersStr != stdinSelector && ommersStr != "" {
+		var ommers []string
+		if err := readFile(ommersStr, "ommers", &ommers); err != nil {
+			return nil, err
+		}
+		inputData.OmmersRlp = ommers
+	}
+	if withdrawalsStr != stdinSelector && withdrawalsStr != "" {
+		var withdrawals []*types.Withdrawal
+		if err := readFile(withdrawalsStr, "withdrawals", &withdrawals); err != nil {
+			return nil, err
+		}
+		inputData.Withdrawals = withdrawals
+	}
+	if txsStr != stdinSelector {
+		var txs string
+		if err := readFile(txsStr, "txs", &txs); err != nil {
+			return nil, err
+		}
+		inputData.TxRlp = txs
+	}
+	// Deserialize rlp txs and ommers
+	var (
+		ommers = []*types.Header{}
+		txs    = []types.Transaction{}
+	)
+	if inputData.TxRlp != "" {
+		it, err := rlp.NewListIterator(libcommon.FromHex(inputData.TxRlp))
+		if err != nil {
+			return nil, NewError(ErrorRlp, fmt.Errorf("unable to decode transaction from rlp data: %v", err))
+		}
+		for it.Next() {
+			if err := it.Err(); err != nil {
+				return nil, NewError(ErrorRlp, err)
+			}
+			tx, err := types.DecodeTransaction(rlp.NewStream(bytes.NewReader(it.Value()), 0))
+			if err != nil {
+				return nil, NewError(ErrorRlp, fmt.Errorf("unable to decode transaction from rlp data: %v", err))
+			}
+			txs = append(txs, tx)
+		}
+	}
+	for _, str := range inputData.OmmersRlp {
+		var ommer types.Header
+		if err := rlp.DecodeBytes(libcommon.FromHex(str), &ommer); err != nil {
+			return nil, NewError(ErrorRlp, fmt.Errorf("unable to decode ommer from rlp data: %v", err))
+		}
+		ommers = append(ommers, &ommer)
+	}
+	inputData.Ommers = ommers
+	inputData.Txs = txs
+
+	return inputData, nil
+}
+
+// dispatchBlock writes the output data to either stderr or stdout, or to the specified
+// files
+func dispatchBlock(ctx *cli.Context, baseDir string, block *types.Block) error {
+	raw, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		return NewError(ErrorRlp, fmt.Errorf("failed encoding block: %v", err))
+	}
+	type blockInfo struct {
+		Rlp  hexutility.Bytes `json:"rlp"`
+		Hash libcommon.Hash   `json:"hash"`
+	}
+	enc := blockInfo{
+		Rlp:  raw,
+		Hash: block.Hash(),
+	}
+	b, err := json.MarshalIndent(enc, "", "  ")
+	if err != nil {
+		return NewError(ErrorJson, fmt.Errorf("failed marshalling output: %v", err))
+	}
+	switch dest := ctx.String(OutputBlockFlag.Name); dest {
+	case "stdout":
+		os.Stdout.Write(b)
+		os.Stdout.WriteString("\n")
+	case "stderr":
+		os.Stderr.Write(b)
+		os.Stderr.WriteString("\n")
+	default:
+		if err := saveFile(baseDir, dest, enc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readFile reads the json-data in the provided path and marshals into dest.
+func readFile(path, desc string, dest interface{}) error {
+	inFile, err := os.Open(path)
+	if err != nil {
+		return NewError(ErrorIO, fmt.Errorf("failed reading %s file: %v", desc, err))
+	}
+	defer inFile.Close()
+
+	decoder := json.NewDecoder(inFile)
+	if err := decoder.Decode(dest); err != nil {
+		return NewError(ErrorJson, fmt.Errorf("failed unmarshaling %s file: %v", desc, err))
+	}
+	return nil
+}
+
+// createBasedir makes sure the basedir exists, if user specified one.
+func createBasedir(ctx *cli.Context) (string, error) {
+	baseDir := ""
+	if ctx.IsSet(OutputBasedir.Name) {
+		if base := ctx.String(OutputBasedir.Name); len(base) > 0 {
+			err := os.MkdirAll(base, 0755) // //rw-r--r--
+			if err != nil {
+				return "", err
+			}
+			baseDir = base
+		}
+	}
+	return baseDir, nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package t8ntool
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/urfave/cli/v2"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/tests"
+)
+
+type txResult struct {
+	Error        error
+	Address      libcommon.Address
+	Hash         libcommon.Hash
+	IntrinsicGas uint64
+}
+
+func (r *txResult) MarshalJSON() ([]byte, error) {
+	type xx struct {
+		Error        string             `json:"error,omitempty"`
+		Address      *libcommon.Address `json:"address,omitempty"`
+		Hash         *libcommon.Hash    `json:"hash,omitempty"`
+		IntrinsicGas hexutil.Uint64     `json:"intrinsicGas,omitempty"`
+	}
+	var out xx
+	if r.Error != nil {
+		out.Error = r.Error.Error()
+	}
+	if r.Address != (libcommon.Address{}) {
+		out.Address = &r.Address
+	}
+	if r.Hash != (libcommon.Hash{}) {
+		out.Hash = &r.Hash
+	}
+	out.IntrinsicGas = hexutil.Uint64(r.IntrinsicGas)
+	return json.Marshal(out)
+}
+
+// Transaction is the entry point of `evm t9n`. It decodes an RLP list of signed
+// transactions and reports the sender, hash and intrinsic gas of each of them,
+// or the reason the transaction is invalid under the selected fork.
+func Transaction(ctx *cli.Context) error {
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StderrHandler))
+
+	// We need to load the transactions. May be either in stdin input or in files.
+	// Check if anything needs to be read from stdin
+	var (
+		txStr     = ctx.String(InputTxsFlag.Name)
+		inputData = &input{}
+		body      hexutility.Bytes
+	)
+	// Construct the chainconfig
+	chainConfig, _, err := tests.GetChainConfig(ctx.String(ForknameFlag.Name))
+	if err != nil {
+		return NewError(ErrorVMConfig, fmt.Errorf("failed constructing chain configuration: %v", err))
+	}
+	// Set the chain id
+	chainConfig.ChainID = big.NewInt(ctx.Int64(ChainIDFlag.Name))
+
+	if txStr == stdinSelector {
+		decoder := json.NewDecoder(os.Stdin)
+		if err := decoder.Decode(inputData); err != nil {
+			return NewError(ErrorJson, fmt.Errorf("failed unmarshaling input: %v", err))
+		}
+		// Decode the body of already signed transactions
+		body = libcommon.FromHex(inputData.TxRlp)
+	} else {
+		// Read input from file
+		inFile, err := os.Open(txStr)
+		if err != nil {
+			return NewError(ErrorIO, fmt.Errorf("failed reading txs file: %v", err))
+		}
+		defer inFile.Close()
+		decoder := json.NewDecoder(inFile)
+		if !strings.HasSuffix(txStr, ".rlp") {
+			return NewError(ErrorIO, errors.New("only rlp supported"))
+		}
+		if err := decoder.Decode(&body); err != nil {
+			return NewError(ErrorJson, fmt.Errorf("failed unmarshaling txs-file: %v", err))
+		}
+	}
+	signer := types.MakeSigner(chainConfig, 0)
+	rules := chainConfig.Rules(0, 0)
+
+	// We now have the transactions in 'body', which is supposed to be an
+	// rlp list of transactions
+	it, err := rlp.NewListIterator([]byte(body))
+	if err != nil {
+		return err
+	}
+	var results []txResult
+	for it.Next() {
+		if err := it.Err(); err != nil {
+			return NewError(ErrorIO, err)
+		}
+		tx, err := types.DecodeTransaction(rlp.NewStream(bytes.NewReader(it.Value()), 0))
+		if err != nil {
+			results = append(results, txResult{Error: err})
+			continue
+		}
+		r := txResult{Hash: tx.Hash()}
+		if r.Address, err = tx.Sender(*signer); err != nil {
+			r.Error = err
+			results = append(results, r)
+			continue
+		}
+		// Check intrinsic gas
+		gas, err := core.IntrinsicGas(tx.GetData(), tx.GetAccessList(), tx.GetTo() == nil, rules.IsHomestead, rules.IsIstanbul, rules.IsShanghai)
+		if err != nil {
+			r.Error = err
+			results = append(results, r)
+			continue
+		}
+		r.IntrinsicGas = gas
+		if tx.GetGas() < gas {
+			r.Error = fmt.Errorf("%w: have %d, want %d", core.ErrIntrinsicGas, tx.GetGas(), gas)
+			results = append(results, r)
+			continue
+		}
+		// Validate fields that must not overflow
+		switch {
+		case tx.GetNonce()+1 < tx.GetNonce():
+			r.Error = fmt.Errorf("%w: nonce: %d", core.ErrNonceMax, tx.GetNonce())
+		case rules.IsLondon && tx.GetFeeCap().Lt(tx.GetTip()):
+			r.Error = fmt.Errorf("%w: address %v, tip: %s, feeCap: %s", core.ErrTipAboveFeeCap, r.Address.Hex(), tx.GetTip(), tx.GetFeeCap())
+		default:
+			if _, overflow := new(uint256.Int).MulOverflow(tx.GetFeeCap(), uint256.NewInt(tx.GetGas())); overflow {
+				r.Error = errors.New("gas * maxFeePerGas exceeds 256 bits")
+			}
+		}
+		// Check whether the init code size has been exceeded.
+		if rules.IsShanghai && tx.GetTo() == nil && len(tx.GetData()) > params.MaxInitCodeSize {
+			r.Error = errors.New("max initcode size exceeded")
+		}
+		results = append(results, r)
+	}
+	out, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return NewError(ErrorJson, fmt.Errorf("failed marshalling output: %v", err))
+	}
+	fmt.Println(string(out))
+	return nil
+}
@@ -281,6 +281,19 @@ func runCmd(ctx *cli.Context) error {
 		}
 	} else {
 		if len(code) > 0 {
+			// The code is not deployed, so the EOF validation has to be done here.
+			rules := runtimeConfig.ChainConfig.Rules(runtimeConfig.BlockNumber.Uint64(), runtimeConfig.Time.Uint64())
+			if rules.IsPrague && vm.HasEOFMagic(code) {
+				var c vm.Container
+				err := c.UnmarshalBinary(code)
+				if err == nil {
+					err = c.ValidateCode()
+				}
+				if err != nil {
+					fmt.Printf("Invalid EOF code: %v\n", err)
+					os.Exit(1)
+				}
+			}
 			statedb.SetCode(receiver, code)
 		}
 		execFunc = func() ([]byte, uint64, error) {
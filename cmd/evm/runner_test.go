@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ledgerwatch/erigon/turbo/cmdtest"
+)
+
+type testRun struct {
+	*cmdtest.TestCmd
+}
+
+func TestRunEOF(t *testing.T) {
+	// Doubles 42 in a function and returns the result, the second container declares a wrong max stack height
+	const (
+		valid   = "ef0001010008020002001300030300000000000002010100026001e10001fe602ae3000160005260206000f38001e4"
+		invalid = "ef0001010008020002001300030300000000000003010100026001e10001fe602ae3000160005260206000f38001e4"
+	)
+	for i, tc := range []struct {
+		args        []string
+		expOut      string
+		expExitCode int
+	}{
+		{
+			args:   []string{"--prestate", "./testdata/eof/genesis.json", "--code", valid, "run"},
+			expOut: "0x0000000000000000000000000000000000000000000000000000000000000054\n",
+		},
+		{
+			args:        []string{"--prestate", "./testdata/eof/genesis.json", "--code", invalid, "run"},
+			expOut:      "Invalid EOF code: code section 0: invalid max stack height: have 3, want 2\n",
+			expExitCode: 1,
+		},
+		{ // EOF is not activated in the default config
+			args:   []string{"--code", valid, "run"},
+			expOut: "0x\n error: invalid opcode: opcode 0xef not defined\n",
+		},
+	} {
+		tt := new(testRun)
+		tt.TestCmd = cmdtest.NewTestCmd(t, tt)
+		tt.Run("evm-test", tc.args...)
+		if have := string(tt.Output()); have != tc.expOut {
+			t.Fatalf("test %d: output wrong, have \n%v\nwant\n%v\n", i, have, tc.expOut)
+		}
+		tt.WaitExit()
+		if have, want := tt.ExitStatus(), tc.expExitCode; have != want {
+			t.Fatalf("test %d: wrong exit code, have %d, want %d", i, have, want)
+		}
+	}
+}
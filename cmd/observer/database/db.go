@@ -25,6 +25,20 @@ type HandshakeError struct {
 	Time       time.Time
 }
 
+// NodeRecordsFilter selects the records of the nodes for a DNS node list.
+type NodeRecordsFilter struct {
+	MaxPingTries uint
+	NetworkID    uint
+	// ForkID is a hex fork hash the nodes have announced in their ENR, any fork if empty.
+	ForkID string
+	// ClientIDPrefixes select the nodes by client, any client if empty.
+	ClientIDPrefixes []string
+	// UpdatedAfter skips the nodes whose ENR wasn't received since then if set.
+	UpdatedAfter *time.Time
+	// Limit is the maximum number of records, the most recently received first. No limit if 0.
+	Limit uint
+}
+
 type DB interface {
 	io.Closer
 
@@ -53,6 +67,8 @@ type DB interface {
 
 	UpdateForkCompatibility(ctx context.Context, id NodeID, isCompatFork bool) error
 
+	UpdateENR(ctx context.Context, id NodeID, enr string, forkID *string) error
+
 	UpdateNeighborBucketKeys(ctx context.Context, id NodeID, keys []string) error
 	FindNeighborBucketKeys(ctx context.Context, id NodeID) ([]string, error)
 
@@ -74,4 +90,5 @@ type DB interface {
 	CountClientsWithNetworkID(ctx context.Context, clientIDPrefix string, maxPingTries uint) (uint, error)
 	CountClientsWithHandshakeTransientError(ctx context.Context, clientIDPrefix string, maxPingTries uint) (uint, error)
 	EnumerateClientIDs(ctx context.Context, maxPingTries uint, networkID uint, enumFunc func(clientID *string)) error
+	FindNodeRecords(ctx context.Context, filter NodeRecordsFilter) ([]string, error)
 }
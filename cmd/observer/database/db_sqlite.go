@@ -38,6 +38,10 @@ CREATE TABLE IF NOT EXISTS nodes (
     compat_fork INTEGER,
     compat_fork_updated INTEGER,
 
+    enr TEXT,
+    fork_id TEXT,
+    enr_updated INTEGER,
+
     client_id TEXT,
     network_id INTEGER,
     eth_version INTEGER,
@@ -69,6 +73,10 @@ CREATE INDEX IF NOT EXISTS idx_nodes_compat_fork ON nodes (compat_fork);
 CREATE INDEX IF NOT EXISTS idx_nodes_network_id ON nodes (network_id);
 CREATE INDEX IF NOT EXISTS idx_nodes_handshake_retry_time ON nodes (handshake_retry_time);
 CREATE INDEX IF NOT EXISTS idx_handshake_errors_id ON handshake_errors (id);
+`
+
+	sqlFindNodesColumns = `
+SELECT name FROM pragma_table_info('nodes')
 `
 
 	sqlUpsertNodeAddr = `
@@ -195,6 +203,10 @@ UPDATE nodes SET handshake_retry_time = ? WHERE id IN (123)
 
 	sqlUpdateForkCompatibility = `
 UPDATE nodes SET compat_fork = ?, compat_fork_updated = ? WHERE id = ?
+`
+
+	sqlUpdateENR = `
+UPDATE nodes SET enr = ?, fork_id = ?, enr_updated = ? WHERE id = ?
 `
 
 	sqlUpdateNeighborBucketKeys = `
@@ -285,8 +297,24 @@ WHERE (ping_try < ?)
     AND ((network_id = ?) OR (network_id IS NULL))
     AND ((compat_fork == TRUE) OR (compat_fork IS NULL))
 `
+
+	sqlFindNodeRecords = `
+SELECT enr FROM nodes
+WHERE (enr IS NOT NULL)
+    AND (ping_try < ?)
+    AND ((network_id = ?) OR (network_id IS NULL))
+    AND ((compat_fork == TRUE) OR (compat_fork IS NULL))
+`
 )
 
+// nodesAddedColumns are the columns added to the nodes table after its creation.
+// They are added to the databases created by the previous versions on open.
+var nodesAddedColumns = []struct{ name, definition string }{
+	{"enr", "TEXT"},
+	{"fork_id", "TEXT"},
+	{"enr_updated", "INTEGER"},
+}
+
 func NewDBSQLite(filePath string) (*DBSQLite, error) {
 	db, err := sql.Open("sqlite", filePath)
 	if err != nil {
@@ -298,10 +326,46 @@ func NewDBSQLite(filePath string) (*DBSQLite, error) {
 		return nil, fmt.Errorf("failed to create the DB schema: %w", err)
 	}
 
+	err = migrateNodesColumns(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate the DB schema: %w", err)
+	}
+
 	instance := DBSQLite{db}
 	return &instance, nil
 }
 
+func migrateNodesColumns(db *sql.DB) error {
+	cursor, err := db.Query(sqlFindNodesColumns)
+	if err != nil {
+		return err
+	}
+	columns := make(map[string]bool)
+	for cursor.Next() {
+		var name string
+		if err := cursor.Scan(&name); err != nil {
+			_ = cursor.Close()
+			return err
+		}
+		columns[name] = true
+	}
+	_ = cursor.Close()
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	for _, column := range nodesAddedColumns {
+		if columns[column.name] {
+			continue
+		}
+		_, err := db.Exec(fmt.Sprintf("ALTER TABLE nodes ADD COLUMN %s %s", column.name, column.definition))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (db *DBSQLite) Close() error {
 	return db.db.Close()
 }
@@ -685,6 +749,16 @@ func (db *DBSQLite) UpdateForkCompatibility(ctx context.Context, id NodeID, isCo
 	return nil
 }
 
+func (db *DBSQLite) UpdateENR(ctx context.Context, id NodeID, enr string, forkID *string) error {
+	updated := time.Now().Unix()
+
+	_, err := db.db.ExecContext(ctx, sqlUpdateENR, enr, forkID, updated, id)
+	if err != nil {
+		return fmt.Errorf("UpdateENR failed to update a node: %w", err)
+	}
+	return nil
+}
+
 func (db *DBSQLite) UpdateNeighborBucketKeys(ctx context.Context, id NodeID, keys []string) error {
 	keysStr := strings.Join(keys, ",")
 
@@ -920,6 +994,56 @@ func (db *DBSQLite) EnumerateClientIDs(
 	return nil
 }
 
+func (db *DBSQLite) FindNodeRecords(ctx context.Context, filter NodeRecordsFilter) ([]string, error) {
+	query := sqlFindNodeRecords
+	args := []interface{}{filter.MaxPingTries, filter.NetworkID}
+
+	if filter.ForkID != "" {
+		query += "    AND (fork_id = ?)\n"
+		args = append(args, filter.ForkID)
+	}
+	if len(filter.ClientIDPrefixes) > 0 {
+		conditions := make([]string, 0, len(filter.ClientIDPrefixes))
+		for _, prefix := range filter.ClientIDPrefixes {
+			conditions = append(conditions, "(client_id LIKE ?)")
+			args = append(args, prefix+"%")
+		}
+		query += "    AND (" + strings.Join(conditions, " OR ") + ")\n"
+	}
+	if filter.UpdatedAfter != nil {
+		query += "    AND (enr_updated > ?)\n"
+		args = append(args, filter.UpdatedAfter.Unix())
+	}
+	query += "ORDER BY enr_updated DESC\n"
+	if filter.Limit > 0 {
+		query += "LIMIT ?\n"
+		args = append(args, filter.Limit)
+	}
+
+	cursor, err := db.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("FindNodeRecords failed to query: %w", err)
+	}
+	defer func() {
+		_ = cursor.Close()
+	}()
+
+	var records []string
+	for cursor.Next() {
+		var record string
+		err := cursor.Scan(&record)
+		if err != nil {
+			return nil, fmt.Errorf("FindNodeRecords failed to read data: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("FindNodeRecords failed to iterate: %w", err)
+	}
+	return records, nil
+}
+
 func stringsToAny(strValues []NodeID) []interface{} {
 	values := make([]interface{}, 0, len(strValues))
 	for _, value := range strValues {
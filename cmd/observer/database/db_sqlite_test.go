@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"net"
 	"path/filepath"
 	"testing"
@@ -39,3 +40,26 @@ func TestDBSQLiteInsertAndFind(t *testing.T) {
 	assert.Equal(t, addr.PortDisc, candidate.PortDisc)
 	assert.Equal(t, addr.PortRLPx, candidate.PortRLPx)
 }
+
+func TestDBSQLiteMigrateNodesColumns(t *testing.T) {
+	ctx := context.Background()
+	filePath := filepath.Join(t.TempDir(), "observer.sqlite")
+
+	oldDB, err := sql.Open("sqlite", filePath)
+	require.Nil(t, err)
+	_, err = oldDB.Exec("CREATE TABLE nodes (id TEXT PRIMARY KEY, ip TEXT, port_disc INTEGER, port_rlpx INTEGER, ip_v6 TEXT, ip_v6_port_disc INTEGER, ip_v6_port_rlpx INTEGER, addr_updated INTEGER NOT NULL, ping_try INTEGER NOT NULL DEFAULT 0, compat_fork INTEGER, compat_fork_updated INTEGER, client_id TEXT, network_id INTEGER, eth_version INTEGER, handshake_transient_err INTEGER NOT NULL DEFAULT 0, handshake_updated INTEGER, handshake_retry_time INTEGER, neighbor_keys TEXT, crawl_retry_time INTEGER)")
+	require.Nil(t, err)
+	require.Nil(t, oldDB.Close())
+
+	db, err := NewDBSQLite(filePath)
+	require.Nil(t, err)
+	defer func() { _ = db.Close() }()
+
+	var id NodeID = "ba85011c70bcc5c04d8607d3a0ed29aa6179c092cbdda10d5d32684fb33ed01bd94f588ca8f91ac48318087dcb02eaf36773a7a453f0eedd6742af668097b29c"
+	require.Nil(t, db.UpsertNodeAddr(ctx, id, NodeAddr{}))
+	require.Nil(t, db.UpdateENR(ctx, id, "enr:test", nil))
+
+	records, err := db.FindNodeRecords(ctx, NodeRecordsFilter{MaxPingTries: 3})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"enr:test"}, records)
+}
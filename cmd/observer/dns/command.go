@@ -0,0 +1,190 @@
+package dns
+
+import (
+	"context"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ledgerwatch/erigon/cmd/utils"
+)
+
+type CommandFlags struct {
+	DataDir      string
+	Chain        string
+	MaxPingTries uint
+	ForkID       string
+	Clients      string
+	MaxAge       time.Duration
+	Limit        uint
+
+	Domain  string
+	KeyFile string
+	Seq     uint
+	Links   string
+
+	Format string
+	TTL    uint
+	Output string
+}
+
+type Command struct {
+	command cobra.Command
+	flags   CommandFlags
+}
+
+func NewCommand() *Command {
+	command := cobra.Command{
+		Use:   "dns",
+		Short: "Sign a DNS node list (EIP-1459) of the crawled nodes",
+	}
+
+	instance := Command{
+		command: command,
+	}
+	instance.withDatadir()
+	instance.withChain()
+	instance.withMaxPingTries()
+	instance.withForkID()
+	instance.withClients()
+	instance.withMaxAge()
+	instance.withLimit()
+	instance.withDomain()
+	instance.withKeyFile()
+	instance.withSeq()
+	instance.withLinks()
+	instance.withFormat()
+	instance.withTTL()
+	instance.withOutput()
+
+	return &instance
+}
+
+func (command *Command) withDatadir() {
+	flag := utils.DataDirFlag
+	command.command.Flags().StringVar(&command.flags.DataDir, flag.Name, flag.Value.String(), flag.Usage)
+	must(command.command.MarkFlagDirname(utils.DataDirFlag.Name))
+}
+
+func (command *Command) withChain() {
+	flag := utils.ChainFlag
+	command.command.Flags().StringVar(&command.flags.Chain, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withMaxPingTries() {
+	flag := cli.UintFlag{
+		Name:  "max-ping-tries",
+		Usage: "A number of PING failures for a node to be considered dead",
+		Value: 3,
+	}
+	command.command.Flags().UintVar(&command.flags.MaxPingTries, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withForkID() {
+	flag := cli.StringFlag{
+		Name:  "fork-id",
+		Usage: "Include only the nodes announcing this fork hash in their ENR (e.g. 0xfc64ec04)",
+	}
+	command.command.Flags().StringVar(&command.flags.ForkID, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withClients() {
+	flag := cli.StringFlag{
+		Name:  "clients",
+		Usage: "Comma separated client ID prefixes to include (e.g. erigon,Geth)",
+	}
+	command.command.Flags().StringVar(&command.flags.Clients, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withMaxAge() {
+	flag := cli.DurationFlag{
+		Name:  "max-age",
+		Usage: "Skip the nodes whose ENR wasn't received within this duration (0 = no limit)",
+	}
+	command.command.Flags().DurationVar(&command.flags.MaxAge, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withLimit() {
+	flag := cli.UintFlag{
+		Name:  "limit",
+		Usage: "A maximum number of nodes in the list, the most recently seen first (0 = no limit)",
+	}
+	command.command.Flags().UintVar(&command.flags.Limit, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withDomain() {
+	flag := cli.StringFlag{
+		Name:  "domain",
+		Usage: "DNS domain of the tree root (e.g. nodes.example.org)",
+	}
+	command.command.Flags().StringVar(&command.flags.Domain, flag.Name, flag.Value, flag.Usage)
+	must(command.command.MarkFlagRequired(flag.Name))
+}
+
+func (command *Command) withKeyFile() {
+	flag := cli.StringFlag{
+		Name:  "key-file",
+		Usage: "Hex encoded secp256k1 private key file to sign the tree",
+	}
+	command.command.Flags().StringVar(&command.flags.KeyFile, flag.Name, flag.Value, flag.Usage)
+	must(command.command.MarkFlagRequired(flag.Name))
+}
+
+func (command *Command) withSeq() {
+	flag := cli.UintFlag{
+		Name:  "seq",
+		Usage: "Sequence number of the tree, has to grow on every update (default: the current unix time)",
+	}
+	command.command.Flags().UintVar(&command.flags.Seq, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withLinks() {
+	flag := cli.StringFlag{
+		Name:  "links",
+		Usage: "Comma separated enrtree:// links to other trees",
+	}
+	command.command.Flags().StringVar(&command.flags.Links, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withFormat() {
+	flag := cli.StringFlag{
+		Name:  "format",
+		Usage: "Output format: 'json' (TXT records by name) or 'zone' (zone file)",
+		Value: "json",
+	}
+	command.command.Flags().StringVar(&command.flags.Format, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withTTL() {
+	flag := cli.UintFlag{
+		Name:  "ttl",
+		Usage: "TTL of the records in seconds for the 'zone' format",
+		Value: 1800,
+	}
+	command.command.Flags().UintVar(&command.flags.TTL, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) withOutput() {
+	flag := cli.StringFlag{
+		Name:  "output",
+		Usage: "Output file path (default: stdout)",
+	}
+	command.command.Flags().StringVar(&command.flags.Output, flag.Name, flag.Value, flag.Usage)
+}
+
+func (command *Command) RawCommand() *cobra.Command {
+	return &command.command
+}
+
+func (command *Command) OnRun(runFunc func(ctx context.Context, flags CommandFlags) error) {
+	command.command.RunE = func(cmd *cobra.Command, args []string) error {
+		return runFunc(cmd.Context(), command.flags)
+	}
+}
+
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
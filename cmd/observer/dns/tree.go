@@ -0,0 +1,104 @@
+package dns
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ledgerwatch/erigon/cmd/observer/database"
+	"github.com/ledgerwatch/erigon/p2p/dnsdisc"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+)
+
+// txtStringMaxLen is the maximum length of a single character-string of a TXT record.
+const txtStringMaxLen = 255
+
+type SignedTree struct {
+	URL     string
+	Seq     uint
+	Nodes   int
+	Records map[string]string
+}
+
+// ParseForkID normalizes a hex fork hash to the format stored by the crawler.
+func ParseForkID(value string) (string, error) {
+	value = strings.TrimPrefix(strings.ToLower(value), "0x")
+	hash, err := hex.DecodeString(value)
+	if (err != nil) || (len(hash) != 4) {
+		return "", fmt.Errorf("invalid fork hash %q, expected 4 hex bytes", value)
+	}
+	return value, nil
+}
+
+// CreateSignedTree builds an EIP-1459 tree of the nodes selected by the filter and signs it.
+func CreateSignedTree(
+	ctx context.Context,
+	db database.DB,
+	filter database.NodeRecordsFilter,
+	seq uint,
+	links []string,
+	domain string,
+	key *ecdsa.PrivateKey,
+) (*SignedTree, error) {
+	records, err := db.FindNodeRecords(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*enode.Node, 0, len(records))
+	for _, record := range records {
+		node, err := enode.Parse(enode.ValidSchemes, record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the node record %s: %w", record, err)
+		}
+		nodes = append(nodes, node)
+	}
+
+	tree, err := dnsdisc.MakeTree(seq, nodes, links)
+	if err != nil {
+		return nil, err
+	}
+	url, err := tree.Sign(key, domain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign the tree: %w", err)
+	}
+
+	signedTree := SignedTree{
+		url,
+		seq,
+		len(nodes),
+		tree.ToTXT(domain),
+	}
+	return &signedTree, nil
+}
+
+// FormatJSON renders the TXT records by their names.
+func (tree *SignedTree) FormatJSON() ([]byte, error) {
+	return json.MarshalIndent(tree.Records, "", "  ")
+}
+
+// FormatZone renders the TXT records as zone file lines.
+// The values longer than a TXT character-string are split into several strings.
+func (tree *SignedTree) FormatZone(ttl uint) string {
+	names := make([]string, 0, len(tree.Records))
+	for name := range tree.Records {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var builder strings.Builder
+	for _, name := range names {
+		builder.WriteString(fmt.Sprintf("%s.\t%d\tIN\tTXT\t", name, ttl))
+		value := tree.Records[name]
+		for len(value) > txtStringMaxLen {
+			builder.WriteString(fmt.Sprintf("%q ", value[:txtStringMaxLen]))
+			value = value[txtStringMaxLen:]
+		}
+		builder.WriteString(fmt.Sprintf("%q\n", value))
+	}
+	return builder.String()
+}
@@ -0,0 +1,130 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cmd/observer/database"
+	"github.com/ledgerwatch/erigon/cmd/observer/observer/node_utils"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/p2p/enr"
+)
+
+func makeSignedNode(t *testing.T, ip string) *enode.Node {
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+
+	var record enr.Record
+	record.Set(enr.IP(net.ParseIP(ip)))
+	record.Set(enr.TCP(30303))
+	record.Set(enr.UDP(30303))
+	require.Nil(t, enode.SignV4(&record, key))
+
+	node, err := enode.New(enode.ValidSchemes, &record)
+	require.Nil(t, err)
+	return node
+}
+
+func insertNode(t *testing.T, db database.DB, node *enode.Node, clientID string, forkID string) {
+	ctx := context.Background()
+	id, err := node_utils.NodeID(node)
+	require.Nil(t, err)
+
+	require.Nil(t, db.UpsertNodeAddr(ctx, id, node_utils.MakeNodeAddr(node)))
+	require.Nil(t, db.UpdateClientID(ctx, id, clientID))
+	require.Nil(t, db.UpdateENR(ctx, id, node.String(), &forkID))
+}
+
+func TestCreateSignedTree(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.NewDBSQLite(filepath.Join(t.TempDir(), "observer.sqlite"))
+	require.Nil(t, err)
+	defer func() { _ = db.Close() }()
+
+	erigonNode := makeSignedNode(t, "10.0.1.1")
+	gethNode := makeSignedNode(t, "10.0.1.2")
+	staleNode := makeSignedNode(t, "10.0.1.3")
+	insertNode(t, db, erigonNode, "erigon/v2.43.0", "fc64ec04")
+	insertNode(t, db, gethNode, "Geth/v1.11.6", "fc64ec04")
+	insertNode(t, db, staleNode, "erigon/v2.42.0", "b96cbd13")
+
+	// a node without a received ENR is never listed
+	unknownNode := makeSignedNode(t, "10.0.1.4")
+	unknownID, err := node_utils.NodeID(unknownNode)
+	require.Nil(t, err)
+	require.Nil(t, db.UpsertNodeAddr(ctx, unknownID, node_utils.MakeNodeAddr(unknownNode)))
+
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+
+	filter := database.NodeRecordsFilter{
+		MaxPingTries:     3,
+		ForkID:           "fc64ec04",
+		ClientIDPrefixes: []string{"erigon"},
+	}
+	tree, err := CreateSignedTree(ctx, db, filter, 7, nil, "nodes.example.org", key)
+	require.Nil(t, err)
+
+	assert.Equal(t, 1, tree.Nodes)
+	assert.Equal(t, uint(7), tree.Seq)
+	assert.True(t, strings.HasPrefix(tree.URL, "enrtree://"))
+	assert.True(t, strings.HasSuffix(tree.URL, "@nodes.example.org"))
+	assert.True(t, strings.HasPrefix(tree.Records["nodes.example.org"], "enrtree-root:v1 "))
+	assert.Contains(t, recordValues(tree), erigonNode.String())
+	assert.NotContains(t, recordValues(tree), gethNode.String())
+	assert.NotContains(t, recordValues(tree), staleNode.String())
+
+	filter = database.NodeRecordsFilter{MaxPingTries: 3}
+	tree, err = CreateSignedTree(ctx, db, filter, 8, nil, "nodes.example.org", key)
+	require.Nil(t, err)
+	assert.Equal(t, 3, tree.Nodes)
+
+	// dead nodes are excluded
+	id, err := node_utils.NodeID(gethNode)
+	require.Nil(t, err)
+	for i := 0; i < 3; i++ {
+		require.Nil(t, db.UpdatePingError(ctx, id))
+	}
+	tree, err = CreateSignedTree(ctx, db, filter, 9, nil, "nodes.example.org", key)
+	require.Nil(t, err)
+	assert.Equal(t, 2, tree.Nodes)
+	assert.NotContains(t, recordValues(tree), gethNode.String())
+}
+
+func recordValues(tree *SignedTree) []string {
+	values := make([]string, 0, len(tree.Records))
+	for _, value := range tree.Records {
+		values = append(values, value)
+	}
+	return values
+}
+
+func TestSignedTreeFormatZone(t *testing.T) {
+	long := strings.Repeat("a", txtStringMaxLen+10)
+	tree := SignedTree{
+		Records: map[string]string{
+			"nodes.example.org":      "enrtree-root:v1 e=A l=B seq=1 sig=C",
+			"ABCD.nodes.example.org": long,
+		},
+	}
+
+	expected := "ABCD.nodes.example.org.\t60\tIN\tTXT\t\"" + long[:txtStringMaxLen] + "\" \"" + long[txtStringMaxLen:] + "\"\n" +
+		"nodes.example.org.\t60\tIN\tTXT\t\"enrtree-root:v1 e=A l=B seq=1 sig=C\"\n"
+	assert.Equal(t, expected, tree.FormatZone(60))
+}
+
+func TestParseForkID(t *testing.T) {
+	forkID, err := ParseForkID("0xFC64EC04")
+	require.Nil(t, err)
+	assert.Equal(t, "fc64ec04", forkID)
+
+	_, err = ParseForkID("0xfc64ec")
+	assert.NotNil(t, err)
+}
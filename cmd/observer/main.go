@@ -4,13 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon/cmd/observer/database"
+	"github.com/ledgerwatch/erigon/cmd/observer/dns"
 	"github.com/ledgerwatch/erigon/cmd/observer/observer"
 	"github.com/ledgerwatch/erigon/cmd/observer/reports"
 	"github.com/ledgerwatch/erigon/cmd/utils"
+	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/log/v3"
 )
@@ -27,7 +31,12 @@ func mainWithFlags(ctx context.Context, flags observer.CommandFlags) error {
 	}
 	defer func() { _ = db.Close() }()
 
-	discV4, err := server.Listen(ctx)
+	var transport observer.DiscTransport
+	if flags.DiscV5 {
+		transport, err = server.ListenV5(ctx)
+	} else {
+		transport, err = server.Listen(ctx)
+	}
 	if err != nil {
 		return err
 	}
@@ -54,7 +63,7 @@ func mainWithFlags(ctx context.Context, flags observer.CommandFlags) error {
 		ErigonLogPath: flags.ErigonLogPath,
 	}
 
-	crawler, err := observer.NewCrawler(discV4, db, crawlerConfig, log.Root())
+	crawler, err := observer.NewCrawler(transport, db, crawlerConfig, log.Root())
 	if err != nil {
 		return err
 	}
@@ -103,6 +112,72 @@ func reportWithFlags(ctx context.Context, flags reports.CommandFlags) error {
 	return nil
 }
 
+func dnsWithFlags(ctx context.Context, flags dns.CommandFlags) error {
+	db, err := database.NewDBSQLite(filepath.Join(flags.DataDir, "observer.sqlite"))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	key, err := crypto.LoadECDSA(flags.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load the signing key: %w", err)
+	}
+
+	filter := database.NodeRecordsFilter{
+		MaxPingTries:     flags.MaxPingTries,
+		NetworkID:        uint(params.NetworkIDByChainName(flags.Chain)),
+		ClientIDPrefixes: utils.SplitAndTrim(flags.Clients),
+		Limit:            flags.Limit,
+	}
+	if flags.ForkID != "" {
+		filter.ForkID, err = dns.ParseForkID(flags.ForkID)
+		if err != nil {
+			return err
+		}
+	}
+	if flags.MaxAge > 0 {
+		updatedAfter := time.Now().Add(-flags.MaxAge)
+		filter.UpdatedAfter = &updatedAfter
+	}
+
+	seq := flags.Seq
+	if seq == 0 {
+		seq = uint(time.Now().Unix())
+	}
+
+	tree, err := dns.CreateSignedTree(ctx, db, filter, seq, utils.SplitAndTrim(flags.Links), flags.Domain, key)
+	if err != nil {
+		return err
+	}
+
+	var output []byte
+	switch flags.Format {
+	case "json":
+		output, err = tree.FormatJSON()
+		if err != nil {
+			return err
+		}
+		output = append(output, '\n')
+	case "zone":
+		output = []byte(tree.FormatZone(flags.TTL))
+	default:
+		return fmt.Errorf("unknown format %s", flags.Format)
+	}
+
+	if flags.Output == "" {
+		_, err = os.Stdout.Write(output)
+	} else {
+		err = os.WriteFile(flags.Output, output, 0644)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "Signed %d nodes with seq %d: %s\n", tree.Nodes, tree.Seq, tree.URL)
+	return nil
+}
+
 func main() {
 	ctx, cancel := common.RootContext()
 	defer cancel()
@@ -113,6 +188,10 @@ func main() {
 	reportCommand.OnRun(reportWithFlags)
 	command.AddSubCommand(reportCommand.RawCommand())
 
+	dnsCommand := dns.NewCommand()
+	dnsCommand.OnRun(dnsWithFlags)
+	command.AddSubCommand(dnsCommand.RawCommand())
+
 	err := command.ExecuteContext(ctx, mainWithFlags)
 	if (err != nil) && !errors.Is(err, context.Canceled) {
 		utils.Fatalf("%v", err)
@@ -24,6 +24,7 @@ type CommandFlags struct {
 	ListenPort  int
 	NATDesc     string
 	NetRestrict string
+	DiscV5      bool
 
 	NodeKeyFile string
 	NodeKeyHex  string
@@ -68,6 +69,7 @@ func NewCommand() *Command {
 	instance.withListenPort()
 	instance.withNAT()
 	instance.withNetRestrict()
+	instance.withDiscV5()
 
 	instance.withNodeKeyFile()
 	instance.withNodeKeyHex()
@@ -128,6 +130,14 @@ func (command *Command) withNetRestrict() {
 	command.command.Flags().StringVar(&command.flags.NetRestrict, flag.Name, flag.Value, flag.Usage)
 }
 
+func (command *Command) withDiscV5() {
+	flag := cli.BoolFlag{
+		Name:  "discv5",
+		Usage: "Crawl using discv5 instead of discv4",
+	}
+	command.command.Flags().BoolVar(&command.flags.DiscV5, flag.Name, false, flag.Usage)
+}
+
 func (command *Command) withNodeKeyFile() {
 	flag := utils.NodeKeyFileFlag
 	command.command.Flags().StringVar(&command.flags.NodeKeyFile, flag.Name, flag.Value, flag.Usage)
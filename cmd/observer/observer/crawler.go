@@ -3,6 +3,7 @@ package observer
 import (
 	"context"
 	"crypto/ecdsa"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"sync/atomic"
@@ -21,7 +22,7 @@ import (
 )
 
 type Crawler struct {
-	transport DiscV4Transport
+	transport DiscTransport
 
 	db        database.DBRetrier
 	saveQueue *utils.TaskQueue
@@ -56,7 +57,7 @@ type CrawlerConfig struct {
 }
 
 func NewCrawler(
-	transport DiscV4Transport,
+	transport DiscTransport,
 	db database.DB,
 	config CrawlerConfig,
 	logger log.Logger,
@@ -443,6 +444,18 @@ func (crawler *Crawler) saveInterrogationResult(
 		}
 	}
 
+	if (result != nil) && (result.ENR != nil) {
+		var forkID *string
+		if result.ForkID != nil {
+			forkID = new(string)
+			*forkID = hex.EncodeToString(result.ForkID.Hash[:])
+		}
+		dbErr := crawler.db.UpdateENR(ctx, id, result.ENR.String(), forkID)
+		if dbErr != nil {
+			return dbErr
+		}
+	}
+
 	if isPingError {
 		dbErr := crawler.db.UpdatePingError(ctx, id)
 		if dbErr != nil {
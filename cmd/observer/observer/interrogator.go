@@ -16,15 +16,24 @@ import (
 	"golang.org/x/sync/semaphore"
 )
 
-type DiscV4Transport interface {
+type DiscTransport interface {
 	RequestENR(*enode.Node) (*enode.Node, error)
 	Ping(*enode.Node) error
+}
+
+type DiscV4Transport interface {
+	DiscTransport
 	FindNode(toNode *enode.Node, targetKey *ecdsa.PublicKey) ([]*enode.Node, error)
 }
 
+type DiscV5Transport interface {
+	DiscTransport
+	FindNode(toNode *enode.Node, distances []uint) ([]*enode.Node, error)
+}
+
 type Interrogator struct {
 	node       *enode.Node
-	transport  DiscV4Transport
+	transport  DiscTransport
 	forkFilter forkid.Filter
 
 	diplomat           *Diplomat
@@ -40,6 +49,8 @@ type Interrogator struct {
 
 type InterrogationResult struct {
 	Node               *enode.Node
+	ENR                *enode.Node
+	ForkID             *forkid.ID
 	IsCompatFork       *bool
 	HandshakeResult    *DiplomatResult
 	HandshakeRetryTime *time.Time
@@ -49,7 +60,7 @@ type InterrogationResult struct {
 
 func NewInterrogator(
 	node *enode.Node,
-	transport DiscV4Transport,
+	transport DiscTransport,
 	forkFilter forkid.Filter,
 	diplomat *Diplomat,
 	handshakeRetryTime *time.Time,
@@ -132,44 +143,84 @@ func (interrogator *Interrogator) Run(ctx context.Context) (*InterrogationResult
 		}
 	}
 
+	// FindNode
+	var keys []*ecdsa.PublicKey
+	var peers []*enode.Node
+	if transport, isV5 := interrogator.transport.(DiscV5Transport); isV5 {
+		peers, err = interrogator.findNeighborsV5(ctx, transport)
+	} else {
+		keys, peers, err = interrogator.findNeighborsV4(ctx, interrogator.transport.(DiscV4Transport))
+	}
+	if err != nil {
+		var interrogationErr *InterrogationError
+		if errors.As(err, &interrogationErr) {
+			return nil, interrogationErr
+		}
+		return nil, NewInterrogationError(InterrogationErrorKeygen, err)
+	}
+
+	result := InterrogationResult{
+		interrogator.node,
+		enr,
+		forkID,
+		isCompatFork,
+		handshakeResult,
+		handshakeRetryTime,
+		keys,
+		peers,
+	}
+	return &result, nil
+}
+
+func (interrogator *Interrogator) findNeighborsV4(ctx context.Context, transport DiscV4Transport) ([]*ecdsa.PublicKey, []*enode.Node, error) {
 	// keygen
 	keys, err := interrogator.keygen(ctx)
 	if err != nil {
-		return nil, NewInterrogationError(InterrogationErrorKeygen, err)
+		return nil, nil, err
 	}
 
-	// FindNode
 	peersByID := make(map[enode.ID]*enode.Node)
 	for _, key := range keys {
-		neighbors, err := interrogator.findNode(ctx, key)
+		key := key
+		neighbors, err := interrogator.findNode(ctx, func() ([]*enode.Node, error) {
+			return transport.FindNode(interrogator.node, key)
+		})
 		if err != nil {
-			if isFindNodeTimeoutError(err) {
-				return nil, NewInterrogationError(InterrogationErrorFindNodeTimeout, err)
-			}
-			return nil, NewInterrogationError(InterrogationErrorFindNode, err)
-		}
-
-		for _, node := range neighbors {
-			if node.Incomplete() {
-				continue
-			}
-			peersByID[node.ID()] = node
+			return nil, nil, err
 		}
+		addNeighbors(peersByID, neighbors)
 
 		utils.Sleep(ctx, 1*time.Second)
 	}
 
-	peers := valuesOfIDToNodeMap(peersByID)
+	return keys, valuesOfIDToNodeMap(peersByID), nil
+}
 
-	result := InterrogationResult{
-		interrogator.node,
-		isCompatFork,
-		handshakeResult,
-		handshakeRetryTime,
-		keys,
-		peers,
+// findNeighborsV5 requests the nodes of the top buckets by their log distances,
+// the same buckets the v4 keygen keys are generated for.
+func (interrogator *Interrogator) findNeighborsV5(ctx context.Context, transport DiscV5Transport) ([]*enode.Node, error) {
+	const bucketsCount = 16
+	const distancesPerRequest = 3
+
+	peersByID := make(map[enode.ID]*enode.Node)
+	for distance := uint(256); distance > 256-bucketsCount; distance -= distancesPerRequest {
+		distances := make([]uint, 0, distancesPerRequest)
+		for d := distance; (d > distance-distancesPerRequest) && (d > 256-bucketsCount); d-- {
+			distances = append(distances, d)
+		}
+
+		neighbors, err := interrogator.findNode(ctx, func() ([]*enode.Node, error) {
+			return transport.FindNode(interrogator.node, distances)
+		})
+		if err != nil {
+			return nil, err
+		}
+		addNeighbors(peersByID, neighbors)
+
+		utils.Sleep(ctx, 1*time.Second)
 	}
-	return &result, nil
+
+	return valuesOfIDToNodeMap(peersByID), nil
 }
 
 func (interrogator *Interrogator) keygen(ctx context.Context) ([]*ecdsa.PublicKey, error) {
@@ -198,17 +249,28 @@ func (interrogator *Interrogator) keygen(ctx context.Context) ([]*ecdsa.PublicKe
 	return keys, ctx.Err()
 }
 
-func (interrogator *Interrogator) findNode(ctx context.Context, targetKey *ecdsa.PublicKey) ([]*enode.Node, error) {
+func (interrogator *Interrogator) findNode(ctx context.Context, findNode func() ([]*enode.Node, error)) ([]*enode.Node, error) {
 	delayForAttempt := func(attempt int) time.Duration { return 2 * time.Second }
 	resultAny, err := utils.Retry(ctx, 2, delayForAttempt, isFindNodeTimeoutError, interrogator.log, "FindNode", func(ctx context.Context) (interface{}, error) {
-		return interrogator.transport.FindNode(interrogator.node, targetKey)
+		return findNode()
 	})
 
-	if resultAny == nil {
-		return nil, err
+	if err != nil {
+		if isFindNodeTimeoutError(err) {
+			return nil, NewInterrogationError(InterrogationErrorFindNodeTimeout, err)
+		}
+		return nil, NewInterrogationError(InterrogationErrorFindNode, err)
+	}
+	return resultAny.([]*enode.Node), nil
+}
+
+func addNeighbors(peersByID map[enode.ID]*enode.Node, neighbors []*enode.Node) {
+	for _, node := range neighbors {
+		if node.Incomplete() {
+			continue
+		}
+		peersByID[node.ID()] = node
 	}
-	result := resultAny.([]*enode.Node)
-	return result, err
 }
 
 func isFindNodeTimeoutError(err error) bool {
@@ -55,6 +55,8 @@ import (
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/paths"
 	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi/remoteproto"
 	"github.com/ledgerwatch/erigon/node"
 	"github.com/ledgerwatch/erigon/node/nodecfg"
 	"github.com/ledgerwatch/erigon/rpc"
@@ -97,6 +99,8 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 	rootCmd.PersistentFlags().BoolVar(&cfg.WebsocketEnabled, "ws", false, "Enable Websockets - Same port as HTTP")
 	rootCmd.PersistentFlags().BoolVar(&cfg.WebsocketCompression, "ws.compression", false, "Enable Websocket compression (RFC 7692)")
 	rootCmd.PersistentFlags().StringVar(&cfg.RpcAllowListFilePath, utils.RpcAccessListFlag.Name, "", "Specify granular (method-by-method) API allowlist")
+	rootCmd.PersistentFlags().StringVar(&cfg.Ethstats, utils.EthStatsURLFlag.Name, "", utils.EthStatsURLFlag.Usage)
+	rootCmd.PersistentFlags().StringVar(&cfg.RpcAuthFilePath, utils.RpcAuthFlag.Name, "", utils.RpcAuthFlag.Usage)
 	rootCmd.PersistentFlags().UintVar(&cfg.RpcBatchConcurrency, utils.RpcBatchConcurrencyFlag.Name, 2, utils.RpcBatchConcurrencyFlag.Usage)
 	rootCmd.PersistentFlags().BoolVar(&cfg.RpcStreamingDisable, utils.RpcStreamingDisableFlag.Name, false, utils.RpcStreamingDisableFlag.Usage)
 	rootCmd.PersistentFlags().IntVar(&cfg.DBReadConcurrency, utils.DBReadConcurrencyFlag.Name, utils.DBReadConcurrencyFlag.Value, utils.DBReadConcurrencyFlag.Usage)
@@ -120,6 +124,9 @@ func RootCommand() (*cobra.Command, *httpcfg.HttpCfg) {
 	rootCmd.PersistentFlags().DurationVar(&cfg.EvmCallTimeout, "rpc.evmtimeout", rpccfg.DefaultEvmCallTimeout, "Maximum amount of time to wait for the answer from EVM call.")
 	rootCmd.PersistentFlags().IntVar(&cfg.BatchLimit, utils.RpcBatchLimit.Name, utils.RpcBatchLimit.Value, utils.RpcBatchLimit.Usage)
 	rootCmd.PersistentFlags().IntVar(&cfg.ReturnDataLimit, utils.RpcReturnDataLimit.Name, utils.RpcReturnDataLimit.Value, utils.RpcReturnDataLimit.Usage)
+	rootCmd.PersistentFlags().DurationVar(&cfg.RpcFiltersConfig.Timeout, utils.RpcFiltersTimeoutFlag.Name, utils.RpcFiltersTimeoutFlag.Value, utils.RpcFiltersTimeoutFlag.Usage)
+	rootCmd.PersistentFlags().IntVar(&cfg.RpcFiltersConfig.MaxFilters, utils.RpcFiltersMaxFlag.Name, utils.RpcFiltersMaxFlag.Value, utils.RpcFiltersMaxFlag.Usage)
+	rootCmd.PersistentFlags().IntVar(&cfg.RpcFiltersConfig.MaxItems, utils.RpcFiltersMaxItemsFlag.Name, utils.RpcFiltersMaxItemsFlag.Value, utils.RpcFiltersMaxItemsFlag.Usage)
 
 	if err := rootCmd.MarkPersistentFlagFilename("rpc.accessList", "json"); err != nil {
 		panic(err)
@@ -246,8 +253,8 @@ func checkDbCompatibility(ctx context.Context, db kv.RoDB) error {
 
 func EmbeddedServices(ctx context.Context,
 	erigonDB kv.RoDB, stateCacheCfg kvcache.CoherentConfig,
-	blockReader services.FullBlockReader, ethBackendServer remote.ETHBACKENDServer, txPoolServer txpool.TxpoolServer,
-	miningServer txpool.MiningServer, stateDiffClient StateChangesClient,
+	blockReader services.FullBlockReader, ethBackendServer *privateapi.EthBackendServer, txPoolServer txpool.TxpoolServer,
+	miningServer txpool.MiningServer, cliqueServer remoteproto.CliqueServer, stateDiffClient StateChangesClient, filtersCfg rpchelper.FiltersConfig,
 ) (eth rpchelper.ApiBackend, txPool txpool.TxpoolClient, mining txpool.MiningClient, stateCache kvcache.Cache, ff *rpchelper.Filters, err error) {
 	if stateCacheCfg.CacheSize > 0 {
 		// notification about new blocks (state stream) doesn't work now inside erigon - because
@@ -263,10 +270,11 @@ func EmbeddedServices(ctx context.Context,
 
 	directClient := direct.NewEthBackendClientDirect(ethBackendServer)
 
-	eth = rpcservices.NewRemoteBackend(directClient, erigonDB, blockReader)
+	eth = rpcservices.NewRemoteBackend(directClient, remoteproto.NewEngineClientDirect(ethBackendServer), remoteproto.NewPeersClientDirect(ethBackendServer), remoteproto.NewCliqueClientDirect(cliqueServer), erigonDB, blockReader)
 	txPool = direct.NewTxPoolClient(txPoolServer)
 	mining = direct.NewMiningClient(miningServer)
 	ff = rpchelper.New(ctx, eth, txPool, mining, func() {})
+	ff.SetConfig(filtersCfg)
 
 	return
 }
@@ -274,7 +282,7 @@ func EmbeddedServices(ctx context.Context,
 // RemoteServices - use when RPCDaemon run as independent process. Still it can use --datadir flag to enable
 // `cfg.WithDatadir` (mode when it on 1 machine with Erigon)
 func RemoteServices(ctx context.Context, cfg httpcfg.HttpCfg, logger log.Logger, rootCancel context.CancelFunc) (
-	db kv.RoDB, borDb kv.RoDB,
+	db kv.RoDB, consensusDb kv.RoDB,
 	eth rpchelper.ApiBackend, txPool txpool.TxpoolClient, mining txpool.MiningClient,
 	stateCache kvcache.Cache, blockReader services.FullBlockReader,
 	ff *rpchelper.Filters, agg *libstate.AggregatorV3, err error) {
@@ -299,6 +307,7 @@ func RemoteServices(ctx context.Context, cfg httpcfg.HttpCfg, logger log.Logger,
 
 	// Configure DB first
 	var allSnapshots *snapshotsync.RoSnapshots
+	var cc *chain.Config
 	onNewSnapshot := func() {}
 	if cfg.WithDatadir {
 		var rwKv kv.RwDB
@@ -314,7 +323,6 @@ func RemoteServices(ctx context.Context, cfg httpcfg.HttpCfg, logger log.Logger,
 		}
 		db = rwKv
 
-		var cc *chain.Config
 		if err := db.View(context.Background(), func(tx kv.Tx) error {
 			genesisBlock, err := rawdb.ReadBlockByNumber(tx, 0)
 			if err != nil {
@@ -412,24 +420,27 @@ func RemoteServices(ctx context.Context, cfg httpcfg.HttpCfg, logger log.Logger,
 		db = remoteKv
 	}
 	if cfg.WithDatadir {
-		// bor (consensus) specific db
-		var borKv kv.RoDB
-		borDbPath := filepath.Join(cfg.DataDir, "bor")
+		// consensus specific db: bor spans or clique snapshots
+		var consensusKv kv.RoDB
+		consensusDbPath := filepath.Join(cfg.DataDir, "bor")
+		if cc.Clique != nil {
+			consensusDbPath = filepath.Join(cfg.DataDir, "clique", "db")
+		}
 		{
 			// ensure db exist
-			tmpDb, err := kv2.NewMDBX(logger).Path(borDbPath).Label(kv.ConsensusDB).Open()
+			tmpDb, err := kv2.NewMDBX(logger).Path(consensusDbPath).Label(kv.ConsensusDB).Open()
 			if err != nil {
 				return nil, nil, nil, nil, nil, nil, nil, ff, nil, err
 			}
 			tmpDb.Close()
 		}
-		log.Trace("Creating consensus db", "path", borDbPath)
-		borKv, err = kv2.NewMDBX(logger).Path(borDbPath).Label(kv.ConsensusDB).Readonly().Open()
+		log.Trace("Creating consensus db", "path", consensusDbPath)
+		consensusKv, err = kv2.NewMDBX(logger).Path(consensusDbPath).Label(kv.ConsensusDB).Readonly().Open()
 		if err != nil {
 			return nil, nil, nil, nil, nil, nil, nil, ff, nil, err
 		}
 		// Skip the compatibility check, until we have a schema in erigon-lib
-		borDb = borKv
+		consensusDb = consensusKv
 	} else {
 		if cfg.StateCache.CacheSize > 0 {
 			stateCache = kvcache.New(cfg.StateCache)
@@ -458,7 +469,7 @@ func RemoteServices(ctx context.Context, cfg httpcfg.HttpCfg, logger log.Logger,
 		blockReader = snapshotsync.NewRemoteBlockReader(remoteBackendClient)
 	}
 
-	remoteEth := rpcservices.NewRemoteBackend(remoteBackendClient, db, blockReader)
+	remoteEth := rpcservices.NewRemoteBackend(remoteBackendClient, remoteproto.NewEngineClient(conn), remoteproto.NewPeersClient(conn), remoteproto.NewCliqueClient(conn), db, blockReader)
 	blockReader = remoteEth
 	eth = remoteEth
 	go func() {
@@ -477,7 +488,8 @@ func RemoteServices(ctx context.Context, cfg httpcfg.HttpCfg, logger log.Logger,
 	}()
 
 	ff = rpchelper.New(ctx, eth, txPool, mining, onNewSnapshot)
-	return db, borDb, eth, txPool, mining, stateCache, blockReader, ff, agg, err
+	ff.SetConfig(cfg.RpcFiltersConfig)
+	return db, consensusDb, eth, txPool, mining, stateCache, blockReader, ff, agg, err
 }
 
 func StartRpcServer(ctx context.Context, cfg httpcfg.HttpCfg, rpcAPI []rpc.API, authAPI []rpc.API) error {
@@ -509,6 +521,17 @@ func startRegularRpcServer(ctx context.Context, cfg httpcfg.HttpCfg, rpcAPI []rp
 	}
 	srv.SetAllowList(allowListForRPC)
 
+	authForRPC, err := parseAuthForRPC(cfg.RpcAuthFilePath)
+	if err != nil {
+		return err
+	}
+	if authForRPC != nil {
+		if cfg.TCPServerEnabled {
+			return fmt.Errorf("--%s can not be combined with --tcp, whose connections carry no credentials", utils.RpcAuthFlag.Name)
+		}
+		srv.SetAuth(authForRPC)
+	}
+
 	srv.SetBatchLimit(cfg.BatchLimit)
 
 	var defaultAPIList []rpc.API
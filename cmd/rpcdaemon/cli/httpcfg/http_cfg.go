@@ -7,11 +7,13 @@ import (
 	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	"github.com/ledgerwatch/erigon/rpc/rpccfg"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 )
 
 type HttpCfg struct {
 	Enabled                  bool
 	PrivateApiAddr           string
+	Ethstats                 string // Reporting URL of a ethstats service (nodename:secret@host:port)
 	GraphQLEnabled           bool
 	WithDatadir              bool // Erigon's database can be read by separated processes on same machine - in read-only mode - with full support of transactions. It will share same "OS PageCache" with Erigon process.
 	DataDir                  string
@@ -33,6 +35,7 @@ type HttpCfg struct {
 	WebsocketEnabled         bool
 	WebsocketCompression     bool
 	RpcAllowListFilePath     string
+	RpcAuthFilePath          string // API keys and JWT secrets of the public RPC, see rpc.AuthConfig
 	RpcBatchConcurrency      uint
 	RpcStreamingDisable      bool
 	DBReadConcurrency        int
@@ -64,4 +67,6 @@ type HttpCfg struct {
 
 	BatchLimit      int // Maximum number of requests in a batch
 	ReturnDataLimit int // Maximum number of bytes returned from calls (like eth_call)
+
+	RpcFiltersConfig rpchelper.FiltersConfig // Limits of the polling filters (eth_newFilter and alike)
 }
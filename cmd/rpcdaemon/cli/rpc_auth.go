@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+func parseAuthForRPC(path string) (*rpc.Auth, error) {
+	path = strings.TrimSpace(path)
+	if path == "" { // no file is provided
+		return nil, nil
+	}
+
+	fileContents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg rpc.AuthConfig
+	if err = json.Unmarshal(fileContents, &cfg); err != nil {
+		return nil, err
+	}
+
+	return rpc.NewAuth(cfg)
+}
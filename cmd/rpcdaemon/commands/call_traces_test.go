@@ -2,6 +2,7 @@ package commands
 
 import (
 	"context"
+	"encoding/json"
 	"sync"
 	"testing"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/valyala/fastjson"
 
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/cli/httpcfg"
+	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/rpc/rpccfg"
 
 	"github.com/ledgerwatch/erigon/common/hexutil"
@@ -75,7 +77,7 @@ func TestCallTraceOneByOne(t *testing.T) {
 		ToBlock:   (*hexutil.Uint64)(&toBlock),
 		ToAddress: []*common.Address{&toAddress1},
 	}
-	if err = api.Filter(context.Background(), traceReq1, stream, new(bool)); err != nil {
+	if err = api.Filter(context.Background(), traceReq1, new(bool), stream); err != nil {
 		t.Fatalf("trace_filter failed: %v", err)
 	}
 	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, blockNumbersFromTraces(t, stream.Buffer()))
@@ -120,7 +122,7 @@ func TestCallTraceUnwind(t *testing.T) {
 		ToBlock:   (*hexutil.Uint64)(&toBlock),
 		ToAddress: []*common.Address{&toAddress1},
 	}
-	if err = api.Filter(context.Background(), traceReq1, stream, new(bool)); err != nil {
+	if err = api.Filter(context.Background(), traceReq1, new(bool), stream); err != nil {
 		t.Fatalf("trace_filter failed: %v", err)
 	}
 	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, blockNumbersFromTraces(t, stream.Buffer()))
@@ -135,7 +137,7 @@ func TestCallTraceUnwind(t *testing.T) {
 		ToBlock:   (*hexutil.Uint64)(&toBlock),
 		ToAddress: []*common.Address{&toAddress1},
 	}
-	if err = api.Filter(context.Background(), traceReq2, stream, new(bool)); err != nil {
+	if err = api.Filter(context.Background(), traceReq2, new(bool), stream); err != nil {
 		t.Fatalf("trace_filter failed: %v", err)
 	}
 	assert.Equal(t, []int{1, 2, 3, 4, 5, 11, 12}, blockNumbersFromTraces(t, stream.Buffer()))
@@ -151,7 +153,7 @@ func TestCallTraceUnwind(t *testing.T) {
 		ToBlock:   (*hexutil.Uint64)(&toBlock),
 		ToAddress: []*common.Address{&toAddress1},
 	}
-	if err = api.Filter(context.Background(), traceReq3, stream, new(bool)); err != nil {
+	if err = api.Filter(context.Background(), traceReq3, new(bool), stream); err != nil {
 		t.Fatalf("trace_filter failed: %v", err)
 	}
 	assert.Equal(t, []int{12, 13, 14, 15, 16, 17, 18, 19, 20}, blockNumbersFromTraces(t, stream.Buffer()))
@@ -183,12 +185,40 @@ func TestFilterNoAddresses(t *testing.T) {
 		FromBlock: (*hexutil.Uint64)(&fromBlock),
 		ToBlock:   (*hexutil.Uint64)(&toBlock),
 	}
-	if err = api.Filter(context.Background(), traceReq1, stream, new(bool)); err != nil {
+	if err = api.Filter(context.Background(), traceReq1, new(bool), stream); err != nil {
 		t.Fatalf("trace_filter failed: %v", err)
 	}
 	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, blockNumbersFromTraces(t, stream.Buffer()))
 }
 
+// TestFilterWireFormat calls trace_filter over JSON-RPC. The server only streams the result of a method whose
+// last parameter is the *jsoniter.Stream, so gasBailOut has to come before it: otherwise the stream is decoded as
+// a parameter, absent from the request, and Filter writes to nil.
+func TestFilterWireFormat(t *testing.T) {
+	m := stages.Mock(t)
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 10, func(i int, gen *core.BlockGen) {
+		gen.SetCoinbase(common.Address{1})
+	}, false /* intermediateHashes */)
+	require.NoError(t, err)
+	require.NoError(t, m.InsertChain(chain))
+	agg := m.HistoryV3Components()
+	br := snapshotsync.NewBlockReaderWithSnapshots(m.BlockSnapshots, m.TransactionsV3)
+	api := NewTraceAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), br, agg, false, rpccfg.DefaultEvmCallTimeout, m.Engine, m.Dirs), m.DB, &httpcfg.HttpCfg{})
+
+	srv := rpc.NewServer(50, false, false)
+	require.NoError(t, srv.RegisterName("trace", TraceAPI(api)))
+	client := rpc.DialInProc(srv)
+	defer srv.Stop()
+	defer client.Close()
+
+	req := json.RawMessage(`{"fromBlock":"0x1","toBlock":"0xa"}`)
+	var result json.RawMessage
+	require.NoError(t, client.CallContext(context.Background(), &result, "trace_filter", req))
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, blockNumbersFromTraces(t, result))
+	require.NoError(t, client.CallContext(context.Background(), &result, "trace_filter", req, true))
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, blockNumbersFromTraces(t, result))
+}
+
 func TestFilterAddressIntersection(t *testing.T) {
 	m := stages.Mock(t)
 	agg := m.HistoryV3Components()
@@ -234,7 +264,7 @@ func TestFilterAddressIntersection(t *testing.T) {
 			ToAddress:   []*common.Address{&m.Address, &toAddress2},
 			Mode:        TraceFilterModeIntersection,
 		}
-		if err = api.Filter(context.Background(), traceReq1, stream, new(bool)); err != nil {
+		if err = api.Filter(context.Background(), traceReq1, new(bool), stream); err != nil {
 			t.Fatalf("trace_filter failed: %v", err)
 		}
 		assert.Equal(t, []int{6, 7, 8, 9, 10}, blockNumbersFromTraces(t, stream.Buffer()))
@@ -250,7 +280,7 @@ func TestFilterAddressIntersection(t *testing.T) {
 			ToAddress:   []*common.Address{&toAddress1, &m.Address},
 			Mode:        TraceFilterModeIntersection,
 		}
-		if err = api.Filter(context.Background(), traceReq1, stream, new(bool)); err != nil {
+		if err = api.Filter(context.Background(), traceReq1, new(bool), stream); err != nil {
 			t.Fatalf("trace_filter failed: %v", err)
 		}
 		assert.Equal(t, []int{1, 2, 3, 4, 5}, blockNumbersFromTraces(t, stream.Buffer()))
@@ -266,7 +296,7 @@ func TestFilterAddressIntersection(t *testing.T) {
 			FromAddress: []*common.Address{&toAddress2, &toAddress1, &other},
 			Mode:        TraceFilterModeIntersection,
 		}
-		if err = api.Filter(context.Background(), traceReq1, stream, new(bool)); err != nil {
+		if err = api.Filter(context.Background(), traceReq1, new(bool), stream); err != nil {
 			t.Fatalf("trace_filter failed: %v", err)
 		}
 		require.Empty(t, blockNumbersFromTraces(t, stream.Buffer()))
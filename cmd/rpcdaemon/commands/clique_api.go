@@ -0,0 +1,201 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon-lib/chain"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+
+	"github.com/ledgerwatch/erigon/consensus/clique"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+)
+
+// cliqueStatusBlocks is the number of most recent blocks clique_status reports on.
+const cliqueStatusBlocks = 64
+
+var errNotClique = errors.New("clique is not the consensus engine of the chain")
+
+// CliqueAPI Clique specific routines
+type CliqueAPI interface {
+	GetSnapshot(ctx context.Context, number *rpc.BlockNumber) (*clique.Snapshot, error)
+	GetSnapshotAtHash(ctx context.Context, hash libcommon.Hash) (*clique.Snapshot, error)
+	GetSigners(ctx context.Context, number *rpc.BlockNumber) ([]libcommon.Address, error)
+	GetSignersAtHash(ctx context.Context, hash libcommon.Hash) ([]libcommon.Address, error)
+	Proposals(ctx context.Context) (map[libcommon.Address]bool, error)
+	Propose(ctx context.Context, address libcommon.Address, auth bool) error
+	Discard(ctx context.Context, address libcommon.Address) error
+	Status(ctx context.Context) (*clique.Status, error)
+}
+
+// CliqueImpl is implementation of the CliqueAPI interface
+type CliqueImpl struct {
+	*BaseAPI
+	db         kv.RoDB // the chain db
+	cliqueDb   kv.RoDB // the consensus db
+	ethBackend rpchelper.ApiBackend
+}
+
+// NewCliqueAPI returns CliqueImpl instance
+func NewCliqueAPI(base *BaseAPI, db kv.RoDB, cliqueDb kv.RoDB, eth rpchelper.ApiBackend) *CliqueImpl {
+	return &CliqueImpl{
+		BaseAPI:    base,
+		db:         db,
+		cliqueDb:   cliqueDb,
+		ethBackend: eth,
+	}
+}
+
+// GetSnapshot retrieves the state snapshot at a given block.
+func (api *CliqueImpl) GetSnapshot(ctx context.Context, number *rpc.BlockNumber) (*clique.Snapshot, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	header, err := api.headerByNumber(tx, number)
+	if err != nil {
+		return nil, err
+	}
+	return api.snapshot(ctx, tx, header)
+}
+
+// GetSnapshotAtHash retrieves the state snapshot at a given block.
+func (api *CliqueImpl) GetSnapshotAtHash(ctx context.Context, hash libcommon.Hash) (*clique.Snapshot, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	header, err := api._blockReader.HeaderByHash(ctx, tx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return api.snapshot(ctx, tx, header)
+}
+
+// GetSigners retrieves the list of authorized signers at the specified block.
+func (api *CliqueImpl) GetSigners(ctx context.Context, number *rpc.BlockNumber) ([]libcommon.Address, error) {
+	snap, err := api.GetSnapshot(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return snap.GetSigners(), nil
+}
+
+// GetSignersAtHash retrieves the list of authorized signers at the specified block.
+func (api *CliqueImpl) GetSignersAtHash(ctx context.Context, hash libcommon.Hash) ([]libcommon.Address, error) {
+	snap, err := api.GetSnapshotAtHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return snap.GetSigners(), nil
+}
+
+// Proposals returns the current proposals the node tries to uphold and vote on.
+func (api *CliqueImpl) Proposals(ctx context.Context) (map[libcommon.Address]bool, error) {
+	return api.ethBackend.CliqueProposals(ctx)
+}
+
+// Propose injects a new authorization proposal that the signer will attempt to
+// push through.
+func (api *CliqueImpl) Propose(ctx context.Context, address libcommon.Address, auth bool) error {
+	return api.ethBackend.CliquePropose(ctx, address, auth)
+}
+
+// Discard drops a currently running proposal, stopping the signer from casting
+// further votes (either for or against).
+func (api *CliqueImpl) Discard(ctx context.Context, address libcommon.Address) error {
+	return api.ethBackend.CliqueDiscard(ctx, address)
+}
+
+// Status returns the signing activity of the current signers over the last 64
+// blocks and the percentage of in-turn blocks among them.
+func (api *CliqueImpl) Status(ctx context.Context) (*clique.Status, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	header := rawdb.ReadCurrentHeader(tx)
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	snap, err := api.snapshot(ctx, tx, header)
+	if err != nil {
+		return nil, err
+	}
+
+	numBlocks := uint64(cliqueStatusBlocks)
+	if header.Number.Uint64() < numBlocks {
+		numBlocks = header.Number.Uint64()
+	}
+	headers := make([]*types.Header, numBlocks)
+	for i := numBlocks; i > 0; i-- {
+		headers[i-1] = header
+		if header, err = api._blockReader.Header(ctx, tx, header.ParentHash, header.Number.Uint64()-1); err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, errUnknownBlock
+		}
+	}
+	return snap.Status(headers)
+}
+
+func (api *CliqueImpl) headerByNumber(tx kv.Tx, number *rpc.BlockNumber) (*types.Header, error) {
+	var header *types.Header
+	var err error
+	if number == nil || *number == rpc.LatestBlockNumber {
+		header = rawdb.ReadCurrentHeader(tx)
+	} else if header, err = api.headerByRPCNumber(*number, tx); err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return header, nil
+}
+
+func (api *CliqueImpl) snapshot(ctx context.Context, tx kv.Tx, header *types.Header) (*clique.Snapshot, error) {
+	config, err := api.cliqueConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	cliqueTx, err := api.cliqueDb.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cliqueTx.Rollback()
+
+	getHeader := func(hash libcommon.Hash, number uint64) (*types.Header, error) {
+		return api._blockReader.Header(ctx, tx, hash, number)
+	}
+	return clique.LoadSnapshot(config, cliqueTx, getHeader, header.Number.Uint64(), header.Hash())
+}
+
+func (api *CliqueImpl) cliqueConfig(tx kv.Tx) (*chain.CliqueConfig, error) {
+	cc, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+	if cc.Clique == nil {
+		return nil, errNotClique
+	}
+	if api.cliqueDb == nil {
+		return nil, fmt.Errorf("clique database is not available")
+	}
+	return cc.Clique, nil
+}
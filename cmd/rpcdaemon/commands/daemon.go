@@ -13,7 +13,7 @@ import (
 )
 
 // APIList describes the list of available RPC apis
-func APIList(db kv.RoDB, borDb kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.TxpoolClient, mining txpool.MiningClient,
+func APIList(db kv.RoDB, consensusDb kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.TxpoolClient, mining txpool.MiningClient,
 	filters *rpchelper.Filters, stateCache kvcache.Cache,
 	blockReader services.FullBlockReader, agg *libstate.AggregatorV3, cfg httpcfg.HttpCfg, engine consensus.EngineReader,
 ) (list []rpc.API) {
@@ -28,7 +28,8 @@ func APIList(db kv.RoDB, borDb kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.
 	dbImpl := NewDBAPIImpl() /* deprecated */
 	adminImpl := NewAdminAPI(eth)
 	parityImpl := NewParityAPIImpl(db)
-	borImpl := NewBorAPI(base, db, borDb) // bor (consensus) specific
+	borImpl := NewBorAPI(base, db, consensusDb)            // bor (consensus) specific
+	cliqueImpl := NewCliqueAPI(base, db, consensusDb, eth) // clique (consensus) specific
 	otsImpl := NewOtterscanAPI(base, db)
 	gqlImpl := NewGraphQLAPI(base, db)
 
@@ -106,6 +107,13 @@ func APIList(db kv.RoDB, borDb kv.RoDB, eth rpchelper.ApiBackend, txPool txpool.
 				Service:   BorAPI(borImpl),
 				Version:   "1.0",
 			})
+		case "clique":
+			list = append(list, rpc.API{
+				Namespace: "clique",
+				Public:    true,
+				Service:   CliqueAPI(cliqueImpl),
+				Version:   "1.0",
+			})
 		case "admin":
 			list = append(list, rpc.API{
 				Namespace: "admin",
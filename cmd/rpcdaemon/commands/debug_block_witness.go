@@ -0,0 +1,207 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+
+	"github.com/ledgerwatch/erigon/common/changeset"
+	"github.com/ledgerwatch/erigon/consensus"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/stateless"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/eth/stagedsync"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/turbo/trie"
+)
+
+// GetBlockWitness implements debug_getBlockWitness. Returns the block together with the witness of the parent state
+// it touches, which is enough to execute the block and check its state root without access to the state,
+// see stateless.Verify.
+//
+// Erigon keeps only the latest state trie, so the parent trie is rebuilt by unwinding the hashed state and intermediate
+// hashes from the head in memory. The cost of that grows with the distance from the head, hence, like eth_getProof,
+// only blocks whose parent is within maxGetProofRewindBlockCount blocks of the head are served. Erigon3 has no
+// intermediate hashes to unwind and is not supported.
+func (api *PrivateDebugAPIImpl) GetBlockWitness(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*stateless.BlockWitness, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if api.historyV3(tx) {
+		return nil, fmt.Errorf("debug_getBlockWitness is not supported by Erigon3")
+	}
+
+	blockNr, hash, _, err := rpchelper.GetBlockNumber(blockNrOrHash, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	if blockNr == 0 {
+		return nil, fmt.Errorf("genesis block has no witness")
+	}
+	latestBlock, err := rpchelper.GetLatestBlockNumber(tx)
+	if err != nil {
+		return nil, err
+	}
+	if latestBlock < blockNr {
+		// shouldn't happen, but check anyway
+		return nil, fmt.Errorf("block number is in the future latest=%d requested=%d", latestBlock, blockNr)
+	}
+	if latestBlock-(blockNr-1) > maxGetProofRewindBlockCount {
+		return nil, fmt.Errorf("requested block is too old, its parent must be within %d blocks of the head block number (currently %d)", maxGetProofRewindBlockCount, latestBlock)
+	}
+
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+	engine, ok := api.engine().(consensus.Engine)
+	if !ok {
+		return nil, fmt.Errorf("engine does not support block execution")
+	}
+	block, err := api.blockWithSenders(tx, hash, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block not found")
+	}
+	parent, err := api._blockReader.Header(ctx, tx, block.ParentHash(), blockNr-1)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		return nil, fmt.Errorf("parent header not found")
+	}
+
+	// Execute the block to find out which parts of the parent state it touches
+	reader, err := rpchelper.CreateHistoryStateReader(tx, blockNr, 0, false, chainConfig.ChainName)
+	if err != nil {
+		return nil, err
+	}
+	recorder := stateless.NewRecorder(reader)
+	oldest := parent.Number.Uint64()
+	getHeader := func(hash common.Hash, number uint64) *types.Header {
+		h, _ := api._blockReader.Header(ctx, tx, hash, number)
+		if h != nil && number < oldest {
+			oldest = number
+		}
+		return h
+	}
+	chainReader := stagedsync.NewChainReaderImpl(chainConfig, tx, api._blockReader)
+	if _, err = core.ExecuteBlockEphemerally(chainConfig, &vm.Config{}, core.GetHashFn(block.Header(), getHeader), engine, block, recorder, recorder, chainReader, nil); err != nil {
+		return nil, fmt.Errorf("executing block %d: %w", blockNr, err)
+	}
+	// Everything the block changed has to be in the witness too, no matter what the execution above did
+	if err = recordChangeSets(tx, blockNr, recorder); err != nil {
+		return nil, err
+	}
+
+	// Load the parent state trie, keeping in memory what the block touches
+	batch := memdb.NewMemoryBatch(tx, api.dirs.Tmp)
+	defer batch.Rollback()
+	unwindState := &stagedsync.UnwindState{UnwindPoint: blockNr - 1}
+	stageState := &stagedsync.StageState{BlockNumber: latestBlock}
+	hashStageCfg := stagedsync.StageHashStateCfg(nil, api.dirs, false, api._agg)
+	if err = stagedsync.UnwindHashStateStage(unwindState, stageState, batch, hashStageCfg, ctx); err != nil {
+		return nil, err
+	}
+	interHashStageCfg := stagedsync.StageTrieCfg(nil, false, false, false, api.dirs.Tmp, api._blockReader, nil, false, api._agg)
+	loadTrie := func(keys [][]byte) (*trie.Trie, error) {
+		// The retain list of the loader also gets the keys changed since the parent block, so it has to be a separate one
+		rl, retain := trie.NewRetainList(0), trie.NewRetainList(0)
+		for _, k := range keys {
+			rl.AddKeyWithMarker(k, false)
+			retain.AddKey(k)
+		}
+		for _, codeHash := range recorder.TouchedCodes() {
+			retain.AddCodeTouch(codeHash)
+		}
+		loader, err := stagedsync.UnwindIntermediateHashesForTrieLoader("debug_getBlockWitness", rl, unwindState, stageState, batch, interHashStageCfg, nil, nil, ctx.Done())
+		if err != nil {
+			return nil, err
+		}
+		t, err := loader.CalcSubTrie(batch, retain, ctx.Done())
+		if err != nil {
+			return nil, err
+		}
+		if t.Hash() != parent.Root {
+			return nil, fmt.Errorf("mismatch in expected state root computed %x vs %x indicates bug in witness implementation", t.Hash(), parent.Root)
+		}
+		return t, nil
+	}
+	keys := recorder.TouchedKeys()
+	t, err := loadTrie(keys)
+	if err != nil {
+		return nil, err
+	}
+	// Deletions may collapse branches into nodes the block does not touch otherwise
+	if siblings := t.FindCollapsingSiblings(recorder.DeletedKeys()); len(siblings) > 0 {
+		for _, prefix := range siblings {
+			keys = append(keys, recorder.KeyThrough(prefix))
+		}
+		if t, err = loadTrie(keys); err != nil {
+			return nil, err
+		}
+	}
+
+	w, err := t.ExtractWitness(false, nil)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if _, err = w.WriteInto(&buf); err != nil {
+		return nil, err
+	}
+	result := &stateless.BlockWitness{Witness: buf.Bytes()}
+	if result.Block, err = rlp.EncodeToBytes(block); err != nil {
+		return nil, err
+	}
+	for h := parent; ; {
+		enc, err := rlp.EncodeToBytes(h)
+		if err != nil {
+			return nil, err
+		}
+		result.Headers = append(result.Headers, enc)
+		if h.Number.Uint64() <= oldest {
+			break
+		}
+		if h, err = api._blockReader.Header(ctx, tx, h.ParentHash, h.Number.Uint64()-1); err != nil {
+			return nil, err
+		}
+		if h == nil {
+			return nil, fmt.Errorf("header %d not found", oldest)
+		}
+	}
+	return result, nil
+}
+
+// recordChangeSets reads the accounts and storage items changed by the given block through the recorder.
+func recordChangeSets(tx kv.Tx, blockNr uint64, recorder *stateless.Recorder) error {
+	if err := changeset.ForRange(tx, kv.AccountChangeSet, blockNr, blockNr+1, func(_ uint64, k, _ []byte) error {
+		_, err := recorder.ReadAccountData(common.BytesToAddress(k))
+		return err
+	}); err != nil {
+		return err
+	}
+	return changeset.ForRange(tx, kv.StorageChangeSet, blockNr, blockNr+1, func(_ uint64, k, _ []byte) error {
+		address := common.BytesToAddress(k[:length.Addr])
+		if _, err := recorder.ReadAccountData(address); err != nil {
+			return err
+		}
+		incarnation := binary.BigEndian.Uint64(k[length.Addr:])
+		key := common.BytesToHash(k[length.Addr+length.Incarnation:])
+		_, err := recorder.ReadAccountStorage(address, incarnation, &key)
+		return err
+	})
+}
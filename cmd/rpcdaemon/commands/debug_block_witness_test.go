@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/core/stateless"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/rpc/rpccfg"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBlockWitness(t *testing.T) {
+	m, chain, _ := rpcdaemontest.CreateTestSentry(t)
+	if m.HistoryV3 {
+		t.Skip("not supported by Erigon3")
+	}
+	agg := m.HistoryV3Components()
+	br := snapshotsync.NewBlockReaderWithSnapshots(m.BlockSnapshots, m.TransactionsV3)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewPrivateDebugAPI(NewBaseApi(nil, stateCache, br, agg, false, rpccfg.DefaultEvmCallTimeout, m.Engine, m.Dirs), m.DB, 0)
+
+	witnesses := make([]*stateless.BlockWitness, len(chain.Blocks))
+	for i, block := range chain.Blocks {
+		bw, err := api.GetBlockWitness(m.Ctx, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(block.NumberU64())))
+		require.NoError(t, err, "block %d", block.NumberU64())
+		require.NoError(t, stateless.Verify(bw, m.ChainConfig, m.Engine), "block %d", block.NumberU64())
+		witnesses[i] = bw
+	}
+
+	// The witness of a block does not allow executing another one
+	mismatched := *witnesses[len(witnesses)-1]
+	mismatched.Witness = witnesses[1].Witness
+	require.Error(t, stateless.Verify(&mismatched, m.ChainConfig, m.Engine))
+
+	_, err := api.GetBlockWitness(m.Ctx, rpc.BlockNumberOrHashWithNumber(0))
+	require.Error(t, err)
+}
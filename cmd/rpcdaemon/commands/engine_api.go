@@ -21,6 +21,7 @@ import (
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/ethdb/privateapi"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi/remoteproto"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 )
@@ -71,6 +72,7 @@ type PayloadAttributes struct {
 	PrevRandao            common.Hash         `json:"prevRandao"            gencodec:"required"`
 	SuggestedFeeRecipient common.Address      `json:"suggestedFeeRecipient" gencodec:"required"`
 	Withdrawals           []*types.Withdrawal `json:"withdrawals"`
+	ParentBeaconBlockRoot *common.Hash        `json:"parentBeaconBlockRoot"`
 }
 
 // TransitionConfiguration represents the correct configurations of the CL and the EL
@@ -95,8 +97,10 @@ type ExecutionPayloadBodyV1 struct {
 type EngineAPI interface {
 	NewPayloadV1(context.Context, *ExecutionPayload) (map[string]interface{}, error)
 	NewPayloadV2(context.Context, *ExecutionPayload) (map[string]interface{}, error)
+	NewPayloadV3(ctx context.Context, payload *ExecutionPayload, expectedBlobVersionedHashes []common.Hash, parentBeaconBlockRoot *common.Hash) (map[string]interface{}, error)
 	ForkchoiceUpdatedV1(ctx context.Context, forkChoiceState *ForkChoiceState, payloadAttributes *PayloadAttributes) (map[string]interface{}, error)
 	ForkchoiceUpdatedV2(ctx context.Context, forkChoiceState *ForkChoiceState, payloadAttributes *PayloadAttributes) (map[string]interface{}, error)
+	ForkchoiceUpdatedV3(ctx context.Context, forkChoiceState *ForkChoiceState, payloadAttributes *PayloadAttributes) (map[string]interface{}, error)
 	GetPayloadV1(ctx context.Context, payloadID hexutility.Bytes) (*ExecutionPayload, error)
 	GetPayloadV2(ctx context.Context, payloadID hexutility.Bytes) (*GetPayloadV2Response, error)
 	GetPayloadV3(ctx context.Context, payloadID hexutility.Bytes) (*GetPayloadV3Response, error)
@@ -168,6 +172,12 @@ func (e *EngineImpl) ForkchoiceUpdatedV2(ctx context.Context, forkChoiceState *F
 	return e.forkchoiceUpdated(2, ctx, forkChoiceState, payloadAttributes)
 }
 
+// ForkchoiceUpdatedV3 is the same as ForkchoiceUpdatedV2, but the payload attributes also carry the parent beacon block root.
+// See https://github.com/ethereum/execution-apis/blob/main/src/engine/cancun.md#engine_forkchoiceupdatedv3
+func (e *EngineImpl) ForkchoiceUpdatedV3(ctx context.Context, forkChoiceState *ForkChoiceState, payloadAttributes *PayloadAttributes) (map[string]interface{}, error) {
+	return e.forkchoiceUpdated(3, ctx, forkChoiceState, payloadAttributes)
+}
+
 // Converts slice of pointers to slice of structs
 func withdrawalValues(ptrs []*types.Withdrawal) []types.Withdrawal {
 	if ptrs == nil {
@@ -193,10 +203,11 @@ func (e *EngineImpl) forkchoiceUpdated(version uint32, ctx context.Context, fork
 		log.Info("Received ForkchoiceUpdated [build]", "version", version,
 			"head", forkChoiceState.HeadHash, "safe", forkChoiceState.SafeBlockHash, "finalized", forkChoiceState.FinalizedBlockHash,
 			"timestamp", payloadAttributes.Timestamp, "prevRandao", payloadAttributes.PrevRandao, "suggestedFeeRecipient", payloadAttributes.SuggestedFeeRecipient,
-			"withdrawals", withdrawalValues(payloadAttributes.Withdrawals))
+			"withdrawals", withdrawalValues(payloadAttributes.Withdrawals), "parentBeaconBlockRoot", payloadAttributes.ParentBeaconBlockRoot)
 	}
 
 	var attributes *remote.EnginePayloadAttributes
+	var parentBeaconBlockRoot *common.Hash
 	if payloadAttributes != nil {
 		attributes = &remote.EnginePayloadAttributes{
 			Version:               1,
@@ -208,14 +219,23 @@ func (e *EngineImpl) forkchoiceUpdated(version uint32, ctx context.Context, fork
 			attributes.Version = 2
 			attributes.Withdrawals = privateapi.ConvertWithdrawalsToRpc(payloadAttributes.Withdrawals)
 		}
+		if version >= 3 {
+			if payloadAttributes.ParentBeaconBlockRoot == nil {
+				return nil, &rpc.InvalidParamsError{Message: "missing parentBeaconBlockRoot"}
+			}
+			parentBeaconBlockRoot = payloadAttributes.ParentBeaconBlockRoot
+		}
 	}
-	reply, err := e.api.EngineForkchoiceUpdated(ctx, &remote.EngineForkChoiceUpdatedRequest{
-		ForkchoiceState: &remote.EngineForkChoiceState{
-			HeadBlockHash:      gointerfaces.ConvertHashToH256(forkChoiceState.HeadHash),
-			SafeBlockHash:      gointerfaces.ConvertHashToH256(forkChoiceState.SafeBlockHash),
-			FinalizedBlockHash: gointerfaces.ConvertHashToH256(forkChoiceState.FinalizedBlockHash),
+	reply, err := e.api.EngineForkchoiceUpdated(ctx, &remoteproto.EngineForkChoiceUpdatedRequest{
+		Request: &remote.EngineForkChoiceUpdatedRequest{
+			ForkchoiceState: &remote.EngineForkChoiceState{
+				HeadBlockHash:      gointerfaces.ConvertHashToH256(forkChoiceState.HeadHash),
+				SafeBlockHash:      gointerfaces.ConvertHashToH256(forkChoiceState.SafeBlockHash),
+				FinalizedBlockHash: gointerfaces.ConvertHashToH256(forkChoiceState.FinalizedBlockHash),
+			},
+			PayloadAttributes: attributes,
 		},
-		PayloadAttributes: attributes,
+		ParentBeaconBlockRoot: privateapi.ConvertParentBeaconBlockRootToRpc(parentBeaconBlockRoot),
 	})
 	if err != nil {
 		return nil, err
@@ -237,21 +257,37 @@ func (e *EngineImpl) forkchoiceUpdated(version uint32, ctx context.Context, fork
 // NewPayloadV1 processes new payloads (blocks) from the beacon chain without withdrawals.
 // See https://github.com/ethereum/execution-apis/blob/main/src/engine/paris.md#engine_newpayloadv1
 func (e *EngineImpl) NewPayloadV1(ctx context.Context, payload *ExecutionPayload) (map[string]interface{}, error) {
-	return e.newPayload(1, ctx, payload)
+	return e.newPayload(1, ctx, payload, nil, nil)
 }
 
 // NewPayloadV2 processes new payloads (blocks) from the beacon chain with withdrawals.
 // See https://github.com/ethereum/execution-apis/blob/main/src/engine/shanghai.md#engine_newpayloadv2
 func (e *EngineImpl) NewPayloadV2(ctx context.Context, payload *ExecutionPayload) (map[string]interface{}, error) {
-	return e.newPayload(2, ctx, payload)
+	return e.newPayload(2, ctx, payload, nil, nil)
+}
+
+// NewPayloadV3 processes new payloads (blocks) from the beacon chain with blob transactions. The versioned hashes
+// of the blobs in the payload must match the expected ones, and the parent beacon block root becomes part of the header.
+// See https://github.com/ethereum/execution-apis/blob/main/src/engine/cancun.md#engine_newpayloadv3
+func (e *EngineImpl) NewPayloadV3(ctx context.Context, payload *ExecutionPayload, expectedBlobVersionedHashes []common.Hash, parentBeaconBlockRoot *common.Hash) (map[string]interface{}, error) {
+	return e.newPayload(3, ctx, payload, expectedBlobVersionedHashes, parentBeaconBlockRoot)
 }
 
-func (e *EngineImpl) newPayload(version uint32, ctx context.Context, payload *ExecutionPayload) (map[string]interface{}, error) {
+func (e *EngineImpl) newPayload(version uint32, ctx context.Context, payload *ExecutionPayload, expectedBlobVersionedHashes []common.Hash, parentBeaconBlockRoot *common.Hash) (map[string]interface{}, error) {
 	if e.internalCL {
 		return nil, errEmbedeedConsensus
 	}
 	log.Debug("Received NewPayload", "version", version, "height", uint64(payload.BlockNumber), "hash", payload.BlockHash)
 
+	if version >= 3 {
+		if expectedBlobVersionedHashes == nil {
+			return nil, &rpc.InvalidParamsError{Message: "missing expectedBlobVersionedHashes"}
+		}
+		if parentBeaconBlockRoot == nil {
+			return nil, &rpc.InvalidParamsError{Message: "missing parentBeaconBlockRoot"}
+		}
+	}
+
 	baseFee, overflow := uint256.FromBig((*big.Int)(payload.BaseFeePerGas))
 	if overflow {
 		log.Warn("NewPayload BaseFeePerGas overflow")
@@ -263,6 +299,16 @@ func (e *EngineImpl) newPayload(version uint32, ctx context.Context, payload *Ex
 	for i, transaction := range payload.Transactions {
 		transactions[i] = transaction
 	}
+	if version >= 3 {
+		if err := validateBlobVersionedHashes(transactions, expectedBlobVersionedHashes); err != nil {
+			log.Warn("[NewPayload] invalid blob versioned hashes", "hash", payload.BlockHash, "err", err)
+			return map[string]interface{}{
+				"status":          remote.EngineStatus_INVALID.String(),
+				"validationError": err.Error(),
+			}, nil
+		}
+	}
+
 	ep := &types2.ExecutionPayload{
 		Version:       1,
 		ParentHash:    gointerfaces.ConvertHashToH256(payload.ParentHash),
@@ -295,8 +341,12 @@ func (e *EngineImpl) newPayload(version uint32, ctx context.Context, payload *Ex
 		}
 		ep.ExcessDataGas = gointerfaces.ConvertUint256IntToH256(excessDataGas)
 	}
+	request := &remoteproto.EngineNewPayloadRequest{ExecutionPayload: ep}
+	if version >= 3 {
+		request.ParentBeaconBlockRoot = privateapi.ConvertParentBeaconBlockRootToRpc(parentBeaconBlockRoot)
+	}
 
-	res, err := e.api.EngineNewPayload(ctx, ep)
+	res, err := e.api.EngineNewPayload(ctx, request)
 	if err != nil {
 		log.Warn("NewPayload", "err", err)
 		return nil, err
@@ -304,6 +354,28 @@ func (e *EngineImpl) newPayload(version uint32, ctx context.Context, payload *Ex
 	return convertPayloadStatus(ctx, e.db, res)
 }
 
+// validateBlobVersionedHashes checks that the blob versioned hashes of the transactions,
+// concatenated in the order they appear in the payload, match the expected ones.
+func validateBlobVersionedHashes(transactions [][]byte, expected []common.Hash) error {
+	txs, err := types.DecodeTransactions(transactions)
+	if err != nil {
+		return err
+	}
+	var actual []common.Hash
+	for _, txn := range txs {
+		actual = append(actual, txn.GetDataHashes()...)
+	}
+	if len(actual) != len(expected) {
+		return fmt.Errorf("invalid number of blob versioned hashes: have %d, expected %d", len(actual), len(expected))
+	}
+	for i := range actual {
+		if actual[i] != expected[i] {
+			return fmt.Errorf("invalid blob versioned hash %d: have %x, expected %x", i, actual[i], expected[i])
+		}
+	}
+	return nil
+}
+
 func convertPayloadFromRpc(payload *types2.ExecutionPayload) *ExecutionPayload {
 	var bloom types.Bloom = gointerfaces.ConvertH2048ToBloom(payload.LogsBloom)
 	baseFee := gointerfaces.ConvertH256ToUint256Int(payload.BaseFeePerGas).ToBig()
@@ -494,12 +566,13 @@ func (e *EngineImpl) GetPayloadBodiesByRangeV1(ctx context.Context, start, count
 var ourCapabilities = []string{
 	"engine_forkchoiceUpdatedV1",
 	"engine_forkchoiceUpdatedV2",
+	"engine_forkchoiceUpdatedV3",
 	"engine_newPayloadV1",
 	"engine_newPayloadV2",
-	// "engine_newPayloadV3",
+	"engine_newPayloadV3",
 	"engine_getPayloadV1",
 	"engine_getPayloadV2",
-	// "engine_getPayloadV3",
+	"engine_getPayloadV3",
 	"engine_exchangeTransitionConfigurationV1",
 	"engine_getPayloadBodiesByHashV1",
 	"engine_getPayloadBodiesByRangeV1",
@@ -1,14 +1,29 @@
 package commands
 
 import (
+	"bytes"
 	"context"
+	"math/big"
 	"testing"
 
+	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	types2 "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
+	. "github.com/protolambda/ztyp/view"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi/remoteproto"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 )
 
 // Test case for https://github.com/ethereum/execution-apis/pull/217 responses
@@ -19,3 +34,167 @@ func TestZeroLatestValidHash(t *testing.T) {
 	assert.Equal(t, "INVALID", json["status"])
 	assert.Equal(t, common.Hash{}, json["latestValidHash"])
 }
+
+type engineBackendMock struct {
+	rpchelper.ApiBackend
+	newPayload  *remoteproto.EngineNewPayloadRequest
+	forkchoice  *remoteproto.EngineForkChoiceUpdatedRequest
+	payload     *types2.ExecutionPayload
+	blobsBundle *types2.BlobsBundleV1
+}
+
+func (b *engineBackendMock) EngineNewPayload(ctx context.Context, request *remoteproto.EngineNewPayloadRequest) (*remote.EnginePayloadStatus, error) {
+	b.newPayload = request
+	return &remote.EnginePayloadStatus{Status: remote.EngineStatus_VALID, LatestValidHash: request.ExecutionPayload.BlockHash}, nil
+}
+
+func (b *engineBackendMock) EngineForkchoiceUpdated(ctx context.Context, request *remoteproto.EngineForkChoiceUpdatedRequest) (*remote.EngineForkChoiceUpdatedResponse, error) {
+	b.forkchoice = request
+	return &remote.EngineForkChoiceUpdatedResponse{
+		PayloadStatus: &remote.EnginePayloadStatus{Status: remote.EngineStatus_VALID, LatestValidHash: request.Request.ForkchoiceState.HeadBlockHash},
+		PayloadId:     1,
+	}, nil
+}
+
+func (b *engineBackendMock) EngineGetPayload(ctx context.Context, payloadId uint64) (*remote.EngineGetPayloadResponse, error) {
+	return &remote.EngineGetPayloadResponse{ExecutionPayload: b.payload, BlockValue: gointerfaces.ConvertUint256IntToH256(uint256.NewInt(1))}, nil
+}
+
+func (b *engineBackendMock) EngineGetBlobsBundleV1(ctx context.Context, payloadId uint64) (*types2.BlobsBundleV1, error) {
+	return b.blobsBundle, nil
+}
+
+func signedBlobTxBytes(t *testing.T, hashes []common.Hash) hexutility.Bytes {
+	t.Helper()
+	chainID := params.AllProtocolChanges.ChainID
+	key, _ := crypto.GenerateKey()
+	to := types.AddressSSZ(common.HexToAddress("0xda7a"))
+	var blobTx types.SignedBlobTx
+	blobTx.Message.ChainID = Uint256View(*uint256.MustFromBig(chainID))
+	blobTx.Message.GasFeeCap = Uint256View(*uint256.NewInt(params.GWei))
+	blobTx.Message.Gas = Uint64View(21_000)
+	blobTx.Message.To = types.AddressOptionalSSZ{Address: &to}
+	blobTx.Message.AccessList = types.AccessListView{}
+	blobTx.Message.MaxFeePerDataGas = Uint256View(*uint256.NewInt(1))
+	blobTx.Message.BlobVersionedHashes = hashes
+	txn, err := types.SignTx(&blobTx, *types.LatestSignerForChainID(chainID), key)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, txn.MarshalBinary(&buf))
+	return buf.Bytes()
+}
+
+func TestNewPayloadV3(t *testing.T) {
+	backend := &engineBackendMock{}
+	engine := NewEngineAPI(nil, nil, backend, false)
+
+	hashes := []common.Hash{{0x01, 0x01}, {0x01, 0x02}}
+	root := common.HexToHash("0xbeac")
+	payload := &ExecutionPayload{
+		BlockNumber:   1,
+		BlockHash:     common.HexToHash("0xb10c"),
+		BaseFeePerGas: (*hexutil.Big)(big.NewInt(1)),
+		LogsBloom:     make([]byte, 256),
+		Transactions:  []hexutility.Bytes{signedBlobTxBytes(t, hashes)},
+		Withdrawals:   []*types.Withdrawal{},
+		ExcessDataGas: (*hexutil.Big)(big.NewInt(0)),
+	}
+
+	status, err := engine.NewPayloadV3(context.Background(), payload, hashes, &root)
+	require.NoError(t, err)
+	assert.Equal(t, "VALID", status["status"])
+	require.NotNil(t, backend.newPayload)
+	assert.Equal(t, uint32(3), backend.newPayload.ExecutionPayload.Version)
+	assert.Equal(t, &root, privateapi.ConvertParentBeaconBlockRootFromRpc(backend.newPayload.ParentBeaconBlockRoot))
+
+	// Versioned hashes that don't match the blobs of the payload make it invalid without reaching the backend
+	backend.newPayload = nil
+	status, err = engine.NewPayloadV3(context.Background(), payload, hashes[:1], &root)
+	require.NoError(t, err)
+	assert.Equal(t, "INVALID", status["status"])
+	assert.Contains(t, status["validationError"], "invalid number of blob versioned hashes")
+	status, err = engine.NewPayloadV3(context.Background(), payload, []common.Hash{hashes[1], hashes[0]}, &root)
+	require.NoError(t, err)
+	assert.Equal(t, "INVALID", status["status"])
+	assert.Nil(t, backend.newPayload)
+
+	// Both the versioned hashes and the parent beacon block root are required
+	var paramsErr *rpc.InvalidParamsError
+	_, err = engine.NewPayloadV3(context.Background(), payload, nil, &root)
+	require.ErrorAs(t, err, &paramsErr)
+	_, err = engine.NewPayloadV3(context.Background(), payload, hashes, nil)
+	require.ErrorAs(t, err, &paramsErr)
+
+	// Older versions don't carry the root
+	_, err = engine.NewPayloadV2(context.Background(), payload)
+	require.NoError(t, err)
+	assert.Nil(t, backend.newPayload.ParentBeaconBlockRoot)
+}
+
+func TestForkchoiceUpdatedV3(t *testing.T) {
+	backend := &engineBackendMock{}
+	engine := NewEngineAPI(nil, nil, backend, false)
+
+	root := common.HexToHash("0xbeac")
+	state := &ForkChoiceState{HeadHash: common.HexToHash("0x01")}
+	attributes := &PayloadAttributes{
+		Timestamp:             1,
+		Withdrawals:           []*types.Withdrawal{},
+		ParentBeaconBlockRoot: &root,
+	}
+	reply, err := engine.ForkchoiceUpdatedV3(context.Background(), state, attributes)
+	require.NoError(t, err)
+	assert.Equal(t, hexutility.Bytes{0, 0, 0, 0, 0, 0, 0, 1}, reply["payloadId"])
+	assert.Equal(t, &root, privateapi.ConvertParentBeaconBlockRootFromRpc(backend.forkchoice.ParentBeaconBlockRoot))
+
+	var paramsErr *rpc.InvalidParamsError
+	attributes.ParentBeaconBlockRoot = nil
+	_, err = engine.ForkchoiceUpdatedV3(context.Background(), state, attributes)
+	require.ErrorAs(t, err, &paramsErr)
+
+	// Without payload attributes there is nothing to check
+	_, err = engine.ForkchoiceUpdatedV3(context.Background(), state, nil)
+	require.NoError(t, err)
+}
+
+func TestGetPayloadV3(t *testing.T) {
+	var blob types.Blob
+	blob[0] = 0xb1
+	var kzg types.KZGCommitment
+	kzg[0] = 0xc0
+	backend := &engineBackendMock{
+		payload: &types2.ExecutionPayload{
+			Version:       3,
+			ParentHash:    gointerfaces.ConvertHashToH256(common.Hash{}),
+			Coinbase:      gointerfaces.ConvertAddressToH160(common.Address{}),
+			StateRoot:     gointerfaces.ConvertHashToH256(common.Hash{}),
+			ReceiptRoot:   gointerfaces.ConvertHashToH256(common.Hash{}),
+			PrevRandao:    gointerfaces.ConvertHashToH256(common.Hash{}),
+			BlockHash:     gointerfaces.ConvertHashToH256(common.HexToHash("0xb10c")),
+			BlockNumber:   1,
+			BaseFeePerGas: gointerfaces.ConvertUint256IntToH256(uint256.NewInt(1)),
+			LogsBloom:     gointerfaces.ConvertBytesToH2048(make([]byte, 256)),
+			ExcessDataGas: gointerfaces.ConvertUint256IntToH256(uint256.NewInt(params.DataGasPerBlob)),
+		},
+		blobsBundle: &types2.BlobsBundleV1{Kzgs: [][]byte{kzg[:]}, Blobs: [][]byte{blob[:]}},
+	}
+	engine := NewEngineAPI(nil, nil, backend, false)
+
+	response, err := engine.GetPayloadV3(context.Background(), hexutility.Bytes{0, 0, 0, 0, 0, 0, 0, 1})
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(params.DataGasPerBlob), response.ExecutionPayload.ExcessDataGas.ToInt())
+	assert.Equal(t, []types.KZGCommitment{kzg}, response.BlobsBundle.KZGs)
+	assert.Equal(t, []types.Blob{blob}, response.BlobsBundle.Blobs)
+
+	backend.blobsBundle = &types2.BlobsBundleV1{Kzgs: [][]byte{kzg[:]}}
+	_, err = engine.GetPayloadV3(context.Background(), hexutility.Bytes{0, 0, 0, 0, 0, 0, 0, 1})
+	require.Error(t, err)
+}
+
+func TestExchangeCapabilities(t *testing.T) {
+	engine := NewEngineAPI(nil, nil, nil, false)
+	capabilities := engine.ExchangeCapabilities([]string{"engine_newPayloadV3"})
+	for _, method := range []string{"engine_newPayloadV3", "engine_forkchoiceUpdatedV3", "engine_getPayloadV3"} {
+		assert.Contains(t, capabilities, method)
+	}
+}
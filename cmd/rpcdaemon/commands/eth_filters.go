@@ -18,12 +18,10 @@ func (api *APIImpl) NewPendingTransactionFilter(_ context.Context) (string, erro
 	if api.filters == nil {
 		return "", rpc.ErrNotificationsUnsupported
 	}
-	txsCh, id := api.filters.SubscribePendingTxs(32)
-	go func() {
-		for txs := range txsCh {
-			api.filters.AddPendingTxs(id, txs)
-		}
-	}()
+	id, err := api.filters.NewPendingTxsFilter()
+	if err != nil {
+		return "", err
+	}
 	return "0x" + string(id), nil
 }
 
@@ -32,12 +30,10 @@ func (api *APIImpl) NewBlockFilter(_ context.Context) (string, error) {
 	if api.filters == nil {
 		return "", rpc.ErrNotificationsUnsupported
 	}
-	ch, id := api.filters.SubscribeNewHeads(32)
-	go func() {
-		for block := range ch {
-			api.filters.AddPendingBlock(id, block)
-		}
-	}()
+	id, err := api.filters.NewHeadsFilter()
+	if err != nil {
+		return "", err
+	}
 	return "0x" + string(id), nil
 }
 
@@ -46,12 +42,10 @@ func (api *APIImpl) NewFilter(_ context.Context, crit filters.FilterCriteria) (s
 	if api.filters == nil {
 		return "", rpc.ErrNotificationsUnsupported
 	}
-	logs, id := api.filters.SubscribeLogs(256, crit)
-	go func() {
-		for lg := range logs {
-			api.filters.AddLogs(id, lg)
-		}
-	}()
+	id, err := api.filters.NewLogsFilter(crit)
+	if err != nil {
+		return "", err
+	}
 	return "0x" + string(id), nil
 }
 
@@ -84,28 +78,36 @@ func (api *APIImpl) GetFilterChanges(_ context.Context, index string) ([]any, er
 	stub := make([]any, 0)
 	// remove 0x
 	cutIndex := strings.TrimPrefix(index, "0x")
-	if blocks, ok := api.filters.ReadPendingBlocks(rpchelper.HeadsSubID(cutIndex)); ok {
+	if blocks, ok, err := api.filters.ReadPendingBlocks(rpchelper.HeadsSubID(cutIndex)); ok {
+		if err != nil {
+			return nil, err
+		}
 		for _, v := range blocks {
 			stub = append(stub, v.Hash())
 		}
 		return stub, nil
 	}
-	if txs, ok := api.filters.ReadPendingTxs(rpchelper.PendingTxsSubID(cutIndex)); ok {
-		if len(txs) > 0 {
-			for _, tx := range txs[0] {
+	if txs, ok, err := api.filters.ReadPendingTxs(rpchelper.PendingTxsSubID(cutIndex)); ok {
+		if err != nil {
+			return nil, err
+		}
+		for _, batch := range txs {
+			for _, tx := range batch {
 				stub = append(stub, tx.Hash())
 			}
-			return stub, nil
 		}
 		return stub, nil
 	}
-	if logs, ok := api.filters.ReadLogs(rpchelper.LogsSubID(cutIndex)); ok {
+	if logs, ok, err := api.filters.ReadLogs(rpchelper.LogsSubID(cutIndex)); ok {
+		if err != nil {
+			return nil, err
+		}
 		for _, v := range logs {
 			stub = append(stub, v)
 		}
 		return stub, nil
 	}
-	return stub, nil
+	return nil, rpchelper.ErrFilterNotFound
 }
 
 // GetFilterLogs implements eth_getFilterLogs.
@@ -116,8 +118,14 @@ func (api *APIImpl) GetFilterLogs(_ context.Context, index string) ([]*types.Log
 		return nil, rpc.ErrNotificationsUnsupported
 	}
 	cutIndex := strings.TrimPrefix(index, "0x")
-	logs, ok := api.filters.ReadLogs(rpchelper.LogsSubID(cutIndex))
-	if len(logs) == 0 || !ok {
+	logs, ok, err := api.filters.ReadLogs(rpchelper.LogsSubID(cutIndex))
+	if !ok {
+		return nil, rpchelper.ErrFilterNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(logs) == 0 {
 		return []*types.Log{}, nil
 	}
 	return logs, nil
@@ -255,6 +263,9 @@ func (api *APIImpl) Logs(ctx context.Context, crit filters.FilterCriteria) (*rpc
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
+	if crit.ResumeToken != nil || crit.Backfill {
+		return api.resumableLogs(ctx, notifier, crit)
+	}
 
 	rpcSub := notifier.CreateSubscription()
 
@@ -0,0 +1,416 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/common/debug"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+)
+
+const (
+	// logsBackfillBlocks is the number of blocks read from the log index at once
+	logsBackfillBlocks = 1000
+	// logsReorgDepth is the number of latest blocks whose logs are notified as removed on reorg
+	logsReorgDepth = 128
+	// logsLiveBufferSize is the number of live logs buffered while the subscription catches up
+	// with the chain, the ones dropped above it are taken from the database afterwards
+	logsLiveBufferSize = 4096
+)
+
+// resumableLogsSub streams the logs matching crit from a given block: first from the log index
+// up to the head, then from the live notifications. Each log is sent with the token to resume
+// the stream after it. The latest blocks are remembered to notify the removal of their logs on reorg.
+type resumableLogsSub struct {
+	api    *APIImpl
+	crit   filters.FilterCriteria
+	notify func(*rpchelper.ResumableLog) error
+
+	next   uint64            // the first block not read from the database yet
+	blocks []*deliveredBlock // the latest covered blocks, contiguous and ascending
+}
+
+// deliveredBlock is a recent block covered by a resumable logs subscription.
+type deliveredBlock struct {
+	number uint64
+	hash   libcommon.Hash
+	logs   []*types.Log // sent from the block
+	next   uint64       // the index of the first log not sent, rpchelper.AllLogs if no more are sent
+}
+
+func (api *APIImpl) resumableLogs(ctx context.Context, notifier *rpc.Notifier, crit filters.FilterCriteria) (*rpc.Subscription, error) {
+	s, err := api.newResumableLogsSub(ctx, crit)
+	if err != nil {
+		return &rpc.Subscription{}, err
+	}
+	rpcSub := notifier.CreateSubscription()
+	s.notify = func(lg *rpchelper.ResumableLog) error {
+		return notifier.Notify(rpcSub.ID, lg)
+	}
+
+	// Subscribe before reading the database, so that no block falls in between
+	logs, id := api.filters.SubscribeLogs(logsLiveBufferSize, crit)
+	heads, headsID := api.filters.SubscribeNewHeads(32)
+	go func() {
+		defer debug.LogPanic()
+		defer api.filters.UnsubscribeLogs(id)
+		defer api.filters.UnsubscribeHeads(headsID)
+		if err := s.run(rpcSub.Err(), logs, heads); err != nil {
+			log.Warn("error while streaming logs", "err", err)
+		}
+	}()
+	return rpcSub, nil
+}
+
+func (api *APIImpl) newResumableLogsSub(ctx context.Context, crit filters.FilterCriteria) (*resumableLogsSub, error) {
+	s := &resumableLogsSub{api: api, crit: crit}
+	s.crit.ResumeToken = nil
+	if crit.ResumeToken == nil {
+		if crit.FromBlock == nil || crit.FromBlock.Sign() < 0 {
+			return nil, fmt.Errorf("backfill requires a fromBlock number")
+		}
+		s.next = crit.FromBlock.Uint64()
+		return s, nil
+	}
+
+	token, err := rpchelper.DecodeLogsResumeToken(crit.ResumeToken)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	hash, err := rawdb.ReadCanonicalHash(tx, token.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if hash != token.BlockHash {
+		return nil, resumeTokenForkError(tx, token)
+	}
+
+	// Remember the logs delivered before the token, they are notified as removed on reorg
+	s.next = 0
+	if token.BlockNumber >= logsReorgDepth {
+		s.next = token.BlockNumber - logsReorgDepth + 1
+	}
+	logs, err := s.getLogs(ctx, s.next, token.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	for len(logs) > 0 && logs[len(logs)-1].BlockNumber == token.BlockNumber && uint64(logs[len(logs)-1].Index) > token.LogIndex {
+		logs = logs[:len(logs)-1]
+	}
+	if err := s.cover(tx, token.BlockNumber, token.BlockNumber, logs, false); err != nil {
+		return nil, err
+	}
+	if b := s.tracked(token.BlockNumber); b != nil && b.hash == token.BlockHash {
+		b.next = rpchelper.AllLogs
+		if token.LogIndex != rpchelper.AllLogs {
+			b.next = token.LogIndex + 1
+		}
+	}
+	// The rest of the token block is taken from the database again
+	s.next = token.BlockNumber
+	return s, nil
+}
+
+// resumeTokenForkError reports the last block of the token's chain which is still canonical.
+func resumeTokenForkError(tx kv.Tx, token rpchelper.LogsResumeToken) error {
+	hash, number := token.BlockHash, token.BlockNumber
+	for number > 0 {
+		header := rawdb.ReadHeader(tx, hash, number)
+		if header == nil {
+			break
+		}
+		hash, number = header.ParentHash, number-1
+		canonical, err := rawdb.ReadCanonicalHash(tx, number)
+		if err != nil {
+			return err
+		}
+		if canonical == hash {
+			return fmt.Errorf("resume token block %d (%x) is not canonical, the chain forked after block %d", token.BlockNumber, token.BlockHash, number)
+		}
+	}
+	return fmt.Errorf("resume token block %d (%x) is not canonical", token.BlockNumber, token.BlockHash)
+}
+
+func (s *resumableLogsSub) run(done <-chan error, logs <-chan *types.Log, heads <-chan *types.Header) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// The live logs are buffered until the subscription catches up with the chain,
+	// the ones of the blocks taken from the database are skipped then.
+	if err := s.catchUp(ctx); err != nil {
+		return err
+	}
+	for {
+		select {
+		case lg, ok := <-logs:
+			if !ok {
+				return nil
+			}
+			batch := []*types.Log{lg}
+			for more := true; more; {
+				select {
+				case lg, ok := <-logs:
+					if !ok {
+						return nil
+					}
+					batch = append(batch, lg)
+				default:
+					more = false
+				}
+			}
+			if err := s.onLogs(ctx, batch); err != nil {
+				return err
+			}
+		case _, ok := <-heads:
+			if !ok {
+				return nil
+			}
+			if err := s.catchUp(ctx); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// catchUp notifies the removed logs of the reorganised blocks and sends the logs of the blocks
+// not covered yet, reading them from the log index.
+func (s *resumableLogsSub) catchUp(ctx context.Context) error {
+	for {
+		tx, err := s.api.db.BeginRo(ctx)
+		if err != nil {
+			return err
+		}
+		if err := s.reconcile(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+		head, err := s.head(tx)
+		tx.Rollback()
+		if err != nil {
+			return err
+		}
+		if s.next > head {
+			return nil
+		}
+
+		to := s.next + logsBackfillBlocks - 1
+		if to > head {
+			to = head
+		}
+		logs, err := s.getLogs(ctx, s.next, to)
+		if err != nil {
+			return err
+		}
+		tx, err = s.api.db.BeginRo(ctx)
+		if err != nil {
+			return err
+		}
+		err = s.cover(tx, to, head, logs, true)
+		tx.Rollback()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// onLogs sends the live logs which were not taken from the database yet.
+func (s *resumableLogsSub) onLogs(ctx context.Context, logs []*types.Log) error {
+	for _, lg := range logs {
+		if lg.BlockNumber > s.next {
+			// Some notifications were dropped, take the blocks in between from the database
+			if err := s.catchUp(ctx); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	tx, err := s.api.db.BeginRo(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, lg := range logs {
+		hash, err := rawdb.ReadCanonicalHash(tx, lg.BlockNumber)
+		if err != nil {
+			return err
+		}
+		if hash != lg.BlockHash {
+			continue
+		}
+		// The logs of a new fork are notified as removed, they are canonical at this point
+		lg.Removed = false
+
+		b := s.tracked(lg.BlockNumber)
+		if b != nil && b.hash != hash {
+			if err := s.reconcile(tx); err != nil {
+				return err
+			}
+			b = nil
+		}
+		if b == nil {
+			if lg.BlockNumber != s.next {
+				continue
+			}
+			// The block stays not covered until it is read from the database, in case some of its logs are dropped
+			b = s.track(lg.BlockNumber, hash)
+		}
+		if err := s.deliver(b, lg, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// head returns the latest block whose logs can be read from the database.
+func (s *resumableLogsSub) head(tx kv.Tx) (uint64, error) {
+	latest, _, _, err := rpchelper.GetBlockNumber(rpc.BlockNumberOrHashWithNumber(rpc.LatestExecutedBlockNumber), tx, nil)
+	if err != nil {
+		return 0, err
+	}
+	if s.api.historyV3(tx) {
+		return latest, nil
+	}
+	indexed, err := stages.GetStageProgress(tx, stages.LogIndex)
+	if err != nil {
+		return 0, err
+	}
+	if indexed < latest {
+		return indexed, nil
+	}
+	return latest, nil
+}
+
+func (s *resumableLogsSub) getLogs(ctx context.Context, from, to uint64) (types.Logs, error) {
+	crit := s.crit
+	crit.FromBlock = new(big.Int).SetUint64(from)
+	crit.ToBlock = new(big.Int).SetUint64(to)
+	return s.api.GetLogs(ctx, crit)
+}
+
+// cover moves s.next past the blocks up to `to`, delivering their logs. It stops at a block
+// which is not canonical anymore, the next reconcile rolls it back.
+func (s *resumableLogsSub) cover(tx kv.Tx, to, head uint64, logs []*types.Log, send bool) error {
+	i := 0
+	for ; s.next <= to; s.next++ {
+		b := s.tracked(s.next)
+		if b != nil || s.next+logsReorgDepth > head {
+			hash, err := rawdb.ReadCanonicalHash(tx, s.next)
+			if err != nil {
+				return err
+			}
+			if b == nil {
+				b = s.track(s.next, hash)
+			} else if b.hash != hash {
+				return nil
+			}
+		}
+		for ; i < len(logs) && logs[i].BlockNumber == s.next; i++ {
+			if b != nil && logs[i].BlockHash != b.hash {
+				return nil
+			}
+			if err := s.deliver(b, logs[i], send); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// reconcile notifies the removal of the logs of the delivered blocks which are not canonical anymore,
+// the newest first, and rolls s.next back to the first of them.
+func (s *resumableLogsSub) reconcile(tx kv.Tx) error {
+	for i, b := range s.blocks {
+		hash, err := rawdb.ReadCanonicalHash(tx, b.number)
+		if err != nil {
+			return err
+		}
+		if hash == b.hash {
+			continue
+		}
+
+		token := rpchelper.LogsResumeToken{LogIndex: rpchelper.AllLogs}
+		if b.number > 0 {
+			token.BlockNumber = b.number - 1
+			if token.BlockHash, err = rawdb.ReadCanonicalHash(tx, token.BlockNumber); err != nil {
+				return err
+			}
+		}
+		encoded := token.Encode()
+		for j := len(s.blocks) - 1; j >= i; j-- {
+			logs := s.blocks[j].logs
+			for k := len(logs) - 1; k >= 0; k-- {
+				removed := *logs[k]
+				removed.Removed = true
+				if err := s.notify(&rpchelper.ResumableLog{Log: &removed, ResumeToken: encoded}); err != nil {
+					return err
+				}
+			}
+		}
+		s.blocks = s.blocks[:i]
+		if b.number < s.next {
+			s.next = b.number
+		}
+		return nil
+	}
+	return nil
+}
+
+// deliver sends lg unless it was sent already. The logs of a block arrive in the order of their index.
+func (s *resumableLogsSub) deliver(b *deliveredBlock, lg *types.Log, send bool) error {
+	if b != nil {
+		if uint64(lg.Index) < b.next {
+			return nil
+		}
+		b.logs = append(b.logs, lg)
+		b.next = uint64(lg.Index) + 1
+	}
+	if !send {
+		return nil
+	}
+	token := rpchelper.LogsResumeToken{BlockNumber: lg.BlockNumber, BlockHash: lg.BlockHash, LogIndex: uint64(lg.Index)}
+	return s.notify(&rpchelper.ResumableLog{Log: lg, ResumeToken: token.Encode()})
+}
+
+func (s *resumableLogsSub) tracked(number uint64) *deliveredBlock {
+	if len(s.blocks) == 0 || number < s.blocks[0].number || number > s.blocks[len(s.blocks)-1].number {
+		return nil
+	}
+	return s.blocks[number-s.blocks[0].number]
+}
+
+func (s *resumableLogsSub) track(number uint64, hash libcommon.Hash) *deliveredBlock {
+	if n := len(s.blocks); n > 0 && s.blocks[n-1].number+1 != number {
+		s.blocks = nil
+	}
+	b := &deliveredBlock{number: number, hash: hash}
+	s.blocks = append(s.blocks, b)
+	if len(s.blocks) > logsReorgDepth {
+		s.blocks = s.blocks[1:]
+	}
+	return b
+}
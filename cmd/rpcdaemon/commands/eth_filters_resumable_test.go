@@ -0,0 +1,175 @@
+package commands
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/rpc/rpccfg"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/ledgerwatch/erigon/turbo/stages"
+)
+
+// logsChain generates a chain with two logs in each block, their data is the fork byte.
+func logsChain(t *testing.T, m *stages.MockSentry, blocks int, fork func(i int) byte) *core.ChainPack {
+	signer := types.LatestSignerForChainID(nil)
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, blocks, func(i int, gen *core.BlockGen) {
+		// PUSH1 fork PUSH1 0 MSTORE8 PUSH1 1 PUSH1 0 LOG0 PUSH1 1 PUSH1 0 LOG0 STOP
+		initcode := []byte{0x60, fork(i), 0x60, 0x00, 0x53, 0x60, 0x01, 0x60, 0x00, 0xa0, 0x60, 0x01, 0x60, 0x00, 0xa0, 0x00}
+		tx, err := types.SignTx(types.NewContractCreation(gen.TxNonce(m.Address), new(uint256.Int), 1e6, new(uint256.Int), initcode), *signer, m.Key)
+		require.NoError(t, err)
+		gen.AddTx(tx)
+	}, false /* intermediateHashes */)
+	require.NoError(t, err)
+	return chain
+}
+
+func newResumableLogsTestAPI(m *stages.MockSentry) *APIImpl {
+	br := snapshotsync.NewBlockReaderWithSnapshots(m.BlockSnapshots, m.TransactionsV3)
+	return NewEthAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), br, m.HistoryV3Components(), false, rpccfg.DefaultEvmCallTimeout, m.Engine, m.Dirs), m.DB, nil, nil, nil, 5000000, 100_000)
+}
+
+func newTestResumableLogsSub(t *testing.T, api *APIImpl, crit filters.FilterCriteria) (*resumableLogsSub, *[]*rpchelper.ResumableLog) {
+	s, err := api.newResumableLogsSub(context.Background(), crit)
+	require.NoError(t, err)
+	var sent []*rpchelper.ResumableLog
+	s.notify = func(lg *rpchelper.ResumableLog) error {
+		sent = append(sent, lg)
+		return nil
+	}
+	return s, &sent
+}
+
+func allLogs(t *testing.T, api *APIImpl) types.Logs {
+	logs, err := api.GetLogs(context.Background(), filters.FilterCriteria{FromBlock: big.NewInt(0)})
+	require.NoError(t, err)
+	return logs
+}
+
+func sentLogs(sent []*rpchelper.ResumableLog) types.Logs {
+	logs := types.Logs{}
+	for _, lg := range sent {
+		logs = append(logs, lg.Log)
+	}
+	return logs
+}
+
+func copyLog(lg *types.Log) *types.Log {
+	cpy := *lg
+	return &cpy
+}
+
+func TestResumableLogsBackfillAndLive(t *testing.T) {
+	m := stages.Mock(t)
+	chain := logsChain(t, m, 10, func(int) byte { return 'a' })
+	require.NoError(t, m.InsertChain(chain.Slice(0, 5)))
+	api := newResumableLogsTestAPI(m)
+	ctx := context.Background()
+
+	s, sent := newTestResumableLogsSub(t, api, filters.FilterCriteria{FromBlock: big.NewInt(3)})
+	require.NoError(t, s.catchUp(ctx))
+	all := allLogs(t, api)
+	require.Len(t, all, 10)
+	require.Equal(t, all[4:], sentLogs(*sent))
+
+	// The live logs of the blocks taken from the database are skipped
+	require.NoError(t, s.onLogs(ctx, []*types.Log{copyLog(all[4]), copyLog(all[9])}))
+	require.Len(t, *sent, 6)
+
+	require.NoError(t, m.InsertChain(chain.Slice(5, 10)))
+	all = allLogs(t, api)
+	require.Len(t, all, 20)
+
+	// The live logs of the next block are sent as they come
+	live := copyLog(all[10])
+	live.Removed = true // the logs of a new fork are notified as removed
+	require.NoError(t, s.onLogs(ctx, []*types.Log{live}))
+	require.Equal(t, all[4:11], sentLogs(*sent))
+	require.False(t, (*sent)[6].Removed)
+
+	// The blocks missed in between are taken from the database
+	require.NoError(t, s.onLogs(ctx, []*types.Log{copyLog(all[19])}))
+	require.Equal(t, all[4:], sentLogs(*sent))
+
+	// The logs which are not canonical are dropped
+	stale := copyLog(all[19])
+	stale.BlockHash = libcommon.Hash{1}
+	require.NoError(t, s.onLogs(ctx, []*types.Log{stale}))
+	require.Len(t, *sent, 16)
+}
+
+func TestResumableLogsResume(t *testing.T) {
+	m := stages.Mock(t)
+	require.NoError(t, m.InsertChain(logsChain(t, m, 5, func(int) byte { return 'a' })))
+	api := newResumableLogsTestAPI(m)
+	ctx := context.Background()
+	all := allLogs(t, api)
+
+	s, sent := newTestResumableLogsSub(t, api, filters.FilterCriteria{FromBlock: big.NewInt(0), Addresses: []libcommon.Address{}})
+	require.NoError(t, s.catchUp(ctx))
+	require.Equal(t, all, sentLogs(*sent))
+
+	for i := range all {
+		resumed, rest := newTestResumableLogsSub(t, api, filters.FilterCriteria{ResumeToken: (*sent)[i].ResumeToken})
+		require.NoError(t, resumed.catchUp(ctx))
+		require.Equal(t, all[i+1:], sentLogs(*rest), "resumed after log %d", i)
+	}
+
+	token, err := rpchelper.DecodeLogsResumeToken((*sent)[0].ResumeToken)
+	require.NoError(t, err)
+	token.BlockHash = libcommon.Hash{1}
+	_, err = api.newResumableLogsSub(ctx, filters.FilterCriteria{ResumeToken: token.Encode()})
+	require.ErrorContains(t, err, "is not canonical")
+	_, err = api.newResumableLogsSub(ctx, filters.FilterCriteria{ResumeToken: []byte{1, 2, 3}})
+	require.ErrorContains(t, err, "invalid resume token")
+}
+
+func TestResumableLogsReorg(t *testing.T) {
+	m := stages.Mock(t)
+	chainA := logsChain(t, m, 8, func(int) byte { return 'a' })
+	chainB := logsChain(t, m, 10, func(i int) byte {
+		if i < 5 {
+			return 'a'
+		}
+		return 'b'
+	})
+	require.NoError(t, m.InsertChain(chainA))
+	api := newResumableLogsTestAPI(m)
+	ctx := context.Background()
+
+	s, sent := newTestResumableLogsSub(t, api, filters.FilterCriteria{FromBlock: big.NewInt(0)})
+	require.NoError(t, s.catchUp(ctx))
+	logsA := allLogs(t, api)
+	require.Equal(t, logsA, sentLogs(*sent))
+	tokenA := (*sent)[len(*sent)-1].ResumeToken
+
+	require.NoError(t, m.InsertChain(chainB))
+	logsB := allLogs(t, api)
+	*sent = nil
+	require.NoError(t, s.catchUp(ctx))
+
+	// The logs of blocks 6-8 are removed, the newest first, then the ones of the new fork follow
+	removed := sentLogs((*sent)[:6])
+	for i, lg := range removed {
+		want := copyLog(logsA[len(logsA)-1-i])
+		want.Removed = true
+		require.Equal(t, want, lg)
+	}
+	token, err := rpchelper.DecodeLogsResumeToken((*sent)[0].ResumeToken)
+	require.NoError(t, err)
+	require.Equal(t, rpchelper.LogsResumeToken{BlockNumber: 5, BlockHash: chainB.Blocks[4].Hash(), LogIndex: rpchelper.AllLogs}, token)
+	require.Equal(t, logsB[10:], sentLogs((*sent)[6:]))
+
+	// The token of the replaced block can't be resumed from
+	_, err = api.newResumableLogsSub(ctx, filters.FilterCriteria{ResumeToken: tokenA})
+	require.ErrorContains(t, err, "the chain forked after block 5")
+}
@@ -53,6 +53,9 @@ func TestNewFilters(t *testing.T) {
 	ok, err = api.UninstallFilter(ctx, ptf)
 	assert.Nil(err)
 	assert.Equal(ok, true)
+
+	_, err = api.GetFilterChanges(ctx, nf)
+	assert.ErrorIs(err, rpchelper.ErrFilterNotFound)
 }
 
 func TestLogsSubscribeAndUnsubscribe_WithoutConcurrentMapIssue(t *testing.T) {
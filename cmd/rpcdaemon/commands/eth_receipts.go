@@ -743,8 +743,8 @@ func marshalReceipt(receipt *types.Receipt, txn types.Transaction, chainConfig *
 		chainId = t.ChainID.ToBig()
 	case *types.DynamicFeeTransaction:
 		chainId = t.ChainID.ToBig()
-		// case *types.SignedBlobTx: // TODO: needs eip-4844 signer
-		// 	chainId = t.GetChainID().ToBig()
+	case *types.SignedBlobTx:
+		chainId = t.GetChainID().ToBig()
 	}
 
 	var from common.Address
@@ -790,8 +790,8 @@ func marshalReceipt(receipt *types.Receipt, txn types.Transaction, chainConfig *
 		if excessDataGas == nil {
 			log.Warn("excess data gas not set when trying to marshal blob tx")
 		} else {
-			fields["dataGasPrice"] = misc.GetDataGasPrice(excessDataGas)
-			fields["dataGasUsed"] = misc.GetDataGasUsed(numBlobs)
+			fields["dataGasPrice"] = (*hexutil.Big)(misc.GetDataGasPrice(excessDataGas))
+			fields["dataGasUsed"] = hexutil.Uint64(misc.GetDataGasUsed(numBlobs))
 		}
 	}
 	return fields
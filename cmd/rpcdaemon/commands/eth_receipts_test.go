@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	. "github.com/protolambda/ztyp/view"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/consensus/misc"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/params"
+)
+
+func TestMarshalBlobTxReceipt(t *testing.T) {
+	config := *params.AllProtocolChanges
+	config.CancunTime = big.NewInt(0)
+	signer := types.LatestSignerForChainID(config.ChainID)
+	key, _ := crypto.GenerateKey()
+
+	to := types.AddressSSZ(libcommon.HexToAddress("0xda7a"))
+	var blobTx types.SignedBlobTx
+	blobTx.Message.ChainID = Uint256View(*uint256.MustFromBig(config.ChainID))
+	blobTx.Message.GasTipCap = Uint256View(*uint256.NewInt(params.GWei))
+	blobTx.Message.GasFeeCap = Uint256View(*uint256.NewInt(10 * params.GWei))
+	blobTx.Message.Gas = Uint64View(21_000)
+	blobTx.Message.To = types.AddressOptionalSSZ{Address: &to}
+	blobTx.Message.AccessList = types.AccessListView{}
+	blobTx.Message.MaxFeePerDataGas = Uint256View(*uint256.NewInt(params.GWei))
+	blobTx.Message.BlobVersionedHashes = []libcommon.Hash{{0x01}, {0x01, 0x02}, {0x01, 0x03}}
+	txn, err := types.SignTx(&blobTx, *signer, key)
+	require.NoError(t, err)
+
+	header := &types.Header{Number: big.NewInt(1), Time: 1, BaseFee: big.NewInt(params.InitialBaseFee)}
+	receipt := &types.Receipt{Status: types.ReceiptStatusSuccessful, GasUsed: 21_000, BlockNumber: header.Number}
+	excessDataGas := big.NewInt(10 * params.DataGasPriceUpdateFraction)
+	fields := marshalReceipt(receipt, txn, &config, header, txn.Hash(), true, excessDataGas)
+
+	// The sender is recovered with the blob tx signer
+	require.Equal(t, crypto.PubkeyToAddress(key.PublicKey), fields["from"])
+	require.Equal(t, hexutil.Uint(types.BlobTxType), fields["type"])
+
+	out, err := json.Marshal(fields)
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(out, &decoded))
+	require.Equal(t, "0x60000", decoded["dataGasUsed"])
+	require.Equal(t, "0x"+misc.GetDataGasPrice(excessDataGas).Text(16), decoded["dataGasPrice"])
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/eth/protocols/eth"
 	"github.com/ledgerwatch/erigon/ethdb/privateapi"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi/remoteproto"
 	"github.com/ledgerwatch/erigon/rlp"
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
@@ -43,7 +44,7 @@ func TestEthSubscribe(t *testing.T) {
 	ctx := context.Background()
 	backendServer := privateapi.NewEthBackendServer(ctx, nil, m.DB, m.Notifications.Events, br, nil, nil, nil, false)
 	backendClient := direct.NewEthBackendClientDirect(backendServer)
-	backend := rpcservices.NewRemoteBackend(backendClient, m.DB, br)
+	backend := rpcservices.NewRemoteBackend(backendClient, remoteproto.NewEngineClientDirect(backendServer), remoteproto.NewPeersClientDirect(backendServer), remoteproto.NewCliqueClientDirect(privateapi.NewCliqueServer(nil)), m.DB, br)
 	ff := rpchelper.New(ctx, backend, nil, nil, func() {})
 
 	newHeads, id := ff.SubscribeNewHeads(16)
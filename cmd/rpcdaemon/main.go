@@ -7,7 +7,9 @@ import (
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/cli"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/commands"
 	"github.com/ledgerwatch/erigon/consensus/ethash"
+	"github.com/ledgerwatch/erigon/ethstats"
 	"github.com/ledgerwatch/erigon/turbo/logging"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/spf13/cobra"
 )
@@ -18,19 +20,35 @@ func main() {
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		ctx := cmd.Context()
 		logging.SetupLoggerCmd("rpcdaemon", cmd)
-		db, borDb, backend, txPool, mining, stateCache, blockReader, ff, agg, err := cli.RemoteServices(ctx, *cfg, log.Root(), rootCancel)
+		db, consensusDb, backend, txPool, mining, stateCache, blockReader, ff, agg, err := cli.RemoteServices(ctx, *cfg, log.Root(), rootCancel)
 		if err != nil {
 			log.Error("Could not connect to DB", "err", err)
 			return nil
 		}
 		defer db.Close()
-		if borDb != nil {
-			defer borDb.Close()
+		if consensusDb != nil {
+			defer consensusDb.Close()
+		}
+
+		if cfg.Ethstats != "" {
+			ethstatsCfg := ethstats.Config{URL: cfg.Ethstats, Backend: backend, DB: db, BlockReader: blockReader, TxPool: txPool}
+			if r, ok := blockReader.(*snapshotsync.BlockReaderWithSnapshots); ok {
+				ethstatsCfg.Snapshots = r.Snapshots()
+			}
+			service, err := ethstats.New(ethstatsCfg)
+			if err != nil {
+				log.Error("Could not start ethstats", "err", err)
+				return nil
+			}
+			if err := service.Start(); err != nil {
+				return err
+			}
+			defer service.Stop()
 		}
 
 		// TODO: Replace with correct consensus Engine
 		engine := ethash.NewFaker()
-		apiList := commands.APIList(db, borDb, backend, txPool, mining, ff, stateCache, blockReader, agg, *cfg, engine)
+		apiList := commands.APIList(db, consensusDb, backend, txPool, mining, ff, stateCache, blockReader, agg, *cfg, engine)
 		if err := cli.StartRpcServer(ctx, *cfg, apiList, nil); err != nil {
 			log.Error(err.Error())
 			return nil
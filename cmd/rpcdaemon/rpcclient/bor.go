@@ -0,0 +1,66 @@
+package rpcclient
+
+import (
+	"context"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/commands"
+	"github.com/ledgerwatch/erigon/consensus/bor/valset"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// BorClient calls the bor_ namespace, see commands.BorAPI. The methods taking a block number
+// use the latest block for nil.
+type BorClient struct {
+	c *rpc.Client
+}
+
+func (bc *BorClient) GetSnapshot(ctx context.Context, number *rpc.BlockNumber) (*commands.Snapshot, error) {
+	var snap *commands.Snapshot
+	err := bc.c.CallContext(ctx, &snap, "bor_getSnapshot", blockNumberPtrArg(number))
+	return snap, err
+}
+
+func (bc *BorClient) GetAuthor(ctx context.Context, number *rpc.BlockNumber) (*common.Address, error) {
+	var author *common.Address
+	err := bc.c.CallContext(ctx, &author, "bor_getAuthor", blockNumberPtrArg(number))
+	return author, err
+}
+
+func (bc *BorClient) GetSnapshotAtHash(ctx context.Context, hash common.Hash) (*commands.Snapshot, error) {
+	var snap *commands.Snapshot
+	err := bc.c.CallContext(ctx, &snap, "bor_getSnapshotAtHash", hash)
+	return snap, err
+}
+
+func (bc *BorClient) GetSigners(ctx context.Context, number *rpc.BlockNumber) ([]common.Address, error) {
+	var signers []common.Address
+	err := bc.c.CallContext(ctx, &signers, "bor_getSigners", blockNumberPtrArg(number))
+	return signers, err
+}
+
+func (bc *BorClient) GetSignersAtHash(ctx context.Context, hash common.Hash) ([]common.Address, error) {
+	var signers []common.Address
+	err := bc.c.CallContext(ctx, &signers, "bor_getSignersAtHash", hash)
+	return signers, err
+}
+
+func (bc *BorClient) GetCurrentProposer(ctx context.Context) (common.Address, error) {
+	var proposer common.Address
+	err := bc.c.CallContext(ctx, &proposer, "bor_getCurrentProposer")
+	return proposer, err
+}
+
+func (bc *BorClient) GetCurrentValidators(ctx context.Context) ([]*valset.Validator, error) {
+	var validators []*valset.Validator
+	err := bc.c.CallContext(ctx, &validators, "bor_getCurrentValidators")
+	return validators, err
+}
+
+// GetRootHash returns the root of the merkle tree of the headers from start to end, as used by checkpoints.
+func (bc *BorClient) GetRootHash(ctx context.Context, start uint64, end uint64) (string, error) {
+	var root string
+	err := bc.c.CallContext(ctx, &root, "bor_getRootHash", start, end)
+	return root, err
+}
@@ -0,0 +1,140 @@
+// Package rpcclient provides typed access to the Erigon specific JSON-RPC namespaces
+// (erigon_, ots_, trace_ and bor_) on top of rpc.Client. Its methods mirror the
+// interfaces of cmd/rpcdaemon/commands and decode into the same types.
+package rpcclient
+
+import (
+	"context"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// Client wraps an rpc.Client, see Erigon, Otterscan, Trace and Bor for the namespaces.
+type Client struct {
+	c *rpc.Client
+}
+
+// Dial connects a client to the given URL, any of the schemes supported by rpc.Dial:
+// http(s), ws(s) and tcp (rpcdaemon --tcp).
+func Dial(rawurl string) (*Client, error) {
+	return DialContext(context.Background(), rawurl)
+}
+
+func DialContext(ctx context.Context, rawurl string) (*Client, error) {
+	c, err := rpc.DialContext(ctx, rawurl)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(c), nil
+}
+
+// NewClient creates a client that uses the given RPC client.
+func NewClient(c *rpc.Client) *Client {
+	return &Client{c: c}
+}
+
+func (c *Client) Close() {
+	c.c.Close()
+}
+
+// Client gives access to the underlying RPC client, for the methods of the other namespaces.
+func (c *Client) Client() *rpc.Client {
+	return c.c
+}
+
+func (c *Client) Erigon() *ErigonClient {
+	return &ErigonClient{c: c.c}
+}
+
+func (c *Client) Otterscan() *OtterscanClient {
+	return &OtterscanClient{c: c.c}
+}
+
+func (c *Client) Trace() *TraceClient {
+	return &TraceClient{c: c.c}
+}
+
+func (c *Client) Bor() *BorClient {
+	return &BorClient{c: c.c}
+}
+
+// toFilterArg encodes the criteria the way filters.FilterCriteria.UnmarshalJSON expects them
+func toFilterArg(crit filters.FilterCriteria) interface{} {
+	arg := map[string]interface{}{
+		"address": crit.Addresses,
+		"topics":  crit.Topics,
+	}
+	if crit.Addresses == nil {
+		arg["address"] = []libcommon.Address{}
+	}
+	if crit.ResumeToken != nil {
+		arg["resumeToken"] = hexutility.Bytes(crit.ResumeToken)
+	}
+	if crit.Backfill {
+		arg["backfill"] = true
+	}
+	if crit.BlockHash != nil {
+		arg["blockHash"] = *crit.BlockHash
+		return arg
+	}
+	if crit.FromBlock != nil {
+		arg["fromBlock"] = blockNumberArg(rpc.BlockNumber(crit.FromBlock.Int64()))
+	}
+	if crit.ToBlock != nil {
+		arg["toBlock"] = blockNumberArg(rpc.BlockNumber(crit.ToBlock.Int64()))
+	}
+	return arg
+}
+
+// blockNumberArg encodes a block number the way rpc.BlockNumber.UnmarshalJSON expects it. rpc.BlockNumber has no
+// encoding of its own, it would be sent as a plain number, which is wrong for the named blocks.
+func blockNumberArg(bn rpc.BlockNumber) string {
+	switch bn {
+	case rpc.EarliestBlockNumber:
+		return "earliest"
+	case rpc.LatestBlockNumber:
+		return "latest"
+	case rpc.PendingBlockNumber:
+		return "pending"
+	case rpc.SafeBlockNumber:
+		return "safe"
+	case rpc.FinalizedBlockNumber:
+		return "finalized"
+	case rpc.LatestExecutedBlockNumber:
+		return "latestExecuted"
+	}
+	return hexutil.EncodeUint64(uint64(bn))
+}
+
+// blockNumberPtrArg is blockNumberArg for the optional block numbers, nil is sent as null.
+func blockNumberPtrArg(bn *rpc.BlockNumber) interface{} {
+	if bn == nil {
+		return nil
+	}
+	return blockNumberArg(*bn)
+}
+
+// blockNumberOrHashArg encodes bnh the way rpc.BlockNumberOrHash.UnmarshalJSON expects it.
+func blockNumberOrHashArg(bnh rpc.BlockNumberOrHash) interface{} {
+	if bnh.BlockHash != nil {
+		return bnh
+	}
+	arg := map[string]interface{}{"blockNumber": blockNumberPtrArg(bnh.BlockNumber)}
+	if bnh.RequireCanonical {
+		arg["requireCanonical"] = true
+	}
+	return arg
+}
+
+// blockNumberOrHashPtrArg is blockNumberOrHashArg for the optional blocks, nil is sent as null.
+func blockNumberOrHashPtrArg(bnh *rpc.BlockNumberOrHash) interface{} {
+	if bnh == nil {
+		return nil
+	}
+	return blockNumberOrHashArg(*bnh)
+}
@@ -0,0 +1,475 @@
+package rpcclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/cli/httpcfg"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/commands"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/consensus/bor/valset"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/rpc/rpccfg"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/ledgerwatch/erigon/turbo/stages"
+)
+
+func newTestClient(t *testing.T) (*Client, *stages.MockSentry, *core.ChainPack, *rpchelper.Filters) {
+	m, chain, _ := rpcdaemontest.CreateTestSentry(t)
+	agg := m.HistoryV3Components()
+	br := snapshotsync.NewBlockReaderWithSnapshots(m.BlockSnapshots, m.TransactionsV3)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	ctx, conn := rpcdaemontest.CreateTestGrpcConn(t, m)
+	ff := rpchelper.New(ctx, nil, nil, txpool.NewMiningClient(conn), func() {})
+	base := commands.NewBaseApi(ff, stateCache, br, agg, false, rpccfg.DefaultEvmCallTimeout, m.Engine, m.Dirs)
+
+	srv := rpc.NewServer(50, false, false)
+	require.NoError(t, srv.RegisterName("eth", commands.EthAPI(commands.NewEthAPI(base, m.DB, nil, nil, nil, 5000000, 100_000))))
+	require.NoError(t, srv.RegisterName("erigon", commands.ErigonAPI(commands.NewErigonAPI(base, m.DB, nil))))
+	require.NoError(t, srv.RegisterName("ots", commands.OtterscanAPI(commands.NewOtterscanAPI(base, m.DB))))
+	require.NoError(t, srv.RegisterName("trace", commands.TraceAPI(commands.NewTraceAPI(base, m.DB, &httpcfg.HttpCfg{}))))
+	c := NewClient(rpc.DialInProc(srv))
+	t.Cleanup(func() {
+		c.Close()
+		srv.Stop()
+	})
+	return c, m, chain, ff
+}
+
+func TestErigonClient(t *testing.T) {
+	c, m, chain, _ := newTestClient(t)
+	ctx := context.Background()
+	ec := c.Erigon()
+
+	forks, err := ec.Forks(ctx)
+	require.NoError(t, err)
+	require.Equal(t, m.Genesis.Hash(), forks.GenesisHash)
+
+	number, err := ec.BlockNumber(ctx, nil)
+	require.NoError(t, err)
+	require.Equal(t, chain.TopBlock.NumberU64(), uint64(number))
+
+	header, err := ec.GetHeaderByNumber(ctx, 5)
+	require.NoError(t, err)
+	require.Equal(t, chain.Headers[4].Hash(), header.Hash())
+	header, err = ec.GetHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	require.NoError(t, err)
+	require.Equal(t, chain.TopBlock.Hash(), header.Hash())
+	header, err = ec.GetHeaderByHash(ctx, chain.Headers[2].Hash())
+	require.NoError(t, err)
+	require.Equal(t, chain.Headers[2].Hash(), header.Hash())
+
+	block, err := ec.GetBlockByTimestamp(ctx, rpc.Timestamp(chain.Headers[3].Time), false)
+	require.NoError(t, err)
+	require.Equal(t, chain.Headers[3].Hash().Hex(), block["hash"])
+
+	// The Poly contract emits a DeployEvent
+	deploy := chain.Blocks[9]
+	logsByHash, err := ec.GetLogsByHash(ctx, deploy.Hash())
+	require.NoError(t, err)
+	require.Len(t, logsByHash, 1)
+	require.Len(t, logsByHash[0], 1)
+	poly := chain.Receipts[8][0].ContractAddress
+	require.Equal(t, poly, logsByHash[0][0].Address)
+
+	receipts, err := ec.GetBlockReceiptsByBlockHash(ctx, deploy.Hash())
+	require.NoError(t, err)
+	require.Len(t, receipts, 1)
+	require.Equal(t, deploy.Transactions()[0].Hash().Hex(), receipts[0]["transactionHash"])
+
+	if m.HistoryV3 {
+		t.Skip("erigon_getLogs by range is not supported by Erigon3")
+	}
+	logs, err := ec.GetLogs(ctx, filters.FilterCriteria{
+		FromBlock: big.NewInt(int64(deploy.NumberU64())),
+		ToBlock:   big.NewInt(int64(deploy.NumberU64())),
+		Addresses: []libcommon.Address{poly},
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	require.Equal(t, deploy.Transactions()[0].Hash(), logs[0].TxHash)
+	require.Equal(t, deploy.Time(), logs[0].Timestamp)
+	logs, err = ec.GetLogs(ctx, filters.FilterCriteria{
+		FromBlock: big.NewInt(1),
+		ToBlock:   big.NewInt(int64(deploy.NumberU64() - 1)),
+		Addresses: []libcommon.Address{poly},
+	})
+	require.NoError(t, err)
+	require.Empty(t, logs)
+
+	latest, err := ec.GetLatestLogs(ctx, filters.FilterCriteria{Addresses: []libcommon.Address{poly}}, filters.LogFilterOptions{LogCount: 1})
+	require.NoError(t, err)
+	require.Len(t, latest, 1)
+	require.Equal(t, deploy.Transactions()[0].Hash(), latest[0].TxHash)
+}
+
+func TestOtterscanClient(t *testing.T) {
+	c, m, chain, _ := newTestClient(t)
+	ctx := context.Background()
+	oc := c.Otterscan()
+
+	level, err := oc.GetApiLevel(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint8(commands.API_LEVEL), level)
+
+	deploy := chain.Blocks[2].Transactions()[0]
+	sender, err := deploy.Sender(*types.MakeSigner(m.ChainConfig, 3))
+	require.NoError(t, err)
+	hash, err := oc.GetTransactionBySenderAndNonce(ctx, sender, deploy.GetNonce())
+	require.NoError(t, err)
+	require.NotNil(t, hash)
+	require.Equal(t, deploy.Hash(), *hash)
+	hash, err = oc.GetTransactionBySenderAndNonce(ctx, sender, 1000)
+	require.NoError(t, err)
+	require.Nil(t, hash)
+
+	token := chain.Receipts[2][0].ContractAddress
+	creator, err := oc.GetContractCreator(ctx, token)
+	require.NoError(t, err)
+	require.NotNil(t, creator)
+	require.Equal(t, deploy.Hash(), creator.Tx)
+	require.Equal(t, sender, creator.Creator)
+	creator, err = oc.GetContractCreator(ctx, sender)
+	require.NoError(t, err)
+	require.Nil(t, creator)
+
+	hasCode, err := oc.HasCode(ctx, token, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+	require.NoError(t, err)
+	require.True(t, hasCode)
+	hasCode, err = oc.HasCode(ctx, token, rpc.BlockNumberOrHashWithNumber(2))
+	require.NoError(t, err)
+	require.False(t, hasCode)
+
+	details, err := oc.GetBlockDetails(ctx, 3)
+	require.NoError(t, err)
+	require.Contains(t, details, "block")
+	details, err = oc.GetBlockDetailsByHash(ctx, chain.Headers[2].Hash())
+	require.NoError(t, err)
+	require.Contains(t, details, "block")
+
+	// The block of many transfers
+	txs, err := oc.GetBlockTransactions(ctx, 6, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, txs["receipts"], 10)
+
+	// Poly deploys a contract with CREATE2 and calls it to self destruct
+	deployAndDestruct := chain.Blocks[9].Transactions()[0]
+	entries, err := oc.TraceTransaction(ctx, deployAndDestruct.Hash())
+	require.NoError(t, err)
+	var kinds []string
+	for _, e := range entries {
+		kinds = append(kinds, e.Type)
+	}
+	require.Contains(t, kinds, "CREATE2")
+	require.Contains(t, kinds, "SELFDESTRUCT")
+
+	ops, err := oc.GetInternalOperations(ctx, deployAndDestruct.Hash())
+	require.NoError(t, err)
+	require.Len(t, ops, 2)
+	ops, err = oc.GetInternalOperations(ctx, deploy.Hash())
+	require.NoError(t, err)
+	require.Empty(t, ops)
+
+	txErr, err := oc.GetTransactionError(ctx, deploy.Hash())
+	require.NoError(t, err)
+	require.Empty(t, txErr)
+
+	if m.HistoryV3 {
+		t.Skip("ots_searchTransactions is not supported by Erigon3")
+	}
+	// The pages are made of whole blocks
+	page, err := oc.SearchTransactionsBefore(ctx, sender, 0, 5)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(page.Txs), 5)
+	require.Len(t, page.Receipts, len(page.Txs))
+	require.True(t, page.FirstPage)
+	page, err = oc.SearchTransactionsAfter(ctx, sender, 0, 1)
+	require.NoError(t, err)
+	require.Len(t, page.Txs, 1)
+	require.Equal(t, chain.Blocks[0].Transactions()[0].Hash(), page.Txs[0].Hash)
+}
+
+func TestTraceClient(t *testing.T) {
+	c, m, chain, _ := newTestClient(t)
+	ctx := context.Background()
+	tc := c.Trace()
+
+	transfer := chain.Blocks[0].Transactions()[0]
+	sender, err := transfer.Sender(*types.MakeSigner(m.ChainConfig, 1))
+	require.NoError(t, err)
+
+	traces, err := tc.Block(ctx, 1, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, traces)
+	require.Equal(t, transfer.Hash(), *traces[0].TransactionHash)
+
+	traces, err = tc.Transaction(ctx, transfer.Hash(), nil)
+	require.NoError(t, err)
+	require.Len(t, traces, 1)
+	trace, err := tc.Get(ctx, transfer.Hash(), []hexutil.Uint64{0}, nil)
+	require.NoError(t, err)
+	require.Nil(t, trace) // A plain transfer has the top level trace only
+
+	replayed, err := tc.ReplayTransaction(ctx, transfer.Hash(), []string{"trace", "stateDiff"}, nil)
+	require.NoError(t, err)
+	require.Len(t, replayed.Trace, 1)
+	require.Contains(t, replayed.StateDiff, sender)
+
+	block, err := tc.ReplayBlockTransactions(ctx, rpc.BlockNumberOrHashWithHash(chain.Blocks[5].Hash(), true), []string{"trace"}, nil)
+	require.NoError(t, err)
+	require.Len(t, block, chain.Blocks[5].Transactions().Len())
+
+	raw, err := tc.RawTransaction(ctx, transfer.Hash(), []string{"trace"})
+	require.Error(t, err) // Not implemented by the server
+	require.Nil(t, raw)
+
+	to := libcommon.Address{2}
+	value := (*hexutil.Big)(big.NewInt(1))
+	call := commands.TraceCallParam{From: &sender, To: &to, Value: value}
+	result, err := tc.Call(ctx, call, []string{"trace"}, nil)
+	require.NoError(t, err)
+	require.Len(t, result.Trace, 1)
+
+	results, err := tc.CallMany(ctx, []TraceCallManyParam{
+		{Call: call, Types: []string{"trace"}},
+		{Call: call, Types: []string{"stateDiff"}},
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Len(t, results[0].Trace, 1)
+	require.Contains(t, results[1].StateDiff, to)
+
+	from, toBlock := hexutil.Uint64(1), hexutil.Uint64(2)
+	filtered, err := tc.Filter(ctx, commands.TraceFilterRequest{FromBlock: &from, ToBlock: &toBlock, FromAddress: []*libcommon.Address{&sender}}, nil)
+	require.NoError(t, err)
+	require.Len(t, filtered, 2)
+	require.Equal(t, transfer.Hash(), *filtered[0].TransactionHash)
+}
+
+type testBorAPI struct{}
+
+var testBorValidators = []*valset.Validator{
+	{ID: 1, Address: libcommon.Address{1}, VotingPower: 10},
+	{ID: 2, Address: libcommon.Address{2}, VotingPower: 20},
+}
+
+func (testBorAPI) GetSnapshot(number *rpc.BlockNumber) (*commands.Snapshot, error) {
+	n := rpc.LatestBlockNumber
+	if number != nil {
+		n = *number
+	}
+	return &commands.Snapshot{Number: uint64(n.Int64() + 100)}, nil
+}
+func (testBorAPI) GetAuthor(number *rpc.BlockNumber) (*libcommon.Address, error) {
+	if number == nil {
+		return nil, nil
+	}
+	return &libcommon.Address{byte(*number)}, nil
+}
+func (testBorAPI) GetSnapshotAtHash(hash libcommon.Hash) (*commands.Snapshot, error) {
+	return &commands.Snapshot{Hash: hash}, nil
+}
+func (testBorAPI) GetSigners(number *rpc.BlockNumber) ([]libcommon.Address, error) {
+	return []libcommon.Address{{byte(*number)}}, nil
+}
+func (testBorAPI) GetSignersAtHash(hash libcommon.Hash) ([]libcommon.Address, error) {
+	return []libcommon.Address{libcommon.BytesToAddress(hash[:])}, nil
+}
+func (testBorAPI) GetCurrentProposer() (libcommon.Address, error) {
+	return testBorValidators[1].Address, nil
+}
+func (testBorAPI) GetCurrentValidators() ([]*valset.Validator, error) {
+	return testBorValidators, nil
+}
+func (testBorAPI) GetRootHash(start uint64, end uint64) (string, error) {
+	return hexutil.EncodeUint64(start*1000 + end), nil
+}
+
+// The mock chain is not a Bor one, the test checks the encoding of the calls against a stub instead
+func TestBorClient(t *testing.T) {
+	srv := rpc.NewServer(50, false, false)
+	defer srv.Stop()
+	require.NoError(t, srv.RegisterName("bor", commands.BorAPI(testBorAPI{})))
+	c := NewClient(rpc.DialInProc(srv))
+	defer c.Close()
+	ctx := context.Background()
+	bc := c.Bor()
+
+	number := rpc.BlockNumber(7)
+	snap, err := bc.GetSnapshot(ctx, &number)
+	require.NoError(t, err)
+	require.Equal(t, uint64(107), snap.Number)
+	snap, err = bc.GetSnapshot(ctx, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(99), snap.Number)
+	hash := libcommon.HexToHash("0x1234")
+	snap, err = bc.GetSnapshotAtHash(ctx, hash)
+	require.NoError(t, err)
+	require.Equal(t, hash, snap.Hash)
+
+	author, err := bc.GetAuthor(ctx, &number)
+	require.NoError(t, err)
+	require.Equal(t, &libcommon.Address{7}, author)
+	author, err = bc.GetAuthor(ctx, nil)
+	require.NoError(t, err)
+	require.Nil(t, author)
+
+	signers, err := bc.GetSigners(ctx, &number)
+	require.NoError(t, err)
+	require.Equal(t, []libcommon.Address{{7}}, signers)
+	signers, err = bc.GetSignersAtHash(ctx, hash)
+	require.NoError(t, err)
+	require.Equal(t, []libcommon.Address{libcommon.BytesToAddress(hash[:])}, signers)
+
+	proposer, err := bc.GetCurrentProposer(ctx)
+	require.NoError(t, err)
+	require.Equal(t, testBorValidators[1].Address, proposer)
+	validators, err := bc.GetCurrentValidators(ctx)
+	require.NoError(t, err)
+	require.Equal(t, testBorValidators, validators)
+
+	root, err := bc.GetRootHash(ctx, 3, 5)
+	require.NoError(t, err)
+	require.Equal(t, hexutil.EncodeUint64(3005), root)
+}
+
+func TestSubscriptions(t *testing.T) {
+	c, _, chain, ff := newTestClient(t)
+	ctx := context.Background()
+
+	headers := make(chan *types.Header)
+	headSub, err := c.SubscribeNewHeads(ctx, headers)
+	require.NoError(t, err)
+	defer headSub.Unsubscribe()
+
+	poly := chain.Receipts[8][0].ContractAddress
+	logs := make(chan *types.Log)
+	logSub, err := c.SubscribeLogs(ctx, filters.FilterCriteria{Addresses: []libcommon.Address{poly}}, logs)
+	require.NoError(t, err)
+	defer logSub.Unsubscribe()
+
+	var buf bytes.Buffer
+	require.NoError(t, rlp.Encode(&buf, chain.TopBlock.Header()))
+	lg := chain.Receipts[9][0].Logs[0]
+	polyLog := &remote.SubscribeLogsReply{
+		Address:         gointerfaces.ConvertAddressToH160(lg.Address),
+		Data:            lg.Data,
+		BlockNumber:     lg.BlockNumber,
+		BlockHash:       gointerfaces.ConvertHashToH256(chain.Blocks[9].Hash()),
+		TransactionHash: gointerfaces.ConvertHashToH256(lg.TxHash),
+	}
+	otherLog := &remote.SubscribeLogsReply{
+		Address:         gointerfaces.ConvertAddressToH160(libcommon.Address{1}),
+		BlockHash:       polyLog.BlockHash,
+		TransactionHash: polyLog.TransactionHash,
+	}
+	for _, topic := range lg.Topics {
+		polyLog.Topics = append(polyLog.Topics, gointerfaces.ConvertHashToH256(topic))
+	}
+
+	// The server subscribes to the filters in the background, repeat the events until they are delivered
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(10 * time.Second)
+	var gotHeader *types.Header
+	var gotLog *types.Log
+	for gotHeader == nil || gotLog == nil {
+		select {
+		case <-ticker.C:
+			if gotHeader == nil {
+				ff.OnNewEvent(&remote.SubscribeReply{Type: remote.Event_HEADER, Data: buf.Bytes()})
+			}
+			if gotLog == nil {
+				ff.OnNewLogs(otherLog)
+				ff.OnNewLogs(polyLog)
+			}
+		case gotHeader = <-headers:
+		case gotLog = <-logs:
+		case err := <-headSub.Err():
+			t.Fatal(err)
+		case err := <-logSub.Err():
+			t.Fatal(err)
+		case <-timeout:
+			t.Fatal("no notification received")
+		}
+	}
+	require.Equal(t, chain.TopBlock.Hash(), gotHeader.Hash())
+	require.Equal(t, lg.Address, gotLog.Address)
+	require.Equal(t, lg.Topics, gotLog.Topics)
+	require.Equal(t, lg.TxHash, gotLog.TxHash)
+}
+
+func TestSubscribeLogsFrom(t *testing.T) {
+	c, _, chain, _ := newTestClient(t)
+	ctx := context.Background()
+
+	logs := make(chan *rpchelper.ResumableLog)
+	sub, err := c.SubscribeLogsFrom(ctx, filters.FilterCriteria{FromBlock: big.NewInt(0)}, logs)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	lg := chain.Receipts[9][0].Logs[0]
+	var got *rpchelper.ResumableLog
+	select {
+	case got = <-logs:
+	case err := <-sub.Err():
+		t.Fatal(err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("no notification received")
+	}
+	require.Equal(t, lg.Address, got.Address)
+	require.Equal(t, chain.Blocks[9].Hash(), got.BlockHash)
+	token, err := rpchelper.DecodeLogsResumeToken(got.ResumeToken)
+	require.NoError(t, err)
+	require.Equal(t, rpchelper.LogsResumeToken{BlockNumber: lg.BlockNumber, BlockHash: got.BlockHash, LogIndex: uint64(got.Index)}, token)
+
+	resumed, err := c.SubscribeLogsFrom(ctx, filters.FilterCriteria{ResumeToken: got.ResumeToken}, logs)
+	require.NoError(t, err)
+	resumed.Unsubscribe()
+
+	token.BlockHash = libcommon.Hash{1}
+	_, err = c.SubscribeLogsFrom(ctx, filters.FilterCriteria{ResumeToken: token.Encode()}, logs)
+	require.ErrorContains(t, err, "is not canonical")
+}
+
+func TestBlockNumberArg(t *testing.T) {
+	for _, bn := range []rpc.BlockNumber{rpc.LatestExecutedBlockNumber, rpc.FinalizedBlockNumber, rpc.SafeBlockNumber, rpc.PendingBlockNumber, rpc.LatestBlockNumber, rpc.EarliestBlockNumber, 1, 18} {
+		data, err := json.Marshal(blockNumberArg(bn))
+		require.NoError(t, err)
+		var num rpc.BlockNumber
+		require.NoError(t, json.Unmarshal(data, &num), string(data))
+		require.Equal(t, bn, num, string(data))
+
+		data, err = json.Marshal(blockNumberOrHashArg(rpc.BlockNumberOrHashWithNumber(bn)))
+		require.NoError(t, err)
+		var bnh rpc.BlockNumberOrHash
+		require.NoError(t, json.Unmarshal(data, &bnh), string(data))
+		n, ok := bnh.Number()
+		require.True(t, ok, string(data))
+		require.Equal(t, bn, n, string(data))
+	}
+	hash := libcommon.Hash{1}
+	data, err := json.Marshal(blockNumberOrHashArg(rpc.BlockNumberOrHashWithHash(hash, true)))
+	require.NoError(t, err)
+	var bnh rpc.BlockNumberOrHash
+	require.NoError(t, json.Unmarshal(data, &bnh))
+	require.Equal(t, hash, *bnh.BlockHash)
+	require.True(t, bnh.RequireCanonical)
+}
@@ -0,0 +1,92 @@
+package rpcclient
+
+import (
+	"context"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/commands"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// ErigonClient calls the erigon_ namespace, see commands.ErigonAPI
+type ErigonClient struct {
+	c *rpc.Client
+}
+
+func (ec *ErigonClient) Forks(ctx context.Context) (commands.Forks, error) {
+	var forks commands.Forks
+	err := ec.c.CallContext(ctx, &forks, "erigon_forks")
+	return forks, err
+}
+
+// BlockNumber returns the number of the given block, nil - means the latest executed one.
+func (ec *ErigonClient) BlockNumber(ctx context.Context, number *rpc.BlockNumber) (hexutil.Uint64, error) {
+	var result hexutil.Uint64
+	err := ec.c.CallContext(ctx, &result, "erigon_blockNumber", blockNumberPtrArg(number))
+	return result, err
+}
+
+func (ec *ErigonClient) GetHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	var header *types.Header
+	err := ec.c.CallContext(ctx, &header, "erigon_getHeaderByNumber", blockNumberArg(number))
+	return header, err
+}
+
+func (ec *ErigonClient) GetHeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	var header *types.Header
+	err := ec.c.CallContext(ctx, &header, "erigon_getHeaderByHash", hash)
+	return header, err
+}
+
+func (ec *ErigonClient) GetBlockByTimestamp(ctx context.Context, timeStamp rpc.Timestamp, fullTx bool) (map[string]interface{}, error) {
+	var block map[string]interface{}
+	err := ec.c.CallContext(ctx, &block, "erigon_getBlockByTimestamp", timeStamp, fullTx)
+	return block, err
+}
+
+func (ec *ErigonClient) GetBalanceChangesInBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (map[common.Address]*hexutil.Big, error) {
+	var changes map[common.Address]*hexutil.Big
+	err := ec.c.CallContext(ctx, &changes, "erigon_getBalanceChangesInBlock", blockNumberOrHashArg(blockNrOrHash))
+	return changes, err
+}
+
+func (ec *ErigonClient) GetLogsByHash(ctx context.Context, hash common.Hash) ([][]*types.Log, error) {
+	var logs [][]*types.Log
+	err := ec.c.CallContext(ctx, &logs, "erigon_getLogsByHash", hash)
+	return logs, err
+}
+
+func (ec *ErigonClient) GetLogs(ctx context.Context, crit filters.FilterCriteria) (types.ErigonLogs, error) {
+	var logs types.ErigonLogs
+	err := ec.c.CallContext(ctx, &logs, "erigon_getLogs", toFilterArg(crit))
+	return logs, err
+}
+
+func (ec *ErigonClient) GetLatestLogs(ctx context.Context, crit filters.FilterCriteria, logOptions filters.LogFilterOptions) (types.ErigonLogs, error) {
+	var logs types.ErigonLogs
+	err := ec.c.CallContext(ctx, &logs, "erigon_getLatestLogs", toFilterArg(crit), logOptions)
+	return logs, err
+}
+
+func (ec *ErigonClient) GetBlockReceiptsByBlockHash(ctx context.Context, cannonicalBlockHash common.Hash) ([]map[string]interface{}, error) {
+	var receipts []map[string]interface{}
+	err := ec.c.CallContext(ctx, &receipts, "erigon_getBlockReceiptsByBlockHash", cannonicalBlockHash)
+	return receipts, err
+}
+
+func (ec *ErigonClient) CumulativeChainTraffic(ctx context.Context, blockNr rpc.BlockNumber) (commands.ChainTraffic, error) {
+	var traffic commands.ChainTraffic
+	err := ec.c.CallContext(ctx, &traffic, "erigon_cumulativeChainTraffic", blockNumberArg(blockNr))
+	return traffic, err
+}
+
+func (ec *ErigonClient) NodeInfo(ctx context.Context) ([]p2p.NodeInfo, error) {
+	var info []p2p.NodeInfo
+	err := ec.c.CallContext(ctx, &info, "erigon_nodeInfo")
+	return info, err
+}
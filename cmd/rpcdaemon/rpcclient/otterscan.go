@@ -0,0 +1,90 @@
+package rpcclient
+
+import (
+	"context"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/commands"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// OtterscanClient calls the ots_ namespace, see commands.OtterscanAPI
+type OtterscanClient struct {
+	c *rpc.Client
+}
+
+func (oc *OtterscanClient) GetApiLevel(ctx context.Context) (uint8, error) {
+	var level uint8
+	err := oc.c.CallContext(ctx, &level, "ots_getApiLevel")
+	return level, err
+}
+
+func (oc *OtterscanClient) GetInternalOperations(ctx context.Context, hash common.Hash) ([]*commands.InternalOperation, error) {
+	var ops []*commands.InternalOperation
+	err := oc.c.CallContext(ctx, &ops, "ots_getInternalOperations", hash)
+	return ops, err
+}
+
+func (oc *OtterscanClient) SearchTransactionsBefore(ctx context.Context, addr common.Address, blockNum uint64, pageSize uint16) (*commands.TransactionsWithReceipts, error) {
+	var result *commands.TransactionsWithReceipts
+	err := oc.c.CallContext(ctx, &result, "ots_searchTransactionsBefore", addr, blockNum, pageSize)
+	return result, err
+}
+
+func (oc *OtterscanClient) SearchTransactionsAfter(ctx context.Context, addr common.Address, blockNum uint64, pageSize uint16) (*commands.TransactionsWithReceipts, error) {
+	var result *commands.TransactionsWithReceipts
+	err := oc.c.CallContext(ctx, &result, "ots_searchTransactionsAfter", addr, blockNum, pageSize)
+	return result, err
+}
+
+func (oc *OtterscanClient) GetBlockDetails(ctx context.Context, number rpc.BlockNumber) (map[string]interface{}, error) {
+	var details map[string]interface{}
+	err := oc.c.CallContext(ctx, &details, "ots_getBlockDetails", blockNumberArg(number))
+	return details, err
+}
+
+func (oc *OtterscanClient) GetBlockDetailsByHash(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
+	var details map[string]interface{}
+	err := oc.c.CallContext(ctx, &details, "ots_getBlockDetailsByHash", hash)
+	return details, err
+}
+
+func (oc *OtterscanClient) GetBlockTransactions(ctx context.Context, number rpc.BlockNumber, pageNumber uint8, pageSize uint8) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := oc.c.CallContext(ctx, &result, "ots_getBlockTransactions", blockNumberArg(number), pageNumber, pageSize)
+	return result, err
+}
+
+func (oc *OtterscanClient) HasCode(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (bool, error) {
+	var hasCode bool
+	err := oc.c.CallContext(ctx, &hasCode, "ots_hasCode", address, blockNumberOrHashArg(blockNrOrHash))
+	return hasCode, err
+}
+
+func (oc *OtterscanClient) TraceTransaction(ctx context.Context, hash common.Hash) ([]*commands.TraceEntry, error) {
+	var entries []*commands.TraceEntry
+	err := oc.c.CallContext(ctx, &entries, "ots_traceTransaction", hash)
+	return entries, err
+}
+
+func (oc *OtterscanClient) GetTransactionError(ctx context.Context, hash common.Hash) (hexutility.Bytes, error) {
+	var result hexutility.Bytes
+	err := oc.c.CallContext(ctx, &result, "ots_getTransactionError", hash)
+	return result, err
+}
+
+// GetTransactionBySenderAndNonce returns nil if the sender has not sent a transaction with the nonce yet.
+func (oc *OtterscanClient) GetTransactionBySenderAndNonce(ctx context.Context, addr common.Address, nonce uint64) (*common.Hash, error) {
+	var hash *common.Hash
+	err := oc.c.CallContext(ctx, &hash, "ots_getTransactionBySenderAndNonce", addr, nonce)
+	return hash, err
+}
+
+// GetContractCreator returns nil if the address is not a contract.
+func (oc *OtterscanClient) GetContractCreator(ctx context.Context, addr common.Address) (*commands.ContractCreatorData, error) {
+	var creator *commands.ContractCreatorData
+	err := oc.c.CallContext(ctx, &creator, "ots_getContractCreator", addr)
+	return creator, err
+}
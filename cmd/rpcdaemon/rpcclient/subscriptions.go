@@ -0,0 +1,38 @@
+package rpcclient
+
+import (
+	"context"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+)
+
+// The subscriptions need a transport with notifications (ws, tcp or in-process) and the
+// eth namespace enabled on the server.
+
+// SubscribeNewHeads sends the header of each new block to ch.
+func (c *Client) SubscribeNewHeads(ctx context.Context, ch chan<- *types.Header) (*rpc.ClientSubscription, error) {
+	return c.c.EthSubscribe(ctx, ch, "newHeads")
+}
+
+// SubscribeLogs sends the logs of the new blocks matching crit to ch.
+func (c *Client) SubscribeLogs(ctx context.Context, crit filters.FilterCriteria, ch chan<- *types.Log) (*rpc.ClientSubscription, error) {
+	return c.c.EthSubscribe(ctx, ch, "logs", toFilterArg(crit))
+}
+
+// SubscribeLogsFrom sends the logs matching crit to ch, starting at crit.FromBlock or right after the log
+// of crit.ResumeToken, then the logs of the new blocks. The logs of the reorganised blocks are sent again
+// with Removed set. Each log comes with the token to resume the subscription after it.
+func (c *Client) SubscribeLogsFrom(ctx context.Context, crit filters.FilterCriteria, ch chan<- *rpchelper.ResumableLog) (*rpc.ClientSubscription, error) {
+	crit.Backfill = crit.ResumeToken == nil
+	return c.c.EthSubscribe(ctx, ch, "logs", toFilterArg(crit))
+}
+
+// SubscribePendingTransactions sends the hash of each transaction added to the pool to ch.
+func (c *Client) SubscribePendingTransactions(ctx context.Context, ch chan<- libcommon.Hash) (*rpc.ClientSubscription, error) {
+	return c.c.EthSubscribe(ctx, ch, "newPendingTransactions")
+}
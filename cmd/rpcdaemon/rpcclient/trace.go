@@ -0,0 +1,84 @@
+package rpcclient
+
+import (
+	"context"
+	"encoding/json"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/commands"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// TraceClient calls the trace_ namespace, see commands.TraceAPI
+type TraceClient struct {
+	c *rpc.Client
+}
+
+// TraceCallManyParam is one of the calls of trace_callMany, with the trace types to return for it
+type TraceCallManyParam struct {
+	Call  commands.TraceCallParam
+	Types []string
+}
+
+func (p TraceCallManyParam) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{p.Call, p.Types})
+}
+
+func (tc *TraceClient) ReplayBlockTransactions(ctx context.Context, blockNr rpc.BlockNumberOrHash, traceTypes []string, gasBailOut *bool) ([]*commands.TraceCallResult, error) {
+	var results []*commands.TraceCallResult
+	err := tc.c.CallContext(ctx, &results, "trace_replayBlockTransactions", blockNumberOrHashArg(blockNr), traceTypes, gasBailOut)
+	return results, err
+}
+
+func (tc *TraceClient) ReplayTransaction(ctx context.Context, txHash libcommon.Hash, traceTypes []string, gasBailOut *bool) (*commands.TraceCallResult, error) {
+	var result *commands.TraceCallResult
+	err := tc.c.CallContext(ctx, &result, "trace_replayTransaction", txHash, traceTypes, gasBailOut)
+	return result, err
+}
+
+// Call traces the call on top of the given block, nil - means the latest one.
+func (tc *TraceClient) Call(ctx context.Context, call commands.TraceCallParam, types []string, blockNr *rpc.BlockNumberOrHash) (*commands.TraceCallResult, error) {
+	var result *commands.TraceCallResult
+	err := tc.c.CallContext(ctx, &result, "trace_call", call, types, blockNumberOrHashPtrArg(blockNr))
+	return result, err
+}
+
+// CallMany traces the calls one after another on top of the given block, nil - means the latest one.
+func (tc *TraceClient) CallMany(ctx context.Context, calls []TraceCallManyParam, blockNr *rpc.BlockNumberOrHash) ([]*commands.TraceCallResult, error) {
+	var results []*commands.TraceCallResult
+	err := tc.c.CallContext(ctx, &results, "trace_callMany", calls, blockNumberOrHashPtrArg(blockNr))
+	return results, err
+}
+
+func (tc *TraceClient) RawTransaction(ctx context.Context, txHash libcommon.Hash, traceTypes []string) ([]interface{}, error) {
+	var result []interface{}
+	err := tc.c.CallContext(ctx, &result, "trace_rawTransaction", txHash, traceTypes)
+	return result, err
+}
+
+func (tc *TraceClient) Transaction(ctx context.Context, txHash libcommon.Hash, gasBailOut *bool) (commands.ParityTraces, error) {
+	var traces commands.ParityTraces
+	err := tc.c.CallContext(ctx, &traces, "trace_transaction", txHash, gasBailOut)
+	return traces, err
+}
+
+func (tc *TraceClient) Get(ctx context.Context, txHash libcommon.Hash, txIndicies []hexutil.Uint64, gasBailOut *bool) (*commands.ParityTrace, error) {
+	var trace *commands.ParityTrace
+	err := tc.c.CallContext(ctx, &trace, "trace_get", txHash, txIndicies, gasBailOut)
+	return trace, err
+}
+
+func (tc *TraceClient) Block(ctx context.Context, blockNr rpc.BlockNumber, gasBailOut *bool) (commands.ParityTraces, error) {
+	var traces commands.ParityTraces
+	err := tc.c.CallContext(ctx, &traces, "trace_block", blockNumberArg(blockNr), gasBailOut)
+	return traces, err
+}
+
+// Filter returns the traces matching the request. The server streams them, the client decodes them all at once.
+func (tc *TraceClient) Filter(ctx context.Context, req commands.TraceFilterRequest, gasBailOut *bool) (commands.ParityTraces, error) {
+	var traces commands.ParityTraces
+	err := tc.c.CallContext(ctx, &traces, "trace_filter", req, gasBailOut)
+	return traces, err
+}
@@ -16,11 +16,13 @@ import (
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/log/v3"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/ethdb/privateapi"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi/remoteproto"
 	"github.com/ledgerwatch/erigon/p2p"
 	"github.com/ledgerwatch/erigon/rlp"
 	"github.com/ledgerwatch/erigon/turbo/services"
@@ -28,15 +30,21 @@ import (
 
 type RemoteBackend struct {
 	remoteEthBackend remote.ETHBACKENDClient
+	remoteEngine     remoteproto.EngineClient
+	remotePeers      remoteproto.PeersClient
+	remoteClique     remoteproto.CliqueClient
 	log              log.Logger
 	version          gointerfaces.Version
 	db               kv.RoDB
 	blockReader      services.FullBlockReader
 }
 
-func NewRemoteBackend(client remote.ETHBACKENDClient, db kv.RoDB, blockReader services.FullBlockReader) *RemoteBackend {
+func NewRemoteBackend(client remote.ETHBACKENDClient, engineClient remoteproto.EngineClient, peersClient remoteproto.PeersClient, cliqueClient remoteproto.CliqueClient, db kv.RoDB, blockReader services.FullBlockReader) *RemoteBackend {
 	return &RemoteBackend{
 		remoteEthBackend: client,
+		remoteEngine:     engineClient,
+		remotePeers:      peersClient,
+		remoteClique:     cliqueClient,
 		version:          gointerfaces.VersionFromProto(privateapi.EthBackendAPIVersion),
 		log:              log.New("remote_service", "eth_backend"),
 		db:               db,
@@ -198,12 +206,12 @@ func (back *RemoteBackend) TxnByIdxInBlock(ctx context.Context, tx kv.Getter, bl
 	return back.blockReader.TxnByIdxInBlock(ctx, tx, blockNum, i)
 }
 
-func (back *RemoteBackend) EngineNewPayload(ctx context.Context, payload *types2.ExecutionPayload) (res *remote.EnginePayloadStatus, err error) {
-	return back.remoteEthBackend.EngineNewPayload(ctx, payload)
+func (back *RemoteBackend) EngineNewPayload(ctx context.Context, request *remoteproto.EngineNewPayloadRequest) (res *remote.EnginePayloadStatus, err error) {
+	return back.remoteEngine.NewPayload(ctx, request)
 }
 
-func (back *RemoteBackend) EngineForkchoiceUpdated(ctx context.Context, request *remote.EngineForkChoiceUpdatedRequest) (*remote.EngineForkChoiceUpdatedResponse, error) {
-	return back.remoteEthBackend.EngineForkChoiceUpdated(ctx, request)
+func (back *RemoteBackend) EngineForkchoiceUpdated(ctx context.Context, request *remoteproto.EngineForkChoiceUpdatedRequest) (*remote.EngineForkChoiceUpdatedResponse, error) {
+	return back.remoteEngine.ForkChoiceUpdated(ctx, request)
 }
 
 func (back *RemoteBackend) EngineGetPayload(ctx context.Context, payloadId uint64) (res *remote.EngineGetPayloadResponse, err error) {
@@ -275,9 +283,30 @@ func (back *RemoteBackend) Peers(ctx context.Context) ([]*p2p.PeerInfo, error) {
 		return nil, fmt.Errorf("ETHBACKENDClient.Peers() error: %w", err)
 	}
 
+	// nodes which predate the Peers service report the peers without their protocols
+	rpcProtocols, err := back.remotePeers.PeersProtocols(ctx, &emptypb.Empty{})
+	if err != nil && status.Code(err) != codes.Unimplemented {
+		return nil, fmt.Errorf("PeersClient.PeersProtocols() error: %w", err)
+	}
+	peersProtocols := make(map[string][]byte, len(rpcProtocols.GetPeers()))
+	for _, peer := range rpcProtocols.GetPeers() {
+		peersProtocols[peer.Id] = peer.Protocols
+	}
+
 	peers := make([]*p2p.PeerInfo, 0, len(rpcPeers.Peers))
 
 	for _, rpcPeer := range rpcPeers.Peers {
+		var protocols map[string]interface{}
+		if b := peersProtocols[rpcPeer.Id]; len(b) > 0 {
+			var raw map[string]json.RawMessage
+			if err := json.Unmarshal(b, &raw); err != nil {
+				return nil, fmt.Errorf("peer %s protocols: %w", rpcPeer.Id, err)
+			}
+			protocols = make(map[string]interface{}, len(raw))
+			for name, info := range raw {
+				protocols[name] = info
+			}
+		}
 		peer := p2p.PeerInfo{
 			ENR:   rpcPeer.Enr,
 			Enode: rpcPeer.Enode,
@@ -297,7 +326,7 @@ func (back *RemoteBackend) Peers(ctx context.Context) ([]*p2p.PeerInfo, error) {
 				Trusted:       rpcPeer.ConnIsTrusted,
 				Static:        rpcPeer.ConnIsStatic,
 			},
-			Protocols: nil,
+			Protocols: protocols,
 		}
 
 		peers = append(peers, &peer)
@@ -323,3 +352,40 @@ func (back *RemoteBackend) PendingBlock(ctx context.Context) (*types.Block, erro
 
 	return &block, nil
 }
+
+func (back *RemoteBackend) CliqueProposals(ctx context.Context) (map[libcommon.Address]bool, error) {
+	res, err := back.remoteClique.Proposals(ctx, &emptypb.Empty{})
+	if err != nil {
+		if s, ok := status.FromError(err); ok {
+			return nil, errors.New(s.Message())
+		}
+		return nil, err
+	}
+	proposals := make(map[libcommon.Address]bool, len(res.Proposals))
+	for _, p := range res.Proposals {
+		proposals[gointerfaces.ConvertH160toAddress(p.Address)] = p.Auth
+	}
+	return proposals, nil
+}
+
+func (back *RemoteBackend) CliquePropose(ctx context.Context, address libcommon.Address, auth bool) error {
+	_, err := back.remoteClique.Propose(ctx, &remoteproto.CliqueProposeRequest{Address: gointerfaces.ConvertAddressToH160(address), Auth: auth})
+	if err != nil {
+		if s, ok := status.FromError(err); ok {
+			return errors.New(s.Message())
+		}
+		return err
+	}
+	return nil
+}
+
+func (back *RemoteBackend) CliqueDiscard(ctx context.Context, address libcommon.Address) error {
+	_, err := back.remoteClique.Discard(ctx, &remoteproto.CliqueDiscardRequest{Address: gointerfaces.ConvertAddressToH160(address)})
+	if err != nil {
+		if s, ok := status.FromError(err); ok {
+			return errors.New(s.Message())
+		}
+		return err
+	}
+	return nil
+}
@@ -35,6 +35,7 @@ var (
 	maxPendPeers int
 	healthCheck  bool
 	metrics      bool
+	txBroadcast  string // policy for the local transactions
 )
 
 func init() {
@@ -55,6 +56,7 @@ func init() {
 	rootCmd.Flags().IntVar(&maxPendPeers, utils.MaxPendingPeersFlag.Name, utils.MaxPendingPeersFlag.Value, utils.MaxPendingPeersFlag.Usage)
 	rootCmd.Flags().BoolVar(&healthCheck, utils.HealthCheckFlag.Name, false, utils.HealthCheckFlag.Usage)
 	rootCmd.Flags().BoolVar(&metrics, utils.MetricsEnabledFlag.Name, false, utils.MetricsEnabledFlag.Usage)
+	rootCmd.Flags().StringVar(&txBroadcast, utils.SentryLocalTxBroadcastFlag.Name, utils.SentryLocalTxBroadcastFlag.Value, utils.SentryLocalTxBroadcastFlag.Usage)
 
 	if err := rootCmd.MarkFlagDirname(utils.DataDirFlag.Name); err != nil {
 		panic(err)
@@ -97,9 +99,13 @@ var rootCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		txBroadcastPolicy, err := sentry.ParseLocalTxBroadcast(txBroadcast)
+		if err != nil {
+			return err
+		}
 
 		logging.SetupLoggerCmd("sentry", cmd)
-		return sentry.Sentry(cmd.Context(), dirs, sentryAddr, discoveryDNS, p2pConfig, protocol, healthCheck)
+		return sentry.Sentry(cmd.Context(), dirs, sentryAddr, discoveryDNS, p2pConfig, protocol, healthCheck, txBroadcastPolicy)
 	},
 }
 
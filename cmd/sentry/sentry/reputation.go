@@ -0,0 +1,131 @@
+package sentry
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+const (
+	// reputationHalfLife is the time after which the history of a peer counts half as much
+	reputationHalfLife = 6 * time.Hour
+
+	usefulReplyScore  = 1.0
+	emptyReplyScore   = -0.5
+	timeoutScore      = -2.0
+	invalidReplyScore = -20.0
+
+	// goodReputationScore is the score from which a peer is preferred for requests
+	goodReputationScore = 10.0
+	// evictReputationScore is the score under which a peer is disconnected and not accepted again
+	// until its history decays, unless it's static or trusted
+	evictReputationScore = -40.0
+
+	latencySmoothing = 0.2 // weight of the latest reply in the moving average of the latency
+)
+
+// Reputation is the history of a peer as a source of headers and bodies. The counters
+// decay with reputationHalfLife, so old misbehaviour is forgiven and old merits expire.
+type Reputation struct {
+	Useful   float64       `json:"useful"`   // replies with headers or bodies
+	Empty    float64       `json:"empty"`    // replies without any
+	Timeouts float64       `json:"timeouts"` // requests not replied before their deadline
+	Invalid  float64       `json:"invalid"`  // replies the core penalized the peer for
+	Latency  time.Duration `json:"latency"`  // moving average of the reply latency
+	Updated  time.Time     `json:"updated"`
+}
+
+// Score sums the weighted counters, higher is better.
+func (r *Reputation) Score() float64 {
+	return r.Useful*usefulReplyScore + r.Empty*emptyReplyScore + r.Timeouts*timeoutScore + r.Invalid*invalidReplyScore
+}
+
+// tier groups the peers by score for request routing: -1 for the bad ones, 0 for the unknown, 1 for the good ones.
+func (r *Reputation) tier() int {
+	switch score := r.Score(); {
+	case score < 0:
+		return -1
+	case score >= goodReputationScore:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (r *Reputation) decay(now time.Time) {
+	if !r.Updated.IsZero() && now.After(r.Updated) {
+		factor := math.Pow(0.5, float64(now.Sub(r.Updated))/float64(reputationHalfLife))
+		r.Useful *= factor
+		r.Empty *= factor
+		r.Timeouts *= factor
+		r.Invalid *= factor
+	}
+	r.Updated = now
+}
+
+func (r *Reputation) addLatency(latency time.Duration) {
+	if r.Latency == 0 {
+		r.Latency = latency
+		return
+	}
+	r.Latency = time.Duration(latencySmoothing*float64(latency) + (1-latencySmoothing)*float64(r.Latency))
+}
+
+// replyItems counts the headers or bodies of a BlockHeadersPacket66 or BlockBodiesPacket66.
+func replyItems(data []byte) (int, error) {
+	content, _, err := rlp.SplitList(data)
+	if err != nil {
+		return 0, err
+	}
+	// skip the request id
+	if _, _, content, err = rlp.Split(content); err != nil {
+		return 0, err
+	}
+	items, _, err := rlp.SplitList(content)
+	if err != nil {
+		return 0, err
+	}
+	return rlp.CountValues(items)
+}
+
+// EthPeerInfo is the eth protocol metadata of a peer in admin_peers.
+type EthPeerInfo struct {
+	Version    uint       `json:"version"`
+	Height     uint64     `json:"height"`
+	Score      float64    `json:"score"`
+	Reputation Reputation `json:"reputation"`
+	Txs        TxStats    `json:"txs"`
+}
+
+func loadReputation(db *enode.DB, id enode.ID) Reputation {
+	var r Reputation
+	if db == nil {
+		return r
+	}
+	if blob := db.PeerReputation(id); blob != nil {
+		if err := json.Unmarshal(blob, &r); err != nil {
+			log.Debug("[p2p] Invalid peer reputation", "id", id, "err", err)
+			return Reputation{}
+		}
+		r.decay(time.Now())
+	}
+	return r
+}
+
+func storeReputation(db *enode.DB, id enode.ID, r Reputation) {
+	if db == nil {
+		return
+	}
+	blob, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	if err := db.UpdatePeerReputation(id, blob); err != nil {
+		log.Debug("[p2p] Failed to store peer reputation", "id", id, "err", err)
+	}
+}
@@ -0,0 +1,117 @@
+package sentry
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/protocols/eth"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+func testPeerInfo(id byte, height uint64) *PeerInfo {
+	peerInfo := NewPeerInfo(p2p.NewPeer(enode.ID{id}, [64]byte{id}, "test", nil, false), nil)
+	peerInfo.height = height
+	return peerInfo
+}
+
+func TestReputationDecay(t *testing.T) {
+	now := time.Now()
+	r := Reputation{Useful: 20, Empty: 4, Timeouts: 2, Invalid: 1, Updated: now}
+	require.Equal(t, 20-2-4-20.0, r.Score())
+	require.Equal(t, -1, r.tier())
+
+	r.decay(now.Add(reputationHalfLife))
+	require.InDelta(t, 10.0, r.Useful, 1e-9)
+	require.InDelta(t, 0.5, r.Invalid, 1e-9)
+	require.Equal(t, now.Add(reputationHalfLife), r.Updated)
+
+	r = Reputation{Useful: goodReputationScore}
+	require.Equal(t, 1, r.tier())
+}
+
+func TestReplyItems(t *testing.T) {
+	headers := []*types.Header{{Number: big.NewInt(1)}, {Number: big.NewInt(2)}}
+	b, err := rlp.EncodeToBytes(&eth.BlockHeadersPacket66{RequestId: 1, BlockHeadersPacket: headers})
+	require.NoError(t, err)
+	items, err := replyItems(b)
+	require.NoError(t, err)
+	require.Equal(t, 2, items)
+
+	b, err = rlp.EncodeToBytes(&eth.BlockBodiesPacket66{RequestId: 2})
+	require.NoError(t, err)
+	items, err = replyItems(b)
+	require.NoError(t, err)
+	require.Equal(t, 0, items)
+
+	_, err = replyItems([]byte{0x01})
+	require.Error(t, err)
+}
+
+func TestPeerReputationRouting(t *testing.T) {
+	ss := &GrpcServer{}
+	good, bad := testPeerInfo(1, 100), testPeerInfo(2, 200)
+	ss.GoodPeers.Store(good.ID(), good)
+	ss.GoodPeers.Store(bad.ID(), bad)
+
+	now := time.Now()
+	good.AddDeadline(now.Add(time.Minute))
+	bad.AddDeadline(now.Add(time.Minute))
+	bad.AddDeadline(now.Add(time.Minute))
+	good.AddReply(time.Now(), 3)
+	bad.AddReply(time.Now(), 0)
+	bad.AddReply(time.Now(), 0)
+	// Unsolicited replies are not credited
+	bad.AddReply(time.Now(), 5)
+	require.Zero(t, bad.Reputation().Useful)
+	require.Equal(t, 0, bad.ClearDeadlines(now, false))
+	found, ok := ss.findPeerByMinBlock(50)
+	require.True(t, ok)
+	require.Equal(t, good, found)
+
+	// The better reputation is preferred over the height
+	peers := ss.findBestPeersWithPermit(1)
+	require.Equal(t, []*PeerInfo{good}, peers)
+
+	// A timed out request counts against the peer and the reply latency is recorded
+	now = time.Now()
+	good.AddDeadline(now.Add(-time.Second))
+	good.AddDeadline(now.Add(time.Minute))
+	require.Equal(t, 0, good.ClearDeadlines(time.Now(), true))
+	reputation := good.Reputation()
+	require.InDelta(t, 1.0, reputation.Timeouts, 1e-6)
+	require.Greater(t, reputation.Latency, time.Duration(0))
+
+	// Peers with a reputation under the eviction score are disconnected
+	for i := 0; i < 3; i++ {
+		bad.Penalize()
+	}
+	require.True(t, bad.Evictable())
+	found, ok = ss.findPeerByMinBlock(150)
+	require.False(t, ok)
+	require.Nil(t, found)
+	require.Nil(t, ss.getPeer(bad.ID()))
+	require.True(t, bad.Removed())
+}
+
+func TestReputationPersistence(t *testing.T) {
+	db, err := enode.OpenDB("", t.TempDir())
+	require.NoError(t, err)
+	defer db.Close()
+
+	id := enode.ID{1}
+	require.Equal(t, Reputation{}, loadReputation(db, id))
+	stored := Reputation{Useful: 5, Invalid: 1, Latency: time.Second, Updated: time.Now()}
+	storeReputation(db, id, stored)
+
+	loaded := loadReputation(db, id)
+	require.InDelta(t, stored.Useful, loaded.Useful, 1e-3)
+	require.InDelta(t, stored.Invalid, loaded.Invalid, 1e-3)
+	require.Equal(t, stored.Latency, loaded.Latency)
+	require.Equal(t, Reputation{}, loadReputation(nil, id))
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/ledgerwatch/erigon/common/debug"
 	"github.com/ledgerwatch/erigon/core/forkid"
 	"github.com/ledgerwatch/erigon/eth/protocols/eth"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi/remoteproto"
 	"github.com/ledgerwatch/erigon/p2p"
 	"github.com/ledgerwatch/erigon/p2p/dnsdisc"
 	"github.com/ledgerwatch/erigon/p2p/enode"
@@ -55,10 +56,14 @@ type PeerInfo struct {
 	peer          *p2p.Peer
 	lock          sync.RWMutex
 	deadlines     []time.Time // Request deadlines
+	sent          []time.Time // Send times of the requests with deadlines
 	latestDealine time.Time
+	reputation    Reputation
 	height        uint64
 	rw            p2p.MsgReadWriter
 	protocol      uint
+	trusted       bool // trusted or static peer
+	txStats       TxStats
 
 	removed    chan struct{} // close this channel on remove
 	ctx        context.Context
@@ -73,9 +78,11 @@ type PeerInfo struct {
 type PeerRef struct {
 	pi     *PeerInfo
 	height uint64
+	tier   int
 }
 
-// PeersByMinBlock is the priority queue of peers. Used to select certain number of peers considered to be "best available"
+// PeersByMinBlock is the priority queue of peers. Used to select certain number of peers considered to be "best available":
+// the ones with the better reputation, then the higher ones
 type PeersByMinBlock []PeerRef
 
 // Len (part of heap.Interface) returns the current size of the best peers queue
@@ -85,6 +92,9 @@ func (bp PeersByMinBlock) Len() int {
 
 // Less (part of heap.Interface) compares two peers
 func (bp PeersByMinBlock) Less(i, j int) bool {
+	if bp[i].tier != bp[j].tier {
+		return bp[i].tier < bp[j].tier
+	}
 	return bp[i].height < bp[j].height
 }
 
@@ -142,13 +152,14 @@ func (pi *PeerInfo) ID() [64]byte {
 	return pi.peer.Pubkey()
 }
 
-// AddDeadline adds given deadline to the list of deadlines
+// AddDeadline adds given deadline of a request sent now to the list of deadlines
 // Deadlines must be added in the chronological order for the function
 // ClearDeadlines to work correctly (it uses binary search)
 func (pi *PeerInfo) AddDeadline(deadline time.Time) {
 	pi.lock.Lock()
 	defer pi.lock.Unlock()
 	pi.deadlines = append(pi.deadlines, deadline)
+	pi.sent = append(pi.sent, time.Now())
 	pi.latestDealine = deadline
 }
 
@@ -169,20 +180,78 @@ func (pi *PeerInfo) SetIncreasedHeight(newHeight uint64) {
 // ClearDeadlines goes through the deadlines of
 // given peers and removes the ones that have passed
 // Optionally, it also clears one extra deadline - this is used when response is received
+// The passed deadlines count as timeouts and the response latency is recorded in the reputation
 // It returns the number of deadlines left
 func (pi *PeerInfo) ClearDeadlines(now time.Time, givePermit bool) int {
 	pi.lock.Lock()
 	defer pi.lock.Unlock()
+	left, _ := pi.clearDeadlines(now, givePermit)
+	return left
+}
+
+// clearDeadlines is ClearDeadlines without the locking, it also reports whether
+// an outstanding deadline was cleared for the permit
+func (pi *PeerInfo) clearDeadlines(now time.Time, givePermit bool) (int, bool) {
 	// Look for the first deadline which is not passed yet
 	firstNotPassed := sort.Search(len(pi.deadlines), func(i int) bool {
 		return pi.deadlines[i].After(now)
 	})
+	if firstNotPassed > 0 {
+		pi.reputation.decay(now)
+		pi.reputation.Timeouts += float64(firstNotPassed)
+	}
 	cutOff := firstNotPassed
+	matched := false
 	if cutOff < len(pi.deadlines) && givePermit {
+		pi.reputation.addLatency(now.Sub(pi.sent[cutOff]))
 		cutOff++
+		matched = true
 	}
 	pi.deadlines = pi.deadlines[cutOff:]
-	return len(pi.deadlines)
+	pi.sent = pi.sent[cutOff:]
+	return len(pi.deadlines), matched
+}
+
+// AddReply clears the deadline of the request answered by a reply with the given
+// number of headers or bodies and records the reply in the reputation
+// Replies which do not match an outstanding request are unsolicited and are not credited
+func (pi *PeerInfo) AddReply(now time.Time, items int) {
+	pi.lock.Lock()
+	defer pi.lock.Unlock()
+	if _, matched := pi.clearDeadlines(now, true); !matched {
+		return
+	}
+	pi.reputation.decay(now)
+	if items > 0 {
+		pi.reputation.Useful++
+	} else {
+		pi.reputation.Empty++
+	}
+}
+
+// Penalize records an invalid reply in the reputation
+func (pi *PeerInfo) Penalize() {
+	pi.lock.Lock()
+	defer pi.lock.Unlock()
+	pi.reputation.decay(time.Now())
+	pi.reputation.Invalid++
+}
+
+func (pi *PeerInfo) Reputation() Reputation {
+	pi.lock.RLock()
+	defer pi.lock.RUnlock()
+	return pi.reputation
+}
+
+// Evictable tells if the reputation of the peer is too bad to keep it connected.
+// Static and trusted peers are never evicted.
+func (pi *PeerInfo) Evictable() bool {
+	reputation := pi.Reputation()
+	if reputation.Score() >= evictReputationScore {
+		return false
+	}
+	info := pi.peer.Info()
+	return !info.Network.Static && !info.Network.Trusted
 }
 
 func (pi *PeerInfo) LatestDeadline() time.Time {
@@ -333,6 +402,7 @@ func runPeer(
 	peerInfo *PeerInfo,
 	send func(msgId proto_sentry.MessageId, peerID [64]byte, b []byte),
 	hasSubscribers func(msgId proto_sentry.MessageId) bool,
+	receivedTxs func(peerInfo *PeerInfo, msgcode uint64, b []byte),
 ) error {
 	printTime := time.Now().Add(time.Minute)
 	peerPrinted := false
@@ -368,6 +438,7 @@ func runPeer(
 			return fmt.Errorf("message is too large %d, limit %d", msg.Size, eth.ProtocolMaxMsgSize)
 		}
 		givePermit := false
+		replyLen := 0
 		switch msg.Code {
 		case eth.StatusMsg:
 			msg.Discard()
@@ -392,6 +463,7 @@ func runPeer(
 			if _, err := io.ReadFull(msg.Payload, b); err != nil {
 				log.Error(fmt.Sprintf("%s: reading msg into bytes: %v", peerID, err))
 			}
+			replyLen, _ = replyItems(b)
 			send(eth.ToProto[protocol][msg.Code], peerID, b)
 		case eth.GetBlockBodiesMsg:
 			if !hasSubscribers(eth.ToProto[protocol][msg.Code]) {
@@ -411,6 +483,7 @@ func runPeer(
 			if _, err := io.ReadFull(msg.Payload, b); err != nil {
 				log.Error(fmt.Sprintf("%s: reading msg into bytes: %v", peerID, err))
 			}
+			replyLen, _ = replyItems(b)
 			send(eth.ToProto[protocol][msg.Code], peerID, b)
 		case eth.GetNodeDataMsg:
 			if protocol >= eth.ETH67 {
@@ -475,6 +548,7 @@ func runPeer(
 			if _, err := io.ReadFull(msg.Payload, b); err != nil {
 				log.Error(fmt.Sprintf("%s: reading msg into bytes: %v", peerID, err))
 			}
+			receivedTxs(peerInfo, msg.Code, b)
 			send(eth.ToProto[protocol][msg.Code], peerID, b)
 		case eth.GetPooledTransactionsMsg:
 			if !hasSubscribers(eth.ToProto[protocol][msg.Code]) {
@@ -495,6 +569,7 @@ func runPeer(
 			if _, err := io.ReadFull(msg.Payload, b); err != nil {
 				log.Error(fmt.Sprintf("%s: reading msg into bytes: %v", peerID, err))
 			}
+			receivedTxs(peerInfo, msg.Code, b)
 			send(eth.ToProto[protocol][msg.Code], peerID, b)
 		case eth.PooledTransactionsMsg:
 			if !hasSubscribers(eth.ToProto[protocol][msg.Code]) {
@@ -505,6 +580,7 @@ func runPeer(
 			if _, err := io.ReadFull(msg.Payload, b); err != nil {
 				log.Error(fmt.Sprintf("%s: reading msg into bytes: %v", peerID, err))
 			}
+			receivedTxs(peerInfo, msg.Code, b)
 			send(eth.ToProto[protocol][msg.Code], peerID, b)
 		case 11:
 			// Ignore
@@ -513,7 +589,15 @@ func runPeer(
 			log.Error(fmt.Sprintf("[p2p] Unknown message code: %d, peerID=%x", msg.Code, peerID))
 		}
 		msg.Discard()
-		peerInfo.ClearDeadlines(time.Now(), givePermit)
+		if givePermit {
+			peerInfo.AddReply(time.Now(), replyLen)
+		} else {
+			peerInfo.ClearDeadlines(time.Now(), false /* givePermit */)
+		}
+		if givePermit && peerInfo.Evictable() {
+			reputation := peerInfo.Reputation()
+			return fmt.Errorf("evicted for bad reputation, score %.1f", reputation.Score())
+		}
 	}
 }
 
@@ -532,6 +616,8 @@ func grpcSentryServer(ctx context.Context, sentryAddr string, ss *GrpcServer, he
 	}
 	grpcServer := grpcutil.NewServer(100, nil)
 	proto_sentry.RegisterSentryServer(grpcServer, ss)
+	remoteproto.RegisterPeersServer(grpcServer, ss)
+	remoteproto.RegisterLocalTxsServer(grpcServer, ss)
 	var healthServer *health.Server
 	if healthCheck {
 		healthServer = health.NewServer()
@@ -551,9 +637,10 @@ func grpcSentryServer(ctx context.Context, sentryAddr string, ss *GrpcServer, he
 
 func NewGrpcServer(ctx context.Context, dialCandidates func() enode.Iterator, readNodeInfo func() *eth.NodeInfo, cfg *p2p.Config, protocol uint) *GrpcServer {
 	ss := &GrpcServer{
-		ctx:          ctx,
-		p2p:          cfg,
-		peersStreams: NewPeersStreams(),
+		ctx:           ctx,
+		p2p:           cfg,
+		peersStreams:  NewPeersStreams(),
+		txPropagation: newTxPropagation(),
 	}
 
 	protocols := []uint{protocol}
@@ -583,8 +670,23 @@ func NewGrpcServer(ctx context.Context, dialCandidates func() enode.Iterator, re
 
 				peerInfo := NewPeerInfo(peer, rw)
 				peerInfo.protocol = protocol
+				if info := peer.Info(); info != nil {
+					peerInfo.trusted = info.Network.Trusted || info.Network.Static
+				}
 				defer peerInfo.Close()
 
+				reputation := loadReputation(ss.nodeDB(), peer.ID())
+				peerInfo.reputation = reputation
+				if peerInfo.Evictable() {
+					log.Trace("[p2p] peer has bad reputation", "peerId", printablePeerID, "score", reputation.Score())
+					return p2p.DiscUselessPeer
+				}
+				defer func() {
+					if reputation := peerInfo.Reputation(); !reputation.Updated.IsZero() {
+						storeReputation(ss.nodeDB(), peer.ID(), reputation)
+					}
+				}()
+
 				defer ss.GoodPeers.Delete(peerID)
 				err := handShake(ctx, ss.GetStatus(), peerID, rw, protocol, protocol, func(bestHash libcommon.Hash) error {
 					ss.GoodPeers.Store(peerID, peerInfo)
@@ -609,6 +711,7 @@ func NewGrpcServer(ctx context.Context, dialCandidates func() enode.Iterator, re
 					peerInfo,
 					ss.send,
 					ss.hasSubscribers,
+					ss.trackIncomingTxs,
 				) // runPeer never returns a nil error
 				log.Trace("[p2p] error while running peer", "peerId", printablePeerID, "err", err)
 				ss.sendGonePeerToClients(gointerfaces.ConvertHashToH512(peerID))
@@ -619,7 +722,12 @@ func NewGrpcServer(ctx context.Context, dialCandidates func() enode.Iterator, re
 			},
 			PeerInfo: func(peerID [64]byte) interface{} {
 				// TODO: remember handshake reply per peer ID and return eth-related Status info (see ethPeerInfo in geth)
-				return nil
+				peerInfo := ss.getPeer(peerID)
+				if peerInfo == nil {
+					return nil
+				}
+				reputation := peerInfo.Reputation()
+				return &EthPeerInfo{Version: protocol, Height: peerInfo.Height(), Score: reputation.Score(), Reputation: reputation, Txs: peerInfo.TxStats()}
 			},
 			//Attributes: []enr.Entry{eth.CurrentENREntry(chainConfig, genesisHash, headHeight)},
 		})
@@ -628,8 +736,14 @@ func NewGrpcServer(ctx context.Context, dialCandidates func() enode.Iterator, re
 	return ss
 }
 
+// ShareTxPropagation makes the sentry share the transactions seen on the network and the local ones with
+// another sentry of the same node, so that the transaction pool can mark the local transactions on either.
+func (ss *GrpcServer) ShareTxPropagation(other *GrpcServer) {
+	ss.txPropagation = other.txPropagation
+}
+
 // Sentry creates and runs standalone sentry
-func Sentry(ctx context.Context, dirs datadir.Dirs, sentryAddr string, discoveryDNS []string, cfg *p2p.Config, protocolVersion uint, healthCheck bool) error {
+func Sentry(ctx context.Context, dirs datadir.Dirs, sentryAddr string, discoveryDNS []string, cfg *p2p.Config, protocolVersion uint, healthCheck bool, txBroadcast TxBroadcastPolicy) error {
 	dir.MustExist(dirs.DataDir)
 
 	discovery := func() enode.Iterator {
@@ -641,6 +755,7 @@ func Sentry(ctx context.Context, dirs datadir.Dirs, sentryAddr string, discovery
 	}
 	sentryServer := NewGrpcServer(ctx, discovery, func() *eth.NodeInfo { return nil }, cfg, protocolVersion)
 	sentryServer.discoveryDNS = discoveryDNS
+	sentryServer.TxBroadcastPolicy = txBroadcast
 
 	grpcServer, err := grpcSentryServer(ctx, sentryAddr, sentryServer, healthCheck)
 	if err != nil {
@@ -655,6 +770,8 @@ func Sentry(ctx context.Context, dirs datadir.Dirs, sentryAddr string, discovery
 
 type GrpcServer struct {
 	proto_sentry.UnimplementedSentryServer
+	remoteproto.UnimplementedPeersServer
+	remoteproto.UnimplementedLocalTxsServer
 	ctx                  context.Context
 	Protocols            []p2p.Protocol
 	discoveryDNS         []string
@@ -668,6 +785,18 @@ type GrpcServer struct {
 	messageStreamsLock   sync.RWMutex
 	peersStreams         *PeersStreams
 	p2p                  *p2p.Config
+	TxBroadcastPolicy    TxBroadcastPolicy // Which peers get the local transactions, all of them if nil
+	txPropagation        *txPropagation
+}
+
+// nodeDB is the node database of the p2p server, where the peer reputations are kept
+func (ss *GrpcServer) nodeDB() *enode.DB {
+	ss.lock.RLock()
+	defer ss.lock.RUnlock()
+	if ss.P2pServer == nil {
+		return nil
+	}
+	return ss.P2pServer.LocalNode().Database()
 }
 
 func (ss *GrpcServer) rangePeers(f func(peerInfo *PeerInfo) bool) {
@@ -746,6 +875,10 @@ func (ss *GrpcServer) PenalizePeer(_ context.Context, req *proto_sentry.Penalize
 	//log.Warn("Received penalty", "kind", req.GetPenalty().Descriptor().FullName, "from", fmt.Sprintf("%s", req.GetPeerId()))
 	peerID := ConvertH512ToPeerID(req.PeerId)
 	peerInfo := ss.getPeer(peerID)
+	if peerInfo != nil {
+		peerInfo.Penalize()
+		storeReputation(ss.nodeDB(), peerInfo.peer.ID(), peerInfo.Reputation())
+	}
 	if ss.statusData != nil && peerInfo != nil && !peerInfo.peer.Info().Network.Static && !peerInfo.peer.Info().Network.Trusted {
 		ss.removePeer(peerID)
 		printablePeerID := hex.EncodeToString(peerID[:])[:8]
@@ -770,10 +903,15 @@ func (ss *GrpcServer) findBestPeersWithPermit(peerCount int) []*PeerInfo {
 	var pokeDeadline time.Time
 	ss.rangePeers(func(peerInfo *PeerInfo) bool {
 		deadlines := peerInfo.ClearDeadlines(now, false /* givePermit */)
+		if peerInfo.Evictable() {
+			ss.removePeer(peerInfo.ID())
+			return true
+		}
 		height := peerInfo.Height()
+		reputation := peerInfo.Reputation()
 		//fmt.Printf("%d deadlines for peer %s\n", deadlines, peerID)
 		if deadlines < maxPermitsPerPeer {
-			heap.Push(&byMinBlock, PeerRef{pi: peerInfo, height: height})
+			heap.Push(&byMinBlock, PeerRef{pi: peerInfo, height: height, tier: reputation.tier()})
 			if byMinBlock.Len() > peerCount {
 				// Remove the worst peer
 				peerRef := heap.Pop(&byMinBlock).(PeerRef)
@@ -800,18 +938,27 @@ func (ss *GrpcServer) findBestPeersWithPermit(peerCount int) []*PeerInfo {
 }
 
 func (ss *GrpcServer) findPeerByMinBlock(minBlock uint64) (*PeerInfo, bool) {
-	// Choose a peer that we can send this request to, with maximum number of permits
+	// Choose a peer that we can send this request to, with the best reputation,
+	// then the maximum number of permits, then the lowest latency
 	var foundPeerInfo *PeerInfo
-	var maxPermits int
+	var maxPermits, maxTier int
+	var minLatency time.Duration
 	now := time.Now()
 	ss.rangePeers(func(peerInfo *PeerInfo) bool {
 		if peerInfo.Height() >= minBlock {
 			deadlines := peerInfo.ClearDeadlines(now, false /* givePermit */)
+			if peerInfo.Evictable() {
+				ss.removePeer(peerInfo.ID())
+				return true
+			}
 			//fmt.Printf("%d deadlines for peer %s\n", deadlines, peerID)
 			if deadlines < maxPermitsPerPeer {
 				permits := maxPermitsPerPeer - deadlines
-				if permits > maxPermits {
-					maxPermits = permits
+				reputation := peerInfo.Reputation()
+				tier := reputation.tier()
+				if (foundPeerInfo == nil) || (tier > maxTier) ||
+					((tier == maxTier) && ((permits > maxPermits) || ((permits == maxPermits) && (reputation.Latency < minLatency)))) {
+					maxPermits, maxTier, minLatency = permits, tier, reputation.Latency
 					foundPeerInfo = peerInfo
 				}
 			}
@@ -832,6 +979,7 @@ func (ss *GrpcServer) SendMessageByMinBlock(_ context.Context, inreq *proto_sent
 	if inreq.MaxPeers == 1 {
 		peerInfo, found := ss.findPeerByMinBlock(inreq.MinBlock)
 		if found {
+			ss.trackTxFetch(peerInfo, msgcode, inreq.Data.Data)
 			ss.writePeer("[sentry] sendMessageByMinBlock", peerInfo, msgcode, inreq.Data.Data, 30*time.Second)
 			reply.Peers = []*proto_types.H512{gointerfaces.ConvertHashToH512(peerInfo.ID())}
 			return reply, nil
@@ -840,6 +988,7 @@ func (ss *GrpcServer) SendMessageByMinBlock(_ context.Context, inreq *proto_sent
 	peerInfos := ss.findBestPeersWithPermit(int(inreq.MaxPeers))
 	reply.Peers = make([]*proto_types.H512, len(peerInfos))
 	for i, peerInfo := range peerInfos {
+		ss.trackTxFetch(peerInfo, msgcode, inreq.Data.Data)
 		ss.writePeer("[sentry] sendMessageByMinBlock", peerInfo, msgcode, inreq.Data.Data, 15*time.Second)
 		reply.Peers[i] = gointerfaces.ConvertHashToH512(peerInfo.ID())
 	}
@@ -868,7 +1017,12 @@ func (ss *GrpcServer) SendMessageById(_ context.Context, inreq *proto_sentry.Sen
 		return reply, nil
 	}
 
-	ss.writePeer("[sentry] sendMessageById", peerInfo, msgcode, inreq.Data.Data, 0)
+	ss.trackTxFetch(peerInfo, msgcode, inreq.Data.Data)
+	data, ok := ss.dataFor(ss.outgoingTxs(inreq.Data.Id, msgcode, inreq.Data.Data), peerInfo, inreq.Data.Data)
+	if !ok {
+		return reply, nil
+	}
+	ss.writePeer("[sentry] sendMessageById", peerInfo, msgcode, data, 0)
 	reply.Peers = []*proto_types.H512{inreq.PeerId}
 	return reply, nil
 }
@@ -900,9 +1054,14 @@ func (ss *GrpcServer) SendMessageToRandomPeers(ctx context.Context, req *proto_s
 	}
 
 	var lastErr error
+	txs := ss.outgoingTxs(req.Data.Id, msgcode, req.Data.Data)
 	// Send the block to a subset of our peers at random
 	for _, peerInfo := range peerInfos[:peersToSendCount] {
-		ss.writePeer("[sentry] sendMessageToRandomPeers", peerInfo, msgcode, req.Data.Data, 0)
+		data, ok := ss.dataFor(txs, peerInfo, req.Data.Data)
+		if !ok {
+			continue
+		}
+		ss.writePeer("[sentry] sendMessageToRandomPeers", peerInfo, msgcode, data, 0)
 		reply.Peers = append(reply.Peers, gointerfaces.ConvertHashToH512(peerInfo.ID()))
 	}
 	return reply, lastErr
@@ -919,8 +1078,13 @@ func (ss *GrpcServer) SendMessageToAll(ctx context.Context, req *proto_sentry.Ou
 	}
 
 	var lastErr error
+	txs := ss.outgoingTxs(req.Id, msgcode, req.Data)
 	ss.rangePeers(func(peerInfo *PeerInfo) bool {
-		ss.writePeer("[sentry] SendMessageToAll", peerInfo, msgcode, req.Data, 0)
+		data, ok := ss.dataFor(txs, peerInfo, req.Data)
+		if !ok {
+			return true
+		}
+		ss.writePeer("[sentry] SendMessageToAll", peerInfo, msgcode, data, 0)
 		reply.Peers = append(reply.Peers, gointerfaces.ConvertHashToH512(peerInfo.ID()))
 		return true
 	})
@@ -1014,6 +1178,24 @@ func (ss *GrpcServer) Peers(_ context.Context, _ *emptypb.Empty) (*proto_sentry.
 	return &reply, nil
 }
 
+// PeersProtocols returns the protocols metadata of the connected peers, which Peers leaves out
+func (ss *GrpcServer) PeersProtocols(_ context.Context, _ *emptypb.Empty) (*remoteproto.PeersProtocolsReply, error) {
+	if ss.P2pServer == nil {
+		return nil, errors.New("p2p server was not started")
+	}
+
+	peers := ss.P2pServer.PeersInfo()
+	reply := &remoteproto.PeersProtocolsReply{Peers: make([]*remoteproto.PeerProtocols, 0, len(peers))}
+	for _, peer := range peers {
+		protocols, err := json.Marshal(peer.Protocols)
+		if err != nil {
+			return nil, fmt.Errorf("peer %s protocols: %w", peer.ID, err)
+		}
+		reply.Peers = append(reply.Peers, &remoteproto.PeerProtocols{Id: peer.ID, Protocols: protocols})
+	}
+	return reply, nil
+}
+
 func (ss *GrpcServer) SimplePeerCount() map[uint]int {
 	counts := map[uint]int{}
 	ss.rangePeers(func(peerInfo *PeerInfo) bool {
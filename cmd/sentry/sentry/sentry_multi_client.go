@@ -35,6 +35,7 @@ import (
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	"github.com/ledgerwatch/erigon/eth/protocols/eth"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi/remoteproto"
 	"github.com/ledgerwatch/erigon/rlp"
 	"github.com/ledgerwatch/erigon/turbo/engineapi"
 	"github.com/ledgerwatch/erigon/turbo/services"
@@ -798,7 +799,8 @@ func (cs *MultiClient) makeStatusData() *proto_sentry.StatusData {
 	}
 }
 
-func GrpcClient(ctx context.Context, sentryAddr string) (*direct.SentryClientRemote, error) {
+// GrpcClient connects to a remote sentry, the Peers and LocalTxs clients share the connection of the sentry client
+func GrpcClient(ctx context.Context, sentryAddr string) (*direct.SentryClientRemote, remoteproto.PeersClient, remoteproto.LocalTxsClient, error) {
 	// creating grpc client connection
 	var dialOpts []grpc.DialOption
 
@@ -814,7 +816,7 @@ func GrpcClient(ctx context.Context, sentryAddr string) (*direct.SentryClientRem
 	dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	conn, err := grpc.DialContext(ctx, sentryAddr, dialOpts...)
 	if err != nil {
-		return nil, fmt.Errorf("creating client connection to sentry P2P: %w", err)
+		return nil, nil, nil, fmt.Errorf("creating client connection to sentry P2P: %w", err)
 	}
-	return direct.NewSentryClientRemote(proto_sentry.NewSentryClient(conn)), nil
+	return direct.NewSentryClientRemote(proto_sentry.NewSentryClient(conn)), remoteproto.NewPeersClient(conn), remoteproto.NewLocalTxsClient(conn), nil
 }
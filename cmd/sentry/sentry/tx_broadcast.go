@@ -0,0 +1,492 @@
+package sentry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	lru "github.com/hashicorp/golang-lru/v2"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+	"github.com/ledgerwatch/erigon-lib/direct"
+	proto_sentry "github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
+	"github.com/ledgerwatch/log/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/protocols/eth"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi/remoteproto"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+var (
+	txAnnouncementsSent     = metrics.GetOrCreateCounter("sentry_tx_announcements_sent")
+	txAnnouncementsReceived = metrics.GetOrCreateCounter("sentry_tx_announcements_received")
+	txsSent                 = metrics.GetOrCreateCounter("sentry_txs_sent")
+	txsReceived             = metrics.GetOrCreateCounter("sentry_txs_received")
+	txDuplicates            = metrics.GetOrCreateCounter("sentry_tx_duplicates")
+	localTxsWithheld        = metrics.GetOrCreateCounter("sentry_local_txs_withheld")
+	txFetchLatency          = metrics.GetOrCreateSummary("sentry_tx_fetch_latency_seconds")
+)
+
+const (
+	seenTxsLimit   = 256 * 1024 // Amount of the latest transaction hashes remembered to count the duplicates
+	localTxsLimit  = 16 * 1024  // Amount of the latest local transaction hashes remembered, they are marked right before being sent
+	txFetchTimeout = time.Minute
+)
+
+// TxBroadcastPolicy decides to which peers the local transactions are sent. The transaction pool
+// marks the local transactions through the LocalTxs service before sending them, see LocalTxsSentryClient.
+// The other transactions are always propagated.
+type TxBroadcastPolicy interface {
+	// AllowLocal tells if a message with the code TransactionsMsg, NewPooledTransactionHashesMsg
+	// or PooledTransactionsMsg can carry local transactions to the peer.
+	AllowLocal(msgcode uint64, peer TxBroadcastPeer) bool
+}
+
+type TxBroadcastPeer struct {
+	ID      [64]byte
+	Trusted bool // trusted or static peer
+}
+
+// LocalTxBroadcast is the built-in TxBroadcastPolicy
+type LocalTxBroadcast string
+
+const (
+	LocalTxBroadcastFull    LocalTxBroadcast = "full"    // send the transactions and announce them, like the remote ones
+	LocalTxBroadcastHashes  LocalTxBroadcast = "hashes"  // only announce the transactions, the peers fetch them if interested
+	LocalTxBroadcastTrusted LocalTxBroadcast = "trusted" // send and announce to the trusted and static peers only
+	LocalTxBroadcastNone    LocalTxBroadcast = "none"    // never send the transactions to any peer
+)
+
+func ParseLocalTxBroadcast(s string) (LocalTxBroadcast, error) {
+	switch b := LocalTxBroadcast(s); b {
+	case LocalTxBroadcastFull, LocalTxBroadcastHashes, LocalTxBroadcastTrusted, LocalTxBroadcastNone:
+		return b, nil
+	default:
+		return "", fmt.Errorf("unknown local transactions broadcast policy %q, expected one of full, hashes, trusted, none", s)
+	}
+}
+
+func (b LocalTxBroadcast) AllowLocal(msgcode uint64, peer TxBroadcastPeer) bool {
+	switch b {
+	case LocalTxBroadcastHashes:
+		return msgcode != eth.TransactionsMsg
+	case LocalTxBroadcastTrusted:
+		return peer.Trusted
+	case LocalTxBroadcastNone:
+		return false
+	default:
+		return true
+	}
+}
+
+// TxStats are the transaction propagation statistics of a peer.
+type TxStats struct {
+	AnnouncementsSent     uint64        `json:"announcementsSent"`
+	AnnouncementsReceived uint64        `json:"announcementsReceived"`
+	TxsSent               uint64        `json:"txsSent"`
+	TxsReceived           uint64        `json:"txsReceived"`
+	Duplicates            uint64        `json:"duplicates"`    // known hashes announced again, transactions received again
+	LocalWithheld         uint64        `json:"localWithheld"` // local transactions not sent because of the policy
+	FetchLatency          time.Duration `json:"fetchLatency"`  // moving average of the pooled transactions reply latency
+}
+
+type txFetch struct {
+	peerID    [64]byte
+	requestID uint64
+}
+
+// txPropagation remembers the transactions seen on the network, the local ones and the pending transaction
+// fetches. It is shared by the sentries of a node, one per protocol version.
+type txPropagation struct {
+	seen  *lru.Cache[libcommon.Hash, bool] // true once the transaction itself is received, not only its hash
+	local *lru.Cache[libcommon.Hash, struct{}]
+
+	lock    sync.Mutex
+	fetches map[txFetch]time.Time
+}
+
+func newTxPropagation() *txPropagation {
+	seen, err := lru.New[libcommon.Hash, bool](seenTxsLimit)
+	if err != nil {
+		panic(err)
+	}
+	local, err := lru.New[libcommon.Hash, struct{}](localTxsLimit)
+	if err != nil {
+		panic(err)
+	}
+	return &txPropagation{seen: seen, local: local, fetches: map[txFetch]time.Time{}}
+}
+
+// addSeen remembers the hashes, of received transactions if received is set, and returns how many
+// of them are duplicates: the announcements of known hashes and the transactions received before.
+// The transactions fetched after their announcement are not duplicates.
+func (p *txPropagation) addSeen(hashes []libcommon.Hash, received bool) (duplicates int) {
+	for _, hash := range hashes {
+		wasReceived, found := p.seen.Get(hash)
+		if found && (wasReceived || !received) {
+			duplicates++
+		}
+		if !found || (received && !wasReceived) {
+			p.seen.Add(hash, received)
+		}
+	}
+	return duplicates
+}
+
+func (p *txPropagation) addFetch(peerID [64]byte, requestID uint64, now time.Time) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if len(p.fetches) >= 1024 {
+		for fetch, sent := range p.fetches {
+			if now.Sub(sent) > txFetchTimeout {
+				delete(p.fetches, fetch)
+			}
+		}
+	}
+	p.fetches[txFetch{peerID: peerID, requestID: requestID}] = now
+}
+
+// fetched returns the latency of the reply to a fetch, false if there is no such fetch.
+func (p *txPropagation) fetched(peerID [64]byte, requestID uint64, now time.Time) (time.Duration, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	fetch := txFetch{peerID: peerID, requestID: requestID}
+	sent, ok := p.fetches[fetch]
+	if !ok {
+		return 0, false
+	}
+	delete(p.fetches, fetch)
+	return now.Sub(sent), true
+}
+
+// txMessage is a message carrying transactions or their hashes, split into items which can be left out.
+type txMessage struct {
+	msgcode   uint64
+	requestID uint64
+	hashes    []libcommon.Hash
+	txs       []rlp.RawValue // TransactionsMsg and PooledTransactionsMsg
+	types     []byte         // eth/68 announcements
+	sizes     []uint32
+}
+
+type announcements68 struct {
+	Types  []byte
+	Sizes  []uint32
+	Hashes []libcommon.Hash
+}
+
+// txHash is the hash of a transaction in the network encoding: a list for the legacy ones,
+// a string wrapping the envelope for the typed ones.
+func txHash(raw rlp.RawValue) (libcommon.Hash, error) {
+	kind, content, _, err := rlp.Split(raw)
+	if err != nil {
+		return libcommon.Hash{}, err
+	}
+	if kind == rlp.List {
+		return crypto.Keccak256Hash(raw), nil
+	}
+	return crypto.Keccak256Hash(content), nil
+}
+
+func parseTxHashes(txs []rlp.RawValue) ([]libcommon.Hash, error) {
+	hashes := make([]libcommon.Hash, len(txs))
+	for i, tx := range txs {
+		var err error
+		if hashes[i], err = txHash(tx); err != nil {
+			return nil, err
+		}
+	}
+	return hashes, nil
+}
+
+// parseTxMessage parses the transaction messages, nil for the other ones. eth68 tells
+// the format of the announcements.
+func parseTxMessage(msgcode uint64, eth68 bool, data []byte) (*txMessage, error) {
+	m := &txMessage{msgcode: msgcode}
+	var err error
+	switch msgcode {
+	case eth.TransactionsMsg:
+		if err = rlp.DecodeBytes(data, &m.txs); err != nil {
+			return nil, err
+		}
+		m.hashes, err = parseTxHashes(m.txs)
+	case eth.PooledTransactionsMsg:
+		var packet eth.PooledTransactionsRLPPacket66
+		if err = rlp.DecodeBytes(data, &packet); err != nil {
+			return nil, err
+		}
+		m.requestID, m.txs = packet.RequestId, packet.PooledTransactionsRLPPacket
+		m.hashes, err = parseTxHashes(m.txs)
+	case eth.NewPooledTransactionHashesMsg:
+		if !eth68 {
+			err = rlp.DecodeBytes(data, &m.hashes)
+			break
+		}
+		var packet announcements68
+		if err = rlp.DecodeBytes(data, &packet); err != nil {
+			return nil, err
+		}
+		if (len(packet.Types) != len(packet.Hashes)) || (len(packet.Sizes) != len(packet.Hashes)) {
+			return nil, fmt.Errorf("announcement of %d hashes with %d types and %d sizes", len(packet.Hashes), len(packet.Types), len(packet.Sizes))
+		}
+		m.hashes, m.types, m.sizes = packet.Hashes, packet.Types, packet.Sizes
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// encode encodes the message with the items for which keep is true.
+func (m *txMessage) encode(keep []bool) ([]byte, error) {
+	var txs []rlp.RawValue
+	var hashes []libcommon.Hash
+	var packet announcements68
+	for i, hash := range m.hashes {
+		if !keep[i] {
+			continue
+		}
+		switch {
+		case m.txs != nil:
+			txs = append(txs, m.txs[i])
+		case m.types != nil:
+			packet.Types = append(packet.Types, m.types[i])
+			packet.Sizes = append(packet.Sizes, m.sizes[i])
+			packet.Hashes = append(packet.Hashes, hash)
+		default:
+			hashes = append(hashes, hash)
+		}
+	}
+	switch {
+	case m.msgcode == eth.PooledTransactionsMsg:
+		return rlp.EncodeToBytes(&eth.PooledTransactionsRLPPacket66{RequestId: m.requestID, PooledTransactionsRLPPacket: txs})
+	case m.txs != nil:
+		return rlp.EncodeToBytes(txs)
+	case m.types != nil:
+		return rlp.EncodeToBytes(&packet)
+	default:
+		return rlp.EncodeToBytes(hashes)
+	}
+}
+
+// outgoingTxs is a transaction message sent to several peers, with the local transactions marked.
+type outgoingTxs struct {
+	*txMessage
+	data  []byte
+	local []bool
+}
+
+// outgoingTxs parses a message sent to peers. It returns nil for the messages without transactions.
+func (ss *GrpcServer) outgoingTxs(id proto_sentry.MessageId, msgcode uint64, data []byte) *outgoingTxs {
+	m, err := parseTxMessage(msgcode, id == proto_sentry.MessageId_NEW_POOLED_TRANSACTION_HASHES_68, data)
+	if (err != nil) || (m == nil) {
+		return nil
+	}
+	out := &outgoingTxs{txMessage: m, data: data, local: make([]bool, len(m.hashes))}
+	for i, hash := range m.hashes {
+		out.local[i] = ss.txPropagation.local.Contains(hash)
+	}
+	return out
+}
+
+// MarkLocalTxs remembers the transactions the transaction pool is about to send as local ones.
+func (ss *GrpcServer) MarkLocalTxs(_ context.Context, req *remoteproto.MarkLocalTxsRequest) (*emptypb.Empty, error) {
+	for _, hash := range req.Hashes {
+		if len(hash) != length.Hash {
+			return nil, fmt.Errorf("invalid transaction hash %x", hash)
+		}
+	}
+	for _, hash := range req.Hashes {
+		ss.txPropagation.local.Add(libcommon.BytesToHash(hash), struct{}{})
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// LocalTxsSentryClient is the sentry client of the transaction pool. It marks the local transactions of the
+// messages sent through it, so that the sentry applies its TxBroadcastPolicy to them.
+type LocalTxsSentryClient struct {
+	direct.SentryClient
+	localTxs remoteproto.LocalTxsClient
+	isLocal  atomic.Pointer[func(hash []byte) bool]
+}
+
+func NewLocalTxsSentryClient(client direct.SentryClient, localTxs remoteproto.LocalTxsClient) *LocalTxsSentryClient {
+	return &LocalTxsSentryClient{SentryClient: client, localTxs: localTxs}
+}
+
+// SetIsLocal sets how the local transactions are told, usually TxPool.IsLocal. Nothing is marked before,
+// the transaction pool is created after its sentry clients.
+func (c *LocalTxsSentryClient) SetIsLocal(isLocal func(hash []byte) bool) {
+	c.isLocal.Store(&isLocal)
+}
+
+func (c *LocalTxsSentryClient) SendMessageById(ctx context.Context, in *proto_sentry.SendMessageByIdRequest, opts ...grpc.CallOption) (*proto_sentry.SentPeers, error) {
+	c.markLocal(ctx, in.Data)
+	return c.SentryClient.SendMessageById(ctx, in, opts...)
+}
+
+func (c *LocalTxsSentryClient) SendMessageToRandomPeers(ctx context.Context, in *proto_sentry.SendMessageToRandomPeersRequest, opts ...grpc.CallOption) (*proto_sentry.SentPeers, error) {
+	c.markLocal(ctx, in.Data)
+	return c.SentryClient.SendMessageToRandomPeers(ctx, in, opts...)
+}
+
+func (c *LocalTxsSentryClient) SendMessageToAll(ctx context.Context, in *proto_sentry.OutboundMessageData, opts ...grpc.CallOption) (*proto_sentry.SentPeers, error) {
+	c.markLocal(ctx, in)
+	return c.SentryClient.SendMessageToAll(ctx, in, opts...)
+}
+
+func (c *LocalTxsSentryClient) markLocal(ctx context.Context, data *proto_sentry.OutboundMessageData) {
+	isLocal := c.isLocal.Load()
+	if (isLocal == nil) || (data == nil) {
+		return
+	}
+	msgcode, ok := eth.FromProto[c.Protocol()][data.Id]
+	if !ok {
+		return
+	}
+	m, err := parseTxMessage(msgcode, data.Id == proto_sentry.MessageId_NEW_POOLED_TRANSACTION_HASHES_68, data.Data)
+	if (err != nil) || (m == nil) {
+		return
+	}
+	var local [][]byte
+	for _, hash := range m.hashes {
+		if (*isLocal)(hash[:]) {
+			local = append(local, hash.Bytes())
+		}
+	}
+	if len(local) == 0 {
+		return
+	}
+	// A sentry without the LocalTxs service sends the transactions like the remote ones
+	if _, err := c.localTxs.MarkLocalTxs(ctx, &remoteproto.MarkLocalTxsRequest{Hashes: local}); err != nil {
+		log.Debug("[sentry] marking local transactions", "err", err)
+	}
+}
+
+// dataFor returns the message for the peer without the local transactions the policy withholds from it.
+// False means there is nothing left to send.
+func (ss *GrpcServer) dataFor(out *outgoingTxs, peerInfo *PeerInfo, data []byte) ([]byte, bool) {
+	if out == nil {
+		return data, true
+	}
+	policy := ss.TxBroadcastPolicy
+	if policy == nil {
+		policy = LocalTxBroadcastFull
+	}
+	peer := TxBroadcastPeer{ID: peerInfo.ID(), Trusted: peerInfo.trusted}
+	var keep []bool
+	kept := len(out.hashes)
+	for i, local := range out.local {
+		if local && !policy.AllowLocal(out.msgcode, peer) {
+			if keep == nil {
+				keep = make([]bool, len(out.hashes))
+				for j := range keep {
+					keep[j] = true
+				}
+			}
+			keep[i] = false
+			kept--
+		}
+	}
+	withheld := len(out.hashes) - kept
+	if withheld > 0 {
+		localTxsWithheld.Add(withheld)
+	}
+	if kept > 0 {
+		if out.msgcode == eth.NewPooledTransactionHashesMsg {
+			txAnnouncementsSent.Add(kept)
+		} else {
+			txsSent.Add(kept)
+		}
+	}
+	peerInfo.addTxsSent(out.msgcode, kept, withheld)
+	if keep == nil {
+		return out.data, true
+	}
+	if kept == 0 {
+		return nil, false
+	}
+	encoded, err := out.encode(keep)
+	if err != nil {
+		return nil, false
+	}
+	return encoded, true
+}
+
+// trackIncomingTxs remembers the transactions received from a peer and updates the statistics.
+func (ss *GrpcServer) trackIncomingTxs(peerInfo *PeerInfo, msgcode uint64, data []byte) {
+	m, err := parseTxMessage(msgcode, peerInfo.protocol >= eth.ETH68, data)
+	if (err != nil) || (m == nil) {
+		return
+	}
+	duplicates := ss.txPropagation.addSeen(m.hashes, msgcode != eth.NewPooledTransactionHashesMsg)
+	txDuplicates.Add(duplicates)
+	if msgcode == eth.NewPooledTransactionHashesMsg {
+		txAnnouncementsReceived.Add(len(m.hashes))
+	} else {
+		txsReceived.Add(len(m.hashes))
+	}
+	var latency time.Duration
+	if msgcode == eth.PooledTransactionsMsg {
+		var ok bool
+		if latency, ok = ss.txPropagation.fetched(peerInfo.ID(), m.requestID, time.Now()); ok {
+			txFetchLatency.Update(latency.Seconds())
+		}
+	}
+	peerInfo.addTxsReceived(msgcode, len(m.hashes), duplicates, latency)
+}
+
+// trackTxFetch remembers a GetPooledTransactions request to measure the latency of the reply.
+func (ss *GrpcServer) trackTxFetch(peerInfo *PeerInfo, msgcode uint64, data []byte) {
+	if msgcode != eth.GetPooledTransactionsMsg {
+		return
+	}
+	var packet eth.GetPooledTransactionsPacket66
+	if err := rlp.DecodeBytes(data, &packet); err != nil {
+		return
+	}
+	ss.txPropagation.addFetch(peerInfo.ID(), packet.RequestId, time.Now())
+}
+
+func (pi *PeerInfo) addTxsSent(msgcode uint64, sent, withheld int) {
+	pi.lock.Lock()
+	defer pi.lock.Unlock()
+	if msgcode == eth.NewPooledTransactionHashesMsg {
+		pi.txStats.AnnouncementsSent += uint64(sent)
+	} else {
+		pi.txStats.TxsSent += uint64(sent)
+	}
+	pi.txStats.LocalWithheld += uint64(withheld)
+}
+
+func (pi *PeerInfo) addTxsReceived(msgcode uint64, received, duplicates int, fetchLatency time.Duration) {
+	pi.lock.Lock()
+	defer pi.lock.Unlock()
+	if msgcode == eth.NewPooledTransactionHashesMsg {
+		pi.txStats.AnnouncementsReceived += uint64(received)
+	} else {
+		pi.txStats.TxsReceived += uint64(received)
+	}
+	pi.txStats.Duplicates += uint64(duplicates)
+	if fetchLatency > 0 {
+		if pi.txStats.FetchLatency == 0 {
+			pi.txStats.FetchLatency = fetchLatency
+		} else {
+			pi.txStats.FetchLatency = time.Duration(latencySmoothing*float64(fetchLatency) + (1-latencySmoothing)*float64(pi.txStats.FetchLatency))
+		}
+	}
+}
+
+func (pi *PeerInfo) TxStats() TxStats {
+	pi.lock.RLock()
+	defer pi.lock.RUnlock()
+	return pi.txStats
+}
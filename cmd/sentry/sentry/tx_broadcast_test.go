@@ -0,0 +1,218 @@
+package sentry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/direct"
+	proto_sentry "github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/protocols/eth"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi/remoteproto"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+func testTxs(t *testing.T) ([]rlp.RawValue, []libcommon.Hash) {
+	legacy := types.NewTransaction(1, libcommon.Address{1}, uint256.NewInt(1), 21000, uint256.NewInt(1), nil)
+	dynamic := &types.DynamicFeeTransaction{CommonTx: types.CommonTx{ChainID: uint256.NewInt(1), Nonce: 2, Gas: 21000, Value: uint256.NewInt(2)}, Tip: uint256.NewInt(1), FeeCap: uint256.NewInt(1)}
+	var txs []rlp.RawValue
+	var hashes []libcommon.Hash
+	for _, tx := range []types.Transaction{legacy, dynamic} {
+		b, err := rlp.EncodeToBytes(tx)
+		require.NoError(t, err)
+		txs = append(txs, b)
+		hashes = append(hashes, tx.Hash())
+	}
+	return txs, hashes
+}
+
+func TestParseTxMessage(t *testing.T) {
+	txs, hashes := testTxs(t)
+	b, err := rlp.EncodeToBytes(txs)
+	require.NoError(t, err)
+	m, err := parseTxMessage(eth.TransactionsMsg, false, b)
+	require.NoError(t, err)
+	require.Equal(t, hashes, m.hashes)
+
+	encoded, err := m.encode([]bool{false, true})
+	require.NoError(t, err)
+	m, err = parseTxMessage(eth.TransactionsMsg, false, encoded)
+	require.NoError(t, err)
+	require.Equal(t, hashes[1:], m.hashes)
+
+	b, err = rlp.EncodeToBytes(&eth.PooledTransactionsRLPPacket66{RequestId: 7, PooledTransactionsRLPPacket: txs})
+	require.NoError(t, err)
+	m, err = parseTxMessage(eth.PooledTransactionsMsg, false, b)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), m.requestID)
+	require.Equal(t, hashes, m.hashes)
+
+	b, err = rlp.EncodeToBytes(&announcements68{Types: []byte{0, 2}, Sizes: []uint32{100, 200}, Hashes: hashes})
+	require.NoError(t, err)
+	m, err = parseTxMessage(eth.NewPooledTransactionHashesMsg, true, b)
+	require.NoError(t, err)
+	require.Equal(t, hashes, m.hashes)
+	encoded, err = m.encode([]bool{true, false})
+	require.NoError(t, err)
+	m, err = parseTxMessage(eth.NewPooledTransactionHashesMsg, true, encoded)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0}, m.types)
+	require.Equal(t, []uint32{100}, m.sizes)
+	require.Equal(t, hashes[:1], m.hashes)
+
+	m, err = parseTxMessage(eth.BlockHeadersMsg, false, b)
+	require.NoError(t, err)
+	require.Nil(t, m)
+	_, err = parseTxMessage(eth.NewPooledTransactionHashesMsg, false, []byte{0x01})
+	require.Error(t, err)
+}
+
+func TestLocalTxBroadcast(t *testing.T) {
+	_, err := ParseLocalTxBroadcast("some")
+	require.Error(t, err)
+	trusted, untrusted := TxBroadcastPeer{Trusted: true}, TxBroadcastPeer{}
+	for _, tt := range []struct {
+		policy    string
+		txs       bool
+		hashes    bool
+		toTrusted bool
+	}{
+		{"full", true, true, true},
+		{"hashes", false, true, false},
+		{"trusted", false, false, true},
+		{"none", false, false, false},
+	} {
+		policy, err := ParseLocalTxBroadcast(tt.policy)
+		require.NoError(t, err)
+		require.Equal(t, tt.txs, policy.AllowLocal(eth.TransactionsMsg, untrusted), tt.policy)
+		require.Equal(t, tt.hashes, policy.AllowLocal(eth.NewPooledTransactionHashesMsg, untrusted), tt.policy)
+		require.Equal(t, tt.toTrusted, policy.AllowLocal(eth.TransactionsMsg, trusted), tt.policy)
+	}
+}
+
+func TestTxPropagation(t *testing.T) {
+	ss := &GrpcServer{txPropagation: newTxPropagation(), TxBroadcastPolicy: LocalTxBroadcastTrusted}
+	peer, trustedPeer := testPeerInfo(1, 0), testPeerInfo(2, 0)
+	trustedPeer.trusted = true
+	txs, hashes := testTxs(t)
+
+	// The second transaction comes from the network, the first one is marked local by the transaction pool
+	b, err := rlp.EncodeToBytes(hashes[1:])
+	require.NoError(t, err)
+	ss.trackIncomingTxs(peer, eth.NewPooledTransactionHashesMsg, b)
+	ss.trackIncomingTxs(peer, eth.NewPooledTransactionHashesMsg, b)
+	stats := peer.TxStats()
+	require.Equal(t, uint64(2), stats.AnnouncementsReceived)
+	require.Equal(t, uint64(1), stats.Duplicates)
+	_, err = ss.MarkLocalTxs(context.Background(), &remoteproto.MarkLocalTxsRequest{Hashes: [][]byte{hashes[0].Bytes()}})
+	require.NoError(t, err)
+	_, err = ss.MarkLocalTxs(context.Background(), &remoteproto.MarkLocalTxsRequest{Hashes: [][]byte{{1, 2, 3}}})
+	require.Error(t, err)
+
+	b, err = rlp.EncodeToBytes(txs)
+	require.NoError(t, err)
+	out := ss.outgoingTxs(proto_sentry.MessageId_TRANSACTIONS_66, eth.TransactionsMsg, b)
+	require.Equal(t, []bool{true, false}, out.local)
+
+	data, ok := ss.dataFor(out, trustedPeer, b)
+	require.True(t, ok)
+	require.Equal(t, b, data)
+	data, ok = ss.dataFor(out, peer, b)
+	require.True(t, ok)
+	m, err := parseTxMessage(eth.TransactionsMsg, false, data)
+	require.NoError(t, err)
+	require.Equal(t, hashes[1:], m.hashes)
+	stats = peer.TxStats()
+	require.Equal(t, uint64(1), stats.TxsSent)
+	require.Equal(t, uint64(1), stats.LocalWithheld)
+
+	// Nothing is left to send when all the transactions are withheld
+	b, err = rlp.EncodeToBytes(txs[:1])
+	require.NoError(t, err)
+	_, ok = ss.dataFor(ss.outgoingTxs(proto_sentry.MessageId_TRANSACTIONS_66, eth.TransactionsMsg, b), peer, b)
+	require.False(t, ok)
+
+	// The latency of the pooled transactions fetch is measured by request id, the fetched
+	// transactions are not duplicates of their announcement
+	b, err = rlp.EncodeToBytes(&eth.GetPooledTransactionsPacket66{RequestId: 5, GetPooledTransactionsPacket: hashes[1:]})
+	require.NoError(t, err)
+	ss.trackTxFetch(peer, eth.GetPooledTransactionsMsg, b)
+	time.Sleep(time.Millisecond)
+	b, err = rlp.EncodeToBytes(&eth.PooledTransactionsRLPPacket66{RequestId: 5, PooledTransactionsRLPPacket: txs[1:]})
+	require.NoError(t, err)
+	ss.trackIncomingTxs(peer, eth.PooledTransactionsMsg, b)
+	stats = peer.TxStats()
+	require.Equal(t, uint64(1), stats.TxsReceived)
+	require.Equal(t, uint64(1), stats.Duplicates)
+	require.Greater(t, stats.FetchLatency, time.Duration(0))
+	_, ok = ss.txPropagation.fetched(peer.ID(), 5, time.Now())
+	require.False(t, ok)
+
+	// A transaction received again is a duplicate
+	b, err = rlp.EncodeToBytes(txs[1:])
+	require.NoError(t, err)
+	ss.trackIncomingTxs(trustedPeer, eth.TransactionsMsg, b)
+	require.Equal(t, uint64(1), trustedPeer.TxStats().Duplicates)
+
+	// The sentries of a node share the local transactions
+	other := &GrpcServer{txPropagation: newTxPropagation()}
+	other.ShareTxPropagation(ss)
+	b, err = rlp.EncodeToBytes(txs)
+	require.NoError(t, err)
+	out = other.outgoingTxs(proto_sentry.MessageId_TRANSACTIONS_66, eth.TransactionsMsg, b)
+	require.Equal(t, []bool{true, false}, out.local)
+}
+
+type testLocalTxsClient struct {
+	marked [][]byte
+}
+
+func (c *testLocalTxsClient) MarkLocalTxs(_ context.Context, in *remoteproto.MarkLocalTxsRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+	c.marked = append(c.marked, in.Hashes...)
+	return &emptypb.Empty{}, nil
+}
+
+type testSentryClient struct {
+	direct.SentryClient
+	sent int
+}
+
+func (c *testSentryClient) Protocol() uint { return direct.ETH66 }
+
+func (c *testSentryClient) SendMessageToAll(context.Context, *proto_sentry.OutboundMessageData, ...grpc.CallOption) (*proto_sentry.SentPeers, error) {
+	c.sent++
+	return &proto_sentry.SentPeers{}, nil
+}
+
+func TestLocalTxsSentryClient(t *testing.T) {
+	txs, hashes := testTxs(t)
+	b, err := rlp.EncodeToBytes(txs)
+	require.NoError(t, err)
+	msg := &proto_sentry.OutboundMessageData{Id: proto_sentry.MessageId_TRANSACTIONS_66, Data: b}
+	sentry, localTxs := &testSentryClient{}, &testLocalTxsClient{}
+	c := NewLocalTxsSentryClient(sentry, localTxs)
+
+	// Nothing is marked until the transaction pool is there
+	_, err = c.SendMessageToAll(context.Background(), msg)
+	require.NoError(t, err)
+	require.Empty(t, localTxs.marked)
+
+	c.SetIsLocal(func(hash []byte) bool { return libcommon.BytesToHash(hash) == hashes[1] })
+	_, err = c.SendMessageToAll(context.Background(), msg)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{hashes[1].Bytes()}, localTxs.marked)
+	require.Equal(t, 2, sentry.sent)
+
+	// The other messages are sent as they are
+	_, err = c.SendMessageToAll(context.Background(), &proto_sentry.OutboundMessageData{Id: proto_sentry.MessageId_BLOCK_HEADERS_66})
+	require.NoError(t, err)
+	require.Len(t, localTxs.marked, 1)
+	require.Equal(t, 3, sentry.sent)
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ledgerwatch/log/v3"
+	"github.com/spf13/cobra"
+
+	"github.com/ledgerwatch/erigon/consensus/ethash"
+	"github.com/ledgerwatch/erigon/consensus/serenity"
+	"github.com/ledgerwatch/erigon/core/stateless"
+)
+
+var witnessFile string
+
+func init() {
+	withChain(verifyWitnessCmd)
+	verifyWitnessCmd.Flags().StringVar(&witnessFile, "witness", "", "path to the result of debug_getBlockWitness")
+	must(verifyWitnessCmd.MarkFlagRequired("witness"))
+	must(verifyWitnessCmd.MarkFlagFilename("witness", "json"))
+	rootCmd.AddCommand(verifyWitnessCmd)
+}
+
+var verifyWitnessCmd = &cobra.Command{
+	Use:   "verifyWitness",
+	Short: "Re-execute a block using only its witness and check the state root",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return VerifyWitness(witnessFile)
+	},
+}
+
+func VerifyWitness(path string) error {
+	enc, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	// Accept both the bare result and the whole JSON-RPC response
+	var response struct {
+		Result *stateless.BlockWitness `json:"result"`
+	}
+	if err = json.Unmarshal(enc, &response); err != nil {
+		return fmt.Errorf("decoding witness file: %w", err)
+	}
+	bw := response.Result
+	if bw == nil {
+		bw = new(stateless.BlockWitness)
+		if err = json.Unmarshal(enc, bw); err != nil {
+			return fmt.Errorf("decoding witness file: %w", err)
+		}
+	}
+
+	if genesis.Config != nil {
+		chainConfig = genesis.Config
+	}
+	if chainConfig.Clique != nil || chainConfig.Aura != nil || chainConfig.Bor != nil {
+		return fmt.Errorf("consensus engine of chain %s is not supported", chainConfig.ChainName)
+	}
+	// Verify does not check seals, the fake ethash only skips those and pays the same rewards
+	engine := serenity.New(ethash.NewFaker())
+	if err = stateless.Verify(bw, chainConfig, engine); err != nil {
+		return err
+	}
+	log.Info("Witness verified")
+	return nil
+}
@@ -154,7 +154,13 @@ func (rw *Worker) RunTxTaskNoLock(txTask *exec22.TxTask) {
 		syscall := func(contract libcommon.Address, data []byte) ([]byte, error) {
 			return core.SysCallContract(contract, data, *rw.chainConfig, ibs, header, rw.engine, false /* constCall */, nil /*excessDataGas*/)
 		}
-		rw.engine.Initialize(rw.chainConfig, rw.chain, header, ibs, txTask.Txs, txTask.Uncles, syscall)
+		if err := rw.engine.Initialize(rw.chainConfig, rw.chain, header, ibs, txTask.Txs, txTask.Uncles, syscall); err != nil {
+			txTask.Error = err
+		} else if rw.chainConfig.IsCancun(header.Time) && header.ParentBeaconBlockRoot != nil {
+			if err := misc.ApplyBeaconRootEip4788(header.ParentBeaconBlockRoot, ibs, syscall); err != nil {
+				txTask.Error = err
+			}
+		}
 	} else if txTask.Final {
 		if txTask.BlockNum > 0 {
 			//fmt.Printf("txNum=%d, blockNum=%d, finalisation of the block\n", txTask.TxNum, txTask.BlockNum)
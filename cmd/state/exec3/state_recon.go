@@ -326,7 +326,18 @@ func (rw *ReconWorker) runTxTask(txTask *exec22.TxTask) error {
 			return core.SysCallContract(contract, data, *rw.chainConfig, ibs, txTask.Header, rw.engine, false /* constCall */, nil /*excessDataGas*/)
 		}
 
-		rw.engine.Initialize(rw.chainConfig, rw.chain, txTask.Header, ibs, txTask.Txs, txTask.Uncles, syscall)
+		if err := rw.engine.Initialize(rw.chainConfig, rw.chain, txTask.Header, ibs, txTask.Txs, txTask.Uncles, syscall); err != nil {
+			if _, readError := rw.stateReader.ReadError(); !readError {
+				return fmt.Errorf("initialize of block %d failed: %w", txTask.BlockNum, err)
+			}
+		}
+		if rw.chainConfig.IsCancun(txTask.Header.Time) && txTask.Header.ParentBeaconBlockRoot != nil {
+			if err := misc.ApplyBeaconRootEip4788(txTask.Header.ParentBeaconBlockRoot, ibs, syscall); err != nil {
+				if _, readError := rw.stateReader.ReadError(); !readError {
+					return fmt.Errorf("store parent beacon block root of block %d failed: %w", txTask.BlockNum, err)
+				}
+			}
+		}
 	} else {
 		gp := new(core.GasPool).AddGas(txTask.Tx.GetGas())
 		vmConfig := vm.Config{NoReceipts: true, SkipAnalysis: txTask.SkipAnalysis}
@@ -23,8 +23,10 @@ import (
 	"github.com/ledgerwatch/erigon-lib/txpool/txpooluitl"
 	"github.com/ledgerwatch/erigon-lib/types"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/cmd/sentry/sentry"
 	common2 "github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/ethdb/privateapi"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi/remoteproto"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/spf13/cobra"
 
@@ -119,6 +121,7 @@ func doTxpool(ctx context.Context) error {
 	log.Info("TxPool started", "db", filepath.Join(datadirCli, "txpool"))
 
 	sentryClients := make([]direct.SentryClient, len(sentryAddr))
+	localTxsSentries := make([]*sentry.LocalTxsSentryClient, len(sentryAddr))
 	for i := range sentryAddr {
 		creds, err := grpcutil.TLS(TLSCACert, TLSCertfile, TLSKeyFile)
 		if err != nil {
@@ -129,7 +132,8 @@ func doTxpool(ctx context.Context) error {
 			return fmt.Errorf("could not connect to sentry: %w", err)
 		}
 
-		sentryClients[i] = direct.NewSentryClientRemote(proto_sentry.NewSentryClient(sentryConn))
+		localTxsSentries[i] = sentry.NewLocalTxsSentryClient(direct.NewSentryClientRemote(proto_sentry.NewSentryClient(sentryConn)), remoteproto.NewLocalTxsClient(sentryConn))
+		sentryClients[i] = localTxsSentries[i]
 	}
 
 	cfg := txpoolcfg.DefaultConfig
@@ -161,6 +165,10 @@ func doTxpool(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	// The sentries apply their local transactions broadcast policy to the transactions marked by the pool
+	for _, sentryClient := range localTxsSentries {
+		sentryClient.SetIsLocal(txPool.IsLocal)
+	}
 	fetch.ConnectCore()
 	fetch.ConnectSentries()
 
@@ -25,6 +25,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/c2h5oh/datasize"
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
@@ -57,6 +58,7 @@ import (
 	"github.com/ledgerwatch/erigon/p2p/netutil"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/params/networkname"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 )
 
 // These are all the command line flags we support.
@@ -91,6 +93,19 @@ var (
 		Name:  "dev.period",
 		Usage: "Block period to use in developer mode (0 = mine only if transaction pending)",
 	}
+	DeveloperPoSFlag = cli.BoolFlag{
+		Name:  "dev.pos",
+		Usage: "Run the dev chain post-merge, with blocks proposed by an in-process mock consensus client every --dev.period seconds",
+	}
+	DeveloperWithdrawalsFlag = cli.StringFlag{
+		Name:  "dev.withdrawals",
+		Usage: "Comma separated list of addresses receiving a synthetic withdrawal in every block of a --dev.pos chain",
+	}
+	DeveloperWithdrawalAmountFlag = cli.Uint64Flag{
+		Name:  "dev.withdrawals.amount",
+		Usage: "Amount in gwei of each synthetic withdrawal of a --dev.pos chain",
+		Value: 1_000_000_000,
+	}
 	ChainFlag = cli.StringFlag{
 		Name:  "chain",
 		Usage: "Name of the testnet to join",
@@ -108,6 +123,10 @@ var (
 		Name:  "override.shanghaiTime",
 		Usage: "Manually specify Shanghai fork time, overriding the bundled setting",
 	}
+	OverrideCancunTime = flags.BigFlag{
+		Name:  "override.cancunTime",
+		Usage: "Manually specify Cancun fork time, overriding the bundled setting",
+	}
 	// Ethash settings
 	EthashCachesInMemoryFlag = cli.IntFlag{
 		Name:  "ethash.cachesinmem",
@@ -361,6 +380,21 @@ var (
 		Usage: "Maximum number of requests in a batch",
 		Value: 100,
 	}
+	RpcFiltersTimeoutFlag = cli.DurationFlag{
+		Name:  "rpc.filters.timeout",
+		Usage: "Filters installed with eth_newFilter, eth_newBlockFilter and eth_newPendingTransactionFilter are uninstalled when not polled for this long, 0 disables the expiry",
+		Value: rpchelper.DefaultFiltersConfig.Timeout,
+	}
+	RpcFiltersMaxFlag = cli.IntFlag{
+		Name:  "rpc.filters.max",
+		Usage: "Maximum number of installed polling filters, 0 means no limit",
+		Value: rpchelper.DefaultFiltersConfig.MaxFilters,
+	}
+	RpcFiltersMaxItemsFlag = cli.IntFlag{
+		Name:  "rpc.filters.maxitems",
+		Usage: "Maximum number of logs, headers or transactions a polling filter buffers between polls, the filter returns an error on the next poll when more came. 0 means no limit",
+		Value: rpchelper.DefaultFiltersConfig.MaxItems,
+	}
 	RpcReturnDataLimit = cli.IntFlag{
 		Name:  "rpc.returndata.limit",
 		Usage: "Maximum number of bytes returned from eth_call or similar invocations",
@@ -380,6 +414,11 @@ var (
 		Usage: "Specify granular (method-by-method) API allowlist",
 	}
 
+	RpcAuthFlag = cli.StringFlag{
+		Name:  "rpc.auth",
+		Usage: "Require API keys or JWT from the HTTP and WebSocket RPC clients, each with its own method allowlist, as listed in the given JSON file",
+	}
+
 	RpcGasCapFlag = cli.UintFlag{
 		Name:  "rpc.gascap",
 		Usage: "Sets a cap on gas that can be used in eth_call/estimateGas",
@@ -506,6 +545,11 @@ var (
 		Name:  "sentry.log-peer-info",
 		Usage: "Log detailed peer info when a peer connects or disconnects. Enable to integrate with observer.",
 	}
+	SentryLocalTxBroadcastFlag = cli.StringFlag{
+		Name:  "sentry.local-tx-broadcast",
+		Usage: "Which peers get the local transactions: full - like the remote ones, hashes - only announced, trusted - only trusted and static peers, none - no peers",
+		Value: "full",
+	}
 	SentryDropUselessPeers = cli.BoolFlag{
 		Name:  "sentry.drop-useless-peers",
 		Usage: "Drop useless peers, those returning empty body or header responses",
@@ -1134,6 +1178,7 @@ func SetNodeConfig(ctx *cli.Context, cfg *nodecfg.Config) {
 	SetP2PConfig(ctx, &cfg.P2P, cfg.NodeName(), cfg.Dirs.DataDir)
 
 	cfg.SentryLogPeerInfo = ctx.IsSet(SentryLogPeerInfoFlag.Name)
+	cfg.SentryLocalTxBroadcast = ctx.String(SentryLocalTxBroadcastFlag.Name)
 }
 
 func SetNodeConfigCobra(cmd *cobra.Command, cfg *nodecfg.Config) {
@@ -1560,9 +1605,26 @@ func SetEthConfig(ctx *cli.Context, nodeConfig *nodecfg.Config, cfg *ethconfig.C
 		}
 		log.Info("Using developer account", "address", developer)
 
-		// Create a new developer genesis block or reuse existing one
-		cfg.Genesis = core.DeveloperGenesisBlock(uint64(ctx.Int(DeveloperPeriodFlag.Name)), developer)
-		log.Info("Using custom developer period", "seconds", cfg.Genesis.Config.Clique.Period)
+		if ctx.Bool(DeveloperPoSFlag.Name) {
+			cfg.Genesis = core.DeveloperPoSGenesisBlock(developer)
+			cfg.DevPoS = true
+			cfg.DevPeriod = time.Duration(ctx.Int(DeveloperPeriodFlag.Name)) * time.Second
+			for _, addr := range SplitAndTrim(ctx.String(DeveloperWithdrawalsFlag.Name)) {
+				if !libcommon.IsHexAddress(addr) {
+					Fatalf("Invalid address in --%s: %s", DeveloperWithdrawalsFlag.Name, addr)
+				}
+				cfg.DevWithdrawalRecipients = append(cfg.DevWithdrawalRecipients, libcommon.HexToAddress(addr))
+			}
+			cfg.DevWithdrawalAmount = ctx.Uint64(DeveloperWithdrawalAmountFlag.Name)
+			// Blocks are proposed by the mock consensus client, not mined.
+			cfg.Miner.Enabled = false
+			cfg.Miner.EnabledPOS = true
+			log.Info("Using proof-of-stake developer chain", "period", cfg.DevPeriod)
+		} else {
+			// Create a new developer genesis block or reuse existing one
+			cfg.Genesis = core.DeveloperGenesisBlock(uint64(ctx.Int(DeveloperPeriodFlag.Name)), developer)
+			log.Info("Using custom developer period", "seconds", cfg.Genesis.Config.Clique.Period)
+		}
 		if !ctx.IsSet(MinerGasPriceFlag.Name) {
 			cfg.Miner.GasPrice = big.NewInt(1)
 		}
@@ -1572,6 +1634,9 @@ func SetEthConfig(ctx *cli.Context, nodeConfig *nodecfg.Config, cfg *ethconfig.C
 		cfg.OverrideShanghaiTime = flags.GlobalBig(ctx, OverrideShanghaiTime.Name)
 		cfg.TxPool.OverrideShanghaiTime = cfg.OverrideShanghaiTime
 	}
+	if ctx.IsSet(OverrideCancunTime.Name) {
+		cfg.OverrideCancunTime = flags.GlobalBig(ctx, OverrideCancunTime.Name)
+	}
 
 	if ctx.IsSet(InternalConsensusFlag.Name) && clparams.EmbeddedEnabledByDefault(cfg.NetworkID) {
 		cfg.InternalCL = ctx.Bool(InternalConsensusFlag.Name)
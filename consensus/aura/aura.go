@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"container/list"
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"sort"
@@ -30,7 +31,6 @@ import (
 	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/log/v3"
-	"github.com/ledgerwatch/secp256k1"
 
 	"github.com/ledgerwatch/erigon-lib/chain"
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
@@ -46,6 +46,7 @@ import (
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
 	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/rlp"
 	"github.com/ledgerwatch/erigon/rpc"
@@ -53,10 +54,40 @@ import (
 
 const DEBUG_LOG_FROM = 999_999_999
 
+// Various error messages to mark blocks invalid. These should be private to
+// prevent engine specific errors from being referenced in the remainder of the
+// codebase, inherently breaking if the engine is swapped out. Please put common
+// error types into the consensus package.
+var (
+	// errUnknownBlock is returned when the list of signers is requested for a block
+	// that is not part of the local blockchain.
+	errUnknownBlock = errors.New("unknown block")
+
+	// errMissingSignature is returned if a block's seal doesn't contain a 65 byte
+	// secp256k1 signature.
+	errMissingSignature = errors.New("seal signature missing")
+
+	// errInvalidSignature is returned if the seal signature wasn't made by the
+	// block's author.
+	errInvalidSignature = errors.New("seal signature doesn't match author")
+
+	// errNotProposer is returned if a block is sealed by a validator other than
+	// the primary of its step.
+	errNotProposer = errors.New("block sealed by a validator which is not the step proposer")
+
+	// errDoubleVote is returned if a block is not from a step after its parent's.
+	errDoubleVote = errors.New("double vote")
+
+	// errInvalidDifficulty is returned if the difficulty of a block doesn't match
+	// the score expected from the parent and the block steps.
+	errInvalidDifficulty = errors.New("invalid difficulty")
+)
+
 /*
 Not implemented features from OS:
  - two_thirds_majority_transition - because no chains in OE where this is != MaxUint64 - means 1/2 majority used everywhere
- - emptyStepsTransition - same
+ - emptyStepsTransition - same. Empty step messages are neither signed, exchanged with other validators nor
+   included into seals, so the score of a block never counts empty steps
 
 Repo with solidity sources: https://github.com/poanetwork/posdao-contracts
 */
@@ -137,6 +168,30 @@ func (s *Step) optCalibrate() bool {
 	return true
 }
 
+// stepAt returns the step the given unix timestamp falls into.
+func (s *Step) stepAt(timestamp uint64) uint64 {
+	info := s.durations[0]
+	for _, d := range s.durations[1:] {
+		if d.TransitionTimestamp > timestamp {
+			break
+		}
+		info = d
+	}
+	return (timestamp-info.TransitionTimestamp)/info.StepDuration + info.TransitionStep
+}
+
+// stepStart returns the unix timestamp at which the given step begins.
+func (s *Step) stepStart(step uint64) uint64 {
+	info := s.durations[0]
+	for _, d := range s.durations[1:] {
+		if d.TransitionStep > step {
+			break
+		}
+		info = d
+	}
+	return (step-info.TransitionStep)*info.StepDuration + info.TransitionTimestamp
+}
+
 type PermissionedStep struct {
 	inner      *Step
 	canPropose atomic.Bool
@@ -144,7 +199,6 @@ type PermissionedStep struct {
 
 type ReceivedStepHashes map[uint64]map[libcommon.Address]libcommon.Hash //BTreeMap<(u64, Address), H256>
 
-// nolint
 func (r ReceivedStepHashes) get(step uint64, author libcommon.Address) (libcommon.Hash, bool) {
 	res, ok := r[step]
 	if !ok {
@@ -154,7 +208,6 @@ func (r ReceivedStepHashes) get(step uint64, author libcommon.Address) (libcommo
 	return result, ok
 }
 
-// nolint
 func (r ReceivedStepHashes) insert(step uint64, author libcommon.Address, blockHash libcommon.Hash) {
 	res, ok := r[step]
 	if !ok {
@@ -164,7 +217,6 @@ func (r ReceivedStepHashes) insert(step uint64, author libcommon.Address, blockH
 	res[author] = blockHash
 }
 
-// nolint
 func (r ReceivedStepHashes) dropAncient(step uint64) {
 	for i := range r {
 		if i < step {
@@ -279,9 +331,14 @@ type AuRa struct {
 	exitCh chan struct{}
 	lock   sync.RWMutex // Protects the signer fields
 
+	signer         libcommon.Address // Ethereum address of the signing key
+	signFn         clique.SignerFn   // Signer function to authorize hashes with
+	lastSealedStep uint64            // Step of the last block sealed by this node
+
 	step PermissionedStep
 	// History of step hashes recently received from peers.
-	receivedStepHashes ReceivedStepHashes
+	receivedStepHashes     ReceivedStepHashes
+	receivedStepHashesLock sync.RWMutex
 
 	cfg           AuthorityRoundParams
 	EmptyStepsSet *EmptyStepSet
@@ -393,6 +450,7 @@ func NewAuRa(spec *chain.AuRaConfig, db kv.RwDB) (*AuRa, error) {
 		step:               PermissionedStep{inner: step},
 		cfg:                auraParams,
 		receivedStepHashes: ReceivedStepHashes{},
+		EmptyStepsSet:      &EmptyStepSet{},
 		EpochManager:       NewEpochManager(),
 	}
 	c.step.canPropose.Store(true)
@@ -493,78 +551,117 @@ func (c *AuRa) VerifyHeader(chain consensus.ChainHeaderReader, header *types.Hea
 		log.Error("consensus.ErrUnknownAncestor", "parentNum", number-1, "hash", header.ParentHash.String())
 		return consensus.ErrUnknownAncestor
 	}
-	return ethash.VerifyHeaderBasics(chain, header, parent, false)
+	if err := ethash.VerifyHeaderBasics(chain, header, parent, false); err != nil {
+		return err
+	}
+	// The seal has to be checked before the step: a header that claims to be
+	// authored by someone else must not get that validator reported.
+	if err := c.VerifySeal(chain, header); err != nil {
+		return err
+	}
+	return c.verifyStep(header, parent)
 }
 
-// nolint
+// verifyStep checks the step of the header against its parent and against the
+// steps recently received from the same author. Double votes and sibling blocks
+// are reported to the validator set as malicious behaviour.
+func (c *AuRa) verifyStep(header, parent *types.Header) error {
+	number := header.Number.Uint64()
+	step, parentStep := header.AuRaStep, parent.AuRaStep
+
+	// Ensure header is from the step after parent.
+	if step == parentStep || (number >= c.cfg.ValidateStepTransition && step <= parentStep) {
+		log.Trace("[aura] Multiple blocks proposed for step", "num", parentStep)
+		c.reportMalicious(header, parent)
+		return fmt.Errorf("%w: %x", errDoubleVote, header.Coinbase)
+	}
+
+	// Report malice if the validator produced other sibling blocks in the same step.
+	if c.hasReceivedStepHashes(step, header.Coinbase, header.Hash()) {
+		log.Trace("[aura] Validator produced sibling blocks in the same step", "validator", header.Coinbase, "step", step)
+		c.reportMalicious(header, parent)
+	} else {
+		c.insertReceivedStepHashes(step, header.Coinbase, header.Hash())
+	}
+
+	// Empty steps are not supported, so none can be included into the score.
+	if number >= c.cfg.ValidateScoreTransition {
+		expectedDifficulty := calculateScore(parentStep, step, 0)
+		if header.Difficulty.Cmp(expectedDifficulty.ToBig()) != 0 {
+			return fmt.Errorf("%w: expect=%s, found=%s", errInvalidDifficulty, expectedDifficulty, header.Difficulty)
+		}
+	}
+	return nil
+}
+
+// reportMalicious reports the author of the header to the validator set of its
+// epoch. The header isn't verified yet, so the set number is looked up among the
+// stored epoch transitions instead of moving the epoch manager to the header:
+// before any transition is stored, the header is in the genesis epoch.
+func (c *AuRa) reportMalicious(header, parent *types.Header) {
+	number := header.Number.Uint64()
+	setNumber := number
+	if !c.cfg.ImmediateTransitions {
+		transitionNumber, _, _, err := c.e.FindBeforeOrEqualNumber(parent.Number.Uint64())
+		if err != nil {
+			log.Warn("[aura] Unable to report malicious validator", "validator", header.Coinbase, "num", number, "err", err)
+			return
+		}
+		setNumber = transitionNumber
+	}
+	c.cfg.Validators.reportMalicious(header.Coinbase, setNumber, number, nil)
+}
+
+// hasReceivedStepHashes reports whether a block other than newHash was already
+// received from the author in the given step.
 func (c *AuRa) hasReceivedStepHashes(step uint64, author libcommon.Address, newHash libcommon.Hash) bool {
-	/*
-		self
-			       .received_step_hashes
-			       .read()
-			       .get(&received_step_key)
-			       .map_or(false, |h| *h != new_hash)
-	*/
-	return false
+	c.receivedStepHashesLock.RLock()
+	defer c.receivedStepHashesLock.RUnlock()
+	h, ok := c.receivedStepHashes.get(step, author)
+	return ok && h != newHash
 }
 
-// nolint
 func (c *AuRa) insertReceivedStepHashes(step uint64, author libcommon.Address, newHash libcommon.Hash) {
-	/*
-	   	    self.received_step_hashes
-	                      .write()
-	                      .insert(received_step_key, new_hash);
-	*/
+	c.receivedStepHashesLock.Lock()
+	defer c.receivedStepHashesLock.Unlock()
+	c.receivedStepHashes.insert(step, author, newHash)
 }
 
-// nolint
+// verifyFamily checks the header against the validator set of its epoch, which
+// needs the state of the parent block: the block has to be sealed by the primary
+// of its step, primaries which skipped their steps since the parent are reported
+// as benign misbehaviour, and step hashes too old to detect sibling blocks are
+// forgotten.
 func (c *AuRa) verifyFamily(chain consensus.ChainHeaderReader, e *NonTransactionalEpochReader, header *types.Header, call consensus.Call, syscall consensus.SystemCall) error {
-	// TODO: I call it from Initialize - because looks like no much reason to have separated "verifyFamily" call
-
 	step := header.AuRaStep
 	parent := chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
 	parentStep := parent.AuRaStep
-	//nolint
 	validators, setNumber, err := c.epochSet(chain, e, header, syscall)
 	if err != nil {
 		return err
 	}
-	return nil
-	// TODO(yperbasis): re-enable the rest
 
-	// Ensure header is from the step after parent.
-	//nolint
-	if step == parentStep ||
-		(header.Number.Uint64() >= c.cfg.ValidateStepTransition && step <= parentStep) {
-		log.Trace("[aura] Multiple blocks proposed for step", "num", parentStep)
-		_ = setNumber
-		/*
-			self.validators.report_malicious(
-				header.author(),
-				set_number,
-				header.number(),
-				Default::default(),
-			);
-			Err(EngineError::DoubleVote(*header.author()))?;
-		*/
-		return fmt.Errorf("double vote: %x", header.Coinbase)
+	// Ensure the header is sealed by the primary of its step.
+	signer, err := ecrecover(header)
+	if err != nil {
+		return err
 	}
-
-	// Report malice if the validator produced other sibling blocks in the same step.
-	if !c.hasReceivedStepHashes(step, header.Coinbase, header.Hash()) {
-		/*
-		   trace!(target: "engine", "Validator {} produced sibling blocks in the same step", header.author());
-		   self.validators.report_malicious(
-		       header.author(),
-		       set_number,
-		       header.number(),
-		       Default::default(),
-		   );
-		*/
-	} else {
-		c.insertReceivedStepHashes(step, header.Coinbase, header.Hash())
+	proposer, err := stepProposer(validators, header.ParentHash, step, call)
+	if err != nil {
+		return err
+	}
+	if signer != proposer {
+		log.Trace("[aura] Block sealed by a validator which is not the step proposer", "signer", signer, "proposer", proposer, "step", step)
+		c.cfg.Validators.reportBenign(signer, setNumber, header.Number.Uint64())
+		return fmt.Errorf("%w: signer=%x, proposer=%x", errNotProposer, signer, proposer)
 	}
 
+	// Empty steps are not supported, so skipped primaries are always reported.
+	c.reportSkipped(header, step, parentStep, validators, setNumber, call)
+
 	// Remove hash records older than two full rounds of steps (picked as a reasonable trade-off between
 	// memory consumption and fault-tolerance).
 	cnt, err := count(validators, parent.Hash(), call)
@@ -576,97 +673,45 @@ func (c *AuRa) verifyFamily(chain consensus.ChainHeaderReader, e *NonTransaction
 	if parentStep > siblingMaliceDetectionPeriod {
 		oldestStep = parentStep - siblingMaliceDetectionPeriod
 	}
-	//nolint
 	if oldestStep > 0 {
-		/*
-		   let mut rsh = self.received_step_hashes.write();
-		   let new_rsh = rsh.split_off(&(oldest_step, Address::zero()));
-		   *rsh = new_rsh;
-		*/
+		c.receivedStepHashesLock.Lock()
+		c.receivedStepHashes.dropAncient(oldestStep)
+		c.receivedStepHashesLock.Unlock()
 	}
+	return nil
+}
 
-	emptyStepLen := uint64(0)
-	//self.report_skipped(header, step, parent_step, &*validators, set_number);
-
-	/*
-	   // If empty step messages are enabled we will validate the messages in the seal, missing messages are not
-	   // reported as there's no way to tell whether the empty step message was never sent or simply not included.
-	   let empty_steps_len = if header.number() >= self.empty_steps_transition {
-	       let validate_empty_steps = || -> Result<usize, Error> {
-	           let strict_empty_steps = header.number() >= self.strict_empty_steps_transition;
-	           let empty_steps = header_empty_steps(header)?;
-	           let empty_steps_len = empty_steps.len();
-	           let mut prev_empty_step = 0;
-
-	           for empty_step in empty_steps {
-	               if empty_step.step <= parent_step || empty_step.step >= step {
-	                   Err(EngineError::InsufficientProof(format!(
-	                       "empty step proof for invalid step: {:?}",
-	                       empty_step.step
-	                   )))?;
-	               }
-
-	               if empty_step.parent_hash != *header.parent_hash() {
-	                   Err(EngineError::InsufficientProof(format!(
-	                       "empty step proof for invalid parent hash: {:?}",
-	                       empty_step.parent_hash
-	                   )))?;
-	               }
-
-	               if !empty_step.verify(&*validators).unwrap_or(false) {
-	                   Err(EngineError::InsufficientProof(format!(
-	                       "invalid empty step proof: {:?}",
-	                       empty_step
-	                   )))?;
-	               }
-
-	               if strict_empty_steps {
-	                   if empty_step.step <= prev_empty_step {
-	                       Err(EngineError::InsufficientProof(format!(
-	                           "{} empty step: {:?}",
-	                           if empty_step.step == prev_empty_step {
-	                               "duplicate"
-	                           } else {
-	                               "unordered"
-	                           },
-	                           empty_step
-	                       )))?;
-	                   }
-
-	                   prev_empty_step = empty_step.step;
-	               }
-	           }
-
-	           Ok(empty_steps_len)
-	       };
-
-	       match validate_empty_steps() {
-	           Ok(len) => len,
-	           Err(err) => {
-	               trace!(
-	                   target: "engine",
-	                   "Reporting benign misbehaviour (cause: invalid empty steps) \
-	                   at block #{}, epoch set number {}. Own address: {}",
-	                   header.number(), set_number, self.address().unwrap_or_default()
-	               );
-	               self.validators
-	                   .report_benign(header.author(), set_number, header.number());
-	               return Err(err);
-	           }
-	       }
-	   } else {
-	       self.report_skipped(header, step, parent_step, &*validators, set_number);
-
-	       0
-	   };
-	*/
-	if header.Number.Uint64() >= c.cfg.ValidateScoreTransition {
-		expectedDifficulty := calculateScore(parentStep, step, emptyStepLen)
-		if header.Difficulty.Cmp(expectedDifficulty.ToBig()) != 0 {
-			return fmt.Errorf("invlid difficulty: expect=%s, found=%s\n", expectedDifficulty, header.Difficulty)
+// reportSkipped reports the primaries of the steps between the parent and the
+// header as benign misbehaviour. Only validators report, and never themselves.
+func (c *AuRa) reportSkipped(header *types.Header, step, parentStep uint64, validators ValidatorSet, setNumber uint64, call consensus.Call) {
+	// we're building on top of the genesis block so don't report any skipped steps
+	if header.Number.Uint64() == 1 {
+		return
+	}
+	c.lock.RLock()
+	me := c.signer
+	c.lock.RUnlock()
+	if me == (libcommon.Address{}) || step <= parentStep+1 {
+		return
+	}
+	log.Debug("[aura] Author built block with step gap", "author", header.Coinbase, "step", step, "parentStep", parentStep)
+	seen := map[libcommon.Address]struct{}{}
+	for s := parentStep + 1; s < step; s++ {
+		skippedPrimary, err := stepProposer(validators, header.ParentHash, s, call)
+		if err != nil {
+			log.Warn("[aura] Unable to get stepProposer", "err", err)
+			return
+		}
+		// Stop reporting once validators start repeating.
+		if _, ok := seen[skippedPrimary]; ok {
+			break
+		}
+		seen[skippedPrimary] = struct{}{}
+		// Do not report this signer.
+		if skippedPrimary != me {
+			c.cfg.Validators.reportBenign(skippedPrimary, setNumber, header.Number.Uint64())
 		}
 	}
-	return nil
 }
 
 // VerifyUncles implements consensus.Engine, always returning an error for any
@@ -682,84 +727,53 @@ func (c *AuRa) VerifyUncles(chain consensus.ChainReader, header *types.Header, u
 // VerifySeal implements consensus.Engine, checking whether the signature contained
 // in the header satisfies the consensus protocol requirements.
 func (c *AuRa) VerifySeal(chain consensus.ChainHeaderReader, header *types.Header) error {
+	signer, err := ecrecover(header)
+	if err != nil {
+		return err
+	}
+	if signer != header.Coinbase {
+		return fmt.Errorf("%w: signer=%x, author=%x", errInvalidSignature, signer, header.Coinbase)
+	}
 	return nil
-	//snap, err := c.Snapshot(chain, header.Number.Uint64(), header.Hash(), nil)
-	//if err != nil {
-	//	return err
-	//}
-	//return c.verifySeal(chain, header, snap)
+}
+
+// ecrecover extracts the Ethereum account address from a signed header.
+func ecrecover(header *types.Header) (libcommon.Address, error) {
+	if len(header.AuRaSeal) != crypto.SignatureLength {
+		return libcommon.Address{}, errMissingSignature
+	}
+	pubkey, err := crypto.Ecrecover(sealHash(header).Bytes(), header.AuRaSeal)
+	if err != nil {
+		return libcommon.Address{}, err
+	}
+	var signer libcommon.Address
+	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+	return signer, nil
 }
 
 // Prepare implements consensus.Engine, preparing all the consensus fields of the
 // header for running the transactions on top.
 func (c *AuRa) Prepare(chain consensus.ChainHeaderReader, header *types.Header, state *state.IntraBlockState) error {
+	number := header.Number.Uint64()
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	// A block can only be sealed in a step after its parent's: move it to the
+	// next step if the parent was sealed in the current one.
+	step := c.step.inner.stepAt(header.Time)
+	if step <= parent.AuRaStep {
+		step = parent.AuRaStep + 1
+	}
+	if start := c.step.inner.stepStart(step); header.Time < start {
+		header.Time = start
+	}
+	header.AuRaStep = step
+	header.Difficulty = calculateScore(parent.AuRaStep, step, 0).ToBig()
 	return nil
-	/// If the block isn't a checkpoint, cast a random vote (good enough for now)
-	//header.Coinbase = libcommon.Address{}
-	//header.Nonce = types.BlockNonce{}
-	//
-	//number := header.Number.Uint64()
-	/// Assemble the voting snapshot to check which votes make sense
-	//snap, err := c.Snapshot(chain, number-1, header.ParentHash, nil)
-	//if err != nil {
-	//	return err
-	//}
-	//if number%c.config.Epoch != 0 {
-	//	c.lock.RLock()
-	//
-	//	// Gather all the proposals that make sense voting on
-	//	addresses := make([]libcommon.Address, 0, len(c.proposals))
-	//	for address, authorize := range c.proposals {
-	//		if snap.validVote(address, authorize) {
-	//			addresses = append(addresses, address)
-	//		}
-	//	}
-	//	// If there's pending proposals, cast a vote on them
-	//	if len(addresses) > 0 {
-	//		header.Coinbase = addresses[rand.Intn(len(addresses))]
-	//		if c.proposals[header.Coinbase] {
-	//			copy(header.Nonce[:], NonceAuthVote)
-	//		} else {
-	//			copy(header.Nonce[:], nonceDropVote)
-	//		}
-	//	}
-	//	c.lock.RUnlock()
-	//}
-	/// Set the correct difficulty
-	//header.Difficulty = calcDifficulty(snap, c.signer)
-	//
-	/// Ensure the extra data has all its components
-	//if len(header.Extra) < ExtraVanity {
-	//	header.Extra = append(header.Extra, bytes.Repeat([]byte{0x00}, ExtraVanity-len(header.Extra))...)
-	//}
-	//header.Extra = header.Extra[:ExtraVanity]
-	//
-	//if number%c.config.Epoch == 0 {
-	//	for _, signer := range snap.GetSigners() {
-	//		header.Extra = append(header.Extra, signer[:]...)
-	//	}
-	//}
-	//header.Extra = append(header.Extra, make([]byte, ExtraSeal)...)
-	//
-	/// Mix digest is reserved for now, set to empty
-	//header.MixDigest = libcommon.Hash{}
-	//
-	/// Ensure the timestamp has the correct delay
-	//parent := chain.GetHeader(header.ParentHash, number-1)
-	//if parent == nil {
-	//	return consensus.ErrUnknownAncestor
-	//}
-	//header.Time = parent.Time + c.config.Period
-	//
-	//now := uint64(time.Now().Unix())
-	//if header.Time < now {
-	//	header.Time = now
-	//}
-	//
-	//return nil
 }
 
-func (c *AuRa) Initialize(config *chain.Config, chain consensus.ChainHeaderReader, header *types.Header, state *state.IntraBlockState, txs []types.Transaction, uncles []*types.Header, syscall consensus.SystemCall) {
+func (c *AuRa) Initialize(config *chain.Config, chain consensus.ChainHeaderReader, header *types.Header, state *state.IntraBlockState, txs []types.Transaction, uncles []*types.Header, syscall consensus.SystemCall) error {
 	blockNum := header.Number.Uint64()
 	for address, rewrittenCode := range c.cfg.RewriteBytecode[blockNum] {
 		state.SetCode(address, rewrittenCode)
@@ -774,36 +788,39 @@ func (c *AuRa) Initialize(config *chain.Config, chain consensus.ChainHeaderReade
 	if blockNum == 1 {
 		proof, err := c.GenesisEpochData(header, syscall)
 		if err != nil {
-			panic(err)
+			return err
 		}
 		err = c.e.PutEpoch(header.ParentHash, 0, proof) //TODO: block 0 hardcoded - need fix it inside validators
 		if err != nil {
-			panic(err)
+			return err
 		}
 	}
 
-	//if err := c.verifyFamily(chain, e, header, call, syscall); err != nil { //TODO: OE has it as a separate engine call? why?
-	//	panic(err)
-	//}
+	// Blocks being produced by this node are not sealed yet
+	if len(header.AuRaSeal) > 0 {
+		if err := c.verifyFamily(chain, c.e, header, consensus.Call(syscall), syscall); err != nil {
+			return fmt.Errorf("[aura] initialize block %d: %w", blockNum, err)
+		}
+	}
 
 	// check_and_lock_block -> check_epoch_end_signal
 
 	epoch, err := c.e.GetEpoch(header.ParentHash, blockNum-1)
 	if err != nil {
 		log.Warn("[aura] initialize block: on epoch begin", "err", err)
-		return
+		return nil
 	}
 	isEpochBegin := epoch != nil
 	if !isEpochBegin {
-		return
+		return nil
 	}
 	err = c.cfg.Validators.onEpochBegin(isEpochBegin, header, syscall)
 	if err != nil {
 		log.Warn("[aura] initialize block: on epoch begin", "err", err)
-		return
+		return nil
 	}
 	// check_and_lock_block -> check_epoch_end_signal END (before enact)
-
+	return nil
 }
 
 func (c *AuRa) ApplyRewards(header *types.Header, state *state.IntraBlockState, syscall consensus.SystemCall) error {
@@ -972,8 +989,45 @@ func (c *AuRa) Authorize(signer libcommon.Address, signFn clique.SignerFn) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	//c.signer = signer
-	//c.signFn = signFn
+	c.signer = signer
+	c.signFn = signFn
+}
+
+// GenerateEngineTransactions implements consensus.EngineTransactor, returning the
+// contract calls, such as reports of validator misbehaviour, signed as service
+// transactions of the local validator for the block it is authoring.
+func (c *AuRa) GenerateEngineTransactions(config *chain.Config, header *types.Header, state *state.IntraBlockState, syscall consensus.SystemCall) (types.Transactions, error) {
+	c.lock.RLock()
+	signer, signFn := c.signer, c.signFn
+	c.lock.RUnlock()
+	if signFn == nil || signer != header.Coinbase {
+		return nil, nil
+	}
+	calls, err := c.cfg.Validators.generateEngineTransactions(header.Number.Uint64() == 0, header, syscall)
+	if err != nil {
+		return nil, err
+	}
+	txs := make(types.Transactions, 0, len(calls))
+	nonce := state.GetNonce(signer)
+	for _, call := range calls {
+		// Same gas limit as OpenEthereum gives to the transactions it creates
+		tx := types.NewTransaction(nonce, call.To, uint256.NewInt(0), header.GasLimit/5, uint256.NewInt(0), call.Data)
+		message, err := rlp.EncodeToBytes([]interface{}{tx.Nonce, tx.GasPrice, tx.Gas, tx.To, tx.Value, tx.Data, config.ChainID, uint(0), uint(0)})
+		if err != nil {
+			return nil, err
+		}
+		sig, err := signFn(signer, accounts.MimetypeTextPlain, message)
+		if err != nil {
+			return nil, err
+		}
+		signed, err := tx.WithSignature(*types.LatestSignerForChainID(config.ChainID), sig)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, signed)
+		nonce++
+	}
+	return txs, nil
 }
 
 func (c *AuRa) GenesisEpochData(header *types.Header, caller consensus.SystemCall) ([]byte, error) {
@@ -991,75 +1045,45 @@ func (c *AuRa) GenesisEpochData(header *types.Header, caller consensus.SystemCal
 	return res, nil
 }
 
-func (c *AuRa) Seal(chain consensus.ChainHeaderReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error {
+// Seal implements consensus.Engine, attempting to create a sealed block using
+// the local signing credentials. The block is sent to results once its step has
+// begun.
+func (c *AuRa) Seal(chain consensus.ChainHeaderReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}, call consensus.Call) error {
+	header := block.Header()
+
+	// Sealing the genesis block is not supported
+	number := header.Number.Uint64()
+	if number == 0 {
+		return errUnknownBlock
+	}
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	seal := c.GenerateSeal(chain, header, parent, call)
+	if seal == nil {
+		return nil
+	}
+	header.AuRaSeal = seal
+
+	// Wait until sealing is terminated or the step of the block begins.
+	delay := time.Until(time.Unix(int64(header.Time), 0))
+	log.Trace("Waiting for slot to sign and propagate", "delay", common.PrettyDuration(delay))
+	go func() {
+		select {
+		case <-stop:
+			return
+		case <-time.After(delay):
+		}
+
+		select {
+		case results <- block.WithSeal(header):
+		default:
+			log.Warn("Sealing result is not read by miner", "sealhash", c.SealHash(header))
+		}
+	}()
+
 	return nil
-	//header := block.Header()
-	//
-	/// Sealing the genesis block is not supported
-	//number := header.Number.Uint64()
-	//if number == 0 {
-	//	return errUnknownBlock
-	//}
-	/// For 0-period chains, refuse to seal empty blocks (no reward but would spin sealing)
-	//if c.config.Period == 0 && len(block.Transactions()) == 0 {
-	//	log.Info("Sealing paused, waiting for transactions")
-	//	return nil
-	//}
-	/// Don't hold the signer fields for the entire sealing procedure
-	//c.lock.RLock()
-	//signer, signFn := c.signer, c.signFn
-	//c.lock.RUnlock()
-	//
-	/// Bail out if we're unauthorized to sign a block
-	//snap, err := c.Snapshot(chain, number-1, header.ParentHash, nil)
-	//if err != nil {
-	//	return err
-	//}
-	//if _, authorized := snap.Signers[signer]; !authorized {
-	//	return ErrUnauthorizedSigner
-	//}
-	/// If we're amongst the recent signers, wait for the next block
-	//for seen, recent := range snap.Recents {
-	//	if recent == signer {
-	//		// Signer is among RecentsRLP, only wait if the current block doesn't shift it out
-	//		if limit := uint64(len(snap.Signers)/2 + 1); number < limit || seen > number-limit {
-	//			log.Info("Signed recently, must wait for others")
-	//			return nil
-	//		}
-	//	}
-	//}
-	/// Sweet, the protocol permits us to sign the block, wait for our time
-	//delay := time.Unix(int64(header.Time), 0).Sub(time.Now()) // nolint: gosimple
-	//if header.Difficulty.Cmp(diffNoTurn) == 0 {
-	//	// It's not our turn explicitly to sign, delay it a bit
-	//	wiggle := time.Duration(len(snap.Signers)/2+1) * wiggleTime
-	//	delay += time.Duration(rand.Int63n(int64(wiggle)))
-	//
-	//	log.Trace("Out-of-turn signing requested", "wiggle", common.PrettyDuration(wiggle))
-	//}
-	/// Sign all the things!
-	//sighash, err := signFn(signer, accounts.MimetypeClique, CliqueRLP(header))
-	//if err != nil {
-	//	return err
-	//}
-	//copy(header.Extra[len(header.Extra)-ExtraSeal:], sighash)
-	/// Wait until sealing is terminated or delay timeout.
-	//log.Trace("Waiting for slot to sign and propagate", "delay", common.PrettyDuration(delay))
-	//go func() {
-	//	select {
-	//	case <-stop:
-	//		return
-	//	case <-time.After(delay):
-	//	}
-	//
-	//	select {
-	//	case results <- block.WithSeal(header):
-	//	default:
-	//		log.Warn("Sealing result is not read by miner", "sealhash", SealHash(header))
-	//	}
-	//}()
-	//
-	//return nil
 }
 
 func stepProposer(validators ValidatorSet, blockHash libcommon.Hash, step uint64, call consensus.Call) (libcommon.Address, error) {
@@ -1073,7 +1097,8 @@ func stepProposer(validators ValidatorSet, blockHash libcommon.Hash, step uint64
 // GenerateSeal - Attempt to seal the block internally.
 //
 // This operation is synchronous and may (quite reasonably) not be available, in which case
-// `Seal::None` will be returned.
+// `nil` will be returned. Otherwise the returned signature is to be set as the `AuRaSeal`
+// of the header.
 func (c *AuRa) GenerateSeal(chain consensus.ChainHeaderReader, current, parent *types.Header, call consensus.Call) []byte {
 	// first check to avoid generating signature most of the time
 	// (but there's still a race to the `compare_exchange`)
@@ -1081,8 +1106,15 @@ func (c *AuRa) GenerateSeal(chain consensus.ChainHeaderReader, current, parent *
 		log.Trace("[aura] Aborting seal generation. Can't propose.")
 		return nil
 	}
+	c.lock.RLock()
+	signer, signFn := c.signer, c.signFn
+	c.lock.RUnlock()
+	if signFn == nil {
+		log.Trace("[aura] Aborting seal generation. No signer.")
+		return nil
+	}
 	parentStep := parent.AuRaStep
-	step := c.step.inner.inner.Load()
+	step := current.AuRaStep
 
 	// filter messages from old and future steps and different parents
 	expectedDiff := calculateScore(parentStep, step, 0)
@@ -1096,7 +1128,7 @@ func (c *AuRa) GenerateSeal(chain consensus.ChainHeaderReader, current, parent *
 		return nil
 	}
 
-	validators, setNumber, err := c.epochSet(chain, nil, current, nil)
+	validators, _, err := c.epochSet(chain, c.e, current, consensus.SystemCall(call))
 	if err != nil {
 		log.Warn("[aura] Unable to generate seal", "err", err)
 		return nil
@@ -1107,7 +1139,8 @@ func (c *AuRa) GenerateSeal(chain consensus.ChainHeaderReader, current, parent *
 		log.Warn("[aura] Unable to get stepProposer", "err", err)
 		return nil
 	}
-	if stepProposerAddr != current.Coinbase {
+	if stepProposerAddr != current.Coinbase || current.Coinbase != signer {
+		log.Trace("[aura] Aborting seal generation. Not the step proposer.", "step", step, "proposer", stepProposerAddr)
 		return nil
 	}
 
@@ -1118,54 +1151,31 @@ func (c *AuRa) GenerateSeal(chain consensus.ChainHeaderReader, current, parent *
 		return nil
 	}
 
-	// TODO(yperbasis) re-enable the rest
-
-	_ = setNumber
-	/*
-		signature, err := c.sign(current.bareHash())
-			if err != nil {
-				log.Warn("[aura] generate_seal: FAIL: Accounts secret key unavailable.", "err", err)
-				return nil
-			}
-	*/
+	// only issue one seal per step: other blocks of this step would be siblings
+	// of the sealed one, which is reported as malicious.
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if step <= c.lastSealedStep {
+		log.Trace("[aura] Aborting seal generation. Already sealed a block in this step.", "step", step)
+		return nil
+	}
+	signature, err := signFn(signer, accounts.MimetypeAuRa, sealRLP(current))
+	if err != nil {
+		log.Warn("[aura] generate_seal: FAIL: Accounts secret key unavailable.", "err", err)
+		return nil
+	}
+	c.lastSealedStep = step
 
-	/*
-		  // only issue the seal if we were the first to reach the compare_exchange.
-		  if self
-			  .step
-			  .can_propose
-			  .compare_exchange(true, false, AtomicOrdering::SeqCst, AtomicOrdering::SeqCst)
-			  .is_ok()
-		  {
-			  // we can drop all accumulated empty step messages that are
-			  // older than the parent step since we're including them in
-			  // the seal
-			  self.clear_empty_steps(parent_step);
-
-			  // report any skipped primaries between the parent block and
-			  // the block we're sealing, unless we have empty steps enabled
-			  if header.number() < self.empty_steps_transition {
-				  self.report_skipped(header, step, parent_step, &*validators, set_number);
-			  }
-
-			  let mut fields =
-				  vec![encode(&step), encode(&(H520::from(signature).as_bytes()))];
-
-			  if let Some(empty_steps_rlp) = empty_steps_rlp {
-				  fields.push(empty_steps_rlp);
-			  }
-
-			  return Seal::Regular(fields);
-		  }
-	*/
-	return nil
+	// skipped primaries between the parent block and the block we're sealing
+	// are reported when the sealed block is imported, see verifyFamily
+	return signature
 }
 
 // epochSet fetch correct validator set for epoch at header, taking into account
 // finality of previous transitions.
 func (c *AuRa) epochSet(chain consensus.ChainHeaderReader, e *NonTransactionalEpochReader, h *types.Header, call consensus.SystemCall) (ValidatorSet, uint64, error) {
 	if c.cfg.ImmediateTransitions {
-		return c.cfg.Validators, h.Number.Uint64(), nil
+		return withHeaderReader(c.cfg.Validators, chain), h.Number.Uint64(), nil
 	}
 
 	finalityChecker, epochTransitionNumber, ok := c.EpochManager.zoomToAfter(chain, e, c.cfg.Validators, h.ParentHash, call)
@@ -1204,8 +1214,46 @@ func calculateScore(parentStep, currentStep, currentEmptySteps uint64) *uint256.
 	return res
 }
 
+// SealHash returns the hash of a block prior to it being sealed, i.e. the hash
+// the block author signs.
 func (c *AuRa) SealHash(header *types.Header) libcommon.Hash {
-	return clique.SealHash(header)
+	return sealHash(header)
+}
+
+// sealHash is the hash of the header without its seal fields (step and
+// signature), known as the "bare hash" in OpenEthereum.
+func sealHash(header *types.Header) libcommon.Hash {
+	return crypto.Keccak256Hash(sealRLP(header))
+}
+
+// sealRLP returns the rlp bytes which need to be signed to seal the header.
+func sealRLP(header *types.Header) []byte {
+	enc := []interface{}{
+		header.ParentHash,
+		header.UncleHash,
+		header.Coinbase,
+		header.Root,
+		header.TxHash,
+		header.ReceiptHash,
+		header.Bloom,
+		header.Difficulty,
+		header.Number,
+		header.GasLimit,
+		header.GasUsed,
+		header.Time,
+		header.Extra,
+	}
+	if header.BaseFee != nil {
+		enc = append(enc, header.BaseFee)
+	}
+	if header.WithdrawalsHash != nil {
+		enc = append(enc, header.WithdrawalsHash)
+	}
+	b, err := rlp.EncodeToBytes(enc)
+	if err != nil {
+		panic("can't encode: " + err.Error())
+	}
+	return b
 }
 
 // See https://openethereum.github.io/Permissioning.html#gas-price
@@ -1257,28 +1305,6 @@ func (c *AuRa) APIs(chain consensus.ChainHeaderReader) []rpc.API {
 	}
 }
 
-// nolint
-func (c *AuRa) emptySteps(fromStep, toStep uint64, parentHash libcommon.Hash) []EmptyStep {
-	from := EmptyStep{step: fromStep + 1, parentHash: parentHash}
-	to := EmptyStep{step: toStep}
-	res := []EmptyStep{}
-	if to.LessOrEqual(&from) {
-		return res
-	}
-
-	c.EmptyStepsSet.Sort()
-	c.EmptyStepsSet.ForEach(func(i int, step *EmptyStep) {
-		if step.Less(&from) || (&to).Less(step) {
-			return
-		}
-		if step.parentHash != parentHash {
-			return
-		}
-		res = append(res, *step)
-	})
-	return res
-}
-
 func calculateRewards(aura *AuRa, header *types.Header, syscall consensus.SystemCall) (beneficiaries []libcommon.Address, rewardKind []aurainterfaces.RewardKind, rewards []*uint256.Int, err error) {
 	beneficiaries = append(beneficiaries, header.Coinbase)
 	rewardKind = append(rewardKind, aurainterfaces.RewardAuthor)
@@ -1374,6 +1400,14 @@ func certifierAbi() abi.ABI {
 	return a
 }
 
+func validatorReportAbi() abi.ABI {
+	a, err := abi.JSON(bytes.NewReader(contracts.ValidatorReport))
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
 func registrarAbi() abi.ABI {
 	a, err := abi.JSON(bytes.NewReader(contracts.Registrar))
 	if err != nil {
@@ -1506,64 +1540,18 @@ type EmptyStep struct {
 	parentHash libcommon.Hash //     H256
 }
 
+// Less orders empty steps by step, then parent hash, then signature.
 func (s *EmptyStep) Less(other *EmptyStep) bool {
-	if s.step < other.step {
-		return true
+	if s.step != other.step {
+		return s.step < other.step
 	}
-	if bytes.Compare(s.parentHash[:], other.parentHash[:]) < 0 {
-		return true
+	if c := bytes.Compare(s.parentHash[:], other.parentHash[:]); c != 0 {
+		return c < 0
 	}
-	if bytes.Compare(s.signature, other.signature) < 0 {
-		return true
-	}
-	return false
+	return bytes.Compare(s.signature, other.signature) < 0
 }
 func (s *EmptyStep) LessOrEqual(other *EmptyStep) bool {
-	if s.step <= other.step {
-		return true
-	}
-	if bytes.Compare(s.parentHash[:], other.parentHash[:]) <= 0 {
-		return true
-	}
-	if bytes.Compare(s.signature, other.signature) <= 0 {
-		return true
-	}
-	return false
-}
-
-// Returns `true` if the message has a valid signature by the expected proposer in the message's step.
-func (s *EmptyStep) verify(validators ValidatorSet) (bool, error) { //nolint
-	//sRlp, err := EmptyStepRlp(s.step, s.parentHash)
-	//if err != nil {
-	//	return false, err
-	//}
-	//message := crypto.Keccak256(sRlp)
-
-	/*
-		let correct_proposer = step_proposer(validators, &self.parent_hash, self.step);
-
-		publickey::verify_address(&correct_proposer, &self.signature.into(), &message)
-		.map_err(|e| e.into())
-	*/
-	return true, nil
-}
-
-// nolint
-func (s *EmptyStep) author() (libcommon.Address, error) {
-	sRlp, err := EmptyStepRlp(s.step, s.parentHash)
-	if err != nil {
-		return libcommon.Address{}, err
-	}
-	message := crypto.Keccak256(sRlp)
-	public, err := secp256k1.RecoverPubkey(message, s.signature)
-	if err != nil {
-		return libcommon.Address{}, err
-	}
-	ecdsa, err := crypto.UnmarshalPubkeyStd(public)
-	if err != nil {
-		return libcommon.Address{}, err
-	}
-	return crypto.PubkeyToAddress(*ecdsa), nil
+	return !other.Less(s)
 }
 
 type EmptyStepSet struct {
@@ -1590,20 +1578,11 @@ func (s *EmptyStepSet) ForEach(f func(int, *EmptyStep)) {
 }
 
 func EmptyStepFullRlp(signature []byte, emptyStepRlp []byte) ([]byte, error) {
-	type A struct {
-		s []byte
-		r []byte
-	}
-
-	return rlp.EncodeToBytes(A{s: signature, r: emptyStepRlp})
+	return rlp.EncodeToBytes([]interface{}{signature, rlp.RawValue(emptyStepRlp)})
 }
 
 func EmptyStepRlp(step uint64, parentHash libcommon.Hash) ([]byte, error) {
-	type A struct {
-		s uint64
-		h libcommon.Hash
-	}
-	return rlp.EncodeToBytes(A{s: step, h: parentHash})
+	return rlp.EncodeToBytes([]interface{}{step, parentHash})
 }
 
 // nolint
@@ -1,16 +1,27 @@
 package aura_test
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/ledgerwatch/erigon-lib/chain"
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/kv/memdb"
 
+	"github.com/ledgerwatch/erigon/accounts/abi"
 	"github.com/ledgerwatch/erigon/consensus/aura"
+	"github.com/ledgerwatch/erigon/consensus/aura/contracts"
 	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/turbo/stages"
 	"github.com/ledgerwatch/erigon/turbo/trie"
 )
@@ -23,28 +34,31 @@ func TestEmptyBlock(t *testing.T) {
 	genesisBlock, _, err := core.GenesisToBlock(genesis, "")
 	require.NoError(err)
 
-	genesis.Config.TerminalTotalDifficultyPassed = false
+	// The block is sealed with a generated key, which has to be the validator
+	// of its step.
+	key, err := crypto.GenerateKey()
+	require.NoError(err)
+	auraConfig := *genesis.Config.Aura
+	auraConfig.Validators = &chain.ValidatorSetJson{List: []libcommon.Address{crypto.PubkeyToAddress(key.PublicKey)}}
+	chainConfig := *genesis.Config
+	chainConfig.Aura = &auraConfig
+	chainConfig.TerminalTotalDifficultyPassed = false
+	genesis.Config = &chainConfig
 
-	chainConfig := genesis.Config
 	auraDB := memdb.NewTestDB(t)
 	engine, err := aura.NewAuRa(chainConfig.Aura, auraDB)
 	require.NoError(err)
 	m := stages.MockWithGenesisEngine(t, genesis, engine, false)
 
 	time := uint64(1539016985)
-	header := core.MakeEmptyHeader(genesisBlock.Header(), chainConfig, time, nil)
+	header := core.MakeEmptyHeader(genesisBlock.Header(), &chainConfig, time, nil)
 	header.UncleHash = types.EmptyUncleHash
 	header.TxHash = trie.EmptyRoot
 	header.ReceiptHash = trie.EmptyRoot
-	header.Coinbase = libcommon.HexToAddress("0xcace5b3c29211740e595850e80478416ee77ca21")
-	header.Difficulty = engine.CalcDifficulty(nil, time,
-		0,
-		genesisBlock.Difficulty(),
-		genesisBlock.NumberU64(),
-		genesisBlock.Hash(),
-		genesisBlock.UncleHash(),
-		genesisBlock.Header().AuRaStep,
-	)
+	header.Coinbase = crypto.PubkeyToAddress(key.PublicKey)
+	header.AuRaStep = time / 5
+	header.Difficulty = auraScore(genesisBlock.Header().AuRaStep, header.AuRaStep)
+	sealHeader(t, engine, header, key)
 
 	block := types.NewBlockWithHeader(header)
 
@@ -56,3 +70,398 @@ func TestEmptyBlock(t *testing.T) {
 	err = m.InsertChain(chain)
 	require.NoError(err)
 }
+
+// auraScore is the expected difficulty of a block without empty steps:
+// sqrt(U256::max_value()) + parent_step - current_step.
+func auraScore(parentStep, step uint64) *big.Int {
+	score := new(big.Int).Lsh(big.NewInt(1), 128)
+	score.Sub(score, big.NewInt(1))
+	score.Add(score, new(big.Int).SetUint64(parentStep))
+	return score.Sub(score, new(big.Int).SetUint64(step))
+}
+
+func sealHeader(t *testing.T, engine *aura.AuRa, header *types.Header, key *ecdsa.PrivateKey) {
+	sig, err := crypto.Sign(engine.SealHash(header).Bytes(), key)
+	require.NoError(t, err)
+	header.AuRaSeal = sig
+}
+
+type headerReader struct {
+	config  *chain.Config
+	headers map[libcommon.Hash]*types.Header
+}
+
+func (r *headerReader) Config() *chain.Config        { return r.config }
+func (r *headerReader) CurrentHeader() *types.Header { return nil }
+func (r *headerReader) GetHeader(hash libcommon.Hash, _ uint64) *types.Header {
+	return r.headers[hash]
+}
+func (r *headerReader) GetHeaderByNumber(number uint64) *types.Header {
+	for _, h := range r.headers {
+		if h.Number.Uint64() == number {
+			return h
+		}
+	}
+	return nil
+}
+func (r *headerReader) GetHeaderByHash(hash libcommon.Hash) *types.Header { return r.headers[hash] }
+func (r *headerReader) GetTd(libcommon.Hash, uint64) *big.Int             { return nil }
+
+// chiadoChild builds the header of a block authored by key on top of parent in
+// the given step of the Chiado chain.
+func chiadoChild(t *testing.T, engine *aura.AuRa, parent *types.Header, step uint64, key *ecdsa.PrivateKey) *types.Header {
+	header := core.MakeEmptyHeader(parent, params.ChiadoChainConfig, parent.Time+5, nil)
+	header.UncleHash = types.EmptyUncleHash
+	header.TxHash = trie.EmptyRoot
+	header.ReceiptHash = trie.EmptyRoot
+	header.Coinbase = crypto.PubkeyToAddress(key.PublicKey)
+	header.AuRaStep = step
+	header.Difficulty = auraScore(parent.AuRaStep, step)
+	sealHeader(t, engine, header, key)
+	return header
+}
+
+func newChiadoEngine(t *testing.T, spec *chain.AuRaConfig) (*aura.AuRa, *headerReader, *types.Header) {
+	genesis, _, err := core.GenesisToBlock(core.ChiadoGenesisBlock(), "")
+	require.NoError(t, err)
+	engine, err := aura.NewAuRa(spec, memdb.NewTestDB(t))
+	require.NoError(t, err)
+	reader := &headerReader{
+		config:  params.ChiadoChainConfig,
+		headers: map[libcommon.Hash]*types.Header{genesis.Hash(): genesis.Header()},
+	}
+	return engine, reader, genesis.Header()
+}
+
+func TestVerifyHeader(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	otherKey, _ := crypto.GenerateKey()
+	engine, chain, genesis := newChiadoEngine(t, params.ChiadoChainConfig.Aura)
+
+	step := genesis.Time/5 + 1
+	parent := chiadoChild(t, engine, genesis, step, key)
+	require.NoError(t, engine.VerifyHeader(chain, parent, true))
+	chain.headers[parent.Hash()] = parent
+
+	tests := []struct {
+		name   string
+		header func() *types.Header
+		err    string
+	}{
+		{"Valid", func() *types.Header {
+			return chiadoChild(t, engine, parent, step+1, otherKey)
+		}, ""},
+		{"SkippedSteps", func() *types.Header {
+			return chiadoChild(t, engine, parent, step+3, key)
+		}, ""},
+		{"MissingSeal", func() *types.Header {
+			h := chiadoChild(t, engine, parent, step+1, key)
+			h.AuRaSeal = nil
+			return h
+		}, "seal signature missing"},
+		{"SealedByOther", func() *types.Header {
+			h := chiadoChild(t, engine, parent, step+1, key)
+			h.Coinbase = crypto.PubkeyToAddress(otherKey.PublicKey)
+			return h
+		}, "seal signature doesn't match author"},
+		{"TamperedAfterSeal", func() *types.Header {
+			h := chiadoChild(t, engine, parent, step+1, key)
+			h.Extra = []byte("tampered")
+			return h
+		}, "seal signature doesn't match author"},
+		{"SameStepAsParent", func() *types.Header {
+			return chiadoChild(t, engine, parent, step, otherKey)
+		}, "double vote"},
+		{"StepBeforeParent", func() *types.Header {
+			return chiadoChild(t, engine, parent, step-1, otherKey)
+		}, "double vote"},
+		{"WrongDifficulty", func() *types.Header {
+			h := chiadoChild(t, engine, parent, step+2, key)
+			h.Difficulty = auraScore(parent.AuRaStep, step+1)
+			sealHeader(t, engine, h, key)
+			return h
+		}, "invalid difficulty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := engine.VerifyHeader(chain, tt.header(), true)
+			if tt.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestReportMisbehaviour(t *testing.T) {
+	contract := libcommon.HexToAddress("0x1000000000000000000000000000000000000001")
+	posdaoTransition := uint64(0)
+	spec := *params.ChiadoChainConfig.Aura
+	spec.Validators = &chain.ValidatorSetJson{Contract: &contract}
+	spec.PosdaoTransition = &posdaoTransition
+
+	key, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+	engine, reader, genesis := newChiadoEngine(t, &spec)
+	step := genesis.Time/5 + 1
+	chainID := params.ChiadoChainConfig.ChainID
+
+	reportABI, err := abi.JSON(bytes.NewReader(contracts.ValidatorReport))
+	require.NoError(t, err)
+	reportMalicious := reportABI.Methods["reportMalicious"].ID
+	var shouldReport bool
+	syscall := func(addr libcommon.Address, data []byte) ([]byte, error) {
+		if addr != contract {
+			return nil, errors.New("unexpected contract")
+		}
+		// shouldValidatorReport(address,address,uint256)
+		if shouldReport {
+			return libcommon.BigToHash(big.NewInt(1)).Bytes(), nil
+		}
+		return make([]byte, 32), nil
+	}
+	// Reports are sent by the validator authoring the block on top of genesis.
+	_, tx := memdb.NewTestTx(t)
+	ibs := state.New(state.NewPlainStateReader(tx))
+	ibs.SetNonce(signer, 7)
+	pending := types.CopyHeader(genesis)
+	pending.Coinbase = signer
+	reports := func() types.Transactions {
+		txs, err := engine.GenerateEngineTransactions(params.ChiadoChainConfig, pending, ibs, syscall)
+		require.NoError(t, err)
+		return txs
+	}
+	engine.Authorize(signer, func(_ libcommon.Address, _ string, message []byte) ([]byte, error) {
+		return crypto.Sign(crypto.Keccak256(message), key)
+	})
+
+	// A block and its re-verification are not misbehaviour.
+	block := chiadoChild(t, engine, genesis, step, key)
+	require.NoError(t, engine.VerifyHeader(reader, block, true))
+	require.NoError(t, engine.VerifyHeader(reader, block, true))
+	shouldReport = true
+	require.Empty(t, reports())
+
+	// A sibling block in the same step is reported, but not rejected.
+	sibling := chiadoChild(t, engine, genesis, step, key)
+	sibling.Extra = []byte("sibling")
+	sealHeader(t, engine, sibling, key)
+	require.NoError(t, engine.VerifyHeader(reader, sibling, true))
+	txs := reports()
+	require.Len(t, txs, 1)
+	require.Equal(t, contract, *txs[0].GetTo())
+	require.Equal(t, reportMalicious, txs[0].GetData()[:4])
+	require.Equal(t, uint64(7), txs[0].GetNonce())
+	require.True(t, txs[0].GetPrice().IsZero())
+	sender, err := txs[0].Sender(*types.LatestSignerForChainID(chainID))
+	require.NoError(t, err)
+	require.Equal(t, signer, sender)
+
+	// Only the author of the block sends its reports.
+	pending.Coinbase = libcommon.Address{1}
+	require.Empty(t, reports())
+	pending.Coinbase = signer
+
+	// Malicious reports are retried until the contract doesn't want them anymore.
+	require.Len(t, reports(), 1)
+	shouldReport = false
+	require.Empty(t, reports())
+
+	// A block in the step of its parent is a double vote: rejected and reported.
+	reader.headers[block.Hash()] = block
+	shouldReport = true
+	require.ErrorContains(t, engine.VerifyHeader(reader, chiadoChild(t, engine, block, step, key), true), "double vote")
+	txs = reports()
+	require.Len(t, txs, 1)
+	require.Equal(t, reportMalicious, txs[0].GetData()[:4])
+
+	// A block not sealed by its author doesn't get the author reported.
+	otherKey, _ := crypto.GenerateKey()
+	forged := chiadoChild(t, engine, block, step, otherKey)
+	forged.Coinbase = crypto.PubkeyToAddress(key.PublicKey)
+	require.Error(t, engine.VerifyHeader(reader, forged, true))
+	shouldReport = false
+	require.Empty(t, reports())
+}
+
+// newLocalChain returns a Chiado-based chain in which validators are the only
+// authorities and there are no system contracts, as used for a local
+// single-validator network.
+func newLocalChain(validators ...libcommon.Address) *types.Genesis {
+	genesis := core.ChiadoGenesisBlock()
+	config := *genesis.Config
+	config.Aura = &chain.AuRaConfig{
+		StepDuration: config.Aura.StepDuration,
+		BlockReward:  config.Aura.BlockReward,
+		Validators:   &chain.ValidatorSetJson{List: validators},
+	}
+	config.TerminalTotalDifficultyPassed = false
+	genesis.Config = &config
+	return genesis
+}
+
+// sealBlock prepares an empty block on top of parent and asks engine to seal it.
+func sealBlock(t *testing.T, engine *aura.AuRa, chain *headerReader, parent *types.Header, coinbase libcommon.Address, timestamp uint64) *types.Block {
+	header := core.MakeEmptyHeader(parent, chain.config, timestamp, nil)
+	header.UncleHash = types.EmptyUncleHash
+	header.TxHash = trie.EmptyRoot
+	header.ReceiptHash = trie.EmptyRoot
+	header.Coinbase = coinbase
+	require.NoError(t, engine.Prepare(chain, header, nil))
+	// Mining initializes the block while executing it, before sealing.
+	require.NoError(t, engine.Initialize(chain.config, chain, header, nil, nil, nil, func(libcommon.Address, []byte) ([]byte, error) {
+		return nil, errors.New("no state")
+	}))
+
+	results, stop := make(chan *types.Block, 1), make(chan struct{})
+	defer close(stop)
+	require.NoError(t, engine.Seal(chain, types.NewBlockWithHeader(header), results, stop, nil))
+	delay := time.Until(time.Unix(int64(header.Time), 0))
+	if delay < 0 {
+		delay = 0
+	}
+	select {
+	case block := <-results:
+		return block
+	case <-time.After(delay + time.Second):
+		return nil
+	}
+}
+
+// Check that a local single-validator chain produces blocks that are accepted
+// by the node which mined them.
+func TestSeal(t *testing.T) {
+	require := require.New(t)
+	key, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+
+	genesis := newLocalChain(signer)
+	genesisBlock, _, err := core.GenesisToBlock(genesis, "")
+	require.NoError(err)
+	engine, err := aura.NewAuRa(genesis.Config.Aura, memdb.NewTestDB(t))
+	require.NoError(err)
+	m := stages.MockWithGenesisEngine(t, genesis, engine, false)
+	chain := &headerReader{
+		config:  genesis.Config,
+		headers: map[libcommon.Hash]*types.Header{genesisBlock.Hash(): genesisBlock.Header()},
+	}
+
+	// Nothing is sealed until a signer is authorized.
+	now := uint64(time.Now().Unix())
+	require.Nil(sealBlock(t, engine, chain, genesisBlock.Header(), signer, now))
+
+	engine.Authorize(signer, func(_ libcommon.Address, _ string, message []byte) ([]byte, error) {
+		return crypto.Sign(crypto.Keccak256(message), key)
+	})
+	block := sealBlock(t, engine, chain, genesisBlock.Header(), signer, now)
+	require.NotNil(block)
+	require.Equal(uint64(1), block.NumberU64())
+	require.NoError(engine.VerifyHeader(chain, block.Header(), true))
+	require.NoError(m.InsertChain(&core.ChainPack{
+		Headers:  []*types.Header{block.Header()},
+		Blocks:   types.Blocks{block},
+		Receipts: make([]types.Receipts, 1),
+		TopBlock: block,
+	}))
+
+	// A second block for the same parent in the same step would be a sibling.
+	header := core.MakeEmptyHeader(genesisBlock.Header(), genesis.Config, block.Time(), nil)
+	header.Coinbase = signer
+	require.NoError(engine.Prepare(chain, header, nil))
+	require.Equal(block.Header().AuRaStep, header.AuRaStep)
+	require.Nil(engine.GenerateSeal(chain, header, genesisBlock.Header(), nil))
+
+	// A child of the sealed block is moved to the next step.
+	chain.headers[block.Hash()] = block.Header()
+	child := sealBlock(t, engine, chain, block.Header(), signer, block.Time())
+	require.NotNil(child)
+	require.Equal(block.Header().AuRaStep+1, child.Header().AuRaStep)
+	require.NoError(engine.VerifyHeader(chain, child.Header(), true))
+}
+
+func TestSealNotProposer(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+	other := libcommon.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	genesis := newLocalChain(signer, other)
+	genesisBlock, _, err := core.GenesisToBlock(genesis, "")
+	require.NoError(t, err)
+	engine, err := aura.NewAuRa(genesis.Config.Aura, memdb.NewTestDB(t))
+	require.NoError(t, err)
+	engine.Authorize(signer, func(_ libcommon.Address, _ string, message []byte) ([]byte, error) {
+		return crypto.Sign(crypto.Keccak256(message), key)
+	})
+	chain := &headerReader{
+		config:  genesis.Config,
+		headers: map[libcommon.Hash]*types.Header{genesisBlock.Hash(): genesisBlock.Header()},
+	}
+
+	// Validators take turns by step: only every other step is ours.
+	step := uint64(time.Now().Unix())/5 - 10
+	if step%2 == 0 {
+		step++
+	}
+	require.Nil(t, sealBlock(t, engine, chain, genesisBlock.Header(), signer, step*5))
+	require.NotNil(t, sealBlock(t, engine, chain, genesisBlock.Header(), signer, (step+1)*5))
+}
+
+// Check that a block is only accepted from the primary of its step, which needs
+// the validator set of the epoch and so is verified when the block is initialized.
+func TestVerifyProposer(t *testing.T) {
+	noState := func(libcommon.Address, []byte) ([]byte, error) {
+		return nil, errors.New("no state")
+	}
+
+	// On Chiado the only validator of the first blocks is 0x14747a698Ec1227e6753026C08B29b4d5D3bC484,
+	// so a block sealed by anyone else is not from the step proposer.
+	key, _ := crypto.GenerateKey()
+	engine, reader, genesis := newChiadoEngine(t, params.ChiadoChainConfig.Aura)
+	block := chiadoChild(t, engine, genesis, genesis.Time/5+1, key)
+	require.NoError(t, engine.VerifyHeader(reader, block, true))
+	// The registrar of the certifier is the only contract called.
+	registrar := func(libcommon.Address, []byte) ([]byte, error) {
+		return make([]byte, 32), nil
+	}
+	err := engine.Initialize(reader.config, reader, block, nil, nil, nil, registrar)
+	require.ErrorContains(t, err, "not the step proposer")
+
+	// With two validators taking turns by step, only the right one seals each step.
+	otherKey, _ := crypto.GenerateKey()
+	validators := []libcommon.Address{crypto.PubkeyToAddress(key.PublicKey), crypto.PubkeyToAddress(otherKey.PublicKey)}
+	genesisBlock, _, err := core.GenesisToBlock(newLocalChain(validators...), "")
+	require.NoError(t, err)
+	local := newLocalChain(validators...).Config
+	for _, tt := range []struct {
+		name string
+		key  *ecdsa.PrivateKey
+		err  string
+	}{
+		{"Proposer", key, ""},
+		{"NotProposer", otherKey, "not the step proposer"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, err := aura.NewAuRa(local.Aura, memdb.NewTestDB(t))
+			require.NoError(t, err)
+			chain := &headerReader{
+				config:  local,
+				headers: map[libcommon.Hash]*types.Header{genesisBlock.Hash(): genesisBlock.Header()},
+			}
+			// Step 2k is the turn of the first validator.
+			step := (genesisBlock.Time()/5 + 2) &^ 1
+			header := core.MakeEmptyHeader(genesisBlock.Header(), local, step*5, nil)
+			header.Coinbase = crypto.PubkeyToAddress(tt.key.PublicKey)
+			header.AuRaStep = step
+			header.Difficulty = auraScore(genesisBlock.Header().AuRaStep, step)
+			sealHeader(t, engine, header, tt.key)
+			require.NoError(t, engine.VerifyHeader(chain, header, true))
+			err = engine.Initialize(local, chain, header, nil, nil, nil, noState)
+			if tt.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tt.err)
+			}
+		})
+	}
+}
@@ -15,3 +15,6 @@ var Registrar []byte
 
 //go:embed withdrawal.json
 var Withdrawal []byte
+
+//go:embed validator_report.json
+var ValidatorReport []byte
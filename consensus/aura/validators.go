@@ -4,6 +4,7 @@ import (
 	"container/list"
 	"fmt"
 	"math"
+	"math/big"
 	"sort"
 	"strings"
 	"sync"
@@ -18,7 +19,6 @@ import (
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/consensus"
 	"github.com/ledgerwatch/erigon/consensus/aura/auraabi"
-	"github.com/ledgerwatch/erigon/consensus/aura/aurainterfaces"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/rlp"
@@ -38,6 +38,13 @@ type Call func(libcommon.Address, []byte) (CallResults, error)
 // A system-calling closure. Enacts calls on a block's state from the system address.
 type SystemCall func(libcommon.Address, []byte) (CallResults, error)
 
+// EngineTransaction is a contract call the engine wants to be included, as a
+// transaction from the local validator, into a block this node is creating.
+type EngineTransaction struct {
+	To   libcommon.Address
+	Data []byte
+}
+
 type client interface {
 	CallAtBlockHash(libcommon.Hash, libcommon.Address, []byte) (CallResults, error)
 	CallAtLatestBlock(libcommon.Address, []byte) (CallResults, error)
@@ -56,7 +63,7 @@ type ValidatorSet interface {
 	// but with the same parameters.
 	//
 	// Returns a list of contract calls to be pushed onto the new block.
-	//func generateEngineTransactions(_firstInEpoch bool, _header *types.Header, _call SystemCall) -> Result<Vec<(Address, Bytes)>, EthcoreError>
+	generateEngineTransactions(firstInEpoch bool, header *types.Header, call consensus.SystemCall) ([]EngineTransaction, error)
 
 	// Signalling that a new epoch has begun.
 	//
@@ -75,6 +82,11 @@ type ValidatorSet interface {
 	// Returns the current number of validators.
 	countWithCaller(parentHash libcommon.Hash, caller consensus.Call) (uint64, error)
 
+	// Notifies about malicious behaviour.
+	reportMalicious(validator libcommon.Address, setBlock, block uint64, proof []byte)
+	// Notifies about benign misbehaviour.
+	reportBenign(validator libcommon.Address, setBlock, block uint64)
+
 	// Recover the validator set from the given proof, the block number, and
 	// whether this header is first in its set.
 	//
@@ -163,19 +175,6 @@ type ValidatorSet interface {
 
 	   // Draws an validator nonce modulo number of validators.
 	   fn get_with_caller(&self, parent_block_hash: &H256, nonce: usize, caller: &Call) -> Address;
-
-
-	   // Notifies about malicious behaviour.
-	   fn report_malicious(
-	       &self,
-	       _validator: &Address,
-	       _set_block: BlockNumber,
-	       _block: BlockNumber,
-	       _proof: Bytes,
-	   ) {
-	   }
-	   // Notifies about benign misbehaviour.
-	   fn report_benign(&self, _validator: &Address, _set_block: BlockNumber, _block: BlockNumber) {}
 	*/
 }
 
@@ -234,7 +233,11 @@ func (s *Multi) defaultCaller(blockHash libcommon.Hash) (Call, error) {
 }
 
 func (s *Multi) getWithCaller(parentHash libcommon.Hash, nonce uint, caller consensus.Call) (libcommon.Address, error) {
-	panic("not implemented")
+	set, ok := s.correctSet(parentHash)
+	if !ok {
+		return libcommon.Address{}, fmt.Errorf("no validator set for given parentHash: %x", parentHash)
+	}
+	return set.getWithCaller(parentHash, nonce, caller)
 }
 func (s *Multi) countWithCaller(parentHash libcommon.Hash, caller consensus.Call) (uint64, error) {
 	set, ok := s.correctSet(parentHash)
@@ -244,7 +247,25 @@ func (s *Multi) countWithCaller(parentHash libcommon.Hash, caller consensus.Call
 	return set.countWithCaller(parentHash, caller)
 }
 
+// withHeaderReader returns a copy of the set which looks up the blocks it is
+// given the hashes of in chain.
+func (s *Multi) withHeaderReader(chain consensus.ChainHeaderReader) *Multi {
+	return &Multi{sorted: s.sorted, parent: chain.GetHeaderByHash}
+}
+
+// withHeaderReader binds the multi validator sets to chain, so that they can
+// find the set to use by block hash.
+func withHeaderReader(set ValidatorSet, chain consensus.ChainHeaderReader) ValidatorSet {
+	if multi, ok := set.(*Multi); ok {
+		return multi.withHeaderReader(chain)
+	}
+	return set
+}
+
 func (s *Multi) correctSet(blockHash libcommon.Hash) (ValidatorSet, bool) {
+	if s.parent == nil {
+		return nil, false
+	}
 	parent := s.parent(blockHash)
 	if parent == nil {
 		return nil, false
@@ -297,6 +318,19 @@ func (s *Multi) signalEpochEnd(_ bool, header *types.Header, r types.Receipts) (
 	first := setBlock == num
 	return set.signalEpochEnd(first, header, r)
 }
+func (s *Multi) reportMalicious(validator libcommon.Address, setBlock, block uint64, proof []byte) {
+	_, set := s.correctSetByNumber(setBlock)
+	set.reportMalicious(validator, setBlock, block, proof)
+}
+func (s *Multi) reportBenign(validator libcommon.Address, setBlock, block uint64) {
+	_, set := s.correctSetByNumber(setBlock)
+	set.reportBenign(validator, setBlock, block)
+}
+func (s *Multi) generateEngineTransactions(_ bool, header *types.Header, call consensus.SystemCall) ([]EngineTransaction, error) {
+	num := header.Number.Uint64()
+	setBlock, set := s.correctSetByNumber(num)
+	return set.generateEngineTransactions(setBlock == num, header, call)
+}
 
 type SimpleList struct {
 	validators []libcommon.Address
@@ -330,6 +364,13 @@ func (s *SimpleList) genesisEpochData(header *types.Header, call consensus.Syste
 func (s *SimpleList) signalEpochEnd(_ bool, header *types.Header, r types.Receipts) ([]byte, error) {
 	return nil, nil
 }
+func (s *SimpleList) reportMalicious(validator libcommon.Address, setBlock, block uint64, proof []byte) {
+}
+func (s *SimpleList) reportBenign(validator libcommon.Address, setBlock, block uint64) {
+}
+func (s *SimpleList) generateEngineTransactions(_ bool, header *types.Header, call consensus.SystemCall) ([]EngineTransaction, error) {
+	return nil, nil
+}
 
 // Draws an validator nonce modulo number of validators.
 
@@ -337,75 +378,108 @@ func NewSimpleList(validators []libcommon.Address) *SimpleList {
 	return &SimpleList{validators: validators}
 }
 
-// nolint
 type ReportQueueItem struct {
 	addr     libcommon.Address
 	blockNum uint64
 	data     []byte
+	benign   bool
 }
 
-// nolint
+// ReportQueue holds the reports of validator misbehaviour that are yet to be
+// included into a block.
 type ReportQueue struct {
 	mu   sync.RWMutex
 	list *list.List
 }
 
-// nolint
-func (q *ReportQueue) push(addr libcommon.Address, blockNum uint64, data []byte) {
+func (q *ReportQueue) push(addr libcommon.Address, blockNum uint64, data []byte, benign bool) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
-	q.list.PushBack(&ReportQueueItem{addr: addr, blockNum: blockNum, data: data})
+	if q.list == nil {
+		q.list = list.New()
+	}
+	q.list.PushBack(&ReportQueueItem{addr: addr, blockNum: blockNum, data: data, benign: benign})
+	// The reports are only taken by the nodes producing blocks, keep the queue bounded for the others too.
+	q.truncateLocked()
 }
 
 // Filters reports of validators that have already been reported or are banned.
-// nolint
-func (q *ReportQueue) filter(abi aurainterfaces.ValidatorSetABI, client client, ourAddr, contractAddr libcommon.Address) error {
+func (q *ReportQueue) filter(a abi.ABI, call consensus.SystemCall, ourAddr, contractAddr libcommon.Address) error {
 	q.mu.Lock()
 	defer q.mu.Unlock()
-	for e := q.list.Front(); e != nil; e = e.Next() {
+	if q.list == nil {
+		return nil
+	}
+	var next *list.Element
+	for e := q.list.Front(); e != nil; e = next {
+		next = e.Next()
 		el := e.Value.(*ReportQueueItem)
+		if el.benign {
+			continue
+		}
 		// Check if the validator should be reported.
-		maliciousValidatorAddress := el.addr
-		data, decoder := abi.ShouldValidatorReport(ourAddr, maliciousValidatorAddress, el.blockNum)
-		res, err := client.CallAtLatestBlock(contractAddr, data)
+		packed, err := a.Pack("shouldValidatorReport", ourAddr, el.addr, new(big.Int).SetUint64(el.blockNum))
 		if err != nil {
 			return err
 		}
-		if res.execError != "" {
-			log.Warn("Failed to query report status, dropping pending report.", "reason", res.execError)
+		out, err := call(contractAddr, packed)
+		if err != nil {
+			log.Warn("Failed to query report status, dropping pending report.", "reason", err)
+			q.list.Remove(e)
 			continue
 		}
-		var shouldReport bool
-		err = decoder(res.data, &res)
-		if err != nil {
-			return err
+		res, err := a.Unpack("shouldValidatorReport", out)
+		if err != nil || len(res) == 0 {
+			log.Warn("Failed to decode report status, dropping pending report.", "reason", err)
+			q.list.Remove(e)
+			continue
 		}
-		if !shouldReport {
+		if shouldReport, ok := res[0].(bool); !ok || !shouldReport {
 			q.list.Remove(e)
 		}
 	}
 	return nil
 }
 
+// The maximum number of reports to keep queued.
+const MaxQueuedReports = 10
+
 // Removes reports from the queue if it contains more than `MAX_QUEUED_REPORTS` entries.
-// nolint
 func (q *ReportQueue) truncate() {
-	// The maximum number of reports to keep queued.
-	const MaxQueuedReports = 10
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.truncateLocked()
+}
 
-	q.mu.RLock()
-	defer q.mu.RUnlock()
-	// Removes reports from the queue if it contains more than `MAX_QUEUED_REPORTS` entries.
-	if q.list.Len() > MaxQueuedReports {
-		log.Warn("Removing reports from report cache, even though it has not been finalized", "amount", q.list.Len()-MaxQueuedReports)
+func (q *ReportQueue) truncateLocked() {
+	if q.list == nil || q.list.Len() <= MaxQueuedReports {
+		return
 	}
-	i := 0
-	for e := q.list.Front(); e != nil; e = e.Next() {
-		if i > MaxQueuedReports {
+	log.Warn("Removing reports from report cache, even though it has not been finalized", "amount", q.list.Len()-MaxQueuedReports)
+	for q.list.Len() > MaxQueuedReports {
+		q.list.Remove(q.list.Front())
+	}
+}
+
+// take returns the data of all queued reports. Benign reports are sent only
+// once, so they are removed from the queue.
+func (q *ReportQueue) take() [][]byte {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.list == nil {
+		return nil
+	}
+	var res [][]byte
+	var next *list.Element
+	for e := q.list.Front(); e != nil; e = next {
+		next = e.Next()
+		el := e.Value.(*ReportQueueItem)
+		res = append(res, el.data)
+		if el.benign {
 			q.list.Remove(e)
 		}
-		i++
 	}
+	return res
 }
 
 // The validator contract should have the following interface:
@@ -616,6 +690,9 @@ func (s *ValidatorSafeContract) countWithCaller(parentHash libcommon.Hash, calle
 }
 
 func (s *ValidatorSafeContract) getList(caller consensus.Call) (*SimpleList, bool) {
+	if caller == nil {
+		return nil, false
+	}
 	packed, err := s.abi.Pack("getValidators")
 	if err != nil {
 		panic(err)
@@ -812,6 +889,39 @@ const EVENT_NAME = "InitiateChange(bytes32,address[])"
 
 var EVENT_NAME_HASH = crypto.Keccak256Hash([]byte(EVENT_NAME))
 
+// The safe contract doesn't support reporting: misbehaviour is reported by the
+// "contract" validator set only.
+func (s *ValidatorSafeContract) reportMalicious(validator libcommon.Address, setBlock, block uint64, proof []byte) {
+}
+func (s *ValidatorSafeContract) reportBenign(validator libcommon.Address, setBlock, block uint64) {
+}
+
+// enqueueReport queues a report to be included into the blocks this node
+// creates, which is only done with POSDAO.
+func (s *ValidatorSafeContract) enqueueReport(validator libcommon.Address, block uint64, data []byte, benign bool) {
+	if s.posdaoTransition == nil || block < *s.posdaoTransition {
+		log.Trace("Skipping queueing a misbehavior report", "validator", validator, "block", block)
+		return
+	}
+	s.reportQueue.push(validator, block, data, benign)
+}
+
+func (s *ValidatorSafeContract) generateEngineTransactions(_ bool, header *types.Header, call consensus.SystemCall) ([]EngineTransaction, error) {
+	if s.posdaoTransition == nil || header.Number.Uint64() < *s.posdaoTransition {
+		return nil, nil
+	}
+	// Retry all pending reports.
+	if err := s.reportQueue.filter(s.abi, call, header.Coinbase, s.contractAddress); err != nil {
+		return nil, err
+	}
+	s.reportQueue.truncate()
+	var txs []EngineTransaction
+	for _, data := range s.reportQueue.take() {
+		txs = append(txs, EngineTransaction{To: s.contractAddress, Data: data})
+	}
+	return txs, nil
+}
+
 func (s *ValidatorSafeContract) onCloseBlock(header *types.Header, ourAddress libcommon.Address) error {
 	// Skip the rest of the function unless there has been a transition to POSDAO AuRa.
 	if s.posdaoTransition != nil && header.Number.Uint64() < *s.posdaoTransition {
@@ -892,6 +1002,33 @@ func (s *ValidatorContract) genesisEpochData(header *types.Header, call consensu
 func (s *ValidatorContract) signalEpochEnd(firstInEpoch bool, header *types.Header, r types.Receipts) ([]byte, error) {
 	return s.validators.signalEpochEnd(firstInEpoch, header, r)
 }
+func (s *ValidatorContract) generateEngineTransactions(firstInEpoch bool, header *types.Header, call consensus.SystemCall) ([]EngineTransaction, error) {
+	return s.validators.generateEngineTransactions(firstInEpoch, header, call)
+}
+
+// The engine has no transaction pool to send reports to, so they are queued
+// and included by generateEngineTransactions into the blocks this node creates.
+func (s *ValidatorContract) reportMalicious(validator libcommon.Address, _, block uint64, proof []byte) {
+	if proof == nil {
+		proof = []byte{}
+	}
+	data, err := validatorReportAbi().Pack("reportMalicious", validator, new(big.Int).SetUint64(block), proof)
+	if err != nil {
+		log.Warn("[aura] Validator could not be reported", "validator", validator, "block", block, "err", err)
+		return
+	}
+	log.Warn("[aura] Reporting malicious validator misbehaviour", "validator", validator, "block", block)
+	s.validators.enqueueReport(validator, block, data, false)
+}
+func (s *ValidatorContract) reportBenign(validator libcommon.Address, _, block uint64) {
+	data, err := validatorReportAbi().Pack("reportBenign", validator, new(big.Int).SetUint64(block))
+	if err != nil {
+		log.Warn("[aura] Validator could not be reported", "validator", validator, "block", block, "err", err)
+		return
+	}
+	log.Trace("[aura] Reporting benign validator misbehaviour", "validator", validator, "block", block)
+	s.validators.enqueueReport(validator, block, data, true)
+}
 
 func proveInitial(s *ValidatorSafeContract, contractAddr libcommon.Address, header *types.Header, caller consensus.SystemCall) ([]byte, error) {
 	return rlp.EncodeToBytes(FirstValidatorSetProof{Header: header, ContractAddress: s.contractAddress})
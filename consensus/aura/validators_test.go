@@ -0,0 +1,57 @@
+package aura
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/params"
+)
+
+func TestMultiGetWithCaller(t *testing.T) {
+	spec := params.ChiadoChainConfig.Aura
+	set := newValidatorSetFromJson(spec.Validators, spec.PosdaoTransition)
+
+	headers := map[libcommon.Hash]*types.Header{}
+	for _, number := range []int64{0, 67332, 67333} {
+		h := &types.Header{Number: big.NewInt(number)}
+		headers[h.Hash()] = h
+	}
+	multi := &Multi{sorted: set.(*Multi).sorted, parent: func(hash libcommon.Hash) *types.Header { return headers[hash] }}
+	hashOf := func(number int64) libcommon.Hash { return (&types.Header{Number: big.NewInt(number)}).Hash() }
+
+	// A single validator proposes every step until the second set kicks in.
+	first := libcommon.HexToAddress("0x14747a698Ec1227e6753026C08B29b4d5D3bC484")
+	for _, number := range []int64{0, 67332} {
+		for step := uint(0); step < 3; step++ {
+			proposer, err := multi.getWithCaller(hashOf(number), step, nil)
+			require.NoError(t, err)
+			assert.Equal(t, first, proposer)
+		}
+	}
+
+	proposer, err := multi.getWithCaller(hashOf(67333), 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, libcommon.HexToAddress("0x56D421c0AC39976E89fa400d34ca6579417B84cA"), proposer)
+
+	_, err = multi.getWithCaller(libcommon.Hash{1}, 0, nil)
+	require.Error(t, err)
+	_, err = set.getWithCaller(hashOf(0), 0, nil)
+	require.Error(t, err)
+}
+
+func TestReportQueueBounded(t *testing.T) {
+	var q ReportQueue
+	for i := 0; i < 3*MaxQueuedReports; i++ {
+		q.push(libcommon.Address{byte(i)}, uint64(i), []byte{byte(i)}, false)
+	}
+	require.Equal(t, MaxQueuedReports, q.list.Len())
+	// The oldest reports are dropped
+	require.Equal(t, uint64(2*MaxQueuedReports), q.list.Front().Value.(*ReportQueueItem).blockNum)
+	require.Len(t, q.take(), MaxQueuedReports)
+}
@@ -9,12 +9,13 @@ import (
 
 	proto "github.com/maticnetwork/polyproto/heimdall"
 	protoutils "github.com/maticnetwork/polyproto/utils"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 func (h *HeimdallGRPCClient) FetchCheckpointCount(ctx context.Context) (int64, error) {
 	log.Info("Fetching checkpoint count")
 
-	res, err := h.client.FetchCheckpointCount(ctx, nil)
+	res, err := h.client.FetchCheckpointCount(ctx, &emptypb.Empty{})
 	if err != nil {
 		return 0, err
 	}
@@ -0,0 +1,162 @@
+package heimdallsim
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/ledgerwatch/log/v3"
+	proto "github.com/maticnetwork/polyproto/heimdall"
+	protoutils "github.com/maticnetwork/polyproto/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/erigon/consensus/bor/valset"
+)
+
+// grpcServer serves the Heimdall gRPC API used by heimdallgrpc.HeimdallGRPCClient.
+type grpcServer struct {
+	proto.UnimplementedHeimdallServer
+	sim *HeimdallSimulator
+}
+
+// RegisterGRPCServer registers the Heimdall gRPC service of the simulator on s.
+func (h *HeimdallSimulator) RegisterGRPCServer(s grpc.ServiceRegistrar) {
+	proto.RegisterHeimdallServer(s, &grpcServer{sim: h})
+}
+
+// StartGRPCServer serves the gRPC API on addr until ctx is done and returns
+// the address it listens on.
+func (h *HeimdallSimulator) StartGRPCServer(ctx context.Context, addr string) (net.Addr, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := grpc.NewServer()
+	h.RegisterGRPCServer(srv)
+	go func() {
+		if err := srv.Serve(listener); err != nil {
+			log.Warn("Heimdall simulator gRPC server stopped", "err", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		srv.Stop()
+	}()
+
+	log.Info("Heimdall simulator gRPC server started", "addr", listener.Addr())
+	return listener.Addr(), nil
+}
+
+func (s *grpcServer) Span(ctx context.Context, req *proto.SpanRequest) (*proto.SpanResponse, error) {
+	span, err := s.sim.Span(ctx, req.ID)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	resp := &proto.Span{
+		ID:         span.ID,
+		StartBlock: span.StartBlock,
+		EndBlock:   span.EndBlock,
+		ValidatorSet: &proto.ValidatorSet{
+			Proposer: protoValidator(span.ValidatorSet.Proposer),
+		},
+		ChainID: span.ChainID,
+	}
+	for _, v := range span.ValidatorSet.Validators {
+		resp.ValidatorSet.Validators = append(resp.ValidatorSet.Validators, protoValidator(v))
+	}
+	for i := range span.SelectedProducers {
+		resp.SelectedProducers = append(resp.SelectedProducers, protoValidator(&span.SelectedProducers[i]))
+	}
+
+	return &proto.SpanResponse{Height: "0", Result: resp}, nil
+}
+
+func (s *grpcServer) StateSyncEvents(req *proto.StateSyncEventsRequest, stream proto.Heimdall_StateSyncEventsServer) error {
+	limit := req.Limit
+	if limit == 0 {
+		limit = stateFetchLimit
+	}
+
+	fromID := req.FromID
+	for {
+		events := s.sim.stateSyncEvents(fromID, int64(req.ToTime), limit)
+		if len(events) == 0 {
+			return nil
+		}
+
+		resp := &proto.StateSyncEventsResponse{Height: "0"}
+		for _, event := range events {
+			resp.Result = append(resp.Result, &proto.EventRecord{
+				ID:       event.ID,
+				Contract: event.Contract.Hex(),
+				Data:     hexutility.Encode(event.Data),
+				TxHash:   event.TxHash.Hex(),
+				LogIndex: event.LogIndex,
+				ChainID:  event.ChainID,
+				Time:     timestamppb.New(event.Time),
+			})
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+
+		if uint64(len(events)) < limit {
+			return nil
+		}
+		fromID = events[len(events)-1].ID + 1
+	}
+}
+
+func (s *grpcServer) FetchCheckpoint(ctx context.Context, req *proto.FetchCheckpointRequest) (*proto.FetchCheckpointResponse, error) {
+	cp, err := s.sim.FetchCheckpoint(ctx, req.ID)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return &proto.FetchCheckpointResponse{
+		Height: "0",
+		Result: &proto.Checkpoint{
+			Proposer:   protoutils.ConvertAddressToH160(cp.Proposer),
+			StartBlock: cp.StartBlock.Uint64(),
+			EndBlock:   cp.EndBlock.Uint64(),
+			RootHash:   protoutils.ConvertHashToH256(cp.RootHash),
+			BorChainID: cp.BorChainID,
+			Timestamp:  &timestamppb.Timestamp{Seconds: int64(cp.Timestamp)},
+		},
+	}, nil
+}
+
+func (s *grpcServer) FetchCheckpointCount(ctx context.Context, _ *emptypb.Empty) (*proto.FetchCheckpointCountResponse, error) {
+	count, err := s.sim.FetchCheckpointCount(ctx)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return &proto.FetchCheckpointCountResponse{
+		Height: "0",
+		Result: &proto.CheckpointCount{Result: count},
+	}, nil
+}
+
+func protoValidator(v *valset.Validator) *proto.Validator {
+	return &proto.Validator{
+		ID:               v.ID,
+		Address:          protoutils.ConvertAddressToH160(v.Address),
+		VotingPower:      v.VotingPower,
+		ProposerPriority: v.ProposerPriority,
+	}
+}
+
+func grpcError(err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,205 @@
+// Package heimdallsim implements a local stand-in for Heimdall, so that Bor
+// chains can be run offline: in tests, where the simulator is used in-process
+// as the Heimdall client, and in devnets, where it serves the Heimdall REST and
+// gRPC APIs to the nodes.
+//
+// Unlike Heimdall, the simulator does not follow the root chain: spans are
+// derived from a fixed (but replaceable) validator set, and checkpoints and
+// state-sync events exist only once they are added through the simulator.
+package heimdallsim
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/consensus/bor"
+	"github.com/ledgerwatch/erigon/consensus/bor/clerk"
+	"github.com/ledgerwatch/erigon/consensus/bor/heimdall/checkpoint"
+	"github.com/ledgerwatch/erigon/consensus/bor/heimdall/span"
+	"github.com/ledgerwatch/erigon/consensus/bor/valset"
+)
+
+const (
+	// DefaultSpanLength is the number of blocks in every span but the first,
+	// as produced by Heimdall for a sprint of 64 blocks.
+	DefaultSpanLength = 6400
+
+	// zerothSpanEnd is the last block of span 0, which is shorter than the
+	// others and whose validators come from the genesis contracts.
+	zerothSpanEnd = 255
+
+	// stateFetchLimit is the page size of state-sync event queries if the
+	// caller does not set one.
+	stateFetchLimit = 50
+)
+
+var ErrNotFound = errors.New("not found")
+
+var _ bor.IHeimdallClient = (*HeimdallSimulator)(nil)
+
+// HeimdallSimulator holds the spans, checkpoints and state-sync events of a
+// simulated Heimdall. It is safe for concurrent use.
+type HeimdallSimulator struct {
+	chainID    string
+	spanLength uint64
+
+	lock        sync.RWMutex
+	validators  []*valset.Validator
+	checkpoints []*checkpoint.Checkpoint
+	events      []*clerk.EventRecordWithTime
+}
+
+// New returns a simulator of the Heimdall of the Bor chain with the given
+// chain id, whose spans of spanLength blocks are produced by validators.
+// A zero spanLength stands for DefaultSpanLength.
+func New(chainID string, validators []*valset.Validator, spanLength uint64) *HeimdallSimulator {
+	if spanLength == 0 {
+		spanLength = DefaultSpanLength
+	}
+	h := &HeimdallSimulator{
+		chainID:    chainID,
+		spanLength: spanLength,
+	}
+	h.SetValidators(validators)
+	return h
+}
+
+// SetValidators replaces the validators of the spans fetched from now on.
+func (h *HeimdallSimulator) SetValidators(validators []*valset.Validator) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.validators = make([]*valset.Validator, len(validators))
+	for i, v := range validators {
+		h.validators[i] = v.Copy()
+	}
+}
+
+// AddCheckpoint records a checkpoint of the blocks [start, end] proposed by
+// proposer and returns its number. Checkpoints are numbered from 1.
+func (h *HeimdallSimulator) AddCheckpoint(proposer libcommon.Address, start, end uint64, rootHash libcommon.Hash) int64 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.checkpoints = append(h.checkpoints, &checkpoint.Checkpoint{
+		Proposer:   proposer,
+		StartBlock: new(big.Int).SetUint64(start),
+		EndBlock:   new(big.Int).SetUint64(end),
+		RootHash:   rootHash,
+		BorChainID: h.chainID,
+		Timestamp:  uint64(time.Now().Unix()),
+	})
+	return int64(len(h.checkpoints))
+}
+
+// AddStateSyncEvent records an event for contract on the Bor chain, as if it
+// was emitted on the root chain at recordTime. Events are numbered from 1,
+// in the order they are added, so recordTime must not go backwards. The root
+// chain transaction hash and log index of the event are left empty.
+func (h *HeimdallSimulator) AddStateSyncEvent(contract libcommon.Address, data []byte, recordTime time.Time) *clerk.EventRecordWithTime {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	event := &clerk.EventRecordWithTime{
+		EventRecord: clerk.EventRecord{
+			ID:       uint64(len(h.events)) + 1,
+			Contract: contract,
+			Data:     common.CopyBytes(data),
+			ChainID:  h.chainID,
+		},
+		Time: recordTime.UTC(),
+	}
+	h.events = append(h.events, event)
+	return event
+}
+
+// Span returns the span with the given id. Span 0 covers the blocks up to
+// 255 and every following span is spanLength blocks long.
+func (h *HeimdallSimulator) Span(_ context.Context, spanID uint64) (*span.HeimdallSpan, error) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	var start, end uint64
+	if spanID > 0 {
+		start = zerothSpanEnd + 1 + (spanID-1)*h.spanLength
+		end = start + h.spanLength - 1
+	} else {
+		end = zerothSpanEnd
+	}
+
+	validatorSet := valset.NewValidatorSet(h.validators)
+	producers := make([]valset.Validator, len(validatorSet.Validators))
+	for i, v := range validatorSet.Validators {
+		producers[i] = *v
+	}
+
+	return &span.HeimdallSpan{
+		Span: span.Span{
+			ID:         spanID,
+			StartBlock: start,
+			EndBlock:   end,
+		},
+		ValidatorSet:      *validatorSet,
+		SelectedProducers: producers,
+		ChainID:           h.chainID,
+	}, nil
+}
+
+// StateSyncEvents returns the events starting from fromID recorded before
+// the unix time to.
+func (h *HeimdallSimulator) StateSyncEvents(_ context.Context, fromID uint64, to int64) ([]*clerk.EventRecordWithTime, error) {
+	return h.stateSyncEvents(fromID, to, 0), nil
+}
+
+// stateSyncEvents returns at most limit events starting from fromID recorded
+// before the unix time to, all of them if limit is 0.
+func (h *HeimdallSimulator) stateSyncEvents(fromID uint64, to int64, limit uint64) []*clerk.EventRecordWithTime {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	events := make([]*clerk.EventRecordWithTime, 0)
+	if fromID == 0 {
+		fromID = 1
+	}
+	toTime := time.Unix(to, 0)
+	for id := fromID; id <= uint64(len(h.events)); id++ {
+		event := h.events[id-1]
+		if !event.Time.Before(toTime) || (limit > 0 && uint64(len(events)) == limit) {
+			break
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// FetchCheckpoint returns the checkpoint with the given number, the latest
+// one if number is -1.
+func (h *HeimdallSimulator) FetchCheckpoint(_ context.Context, number int64) (*checkpoint.Checkpoint, error) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	if number == -1 {
+		number = int64(len(h.checkpoints))
+	}
+	if number < 1 || number > int64(len(h.checkpoints)) {
+		return nil, ErrNotFound
+	}
+	cp := *h.checkpoints[number-1]
+	return &cp, nil
+}
+
+// FetchCheckpointCount returns the number of checkpoints.
+func (h *HeimdallSimulator) FetchCheckpointCount(_ context.Context) (int64, error) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return int64(len(h.checkpoints)), nil
+}
+
+func (h *HeimdallSimulator) Close() {}
@@ -0,0 +1,157 @@
+package heimdallsim
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/consensus/bor"
+	"github.com/ledgerwatch/erigon/consensus/bor/heimdall"
+	"github.com/ledgerwatch/erigon/consensus/bor/heimdallgrpc"
+	"github.com/ledgerwatch/erigon/consensus/bor/valset"
+)
+
+func newTestSimulator() *HeimdallSimulator {
+	sim := New("1337", []*valset.Validator{
+		{ID: 1, Address: libcommon.HexToAddress("0x1"), VotingPower: 100},
+		{ID: 2, Address: libcommon.HexToAddress("0x2"), VotingPower: 50},
+	}, 64)
+
+	sim.AddCheckpoint(libcommon.HexToAddress("0x1"), 0, 255, libcommon.HexToHash("0xaa"))
+	sim.AddCheckpoint(libcommon.HexToAddress("0x2"), 256, 511, libcommon.HexToHash("0xbb"))
+
+	recordTime := time.Unix(1_000_000, 0)
+	for i := 0; i < 120; i++ {
+		sim.AddStateSyncEvent(libcommon.HexToAddress("0x1001"), []byte{byte(i)}, recordTime.Add(time.Duration(i)*time.Second))
+	}
+	return sim
+}
+
+func TestSpans(t *testing.T) {
+	sim := newTestSimulator()
+	ctx := context.Background()
+
+	for _, tt := range []struct{ id, start, end uint64 }{{0, 0, 255}, {1, 256, 319}, {2, 320, 383}} {
+		span, err := sim.Span(ctx, tt.id)
+		require.NoError(t, err)
+		require.Equal(t, tt.id, span.ID)
+		require.Equal(t, tt.start, span.StartBlock)
+		require.Equal(t, tt.end, span.EndBlock)
+		require.Equal(t, "1337", span.ChainID)
+		require.Len(t, span.ValidatorSet.Validators, 2)
+		require.Len(t, span.SelectedProducers, 2)
+		require.NotNil(t, span.ValidatorSet.Proposer)
+	}
+
+	sim.SetValidators([]*valset.Validator{{ID: 3, Address: libcommon.HexToAddress("0x3"), VotingPower: 10}})
+	span, err := sim.Span(ctx, 3)
+	require.NoError(t, err)
+	require.Len(t, span.ValidatorSet.Validators, 1)
+	require.Equal(t, libcommon.HexToAddress("0x3"), span.ValidatorSet.Proposer.Address)
+}
+
+func TestStateSyncEvents(t *testing.T) {
+	sim := newTestSimulator()
+	ctx := context.Background()
+
+	// Events are returned from fromID up to, but excluding, the given time
+	events, err := sim.StateSyncEvents(ctx, 11, 1_000_020)
+	require.NoError(t, err)
+	require.Len(t, events, 10)
+	for i, event := range events {
+		require.Equal(t, uint64(11+i), event.ID)
+		require.Equal(t, "1337", event.ChainID)
+	}
+
+	events, err = sim.StateSyncEvents(ctx, 200, 2_000_000)
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
+
+func TestCheckpoints(t *testing.T) {
+	sim := newTestSimulator()
+	ctx := context.Background()
+
+	count, err := sim.FetchCheckpointCount(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	latest, err := sim.FetchCheckpoint(ctx, -1)
+	require.NoError(t, err)
+	require.Equal(t, uint64(256), latest.StartBlock.Uint64())
+
+	_, err = sim.FetchCheckpoint(ctx, 3)
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+// testClient checks that client sees the same data as the simulator itself.
+func testClient(t *testing.T, sim *HeimdallSimulator, client bor.IHeimdallClient) {
+	t.Helper()
+	ctx := context.Background()
+
+	want, err := sim.Span(ctx, 1)
+	require.NoError(t, err)
+	span, err := client.Span(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, want.Span, span.Span)
+	require.Equal(t, want.ChainID, span.ChainID)
+	require.Equal(t, want.ValidatorSet.Validators, span.ValidatorSet.Validators)
+	require.Equal(t, want.ValidatorSet.Proposer, span.ValidatorSet.Proposer)
+	require.Equal(t, want.SelectedProducers, span.SelectedProducers)
+
+	// More events than fit in one page of the client
+	events, err := client.StateSyncEvents(ctx, 1, 1_000_100)
+	require.NoError(t, err)
+	require.Len(t, events, 100)
+	for i, event := range events {
+		require.Equal(t, uint64(i+1), event.ID)
+		require.Equal(t, libcommon.HexToAddress("0x1001"), event.Contract)
+		require.Equal(t, []byte{byte(i)}, []byte(event.Data))
+		require.Equal(t, "1337", event.ChainID)
+		require.True(t, event.Time.Equal(time.Unix(1_000_000+int64(i), 0)))
+	}
+
+	count, err := client.FetchCheckpointCount(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	for _, number := range []int64{1, 2, -1} {
+		want, err := sim.FetchCheckpoint(ctx, number)
+		require.NoError(t, err)
+		cp, err := client.FetchCheckpoint(ctx, number)
+		require.NoError(t, err)
+		require.Equal(t, want.Proposer, cp.Proposer)
+		require.Equal(t, want.StartBlock.Uint64(), cp.StartBlock.Uint64())
+		require.Equal(t, want.EndBlock.Uint64(), cp.EndBlock.Uint64())
+		require.Equal(t, want.RootHash, cp.RootHash)
+		require.Equal(t, want.BorChainID, cp.BorChainID)
+		require.Equal(t, want.Timestamp, cp.Timestamp)
+	}
+}
+
+func TestHTTPServer(t *testing.T) {
+	sim := newTestSimulator()
+	srv := httptest.NewServer(sim.HTTPHandler())
+	defer srv.Close()
+
+	client := heimdall.NewHeimdallClient(srv.URL)
+	defer client.Close()
+	testClient(t, sim, client)
+}
+
+func TestGRPCServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sim := newTestSimulator()
+	addr, err := sim.StartGRPCServer(ctx, "127.0.0.1:0")
+	require.NoError(t, err)
+
+	client := heimdallgrpc.NewHeimdallGRPCClient(addr.String())
+	defer client.Close()
+	testClient(t, sim, client)
+}
@@ -0,0 +1,134 @@
+package heimdallsim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/consensus/bor/heimdall"
+	"github.com/ledgerwatch/erigon/consensus/bor/heimdall/checkpoint"
+)
+
+// HTTPHandler returns the handler of the subset of the Heimdall REST API
+// used by heimdall.HeimdallClient.
+func (h *HeimdallSimulator) HTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/bor/span/", h.handleSpan)
+	mux.HandleFunc("/clerk/event-record/list", h.handleStateSyncEvents)
+	mux.HandleFunc("/checkpoints/", h.handleCheckpoint)
+	return mux
+}
+
+// StartHTTPServer serves the REST API on addr until ctx is done and returns
+// the address it listens on.
+func (h *HeimdallSimulator) StartHTTPServer(ctx context.Context, addr string) (net.Addr, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := &http.Server{
+		Handler:           h.HTTPHandler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Warn("Heimdall simulator HTTP server stopped", "err", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	log.Info("Heimdall simulator HTTP server started", "addr", listener.Addr())
+	return listener.Addr(), nil
+}
+
+func (h *HeimdallSimulator) handleSpan(w http.ResponseWriter, r *http.Request) {
+	spanID, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/bor/span/"), 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	span, err := h.Span(r.Context(), spanID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, &heimdall.SpanResponse{Height: "0", Result: *span})
+}
+
+func (h *HeimdallSimulator) handleStateSyncEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	fromID, err := strconv.ParseUint(query.Get("from-id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid from-id: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	toTime, err := strconv.ParseInt(query.Get("to-time"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid to-time: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit := uint64(stateFetchLimit)
+	if l := query.Get("limit"); l != "" {
+		if limit, err = strconv.ParseUint(l, 10, 64); err != nil {
+			http.Error(w, "invalid limit: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	writeJSON(w, &heimdall.StateSyncEventsResponse{Height: "0", Result: h.stateSyncEvents(fromID, toTime, limit)})
+}
+
+func (h *HeimdallSimulator) handleCheckpoint(w http.ResponseWriter, r *http.Request) {
+	arg := strings.TrimPrefix(r.URL.Path, "/checkpoints/")
+	if arg == "count" {
+		count, err := h.FetchCheckpointCount(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, &checkpoint.CheckpointCountResponse{Height: "0", Result: checkpoint.CheckpointCount{Result: count}})
+		return
+	}
+
+	number := int64(-1)
+	if arg != "latest" {
+		var err error
+		if number, err = strconv.ParseInt(arg, 10, 64); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	cp, err := h.FetchCheckpoint(r.Context(), number)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, &checkpoint.CheckpointResponse{Height: "0", Result: *cp})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Warn("Heimdall simulator failed to write response", "err", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
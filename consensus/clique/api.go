@@ -16,100 +16,25 @@
 
 package clique
 
-/*
 import (
-	"fmt"
+	lru "github.com/hashicorp/golang-lru/v2"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
 
-	"github.com/ledgerwatch/erigon/common"
-	"github.com/ledgerwatch/erigon/consensus"
 	"github.com/ledgerwatch/erigon/core/types"
-	"github.com/ledgerwatch/erigon/rpc"
 )
-// API is a user facing RPC API to allow controlling the signer and voting
-// mechanisms of the proof-of-authority scheme.
-type API struct {
-	chain  consensus.ChainHeaderReader
-	clique *Clique
-}
-
-// GetSnapshot retrieves the state snapshot at a given block.
-func (api *API) GetSnapshot(number *rpc.BlockNumber) (*Snapshot, error) {
-	// Retrieve the requested block number (or current if none requested)
-	var header *types.Header
-	if number == nil || *number == rpc.LatestBlockNumber {
-		header = api.chain.CurrentHeader()
-	} else {
-		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
-	}
-	// Ensure we have an actually valid block and return its snapshot
-	if header == nil {
-		return nil, errUnknownBlock
-	}
-
-	snap, err := api.clique.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
-	if err != nil {
-		return nil, err
-	}
-
-	return snap, err
-}
 
-// GetSnapshotAtHash retrieves the state snapshot at a given block.
-func (api *API) GetSnapshotAtHash(hash libcommon.Hash) (*Snapshot, error) {
-	header := api.chain.GetHeaderByHash(hash)
-	if header == nil {
-		return nil, errUnknownBlock
-	}
-
-	snap, err := api.clique.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
-	if err != nil {
-		return nil, err
-	}
-
-	return snap, err
-}
-
-// GetSigners retrieves the list of authorized signers at the specified block.
-func (api *API) GetSigners(number *rpc.BlockNumber) ([]libcommon.Address, error) {
-	// Retrieve the requested block number (or current if none requested)
-	var header *types.Header
-	if number == nil || *number == rpc.LatestBlockNumber {
-		header = api.chain.CurrentHeader()
-	} else {
-		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
-	}
-	// Ensure we have an actually valid block and return the signers from its snapshot
-	if header == nil {
-		return nil, errUnknownBlock
-	}
-
-	snap, err := api.clique.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
-	if err != nil {
-		return nil, err
-	}
-	return snap.signers(), nil
-}
-
-// GetSignersAtHash retrieves the list of authorized signers at the specified block.
-func (api *API) GetSignersAtHash(hash libcommon.Hash) ([]libcommon.Address, error) {
-	header := api.chain.GetHeaderByHash(hash)
-	if header == nil {
-		return nil, errUnknownBlock
-	}
-	snap, err := api.clique.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
-	if err != nil {
-		return nil, err
-	}
-	return snap.signers(), nil
-}
+// The user facing clique RPC API is served by rpcdaemon (see
+// cmd/rpcdaemon/commands/clique_api.go), which reads snapshots from the
+// consensus database and controls the signer voting of the node through the
+// methods below.
 
 // Proposals returns the current proposals the node tries to uphold and vote on.
-func (api *API) Proposals() map[libcommon.Address]bool {
-	api.clique.lock.RLock()
-	defer api.clique.lock.RUnlock()
+func (c *Clique) Proposals() map[libcommon.Address]bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
 
 	proposals := make(map[libcommon.Address]bool)
-	for address, auth := range api.clique.proposals {
+	for address, auth := range c.proposals {
 		proposals[address] = auth
 	}
 	return proposals
@@ -117,75 +42,59 @@ func (api *API) Proposals() map[libcommon.Address]bool {
 
 // Propose injects a new authorization proposal that the signer will attempt to
 // push through.
-func (api *API) Propose(address libcommon.Address, auth bool) {
-	api.clique.lock.Lock()
-	defer api.clique.lock.Unlock()
+func (c *Clique) Propose(address libcommon.Address, auth bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 
-	api.clique.proposals[address] = auth
+	c.proposals[address] = auth
 }
 
 // Discard drops a currently running proposal, stopping the signer from casting
 // further votes (either for or against).
-func (api *API) Discard(address libcommon.Address) {
-	api.clique.lock.Lock()
-	defer api.clique.lock.Unlock()
+func (c *Clique) Discard(address libcommon.Address) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 
-	delete(api.clique.proposals, address)
+	delete(c.proposals, address)
 }
 
-type status struct {
-	InturnPercent float64                `json:"inturnPercent"`
+// Status is the signing activity over a range of blocks.
+type Status struct {
+	InturnPercent float64                   `json:"inturnPercent"`
 	SigningStatus map[libcommon.Address]int `json:"sealerActivity"`
-	NumBlocks     uint64                 `json:"numBlocks"`
+	NumBlocks     uint64                    `json:"numBlocks"`
 }
 
-// Status returns the status of the last N blocks,
-// - the number of active signers,
-// - the number of signers,
-// - the percentage of in-turn blocks
-func (api *API) Status() (*status, error) {
-	var (
-		numBlocks = uint64(64)
-		header    = api.chain.CurrentHeader()
-		diff      = uint64(0)
-		optimals  = 0
-	)
-	snap, err := api.clique.snapshot(api.chain, header.Number.Uint64(), header.Hash(), nil)
+// Status returns the status of the given consecutive headers sealed by the
+// signers of the snapshot:
+// - the number of blocks sealed by each signer,
+// - the percentage of in-turn blocks.
+func (s *Snapshot) Status(headers []*types.Header) (*Status, error) {
+	signStatus := make(map[libcommon.Address]int)
+	for _, signer := range s.GetSigners() {
+		signStatus[signer] = 0
+	}
+	sigcache, err := lru.NewARC[libcommon.Hash, libcommon.Address](len(headers) + 1)
 	if err != nil {
 		return nil, err
 	}
-	var (
-		signers = snap.signers()
-		end     = header.Number.Uint64()
-		start   = end - numBlocks
-	)
-	if numBlocks > end {
-		start = 1
-		numBlocks = end - start
-	}
-	signStatus := make(map[libcommon.Address]int)
-	for _, s := range signers {
-		signStatus[s] = 0
-	}
-	for n := start; n < end; n++ {
-		h := api.chain.GetHeaderByNumber(n)
-		if h == nil {
-			return nil, fmt.Errorf("missing block %d", n)
-		}
-		if h.Difficulty.Cmp(diffInTurn) == 0 {
+	optimals := 0
+	for _, h := range headers {
+		if h.Difficulty.Cmp(DiffInTurn) == 0 {
 			optimals++
 		}
-		diff += h.Difficulty.Uint64()
-		sealer, err := api.clique.Author(h)
+		sealer, err := ecrecover(h, sigcache)
 		if err != nil {
 			return nil, err
 		}
 		signStatus[sealer]++
 	}
-	return &status{
-		InturnPercent: float64(100*optimals) / float64(numBlocks),
+	status := &Status{
 		SigningStatus: signStatus,
-		NumBlocks:     numBlocks,
-	}, nil
+		NumBlocks:     uint64(len(headers)),
+	}
+	if len(headers) > 0 {
+		status.InturnPercent = float64(100*optimals) / float64(len(headers))
+	}
+	return status, nil
 }
-*/
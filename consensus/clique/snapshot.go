@@ -28,12 +28,14 @@ import (
 	"github.com/hashicorp/golang-lru/v2"
 	"github.com/ledgerwatch/erigon-lib/chain"
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/length"
 	"github.com/ledgerwatch/erigon-lib/kv"
 
 	"github.com/ledgerwatch/log/v3"
 
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/dbutils"
+	"github.com/ledgerwatch/erigon/consensus"
 	"github.com/ledgerwatch/erigon/core/types"
 )
 
@@ -113,6 +115,64 @@ func loadSnapshot(config *chain.CliqueConfig, db kv.RwDB, num uint64, hash libco
 	return snap, nil
 }
 
+// LoadSnapshot reconstructs the voting snapshot at the given block without
+// writing to the database, so it can be used by readers of the consensus
+// database such as rpcdaemon: the nearest snapshot stored in tx is loaded and
+// the headers after it, read with getHeader, are applied on top.
+func LoadSnapshot(config *chain.CliqueConfig, tx kv.Tx, getHeader func(hash libcommon.Hash, number uint64) (*types.Header, error),
+	number uint64, hash libcommon.Hash) (*Snapshot, error) {
+	conf := *config
+	if conf.Epoch == 0 {
+		conf.Epoch = epochLength
+	}
+
+	var headers []*types.Header
+	var snap *Snapshot
+	for snap == nil {
+		blob, err := tx.GetOne(kv.CliqueSeparate, SnapshotFullKey(number, hash))
+		if err != nil {
+			return nil, err
+		}
+		if blob != nil {
+			snap = new(Snapshot)
+			if err := json.Unmarshal(blob, snap); err != nil {
+				return nil, err
+			}
+			snap.config = &conf
+			break
+		}
+
+		header, err := getHeader(hash, number)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, consensus.ErrUnknownAncestor
+		}
+		// The genesis snapshot is stored by the node when it starts verifying
+		// headers, but it can be derived from the genesis header as well.
+		if number == 0 {
+			signers := make([]libcommon.Address, (len(header.Extra)-ExtraVanity-ExtraSeal)/length.Addr)
+			for i := 0; i < len(signers); i++ {
+				copy(signers[i][:], header.Extra[ExtraVanity+i*length.Addr:])
+			}
+			snap = newSnapshot(&conf, number, hash, signers)
+			break
+		}
+		headers = append(headers, header)
+		number, hash = number-1, header.ParentHash
+	}
+
+	for i := 0; i < len(headers)/2; i++ {
+		headers[i], headers[len(headers)-1-i] = headers[len(headers)-1-i], headers[i]
+	}
+	sigcache, err := lru.NewARC[libcommon.Hash, libcommon.Address](len(headers) + 1)
+	if err != nil {
+		return nil, err
+	}
+	return snap.apply(sigcache, headers...)
+}
+
 var ErrNotFound = errors.New("not found")
 
 func lastSnapshot(db kv.RwDB) (uint64, error) {
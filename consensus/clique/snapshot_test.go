@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/ecdsa"
+	"reflect"
 	"sort"
 	"testing"
 
@@ -30,6 +31,7 @@ import (
 	"github.com/ledgerwatch/erigon-lib/kv/memdb"
 	"github.com/ledgerwatch/erigon/consensus/clique"
 	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/eth/stagedsync"
@@ -542,6 +544,24 @@ func TestClique(t *testing.T) {
 					t.Errorf("test %d, signer %d: signer mismatch: have %x, want %x", i, j, result[j], signers[j])
 				}
 			}
+			// The snapshot reconstructed without the engine, as rpcdaemon does, must agree
+			if err := m.DB.View(context.Background(), func(tx kv.Tx) error {
+				return cliqueDB.View(context.Background(), func(cliqueTx kv.Tx) error {
+					getHeader := func(hash libcommon.Hash, number uint64) (*types.Header, error) {
+						return rawdb.ReadHeader(tx, hash, number), nil
+					}
+					loaded, err := clique.LoadSnapshot(config.Clique, cliqueTx, getHeader, head.NumberU64(), head.Hash())
+					if err != nil {
+						return err
+					}
+					if !reflect.DeepEqual(loaded.GetSigners(), result) {
+						t.Errorf("test %d: loaded signers mismatch: have %x, want %x", i, loaded.GetSigners(), result)
+					}
+					return nil
+				})
+			}); err != nil {
+				t.Errorf("test %d: failed to load voting snapshot %d(%s): %v", i, head.NumberU64(), head.Hash().Hex(), err)
+			}
 			engine.Close()
 		})
 	}
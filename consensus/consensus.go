@@ -101,8 +101,9 @@ type EngineWriter interface {
 	Prepare(chain ChainHeaderReader, header *types.Header, state *state.IntraBlockState) error
 
 	// Initialize runs any pre-transaction state modifications (e.g. epoch start)
+	// and any checks of the header which need the state of its parent.
 	Initialize(config *chain.Config, chain ChainHeaderReader, header *types.Header,
-		state *state.IntraBlockState, txs []types.Transaction, uncles []*types.Header, syscall SystemCall)
+		state *state.IntraBlockState, txs []types.Transaction, uncles []*types.Header, syscall SystemCall) error
 
 	// Finalize runs any post-transaction state modifications (e.g. block rewards)
 	// but does not assemble the block.
@@ -126,7 +127,9 @@ type EngineWriter interface {
 	//
 	// Note, the method returns immediately and will send the result async. More
 	// than one result may also be returned depending on the consensus algorithm.
-	Seal(chain ChainHeaderReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error
+	// Contracts are called on the state of the parent block with call, which is
+	// only used before the method returns.
+	Seal(chain ChainHeaderReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}, call Call) error
 
 	// SealHash returns the hash of a block prior to it being sealed.
 	SealHash(header *types.Header) libcommon.Hash
@@ -144,6 +147,14 @@ type EngineWriter interface {
 	Close() error
 }
 
+// EngineTransactor is implemented by engines which include transactions of their
+// own, such as reports of validator misbehaviour, into the blocks this node produces.
+type EngineTransactor interface {
+	// GenerateEngineTransactions returns the signed transactions to be applied at
+	// the start of the block being produced, on top of the given state.
+	GenerateEngineTransactions(config *chain.Config, header *types.Header, state *state.IntraBlockState, syscall SystemCall) (types.Transactions, error)
+}
+
 // PoW is a consensus engine based on proof-of-work.
 type PoW interface {
 	Engine
@@ -41,7 +41,7 @@ func TestRemoteSealer(t *testing.T) {
 
 	// Push new work.
 	results := make(chan *types.Block)
-	if err := ethash.Seal(nil, block, results, nil); err != nil {
+	if err := ethash.Seal(nil, block, results, nil, nil); err != nil {
 		t.Fatal(err)
 	}
 	var (
@@ -59,7 +59,7 @@ func TestRemoteSealer(t *testing.T) {
 	header = &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1000)}
 	block = types.NewBlockWithHeader(header)
 	sealhash = ethash.SealHash(header)
-	err = ethash.Seal(nil, block, results, nil)
+	err = ethash.Seal(nil, block, results, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
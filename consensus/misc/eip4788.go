@@ -0,0 +1,20 @@
+package misc
+
+import (
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/consensus"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/params"
+)
+
+// ApplyBeaconRootEip4788 stores the root of the parent beacon block in the
+// beacon roots contract by calling it from the system address, as specified
+// in EIP-4788. The call is a no-op if the contract is not deployed.
+func ApplyBeaconRootEip4788(parentBeaconBlockRoot *libcommon.Hash, ibs *state.IntraBlockState, syscall consensus.SystemCall) error {
+	// The system call is not a transaction, so the contract has to be added
+	// to the access list by hand for its storage to be accessible
+	ibs.AddAddressToAccessList(params.BeaconRootsAddress)
+	_, err := syscall(params.BeaconRootsAddress, parentBeaconBlockRoot.Bytes())
+	return err
+}
@@ -146,6 +146,9 @@ func (s *Serenity) Finalize(config *chain.Config, header *types.Header, state *s
 		}
 	}
 	if config.IsCancun(header.Time) {
+		if blobs := misc.CountBlobs(txs); blobs > params.MaxBlobsPerBlock {
+			return nil, nil, fmt.Errorf("block %v has too many blobs: %d, max %d", header.Number.Uint64(), blobs, params.MaxBlobsPerBlock)
+		}
 		parent := chain.GetHeaderByHash(header.ParentHash)
 		if parent == nil {
 			return nil, nil, fmt.Errorf("Could not find the parent of block %v to get excess data gas", header.Number.Uint64())
@@ -169,6 +172,16 @@ func (s *Serenity) FinalizeAndAssemble(config *chain.Config, header *types.Heade
 	return types.NewBlock(header, outTxs, uncles, outReceipts, withdrawals), outTxs, outReceipts, nil
 }
 
+// GenerateEngineTransactions implements consensus.EngineTransactor for the blocks
+// of the eth1 engine.
+func (s *Serenity) GenerateEngineTransactions(config *chain.Config, header *types.Header, state *state.IntraBlockState, syscall consensus.SystemCall) (types.Transactions, error) {
+	transactor, ok := s.eth1Engine.(consensus.EngineTransactor)
+	if !ok || IsPoSHeader(header) {
+		return nil, nil
+	}
+	return transactor.GenerateEngineTransactions(config, header, state, syscall)
+}
+
 func (s *Serenity) SealHash(header *types.Header) (hash libcommon.Hash) {
 	return s.eth1Engine.SealHash(header)
 }
@@ -243,12 +256,21 @@ func (s *Serenity) verifyHeader(chain consensus.ChainHeaderReader, header, paren
 		// Verify the header's EIP-4844 attributes.
 		return err
 	}
+
+	// Verify existence / non-existence of parentBeaconBlockRoot
+	cancun := chain.Config().IsCancun(header.Time)
+	if cancun && header.ParentBeaconBlockRoot == nil {
+		return fmt.Errorf("missing parentBeaconBlockRoot")
+	}
+	if !cancun && header.ParentBeaconBlockRoot != nil {
+		return fmt.Errorf("invalid parentBeaconBlockRoot before fork: have %x, expected 'nil'", *header.ParentBeaconBlockRoot)
+	}
 	return nil
 }
 
-func (s *Serenity) Seal(chain consensus.ChainHeaderReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error {
+func (s *Serenity) Seal(chain consensus.ChainHeaderReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}, call consensus.Call) error {
 	if !IsPoSHeader(block.Header()) {
-		return s.eth1Engine.Seal(chain, block, results, stop)
+		return s.eth1Engine.Seal(chain, block, results, stop, call)
 	}
 	return nil
 }
@@ -261,8 +283,8 @@ func (s *Serenity) IsServiceTransaction(sender libcommon.Address, syscall consen
 	return s.eth1Engine.IsServiceTransaction(sender, syscall)
 }
 
-func (s *Serenity) Initialize(config *chain.Config, chain consensus.ChainHeaderReader, header *types.Header, state *state.IntraBlockState, txs []types.Transaction, uncles []*types.Header, syscall consensus.SystemCall) {
-	s.eth1Engine.Initialize(config, chain, header, state, txs, uncles, syscall)
+func (s *Serenity) Initialize(config *chain.Config, chain consensus.ChainHeaderReader, header *types.Header, state *state.IntraBlockState, txs []types.Transaction, uncles []*types.Header, syscall consensus.SystemCall) error {
+	return s.eth1Engine.Initialize(config, chain, header, state, txs, uncles, syscall)
 }
 
 func (s *Serenity) APIs(chain consensus.ChainHeaderReader) []rpc.API {
@@ -14,5 +14,6 @@ type BlockBuilderParameters struct {
 	PrevRandao            libcommon.Hash
 	SuggestedFeeRecipient libcommon.Address
 	Withdrawals           []*types.Withdrawal
+	ParentBeaconBlockRoot *libcommon.Hash
 	PayloadId             uint64
 }
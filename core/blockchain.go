@@ -456,9 +456,17 @@ func FinalizeBlockExecution(
 }
 
 func InitializeBlockExecution(engine consensus.Engine, chain consensus.ChainHeaderReader, header *types.Header, txs types.Transactions, uncles []*types.Header, cc *chain.Config, ibs *state.IntraBlockState, excessDataGas *big.Int) error {
-	engine.Initialize(cc, chain, header, ibs, txs, uncles, func(contract libcommon.Address, data []byte) ([]byte, error) {
+	syscall := func(contract libcommon.Address, data []byte) ([]byte, error) {
 		return SysCallContract(contract, data, *cc, ibs, header, engine, false /* constCall */, excessDataGas)
-	})
+	}
+	if err := engine.Initialize(cc, chain, header, ibs, txs, uncles, syscall); err != nil {
+		return err
+	}
+	if cc.IsCancun(header.Time) && header.ParentBeaconBlockRoot != nil {
+		if err := misc.ApplyBeaconRootEip4788(header.ParentBeaconBlockRoot, ibs, syscall); err != nil {
+			return fmt.Errorf("store parent beacon block root: %w", err)
+		}
+	}
 	noop := state.NewNoopWriter()
 	ibs.FinalizeTx(cc.Rules(header.Number.Uint64(), header.Time), noop)
 	return nil
@@ -0,0 +1,212 @@
+package core_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	. "github.com/protolambda/ztyp/view"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/consensus/ethash"
+	"github.com/ledgerwatch/erigon/consensus/misc"
+	"github.com/ledgerwatch/erigon/consensus/serenity"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/core/vm/evmtypes"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/params"
+)
+
+func TestBeaconRootContract(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	ibs := state.New(state.NewPlainStateReader(tx))
+	ibs.SetCode(params.BeaconRootsAddress, params.BeaconRootsCode)
+
+	config := *params.TestChainConfig
+	config.TerminalTotalDifficulty = big.NewInt(0)
+	config.ShanghaiTime = big.NewInt(0)
+	config.CancunTime = big.NewInt(0)
+
+	const timestamp = 100_000
+	root := libcommon.HexToHash("0x88e96d4537bea4d9c05d12549907b32561d3bf31f45aae734cdc119f13406cb6")
+	header := &types.Header{
+		Number:                big.NewInt(1),
+		Difficulty:            serenity.SerenityDifficulty,
+		Time:                  timestamp,
+		GasLimit:              30_000_000,
+		BaseFee:               big.NewInt(params.InitialBaseFee),
+		ParentBeaconBlockRoot: &root,
+	}
+	engine := serenity.New(ethash.NewFaker())
+	require.NoError(t, core.InitializeBlockExecution(engine, nil, header, nil, nil, &config, ibs, nil))
+
+	// The timestamp and the root are stored in the ring buffers of the contract
+	var value uint256.Int
+	key := libcommon.BigToHash(big.NewInt(timestamp % 8191))
+	ibs.GetState(params.BeaconRootsAddress, &key, &value)
+	require.Equal(t, uint64(timestamp), value.Uint64())
+	key = libcommon.BigToHash(big.NewInt(timestamp%8191 + 8191))
+	ibs.GetState(params.BeaconRootsAddress, &key, &value)
+	require.Equal(t, root, libcommon.Hash(value.Bytes32()))
+
+	// Anyone else can read the root back by its timestamp
+	caller := libcommon.HexToAddress("0x1234")
+	blockContext := core.NewEVMBlockContext(header, core.GetHashFn(header, nil), engine, &caller, nil)
+	evm := vm.NewEVM(blockContext, evmtypes.TxContext{}, ibs, &config, vm.Config{})
+	ret, _, err := evm.StaticCall(vm.AccountRef(caller), params.BeaconRootsAddress, common.LeftPadBytes(big.NewInt(timestamp).Bytes(), 32), 100_000)
+	require.NoError(t, err)
+	require.Equal(t, root.Bytes(), ret)
+
+	_, _, err = evm.StaticCall(vm.AccountRef(caller), params.BeaconRootsAddress, common.LeftPadBytes(big.NewInt(timestamp+1).Bytes(), 32), 100_000)
+	require.ErrorIs(t, err, vm.ErrExecutionReverted)
+
+	// Without a parent beacon block root nothing is stored
+	ibs = state.New(state.NewPlainStateReader(tx))
+	ibs.SetCode(params.BeaconRootsAddress, params.BeaconRootsCode)
+	header.ParentBeaconBlockRoot = nil
+	require.NoError(t, core.InitializeBlockExecution(engine, nil, header, nil, nil, &config, ibs, nil))
+	key = libcommon.BigToHash(big.NewInt(timestamp % 8191))
+	ibs.GetState(params.BeaconRootsAddress, &key, &value)
+	require.True(t, value.IsZero())
+}
+
+func TestBlobGasCharging(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+
+	config := *params.AllProtocolChanges
+	config.CancunTime = big.NewInt(0)
+	signer := types.LatestSignerForChainID(config.ChainID)
+
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	coinbase := libcommon.HexToAddress("0xc014ba5e")
+	// PUSH0 DATAHASH PUSH0 SSTORE: stores the first versioned hash of the tx in slot 0
+	contract := libcommon.HexToAddress("0xda7a")
+	contractAddr := types.AddressSSZ(contract)
+	hashes := []libcommon.Hash{
+		libcommon.HexToHash("0x01c357eb79e30f84b7f1b78e2dc064d5e889714a94a879218ffe6ffea8467682"),
+		libcommon.HexToHash("0x0100000000000000000000000000000000000000000000000000000000000002"),
+	}
+
+	header := &types.Header{
+		Number:     big.NewInt(1),
+		Difficulty: serenity.SerenityDifficulty,
+		Time:       1,
+		GasLimit:   30_000_000,
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+		Coinbase:   coinbase,
+	}
+	// The parent excess makes the data gas price noticeably higher than the minimum
+	parentExcessDataGas := big.NewInt(10 * params.DataGasPriceUpdateFraction)
+	dataGasPrice := misc.GetDataGasPrice(parentExcessDataGas)
+	require.Equal(t, 1, dataGasPrice.Cmp(big.NewInt(params.MinDataGasPrice)))
+
+	apply := func(maxFeePerDataGas *uint256.Int) (*state.IntraBlockState, *core.ExecutionResult, error) {
+		ibs := state.New(state.NewPlainStateReader(tx))
+		ibs.AddBalance(sender, uint256.NewInt(params.Ether))
+		ibs.SetCode(contract, []byte{byte(vm.PUSH0), byte(vm.DATAHASH), byte(vm.PUSH0), byte(vm.SSTORE)})
+
+		var blobTx types.SignedBlobTx
+		blobTx.Message.ChainID = Uint256View(*uint256.MustFromBig(config.ChainID))
+		blobTx.Message.GasTipCap = Uint256View(*uint256.NewInt(params.GWei))
+		blobTx.Message.GasFeeCap = Uint256View(*uint256.NewInt(10 * params.GWei))
+		blobTx.Message.Gas = Uint64View(100_000)
+		blobTx.Message.To = types.AddressOptionalSSZ{Address: &contractAddr}
+		blobTx.Message.AccessList = types.AccessListView{}
+		blobTx.Message.MaxFeePerDataGas = Uint256View(*maxFeePerDataGas)
+		blobTx.Message.BlobVersionedHashes = hashes
+		txn, err := types.SignTx(&blobTx, *signer, key)
+		require.NoError(t, err)
+
+		rules := config.Rules(header.Number.Uint64(), header.Time)
+		msg, err := txn.AsMessage(*signer, header.BaseFee, rules)
+		require.NoError(t, err)
+		require.Equal(t, sender, msg.From())
+
+		engine := serenity.New(ethash.NewFaker())
+		blockContext := core.NewEVMBlockContext(header, core.GetHashFn(header, nil), engine, nil, parentExcessDataGas)
+		evm := vm.NewEVM(blockContext, core.NewEVMTxContext(msg), ibs, &config, vm.Config{})
+		result, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(header.GasLimit), true /* refunds */, false /* gasBailout */)
+		return ibs, result, err
+	}
+
+	maxFee, _ := uint256.FromBig(dataGasPrice)
+	ibs, result, err := apply(maxFee)
+	require.NoError(t, err)
+	require.NoError(t, result.Err)
+
+	// The sender pays for the execution gas and, on top of it, for the data gas of both blobs
+	gasCost := new(uint256.Int).Mul(uint256.NewInt(result.UsedGas), uint256.NewInt(params.InitialBaseFee+params.GWei))
+	dataGasCost := new(uint256.Int).Mul(uint256.NewInt(misc.GetDataGasUsed(len(hashes))), maxFee)
+	require.Equal(t, uint64(2*params.DataGasPerBlob), misc.GetDataGasUsed(len(hashes)))
+	expected := new(uint256.Int).Sub(uint256.NewInt(params.Ether), gasCost)
+	expected.Sub(expected, dataGasCost)
+	require.Equal(t, expected, ibs.GetBalance(sender))
+	// The data fee is burnt, the block producer only receives the tip
+	require.Equal(t, new(uint256.Int).Mul(uint256.NewInt(result.UsedGas), uint256.NewInt(params.GWei)), ibs.GetBalance(coinbase))
+
+	// DATAHASH sees the versioned hashes of the transaction
+	var value uint256.Int
+	var slot libcommon.Hash
+	ibs.GetState(contract, &slot, &value)
+	require.Equal(t, hashes[0], libcommon.Hash(value.Bytes32()))
+
+	// A transaction that does not cover the data gas price of the block is rejected
+	_, _, err = apply(new(uint256.Int).Sub(maxFee, uint256.NewInt(1)))
+	require.ErrorIs(t, err, core.ErrMaxFeePerDataGas)
+}
+
+func TestPointEvaluationPrecompileActivation(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	pointEvaluation := libcommon.BytesToAddress([]byte{0x0a})
+
+	config := *params.TestChainConfig
+	config.TerminalTotalDifficulty = big.NewInt(0)
+	config.ShanghaiTime = big.NewInt(0)
+	config.CancunTime = big.NewInt(1000)
+
+	caller := libcommon.HexToAddress("0x1234")
+	for _, tt := range []struct {
+		time   uint64
+		active bool
+	}{{time: 999, active: false}, {time: 1000, active: true}} {
+		header := &types.Header{
+			Number:     big.NewInt(1),
+			Difficulty: serenity.SerenityDifficulty,
+			Time:       tt.time,
+			GasLimit:   30_000_000,
+			BaseFee:    big.NewInt(params.InitialBaseFee),
+		}
+		rules := config.Rules(header.Number.Uint64(), header.Time)
+		require.Equal(t, tt.active, containsAddress(vm.ActivePrecompiles(rules), pointEvaluation))
+
+		ibs := state.New(state.NewPlainStateReader(tx))
+		blockContext := core.NewEVMBlockContext(header, core.GetHashFn(header, nil), nil, &caller, nil)
+		evm := vm.NewEVM(blockContext, evmtypes.TxContext{}, ibs, &config, vm.Config{})
+		// Malformed input fails in the precompile and consumes all the gas, before Cancun
+		// the address is an empty account and the call succeeds.
+		_, leftOver, err := evm.StaticCall(vm.AccountRef(caller), pointEvaluation, []byte{0x01}, 100_000)
+		if tt.active {
+			require.Error(t, err)
+			require.Zero(t, leftOver)
+		} else {
+			require.NoError(t, err)
+			require.Equal(t, uint64(100_000), leftOver)
+		}
+	}
+}
+
+func containsAddress(addrs []libcommon.Address, addr libcommon.Address) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
@@ -96,6 +96,14 @@ var (
 	// the base fee of the block.
 	ErrFeeCapTooLow = errors.New("fee cap less than block base fee")
 
+	// ErrMaxFeePerDataGas is returned if the transaction's max fee per data gas
+	// is less than the data gas price of the block.
+	ErrMaxFeePerDataGas = errors.New("max fee per data gas less than block data gas price")
+
+	// ErrTooManyBlobs is returned if a transaction or a block carries more blobs
+	// than fit into a single block.
+	ErrTooManyBlobs = errors.New("too many blobs")
+
 	// ErrSenderNoEOA is returned if the sender of a transaction is a contract.
 	// See EIP-3607: Reject transactions from senders with deployed code.
 	ErrSenderNoEOA = errors.New("sender not an eoa")
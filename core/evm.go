@@ -85,8 +85,9 @@ func NewEVMBlockContext(header *types.Header, blockHashFunc func(n uint64) libco
 // NewEVMTxContext creates a new transaction context for a single transaction.
 func NewEVMTxContext(msg Message) evmtypes.TxContext {
 	return evmtypes.TxContext{
-		Origin:   msg.From(),
-		GasPrice: msg.GasPrice(),
+		Origin:     msg.From(),
+		GasPrice:   msg.GasPrice(),
+		DataHashes: msg.DataHashes(),
 	}
 }
 
@@ -1,7 +1,9 @@
 package core_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"math/big"
 	"testing"
 
@@ -10,6 +12,7 @@ import (
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/memdb"
 	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -30,7 +33,7 @@ func TestGenesisBlockHashes(t *testing.T) {
 			t.Fatal(err)
 		}
 		defer tx.Rollback()
-		_, block, err := core.WriteGenesisBlock(tx, genesis, nil, "")
+		_, block, err := core.WriteGenesisBlock(tx, genesis, nil, nil, "")
 		require.NoError(t, err)
 		expect := params.GenesisHashByChainName(network)
 		require.NotNil(t, expect, network)
@@ -72,13 +75,13 @@ func TestGenesisBlockRoots(t *testing.T) {
 func TestCommitGenesisIdempotency(t *testing.T) {
 	_, tx := memdb.NewTestTx(t)
 	genesis := core.GenesisBlockByChainName(networkname.MainnetChainName)
-	_, _, err := core.WriteGenesisBlock(tx, genesis, nil, "")
+	_, _, err := core.WriteGenesisBlock(tx, genesis, nil, nil, "")
 	require.NoError(t, err)
 	seq, err := tx.ReadSequence(kv.EthTx)
 	require.NoError(t, err)
 	require.Equal(t, uint64(2), seq)
 
-	_, _, err = core.WriteGenesisBlock(tx, genesis, nil, "")
+	_, _, err = core.WriteGenesisBlock(tx, genesis, nil, nil, "")
 	require.NoError(t, err)
 	seq, err = tx.ReadSequence(kv.EthTx)
 	require.NoError(t, err)
@@ -128,3 +131,61 @@ func TestAllocConstructor(t *testing.T) {
 	state.GetState(address, &key1, storage1)
 	assert.Equal(uint256.NewInt(0x01c9), storage1)
 }
+
+func TestCommitGenesisBlockWithAlloc(t *testing.T) {
+	require := require.New(t)
+
+	genesis := core.MainnetGenesisBlock()
+	genesis.Alloc[libcommon.HexToAddress("0x1000000000000000000000000000000000000001")] = types.GenesisAccount{
+		Balance: big.NewInt(1),
+		Code:    common.FromHex("5f355f55"),
+		Storage: map[libcommon.Hash]libcommon.Hash{
+			libcommon.HexToHash("0x01"): libcommon.HexToHash("0x2a"),
+			libcommon.HexToHash("0x02"): {},
+		},
+	}
+	genesis.Alloc[libcommon.HexToAddress("0x1000000000000000000000000000000000000002")] = types.GenesisAccount{
+		Balance: big.NewInt(0),
+		Storage: map[libcommon.Hash]libcommon.Hash{libcommon.HexToHash("0x01"): libcommon.HexToHash("0x01c9")},
+	}
+	enc, err := json.Marshal(genesis.Alloc)
+	require.NoError(err)
+	alloc, err := state.NewAllocReader(bytes.NewReader(enc))
+	require.NoError(err)
+
+	streamed := memdb.NewTestDB(t)
+	block, err := core.CommitGenesisBlockWithAlloc(streamed, &types.Genesis{Config: genesis.Config, Nonce: genesis.Nonce, ExtraData: genesis.ExtraData, GasLimit: genesis.GasLimit, Difficulty: genesis.Difficulty}, alloc)
+	require.NoError(err)
+	_, err = core.CommitGenesisBlockWithAlloc(streamed, genesis, alloc)
+	require.Error(err)
+
+	db := memdb.NewTestDB(t)
+	_, expected, err := core.CommitGenesisBlock(db, genesis, "")
+	require.NoError(err)
+	require.Equal(expected.Hash(), block.Hash())
+
+	tx, err := db.BeginRo(context.Background())
+	require.NoError(err)
+	defer tx.Rollback()
+	streamedTx, err := streamed.BeginRo(context.Background())
+	require.NoError(err)
+	defer streamedTx.Rollback()
+	for _, table := range []string{kv.PlainState, kv.PlainContractCode, kv.Code, kv.IncarnationMap, kv.HashedAccounts, kv.HashedStorage} {
+		require.Equal(dumpTable(t, tx, table), dumpTable(t, streamedTx, table), table)
+	}
+	issued, err := rawdb.ReadTotalIssued(tx, 0)
+	require.NoError(err)
+	streamedIssued, err := rawdb.ReadTotalIssued(streamedTx, 0)
+	require.NoError(err)
+	require.Equal(issued, streamedIssued)
+}
+
+func dumpTable(t *testing.T, tx kv.Tx, table string) map[string]string {
+	t.Helper()
+	m := map[string]string{}
+	require.NoError(t, tx.ForEach(table, nil, func(k, v []byte) error {
+		m[string(k)] = string(v)
+		return nil
+	}))
+	return m
+}
@@ -21,7 +21,9 @@ import (
 	"embed"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"math/big"
 	"sync"
 
@@ -33,12 +35,14 @@ import (
 	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
 	"github.com/ledgerwatch/erigon-lib/kv/rawdbv3"
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/dbutils"
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/consensus/ethash"
 	"github.com/ledgerwatch/erigon/consensus/serenity"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
 	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	"github.com/ledgerwatch/erigon/params"
@@ -62,16 +66,16 @@ import (
 //
 // The returned chain configuration is never nil.
 func CommitGenesisBlock(db kv.RwDB, genesis *types.Genesis, tmpDir string) (*chain.Config, *types.Block, error) {
-	return CommitGenesisBlockWithOverride(db, genesis, nil, tmpDir)
+	return CommitGenesisBlockWithOverride(db, genesis, nil, nil, tmpDir)
 }
 
-func CommitGenesisBlockWithOverride(db kv.RwDB, genesis *types.Genesis, overrideShanghaiTime *big.Int, tmpDir string) (*chain.Config, *types.Block, error) {
+func CommitGenesisBlockWithOverride(db kv.RwDB, genesis *types.Genesis, overrideShanghaiTime, overrideCancunTime *big.Int, tmpDir string) (*chain.Config, *types.Block, error) {
 	tx, err := db.BeginRw(context.Background())
 	if err != nil {
 		return nil, nil, err
 	}
 	defer tx.Rollback()
-	c, b, err := WriteGenesisBlock(tx, genesis, overrideShanghaiTime, tmpDir)
+	c, b, err := WriteGenesisBlock(tx, genesis, overrideShanghaiTime, overrideCancunTime, tmpDir)
 	if err != nil {
 		return c, b, err
 	}
@@ -82,7 +86,7 @@ func CommitGenesisBlockWithOverride(db kv.RwDB, genesis *types.Genesis, override
 	return c, b, nil
 }
 
-func WriteGenesisBlock(tx kv.RwTx, genesis *types.Genesis, overrideShanghaiTime *big.Int, tmpDir string) (*chain.Config, *types.Block, error) {
+func WriteGenesisBlock(tx kv.RwTx, genesis *types.Genesis, overrideShanghaiTime, overrideCancunTime *big.Int, tmpDir string) (*chain.Config, *types.Block, error) {
 	if genesis != nil && genesis.Config == nil {
 		return params.AllProtocolChanges, nil, types.ErrGenesisNoConfig
 	}
@@ -96,6 +100,9 @@ func WriteGenesisBlock(tx kv.RwTx, genesis *types.Genesis, overrideShanghaiTime
 		if overrideShanghaiTime != nil {
 			config.ShanghaiTime = overrideShanghaiTime
 		}
+		if overrideCancunTime != nil {
+			config.CancunTime = overrideCancunTime
+		}
 	}
 
 	if (storedHash == libcommon.Hash{}) {
@@ -237,46 +244,55 @@ func write(tx kv.RwTx, g *types.Genesis, tmpDir string) (*types.Block, *state.In
 	if err2 != nil {
 		return block, statedb, err2
 	}
+	// Issuance is the sum of allocs
+	allocBalance := big.NewInt(0)
+	for _, account := range g.Alloc {
+		allocBalance.Add(allocBalance, account.Balance)
+	}
+	if err := writeGenesisBlock(tx, g, block, allocBalance); err != nil {
+		return nil, nil, err
+	}
+	return block, statedb, nil
+}
+
+// writeGenesisBlock writes the genesis block, whose state is already written, as the canonical head block.
+func writeGenesisBlock(tx kv.RwTx, g *types.Genesis, block *types.Block, allocBalance *big.Int) error {
 	config := g.Config
 	if config == nil {
 		config = params.AllProtocolChanges
 	}
 	if err := config.CheckConfigForkOrder(); err != nil {
-		return nil, nil, err
+		return err
 	}
 	if err := rawdb.WriteTd(tx, block.Hash(), block.NumberU64(), g.Difficulty); err != nil {
-		return nil, nil, err
+		return err
 	}
 	if err := rawdb.WriteBlock(tx, block); err != nil {
-		return nil, nil, err
+		return err
 	}
 	if err := rawdbv3.TxNums.WriteForGenesis(tx, 1); err != nil {
-		return nil, nil, err
+		return err
 	}
 	if err := rawdb.WriteReceipts(tx, block.NumberU64(), nil); err != nil {
-		return nil, nil, err
+		return err
 	}
 
 	if err := rawdb.WriteCanonicalHash(tx, block.Hash(), block.NumberU64()); err != nil {
-		return nil, nil, err
+		return err
 	}
 
 	rawdb.WriteHeadBlockHash(tx, block.Hash())
 	if err := rawdb.WriteHeadHeaderHash(tx, block.Hash()); err != nil {
-		return nil, nil, err
+		return err
 	}
 	if err := rawdb.WriteChainConfig(tx, block.Hash(), config); err != nil {
-		return nil, nil, err
+		return err
 	}
 	// We support ethash/serenity for issuance (for now)
 	if g.Config.Consensus != chain.EtHashConsensus {
-		return block, statedb, nil
-	}
-	// Issuance is the sum of allocs
-	genesisIssuance := big.NewInt(0)
-	for _, account := range g.Alloc {
-		genesisIssuance.Add(genesisIssuance, account.Balance)
+		return nil
 	}
+	genesisIssuance := new(big.Int).Set(allocBalance)
 
 	// BlockReward can be present at genesis
 	if block.Header().Difficulty.Cmp(serenity.SerenityDifficulty) == 0 {
@@ -288,9 +304,128 @@ func write(tx kv.RwTx, g *types.Genesis, tmpDir string) (*types.Block, *state.In
 		genesisIssuance.Add(genesisIssuance, blockReward.ToBig())
 	}
 	if err := rawdb.WriteTotalIssued(tx, 0, genesisIssuance); err != nil {
-		return nil, nil, err
+		return err
+	}
+	return rawdb.WriteTotalBurnt(tx, 0, libcommon.Big0)
+}
+
+// GenesisAllocIterator yields the accounts of a genesis alloc one by one, Next returns io.EOF after the last one.
+type GenesisAllocIterator interface {
+	Next() (libcommon.Address, *types.GenesisAccount, error)
+}
+
+// CommitGenesisBlockWithAlloc writes the genesis block of a new database, taking its accounts from alloc instead
+// of genesis.Alloc. The accounts are written as they are read, so that an alloc of any size can be used. Unlike
+// CommitGenesisBlock it fails if a genesis block is already stored, constructors are not supported and no change
+// sets are written for the genesis accounts.
+func CommitGenesisBlockWithAlloc(db kv.RwDB, genesis *types.Genesis, alloc GenesisAllocIterator) (*types.Block, error) {
+	if genesis.Config == nil {
+		return nil, types.ErrGenesisNoConfig
+	}
+	tx, err := db.BeginRw(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	storedHash, err := rawdb.ReadCanonicalHash(tx, 0)
+	if err != nil {
+		return nil, err
+	}
+	if (storedHash != libcommon.Hash{}) {
+		return nil, fmt.Errorf("genesis block %x is already written", storedHash)
+	}
+	root, allocBalance, err := writeGenesisAlloc(tx, alloc)
+	if err != nil {
+		return nil, err
 	}
-	return block, statedb, rawdb.WriteTotalBurnt(tx, 0, libcommon.Big0)
+	head, withdrawals := genesisHeader(genesis)
+	if head.Number.Sign() != 0 {
+		return nil, fmt.Errorf("can't commit genesis block with number > 0")
+	}
+	head.Root = root
+	block := types.NewBlock(head, nil, nil, nil, withdrawals)
+	if err := writeGenesisBlock(tx, genesis, block, allocBalance); err != nil {
+		return nil, err
+	}
+	return block, tx.Commit()
+}
+
+// writeGenesisAlloc writes the plain state of the accounts and returns the state root and their total balance.
+// The root is calculated from a hashed state written next to the plain one, which is removed afterwards: like
+// after WriteGenesisState, the stages build the hashed state themselves.
+func writeGenesisAlloc(tx kv.RwTx, alloc GenesisAllocIterator) (libcommon.Hash, *big.Int, error) {
+	w := state.NewPlainStateWriterNoHistory(tx)
+	allocBalance := big.NewInt(0)
+	for {
+		addr, account, err := alloc.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return libcommon.Hash{}, nil, err
+		}
+		if len(account.Constructor) > 0 {
+			return libcommon.Hash{}, nil, fmt.Errorf("constructor of %x is not supported in a streamed alloc", addr)
+		}
+		acc := accounts.NewAccount()
+		acc.Nonce = account.Nonce
+		if account.Balance != nil {
+			balance, overflow := uint256.FromBig(account.Balance)
+			if overflow {
+				return libcommon.Hash{}, nil, fmt.Errorf("balance of %x overflows", addr)
+			}
+			acc.Balance = *balance
+			allocBalance.Add(allocBalance, account.Balance)
+		}
+		if len(account.Code) > 0 || len(account.Storage) > 0 {
+			acc.Incarnation = state.FirstContractIncarnation
+			var b [8]byte
+			binary.BigEndian.PutUint64(b[:], state.FirstContractIncarnation)
+			if err := tx.Put(kv.IncarnationMap, addr[:], b[:]); err != nil {
+				return libcommon.Hash{}, nil, err
+			}
+		}
+		if len(account.Code) > 0 {
+			acc.CodeHash = crypto.Keccak256Hash(account.Code)
+			if err := w.UpdateAccountCode(addr, acc.Incarnation, acc.CodeHash, account.Code); err != nil {
+				return libcommon.Hash{}, nil, err
+			}
+		}
+		if err := w.UpdateAccountData(addr, &accounts.Account{}, &acc); err != nil {
+			return libcommon.Hash{}, nil, err
+		}
+		addrHash := crypto.Keccak256Hash(addr[:])
+		enc := make([]byte, acc.EncodingLengthForStorage())
+		acc.EncodeForStorage(enc)
+		if err := tx.Put(kv.HashedAccounts, addrHash[:], enc); err != nil {
+			return libcommon.Hash{}, nil, err
+		}
+		for key, value := range account.Storage {
+			key := key
+			val := uint256.NewInt(0).SetBytes(value.Bytes())
+			if val.IsZero() {
+				continue
+			}
+			if err := w.WriteAccountStorage(addr, acc.Incarnation, &key, uint256.NewInt(0), val); err != nil {
+				return libcommon.Hash{}, nil, err
+			}
+			keyHash := crypto.Keccak256Hash(key[:])
+			if err := tx.Put(kv.HashedStorage, dbutils.GenerateCompositeStorageKey(addrHash, acc.Incarnation, keyHash), val.Bytes()); err != nil {
+				return libcommon.Hash{}, nil, err
+			}
+		}
+	}
+	root, err := trie.CalcRoot("genesis", tx)
+	if err != nil {
+		return libcommon.Hash{}, nil, err
+	}
+	if err := tx.ClearBucket(kv.HashedAccounts); err != nil {
+		return libcommon.Hash{}, nil, err
+	}
+	if err := tx.ClearBucket(kv.HashedStorage); err != nil {
+		return libcommon.Hash{}, nil, err
+	}
+	return root, allocBalance, nil
 }
 
 // GenesisBlockForTesting creates and writes a block in which addr has the given wei balance.
@@ -450,6 +585,26 @@ func DeveloperGenesisBlock(period uint64, faucet libcommon.Address) *types.Genes
 	}
 }
 
+// DeveloperPoSGenesisBlock returns the genesis block of a dev chain which is post-merge from the start,
+// to be driven by an in-process mock consensus client rather than Clique.
+func DeveloperPoSGenesisBlock(faucet libcommon.Address) *types.Genesis {
+	config := *params.AllProtocolChanges
+	config.CancunTime = big.NewInt(0)
+
+	alloc := readPrealloc("allocs/dev.json")
+	if _, ok := alloc[faucet]; !ok {
+		alloc[faucet] = alloc[DevnetEtherbase]
+	}
+	// Cancun is active from genesis, so the beacon block roots have to be stored from the first block.
+	alloc[params.BeaconRootsAddress] = types.GenesisAccount{Code: params.BeaconRootsCode, Nonce: 1, Balance: new(big.Int)}
+	return &types.Genesis{
+		Config:     &config,
+		GasLimit:   11500000,
+		Difficulty: big.NewInt(0),
+		Alloc:      alloc,
+	}
+}
+
 var genesisTmpDB kv.RwDB
 var genesisDBLock sync.Mutex
 
@@ -458,40 +613,7 @@ var genesisDBLock sync.Mutex
 func GenesisToBlock(g *types.Genesis, tmpDir string) (*types.Block, *state.IntraBlockState, error) {
 	_ = g.Alloc //nil-check
 
-	head := &types.Header{
-		Number:        new(big.Int).SetUint64(g.Number),
-		Nonce:         types.EncodeNonce(g.Nonce),
-		Time:          g.Timestamp,
-		ParentHash:    g.ParentHash,
-		Extra:         g.ExtraData,
-		GasLimit:      g.GasLimit,
-		GasUsed:       g.GasUsed,
-		Difficulty:    g.Difficulty,
-		MixDigest:     g.Mixhash,
-		Coinbase:      g.Coinbase,
-		BaseFee:       g.BaseFee,
-		ExcessDataGas: g.ExcessDataGas,
-		AuRaStep:      g.AuRaStep,
-		AuRaSeal:      g.AuRaSeal,
-	}
-	if g.GasLimit == 0 {
-		head.GasLimit = params.GenesisGasLimit
-	}
-	if g.Difficulty == nil {
-		head.Difficulty = params.GenesisDifficulty
-	}
-	if g.Config != nil && (g.Config.IsLondon(0)) {
-		if g.BaseFee != nil {
-			head.BaseFee = g.BaseFee
-		} else {
-			head.BaseFee = new(big.Int).SetUint64(params.InitialBaseFee)
-		}
-	}
-
-	var withdrawals []*types.Withdrawal
-	if g.Config != nil && (g.Config.IsShanghai(g.Timestamp)) {
-		withdrawals = []*types.Withdrawal{}
-	}
+	head, withdrawals := genesisHeader(g)
 
 	var root libcommon.Hash
 	var statedb *state.IntraBlockState
@@ -571,6 +693,52 @@ func GenesisToBlock(g *types.Genesis, tmpDir string) (*types.Block, *state.Intra
 	return types.NewBlock(head, nil, nil, nil, withdrawals), statedb, nil
 }
 
+// genesisHeader creates the header of the genesis block, without the state root, and its withdrawals.
+func genesisHeader(g *types.Genesis) (*types.Header, []*types.Withdrawal) {
+	head := &types.Header{
+		Number:        new(big.Int).SetUint64(g.Number),
+		Nonce:         types.EncodeNonce(g.Nonce),
+		Time:          g.Timestamp,
+		ParentHash:    g.ParentHash,
+		Extra:         g.ExtraData,
+		GasLimit:      g.GasLimit,
+		GasUsed:       g.GasUsed,
+		Difficulty:    g.Difficulty,
+		MixDigest:     g.Mixhash,
+		Coinbase:      g.Coinbase,
+		BaseFee:       g.BaseFee,
+		ExcessDataGas: g.ExcessDataGas,
+		AuRaStep:      g.AuRaStep,
+		AuRaSeal:      g.AuRaSeal,
+	}
+	if g.GasLimit == 0 {
+		head.GasLimit = params.GenesisGasLimit
+	}
+	if g.Difficulty == nil {
+		head.Difficulty = params.GenesisDifficulty
+	}
+	if g.Config != nil && (g.Config.IsLondon(0)) {
+		if g.BaseFee != nil {
+			head.BaseFee = g.BaseFee
+		} else {
+			head.BaseFee = new(big.Int).SetUint64(params.InitialBaseFee)
+		}
+	}
+
+	var withdrawals []*types.Withdrawal
+	if g.Config != nil && (g.Config.IsShanghai(g.Timestamp)) {
+		withdrawals = []*types.Withdrawal{}
+	}
+
+	if g.Config != nil && g.Config.IsCancun(g.Timestamp) {
+		if head.ExcessDataGas == nil {
+			head.ExcessDataGas = new(big.Int)
+		}
+		head.ParentBeaconBlockRoot = new(libcommon.Hash)
+	}
+	return head, withdrawals
+}
+
 func sortedAllocKeys(m types.GenesisAlloc) []string {
 	keys := make([]string, len(m))
 	i := 0
@@ -40,6 +40,9 @@ type Dumper struct {
 	db          kv.Tx
 	hashedState bool
 	historyV3   bool
+	// storageAtBlockEnd makes the storage be read as of the end of the block, like the accounts are, instead of
+	// as of its beginning. Alloc dumps need it to be consistent, debug_accountRange keeps the old behaviour.
+	storageAtBlockEnd bool
 }
 
 // DumpAccount represents an account in the state.
@@ -149,16 +152,19 @@ func (d *Dumper) DumpToCollector(c DumpCollector, excludeCode, excludeStorage bo
 	var acc accounts.Account
 	numberOfResults := 0
 
+	storageBlock := d.blockNumber
+	if d.storageAtBlockEnd {
+		storageBlock++
+	}
 	var txNum, txNumForStorage uint64
 	if d.historyV3 {
 		ttx := d.db.(kv.TemporalTx)
 		var err error
-		// Why only account does +1?
 		txNum, err = rawdbv3.TxNums.Min(ttx, d.blockNumber+1)
 		if err != nil {
 			return nil, err
 		}
-		txNumForStorage, err = rawdbv3.TxNums.Min(ttx, d.blockNumber)
+		txNumForStorage, err = rawdbv3.TxNums.Min(ttx, storageBlock)
 		if err != nil {
 			return nil, err
 		}
@@ -283,7 +289,7 @@ func (d *Dumper) DumpToCollector(c DumpCollector, excludeCode, excludeStorage bo
 					addr,
 					incarnation,
 					libcommon.Hash{}, /* startLocation */
-					d.blockNumber,
+					storageBlock,
 					func(_, loc, vs []byte) (bool, error) {
 						account.Storage[libcommon.BytesToHash(loc).String()] = common.Bytes2Hex(vs)
 						h, _ := common.HashData(loc)
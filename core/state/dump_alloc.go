@@ -0,0 +1,258 @@
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+// AllocFormat is the encoding of a state dump produced by AllocDump.
+type AllocFormat string
+
+const (
+	// AllocJSON is the format of the "alloc" field of genesis.json
+	AllocJSON AllocFormat = "json"
+	// AllocBinary is a stream of RLP encoded accounts, more compact and quicker to load than JSON
+	AllocBinary AllocFormat = "binary"
+)
+
+// allocBinaryMagic starts every dump in the AllocBinary format, it is also how ReadAlloc tells the formats apart
+var allocBinaryMagic = []byte("erigon-alloc/1\n")
+
+// allocDumpBatch is the number of accounts read at once by DumpAlloc
+const allocDumpBatch = 1024
+
+type allocAccount struct {
+	Address libcommon.Address
+	Nonce   uint64
+	Balance *big.Int
+	Code    []byte
+	Storage []allocStorage
+}
+
+type allocStorage struct {
+	Key   libcommon.Hash
+	Value []byte // without leading zeroes
+}
+
+// AllocDump is a DumpCollector which streams the accounts into a genesis alloc. Close has to be called
+// once all the accounts are collected, it reports the first error encountered while writing.
+type AllocDump struct {
+	w      *bufio.Writer
+	format AllocFormat
+	count  int
+	err    error
+}
+
+func NewAllocDump(w io.Writer, format AllocFormat) (*AllocDump, error) {
+	d := &AllocDump{w: bufio.NewWriter(w), format: format}
+	switch format {
+	case AllocJSON:
+		_, d.err = d.w.WriteString("{")
+	case AllocBinary:
+		_, d.err = d.w.Write(allocBinaryMagic)
+	default:
+		return nil, fmt.Errorf("unknown state dump format %q", format)
+	}
+	return d, nil
+}
+
+// Count returns the number of accounts collected so far.
+func (d *AllocDump) Count() int {
+	return d.count
+}
+
+// OnRoot implements DumpCollector interface
+func (d *AllocDump) OnRoot(libcommon.Hash) {}
+
+// OnAccount implements DumpCollector interface
+func (d *AllocDump) OnAccount(addr libcommon.Address, account DumpAccount) {
+	if d.err != nil {
+		return
+	}
+	balance, ok := new(big.Int).SetString(account.Balance, 10)
+	if !ok {
+		d.err = fmt.Errorf("invalid balance %q of %x", account.Balance, addr)
+		return
+	}
+	keys := make([]string, 0, len(account.Storage))
+	for k := range account.Storage {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	switch d.format {
+	case AllocJSON:
+		acc := types.GenesisAccount{Balance: balance, Nonce: account.Nonce, Code: account.Code}
+		if len(keys) > 0 {
+			acc.Storage = make(map[libcommon.Hash]libcommon.Hash, len(keys))
+			for _, k := range keys {
+				acc.Storage[libcommon.HexToHash(k)] = libcommon.BytesToHash(common.FromHex(account.Storage[k]))
+			}
+		}
+		var enc []byte
+		if enc, d.err = json.Marshal(acc); d.err != nil {
+			return
+		}
+		if d.count > 0 {
+			if d.err = d.w.WriteByte(','); d.err != nil {
+				return
+			}
+		}
+		_, d.err = fmt.Fprintf(d.w, "\n  \"%x\": %s", addr, enc)
+	case AllocBinary:
+		acc := allocAccount{Address: addr, Nonce: account.Nonce, Balance: balance, Code: account.Code}
+		for _, k := range keys {
+			acc.Storage = append(acc.Storage, allocStorage{Key: libcommon.HexToHash(k), Value: libcommon.BytesToHash(common.FromHex(account.Storage[k])).Big().Bytes()})
+		}
+		d.err = rlp.Encode(d.w, &acc)
+	}
+	d.count++
+}
+
+// Close writes the end of the dump and flushes it.
+func (d *AllocDump) Close() error {
+	if d.err != nil {
+		return d.err
+	}
+	if d.format == AllocJSON {
+		if _, err := d.w.WriteString("\n}\n"); err != nil {
+			return err
+		}
+	}
+	return d.w.Flush()
+}
+
+// DumpAlloc streams the accounts to the collector without keeping the whole state in memory. When addresses are
+// given, only those of them that exist are dumped.
+func (d *Dumper) DumpAlloc(c DumpCollector, addresses []libcommon.Address) error {
+	d.storageAtBlockEnd = true
+	defer func() { d.storageAtBlockEnd = false }()
+	if len(addresses) == 0 {
+		for next := []byte{}; next != nil; {
+			var err error
+			if next, err = d.DumpToCollector(c, false, false, libcommon.BytesToAddress(next), allocDumpBatch); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	sorted := make([]libcommon.Address, len(addresses))
+	copy(sorted, addresses)
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i][:], sorted[j][:]) < 0 })
+	for _, addr := range sorted {
+		// The first account at or after the address is only the one we want if the address exists
+		if _, err := d.DumpToCollector(&addressFilter{c, addr}, false, false, addr, 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type addressFilter struct {
+	DumpCollector
+	addr libcommon.Address
+}
+
+func (f *addressFilter) OnAccount(addr libcommon.Address, account DumpAccount) {
+	if addr == f.addr {
+		f.DumpCollector.OnAccount(addr, account)
+	}
+}
+
+// AllocReader reads the accounts of a state dump written by AllocDump one by one, so that the dump never has to
+// fit in memory. A genesis alloc JSON not produced by AllocDump is accepted as well.
+type AllocReader struct {
+	stream *rlp.Stream   // binary format
+	dec    *json.Decoder // JSON format
+}
+
+func NewAllocReader(r io.Reader) (*AllocReader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(allocBinaryMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if !bytes.Equal(magic, allocBinaryMagic) {
+		dec := json.NewDecoder(br)
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+			return nil, fmt.Errorf("decoding state dump: expected a JSON object")
+		}
+		return &AllocReader{dec: dec}, nil
+	}
+	if _, err := br.Discard(len(allocBinaryMagic)); err != nil {
+		return nil, err
+	}
+	return &AllocReader{stream: rlp.NewStream(br, 0)}, nil
+}
+
+// Next returns the next account of the dump, or io.EOF after the last one.
+func (r *AllocReader) Next() (libcommon.Address, *types.GenesisAccount, error) {
+	if r.dec != nil {
+		if !r.dec.More() {
+			if _, err := r.dec.Token(); err != nil {
+				return libcommon.Address{}, nil, fmt.Errorf("decoding state dump: %w", err)
+			}
+			return libcommon.Address{}, nil, io.EOF
+		}
+		tok, err := r.dec.Token()
+		if err != nil {
+			return libcommon.Address{}, nil, fmt.Errorf("decoding state dump: %w", err)
+		}
+		key, _ := tok.(string)
+		var addr common.UnprefixedAddress
+		if err := addr.UnmarshalText([]byte(key)); err != nil {
+			return libcommon.Address{}, nil, fmt.Errorf("decoding state dump: invalid address %q: %w", key, err)
+		}
+		var account types.GenesisAccount
+		if err := r.dec.Decode(&account); err != nil {
+			return libcommon.Address{}, nil, fmt.Errorf("decoding state dump: %w", err)
+		}
+		return libcommon.Address(addr), &account, nil
+	}
+	var acc allocAccount
+	if err := r.stream.Decode(&acc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return libcommon.Address{}, nil, io.EOF
+		}
+		return libcommon.Address{}, nil, fmt.Errorf("decoding state dump: %w", err)
+	}
+	account := &types.GenesisAccount{Balance: acc.Balance, Nonce: acc.Nonce, Code: acc.Code}
+	if len(acc.Storage) > 0 {
+		account.Storage = make(map[libcommon.Hash]libcommon.Hash, len(acc.Storage))
+		for _, s := range acc.Storage {
+			account.Storage[s.Key] = libcommon.BytesToHash(s.Value)
+		}
+	}
+	return acc.Address, account, nil
+}
+
+// ReadAlloc reads a whole state dump into memory, see AllocReader.
+func ReadAlloc(r io.Reader) (types.GenesisAlloc, error) {
+	ar, err := NewAllocReader(r)
+	if err != nil {
+		return nil, err
+	}
+	alloc := make(types.GenesisAlloc)
+	for {
+		addr, account, err := ar.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return alloc, nil
+			}
+			return nil, err
+		}
+		alloc[addr] = *account
+	}
+}
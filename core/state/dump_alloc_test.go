@@ -0,0 +1,95 @@
+package state_test
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/stages"
+)
+
+func TestDumpAlloc(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address  = crypto.PubkeyToAddress(key.PublicKey)
+		contract = libcommon.HexToAddress("0x00000000000000000000000000000000000000ff")
+		gspec    = &types.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				address: {Balance: big.NewInt(1000000000000000000)},
+				contract: {
+					Balance: big.NewInt(0),
+					// sstore(0, number); sstore(number, calldataload(0))
+					Code: []byte{
+						byte(vm.NUMBER), byte(vm.PUSH1), 0x00, byte(vm.SSTORE),
+						byte(vm.PUSH1), 0x00, byte(vm.CALLDATALOAD), byte(vm.NUMBER), byte(vm.SSTORE),
+					},
+					Storage: map[libcommon.Hash]libcommon.Hash{
+						{}:                         libcommon.HexToHash("0x42"),
+						libcommon.HexToHash("0x2"): libcommon.HexToHash("0x2a"),
+					},
+				},
+			},
+			GasLimit: 10000000,
+		}
+		signer = types.LatestSignerForChainID(nil)
+	)
+	m := stages.MockWithGenesis(t, gspec, key, false)
+	if m.HistoryV3 {
+		t.Skip("not supported by Erigon3")
+	}
+
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 3, func(i int, block *core.BlockGen) {
+		// The second block clears the slot set in the genesis
+		value := libcommon.BigToHash(big.NewInt(int64(i)))
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), contract, uint256.NewInt(0), 100000, uint256.NewInt(1), value[:]), *signer, key)
+		require.NoError(t, err)
+		block.AddTx(tx)
+	}, false /* intermediateHashes */)
+	require.NoError(t, err)
+	require.NoError(t, m.InsertChain(chain))
+
+	tx, err := m.DB.BeginRo(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	for blockNum := uint64(0); blockNum <= 3; blockNum++ {
+		header := rawdb.ReadHeaderByNumber(tx, blockNum)
+		for _, format := range []state.AllocFormat{state.AllocJSON, state.AllocBinary} {
+			var buf bytes.Buffer
+			dump, err := state.NewAllocDump(&buf, format)
+			require.NoError(t, err)
+			require.NoError(t, state.NewDumper(tx, blockNum, false).DumpAlloc(dump, nil))
+			require.NoError(t, dump.Close())
+
+			alloc, err := state.ReadAlloc(&buf)
+			require.NoError(t, err)
+			genesis, _, err := core.GenesisToBlock(&types.Genesis{Config: gspec.Config, Alloc: alloc}, "")
+			require.NoError(t, err)
+			require.Equal(t, header.Root, genesis.Root(), "block %d, format %s", blockNum, format)
+		}
+	}
+
+	var buf bytes.Buffer
+	dump, err := state.NewAllocDump(&buf, state.AllocJSON)
+	require.NoError(t, err)
+	require.NoError(t, state.NewDumper(tx, 3, false).DumpAlloc(dump, []libcommon.Address{contract, libcommon.HexToAddress("0x1234")}))
+	require.NoError(t, dump.Close())
+	alloc, err := state.ReadAlloc(&buf)
+	require.NoError(t, err)
+	require.Len(t, alloc, 1)
+	require.Equal(t, gspec.Alloc[contract].Code, alloc[contract].Code)
+	require.Equal(t, libcommon.HexToHash("0x3"), alloc[contract].Storage[libcommon.Hash{}])
+}
@@ -420,6 +420,18 @@ func (sdb *IntraBlockState) Selfdestruct(addr libcommon.Address) bool {
 	return true
 }
 
+// Selfdestruct6780 implements SELFDESTRUCT as modified by EIP-6780: the
+// account is only destroyed if it was created in the current transaction.
+func (sdb *IntraBlockState) Selfdestruct6780(addr libcommon.Address) {
+	stateObject := sdb.getStateObject(addr)
+	if stateObject == nil {
+		return
+	}
+	if stateObject.newlyCreated {
+		sdb.Selfdestruct(addr)
+	}
+}
+
 // SetTransientState sets transient storage for a given account. It
 // adds the change to the journal so that it can be rolled back
 // to its previous value if there is a revert.
@@ -554,6 +566,7 @@ func (sdb *IntraBlockState) CreateAccount(addr libcommon.Address, contractCreati
 
 	if contractCreation {
 		newObj.created = true
+		newObj.newlyCreated = true
 		newObj.data.Incarnation = prevInc + 1
 	} else {
 		newObj.selfdestructed = false
@@ -665,6 +678,7 @@ func (sdb *IntraBlockState) FinalizeTx(chainRules *chain.Rules, stateWriter Stat
 		if err := updateAccount(chainRules.IsSpuriousDragon, chainRules.IsAura, stateWriter, addr, so, true); err != nil {
 			return err
 		}
+		so.newlyCreated = false
 
 		sdb.stateObjectsDirty[addr] = struct{}{}
 	}
@@ -675,7 +689,7 @@ func (sdb *IntraBlockState) FinalizeTx(chainRules *chain.Rules, stateWriter Stat
 
 func (sdb *IntraBlockState) SoftFinalise() {
 	for addr := range sdb.journal.dirties {
-		_, exist := sdb.stateObjects[addr]
+		so, exist := sdb.stateObjects[addr]
 		if !exist {
 			// ripeMD is 'touched' at block 1714175, in tx 0x1237f737031e40bcde4a8b7e717b2d15e3ecadfe49bb1bbc71ee9deb09c6fcf2
 			// That tx goes out of gas, and although the notion of 'touched' does not exist there, the
@@ -685,6 +699,7 @@ func (sdb *IntraBlockState) SoftFinalise() {
 			// Thus, we can safely ignore it here
 			continue
 		}
+		so.newlyCreated = false
 		sdb.stateObjectsDirty[addr] = struct{}{}
 	}
 	// Invalidate journal because reverting across transactions is not allowed.
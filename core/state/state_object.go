@@ -89,6 +89,7 @@ type stateObject struct {
 	selfdestructed bool
 	deleted        bool // true if account was deleted during the lifetime of this object
 	created        bool // true if this object represents a newly created contract
+	newlyCreated   bool // true if the contract was created in the current transaction
 }
 
 // empty returns whether the account is considered empty.
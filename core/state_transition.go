@@ -69,6 +69,10 @@ type StateTransition struct {
 	state      evmtypes.IntraBlockState
 	evm        vm.VMInterface
 
+	// dataGasPrice is the per-unit price of data gas charged for the blobs
+	// of the message, nil if the message has no blobs or pays no data fee.
+	dataGasPrice *uint256.Int
+
 	//some pre-allocated intermediate variables
 	sharedBuyGas        *uint256.Int
 	sharedBuyGasBalance *uint256.Int
@@ -201,6 +205,16 @@ func (st *StateTransition) buyGas(gasBailout bool) error {
 	if overflow {
 		return fmt.Errorf("%w: address %v", ErrInsufficientFunds, st.msg.From().Hex())
 	}
+	if st.dataGasPrice != nil {
+		// Data gas is paid upfront and burnt, it is never refunded
+		dataGasVal, overflow := new(uint256.Int).MulOverflow(new(uint256.Int).SetUint64(st.dataGasUsed()), st.dataGasPrice)
+		if overflow {
+			return fmt.Errorf("%w: address %v", ErrInsufficientFunds, st.msg.From().Hex())
+		}
+		if mgval, overflow = mgval.AddOverflow(mgval, dataGasVal); overflow {
+			return fmt.Errorf("%w: address %v", ErrInsufficientFunds, st.msg.From().Hex())
+		}
+	}
 	balanceCheck := mgval
 	if st.gasFeeCap != nil {
 		balanceCheck = st.sharedBuyGasBalance.SetUint64(st.msg.Gas())
@@ -212,6 +226,15 @@ func (st *StateTransition) buyGas(gasBailout bool) error {
 		if overflow {
 			return fmt.Errorf("%w: address %v", ErrInsufficientFunds, st.msg.From().Hex())
 		}
+		if st.dataGasPrice != nil {
+			maxDataGasVal, overflow := new(uint256.Int).MulOverflow(new(uint256.Int).SetUint64(st.msg.DataGas()), st.msg.MaxFeePerDataGas())
+			if overflow {
+				return fmt.Errorf("%w: address %v", ErrInsufficientFunds, st.msg.From().Hex())
+			}
+			if balanceCheck, overflow = balanceCheck.AddOverflow(balanceCheck, maxDataGasVal); overflow {
+				return fmt.Errorf("%w: address %v", ErrInsufficientFunds, st.msg.From().Hex())
+			}
+		}
 	}
 	var subBalance = false
 	if have, want := st.state.GetBalance(st.msg.From()), balanceCheck; have.Cmp(want) < 0 {
@@ -282,6 +305,26 @@ func (st *StateTransition) preCheck(gasBailout bool) error {
 			}
 		}
 	}
+
+	// Make sure the transaction pays at least the data gas price of the block (EIP-4844).
+	if numBlobs := len(st.msg.DataHashes()); numBlobs > 0 && st.evm.ChainRules().IsCancun {
+		if numBlobs > params.MaxBlobsPerBlock {
+			return fmt.Errorf("%w: address %v, blobs: %d max: %d", ErrTooManyBlobs,
+				st.msg.From().Hex(), numBlobs, params.MaxBlobsPerBlock)
+		}
+		// Skip the checks if the fee field is zero and baseFee was explicitly disabled (eth_call)
+		if !st.evm.Config().NoBaseFee || !st.msg.MaxFeePerDataGas().IsZero() {
+			dataGasPrice, overflow := uint256.FromBig(misc.GetDataGasPrice(st.evm.Context().ExcessDataGas))
+			if overflow {
+				return fmt.Errorf("dataGasPrice higher than 2^256-1")
+			}
+			if st.msg.MaxFeePerDataGas().Lt(dataGasPrice) {
+				return fmt.Errorf("%w: address %v, maxFeePerDataGas: %s dataGasPrice: %s", ErrMaxFeePerDataGas,
+					st.msg.From().Hex(), st.msg.MaxFeePerDataGas(), dataGasPrice)
+			}
+			st.dataGasPrice = dataGasPrice
+		}
+	}
 	return st.buyGas(gasBailout)
 }
 
@@ -0,0 +1,188 @@
+package stateless
+
+import (
+	"encoding/binary"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/crypto"
+)
+
+var _ state.StateReader = (*Recorder)(nil)
+var _ state.WriterWithChangeSets = (*Recorder)(nil)
+
+// Recorder is a state reader that records which items are read from the underlying reader, and a state writer that
+// records which items are deleted. A block executed on top of it tells what the witness for the block has to contain.
+type Recorder struct {
+	reader state.StateReader
+
+	accounts     map[libcommon.Address]struct{}
+	incarnations map[libcommon.Hash]uint64 // by address hash, as of the underlying reader
+	storage      map[libcommon.Address]map[libcommon.Hash]struct{}
+	codes        map[libcommon.Hash]struct{}
+
+	deletedAccounts map[libcommon.Address]struct{}
+	deletedStorage  map[libcommon.Address]map[libcommon.Hash]struct{}
+}
+
+func NewRecorder(reader state.StateReader) *Recorder {
+	return &Recorder{
+		reader:          reader,
+		accounts:        make(map[libcommon.Address]struct{}),
+		incarnations:    make(map[libcommon.Hash]uint64),
+		storage:         make(map[libcommon.Address]map[libcommon.Hash]struct{}),
+		codes:           make(map[libcommon.Hash]struct{}),
+		deletedAccounts: make(map[libcommon.Address]struct{}),
+		deletedStorage:  make(map[libcommon.Address]map[libcommon.Hash]struct{}),
+	}
+}
+
+func (r *Recorder) ReadAccountData(address libcommon.Address) (*accounts.Account, error) {
+	acc, err := r.reader.ReadAccountData(address)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := r.accounts[address]; !ok {
+		r.accounts[address] = struct{}{}
+		if acc != nil {
+			r.incarnations[crypto.Keccak256Hash(address[:])] = acc.Incarnation
+		}
+	}
+	return acc, nil
+}
+
+func (r *Recorder) ReadAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash) ([]byte, error) {
+	m, ok := r.storage[address]
+	if !ok {
+		m = make(map[libcommon.Hash]struct{})
+		r.storage[address] = m
+	}
+	m[*key] = struct{}{}
+	return r.reader.ReadAccountStorage(address, incarnation, key)
+}
+
+func (r *Recorder) ReadAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) ([]byte, error) {
+	r.codes[codeHash] = struct{}{}
+	return r.reader.ReadAccountCode(address, incarnation, codeHash)
+}
+
+func (r *Recorder) ReadAccountCodeSize(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) (int, error) {
+	r.codes[codeHash] = struct{}{}
+	return r.reader.ReadAccountCodeSize(address, incarnation, codeHash)
+}
+
+func (r *Recorder) ReadAccountIncarnation(address libcommon.Address) (uint64, error) {
+	return r.reader.ReadAccountIncarnation(address)
+}
+
+func (r *Recorder) UpdateAccountData(address libcommon.Address, original, account *accounts.Account) error {
+	delete(r.deletedAccounts, address)
+	return nil
+}
+
+func (r *Recorder) UpdateAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash, code []byte) error {
+	return nil
+}
+
+func (r *Recorder) DeleteAccount(address libcommon.Address, original *accounts.Account) error {
+	r.deletedAccounts[address] = struct{}{}
+	return nil
+}
+
+func (r *Recorder) WriteAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash, original, value *uint256.Int) error {
+	m, ok := r.deletedStorage[address]
+	if !ok {
+		m = make(map[libcommon.Hash]struct{})
+		r.deletedStorage[address] = m
+	}
+	if value.IsZero() && !original.IsZero() {
+		m[*key] = struct{}{}
+	} else {
+		delete(m, *key)
+	}
+	return nil
+}
+
+func (r *Recorder) CreateContract(address libcommon.Address) error {
+	return nil
+}
+
+func (r *Recorder) WriteChangeSets() error { return nil }
+func (r *Recorder) WriteHistory() error    { return nil }
+
+// TouchedKeys returns the keys of the accounts and storage items read, in the format of the hashed state.
+func (r *Recorder) TouchedKeys() [][]byte {
+	keys := make([][]byte, 0, len(r.accounts))
+	for address := range r.accounts {
+		keys = append(keys, crypto.Keccak256(address[:]))
+	}
+	for address, m := range r.storage {
+		addrHash := crypto.Keccak256Hash(address[:])
+		incarnation, ok := r.incarnations[addrHash]
+		if !ok {
+			// The account does not exist before the block, neither does its storage
+			continue
+		}
+		for key := range m {
+			k := make([]byte, 0, length.Hash+length.Incarnation+length.Hash)
+			k = append(k, addrHash[:]...)
+			k = binary.BigEndian.AppendUint64(k, incarnation)
+			k = append(k, crypto.Keccak256(key[:])...)
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// TouchedCodes returns the hashes of the contract code read.
+func (r *Recorder) TouchedCodes() []libcommon.Hash {
+	codes := make([]libcommon.Hash, 0, len(r.codes))
+	for codeHash := range r.codes {
+		codes = append(codes, codeHash)
+	}
+	return codes
+}
+
+// DeletedKeys returns the keys of the accounts and storage items deleted, in the format of trie.Trie.
+func (r *Recorder) DeletedKeys() [][]byte {
+	keys := make([][]byte, 0, len(r.deletedAccounts))
+	for address := range r.deletedAccounts {
+		keys = append(keys, crypto.Keccak256(address[:]))
+	}
+	for address, m := range r.deletedStorage {
+		if _, ok := r.deletedAccounts[address]; ok {
+			continue
+		}
+		addrHash := crypto.Keccak256(address[:])
+		for key := range m {
+			keys = append(keys, append(libcommon.Copy(addrHash), crypto.Keccak256(key[:])...))
+		}
+	}
+	return keys
+}
+
+// KeyThrough returns a key of the hashed state whose path goes through the given hex prefix of trie.Trie,
+// retaining the key keeps the node at the prefix in the witness.
+func (r *Recorder) KeyThrough(prefix []byte) []byte {
+	var nibbles []byte
+	if len(prefix) <= 2*length.Hash {
+		nibbles = make([]byte, 2*length.Hash)
+		copy(nibbles, prefix)
+	} else {
+		nibbles = make([]byte, 2*(length.Hash+length.Incarnation+length.Hash))
+		copy(nibbles, prefix[:2*length.Hash])
+		copy(nibbles[2*(length.Hash+length.Incarnation):], prefix[2*length.Hash:])
+	}
+	key := make([]byte, len(nibbles)/2)
+	for i := range key {
+		key[i] = nibbles[2*i]<<4 | nibbles[2*i+1]
+	}
+	if len(prefix) > 2*length.Hash {
+		binary.BigEndian.PutUint64(key[length.Hash:], r.incarnations[libcommon.BytesToHash(key[:length.Hash])])
+	}
+	return key
+}
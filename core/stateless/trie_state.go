@@ -0,0 +1,136 @@
+package stateless
+
+import (
+	"fmt"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/turbo/trie"
+)
+
+var _ state.StateReader = (*TrieState)(nil)
+var _ state.WriterWithChangeSets = (*TrieState)(nil)
+
+// TrieState reads the state from a trie built out of a block witness, and applies the state changes back to the trie.
+// Reading an item that is not part of the witness fails, the first such failure is kept and reported by Error,
+// because the intra block state does not always propagate the errors of its reader.
+type TrieState struct {
+	t   *trie.Trie
+	err error
+
+	// storage changes and contract creation of the account being committed, they are applied once the account itself is updated
+	storage map[libcommon.Hash]*uint256.Int
+	created bool
+}
+
+func NewTrieState(t *trie.Trie) *TrieState {
+	return &TrieState{t: t, storage: make(map[libcommon.Hash]*uint256.Int)}
+}
+
+// Error returns the first read of an item missing from the witness.
+func (s *TrieState) Error() error {
+	return s.err
+}
+
+func (s *TrieState) missing(format string, args ...interface{}) error {
+	err := fmt.Errorf("witness is incomplete: "+format, args...)
+	if s.err == nil {
+		s.err = err
+	}
+	return err
+}
+
+func (s *TrieState) ReadAccountData(address libcommon.Address) (*accounts.Account, error) {
+	acc, ok := s.t.GetAccount(crypto.Keccak256(address[:]))
+	if !ok {
+		return nil, s.missing("account %x", address)
+	}
+	if acc == nil {
+		return nil, nil
+	}
+	// The witness does not carry incarnations
+	if !acc.IsEmptyCodeHash() || !acc.IsEmptyRoot() {
+		acc.Incarnation = state.FirstContractIncarnation
+	}
+	return acc, nil
+}
+
+func (s *TrieState) ReadAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash) ([]byte, error) {
+	enc, ok := s.t.Get(append(crypto.Keccak256(address[:]), crypto.Keccak256(key[:])...))
+	if !ok {
+		return nil, s.missing("storage %x of account %x", *key, address)
+	}
+	return enc, nil
+}
+
+func (s *TrieState) ReadAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) ([]byte, error) {
+	if codeHash == trie.EmptyCodeHash {
+		return nil, nil
+	}
+	code, ok := s.t.GetAccountCode(crypto.Keccak256(address[:]))
+	if !ok {
+		return nil, s.missing("code of account %x", address)
+	}
+	return code, nil
+}
+
+func (s *TrieState) ReadAccountCodeSize(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash) (int, error) {
+	if codeHash == trie.EmptyCodeHash {
+		return 0, nil
+	}
+	if size, ok := s.t.GetAccountCodeSize(crypto.Keccak256(address[:])); ok {
+		return size, nil
+	}
+	code, err := s.ReadAccountCode(address, incarnation, codeHash)
+	return len(code), err
+}
+
+func (s *TrieState) ReadAccountIncarnation(address libcommon.Address) (uint64, error) {
+	return 0, nil
+}
+
+func (s *TrieState) UpdateAccountData(address libcommon.Address, original, account *accounts.Account) error {
+	addrHash := crypto.Keccak256(address[:])
+	if s.created {
+		// Wipe the storage of the previous incarnation
+		s.t.DeleteSubtree(addrHash)
+	}
+	s.t.UpdateAccount(addrHash, account)
+	for keyHash, value := range s.storage {
+		if value.IsZero() {
+			s.t.Delete(append(addrHash, keyHash[:]...))
+		} else {
+			s.t.Update(append(addrHash, keyHash[:]...), value.Bytes())
+		}
+	}
+	s.storage = make(map[libcommon.Hash]*uint256.Int)
+	s.created = false
+	return nil
+}
+
+func (s *TrieState) UpdateAccountCode(address libcommon.Address, incarnation uint64, codeHash libcommon.Hash, code []byte) error {
+	// The account carries the code hash, which is all the state root depends on
+	return nil
+}
+
+func (s *TrieState) DeleteAccount(address libcommon.Address, original *accounts.Account) error {
+	s.t.Delete(crypto.Keccak256(address[:]))
+	return nil
+}
+
+func (s *TrieState) WriteAccountStorage(address libcommon.Address, incarnation uint64, key *libcommon.Hash, original, value *uint256.Int) error {
+	s.storage[crypto.Keccak256Hash(key[:])] = value.Clone()
+	return nil
+}
+
+func (s *TrieState) CreateContract(address libcommon.Address) error {
+	s.created = true
+	return nil
+}
+
+func (s *TrieState) WriteChangeSets() error { return nil }
+func (s *TrieState) WriteHistory() error    { return nil }
@@ -0,0 +1,110 @@
+package stateless
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/ledgerwatch/erigon-lib/chain"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+
+	"github.com/ledgerwatch/erigon/consensus"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/turbo/trie"
+)
+
+// BlockWitness is everything needed to execute a block without access to the state: the block itself, the headers of
+// its ancestors it refers to, and the witness of the parts of the parent state the block touches.
+type BlockWitness struct {
+	Block   hexutility.Bytes   `json:"block"`   // RLP encoded block
+	Headers []hexutility.Bytes `json:"headers"` // RLP encoded headers, starting with the parent and going back
+	Witness hexutility.Bytes   `json:"witness"` // trie witness of the parent state
+}
+
+// Verify executes the block of the witness on top of the state built from the witness, and checks that the resulting
+// state root matches the one of the block.
+func Verify(bw *BlockWitness, chainConfig *chain.Config, engine consensus.Engine) error {
+	block := new(types.Block)
+	if err := rlp.DecodeBytes(bw.Block, block); err != nil {
+		return fmt.Errorf("decoding block: %w", err)
+	}
+	if len(bw.Headers) == 0 {
+		return fmt.Errorf("parent header of block %d is missing", block.NumberU64())
+	}
+	headers := &headerReader{config: chainConfig, byHash: make(map[libcommon.Hash]*types.Header)}
+	next := block.Header()
+	for i, enc := range bw.Headers {
+		header := new(types.Header)
+		if err := rlp.DecodeBytes(enc, header); err != nil {
+			return fmt.Errorf("decoding header %d: %w", i, err)
+		}
+		if header.Hash() != next.ParentHash || header.Number.Uint64()+1 != next.Number.Uint64() {
+			return fmt.Errorf("header %d (%d %x) is not the parent of %d", i, header.Number.Uint64(), header.Hash(), next.Number.Uint64())
+		}
+		headers.byHash[header.Hash()] = header
+		next = header
+	}
+	parent := headers.byHash[block.ParentHash()]
+	headers.current = parent
+
+	w, err := trie.NewWitnessFromReader(bytes.NewReader(bw.Witness), false)
+	if err != nil {
+		return fmt.Errorf("decoding witness: %w", err)
+	}
+	t, err := trie.BuildTrieFromWitness(w, false)
+	if err != nil {
+		return fmt.Errorf("building trie from witness: %w", err)
+	}
+	if root := t.Hash(); root != parent.Root {
+		return fmt.Errorf("witness root %x does not match the parent state root %x", root, parent.Root)
+	}
+
+	st := NewTrieState(t)
+	getHeader := func(hash libcommon.Hash, number uint64) *types.Header { return headers.GetHeader(hash, number) }
+	if _, err = core.ExecuteBlockEphemerally(chainConfig, &vm.Config{}, core.GetHashFn(block.Header(), getHeader), engine, block, st, st, headers, nil); err != nil {
+		if st.Error() != nil {
+			return st.Error()
+		}
+		return fmt.Errorf("executing block %d: %w", block.NumberU64(), err)
+	}
+	if st.Error() != nil {
+		return st.Error()
+	}
+	if root := t.Hash(); root != block.Root() {
+		return fmt.Errorf("state root mismatch for block %d: got %x, expected %x", block.NumberU64(), root, block.Root())
+	}
+	return nil
+}
+
+// headerReader serves the headers of a block witness.
+type headerReader struct {
+	config  *chain.Config
+	current *types.Header
+	byHash  map[libcommon.Hash]*types.Header
+}
+
+func (r *headerReader) Config() *chain.Config        { return r.config }
+func (r *headerReader) CurrentHeader() *types.Header { return r.current }
+
+func (r *headerReader) GetHeader(hash libcommon.Hash, number uint64) *types.Header {
+	if h, ok := r.byHash[hash]; ok && h.Number.Uint64() == number {
+		return h
+	}
+	return nil
+}
+
+func (r *headerReader) GetHeaderByNumber(number uint64) *types.Header {
+	for _, h := range r.byHash {
+		if h.Number.Uint64() == number {
+			return h
+		}
+	}
+	return nil
+}
+
+func (r *headerReader) GetHeaderByHash(hash libcommon.Hash) *types.Header { return r.byHash[hash] }
+func (r *headerReader) GetTd(hash libcommon.Hash, number uint64) *big.Int { return nil }
@@ -30,6 +30,7 @@ const (
 	MimetypeTypedData         = "data/typed"
 	MimetypeClique            = "application/x-clique-header"
 	MimetypeBor               = "application/x-bor-header"
+	MimetypeAuRa              = "application/x-aura-header"
 	MimetypeTextPlain         = "text/plain"
 )
 
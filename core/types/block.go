@@ -98,6 +98,8 @@ type Header struct {
 	WithdrawalsHash *libcommon.Hash `json:"withdrawalsRoot"` // EIP-4895
 	// ExcessDataGas was added by EIP-4844 and is ignored in legacy headers.
 	ExcessDataGas *big.Int `json:"excessDataGas"`
+	// ParentBeaconBlockRoot was added by EIP-4788 and is ignored in legacy headers.
+	ParentBeaconBlockRoot *libcommon.Hash `json:"parentBeaconBlockRoot"`
 
 	// The verkle proof is ignored in legacy headers
 	Verkle        bool
@@ -176,6 +178,10 @@ func (h *Header) EncodingSize() int {
 		encodingSize += rlp.BigIntLenExcludingHead(h.ExcessDataGas)
 	}
 
+	if h.ParentBeaconBlockRoot != nil {
+		encodingSize += 33
+	}
+
 	if h.Verkle {
 		// Encoding of Verkle Proof
 		encodingSize++
@@ -324,6 +330,16 @@ func (h *Header) EncodeRLP(w io.Writer) error {
 		}
 	}
 
+	if h.ParentBeaconBlockRoot != nil {
+		b[0] = 128 + 32
+		if _, err := w.Write(b[:1]); err != nil {
+			return err
+		}
+		if _, err := w.Write(h.ParentBeaconBlockRoot.Bytes()); err != nil {
+			return err
+		}
+	}
+
 	if h.Verkle {
 		if err := rlp.EncodeString(h.VerkleProof, w, b[:]); err != nil {
 			return err
@@ -482,6 +498,23 @@ func (h *Header) DecodeRLP(s *rlp.Stream) error {
 	}
 	h.ExcessDataGas = new(big.Int).SetBytes(b)
 
+	// ParentBeaconBlockRoot
+	if b, err = s.Bytes(); err != nil {
+		if errors.Is(err, rlp.EOL) {
+			h.ParentBeaconBlockRoot = nil
+			if err := s.ListEnd(); err != nil {
+				return fmt.Errorf("close header struct (no ParentBeaconBlockRoot): %w", err)
+			}
+			return nil
+		}
+		return fmt.Errorf("read ParentBeaconBlockRoot: %w", err)
+	}
+	if len(b) != 32 {
+		return fmt.Errorf("wrong size for ParentBeaconBlockRoot: %d", len(b))
+	}
+	h.ParentBeaconBlockRoot = new(libcommon.Hash)
+	h.ParentBeaconBlockRoot.SetBytes(b)
+
 	if h.Verkle {
 		if h.VerkleProof, err = s.Bytes(); err != nil {
 			return fmt.Errorf("read VerkleProof: %w", err)
@@ -545,6 +578,9 @@ func (h *Header) Size() common.StorageSize {
 	if h.ExcessDataGas != nil {
 		s += common.StorageSize(bitsToBytes(h.ExcessDataGas.BitLen()))
 	}
+	if h.ParentBeaconBlockRoot != nil {
+		s += common.StorageSize(32)
+	}
 	return s
 }
 
@@ -1236,6 +1272,10 @@ func CopyHeader(h *Header) *Header {
 		cpy.ExcessDataGas = new(big.Int)
 		cpy.ExcessDataGas.Set(h.ExcessDataGas)
 	}
+	if h.ParentBeaconBlockRoot != nil {
+		cpy.ParentBeaconBlockRoot = new(libcommon.Hash)
+		cpy.ParentBeaconBlockRoot.SetBytes(h.ParentBeaconBlockRoot.Bytes())
+	}
 	return &cpy
 }
 
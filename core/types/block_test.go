@@ -442,6 +442,43 @@ func TestWithdrawalsEncoding(t *testing.T) {
 	assert.Equal(t, block2, &decoded2)
 }
 
+func TestParentBeaconBlockRootEncoding(t *testing.T) {
+	root := libcommon.HexToHash("0x88e96d4537bea4d9c05d12549907b32561d3bf31f45aae734cdc119f13406cb6")
+	header := Header{
+		ParentHash:            libcommon.HexToHash("0x8b00fcf1e541d371a3a1b79cc999a85cc3db5ee5637b5159646e1acd3613fd15"),
+		Coinbase:              libcommon.HexToAddress("0x571846e42308df2dad8ed792f44a8bfddf0acb4d"),
+		Root:                  libcommon.HexToHash("0x351780124dae86b84998c6d4fe9a88acfb41b4856b4f2c56767b51a4e2f94dd4"),
+		Difficulty:            libcommon.Big0,
+		Number:                big.NewInt(20_000_000),
+		GasLimit:              30_000_000,
+		GasUsed:               3_074_345,
+		Time:                  1666343339,
+		Extra:                 make([]byte, 0),
+		BaseFee:               big.NewInt(7_000_000_000),
+		WithdrawalsHash:       &EmptyRootHash,
+		ExcessDataGas:         big.NewInt(0),
+		ParentBeaconBlockRoot: &root,
+	}
+
+	encoded, err := rlp.EncodeToBytes(&header)
+	require.NoError(t, err)
+
+	var decoded Header
+	require.NoError(t, rlp.DecodeBytes(encoded, &decoded))
+	assert.Equal(t, header, decoded)
+
+	encodedJSON, err := json.Marshal(&header)
+	require.NoError(t, err)
+	var decodedJSON Header
+	require.NoError(t, json.Unmarshal(encodedJSON, &decodedJSON))
+	assert.Equal(t, root, *decodedJSON.ParentBeaconBlockRoot)
+
+	// The root changes the hash of the header
+	withoutRoot := header
+	withoutRoot.ParentBeaconBlockRoot = nil
+	assert.NotEqual(t, header.Hash(), withoutRoot.Hash())
+}
+
 func TestBlockRawBodyPreShanghai(t *testing.T) {
 	require := require.New(t)
 
@@ -18,24 +18,25 @@ var _ = (*headerMarshaling)(nil)
 // MarshalJSON marshals as JSON.
 func (h Header) MarshalJSON() ([]byte, error) {
 	type Header struct {
-		ParentHash      libcommon.Hash    `json:"parentHash"       gencodec:"required"`
-		UncleHash       libcommon.Hash    `json:"sha3Uncles"       gencodec:"required"`
-		Coinbase        libcommon.Address `json:"miner"`
-		Root            libcommon.Hash    `json:"stateRoot"        gencodec:"required"`
-		TxHash          libcommon.Hash    `json:"transactionsRoot" gencodec:"required"`
-		ReceiptHash     libcommon.Hash    `json:"receiptsRoot"     gencodec:"required"`
-		Bloom           Bloom             `json:"logsBloom"        gencodec:"required"`
-		Difficulty      *hexutil.Big      `json:"difficulty"       gencodec:"required"`
-		Number          *hexutil.Big      `json:"number"           gencodec:"required"`
-		GasLimit        hexutil.Uint64    `json:"gasLimit"         gencodec:"required"`
-		GasUsed         hexutil.Uint64    `json:"gasUsed"          gencodec:"required"`
-		Time            hexutil.Uint64    `json:"timestamp"        gencodec:"required"`
-		Extra           hexutility.Bytes  `json:"extraData"        gencodec:"required"`
-		MixDigest       libcommon.Hash    `json:"mixHash"`
-		Nonce           BlockNonce        `json:"nonce"`
-		BaseFee         *hexutil.Big      `json:"baseFeePerGas"`
-		WithdrawalsHash *libcommon.Hash   `json:"withdrawalsRoot"`
-		Hash            libcommon.Hash    `json:"hash"`
+		ParentHash            libcommon.Hash    `json:"parentHash"       gencodec:"required"`
+		UncleHash             libcommon.Hash    `json:"sha3Uncles"       gencodec:"required"`
+		Coinbase              libcommon.Address `json:"miner"`
+		Root                  libcommon.Hash    `json:"stateRoot"        gencodec:"required"`
+		TxHash                libcommon.Hash    `json:"transactionsRoot" gencodec:"required"`
+		ReceiptHash           libcommon.Hash    `json:"receiptsRoot"     gencodec:"required"`
+		Bloom                 Bloom             `json:"logsBloom"        gencodec:"required"`
+		Difficulty            *hexutil.Big      `json:"difficulty"       gencodec:"required"`
+		Number                *hexutil.Big      `json:"number"           gencodec:"required"`
+		GasLimit              hexutil.Uint64    `json:"gasLimit"         gencodec:"required"`
+		GasUsed               hexutil.Uint64    `json:"gasUsed"          gencodec:"required"`
+		Time                  hexutil.Uint64    `json:"timestamp"        gencodec:"required"`
+		Extra                 hexutility.Bytes  `json:"extraData"        gencodec:"required"`
+		MixDigest             libcommon.Hash    `json:"mixHash"`
+		Nonce                 BlockNonce        `json:"nonce"`
+		BaseFee               *hexutil.Big      `json:"baseFeePerGas"`
+		WithdrawalsHash       *libcommon.Hash   `json:"withdrawalsRoot"`
+		ParentBeaconBlockRoot *libcommon.Hash   `json:"parentBeaconBlockRoot"`
+		Hash                  libcommon.Hash    `json:"hash"`
 	}
 	var enc Header
 	enc.ParentHash = h.ParentHash
@@ -55,6 +56,7 @@ func (h Header) MarshalJSON() ([]byte, error) {
 	enc.Nonce = h.Nonce
 	enc.BaseFee = (*hexutil.Big)(h.BaseFee)
 	enc.WithdrawalsHash = h.WithdrawalsHash
+	enc.ParentBeaconBlockRoot = h.ParentBeaconBlockRoot
 	enc.Hash = h.Hash()
 	return json.Marshal(&enc)
 }
@@ -62,23 +64,24 @@ func (h Header) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON unmarshals from JSON.
 func (h *Header) UnmarshalJSON(input []byte) error {
 	type Header struct {
-		ParentHash      *libcommon.Hash    `json:"parentHash"       gencodec:"required"`
-		UncleHash       *libcommon.Hash    `json:"sha3Uncles"       gencodec:"required"`
-		Coinbase        *libcommon.Address `json:"miner"`
-		Root            *libcommon.Hash    `json:"stateRoot"        gencodec:"required"`
-		TxHash          *libcommon.Hash    `json:"transactionsRoot" gencodec:"required"`
-		ReceiptHash     *libcommon.Hash    `json:"receiptsRoot"     gencodec:"required"`
-		Bloom           *Bloom             `json:"logsBloom"        gencodec:"required"`
-		Difficulty      *hexutil.Big       `json:"difficulty"       gencodec:"required"`
-		Number          *hexutil.Big       `json:"number"           gencodec:"required"`
-		GasLimit        *hexutil.Uint64    `json:"gasLimit"         gencodec:"required"`
-		GasUsed         *hexutil.Uint64    `json:"gasUsed"          gencodec:"required"`
-		Time            *hexutil.Uint64    `json:"timestamp"        gencodec:"required"`
-		Extra           *hexutility.Bytes  `json:"extraData"        gencodec:"required"`
-		MixDigest       *libcommon.Hash    `json:"mixHash"`
-		Nonce           *BlockNonce        `json:"nonce"`
-		BaseFee         *hexutil.Big       `json:"baseFeePerGas"`
-		WithdrawalsHash *libcommon.Hash    `json:"withdrawalsRoot"`
+		ParentHash            *libcommon.Hash    `json:"parentHash"       gencodec:"required"`
+		UncleHash             *libcommon.Hash    `json:"sha3Uncles"       gencodec:"required"`
+		Coinbase              *libcommon.Address `json:"miner"`
+		Root                  *libcommon.Hash    `json:"stateRoot"        gencodec:"required"`
+		TxHash                *libcommon.Hash    `json:"transactionsRoot" gencodec:"required"`
+		ReceiptHash           *libcommon.Hash    `json:"receiptsRoot"     gencodec:"required"`
+		Bloom                 *Bloom             `json:"logsBloom"        gencodec:"required"`
+		Difficulty            *hexutil.Big       `json:"difficulty"       gencodec:"required"`
+		Number                *hexutil.Big       `json:"number"           gencodec:"required"`
+		GasLimit              *hexutil.Uint64    `json:"gasLimit"         gencodec:"required"`
+		GasUsed               *hexutil.Uint64    `json:"gasUsed"          gencodec:"required"`
+		Time                  *hexutil.Uint64    `json:"timestamp"        gencodec:"required"`
+		Extra                 *hexutility.Bytes  `json:"extraData"        gencodec:"required"`
+		MixDigest             *libcommon.Hash    `json:"mixHash"`
+		Nonce                 *BlockNonce        `json:"nonce"`
+		BaseFee               *hexutil.Big       `json:"baseFeePerGas"`
+		WithdrawalsHash       *libcommon.Hash    `json:"withdrawalsRoot"`
+		ParentBeaconBlockRoot *libcommon.Hash    `json:"parentBeaconBlockRoot"`
 	}
 	var dec Header
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -145,5 +148,8 @@ func (h *Header) UnmarshalJSON(input []byte) error {
 		h.BaseFee = (*big.Int)(dec.BaseFee)
 	}
 	h.WithdrawalsHash = dec.WithdrawalsHash
+	if dec.ParentBeaconBlockRoot != nil {
+		h.ParentBeaconBlockRoot = dec.ParentBeaconBlockRoot
+	}
 	return nil
 }
@@ -161,6 +161,17 @@ func DecodeTransaction(s *rlp.Stream) (Transaction, error) {
 			return nil, err
 		}
 		tx = t
+	case BlobTxType:
+		// Blob txs are SSZ-encoded after the type byte
+		var payload []byte
+		if payload, err = s.ReadRemaining(); err != nil {
+			return nil, err
+		}
+		t := &SignedBlobTx{}
+		if err = DecodeSSZ(payload, t); err != nil {
+			return nil, err
+		}
+		tx = t
 	default:
 		return nil, fmt.Errorf("%w, got: %d", rlp.ErrUnknownTxTypePrefix, b[0])
 	}
@@ -240,6 +240,17 @@ func (sg Signer) SenderWithContext(context *secp256k1.Context, tx Transaction) (
 		// id, add 27 to become equivalent to unprotected Homestead signatures.
 		V.Add(&t.V, u256.Num27)
 		R, S = &t.R, &t.S
+	case *SignedBlobTx:
+		if !sg.dynamicfee {
+			return libcommon.Address{}, fmt.Errorf("blob tx is not supported by signer %s", sg)
+		}
+		if !t.GetChainID().Eq(&sg.chainID) {
+			return libcommon.Address{}, ErrInvalidChainId
+		}
+		// Blob txs use the same 0 and 1 recovery id as DynamicFee txs.
+		v, r, s := t.RawSignatureValues()
+		V.Add(v, u256.Num27)
+		R, S = r, s
 	default:
 		return libcommon.Address{}, ErrTxTypeNotSupported
 	}
@@ -272,6 +283,11 @@ func (sg Signer) SignatureValues(tx Transaction, sig []byte) (R, S, V *uint256.I
 			return nil, nil, nil, ErrInvalidChainId
 		}
 		R, S, V = decodeSignature(sig)
+	case *SignedBlobTx:
+		if chainID := t.GetChainID(); !chainID.IsZero() && !chainID.Eq(&sg.chainID) {
+			return nil, nil, nil, ErrInvalidChainId
+		}
+		R, S, V = decodeSignature(sig)
 	default:
 		return nil, nil, nil, ErrTxTypeNotSupported
 	}
@@ -275,6 +275,48 @@ func TestEIP1559TransactionEncode(t *testing.T) {
 	}
 }
 
+func TestBlobTransactionEncode(t *testing.T) {
+	key, addr := defaultTestKey()
+	to := AddressSSZ(testAddr)
+	var blobTx SignedBlobTx
+	blobTx.Message.ChainID.SetFromBig(big.NewInt(1))
+	blobTx.Message.Nonce = 3
+	blobTx.Message.GasFeeCap.SetFromBig(big.NewInt(10))
+	blobTx.Message.Gas = 25000
+	blobTx.Message.To = AddressOptionalSSZ{Address: &to}
+	blobTx.Message.Data = common.FromHex("5544")
+	blobTx.Message.AccessList = AccessListView{}
+	blobTx.Message.MaxFeePerDataGas.SetFromBig(big.NewInt(1))
+	blobTx.Message.BlobVersionedHashes = []libcommon.Hash{{0x01, 0x01}, {0x01, 0x02}}
+	signed, err := SignTx(&blobTx, *LatestSignerForChainID(big.NewInt(1)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both the binary and the RLP-wrapped forms decode back into the same tx
+	var binary, wrapped bytes.Buffer
+	if err := signed.MarshalBinary(&binary); err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	if err := signed.EncodeRLP(&wrapped); err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	assert.False(t, TypedTransactionMarshalledAsRlpString(binary.Bytes()))
+	for _, data := range [][]byte{binary.Bytes(), wrapped.Bytes()} {
+		tx, err := decodeTx(data)
+		if err != nil {
+			t.Fatalf("decode error: %v", err)
+		}
+		assert.Equal(t, signed.Hash(), tx.Hash())
+		assert.Equal(t, blobTx.Message.BlobVersionedHashes, VersionedHashesView(tx.GetDataHashes()))
+		sender, err := tx.Sender(*LatestSignerForChainID(big.NewInt(1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, addr, sender)
+	}
+}
+
 func decodeTx(data []byte) (Transaction, error) {
 	return DecodeTransaction(rlp.NewStream(bytes.NewReader(data), 0))
 }
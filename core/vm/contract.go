@@ -58,6 +58,9 @@ type Contract struct {
 
 	Gas   uint64
 	value *uint256.Int
+
+	container   *Container // EOF container of the code, nil for legacy code
+	returnStack []uint64   // return pcs of the CALLF instructions
 }
 
 // NewContract returns a new contract environment for the execution of EVM.
@@ -32,6 +32,7 @@ import (
 	"github.com/ledgerwatch/erigon/crypto/blake2b"
 	"github.com/ledgerwatch/erigon/crypto/bls12381"
 	"github.com/ledgerwatch/erigon/crypto/bn256"
+	"github.com/ledgerwatch/erigon/crypto/kzg"
 	"github.com/ledgerwatch/erigon/params"
 
 	//lint:ignore SA1019 Needed for precompile
@@ -96,6 +97,21 @@ var PrecompiledContractsBerlin = map[libcommon.Address]PrecompiledContract{
 	libcommon.BytesToAddress([]byte{9}): &blake2F{},
 }
 
+// PrecompiledContractsCancun contains the default set of pre-compiled Ethereum
+// contracts used in the Cancun release.
+var PrecompiledContractsCancun = map[libcommon.Address]PrecompiledContract{
+	libcommon.BytesToAddress([]byte{1}):  &ecrecover{},
+	libcommon.BytesToAddress([]byte{2}):  &sha256hash{},
+	libcommon.BytesToAddress([]byte{3}):  &ripemd160hash{},
+	libcommon.BytesToAddress([]byte{4}):  &dataCopy{},
+	libcommon.BytesToAddress([]byte{5}):  &bigModExp{eip2565: true},
+	libcommon.BytesToAddress([]byte{6}):  &bn256AddIstanbul{},
+	libcommon.BytesToAddress([]byte{7}):  &bn256ScalarMulIstanbul{},
+	libcommon.BytesToAddress([]byte{8}):  &bn256PairingIstanbul{},
+	libcommon.BytesToAddress([]byte{9}):  &blake2F{},
+	libcommon.BytesToAddress([]byte{10}): &pointEvaluation{},
+}
+
 // PrecompiledContractsBLS contains the set of pre-compiled Ethereum
 // contracts specified in EIP-2537. These are exported for testing purposes.
 var PrecompiledContractsBLS = map[libcommon.Address]PrecompiledContract{
@@ -111,6 +127,7 @@ var PrecompiledContractsBLS = map[libcommon.Address]PrecompiledContract{
 }
 
 var (
+	PrecompiledAddressesCancun    []libcommon.Address
 	PrecompiledAddressesBerlin    []libcommon.Address
 	PrecompiledAddressesIstanbul  []libcommon.Address
 	PrecompiledAddressesByzantium []libcommon.Address
@@ -130,11 +147,16 @@ func init() {
 	for k := range PrecompiledContractsBerlin {
 		PrecompiledAddressesBerlin = append(PrecompiledAddressesBerlin, k)
 	}
+	for k := range PrecompiledContractsCancun {
+		PrecompiledAddressesCancun = append(PrecompiledAddressesCancun, k)
+	}
 }
 
 // ActivePrecompiles returns the precompiles enabled with the current configuration.
 func ActivePrecompiles(rules *chain.Rules) []libcommon.Address {
 	switch {
+	case rules.IsCancun:
+		return PrecompiledAddressesCancun
 	case rules.IsBerlin:
 		return PrecompiledAddressesBerlin
 	case rules.IsIstanbul:
@@ -1062,3 +1084,15 @@ func (c *bls12381MapG2) Run(input []byte) ([]byte, error) {
 	// Encode the G2 point to 256 bytes
 	return g.EncodePoint(r), nil
 }
+
+// pointEvaluation implements the EIP-4844 point evaluation precompile.
+type pointEvaluation struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (b *pointEvaluation) RequiredGas(input []byte) uint64 {
+	return params.PointEvaluationGas
+}
+
+func (b *pointEvaluation) Run(input []byte) ([]byte, error) {
+	return kzg.PointEvaluationPrecompile(input)
+}
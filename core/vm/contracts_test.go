@@ -67,6 +67,7 @@ var allPrecompiles = map[libcommon.Address]PrecompiledContract{
 	libcommon.BytesToAddress([]byte{16}):   &bls12381Pairing{},
 	libcommon.BytesToAddress([]byte{17}):   &bls12381MapG1{},
 	libcommon.BytesToAddress([]byte{18}):   &bls12381MapG2{},
+	libcommon.BytesToAddress([]byte{20}):   &pointEvaluation{},
 }
 
 // EIP-152 test vectors
@@ -274,6 +275,12 @@ func TestPrecompileBlake2FMalformedInput(t *testing.T) {
 
 func TestPrecompiledEcrecover(t *testing.T) { testJson("ecRecover", "01", t) }
 
+// The point evaluation precompile lives at 0x0a in Cancun, but is tested at 0x14
+// as the BLS precompiles occupy its slot in allPrecompiles.
+func TestPrecompiledPointEvaluation(t *testing.T)      { testJson("pointEvaluation", "14", t) }
+func TestPrecompiledPointEvaluationFail(t *testing.T)  { testJsonFail("pointEvaluation", "14", t) }
+func BenchmarkPrecompiledPointEvaluation(b *testing.B) { benchJson("pointEvaluation", "14", b) }
+
 func testJson(name, addr string, t *testing.T) {
 	tests, err := loadJson(name)
 	if err != nil {
@@ -17,6 +17,7 @@
 package vm
 
 import (
+	"encoding/binary"
 	"fmt"
 	"sort"
 
@@ -28,6 +29,8 @@ import (
 )
 
 var activators = map[int]func(*JumpTable){
+	6780: enable6780,
+	5656: enable5656,
 	3860: enable3860,
 	3855: enable3855,
 	3529: enable3529,
@@ -241,6 +244,43 @@ func enable3860(jt *JumpTable) {
 	jt[CREATE2].dynamicGas = gasCreate2Eip3860
 }
 
+// enable5656 applies EIP-5656 (MCOPY opcode)
+// https://eips.ethereum.org/EIPS/eip-5656
+func enable5656(jt *JumpTable) {
+	jt[MCOPY] = &operation{
+		execute:     opMcopy,
+		constantGas: GasFastestStep,
+		dynamicGas:  gasMcopy,
+		numPop:      3,
+		numPush:     0,
+		memorySize:  memoryMcopy,
+	}
+}
+
+// opMcopy implements the MCOPY opcode (https://eips.ethereum.org/EIPS/eip-5656)
+func opMcopy(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		dst    = scope.Stack.Pop()
+		src    = scope.Stack.Pop()
+		length = scope.Stack.Pop()
+	)
+	// These values are checked for validity during memory expansion
+	scope.Memory.Copy(dst.Uint64(), src.Uint64(), length.Uint64())
+	return nil, nil
+}
+
+// enable6780 applies EIP-6780 (deactivate SELFDESTRUCT)
+// https://eips.ethereum.org/EIPS/eip-6780
+func enable6780(jt *JumpTable) {
+	jt[SELFDESTRUCT] = &operation{
+		execute:     opSelfdestruct6780,
+		dynamicGas:  gasSelfdestructEIP3529,
+		constantGas: params.SelfdestructGasEIP150,
+		numPop:      1,
+		numPush:     0,
+	}
+}
+
 // enableSharding applies mini-danksharding (DATAHASH Opcode)
 // - Adds an opcode that returns the versioned data hash of the tx at a index.
 func enableSharding(jt *JumpTable) {
@@ -263,3 +303,118 @@ func opDataHash(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([
 	}
 	return nil, nil
 }
+
+// enableEOF applies the EVM Object Format v1 rules to the jump table of the
+// EOF code:
+// - EIP-3670: JUMP, JUMPI, PC, CALLCODE and SELFDESTRUCT are rejected, INVALID is defined
+// - EIP-4200: static relative jumps RJUMP, RJUMPI and RJUMPV
+// - EIP-4750: functions CALLF and RETF
+func enableEOF(jt *JumpTable) {
+	undefined := &operation{
+		execute:   opUndefined,
+		undefined: true,
+	}
+	jt[JUMP] = undefined
+	jt[JUMPI] = undefined
+	jt[PC] = undefined
+	jt[CALLCODE] = undefined
+	jt[SELFDESTRUCT] = undefined
+	jt[INVALID] = &operation{
+		execute:  opUndefined,
+		terminal: true,
+	}
+	jt[RJUMP] = &operation{
+		execute:     opRjump,
+		constantGas: GasQuickStep,
+		numPop:      0,
+		numPush:     0,
+	}
+	jt[RJUMPI] = &operation{
+		execute:     opRjumpi,
+		constantGas: 4,
+		numPop:      1,
+		numPush:     0,
+	}
+	jt[RJUMPV] = &operation{
+		execute:     opRjumpv,
+		constantGas: 4,
+		numPop:      1,
+		numPush:     0,
+	}
+	jt[CALLF] = &operation{
+		execute:     opCallf,
+		constantGas: GasFastStep,
+		numPop:      0,
+		numPush:     0,
+	}
+	jt[RETF] = &operation{
+		execute:     opRetf,
+		constantGas: GasFastestStep,
+		numPop:      0,
+		numPush:     0,
+		terminal:    true,
+	}
+}
+
+// opRjump implements the RJUMP opcode (https://eips.ethereum.org/EIPS/eip-4200)
+func opRjump(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	dest := relativeJumpDest(scope.Contract.Code, int(*pc)+1, int(*pc)+3)
+	*pc = uint64(dest) - 1 // pc will be increased by the interpreter loop
+	return nil, nil
+}
+
+// opRjumpi implements the RJUMPI opcode (https://eips.ethereum.org/EIPS/eip-4200)
+func opRjumpi(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	cond := scope.Stack.Pop()
+	if cond.IsZero() {
+		*pc += 2 // skip the immediate
+		return nil, nil
+	}
+	return opRjump(pc, interpreter, scope)
+}
+
+// opRjumpv implements the RJUMPV opcode (https://eips.ethereum.org/EIPS/eip-4200)
+func opRjumpv(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		code  = scope.Contract.Code
+		idx   = scope.Stack.Pop()
+		count = uint64(code[*pc+1])
+	)
+	if !idx.LtUint64(count) {
+		// Index out of bounds, skip the jump table.
+		*pc += 1 + 2*count
+		return nil, nil
+	}
+	dest := relativeJumpDest(code, int(*pc+2+2*idx.Uint64()), int(*pc+2+2*count))
+	*pc = uint64(dest) - 1 // pc will be increased by the interpreter loop
+	return nil, nil
+}
+
+// opCallf implements the CALLF opcode (https://eips.ethereum.org/EIPS/eip-4750)
+func opCallf(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	var (
+		idx = binary.BigEndian.Uint16(scope.Contract.Code[*pc+1:])
+		typ = scope.Contract.container.Types[idx]
+	)
+	if len(scope.Contract.returnStack) >= int(params.StackLimit) {
+		return nil, ErrReturnStackExceeded
+	}
+	if sLen, limit := scope.Stack.Len(), int(params.StackLimit)+int(typ.Input)-int(typ.MaxStackHeight); sLen > limit {
+		return nil, &ErrStackOverflow{stackLen: sLen, limit: limit}
+	}
+	scope.Contract.returnStack = append(scope.Contract.returnStack, *pc+3)
+	*pc = scope.Contract.container.codeOffsets[idx] - 1 // pc will be increased by the interpreter loop
+	return nil, nil
+}
+
+// opRetf implements the RETF opcode (https://eips.ethereum.org/EIPS/eip-4750)
+func opRetf(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
+	if len(scope.Contract.returnStack) == 0 {
+		// Returning from the first code section ends the execution.
+		return nil, errStopToken
+	}
+	retPc := scope.Contract.returnStack[len(scope.Contract.returnStack)-1]
+	scope.Contract.returnStack = scope.Contract.returnStack[:len(scope.Contract.returnStack)-1]
+	*pc = retPc - 1 // pc will be increased by the interpreter loop
+	return nil, nil
+}
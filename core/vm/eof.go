@@ -0,0 +1,296 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// EVM Object Format v1, see https://eips.ethereum.org/EIPS/eip-3540
+//
+//	container := header, body
+//	header    := magic, version, kind_types, types_size, kind_code, num_code_sections, code_size+,
+//	             kind_data, data_size, terminator
+//	body      := types_section, code_section+, data_section
+//
+// The types section holds 4 bytes per code section: inputs, outputs and max stack height,
+// see https://eips.ethereum.org/EIPS/eip-4750
+const (
+	eofFormatByte = 0xef
+	eofMagicByte  = 0x00
+	eof1Version   = 1
+
+	kindTypes      = 1
+	kindCode       = 2
+	kindData       = 3
+	headerTerminal = 0
+
+	offsetVersion   = 2
+	offsetTypesKind = 3
+	offsetCodeKind  = 6
+
+	typesSectionEntrySize = 4
+
+	maxCodeSections   = 1024
+	maxIOCount        = 127
+	maxEOFStackHeight = 1023
+)
+
+var eofMagic = []byte{eofFormatByte, eofMagicByte}
+
+var (
+	ErrInvalidEOFInitcode = errors.New("invalid eof initcode")
+	ErrInvalidEOFCode     = errors.New("invalid eof code")
+
+	errInvalidMagic           = errors.New("invalid magic")
+	errInvalidVersion         = errors.New("invalid version")
+	errMissingTypeHeader      = errors.New("missing type header")
+	errInvalidTypeSize        = errors.New("invalid type section size")
+	errMissingCodeHeader      = errors.New("missing code header")
+	errInvalidCodeHeader      = errors.New("invalid code header")
+	errInvalidCodeSize        = errors.New("invalid code size")
+	errMissingDataHeader      = errors.New("missing data header")
+	errMissingTerminator      = errors.New("missing header terminator")
+	errTooManyInputs          = errors.New("invalid type content, too many inputs")
+	errTooManyOutputs         = errors.New("invalid type content, too many outputs")
+	errInvalidSection0Type    = errors.New("invalid section 0 type, input and output should be zero")
+	errTooLargeMaxStackHeight = errors.New("invalid type content, max stack height exceeds limit")
+	errInvalidContainerSize   = errors.New("invalid container size")
+)
+
+// Container is an EOF container object.
+type Container struct {
+	Types []*FunctionMetadata
+	Code  [][]byte
+	Data  []byte
+
+	codeOffsets []uint64 // position of each code section within the serialised container
+}
+
+// FunctionMetadata is an EOF function signature.
+type FunctionMetadata struct {
+	Input          uint8
+	Output         uint8
+	MaxStackHeight uint16
+}
+
+// HasEOFMagic returns true if code starts with the EOF magic prefix 0xEF00.
+func HasEOFMagic(code []byte) bool {
+	return len(code) >= len(eofMagic) && bytes.Equal(eofMagic, code[:len(eofMagic)])
+}
+
+// isEOFVersion1 returns true if the code's version byte equals eof1Version.
+func isEOFVersion1(code []byte) bool {
+	return HasEOFMagic(code) && len(code) > offsetVersion && code[offsetVersion] == eof1Version
+}
+
+// MarshalBinary encodes an EOF container into binary format.
+func (c *Container) MarshalBinary() []byte {
+	b := make([]byte, 0, 15+2*len(c.Code)+typesSectionEntrySize*len(c.Types))
+	b = append(b, eofMagic...)
+	b = append(b, eof1Version)
+
+	b = append(b, kindTypes)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(c.Types)*typesSectionEntrySize))
+	b = append(b, kindCode)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(c.Code)))
+	for _, code := range c.Code {
+		b = binary.BigEndian.AppendUint16(b, uint16(len(code)))
+	}
+	b = append(b, kindData)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(c.Data)))
+	b = append(b, headerTerminal)
+
+	for _, ty := range c.Types {
+		b = append(b, ty.Input, ty.Output)
+		b = binary.BigEndian.AppendUint16(b, ty.MaxStackHeight)
+	}
+	for _, code := range c.Code {
+		b = append(b, code...)
+	}
+	b = append(b, c.Data...)
+	return b
+}
+
+// UnmarshalBinary decodes an EOF container. It only checks the layout of the
+// container, ValidateCode has to be called to check the code sections.
+func (c *Container) UnmarshalBinary(b []byte) error {
+	if !HasEOFMagic(b) {
+		return fmt.Errorf("%w: want %x", errInvalidMagic, eofMagic)
+	}
+	if !isEOFVersion1(b) {
+		return fmt.Errorf("%w: have %d, want %d", errInvalidVersion, versionByte(b), eof1Version)
+	}
+
+	// Parse the types section header.
+	kind, typesSize, err := parseSection(b, offsetTypesKind)
+	if err != nil {
+		return err
+	}
+	if kind != kindTypes {
+		return fmt.Errorf("%w: found section kind %x instead", errMissingTypeHeader, kind)
+	}
+	if typesSize < typesSectionEntrySize || typesSize%typesSectionEntrySize != 0 {
+		return fmt.Errorf("%w: type section size must be divisible by %d, have %d", errInvalidTypeSize, typesSectionEntrySize, typesSize)
+	}
+	if typesSize/typesSectionEntrySize > maxCodeSections {
+		return fmt.Errorf("%w: type section must not exceed %d entries, have %d", errInvalidTypeSize, maxCodeSections, typesSize/typesSectionEntrySize)
+	}
+
+	// Parse the code section header.
+	kind, codeSizes, err := parseSectionList(b, offsetCodeKind)
+	if err != nil {
+		return err
+	}
+	if kind != kindCode {
+		return fmt.Errorf("%w: found section kind %x instead", errMissingCodeHeader, kind)
+	}
+	if len(codeSizes) != typesSize/typesSectionEntrySize {
+		return fmt.Errorf("%w: mismatch of code sections count and type signatures, types %d, code %d", errInvalidCodeSize, typesSize/typesSectionEntrySize, len(codeSizes))
+	}
+
+	// Parse the data section header.
+	offsetDataKind := offsetCodeKind + 2 + 1 + 2*len(codeSizes)
+	kind, dataSize, err := parseSection(b, offsetDataKind)
+	if err != nil {
+		return err
+	}
+	if kind != kindData {
+		return fmt.Errorf("%w: found section %x instead", errMissingDataHeader, kind)
+	}
+
+	// Check for the terminator.
+	offsetTerminator := offsetDataKind + 3
+	if len(b) <= offsetTerminator {
+		return fmt.Errorf("%w: invalid offset terminator", io.ErrUnexpectedEOF)
+	}
+	if b[offsetTerminator] != headerTerminal {
+		return fmt.Errorf("%w: have %x", errMissingTerminator, b[offsetTerminator])
+	}
+
+	// Verify the overall container size.
+	expectedSize := offsetTerminator + 1 + typesSize + dataSize
+	for _, size := range codeSizes {
+		expectedSize += size
+	}
+	if len(b) != expectedSize {
+		return fmt.Errorf("%w: have %d, want %d", errInvalidContainerSize, len(b), expectedSize)
+	}
+
+	// Parse the types section.
+	idx := offsetTerminator + 1
+	types := make([]*FunctionMetadata, 0, typesSize/typesSectionEntrySize)
+	for i := 0; i < typesSize/typesSectionEntrySize; i++ {
+		sig := &FunctionMetadata{
+			Input:          b[idx+i*typesSectionEntrySize],
+			Output:         b[idx+i*typesSectionEntrySize+1],
+			MaxStackHeight: binary.BigEndian.Uint16(b[idx+i*typesSectionEntrySize+2:]),
+		}
+		if sig.Input > maxIOCount {
+			return fmt.Errorf("%w for section %d: have %d", errTooManyInputs, i, sig.Input)
+		}
+		if sig.Output > maxIOCount {
+			return fmt.Errorf("%w for section %d: have %d", errTooManyOutputs, i, sig.Output)
+		}
+		if sig.MaxStackHeight > maxEOFStackHeight {
+			return fmt.Errorf("%w for section %d: have %d", errTooLargeMaxStackHeight, i, sig.MaxStackHeight)
+		}
+		types = append(types, sig)
+	}
+	if types[0].Input != 0 || types[0].Output != 0 {
+		return fmt.Errorf("%w: have %d, %d", errInvalidSection0Type, types[0].Input, types[0].Output)
+	}
+	c.Types = types
+
+	// Parse the code sections.
+	idx += typesSize
+	code := make([][]byte, len(codeSizes))
+	offsets := make([]uint64, len(codeSizes))
+	for i, size := range codeSizes {
+		if size == 0 {
+			return fmt.Errorf("%w for section %d: size must not be 0", errInvalidCodeSize, i)
+		}
+		code[i] = b[idx : idx+size]
+		offsets[i] = uint64(idx)
+		idx += size
+	}
+	c.Code = code
+	c.codeOffsets = offsets
+
+	// Parse the data section.
+	c.Data = b[idx : idx+dataSize]
+
+	return nil
+}
+
+// ValidateCode validates each code section of the container against the EOF v1 rule set.
+func (c *Container) ValidateCode() error {
+	return c.validateCode(&eofInstructionSet)
+}
+
+func (c *Container) validateCode(jt *JumpTable) error {
+	for i, code := range c.Code {
+		if err := validateCode(code, i, c.Types, jt); err != nil {
+			return fmt.Errorf("code section %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// parseEOF decodes and validates an EOF container.
+func parseEOF(b []byte) (*Container, error) {
+	var c Container
+	if err := c.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	if err := c.ValidateCode(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// parseSection decodes a (kind, size) pair from an EOF header.
+func parseSection(b []byte, idx int) (kind, size int, err error) {
+	if idx+3 > len(b) {
+		return 0, 0, io.ErrUnexpectedEOF
+	}
+	kind, size, err = parseSectionKind(b, idx), parseUint16(b, idx+1), nil
+	return
+}
+
+// parseSectionList decodes a (kind, len, []codeSize) section list from an EOF header.
+func parseSectionList(b []byte, idx int) (kind int, list []int, err error) {
+	if idx+3 > len(b) {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	kind = parseSectionKind(b, idx)
+	count := parseUint16(b, idx+1)
+	if count == 0 || count > maxCodeSections {
+		return 0, nil, fmt.Errorf("%w: have %d sections", errInvalidCodeHeader, count)
+	}
+	if idx+3+2*count > len(b) {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	list = make([]int, count)
+	for i := 0; i < count; i++ {
+		list[i] = parseUint16(b, idx+3+2*i)
+	}
+	return kind, list, nil
+}
+
+func parseSectionKind(b []byte, idx int) int {
+	return int(b[idx])
+}
+
+func parseUint16(b []byte, idx int) int {
+	return int(binary.BigEndian.Uint16(b[idx:]))
+}
+
+func versionByte(b []byte) int {
+	if len(b) <= offsetVersion {
+		return -1
+	}
+	return int(b[offsetVersion])
+}
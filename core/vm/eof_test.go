@@ -0,0 +1,332 @@
+package vm
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+)
+
+func TestEOFMarshaling(t *testing.T) {
+	for i, test := range []struct {
+		want Container
+		err  error
+	}{
+		{
+			want: Container{
+				Types: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+				Code:  [][]byte{hexutility.MustDecodeHex("604200")},
+				Data:  []byte{0x01, 0x02, 0x03},
+			},
+		},
+		{
+			want: Container{
+				Types: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+				Code:  [][]byte{hexutility.MustDecodeHex("604200")},
+				Data:  []byte{},
+			},
+		},
+		{
+			want: Container{
+				Types: []*FunctionMetadata{
+					{Input: 0, Output: 0, MaxStackHeight: 1},
+					{Input: 2, Output: 3, MaxStackHeight: 4},
+					{Input: 1, Output: 1, MaxStackHeight: 1},
+				},
+				Code: [][]byte{
+					hexutility.MustDecodeHex("604200"),
+					hexutility.MustDecodeHex("6042604200"),
+					hexutility.MustDecodeHex("00"),
+				},
+				Data: []byte{},
+			},
+		},
+	} {
+		var (
+			b   = test.want.MarshalBinary()
+			got Container
+		)
+		if err := got.UnmarshalBinary(b); err != nil && err != test.err {
+			t.Fatalf("test %d: got error \"%v\", want \"%v\"", i, err, test.err)
+		}
+		got.codeOffsets = nil
+		if !reflect.DeepEqual(got, test.want) {
+			t.Fatalf("test %d: got %+v, want %+v", i, got, test.want)
+		}
+	}
+}
+
+func TestEOFUnmarshalInvalid(t *testing.T) {
+	for i, test := range []struct {
+		code string
+		err  error
+	}{
+		{"", errInvalidMagic},
+		{"ef01", errInvalidMagic},
+		{"ef00", errInvalidVersion},
+		{"ef0002", errInvalidVersion},
+		{"ef0001", io.ErrUnexpectedEOF},
+		{"ef000102000402000100030000000000000000", errMissingTypeHeader},
+		{"ef000101000302000100030000000000000000", errInvalidTypeSize},
+		{"ef000101000403000100030000000000000000", errMissingCodeHeader},
+		{"ef000101000402000000", errInvalidCodeHeader},
+		{"ef000101000802000100030000000000000000", errInvalidCodeSize},
+		{"ef000101000402000100030400000000000000", errMissingDataHeader},
+		{"ef000101000402000100030300000100000000", errMissingTerminator},
+		{"ef00010100040200010003030000000000000000", errInvalidContainerSize},
+		{"ef000101000402000100030300000000000000", errInvalidContainerSize},
+		{"ef000101000402000100030300000001000000604200", errInvalidSection0Type},
+		{"ef000101000402000100030300000000000400604200", errTooLargeMaxStackHeight},
+		{"ef000101000402000100000300000000000000", errInvalidCodeSize},
+	} {
+		var c Container
+		err := c.UnmarshalBinary(hexutility.MustDecodeHex(test.code))
+		if !errors.Is(err, test.err) {
+			t.Errorf("test %d: got error \"%v\", want \"%v\"", i, err, test.err)
+		}
+	}
+}
+
+func TestEOFValidateCode(t *testing.T) {
+	for i, test := range []struct {
+		code     []byte
+		section  int
+		metadata []*FunctionMetadata
+		err      error
+	}{
+		{
+			code: []byte{
+				byte(CALLER),
+				byte(POP),
+				byte(STOP),
+			},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+		},
+		{
+			code: []byte{
+				byte(CALLF), 0x00, 0x00,
+				byte(STOP),
+			},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}},
+		},
+		{
+			code: []byte{
+				byte(ADDRESS),
+				byte(CALLF), 0x00, 0x00,
+				byte(STOP),
+			},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+		},
+		{
+			code: []byte{
+				byte(CALLER),
+				byte(POP),
+			},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			err:      errInvalidCodeTermination,
+		},
+		{
+			code: []byte{
+				byte(RJUMP),
+				byte(0xff),
+				byte(0xfe), // into the immediate
+				byte(STOP),
+			},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}},
+			err:      errInvalidJumpDest,
+		},
+		{
+			code: []byte{
+				byte(RJUMP),
+				byte(0x00),
+				byte(0x02),
+				byte(CALLER),
+				byte(POP),
+				byte(STOP),
+			},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}},
+			err:      errUnreachableCode,
+		},
+		{
+			code: []byte{
+				byte(PUSH1),
+				byte(0x42),
+				byte(ADD),
+				byte(STOP),
+			},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			err:      errEOFStackUnderflow,
+		},
+		{
+			code: []byte{
+				byte(PUSH1),
+				byte(0x42),
+				byte(POP),
+				byte(STOP),
+			},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 2}},
+			err:      errInvalidMaxStackHeight,
+		},
+		{
+			code: []byte{
+				byte(PUSH0),
+				byte(RJUMPI),
+				byte(0x00),
+				byte(0x01),
+				byte(PUSH1),
+				byte(0x42), // jumps to here
+				byte(POP),
+				byte(STOP),
+			},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			err:      errInvalidJumpDest,
+		},
+		{
+			code: []byte{
+				byte(PUSH0),
+				byte(RJUMPV),
+				byte(0x02),
+				byte(0x00),
+				byte(0x01),
+				byte(0x00),
+				byte(0x02),
+				byte(PUSH1),
+				byte(0x42), // jumps to here
+				byte(POP),  // and here
+				byte(STOP),
+			},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			err:      errInvalidJumpDest,
+		},
+		{
+			code: []byte{
+				byte(PUSH0),
+				byte(RJUMPV),
+				byte(0x00),
+				byte(STOP),
+			},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			err:      errInvalidRjumpvCount,
+		},
+		{
+			code: []byte{
+				byte(PUSH0),
+				byte(RJUMPV),
+				byte(0x02),
+				byte(0x00),
+				byte(0x01),
+				byte(0x00),
+				byte(0x02),
+				byte(CALLER),
+				byte(CALLER),
+				byte(STOP),
+			},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			err:      errConflictingStack,
+		},
+		{
+			code: []byte{
+				byte(PUSH0),
+				byte(RJUMPV),
+				byte(0x02),
+				byte(0x00),
+				byte(0x01),
+				byte(0x00),
+				byte(0x02),
+				byte(PUSH0),
+				byte(POP),
+				byte(STOP),
+			},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			err:      errConflictingStack,
+		},
+		{
+			code: []byte{
+				byte(PUSH0),
+				byte(RJUMPV),
+				byte(0x02),
+				byte(0x00),
+				byte(0x01),
+				byte(0x00),
+				byte(0x02),
+				byte(STOP),
+				byte(STOP),
+				byte(STOP),
+			},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+		},
+		{
+			code: []byte{
+				byte(RETF),
+			},
+			section:  1,
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}, {Input: 1, Output: 0, MaxStackHeight: 1}},
+			err:      errInvalidOutputs,
+		},
+		{
+			code: []byte{
+				byte(DUP1),
+				byte(ADD),
+				byte(RETF),
+			},
+			section:  1,
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}, {Input: 1, Output: 1, MaxStackHeight: 2}},
+		},
+		{
+			code: []byte{
+				byte(CALLF), 0x00, 0x02,
+				byte(STOP),
+			},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}, {Input: 0, Output: 0, MaxStackHeight: 0}},
+			err:      errInvalidSectionArgument,
+		},
+		{
+			code: []byte{
+				byte(PUSH2), 0x00,
+			},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			err:      errTruncatedImmediate,
+		},
+		{
+			code: []byte{
+				byte(PC),
+				byte(STOP),
+			},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 1}},
+			err:      errUndefinedInstruction,
+		},
+		{
+			code: []byte{
+				byte(INVALID),
+			},
+			metadata: []*FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 0}},
+		},
+	} {
+		err := validateCode(test.code, test.section, test.metadata, &eofInstructionSet)
+		if !errors.Is(err, test.err) {
+			t.Errorf("test %d (%s): unexpected error (want: %v, got: %v)", i, hexutility.Bytes(test.code), test.err, err)
+		}
+	}
+}
+
+func TestEOFLegacyInstructionSet(t *testing.T) {
+	// The EOF instructions must stay undefined for the legacy code.
+	for _, op := range []OpCode{RJUMP, RJUMPI, RJUMPV, CALLF, RETF} {
+		if !pragueInstructionSet[op].undefined {
+			t.Errorf("%s is defined for legacy code", op)
+		}
+		if eofInstructionSet[op].undefined {
+			t.Errorf("%s is undefined for EOF code", op)
+		}
+	}
+	for _, op := range []OpCode{JUMP, JUMPI, PC, CALLCODE, SELFDESTRUCT} {
+		if pragueInstructionSet[op].undefined {
+			t.Errorf("%s is undefined for legacy code", op)
+		}
+		if !eofInstructionSet[op].undefined {
+			t.Errorf("%s is defined for EOF code", op)
+		}
+	}
+}
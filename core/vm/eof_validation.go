@@ -0,0 +1,194 @@
+package vm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var (
+	errUndefinedInstruction   = errors.New("undefined instruction")
+	errTruncatedImmediate     = errors.New("truncated immediate")
+	errInvalidSectionArgument = errors.New("invalid section argument")
+	errInvalidJumpDest        = errors.New("invalid jump destination")
+	errInvalidCodeTermination = errors.New("invalid code termination")
+	errInvalidRjumpvCount     = errors.New("invalid number of items in jump table")
+	errConflictingStack       = errors.New("conflicting stack height")
+	errInvalidOutputs         = errors.New("invalid number of outputs")
+	errInvalidMaxStackHeight  = errors.New("invalid max stack height")
+	errUnreachableCode        = errors.New("unreachable code")
+	errEOFStackUnderflow      = errors.New("stack underflow")
+	errEOFStackOverflow       = errors.New("stack overflow")
+)
+
+// validateCode validates a code section of an EOF container, see
+// https://eips.ethereum.org/EIPS/eip-3670 and https://eips.ethereum.org/EIPS/eip-4200:
+// - all instructions are defined and their immediates are not truncated
+// - relative jumps land on an instruction inside the section
+// - CALLF refers to an existing section
+// - the section ends with a terminating instruction
+//
+// It then checks the stack heights with validateControlFlow.
+func validateCode(code []byte, section int, metadata []*FunctionMetadata, jt *JumpTable) error {
+	var (
+		i            = 0
+		instructions = 0
+		op           OpCode
+		isInstr      = make([]bool, len(code))
+		jumpDests    []int
+	)
+	for i < len(code) {
+		instructions++
+		isInstr[i] = true
+		op = OpCode(code[i])
+		if jt[op].undefined {
+			return fmt.Errorf("%w: op %s, pos %d", errUndefinedInstruction, op, i)
+		}
+		switch {
+		case op >= PUSH1 && op <= PUSH32:
+			size := int(op - PUSH1 + 1)
+			if len(code) <= i+size {
+				return fmt.Errorf("%w: op %s, pos %d", errTruncatedImmediate, op, i)
+			}
+			i += size
+		case op == RJUMP || op == RJUMPI:
+			if len(code) <= i+2 {
+				return fmt.Errorf("%w: op %s, pos %d", errTruncatedImmediate, op, i)
+			}
+			jumpDests = append(jumpDests, relativeJumpDest(code, i+1, i+3))
+			i += 2
+		case op == RJUMPV:
+			if len(code) <= i+1 {
+				return fmt.Errorf("%w: jump table size missing, op %s, pos %d", errTruncatedImmediate, op, i)
+			}
+			count := int(code[i+1])
+			if count == 0 {
+				return fmt.Errorf("%w: pos %d", errInvalidRjumpvCount, i)
+			}
+			if len(code) <= i+1+2*count {
+				return fmt.Errorf("%w: jump table truncated, op %s, pos %d", errTruncatedImmediate, op, i)
+			}
+			for j := 0; j < count; j++ {
+				jumpDests = append(jumpDests, relativeJumpDest(code, i+2+2*j, i+2+2*count))
+			}
+			i += 1 + 2*count
+		case op == CALLF:
+			if len(code) <= i+2 {
+				return fmt.Errorf("%w: op %s, pos %d", errTruncatedImmediate, op, i)
+			}
+			if arg := int(binary.BigEndian.Uint16(code[i+1:])); arg >= len(metadata) {
+				return fmt.Errorf("%w: arg %d, last %d, pos %d", errInvalidSectionArgument, arg, len(metadata)-1, i)
+			}
+			i += 2
+		}
+		i++
+	}
+	// Code sections may not "fall through" and require proper termination.
+	if !jt[op].terminal && op != RJUMP {
+		return fmt.Errorf("%w: end with %s", errInvalidCodeTermination, op)
+	}
+	for _, dest := range jumpDests {
+		if dest < 0 || dest >= len(code) || !isInstr[dest] {
+			return fmt.Errorf("%w: dest %d", errInvalidJumpDest, dest)
+		}
+	}
+	reached, maxHeight, err := validateControlFlow(code, section, metadata, jt)
+	if err != nil {
+		return err
+	}
+	if reached != instructions {
+		return fmt.Errorf("%w: reached %d of %d instructions", errUnreachableCode, reached, instructions)
+	}
+	if have := int(metadata[section].MaxStackHeight); have != maxHeight {
+		return fmt.Errorf("%w: have %d, want %d", errInvalidMaxStackHeight, have, maxHeight)
+	}
+	return nil
+}
+
+// relativeJumpDest returns the destination of the signed 16-bit jump offset at
+// code[imm], relative to the position of the next instruction.
+func relativeJumpDest(code []byte, imm, next int) int {
+	return next + int(int16(binary.BigEndian.Uint16(code[imm:])))
+}
+
+// validateControlFlow walks all code paths of the section, tracking the exact
+// stack height before each instruction, see https://eips.ethereum.org/EIPS/eip-5450.
+// It returns the number of instructions reached and the maximum stack height.
+func validateControlFlow(code []byte, section int, metadata []*FunctionMetadata, jt *JumpTable) (int, int, error) {
+	type item struct {
+		pos    int
+		height int
+	}
+	var (
+		heights   = make(map[int]int)
+		worklist  = []item{{0, int(metadata[section].Input)}}
+		maxHeight = int(metadata[section].Input)
+	)
+	for len(worklist) > 0 {
+		pos, height := worklist[len(worklist)-1].pos, worklist[len(worklist)-1].height
+		worklist = worklist[:len(worklist)-1]
+
+	outer:
+		for pos < len(code) {
+			op := OpCode(code[pos])
+
+			// Check if pos has already been visited; if so, the stack heights should be the same.
+			if want, ok := heights[pos]; ok {
+				if height != want {
+					return 0, 0, fmt.Errorf("%w: have %d, want %d, pos %d", errConflictingStack, height, want, pos)
+				}
+				// Already visited this path and stack height matches.
+				break
+			}
+			heights[pos] = height
+
+			// Validate height for current op and update as needed.
+			if want, have := jt[op].numPop, height; want > have {
+				return 0, 0, fmt.Errorf("%w: at pos %d", errEOFStackUnderflow, pos)
+			}
+			if want, have := jt[op].maxStack, height; want < have {
+				return 0, 0, fmt.Errorf("%w: at pos %d", errEOFStackOverflow, pos)
+			}
+			height += jt[op].numPush - jt[op].numPop
+
+			switch {
+			case op == CALLF:
+				arg := binary.BigEndian.Uint16(code[pos+1:])
+				if want, have := int(metadata[arg].Input), height; want > have {
+					return 0, 0, fmt.Errorf("%w: at pos %d", errEOFStackUnderflow, pos)
+				}
+				height += int(metadata[arg].Output) - int(metadata[arg].Input)
+				pos += 3
+			case op == RETF:
+				if have, want := height, int(metadata[section].Output); have != want {
+					return 0, 0, fmt.Errorf("%w: have %d, want %d, at pos %d", errInvalidOutputs, have, want, pos)
+				}
+				break outer
+			case op == RJUMP:
+				pos = relativeJumpDest(code, pos+1, pos+3)
+			case op == RJUMPI:
+				worklist = append(worklist, item{relativeJumpDest(code, pos+1, pos+3), height})
+				pos += 3
+			case op == RJUMPV:
+				count := int(code[pos+1])
+				for i := 0; i < count; i++ {
+					worklist = append(worklist, item{relativeJumpDest(code, pos+2+2*i, pos+2+2*count), height})
+				}
+				pos += 2 + 2*count
+			case op >= PUSH1 && op <= PUSH32:
+				pos += 1 + int(op-PUSH1+1)
+			case jt[op].terminal:
+				break outer
+			default:
+				pos++
+			}
+			if height > maxHeight {
+				maxHeight = height
+			}
+		}
+	}
+	if maxHeight > maxEOFStackHeight {
+		return 0, 0, fmt.Errorf("%w: max stack height %d", errEOFStackOverflow, maxHeight)
+	}
+	return len(heights), maxHeight, nil
+}
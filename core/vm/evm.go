@@ -17,6 +17,7 @@
 package vm
 
 import (
+	"fmt"
 	"sync/atomic"
 
 	"github.com/holiman/uint256"
@@ -37,6 +38,8 @@ var emptyCodeHash = crypto.Keccak256Hash(nil)
 func (evm *EVM) precompile(addr libcommon.Address) (PrecompiledContract, bool) {
 	var precompiles map[libcommon.Address]PrecompiledContract
 	switch {
+	case evm.chainRules.IsCancun:
+		precompiles = PrecompiledContractsCancun
 	case evm.chainRules.IsBerlin:
 		precompiles = PrecompiledContractsBerlin
 	case evm.chainRules.IsIstanbul:
@@ -390,7 +393,16 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 		return nil, address, gas, nil
 	}
 
-	ret, err = run(evm, contract, nil, false)
+	// EOF initcode has to be valid, see https://eips.ethereum.org/EIPS/eip-3540
+	isEOF := evm.chainRules.IsPrague && HasEOFMagic(codeAndHash.code)
+	if isEOF {
+		if _, verr := parseEOF(codeAndHash.code); verr != nil {
+			err = fmt.Errorf("%w: %v", ErrInvalidEOFInitcode, verr)
+		}
+	}
+	if err == nil {
+		ret, err = run(evm, contract, nil, false)
+	}
 
 	// EIP-170: Contract code size limit
 	if err == nil && evm.chainRules.IsSpuriousDragon && len(ret) > params.MaxCodeSize {
@@ -401,8 +413,13 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 		}
 	}
 
-	// Reject code starting with 0xEF if EIP-3541 is enabled.
-	if err == nil && evm.chainRules.IsLondon && len(ret) >= 1 && ret[0] == 0xEF {
+	if err == nil && isEOF {
+		// EOF initcode has to deploy valid EOF code.
+		if _, verr := parseEOF(ret); verr != nil {
+			err = fmt.Errorf("%w: %v", ErrInvalidEOFCode, verr)
+		}
+	} else if err == nil && evm.chainRules.IsLondon && len(ret) >= 1 && ret[0] == 0xEF {
+		// Reject code starting with 0xEF if EIP-3541 is enabled.
 		err = ErrInvalidCode
 	}
 	// if the contract creation ran successfully and no errors were returned
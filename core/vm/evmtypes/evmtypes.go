@@ -83,6 +83,7 @@ type IntraBlockState interface {
 	SetTransientState(addr libcommon.Address, key libcommon.Hash, value uint256.Int)
 
 	Selfdestruct(libcommon.Address) bool
+	Selfdestruct6780(libcommon.Address)
 	HasSelfdestructed(libcommon.Address) bool
 
 	// Exist reports whether the given account exists in state.
@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/holiman/uint256"
@@ -610,6 +611,111 @@ func TestOpTstore(t *testing.T) {
 	}
 }
 
+func TestOpMCopy(t *testing.T) {
+	// Test cases from https://eips.ethereum.org/EIPS/eip-5656#test-cases
+	for i, tc := range []struct {
+		dst, src, len string
+		pre           string
+		want          string
+		wantGas       uint64
+	}{
+		{ // MCOPY 0 32 32 - copy 32 bytes from offset 32 to offset 0.
+			dst: "0x0", src: "0x20", len: "0x20",
+			pre:     "0000000000000000000000000000000000000000000000000000000000000000 000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+			want:    "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f 000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+			wantGas: 6,
+		},
+		{ // MCOPY 0 0 32 - copy 32 bytes from offset 0 to offset 0.
+			dst: "0x0", src: "0x0", len: "0x20",
+			pre:     "0101010101010101010101010101010101010101010101010101010101010101",
+			want:    "0101010101010101010101010101010101010101010101010101010101010101",
+			wantGas: 6,
+		},
+		{ // MCOPY 0 1 8 - copy 8 bytes from offset 1 to offset 0 (overlapping).
+			dst: "0x0", src: "0x1", len: "0x8",
+			pre:     "000102030405060708 000000000000000000000000000000000000000000000000",
+			want:    "010203040506070808 000000000000000000000000000000000000000000000000",
+			wantGas: 6,
+		},
+		{ // MCOPY 1 0 8 - copy 8 bytes from offset 0 to offset 1 (overlapping).
+			dst: "0x1", src: "0x0", len: "0x8",
+			pre:     "000102030405060708 000000000000000000000000000000000000000000000000",
+			want:    "000001020304050607 000000000000000000000000000000000000000000000000",
+			wantGas: 6,
+		},
+		{ // MCOPY 0xFFFFFFFFFFFF 0xFFFFFFFFFFFF 0 - zero bytes from and to out-of-bounds offsets.
+			dst: "0xFFFFFFFFFFFF", src: "0xFFFFFFFFFFFF", len: "0x0",
+			pre:     "11",
+			want:    "11",
+			wantGas: 3,
+		},
+		{ // MCOPY 0 0xFFFFFFFFFFFF 0 - zero bytes from an out-of-bounds offset.
+			dst: "0x0", src: "0xFFFFFFFFFFFF", len: "0x0",
+			pre:     "11",
+			want:    "11",
+			wantGas: 3,
+		},
+		{ // MCOPY 0 2^64 1 - source outside of the uint64 space.
+			dst: "0x0", src: "0x10000000000000000", len: "0x1",
+		},
+		{ // MCOPY 2^64 0 1 - destination outside of the uint64 space.
+			dst: "0x10000000000000000", src: "0x0", len: "0x1",
+		},
+		{ // MCOPY 0x10 0x20 1 - expands memory to cover the source.
+			dst: "0x10", src: "0x20", len: "0x1",
+			want:    "0000000000000000000000000000000000000000000000000000000000000000 0000000000000000000000000000000000000000000000000000000000000000",
+			wantGas: 12,
+		},
+		{ // MCOPY 0x10 0x19 1 - expands memory to cover both regions.
+			dst: "0x10", src: "0x19", len: "0x1",
+			want:    "0000000000000000000000000000000000000000000000000000000000000000",
+			wantGas: 9,
+		},
+	} {
+		var (
+			env            = NewEVM(evmtypes.BlockContext{}, evmtypes.TxContext{}, nil, params.TestChainConfig, Config{})
+			stack          = stack.New()
+			mem            = NewMemory()
+			evmInterpreter = NewEVMInterpreter(env, env.Config())
+			pc             = uint64(0)
+		)
+		env.interpreter = evmInterpreter
+		data := common.FromHex(strings.ReplaceAll(tc.pre, " ", ""))
+		mem.Resize(uint64(len(data)))
+		mem.Set(0, uint64(len(data)), data)
+
+		length, _ := uint256.FromHex(tc.len)
+		src, _ := uint256.FromHex(tc.src)
+		dst, _ := uint256.FromHex(tc.dst)
+		stack.Push(length)
+		stack.Push(src)
+		stack.Push(dst)
+
+		memSize, overflow := memoryMcopy(stack)
+		if overflow {
+			if tc.wantGas != 0 {
+				t.Errorf("case %d: unexpected memory size overflow", i)
+			}
+			continue
+		}
+		memorySize := ToWordSize(memSize) * 32
+		dynamicGas, err := gasMcopy(env, nil, stack, mem, memorySize)
+		if err != nil {
+			t.Fatalf("case %d: %v", i, err)
+		}
+		if haveGas := GasFastestStep + dynamicGas; haveGas != tc.wantGas {
+			t.Errorf("case %d: gas wrong, want %d have %d", i, tc.wantGas, haveGas)
+		}
+		if memorySize > 0 {
+			mem.Resize(memorySize)
+		}
+		opMcopy(&pc, evmInterpreter, &ScopeContext{mem, stack, nil})
+		if want := common.FromHex(strings.ReplaceAll(tc.want, " ", "")); !bytes.Equal(want, mem.Data()) {
+			t.Errorf("case %d: memory wrong\nwant: %x\nhave: %x", i, want, mem.Data())
+		}
+	}
+}
+
 func BenchmarkOpKeccak256(bench *testing.B) {
 	var (
 		env            = NewEVM(evmtypes.BlockContext{}, evmtypes.TxContext{}, nil, params.TestChainConfig, Config{})
@@ -92,6 +92,7 @@ type keccakState interface {
 type EVMInterpreter struct {
 	*VM
 	jt    *JumpTable // EVM instruction table
+	eofJt *JumpTable // EVM instruction table for the EOF code, nil before the EOF is activated
 	depth int
 }
 
@@ -160,12 +161,18 @@ func NewEVMInterpreter(evm VMInterpreter, cfg Config) *EVMInterpreter {
 		}
 	}
 
+	var eofJt *JumpTable
+	if evm.ChainRules().IsPrague {
+		eofJt = &eofInstructionSet
+	}
+
 	return &EVMInterpreter{
 		VM: &VM{
 			evm: evm,
 			cfg: cfg,
 		},
-		jt: jt,
+		jt:    jt,
+		eofJt: eofJt,
 	}
 }
 
@@ -200,6 +207,7 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 
 	var (
 		op          OpCode // current opcode
+		jt          = in.jt
 		mem         = pool.Get().(*Memory)
 		locStack    = stack.New()
 		callContext = &ScopeContext{
@@ -227,6 +235,18 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 	defer stack.ReturnNormalStack(locStack)
 	contract.Input = input
 
+	// The EOF code is validated on deployment, so only the header is decoded
+	// here to locate the code sections.
+	if in.eofJt != nil && HasEOFMagic(contract.Code) {
+		var c Container
+		if err = c.UnmarshalBinary(contract.Code); err == nil {
+			jt = in.eofJt
+			contract.container = &c
+			_pc = c.codeOffsets[0]
+		}
+		err = nil
+	}
+
 	if in.cfg.Debug {
 		defer func() {
 			if err != nil {
@@ -255,7 +275,7 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		// Get the operation from the jump table and validate the stack to ensure there are
 		// enough stack items available to perform the operation.
 		op = contract.GetOp(_pc)
-		operation := in.jt[op]
+		operation := jt[op]
 		cost = operation.constantGas // For tracing
 		// Validate stack
 		if sLen := locStack.Len(); sLen < operation.numPop {
@@ -48,6 +48,11 @@ type operation struct {
 	opNum   int // only for push, swap, dup
 	// memorySize returns the memory size required for the operation
 	memorySize memorySizeFunc
+
+	// undefined denotes if the instruction is not officially defined in the jump table
+	undefined bool
+	// terminal denotes if the instruction ends the execution of a code section
+	terminal bool
 }
 
 var (
@@ -63,6 +68,7 @@ var (
 	shanghaiInstructionSet         = newShanghaiInstructionSet()
 	cancunInstructionSet           = newCancunInstructionSet()
 	pragueInstructionSet           = newPragueInstructionSet()
+	eofInstructionSet              = newEOFInstructionSetForPrague()
 )
 
 // JumpTable contains the EVM opcodes supported at a given fork.
@@ -86,6 +92,15 @@ func validateAndFillMaxStack(jt *JumpTable) {
 	}
 }
 
+// newEOFInstructionSetForPrague returns the prague instructions as seen by
+// the code of EOF containers.
+func newEOFInstructionSetForPrague() JumpTable {
+	instructionSet := newPragueInstructionSet()
+	enableEOF(&instructionSet)
+	validateAndFillMaxStack(&instructionSet)
+	return instructionSet
+}
+
 // newPragueInstructionSet returns the frontier, homestead, byzantium,
 // constantinople, istanbul, petersburg, berlin, london, paris, shanghai,
 // cancun, and prague instructions.
@@ -100,6 +115,10 @@ func newPragueInstructionSet() JumpTable {
 // and cancun instructions.
 func newCancunInstructionSet() JumpTable {
 	instructionSet := newShanghaiInstructionSet()
+	enable1153(&instructionSet)     // Transient storage opcodes https://eips.ethereum.org/EIPS/eip-1153
+	enable5656(&instructionSet)     // MCOPY opcode https://eips.ethereum.org/EIPS/eip-5656
+	enable6780(&instructionSet)     // SELFDESTRUCT only in same transaction https://eips.ethereum.org/EIPS/eip-6780
+	enableSharding(&instructionSet) // DATAHASH opcode https://eips.ethereum.org/EIPS/eip-4844
 	validateAndFillMaxStack(&instructionSet)
 	return instructionSet
 }
@@ -218,6 +237,7 @@ func newByzantiumInstructionSet() JumpTable {
 		numPop:     2,
 		numPush:    0,
 		memorySize: memoryRevert,
+		terminal:   true,
 	}
 	validateAndFillMaxStack(&instructionSet)
 	return instructionSet
@@ -271,6 +291,7 @@ func newFrontierInstructionSet() JumpTable {
 			constantGas: 0,
 			numPop:      0,
 			numPush:     0,
+			terminal:    true,
 		},
 		ADD: {
 			execute:     opAdd,
@@ -1187,6 +1208,7 @@ func newFrontierInstructionSet() JumpTable {
 			numPop:     2,
 			numPush:    0,
 			memorySize: memoryReturn,
+			terminal:   true,
 		},
 		SELFDESTRUCT: {
 			execute:    opSelfdestruct,
@@ -1199,7 +1221,7 @@ func newFrontierInstructionSet() JumpTable {
 	// Fill all unassigned slots with opUndefined.
 	for i, entry := range tbl {
 		if entry == nil {
-			tbl[i] = &operation{execute: opUndefined}
+			tbl[i] = &operation{execute: opUndefined, undefined: true}
 		}
 	}
 
@@ -78,6 +78,15 @@ func (m *Memory) Resize(size uint64) {
 	m.store = append(m.store, zeroes[:l]...)
 }
 
+// Copy copies size bytes of memory from src to dst. The regions may overlap.
+// Memory must already have been expanded to cover both of them.
+func (m *Memory) Copy(dst, src, size uint64) {
+	if size == 0 {
+		return
+	}
+	copy(m.store[dst:], m.store[src:src+size])
+}
+
 func (m *Memory) Reset() {
 	m.lastGasCost = 0
 	m.store = m.store[:0]
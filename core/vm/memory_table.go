@@ -38,6 +38,14 @@ func memoryExtCodeCopy(stack *stack.Stack) (uint64, bool) {
 	return calcMemSize64(stack.Back(1), stack.Back(3))
 }
 
+func memoryMcopy(stack *stack.Stack) (uint64, bool) {
+	mStart := stack.Back(0) // stack[0]: dest
+	if stack.Back(1).Gt(mStart) {
+		mStart = stack.Back(1) // stack[1]: source
+	}
+	return calcMemSize64(mStart, stack.Back(2)) // stack[2]: length
+}
+
 func memoryMLoad(stack *stack.Stack) (uint64, bool) {
 	return calcMemSize64WithUint(stack.Back(0), 32)
 }
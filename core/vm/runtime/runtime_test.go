@@ -17,13 +17,16 @@
 package runtime
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"os"
 	"strings"
 	"testing"
 
+	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/chain"
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/kv/memdb"
@@ -36,6 +39,7 @@ import (
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/core/vm"
 	"github.com/ledgerwatch/erigon/eth/tracers/logger"
+	"github.com/ledgerwatch/erigon/params"
 )
 
 func TestDefaults(t *testing.T) {
@@ -108,7 +112,7 @@ func TestExecute(t *testing.T) {
 func TestCall(t *testing.T) {
 	_, tx := memdb.NewTestTx(t)
 	state := state.New(state.NewDbStateReader(tx))
-	address := libcommon.HexToAddress("0x0a")
+	address := libcommon.HexToAddress("0xaa")
 	state.SetCode(address, []byte{
 		byte(vm.PUSH1), 10,
 		byte(vm.PUSH1), 0,
@@ -606,3 +610,187 @@ func TestEip2929Cases(t *testing.T) {
 			"account (cheap)", code)
 	}
 }
+
+func TestCancunOpcodes(t *testing.T) {
+	code := []byte{
+		// tstore(0, 42)
+		byte(vm.PUSH1), 42, byte(vm.PUSH1), 0, byte(vm.TSTORE),
+		// mstore(32, tload(0))
+		byte(vm.PUSH1), 0, byte(vm.TLOAD), byte(vm.PUSH1), 32, byte(vm.MSTORE),
+		// mcopy(0, 32, 32)
+		byte(vm.PUSH1), 32, byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.MCOPY),
+		// return(0, 32)
+		byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.RETURN),
+	}
+
+	ret, _, err := Execute(code, nil, nil, 0)
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if num := new(big.Int).SetBytes(ret); num.Cmp(big.NewInt(42)) != 0 {
+		t.Error("Expected 42, got", num)
+	}
+
+	// The opcodes are undefined before Cancun
+	shanghai := *params.TestChainConfig
+	shanghai.ShanghaiTime = big.NewInt(0)
+	if _, _, err := Execute(code, nil, &Config{ChainConfig: &shanghai}, 0); err == nil {
+		t.Error("expected invalid opcode error before Cancun")
+	}
+}
+
+func TestEip6780(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	ibs := state.New(state.NewDbStateReader(tx))
+	cfg := &Config{State: ibs}
+	beneficiary := libcommon.HexToAddress("0xbb")
+	selfdestruct := []byte{byte(vm.PUSH1), 0xbb, byte(vm.SELFDESTRUCT)}
+
+	// A contract created in an earlier transaction only sends its balance away
+	address := libcommon.HexToAddress("0xaa")
+	ibs.SetCode(address, selfdestruct)
+	ibs.AddBalance(address, uint256.NewInt(100))
+	if _, _, err := Call(address, nil, cfg); err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if ibs.HasSelfdestructed(address) {
+		t.Error("expected the contract to survive SELFDESTRUCT")
+	}
+	if !bytes.Equal(ibs.GetCode(address), selfdestruct) {
+		t.Error("expected the contract to keep its code")
+	}
+	if !ibs.GetBalance(address).IsZero() || ibs.GetBalance(beneficiary).Uint64() != 100 {
+		t.Errorf("expected the balance to be sent to the beneficiary, have %d", ibs.GetBalance(beneficiary).Uint64())
+	}
+
+	// A contract which self-destructs in its constructor is destroyed
+	_, created, _, err := Create(selfdestruct, cfg, 0)
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if !ibs.HasSelfdestructed(created) {
+		t.Error("expected the contract to be destroyed by SELFDESTRUCT in its constructor")
+	}
+
+	// As is a contract which is called in the transaction that created it
+	initCode := []byte{
+		byte(vm.PUSH3), byte(vm.PUSH1), 0xbb, byte(vm.SELFDESTRUCT),
+		byte(vm.PUSH1), 0, byte(vm.MSTORE),
+		byte(vm.PUSH1), 3, byte(vm.PUSH1), 29, byte(vm.RETURN),
+	}
+	_, created, _, err = Create(initCode, cfg, 0)
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if _, _, err := Call(created, nil, cfg); err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if !ibs.HasSelfdestructed(created) {
+		t.Error("expected the contract to be destroyed in its creation transaction")
+	}
+
+	// But not by a later transaction
+	_, created, _, err = Create(initCode, cfg, 0)
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	rules := cfg.ChainConfig.Rules(0, 0)
+	if err := ibs.FinalizeTx(rules, state.NewNoopWriter()); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Call(created, nil, cfg); err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if ibs.HasSelfdestructed(created) {
+		t.Error("expected the contract to survive SELFDESTRUCT in a later transaction")
+	}
+}
+
+func TestEOF(t *testing.T) {
+	// Doubles 42 in a function and returns the result, skipping an INVALID with RJUMPI.
+	double := vm.Container{
+		Types: []*vm.FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: 2}, {Input: 1, Output: 1, MaxStackHeight: 2}},
+		Code: [][]byte{
+			{
+				byte(vm.PUSH1), 1, byte(vm.RJUMPI), 0x00, 0x01, byte(vm.INVALID),
+				byte(vm.PUSH1), 42, byte(vm.CALLF), 0x00, 0x01,
+				byte(vm.PUSH1), 0, byte(vm.MSTORE),
+				byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.RETURN),
+			},
+			{
+				byte(vm.DUP1), byte(vm.ADD), byte(vm.RETF),
+			},
+		},
+		Data: []byte{},
+	}
+	code := double.MarshalBinary()
+	if err := double.ValidateCode(); err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	ret, _, err := Execute(code, nil, nil, 0)
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if num := new(big.Int).SetBytes(ret); num.Cmp(big.NewInt(84)) != 0 {
+		t.Error("Expected 84, got", num)
+	}
+
+	// The EOF code is rejected before Prague
+	cancun := *params.TestChainConfig
+	cancun.ShanghaiTime = big.NewInt(0)
+	cancun.CancunTime = big.NewInt(0)
+	if _, _, err := Execute(code, nil, &Config{ChainConfig: &cancun}, 0); err == nil {
+		t.Error("expected invalid opcode error before Prague")
+	}
+
+	// initCode returns the container in its data section
+	initCode := func(deployed []byte, maxStackHeight uint16) []byte {
+		c := vm.Container{
+			Types: []*vm.FunctionMetadata{{Input: 0, Output: 0, MaxStackHeight: maxStackHeight}},
+			Code: [][]byte{{
+				byte(vm.PUSH1), byte(len(deployed)), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.CODECOPY),
+				byte(vm.PUSH1), byte(len(deployed)), byte(vm.PUSH1), 0, byte(vm.RETURN),
+			}},
+			Data: deployed,
+		}
+		// The data section is at the end of the container
+		c.Code[0][3] = byte(len(c.MarshalBinary()) - len(deployed))
+		return c.MarshalBinary()
+	}
+
+	_, tx := memdb.NewTestTx(t)
+	ibs := state.New(state.NewDbStateReader(tx))
+	cfg := &Config{State: ibs}
+	_, created, _, err := Create(initCode(code, 3), cfg, 0)
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if !bytes.Equal(ibs.GetCode(created), code) {
+		t.Fatalf("unexpected code deployed: %x", ibs.GetCode(created))
+	}
+	ret, _, err = Call(created, nil, cfg)
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if num := new(big.Int).SetBytes(ret); num.Cmp(big.NewInt(84)) != 0 {
+		t.Error("Expected 84, got", num)
+	}
+
+	// Invalid initcode is not executed
+	if _, _, _, err := Create(initCode(code, 4), cfg, 0); !errors.Is(err, vm.ErrInvalidEOFInitcode) {
+		t.Errorf("expected %v, got %v", vm.ErrInvalidEOFInitcode, err)
+	}
+	// EOF initcode can't deploy legacy code
+	legacy := []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.RETURN), byte(vm.STOP)}
+	if _, _, _, err := Create(initCode(legacy, 3), cfg, 0); !errors.Is(err, vm.ErrInvalidEOFCode) {
+		t.Errorf("expected %v, got %v", vm.ErrInvalidEOFCode, err)
+	}
+	// And legacy initcode can't deploy EOF code
+	legacyInit := append([]byte{
+		byte(vm.PUSH1), byte(len(code)), byte(vm.PUSH1), 12, byte(vm.PUSH1), 0, byte(vm.CODECOPY),
+		byte(vm.PUSH1), byte(len(code)), byte(vm.PUSH1), 0, byte(vm.RETURN),
+	}, code...)
+	if _, _, _, err := Create(legacyInit, cfg, 0); !errors.Is(err, vm.ErrInvalidCode) {
+		t.Errorf("expected %v, got %v", vm.ErrInvalidCode, err)
+	}
+}
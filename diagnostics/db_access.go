@@ -32,6 +32,14 @@ func SetupDbAccess(ctx *cli.Context) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		writeDbRead(w, r, dataDir)
 	})
+	http.HandleFunc("/debug/metrics/db/decoded", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		writeDbDecoded(w, r, dataDir)
+	})
+	http.HandleFunc("/debug/metrics/db/compare", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		writeDbCompare(w, r, dataDir)
+	})
 }
 
 func writeDbList(w io.Writer, dataDir string) {
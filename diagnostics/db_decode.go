@@ -0,0 +1,656 @@
+package diagnostics
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcfg"
+	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
+
+	"github.com/ledgerwatch/erigon/common/dbutils"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/ethdb/prune"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/turbo/services"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+)
+
+const (
+	defaultDecodedLimit = 16
+	maxDecodedLimit     = 256 // same as the raw reads
+)
+
+// dbQuery is the parsed arguments of a decoded read. The views use the ones they need.
+type dbQuery struct {
+	key      []byte // position to read from, the next of the previous page
+	limit    int
+	block    *uint64
+	address  *libcommon.Address
+	location *libcommon.Hash
+}
+
+// dbPage is a page of decoded records, Next is the key argument to read the next page with.
+type dbPage struct {
+	Items []interface{} `json:"items"`
+	Next  string        `json:"next,omitempty"`
+}
+
+type dbView func(tx kv.Tx, q *dbQuery) (interface{}, error)
+
+var dbViews = map[string]dbView{
+	"headers":   viewHeaders,
+	"bodies":    viewBodies,
+	"receipts":  viewReceipts,
+	"accounts":  viewAccounts,
+	"storage":   viewStorage,
+	"stages":    viewStages,
+	"prune":     viewPrune,
+	"snapshots": viewSnapshots,
+}
+
+func writeDbDecoded(w io.Writer, r *http.Request, dataDir string) {
+	db, q, ok := parseDbQuery(w, r)
+	if !ok {
+		return
+	}
+	viewName := r.Form.Get("view")
+	view, ok := dbViews[viewName]
+	if !ok {
+		fmt.Fprintf(w, "ERROR: view argument must be one of headers, bodies, receipts, accounts, storage, stages, prune, snapshots, got [%s]\n", viewName)
+		return
+	}
+	var result interface{}
+	if err := db.View(context.Background(), func(tx kv.Tx) error {
+		var e error
+		result, e = view(tx, q)
+		return e
+	}); err != nil {
+		fmt.Fprintf(w, "ERROR: reading %s in %s: %v\n", viewName, r.Form.Get("path"), err)
+		return
+	}
+	writeJSON(w, result)
+}
+
+func writeDbCompare(w io.Writer, r *http.Request, dataDir string) {
+	db, q, ok := parseDbQuery(w, r)
+	if !ok {
+		return
+	}
+	if q.block == nil {
+		fmt.Fprintf(w, "ERROR: block argument is required - specify the block to compare\n")
+		return
+	}
+	viewName := r.Form.Get("view")
+	if viewName != "headers" && viewName != "bodies" {
+		fmt.Fprintf(w, "ERROR: view argument must be headers or bodies, got [%s]\n", viewName)
+		return
+	}
+	var result *snapshotComparison
+	if err := db.View(context.Background(), func(tx kv.Tx) error {
+		var e error
+		result, e = compareWithSnapshot(tx, q, viewName == "bodies")
+		return e
+	}); err != nil {
+		fmt.Fprintf(w, "ERROR: comparing block %d in %s: %v\n", *q.block, r.Form.Get("path"), err)
+		return
+	}
+	writeJSON(w, result)
+}
+
+func writeJSON(w io.Writer, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		fmt.Fprintf(w, "ERROR: encoding result: %v\n", err)
+		return
+	}
+	fmt.Fprintf(w, "SUCCESS\n%s\n", b)
+}
+
+func parseDbQuery(w io.Writer, r *http.Request) (kv.RoDB, *dbQuery, bool) {
+	if err := r.ParseForm(); err != nil {
+		fmt.Fprintf(w, "ERROR: parsing arguments: %v\n", err)
+		return nil, nil, false
+	}
+	path := r.Form.Get("path")
+	if path == "" {
+		fmt.Fprintf(w, "ERROR: path argument is required - specify the relative path to an MDBX database directory")
+		return nil, nil, false
+	}
+	db, ok := mdbx.PathDbMap()[path]
+	if !ok {
+		fmt.Fprintf(w, "ERROR: path %s is not in the list of allowed paths", path)
+		return nil, nil, false
+	}
+	q := &dbQuery{limit: defaultDecodedLimit}
+	var err error
+	if keyHex := r.Form.Get("key"); keyHex != "" {
+		if q.key, err = hex.DecodeString(keyHex); err != nil {
+			fmt.Fprintf(w, "ERROR: key [%s] argument may only contain hexadecimal digits: %v\n", keyHex, err)
+			return nil, nil, false
+		}
+	}
+	if limit := r.Form.Get("limit"); limit != "" {
+		if q.limit, err = strconv.Atoi(limit); err != nil || q.limit <= 0 || q.limit > maxDecodedLimit {
+			fmt.Fprintf(w, "ERROR: limit [%s] argument must be a number from 1 to %d\n", limit, maxDecodedLimit)
+			return nil, nil, false
+		}
+	}
+	if block := r.Form.Get("block"); block != "" {
+		n, err := strconv.ParseUint(block, 10, 64)
+		if err != nil {
+			fmt.Fprintf(w, "ERROR: block [%s] argument must be a number: %v\n", block, err)
+			return nil, nil, false
+		}
+		q.block = &n
+	}
+	if address := r.Form.Get("address"); address != "" {
+		if !libcommon.IsHexAddress(address) {
+			fmt.Fprintf(w, "ERROR: address [%s] argument must be a hex address\n", address)
+			return nil, nil, false
+		}
+		a := libcommon.HexToAddress(address)
+		q.address = &a
+	}
+	if location := r.Form.Get("location"); location != "" {
+		b, err := hex.DecodeString(location)
+		if err != nil || len(b) > 32 {
+			fmt.Fprintf(w, "ERROR: location [%s] argument must be a hex storage slot\n", location)
+			return nil, nil, false
+		}
+		l := libcommon.BytesToHash(b)
+		q.location = &l
+	}
+	return db, q, true
+}
+
+// blockKey is where the block tables keyed by number (and hash) are read from: the key
+// argument if given, the block argument otherwise.
+func (q *dbQuery) blockKey() []byte {
+	if (q.key == nil) && (q.block != nil) {
+		return dbutils.EncodeBlockNumber(*q.block)
+	}
+	return q.key
+}
+
+// pageTable decodes the entries of a table from key on, up to the limit of the query.
+// The entries decoded to nil are left out.
+func pageTable(tx kv.Tx, table string, key []byte, limit int, decode func(k, v []byte) (interface{}, error)) (*dbPage, error) {
+	c, err := tx.Cursor(table)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	page := &dbPage{Items: []interface{}{}}
+	for k, v, err := c.Seek(key); k != nil; k, v, err = c.Next() {
+		if err != nil {
+			return nil, err
+		}
+		if len(page.Items) == limit {
+			page.Next = hex.EncodeToString(k)
+			break
+		}
+		item, err := decode(k, v)
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s %x: %w", table, k, err)
+		}
+		if item != nil {
+			page.Items = append(page.Items, item)
+		}
+	}
+	return page, nil
+}
+
+type blockRecord struct {
+	Number    uint64         `json:"number"`
+	Hash      libcommon.Hash `json:"hash"`
+	Canonical bool           `json:"canonical"`
+}
+
+func newBlockRecord(tx kv.Tx, k []byte) (blockRecord, error) {
+	if len(k) != 8+length.Hash {
+		return blockRecord{}, fmt.Errorf("unexpected key length %d", len(k))
+	}
+	r := blockRecord{Number: binary.BigEndian.Uint64(k), Hash: libcommon.BytesToHash(k[8:])}
+	canonical, err := rawdb.ReadCanonicalHash(tx, r.Number)
+	if err != nil {
+		return r, err
+	}
+	r.Canonical = canonical == r.Hash
+	return r, nil
+}
+
+type headerRecord struct {
+	blockRecord
+	Header *types.Header `json:"header"`
+}
+
+func viewHeaders(tx kv.Tx, q *dbQuery) (interface{}, error) {
+	return pageTable(tx, kv.Headers, q.blockKey(), q.limit, func(k, v []byte) (interface{}, error) {
+		r, err := newBlockRecord(tx, k)
+		if err != nil {
+			return nil, err
+		}
+		header := new(types.Header)
+		if err := rlp.DecodeBytes(v, header); err != nil {
+			return nil, err
+		}
+		return &headerRecord{blockRecord: r, Header: header}, nil
+	})
+}
+
+type bodyRecord struct {
+	blockRecord
+	BaseTxId    uint64              `json:"baseTxId"`
+	TxAmount    uint32              `json:"txAmount"` // including the system transactions at the start and the end of the block
+	Uncles      []*types.Header     `json:"uncles"`
+	Withdrawals []*types.Withdrawal `json:"withdrawals"`
+}
+
+func viewBodies(tx kv.Tx, q *dbQuery) (interface{}, error) {
+	return pageTable(tx, kv.BlockBody, q.blockKey(), q.limit, func(k, v []byte) (interface{}, error) {
+		r, err := newBlockRecord(tx, k)
+		if err != nil {
+			return nil, err
+		}
+		body := new(types.BodyForStorage)
+		if err := rlp.DecodeBytes(v, body); err != nil {
+			return nil, err
+		}
+		return &bodyRecord{blockRecord: r, BaseTxId: body.BaseTxId, TxAmount: body.TxAmount, Uncles: body.Uncles, Withdrawals: body.Withdrawals}, nil
+	})
+}
+
+type receiptsRecord struct {
+	Number   uint64         `json:"number"`
+	Receipts types.Receipts `json:"receipts"` // as stored, without the fields derived from the block
+}
+
+func viewReceipts(tx kv.Tx, q *dbQuery) (interface{}, error) {
+	return pageTable(tx, kv.Receipts, q.blockKey(), q.limit, func(k, v []byte) (interface{}, error) {
+		number := binary.BigEndian.Uint64(k)
+		receipts := rawdb.ReadRawReceipts(tx, number)
+		if receipts == nil {
+			return nil, fmt.Errorf("undecodable receipts")
+		}
+		return &receiptsRecord{Number: number, Receipts: receipts}, nil
+	})
+}
+
+type accountRecord struct {
+	Address     libcommon.Address `json:"address"`
+	Nonce       uint64            `json:"nonce"`
+	Balance     string            `json:"balance"`
+	CodeHash    libcommon.Hash    `json:"codeHash"`
+	Incarnation uint64            `json:"incarnation"`
+	AsOf        *uint64           `json:"asOf,omitempty"` // the block after which the account is read, the latest state if nil
+}
+
+func newAccountRecord(address libcommon.Address, a *accounts.Account, asOf *uint64) *accountRecord {
+	return &accountRecord{Address: address, Nonce: a.Nonce, Balance: a.Balance.ToBig().String(), CodeHash: a.CodeHash, Incarnation: a.Incarnation, AsOf: asOf}
+}
+
+// historicalState reads the state after the block from the history, nil for the latest state.
+func historicalState(tx kv.Tx, block *uint64) (*state.PlainState, error) {
+	if block == nil {
+		return nil, nil
+	}
+	if historyV3, err := kvcfg.HistoryV3.Enabled(tx); err != nil {
+		return nil, err
+	} else if historyV3 {
+		return nil, fmt.Errorf("historical state is not supported with history v3")
+	}
+	return state.NewPlainState(tx, *block+1, nil), nil
+}
+
+// viewAccounts decodes the latest accounts, or a single account as of a block.
+func viewAccounts(tx kv.Tx, q *dbQuery) (interface{}, error) {
+	if q.address != nil {
+		history, err := historicalState(tx, q.block)
+		if err != nil {
+			return nil, err
+		}
+		var a *accounts.Account
+		if history != nil {
+			a, err = history.ReadAccountData(*q.address)
+		} else {
+			a, err = state.NewPlainStateReader(tx).ReadAccountData(*q.address)
+		}
+		if err != nil {
+			return nil, err
+		}
+		page := &dbPage{Items: []interface{}{}}
+		if a != nil {
+			page.Items = append(page.Items, newAccountRecord(*q.address, a, q.block))
+		}
+		return page, nil
+	}
+	if q.block != nil {
+		return nil, fmt.Errorf("address argument is required to read an account as of a block")
+	}
+	c, err := tx.CursorDupSort(kv.PlainState)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	page := &dbPage{Items: []interface{}{}}
+	// The storage of a contract follows its account, skip it key by key
+	for k, v, err := c.Seek(q.key); k != nil; k, v, err = c.NextNoDup() {
+		if err != nil {
+			return nil, err
+		}
+		if len(k) != length.Addr {
+			continue
+		}
+		if len(page.Items) == q.limit {
+			page.Next = hex.EncodeToString(k)
+			break
+		}
+		var a accounts.Account
+		if err := a.DecodeForStorage(v); err != nil {
+			return nil, fmt.Errorf("decoding account %x: %w", k, err)
+		}
+		page.Items = append(page.Items, newAccountRecord(libcommon.BytesToAddress(k), &a, nil))
+	}
+	return page, nil
+}
+
+type storageRecord struct {
+	Location libcommon.Hash `json:"location"`
+	Value    string         `json:"value"`
+	AsOf     *uint64        `json:"asOf,omitempty"`
+}
+
+// viewStorage decodes the latest storage of a contract from the location on, or a single slot as of a block.
+// The next page is read with the location argument, not the key one.
+func viewStorage(tx kv.Tx, q *dbQuery) (interface{}, error) {
+	if q.address == nil {
+		return nil, fmt.Errorf("address argument is required to read the storage")
+	}
+	history, err := historicalState(tx, q.block)
+	if err != nil {
+		return nil, err
+	}
+	page := &dbPage{Items: []interface{}{}}
+	if history != nil {
+		if q.location == nil {
+			return nil, fmt.Errorf("location argument is required to read the storage as of a block")
+		}
+		a, err := history.ReadAccountData(*q.address)
+		if (err != nil) || (a == nil) {
+			return page, err
+		}
+		v, err := history.ReadAccountStorage(*q.address, a.Incarnation, q.location)
+		if err != nil {
+			return nil, err
+		}
+		page.Items = append(page.Items, &storageRecord{Location: *q.location, Value: hex.EncodeToString(v), AsOf: q.block})
+		return page, nil
+	}
+	a, err := state.NewPlainStateReader(tx).ReadAccountData(*q.address)
+	if (err != nil) || (a == nil) {
+		return page, err
+	}
+	c, err := tx.CursorDupSort(kv.PlainState)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	prefix := make([]byte, length.Addr+length.Incarnation)
+	copy(prefix, q.address[:])
+	binary.BigEndian.PutUint64(prefix[length.Addr:], a.Incarnation)
+	var from []byte
+	if q.location != nil {
+		from = q.location[:]
+	}
+	for v, err := c.SeekBothRange(prefix, from); v != nil; _, v, err = c.NextDup() {
+		if err != nil {
+			return nil, err
+		}
+		if len(v) < length.Hash {
+			return nil, fmt.Errorf("unexpected storage value length %d", len(v))
+		}
+		if len(page.Items) == q.limit {
+			page.Next = hex.EncodeToString(v[:length.Hash])
+			break
+		}
+		page.Items = append(page.Items, &storageRecord{Location: libcommon.BytesToHash(v[:length.Hash]), Value: hex.EncodeToString(v[length.Hash:])})
+	}
+	return page, nil
+}
+
+type stageRecord struct {
+	Stage         stages.SyncStage `json:"stage"`
+	Progress      uint64           `json:"progress"`
+	PruneProgress uint64           `json:"pruneProgress"`
+}
+
+func viewStages(tx kv.Tx, q *dbQuery) (interface{}, error) {
+	records := make([]stageRecord, 0, len(stages.AllStages))
+	for _, stage := range stages.AllStages {
+		progress, err := stages.GetStageProgress(tx, stage)
+		if err != nil {
+			return nil, err
+		}
+		pruneProgress, err := stages.GetStagePruneProgress(tx, stage)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, stageRecord{Stage: stage, Progress: progress, PruneProgress: pruneProgress})
+	}
+	return records, nil
+}
+
+type pruneAmountRecord struct {
+	Enabled bool   `json:"enabled"`
+	PruneTo uint64 `json:"pruneTo,omitempty"` // the first block kept at the current progress of the Finish stage
+}
+
+type pruneRecord struct {
+	Mode       string            `json:"mode"`
+	Head       uint64            `json:"head"`
+	History    pruneAmountRecord `json:"history"`
+	Receipts   pruneAmountRecord `json:"receipts"`
+	TxIndex    pruneAmountRecord `json:"txIndex"`
+	CallTraces pruneAmountRecord `json:"callTraces"`
+}
+
+func viewPrune(tx kv.Tx, q *dbQuery) (interface{}, error) {
+	mode, err := prune.Get(tx)
+	if err != nil {
+		return nil, err
+	}
+	head, err := stages.GetStageProgress(tx, stages.Finish)
+	if err != nil {
+		return nil, err
+	}
+	amount := func(a prune.BlockAmount) pruneAmountRecord {
+		if (a == nil) || !a.Enabled() {
+			return pruneAmountRecord{}
+		}
+		return pruneAmountRecord{Enabled: true, PruneTo: a.PruneTo(head)}
+	}
+	return &pruneRecord{
+		Mode:       mode.String(),
+		Head:       head,
+		History:    amount(mode.History),
+		Receipts:   amount(mode.Receipts),
+		TxIndex:    amount(mode.TxIndex),
+		CallTraces: amount(mode.CallTraces),
+	}, nil
+}
+
+var (
+	blockReaderLock sync.RWMutex
+	blockReader     services.FullBlockReader
+	blockSnapshots  *snapshotsync.RoSnapshots
+)
+
+// SetBlockReader makes the block reader and the snapshots of the running node available to the
+// snapshots views, which read the files the node keeps open instead of opening them again.
+func SetBlockReader(br services.FullBlockReader, sn *snapshotsync.RoSnapshots) {
+	blockReaderLock.Lock()
+	defer blockReaderLock.Unlock()
+	blockReader, blockSnapshots = br, sn
+}
+
+func nodeBlockReader() (services.FullBlockReader, *snapshotsync.RoSnapshots, error) {
+	blockReaderLock.RLock()
+	defer blockReaderLock.RUnlock()
+	if blockReader == nil || blockSnapshots == nil {
+		return nil, nil, fmt.Errorf("block snapshots are not open, the node is not running")
+	}
+	return blockReader, blockSnapshots, nil
+}
+
+type snapshotsRecord struct {
+	Dir             string   `json:"dir"`
+	SegmentsMax     uint64   `json:"segmentsMax"`
+	IndicesMax      uint64   `json:"indicesMax"`
+	BlocksAvailable uint64   `json:"blocksAvailable"`
+	Ranges          []string `json:"ranges"` // in thousands of blocks
+	Files           []string `json:"files"`
+	DbFirstHeader   *uint64  `json:"dbFirstHeader"` // the blocks before are only in the snapshots
+	DbFirstBody     *uint64  `json:"dbFirstBody"`
+}
+
+func firstBlock(tx kv.Tx, table string) (*uint64, error) {
+	k, err := kv.FirstKey(tx, table)
+	if (err != nil) || (len(k) < 8) {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint64(k)
+	return &n, nil
+}
+
+func viewSnapshots(tx kv.Tx, q *dbQuery) (interface{}, error) {
+	_, sn, err := nodeBlockReader()
+	if err != nil {
+		return nil, err
+	}
+	r := &snapshotsRecord{Dir: sn.Dir(), SegmentsMax: sn.SegmentsMax(), IndicesMax: sn.IndicesMax(), BlocksAvailable: sn.BlocksAvailable(), Ranges: []string{}, Files: sn.Files()}
+	for _, rng := range sn.Ranges() {
+		r.Ranges = append(r.Ranges, rng.String())
+	}
+	if r.DbFirstHeader, err = firstBlock(tx, kv.Headers); err != nil {
+		return nil, err
+	}
+	if r.DbFirstBody, err = firstBlock(tx, kv.BlockBody); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// frozenBlock is a header or a body as found in the db or in a snapshot.
+type frozenBlock struct {
+	Hash         libcommon.Hash   `json:"hash"`
+	Header       *types.Header    `json:"header,omitempty"`
+	Transactions []libcommon.Hash `json:"transactions,omitempty"`
+	Uncles       []libcommon.Hash `json:"uncles,omitempty"`
+	Withdrawals  int              `json:"withdrawals,omitempty"`
+	rlp          []byte
+}
+
+type snapshotComparison struct {
+	Block    uint64       `json:"block"`
+	Frozen   bool         `json:"frozen"` // the block is in the snapshots
+	Equal    bool         `json:"equal"`  // the db and the snapshot copies are encoded the same
+	Db       *frozenBlock `json:"db"`
+	Snapshot *frozenBlock `json:"snapshot"`
+}
+
+func newFrozenHeader(h *types.Header) (*frozenBlock, error) {
+	if h == nil {
+		return nil, nil
+	}
+	enc, err := rlp.EncodeToBytes(h)
+	if err != nil {
+		return nil, err
+	}
+	return &frozenBlock{Hash: h.Hash(), Header: h, rlp: enc}, nil
+}
+
+func newFrozenBody(hash libcommon.Hash, body *types.Body) (*frozenBlock, error) {
+	if body == nil {
+		return nil, nil
+	}
+	enc, err := rlp.EncodeToBytes(body)
+	if err != nil {
+		return nil, err
+	}
+	b := &frozenBlock{Hash: hash, Transactions: []libcommon.Hash{}, Uncles: []libcommon.Hash{}, Withdrawals: len(body.Withdrawals), rlp: enc}
+	for _, txn := range body.Transactions {
+		b.Transactions = append(b.Transactions, txn.Hash())
+	}
+	for _, uncle := range body.Uncles {
+		b.Uncles = append(b.Uncles, uncle.Hash())
+	}
+	return b, nil
+}
+
+// compareWithSnapshot reads the canonical header or body of a block from the db and from the snapshots.
+func compareWithSnapshot(tx kv.Tx, q *dbQuery, body bool) (*snapshotComparison, error) {
+	blockReader, sn, err := nodeBlockReader()
+	if err != nil {
+		return nil, err
+	}
+	ctx, number := context.Background(), *q.block
+	r := &snapshotComparison{Block: number}
+	if r.Frozen, err = sn.ViewHeaders(number, func(*snapshotsync.HeaderSegment) error { return nil }); err != nil {
+		return nil, err
+	}
+	dbHeader := rawdb.ReadHeaderByNumber(tx, number)
+	if !body {
+		if r.Db, err = newFrozenHeader(dbHeader); err != nil {
+			return nil, err
+		}
+		if r.Frozen {
+			h, err := blockReader.HeaderByNumber(ctx, tx, number)
+			if err != nil {
+				return nil, err
+			}
+			if r.Snapshot, err = newFrozenHeader(h); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		if dbHeader != nil {
+			dbBody, err := rawdb.ReadBodyWithTransactions(tx, dbHeader.Hash(), number)
+			if err != nil {
+				return nil, err
+			}
+			if r.Db, err = newFrozenBody(dbHeader.Hash(), dbBody); err != nil {
+				return nil, err
+			}
+		}
+		if r.Frozen {
+			hash, err := blockReader.CanonicalHash(ctx, tx, number)
+			if err != nil {
+				return nil, err
+			}
+			snapshotBody, err := blockReader.BodyWithTransactions(ctx, tx, hash, number)
+			if err != nil {
+				return nil, err
+			}
+			if r.Snapshot, err = newFrozenBody(hash, snapshotBody); err != nil {
+				return nil, err
+			}
+		}
+	}
+	r.Equal = (r.Db != nil) && (r.Snapshot != nil) && bytes.Equal(r.Db.rlp, r.Snapshot.rlp)
+	return r, nil
+}
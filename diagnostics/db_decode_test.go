@@ -0,0 +1,224 @@
+package diagnostics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/common/dbutils"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/eth/ethconfig"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/ethdb/prune"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+)
+
+var (
+	testAddress = libcommon.Address{1}
+	testSlots   = []libcommon.Hash{{1}, {2}, {3}}
+)
+
+func newTestDb(t *testing.T) (string, []*types.Header) {
+	dataDir := t.TempDir()
+	path := filepath.Join(dataDir, "chaindata")
+	db := mdbx.NewMDBX(log.New()).Path(path).MustOpen()
+	t.Cleanup(db.Close)
+	sn := snapshotsync.NewRoSnapshots(ethconfig.Snapshot{Enabled: true}, filepath.Join(dataDir, "snapshots"))
+	SetBlockReader(snapshotsync.NewBlockReaderWithSnapshots(sn, false), sn)
+	t.Cleanup(func() { SetBlockReader(nil, nil) })
+
+	tx, err := db.BeginRw(context.Background())
+	require.NoError(t, err)
+	defer tx.Rollback()
+	var headers []*types.Header
+	for i := 0; i < 3; i++ {
+		h := &types.Header{Number: big.NewInt(int64(i)), Difficulty: big.NewInt(1), Extra: []byte{byte(i)}}
+		if i > 0 {
+			h.ParentHash = headers[i-1].Hash()
+		}
+		headers = append(headers, h)
+		rawdb.WriteHeader(tx, h)
+		require.NoError(t, rawdb.WriteCanonicalHash(tx, h.Hash(), h.Number.Uint64()))
+		require.NoError(t, rawdb.WriteBody(tx, h.Hash(), h.Number.Uint64(), &types.Body{}))
+	}
+	// A header of the same height off the canonical chain
+	rawdb.WriteHeader(tx, &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(2)})
+
+	a := accounts.Account{Nonce: 5, Balance: *uint256.NewInt(100), Incarnation: 1}
+	enc := make([]byte, a.EncodingLengthForStorage())
+	a.EncodeForStorage(enc)
+	require.NoError(t, tx.Put(kv.PlainState, testAddress[:], enc))
+	for i, slot := range testSlots {
+		require.NoError(t, tx.Put(kv.PlainState, dbutils.PlainGenerateCompositeStorageKey(testAddress[:], 1, slot[:]), []byte{byte(i + 1)}))
+	}
+	require.NoError(t, tx.Put(kv.PlainState, libcommon.Address{2}.Bytes(), enc))
+
+	require.NoError(t, stages.SaveStageProgress(tx, stages.Finish, 2))
+	require.NoError(t, prune.Override(tx, prune.Mode{Initialised: true, History: prune.Distance(1), Receipts: prune.Distance(90_000), TxIndex: prune.Distance(90_000), CallTraces: prune.Distance(90_000)}))
+	require.NoError(t, tx.Commit())
+	return dataDir, headers
+}
+
+// get calls the handler with the arguments and decodes its JSON result.
+func get(t *testing.T, handler func(w *bytes.Buffer, q url.Values), args url.Values, result interface{}) {
+	var w bytes.Buffer
+	handler(&w, args)
+	out := w.String()
+	require.True(t, strings.HasPrefix(out, "SUCCESS\n"), out)
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(out, "SUCCESS\n")), result))
+}
+
+func TestDbDecoded(t *testing.T) {
+	dataDir, headers := newTestDb(t)
+	path := filepath.Join(dataDir, "chaindata")
+	decoded := func(w *bytes.Buffer, q url.Values) {
+		q.Set("path", path)
+		writeDbDecoded(w, httptest.NewRequest("GET", "/debug/metrics/db/decoded?"+q.Encode(), nil), dataDir)
+	}
+
+	var page struct {
+		Items []struct {
+			Number    uint64         `json:"number"`
+			Hash      libcommon.Hash `json:"hash"`
+			Canonical bool           `json:"canonical"`
+		} `json:"items"`
+		Next string `json:"next"`
+	}
+	get(t, decoded, url.Values{"view": {"headers"}, "limit": {"2"}}, &page)
+	require.Len(t, page.Items, 2)
+	require.Equal(t, headers[0].Hash(), page.Items[0].Hash)
+	require.True(t, page.Items[0].Canonical)
+	require.NotEmpty(t, page.Next)
+	items, next := page.Items, page.Next
+	page.Items, page.Next = nil, ""
+	get(t, decoded, url.Values{"view": {"headers"}, "key": {next}}, &page)
+	require.Len(t, page.Items, 2)
+	require.Empty(t, page.Next)
+	// Only the header off the canonical chain is not canonical
+	canonical := 0
+	for _, item := range append(items, page.Items...) {
+		if item.Canonical {
+			canonical++
+		}
+	}
+	require.Equal(t, 3, canonical)
+
+	get(t, decoded, url.Values{"view": {"bodies"}, "block": {"2"}}, &page)
+	require.Len(t, page.Items, 1)
+	require.Equal(t, headers[2].Hash(), page.Items[0].Hash)
+
+	var accountsPage struct {
+		Items []struct {
+			Address     libcommon.Address `json:"address"`
+			Nonce       uint64            `json:"nonce"`
+			Balance     string            `json:"balance"`
+			Incarnation uint64            `json:"incarnation"`
+		} `json:"items"`
+	}
+	get(t, decoded, url.Values{"view": {"accounts"}}, &accountsPage)
+	require.Len(t, accountsPage.Items, 2)
+	require.Equal(t, testAddress, accountsPage.Items[0].Address)
+	require.Equal(t, uint64(5), accountsPage.Items[0].Nonce)
+	require.Equal(t, "100", accountsPage.Items[0].Balance)
+	require.Equal(t, libcommon.Address{2}, accountsPage.Items[1].Address)
+
+	var storagePage struct {
+		Items []struct {
+			Location libcommon.Hash `json:"location"`
+			Value    string         `json:"value"`
+		} `json:"items"`
+		Next string `json:"next"`
+	}
+	get(t, decoded, url.Values{"view": {"storage"}, "address": {testAddress.Hex()}, "limit": {"2"}}, &storagePage)
+	require.Len(t, storagePage.Items, 2)
+	require.Equal(t, testSlots[0], storagePage.Items[0].Location)
+	require.Equal(t, "01", storagePage.Items[0].Value)
+	get(t, decoded, url.Values{"view": {"storage"}, "address": {testAddress.Hex()}, "location": {storagePage.Next}}, &storagePage)
+	require.Len(t, storagePage.Items, 1)
+	require.Equal(t, testSlots[2], storagePage.Items[0].Location)
+
+	var stagesList []struct {
+		Stage    string `json:"stage"`
+		Progress uint64 `json:"progress"`
+	}
+	get(t, decoded, url.Values{"view": {"stages"}}, &stagesList)
+	require.Len(t, stagesList, len(stages.AllStages))
+
+	var pruneMode struct {
+		Head    uint64 `json:"head"`
+		History struct {
+			Enabled bool   `json:"enabled"`
+			PruneTo uint64 `json:"pruneTo"`
+		} `json:"history"`
+	}
+	get(t, decoded, url.Values{"view": {"prune"}}, &pruneMode)
+	require.Equal(t, uint64(2), pruneMode.Head)
+	require.True(t, pruneMode.History.Enabled)
+	require.Equal(t, uint64(1), pruneMode.History.PruneTo)
+
+	var snapshots struct {
+		BlocksAvailable uint64  `json:"blocksAvailable"`
+		DbFirstHeader   *uint64 `json:"dbFirstHeader"`
+	}
+	get(t, decoded, url.Values{"view": {"snapshots"}}, &snapshots)
+	require.Zero(t, snapshots.BlocksAvailable)
+	require.NotNil(t, snapshots.DbFirstHeader)
+	require.Zero(t, *snapshots.DbFirstHeader)
+
+	var w bytes.Buffer
+	decoded(&w, url.Values{"view": {"logs"}})
+	require.True(t, strings.HasPrefix(w.String(), "ERROR: "))
+	w.Reset()
+	decoded(&w, url.Values{"view": {"storage"}})
+	require.True(t, strings.HasPrefix(w.String(), "ERROR: "))
+}
+
+func TestDbCompare(t *testing.T) {
+	dataDir, headers := newTestDb(t)
+	path := filepath.Join(dataDir, "chaindata")
+	compare := func(w *bytes.Buffer, q url.Values) {
+		q.Set("path", path)
+		writeDbCompare(w, httptest.NewRequest("GET", "/debug/metrics/db/compare?"+q.Encode(), nil), dataDir)
+	}
+
+	var result struct {
+		Frozen bool `json:"frozen"`
+		Equal  bool `json:"equal"`
+		Db     *struct {
+			Hash libcommon.Hash `json:"hash"`
+		} `json:"db"`
+		Snapshot *struct{} `json:"snapshot"`
+	}
+	// Nothing is frozen without the snapshot files
+	get(t, compare, url.Values{"view": {"headers"}, "block": {"1"}}, &result)
+	require.False(t, result.Frozen)
+	require.False(t, result.Equal)
+	require.NotNil(t, result.Db)
+	require.Equal(t, headers[1].Hash(), result.Db.Hash)
+	require.Nil(t, result.Snapshot)
+
+	var w bytes.Buffer
+	compare(&w, url.Values{"view": {"headers"}})
+	require.True(t, strings.HasPrefix(w.String(), "ERROR: "))
+
+	// The snapshots are the ones of the running node
+	SetBlockReader(nil, nil)
+	w.Reset()
+	compare(&w, url.Values{"view": {"headers"}, "block": {"1"}})
+	require.True(t, strings.HasPrefix(w.String(), "ERROR: "))
+}
@@ -249,6 +249,13 @@ type Config struct {
 	SentinelPort                uint64
 
 	OverrideShanghaiTime *big.Int `toml:",omitempty"`
+	OverrideCancunTime   *big.Int `toml:",omitempty"`
+
+	// Dev chain driven by the in-process mock consensus client instead of Clique
+	DevPoS                  bool
+	DevPeriod               time.Duration
+	DevWithdrawalRecipients []common.Address `toml:",omitempty"`
+	DevWithdrawalAmount     uint64
 
 	DropUselessPeers bool
 }
@@ -23,6 +23,7 @@ import (
 	"math/big"
 
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
 	"github.com/ledgerwatch/erigon-lib/common/length"
 
 	ethereum "github.com/ledgerwatch/erigon"
@@ -485,6 +486,9 @@ func (args *FilterCriteria) UnmarshalJSON(data []byte) error {
 		ToBlock   *rpc.BlockNumber `json:"toBlock"`
 		Addresses interface{}      `json:"address"`
 		Topics    []interface{}    `json:"topics"`
+
+		ResumeToken *hexutility.Bytes `json:"resumeToken"`
+		Backfill    bool              `json:"backfill"`
 	}
 
 	var raw input
@@ -508,6 +512,22 @@ func (args *FilterCriteria) UnmarshalJSON(data []byte) error {
 		}
 	}
 
+	if raw.ResumeToken != nil {
+		if raw.BlockHash != nil || raw.FromBlock != nil {
+			return fmt.Errorf("cannot specify both resumeToken and BlockHash/FromBlock, choose one or the other")
+		}
+		args.ResumeToken = *raw.ResumeToken
+	}
+	if raw.Backfill {
+		if raw.ResumeToken != nil {
+			return fmt.Errorf("cannot specify both resumeToken and backfill, a resumeToken always backfills")
+		}
+		if raw.FromBlock == nil || *raw.FromBlock < 0 {
+			return fmt.Errorf("backfill requires a fromBlock number")
+		}
+		args.Backfill = true
+	}
+
 	args.Addresses = []libcommon.Address{}
 
 	if raw.Addresses != nil {
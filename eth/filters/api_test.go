@@ -183,4 +183,37 @@ func TestUnmarshalJSONNewFilterArgs(t *testing.T) {
 	if len(test7.Topics[2]) != 0 {
 		t.Fatalf("expected 0 topics, got %d topics", len(test7.Topics[2]))
 	}
+
+	// resume token of a logs subscription
+	var test8 FilterCriteria
+	if err := json.Unmarshal([]byte(`{"resumeToken": "0x0102"}`), &test8); err != nil {
+		t.Fatal(err)
+	}
+	if string(test8.ResumeToken) != "\x01\x02" {
+		t.Fatalf("got resume token %x, expected 0102", test8.ResumeToken)
+	}
+	vector = fmt.Sprintf(`{"fromBlock":"0x%x","resumeToken":"0x0102"}`, fromBlock)
+	if err := json.Unmarshal([]byte(vector), &test8); err == nil {
+		t.Fatal("expected an error for both fromBlock and resumeToken")
+	}
+
+	// backfill of a logs subscription is only done on request
+	var test9 FilterCriteria
+	vector = fmt.Sprintf(`{"fromBlock":"0x%x"}`, fromBlock)
+	if err := json.Unmarshal([]byte(vector), &test9); err != nil {
+		t.Fatal(err)
+	}
+	if test9.Backfill {
+		t.Fatal("backfill without a request")
+	}
+	vector = fmt.Sprintf(`{"fromBlock":"0x%x","backfill":true}`, fromBlock)
+	if err := json.Unmarshal([]byte(vector), &test9); err != nil {
+		t.Fatal(err)
+	}
+	if !test9.Backfill {
+		t.Fatal("expected backfill")
+	}
+	if err := json.Unmarshal([]byte(`{"fromBlock":"latest","backfill":true}`), &test9); err == nil {
+		t.Fatal("expected an error for backfill without a fromBlock number")
+	}
 }
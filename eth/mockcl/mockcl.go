@@ -0,0 +1,302 @@
+/*
+   Copyright 2023 Erigon contributors
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+       http://www.apache.org/licenses/LICENSE-2.0
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package mockcl implements an in-process consensus client for dev chains.
+// It drives the execution layer through the same forkchoiceUpdated/getPayload/newPayload
+// sequence a beacon node would use, so that a dev chain follows post-merge rules without an external CL.
+package mockcl
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/chain"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi/remoteproto"
+)
+
+// time given to the block builder between the payload request and its retrieval.
+const payloadBuildTime = 100 * time.Millisecond
+
+// how often the transaction pool is polled when blocks are produced on demand.
+const txPoolPollInterval = 100 * time.Millisecond
+
+// EngineServer is the subset of the engine API the mock consensus client drives, implemented by privateapi.EthBackendServer.
+type EngineServer interface {
+	NewPayload(ctx context.Context, req *remoteproto.EngineNewPayloadRequest) (*remote.EnginePayloadStatus, error)
+	ForkChoiceUpdated(ctx context.Context, req *remoteproto.EngineForkChoiceUpdatedRequest) (*remote.EngineForkChoiceUpdatedResponse, error)
+	EngineGetPayload(ctx context.Context, req *remote.EngineGetPayloadRequest) (*remote.EngineGetPayloadResponse, error)
+}
+
+// TxPool is used to find out whether there are transactions waiting to be included.
+type TxPool interface {
+	Status(ctx context.Context, req *txpool.StatusRequest) (*txpool.StatusReply, error)
+}
+
+type Config struct {
+	// Period is the slot time, zero means a block is produced as soon as there are pending transactions.
+	Period time.Duration
+	// FeeRecipient is suggested to the block builder for every block.
+	FeeRecipient libcommon.Address
+	// WithdrawalRecipients receive a synthetic withdrawal of WithdrawalAmount gwei in every block after Shanghai.
+	WithdrawalRecipients []libcommon.Address
+	WithdrawalAmount     uint64
+}
+
+// MockCL plays the role of the beacon node for a single local validator which proposes every slot,
+// every produced block is immediately considered safe and finalized.
+type MockCL struct {
+	cfg         Config
+	chainConfig *chain.Config
+	db          kv.RoDB
+	engine      EngineServer
+	txPool      TxPool
+
+	withdrawalIndex uint64
+	// whether withdrawalIndex continues the withdrawals of the chain
+	withdrawalIndexRestored bool
+	lock                    sync.Mutex
+}
+
+func New(cfg Config, chainConfig *chain.Config, db kv.RoDB, engine EngineServer, txPool TxPool) *MockCL {
+	return &MockCL{
+		cfg:         cfg,
+		chainConfig: chainConfig,
+		db:          db,
+		engine:      engine,
+		txPool:      txPool,
+	}
+}
+
+// Start produces blocks until ctx is cancelled, either every Period or whenever transactions are pending.
+func (m *MockCL) Start(ctx context.Context) {
+	if m.cfg.Period == 0 && m.txPool == nil {
+		log.Error("[MockCL] no transaction pool to watch and no block period set, no blocks will be produced")
+		return
+	}
+	go m.loop(ctx)
+}
+
+func (m *MockCL) loop(ctx context.Context) {
+	interval := m.cfg.Period
+	if interval == 0 {
+		interval = txPoolPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	// the transaction pool learns about the new head asynchronously, until then it reports already included transactions as pending.
+	var justProduced bool
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if m.cfg.Period == 0 {
+			if justProduced {
+				justProduced = false
+				continue
+			}
+			status, err := m.txPool.Status(ctx, &txpool.StatusRequest{})
+			if err != nil {
+				log.Warn("[MockCL] could not get transaction pool status", "err", err)
+				continue
+			}
+			if status.PendingCount == 0 {
+				continue
+			}
+		}
+		if _, err := m.ProduceBlock(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			log.Warn("[MockCL] could not produce block", "err", err)
+		}
+		justProduced = true
+	}
+}
+
+// ProduceBlock builds a block on top of the current head, imports it and makes it the new head.
+func (m *MockCL) ProduceBlock(ctx context.Context) (libcommon.Hash, error) {
+	parent, err := m.readHead(ctx)
+	if err != nil {
+		return libcommon.Hash{}, err
+	}
+	parentHash := parent.Hash()
+
+	timestamp := uint64(time.Now().Unix())
+	if timestamp <= parent.Time {
+		timestamp = parent.Time + 1
+	}
+	var prevRandao libcommon.Hash
+	if _, err := rand.Read(prevRandao[:]); err != nil {
+		return libcommon.Hash{}, err
+	}
+	attributes := &remote.EnginePayloadAttributes{
+		Version:               1,
+		Timestamp:             timestamp,
+		PrevRandao:            gointerfaces.ConvertHashToH256(prevRandao),
+		SuggestedFeeRecipient: gointerfaces.ConvertAddressToH160(m.cfg.FeeRecipient),
+	}
+	if m.chainConfig.IsShanghai(timestamp) {
+		attributes.Version = 2
+		attributes.Withdrawals = privateapi.ConvertWithdrawalsToRpc(m.nextWithdrawals())
+	}
+	// There is no beacon chain, so the parent hash stands in for the parent beacon block root.
+	var parentBeaconBlockRoot *libcommon.Hash
+	if m.chainConfig.IsCancun(timestamp) {
+		parentBeaconBlockRoot = &parentHash
+	}
+
+	fcuReply, err := m.engine.ForkChoiceUpdated(ctx, forkChoiceRequest(parentHash, attributes, parentBeaconBlockRoot))
+	if err != nil {
+		return libcommon.Hash{}, fmt.Errorf("forkchoiceUpdated with payload attributes: %w", err)
+	}
+	if err := checkStatus(fcuReply.PayloadStatus); err != nil {
+		return libcommon.Hash{}, fmt.Errorf("forkchoiceUpdated with payload attributes: %w", err)
+	}
+	if fcuReply.PayloadId == 0 {
+		return libcommon.Hash{}, fmt.Errorf("forkchoiceUpdated did not start building a payload on top of %x", parentHash)
+	}
+
+	select {
+	case <-ctx.Done():
+		return libcommon.Hash{}, ctx.Err()
+	case <-time.After(payloadBuildTime):
+	}
+
+	payloadReply, err := m.engine.EngineGetPayload(ctx, &remote.EngineGetPayloadRequest{PayloadId: fcuReply.PayloadId})
+	if err != nil {
+		return libcommon.Hash{}, fmt.Errorf("getPayload: %w", err)
+	}
+	payload := payloadReply.ExecutionPayload
+	payloadStatus, err := m.engine.NewPayload(ctx, &remoteproto.EngineNewPayloadRequest{
+		ExecutionPayload:      payload,
+		ParentBeaconBlockRoot: privateapi.ConvertParentBeaconBlockRootToRpc(parentBeaconBlockRoot),
+	})
+	if err != nil {
+		return libcommon.Hash{}, fmt.Errorf("newPayload: %w", err)
+	}
+	if err := checkStatus(payloadStatus); err != nil {
+		return libcommon.Hash{}, fmt.Errorf("newPayload: %w", err)
+	}
+
+	headHash := libcommon.Hash(gointerfaces.ConvertH256ToHash(payload.BlockHash))
+	fcuReply, err = m.engine.ForkChoiceUpdated(ctx, forkChoiceRequest(headHash, nil, nil))
+	if err != nil {
+		return libcommon.Hash{}, fmt.Errorf("forkchoiceUpdated: %w", err)
+	}
+	if err := checkStatus(fcuReply.PayloadStatus); err != nil {
+		return libcommon.Hash{}, fmt.Errorf("forkchoiceUpdated: %w", err)
+	}
+	log.Info("[MockCL] produced block", "number", payload.BlockNumber, "hash", headHash, "txs", len(payload.Transactions), "withdrawals", len(payload.Withdrawals))
+	return headHash, nil
+}
+
+func (m *MockCL) readHead(ctx context.Context) (*types.Header, error) {
+	tx, err := m.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	headHash := rawdb.ReadHeadBlockHash(tx)
+	header, err := rawdb.ReadHeaderByHash(tx, headHash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("head header not found: %x", headHash)
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if !m.withdrawalIndexRestored {
+		if m.withdrawalIndex, err = lastWithdrawalIndex(tx, header); err != nil {
+			return nil, err
+		}
+		m.withdrawalIndexRestored = true
+	}
+	return header, nil
+}
+
+// lastWithdrawalIndex returns the index following the last withdrawal of the chain ending with head,
+// going back through the blocks without withdrawals up to Shanghai.
+func lastWithdrawalIndex(tx kv.Tx, head *types.Header) (uint64, error) {
+	for header := head; header.WithdrawalsHash != nil && header.Number.Uint64() > 0; {
+		body, _, _ := rawdb.ReadBody(tx, header.Hash(), header.Number.Uint64())
+		if body == nil {
+			return 0, fmt.Errorf("body of block %d not found: %x", header.Number.Uint64(), header.Hash())
+		}
+		if len(body.Withdrawals) > 0 {
+			return body.Withdrawals[len(body.Withdrawals)-1].Index + 1, nil
+		}
+		parent := rawdb.ReadHeader(tx, header.ParentHash, header.Number.Uint64()-1)
+		if parent == nil {
+			return 0, fmt.Errorf("header of block %d not found: %x", header.Number.Uint64()-1, header.ParentHash)
+		}
+		header = parent
+	}
+	return 0, nil
+}
+
+// nextWithdrawals returns the withdrawals of the next block, one to each of the configured recipients.
+func (m *MockCL) nextWithdrawals() []*types.Withdrawal {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var withdrawals []*types.Withdrawal
+	for _, recipient := range m.cfg.WithdrawalRecipients {
+		withdrawals = append(withdrawals, &types.Withdrawal{Address: recipient, Amount: m.cfg.WithdrawalAmount})
+	}
+	for i, w := range withdrawals {
+		w.Index = m.withdrawalIndex
+		w.Validator = uint64(i)
+		m.withdrawalIndex++
+	}
+	if withdrawals == nil {
+		withdrawals = []*types.Withdrawal{}
+	}
+	return withdrawals
+}
+
+func forkChoiceRequest(head libcommon.Hash, attributes *remote.EnginePayloadAttributes, parentBeaconBlockRoot *libcommon.Hash) *remoteproto.EngineForkChoiceUpdatedRequest {
+	return &remoteproto.EngineForkChoiceUpdatedRequest{
+		Request: &remote.EngineForkChoiceUpdatedRequest{
+			ForkchoiceState: &remote.EngineForkChoiceState{
+				HeadBlockHash:      gointerfaces.ConvertHashToH256(head),
+				SafeBlockHash:      gointerfaces.ConvertHashToH256(head),
+				FinalizedBlockHash: gointerfaces.ConvertHashToH256(head),
+			},
+			PayloadAttributes: attributes,
+		},
+		ParentBeaconBlockRoot: privateapi.ConvertParentBeaconBlockRootToRpc(parentBeaconBlockRoot),
+	}
+}
+
+func checkStatus(status *remote.EnginePayloadStatus) error {
+	if status == nil {
+		return fmt.Errorf("no payload status")
+	}
+	if status.Status != remote.EngineStatus_VALID {
+		return fmt.Errorf("unexpected status %s: %s", status.Status, status.ValidationError)
+	}
+	return nil
+}
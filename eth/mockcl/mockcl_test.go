@@ -0,0 +1,269 @@
+package mockcl
+
+import (
+	"context"
+	"encoding/binary"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
+	types2 "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/consensus/ethash"
+	"github.com/ledgerwatch/erigon/consensus/serenity"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi/remoteproto"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/engineapi"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/ledgerwatch/erigon/turbo/stages"
+)
+
+// engineMock builds empty blocks out of the payload attributes and records the calls it receives.
+type engineMock struct {
+	t  *testing.T
+	db kv.RwDB
+
+	lock       sync.Mutex
+	calls      []string
+	attributes map[uint64]*remote.EnginePayloadAttributes
+	payloads   map[libcommon.Hash]*types.Block
+	// the root of every forkchoiceUpdated with payload attributes, then of every newPayload
+	roots      []*libcommon.Hash
+	newPayload []*types2.ExecutionPayload
+}
+
+func newEngineMock(t *testing.T, db kv.RwDB) *engineMock {
+	return &engineMock{t: t, db: db, attributes: map[uint64]*remote.EnginePayloadAttributes{}, payloads: map[libcommon.Hash]*types.Block{}}
+}
+
+func (e *engineMock) ForkChoiceUpdated(ctx context.Context, req *remoteproto.EngineForkChoiceUpdatedRequest) (*remote.EngineForkChoiceUpdatedResponse, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	head := libcommon.Hash(gointerfaces.ConvertH256ToHash(req.Request.ForkchoiceState.HeadBlockHash))
+	reply := &remote.EngineForkChoiceUpdatedResponse{PayloadStatus: &remote.EnginePayloadStatus{Status: remote.EngineStatus_VALID}}
+	if req.Request.PayloadAttributes == nil {
+		e.calls = append(e.calls, "forkchoiceUpdated")
+		block, ok := e.payloads[head]
+		require.True(e.t, ok)
+		require.NoError(e.t, e.db.Update(ctx, func(tx kv.RwTx) error {
+			rawdb.WriteHeader(tx, block.Header())
+			if err := rawdb.WriteBody(tx, head, block.NumberU64(), block.Body()); err != nil {
+				return err
+			}
+			rawdb.WriteHeadBlockHash(tx, head)
+			return nil
+		}))
+		return reply, nil
+	}
+	e.calls = append(e.calls, "forkchoiceUpdated+attributes")
+	reply.PayloadId = uint64(len(e.attributes) + 1)
+	e.attributes[reply.PayloadId] = req.Request.PayloadAttributes
+	e.roots = append(e.roots, privateapi.ConvertParentBeaconBlockRootFromRpc(req.ParentBeaconBlockRoot))
+	return reply, nil
+}
+
+func (e *engineMock) EngineGetPayload(ctx context.Context, req *remote.EngineGetPayloadRequest) (*remote.EngineGetPayloadResponse, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.calls = append(e.calls, "getPayload")
+	attributes := e.attributes[req.PayloadId]
+	var parent *types.Header
+	require.NoError(e.t, e.db.View(ctx, func(tx kv.Tx) error {
+		var err error
+		parent, err = rawdb.ReadHeaderByHash(tx, rawdb.ReadHeadBlockHash(tx))
+		return err
+	}))
+	withdrawals := privateapi.ConvertWithdrawalsFromRpc(attributes.Withdrawals)
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, libcommon.Big1),
+		Time:       attributes.Timestamp,
+		MixDigest:  gointerfaces.ConvertH256ToHash(attributes.PrevRandao),
+		Difficulty: libcommon.Big0,
+		BaseFee:    libcommon.Big1,
+	}
+	if withdrawals != nil {
+		header.WithdrawalsHash = new(libcommon.Hash)
+	}
+	e.payloads[header.Hash()] = types.NewBlockFromStorage(header.Hash(), header, nil, nil, withdrawals)
+	return &remote.EngineGetPayloadResponse{ExecutionPayload: &types2.ExecutionPayload{
+		Version:     attributes.Version,
+		ParentHash:  gointerfaces.ConvertHashToH256(header.ParentHash),
+		BlockNumber: header.Number.Uint64(),
+		Timestamp:   header.Time,
+		BlockHash:   gointerfaces.ConvertHashToH256(header.Hash()),
+		Withdrawals: attributes.Withdrawals,
+	}}, nil
+}
+
+func (e *engineMock) NewPayload(ctx context.Context, req *remoteproto.EngineNewPayloadRequest) (*remote.EnginePayloadStatus, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.calls = append(e.calls, "newPayload")
+	e.newPayload = append(e.newPayload, req.ExecutionPayload)
+	e.roots = append(e.roots, privateapi.ConvertParentBeaconBlockRootFromRpc(req.ParentBeaconBlockRoot))
+	return &remote.EnginePayloadStatus{Status: remote.EngineStatus_VALID}, nil
+}
+
+func (e *engineMock) produced() int {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return len(e.newPayload)
+}
+
+type txPoolMock struct {
+	pending atomic.Uint32
+}
+
+func (p *txPoolMock) Status(context.Context, *txpool.StatusRequest) (*txpool.StatusReply, error) {
+	return &txpool.StatusReply{PendingCount: p.pending.Load()}, nil
+}
+
+func newTestMockCL(t *testing.T, cfg Config, txPool TxPool) (*MockCL, *engineMock, *types.Block) {
+	db := memdb.NewTestDB(t)
+	chainConfig, genesis, err := core.CommitGenesisBlock(db, core.DeveloperPoSGenesisBlock(core.DevnetEtherbase), t.TempDir())
+	require.NoError(t, err)
+	engine := newEngineMock(t, db)
+	return New(cfg, chainConfig, db, engine, txPool), engine, genesis
+}
+
+func TestProduceBlock(t *testing.T) {
+	recipient := libcommon.Address{0xaa}
+	m, engine, genesis := newTestMockCL(t, Config{
+		FeeRecipient:         core.DevnetEtherbase,
+		WithdrawalRecipients: []libcommon.Address{recipient},
+		WithdrawalAmount:     1_000,
+	}, nil)
+	ctx := context.Background()
+
+	head1, err := m.ProduceBlock(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"forkchoiceUpdated+attributes", "getPayload", "newPayload", "forkchoiceUpdated"}, engine.calls)
+
+	attributes := engine.attributes[1]
+	require.Equal(t, uint32(2), attributes.Version)
+	require.Equal(t, core.DevnetEtherbase, libcommon.Address(gointerfaces.ConvertH160toAddress(attributes.SuggestedFeeRecipient)))
+	require.Greater(t, attributes.Timestamp, genesis.Time())
+	withdrawals := privateapi.ConvertWithdrawalsFromRpc(attributes.Withdrawals)
+	require.Equal(t, []*types.Withdrawal{{Index: 0, Validator: 0, Address: recipient, Amount: 1_000}}, withdrawals)
+	// Cancun is active from genesis, the parent hash stands in for the beacon block root.
+	root := genesis.Hash()
+	require.Equal(t, []*libcommon.Hash{&root, &root}, engine.roots)
+
+	head2, err := m.ProduceBlock(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), engine.newPayload[1].BlockNumber)
+	require.Equal(t, head1, libcommon.Hash(gointerfaces.ConvertH256ToHash(engine.newPayload[1].ParentHash)))
+	require.NotEqual(t, head1, head2)
+	withdrawals = privateapi.ConvertWithdrawalsFromRpc(engine.attributes[2].Withdrawals)
+	require.Equal(t, []*types.Withdrawal{{Index: 1, Validator: 0, Address: recipient, Amount: 1_000}}, withdrawals)
+	require.Greater(t, engine.attributes[2].Timestamp, engine.attributes[1].Timestamp)
+}
+
+// TestWithdrawalIndexAfterRestart checks that the withdrawal indices continue those of the chain
+// when the last blocks have no withdrawals.
+func TestWithdrawalIndexAfterRestart(t *testing.T) {
+	recipients := []libcommon.Address{{0xaa}, {0xbb}}
+	m, engine, _ := newTestMockCL(t, Config{WithdrawalRecipients: recipients, WithdrawalAmount: 1_000}, nil)
+	ctx := context.Background()
+	_, err := m.ProduceBlock(ctx)
+	require.NoError(t, err)
+
+	// Two blocks without withdrawals
+	m = New(Config{}, m.chainConfig, m.db, engine, nil)
+	for i := 0; i < 2; i++ {
+		_, err = m.ProduceBlock(ctx)
+		require.NoError(t, err)
+	}
+	require.Empty(t, engine.attributes[3].Withdrawals)
+
+	m = New(Config{WithdrawalRecipients: recipients[:1], WithdrawalAmount: 1_000}, m.chainConfig, m.db, engine, nil)
+	_, err = m.ProduceBlock(ctx)
+	require.NoError(t, err)
+	withdrawals := privateapi.ConvertWithdrawalsFromRpc(engine.attributes[4].Withdrawals)
+	require.Equal(t, []*types.Withdrawal{{Index: 2, Validator: 0, Address: recipients[0], Amount: 1_000}}, withdrawals)
+}
+
+func TestProduceBlockOnPendingTransactions(t *testing.T) {
+	pool := &txPoolMock{}
+	m, engine, _ := newTestMockCL(t, Config{}, pool)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.Start(ctx)
+
+	time.Sleep(3 * txPoolPollInterval)
+	require.Zero(t, engine.produced())
+
+	pool.pending.Store(1)
+	require.Eventually(t, func() bool { return engine.produced() > 0 }, 5*time.Second, 10*time.Millisecond)
+}
+
+// TestProduceBlockOnBackend drives the engine API of a node set up like --dev.pos, with Cancun active from genesis.
+func TestProduceBlockOnBackend(t *testing.T) {
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	gspec := core.DeveloperPoSGenesisBlock(crypto.PubkeyToAddress(key.PublicKey))
+	m := stages.MockWithForkValidation(t, gspec, key, serenity.New(ethash.NewFaker()), true /* withTxPool */)
+	// The stage loop runs on its own, so there is no counting of the state changes sent to the transaction pool.
+	m.TxPoolFetch.SetWaitGroup(nil)
+	waitForDone := make(chan struct{})
+	go stages.StageLoop(m.Ctx, m.ChainConfig, m.DB, m.Sync, m.HeaderDownload(), m.Notifications, m.UpdateHead, waitForDone, 0)
+	t.Cleanup(func() {
+		m.HeaderDownload().BeaconRequestList.Interrupt(engineapi.Stopping)
+		<-waitForDone
+	})
+	blockReader := snapshotsync.NewBlockReaderWithSnapshots(m.BlockSnapshots, m.TransactionsV3)
+	backend := privateapi.NewEthBackendServer(m.Ctx, nil, m.DB, m.Notifications.Events, blockReader, m.ChainConfig, m.AssembleBlockPOS, m.HeaderDownload(), true)
+
+	recipient := libcommon.Address{0xaa}
+	cl := New(Config{
+		FeeRecipient:         m.Address,
+		WithdrawalRecipients: []libcommon.Address{recipient},
+		WithdrawalAmount:     1_000,
+	}, m.ChainConfig, m.DB, backend, nil)
+	head1, err := cl.ProduceBlock(m.Ctx)
+	require.NoError(t, err)
+	head2, err := cl.ProduceBlock(m.Ctx)
+	require.NoError(t, err)
+
+	tx, err := m.DB.BeginRo(m.Ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	require.Equal(t, head2, rawdb.ReadHeadBlockHash(tx))
+	block, _, err := blockReader.BlockWithSenders(m.Ctx, tx, head2, 2)
+	require.NoError(t, err)
+	require.NotNil(t, block)
+	require.Equal(t, uint64(2), block.NumberU64())
+	require.Equal(t, &head1, block.Header().ParentBeaconBlockRoot)
+	require.NotNil(t, block.Header().ExcessDataGas)
+	require.Equal(t, []*types.Withdrawal{{Index: 1, Validator: 0, Address: recipient, Amount: 1_000}}, []*types.Withdrawal(block.Withdrawals()))
+
+	// Both withdrawals were credited and the parent beacon block roots were stored by the executed blocks.
+	reader := state.NewPlainStateReader(tx)
+	account, err := reader.ReadAccountData(recipient)
+	require.NoError(t, err)
+	require.NotNil(t, account)
+	require.Equal(t, uint64(2_000*params.GWei), account.Balance.Uint64())
+	account, err = reader.ReadAccountData(params.BeaconRootsAddress)
+	require.NoError(t, err)
+	require.NotNil(t, account)
+	var slot libcommon.Hash
+	binary.BigEndian.PutUint64(slot[24:], block.Time()%8191+8191)
+	root, err := reader.ReadAccountStorage(params.BeaconRootsAddress, account.Incarnation, &slot)
+	require.NoError(t, err)
+	require.Equal(t, head1.Bytes(), root)
+}
@@ -386,6 +386,10 @@ func startHandlingForkChoice(
 			}
 		}
 
+		if cfg.forkValidator.SelectSideFork(headerHash, forkingPoint) {
+			log.Info(fmt.Sprintf("[%s] Fork choice: side fork already validated in memory", s.LogPrefix()), "head", headerNumber, "forkingPoint", forkingPoint)
+		}
+
 		u.UnwindTo(forkingPoint, libcommon.Hash{})
 
 		cfg.hd.SetUnsettledForkChoice(forkChoice, headerNumber)
@@ -394,6 +398,13 @@ func startHandlingForkChoice(
 		log.Info(fmt.Sprintf("[%s] Fork choice: chain extension", s.LogPrefix()), "from", preProgress, "to", headerNumber)
 		logEvery := time.NewTicker(logInterval)
 		defer logEvery.Stop()
+		flushed, err := cfg.forkValidator.FlushSideFork(tx, cfg.notifications.Accumulator, headerHash)
+		if err != nil {
+			return nil, err
+		}
+		if flushed {
+			log.Info(fmt.Sprintf("[%s] Fork choice update: flushing in-memory state (built by previous newPayload on a side fork)", s.LogPrefix()))
+		}
 		if err = fixCanonicalChain(s.LogPrefix(), logEvery, headerNumber, headerHash, tx, cfg.blockReader); err != nil {
 			return nil, err
 		}
@@ -438,6 +449,14 @@ func finishHandlingForkChoice(
 	logEvery := time.NewTicker(logInterval)
 	defer logEvery.Stop()
 
+	flushed, err := cfg.forkValidator.FlushSideFork(tx, cfg.notifications.Accumulator, forkChoice.HeadBlockHash)
+	if err != nil {
+		return err
+	}
+	if flushed {
+		log.Info(fmt.Sprintf("[%s] Fork choice update: flushing in-memory state (built by previous newPayload on a side fork)", s.LogPrefix()))
+	}
+
 	if err := fixCanonicalChain(s.LogPrefix(), logEvery, headHeight, forkChoice.HeadBlockHash, tx, cfg.blockReader); err != nil {
 		return err
 	}
@@ -103,6 +103,31 @@ func SpawnMiningExecStage(s *StageState, tx kv.RwTx, cfg MiningExecCfg, quit <-c
 		misc.ApplyDAOHardFork(ibs)
 	}
 	systemcontracts.UpgradeBuildInSystemContract(&cfg.chainConfig, current.Header.Number, ibs)
+	if cfg.chainConfig.IsCancun(current.Header.Time) && current.Header.ParentBeaconBlockRoot != nil {
+		if err := misc.ApplyBeaconRootEip4788(current.Header.ParentBeaconBlockRoot, ibs, func(contract libcommon.Address, data []byte) ([]byte, error) {
+			return core.SysCallContract(contract, data, cfg.chainConfig, ibs, current.Header, cfg.engine, false /* constCall */, nil /* excessDataGas */)
+		}); err != nil {
+			return err
+		}
+	}
+	getHeader := func(hash libcommon.Hash, number uint64) *types.Header { return rawdb.ReadHeader(tx, hash, number) }
+
+	// Transactions of the engine itself go first, as in OpenEthereum
+	if transactor, ok := cfg.engine.(consensus.EngineTransactor); ok {
+		engineTxs, err := transactor.GenerateEngineTransactions(&cfg.chainConfig, current.Header, ibs, func(contract libcommon.Address, data []byte) ([]byte, error) {
+			return core.SysCallContract(contract, data, cfg.chainConfig, ibs, current.Header, cfg.engine, true /* constCall */, nil /* excessDataGas */)
+		})
+		if err != nil {
+			return err
+		}
+		if len(engineTxs) > 0 {
+			logs, _, err := addTransactionsToMiningBlock(logPrefix, current, cfg.chainConfig, cfg.vmConfig, getHeader, cfg.engine, types.NewTransactionsFixedOrder(engineTxs), cfg.miningState.MiningConfig.Etherbase, ibs, quit, cfg.interrupt, cfg.payloadId)
+			if err != nil {
+				return err
+			}
+			NotifyPendingLogs(logPrefix, cfg.notifier, logs)
+		}
+	}
 
 	// Create an empty block based on temporary copied state for
 	// sealing in advance without waiting block execution finished.
@@ -111,8 +136,6 @@ func SpawnMiningExecStage(s *StageState, tx kv.RwTx, cfg MiningExecCfg, quit <-c
 		return nil
 	}
 
-	getHeader := func(hash libcommon.Hash, number uint64) *types.Header { return rawdb.ReadHeader(tx, hash, number) }
-
 	// Short circuit if there is no available pending transactions.
 	// But if we disable empty precommit already, ignore it. Since
 	// empty block is necessary to keep the liveness of the network.
@@ -1,13 +1,17 @@
 package stagedsync
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/ledgerwatch/erigon-lib/chain"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/log/v3"
 
 	"github.com/ledgerwatch/erigon/consensus"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/core/types"
 )
 
@@ -84,9 +88,26 @@ func SpawnMiningFinishStage(s *StageState, tx kv.RwTx, cfg MiningFinishCfg, quit
 		log.Trace("None in-flight sealing task.")
 	}
 	chain := ChainReader{Cfg: cfg.chainConfig, Db: tx}
-	if err := cfg.engine.Seal(chain, block, cfg.miningState.MiningResultCh, cfg.sealCancel); err != nil {
+	header := block.Header()
+	call := func(contract libcommon.Address, data []byte) ([]byte, error) {
+		return parentStateCall(cfg, header, contract, data)
+	}
+	if err := cfg.engine.Seal(chain, block, cfg.miningState.MiningResultCh, cfg.sealCancel, call); err != nil {
 		log.Warn("Block sealing failed", "err", err)
 	}
 
 	return nil
 }
+
+// parentStateCall calls a contract on the state of the parent of the block being sealed.
+// The block is already executed in the mining batch, while the database is still at its
+// parent, so the call reads the plain state in a short read-only transaction of its own:
+// the engine may also call contracts after the mining transaction is gone.
+func parentStateCall(cfg MiningFinishCfg, header *types.Header, contract libcommon.Address, data []byte) (result []byte, err error) {
+	err = cfg.db.View(context.Background(), func(tx kv.Tx) error {
+		ibs := state.New(state.NewPlainStateReader(tx))
+		result, err = core.SysCallContract(contract, data, cfg.chainConfig, ibs, header, cfg.engine, true /* constCall */, nil /* excessDataGas */)
+		return err
+	})
+	return result, err
+}
@@ -13,10 +13,12 @@ import (
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/ledgerwatch/erigon/ethdb/privateapi/remoteproto"
 )
 
 func StartGrpc(kv *remotedbserver.KvServer, ethBackendSrv *EthBackendServer, txPoolServer txpool_proto.TxpoolServer,
-	miningServer txpool_proto.MiningServer, addr string, rateLimit uint32, creds credentials.TransportCredentials,
+	miningServer txpool_proto.MiningServer, cliqueServer *CliqueServer, addr string, rateLimit uint32, creds credentials.TransportCredentials,
 	healthCheck bool) (*grpc.Server, error) {
 	log.Info("Starting private RPC server", "on", addr)
 	lis, err := net.Listen("tcp", addr)
@@ -26,12 +28,17 @@ func StartGrpc(kv *remotedbserver.KvServer, ethBackendSrv *EthBackendServer, txP
 
 	grpcServer := grpcutil.NewServer(rateLimit, creds)
 	remote.RegisterETHBACKENDServer(grpcServer, ethBackendSrv)
+	remoteproto.RegisterEngineServer(grpcServer, ethBackendSrv)
+	remoteproto.RegisterPeersServer(grpcServer, ethBackendSrv)
 	if txPoolServer != nil {
 		txpool_proto.RegisterTxpoolServer(grpcServer, txPoolServer)
 	}
 	if miningServer != nil {
 		txpool_proto.RegisterMiningServer(grpcServer, miningServer)
 	}
+	if cliqueServer != nil {
+		remoteproto.RegisterCliqueServer(grpcServer, cliqueServer)
+	}
 	remote.RegisterKVServer(grpcServer, kv)
 	var healthServer *health.Server
 	if healthCheck {
@@ -0,0 +1,62 @@
+package privateapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/ledgerwatch/erigon/consensus/clique"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi/remoteproto"
+)
+
+var errNoCliqueEngine = errors.New("not supported, consensus engine is not clique")
+
+// CliqueServer lets a remote rpcdaemon control the signer voting of the node.
+type CliqueServer struct {
+	remoteproto.UnimplementedCliqueServer
+	engine *clique.Clique
+}
+
+// NewCliqueServer returns the server of the Clique service. engine is nil if
+// the node does not run clique, the calls fail then.
+func NewCliqueServer(engine *clique.Clique) *CliqueServer {
+	return &CliqueServer{engine: engine}
+}
+
+func (s *CliqueServer) Proposals(context.Context, *emptypb.Empty) (*remoteproto.CliqueProposalsReply, error) {
+	if s.engine == nil {
+		return nil, errNoCliqueEngine
+	}
+	proposals := s.engine.Proposals()
+	addresses := make([]libcommon.Address, 0, len(proposals))
+	for address := range proposals {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool { return bytes.Compare(addresses[i][:], addresses[j][:]) < 0 })
+	reply := &remoteproto.CliqueProposalsReply{Proposals: make([]*remoteproto.CliqueProposal, 0, len(addresses))}
+	for _, address := range addresses {
+		reply.Proposals = append(reply.Proposals, &remoteproto.CliqueProposal{Address: gointerfaces.ConvertAddressToH160(address), Auth: proposals[address]})
+	}
+	return reply, nil
+}
+
+func (s *CliqueServer) Propose(_ context.Context, req *remoteproto.CliqueProposeRequest) (*emptypb.Empty, error) {
+	if s.engine == nil {
+		return nil, errNoCliqueEngine
+	}
+	s.engine.Propose(gointerfaces.ConvertH160toAddress(req.Address), req.Auth)
+	return &emptypb.Empty{}, nil
+}
+
+func (s *CliqueServer) Discard(_ context.Context, req *remoteproto.CliqueDiscardRequest) (*emptypb.Empty, error) {
+	if s.engine == nil {
+		return nil, errNoCliqueEngine
+	}
+	s.engine.Discard(gointerfaces.ConvertH160toAddress(req.Address))
+	return &emptypb.Empty{}, nil
+}
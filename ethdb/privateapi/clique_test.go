@@ -0,0 +1,61 @@
+package privateapi
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/ledgerwatch/erigon/consensus/clique"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi/remoteproto"
+	"github.com/ledgerwatch/erigon/params"
+)
+
+func TestCliqueService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine := clique.New(params.AllCliqueProtocolChanges, params.CliqueSnapshot, memdb.NewTestDB(t))
+	defer engine.Close()
+
+	server := grpc.NewServer()
+	remoteproto.RegisterCliqueServer(server, NewCliqueServer(engine))
+	listener := bufconn.Listen(1024 * 1024)
+	go server.Serve(listener) //nolint:errcheck
+	defer server.Stop()
+
+	conn, err := grpc.DialContext(ctx, "", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := remoteproto.NewCliqueClient(conn)
+
+	a, b := libcommon.HexToAddress("0xa"), libcommon.HexToAddress("0xb")
+	_, err = client.Propose(ctx, &remoteproto.CliqueProposeRequest{Address: gointerfaces.ConvertAddressToH160(b), Auth: false})
+	require.NoError(t, err)
+	_, err = client.Propose(ctx, &remoteproto.CliqueProposeRequest{Address: gointerfaces.ConvertAddressToH160(a), Auth: true})
+	require.NoError(t, err)
+
+	reply, err := client.Proposals(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, reply.Proposals, 2)
+	require.Equal(t, a, libcommon.Address(gointerfaces.ConvertH160toAddress(reply.Proposals[0].Address)))
+	require.True(t, reply.Proposals[0].Auth)
+	require.Equal(t, b, libcommon.Address(gointerfaces.ConvertH160toAddress(reply.Proposals[1].Address)))
+	require.False(t, reply.Proposals[1].Auth)
+
+	_, err = client.Discard(ctx, &remoteproto.CliqueDiscardRequest{Address: gointerfaces.ConvertAddressToH160(a)})
+	require.NoError(t, err)
+	require.Equal(t, map[libcommon.Address]bool{b: false}, engine.Proposals())
+
+	_, err = remoteproto.NewCliqueClientDirect(NewCliqueServer(nil)).Proposals(ctx, &emptypb.Empty{})
+	require.ErrorIs(t, err, errNoCliqueEngine)
+}
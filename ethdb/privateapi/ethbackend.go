@@ -26,6 +26,7 @@ import (
 	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi/remoteproto"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rlp"
 	"github.com/ledgerwatch/erigon/rpc"
@@ -43,7 +44,8 @@ import (
 // 3.0.0 - adding PoS interfaces
 // 3.1.0 - add Subscribe to logs
 // 3.2.0 - add EngineGetBlobsBundleV1
-var EthBackendAPIVersion = &types2.VersionReply{Major: 3, Minor: 2, Patch: 0}
+// 3.3.0 - pass parentBeaconBlockRoot in ExecutionPayload and EnginePayloadAttributes
+var EthBackendAPIVersion = &types2.VersionReply{Major: 3, Minor: 3, Patch: 0}
 
 const MaxBuilders = 128
 
@@ -54,6 +56,8 @@ var TooLargeRequestErr = rpc.CustomError{Code: -38004, Message: "Too large reque
 
 type EthBackendServer struct {
 	remote.UnimplementedETHBACKENDServer // must be embedded to have forward compatible implementations.
+	remoteproto.UnimplementedEngineServer
+	remoteproto.UnimplementedPeersServer
 
 	ctx         context.Context
 	eth         EthBackend
@@ -80,6 +84,7 @@ type EthBackend interface {
 	NetPeerCount() (uint64, error)
 	NodesInfo(limit int) (*remote.NodesInfoReply, error)
 	Peers(ctx context.Context) (*remote.PeersReply, error)
+	PeersProtocols(ctx context.Context) (*remoteproto.PeersProtocolsReply, error)
 }
 
 func NewEthBackendServer(ctx context.Context, eth EthBackend, db kv.RwDB, events *shards.Events, blockReader services.BlockAndTxnReader,
@@ -296,6 +301,16 @@ func (s *EthBackendServer) checkWithdrawalsPresence(time uint64, withdrawals []*
 	return nil
 }
 
+func (s *EthBackendServer) checkParentBeaconBlockRootPresence(time uint64, parentBeaconBlockRoot *libcommon.Hash) error {
+	if !s.config.IsCancun(time) && parentBeaconBlockRoot != nil {
+		return &rpc.InvalidParamsError{Message: "parentBeaconBlockRoot before cancun"}
+	}
+	if s.config.IsCancun(time) && parentBeaconBlockRoot == nil {
+		return &rpc.InvalidParamsError{Message: "missing parentBeaconBlockRoot"}
+	}
+	return nil
+}
+
 func (s *EthBackendServer) EngineGetBlobsBundleV1(ctx context.Context, req *remote.EngineGetBlobsBundleRequest) (*types2.BlobsBundleV1, error) {
 	// TODO: get the latest update on this function (it was replaced)
 	if !s.proposing {
@@ -352,6 +367,15 @@ func (s *EthBackendServer) EngineGetBlobsBundleV1(ctx context.Context, req *remo
 
 // EngineNewPayload validates and possibly executes payload
 func (s *EthBackendServer) EngineNewPayload(ctx context.Context, req *types2.ExecutionPayload) (*remote.EnginePayloadStatus, error) {
+	return s.engineNewPayload(ctx, req, nil)
+}
+
+// NewPayload is EngineNewPayload with the parent beacon block root of Cancun
+func (s *EthBackendServer) NewPayload(ctx context.Context, req *remoteproto.EngineNewPayloadRequest) (*remote.EnginePayloadStatus, error) {
+	return s.engineNewPayload(ctx, req.ExecutionPayload, ConvertParentBeaconBlockRootFromRpc(req.ParentBeaconBlockRoot))
+}
+
+func (s *EthBackendServer) engineNewPayload(ctx context.Context, req *types2.ExecutionPayload, parentBeaconBlockRoot *libcommon.Hash) (*remote.EnginePayloadStatus, error) {
 	header := types.Header{
 		ParentHash:  gointerfaces.ConvertH256ToHash(req.ParentHash),
 		Coinbase:    gointerfaces.ConvertH160toAddress(req.Coinbase),
@@ -392,6 +416,11 @@ func (s *EthBackendServer) EngineNewPayload(ctx context.Context, req *types2.Exe
 		return nil, &rpc.InvalidParamsError{Message: "excess data gas setting doesn't match sharding state"}
 	}
 
+	if err := s.checkParentBeaconBlockRootPresence(header.Time, parentBeaconBlockRoot); err != nil {
+		return nil, err
+	}
+	header.ParentBeaconBlockRoot = parentBeaconBlockRoot
+
 	blockHash := gointerfaces.ConvertH256ToHash(req.BlockHash)
 	if header.Hash() != blockHash {
 		log.Error("[NewPayload] invalid block hash", "stated", libcommon.Hash(blockHash), "actual", header.Hash())
@@ -662,8 +691,19 @@ func (s *EthBackendServer) EngineGetPayload(ctx context.Context, req *remote.Eng
 	}, nil
 }
 
-// engineForkChoiceUpdated either states new block head or request the assembling of a new block
+// EngineForkChoiceUpdated either states new block head or request the assembling of a new block
 func (s *EthBackendServer) EngineForkChoiceUpdated(ctx context.Context, req *remote.EngineForkChoiceUpdatedRequest,
+) (*remote.EngineForkChoiceUpdatedResponse, error) {
+	return s.engineForkChoiceUpdated(ctx, req, nil)
+}
+
+// ForkChoiceUpdated is EngineForkChoiceUpdated with the parent beacon block root of Cancun in the payload attributes
+func (s *EthBackendServer) ForkChoiceUpdated(ctx context.Context, req *remoteproto.EngineForkChoiceUpdatedRequest,
+) (*remote.EngineForkChoiceUpdatedResponse, error) {
+	return s.engineForkChoiceUpdated(ctx, req.Request, ConvertParentBeaconBlockRootFromRpc(req.ParentBeaconBlockRoot))
+}
+
+func (s *EthBackendServer) engineForkChoiceUpdated(ctx context.Context, req *remote.EngineForkChoiceUpdatedRequest, parentBeaconBlockRoot *libcommon.Hash,
 ) (*remote.EngineForkChoiceUpdatedResponse, error) {
 	forkChoice := engineapi.ForkChoiceMessage{
 		HeadBlockHash:      gointerfaces.ConvertH256ToHash(req.ForkchoiceState.HeadBlockHash),
@@ -742,6 +782,10 @@ func (s *EthBackendServer) EngineForkChoiceUpdated(ctx context.Context, req *rem
 	if err := s.checkWithdrawalsPresence(payloadAttributes.Timestamp, param.Withdrawals); err != nil {
 		return nil, err
 	}
+	param.ParentBeaconBlockRoot = parentBeaconBlockRoot
+	if err := s.checkParentBeaconBlockRootPresence(payloadAttributes.Timestamp, param.ParentBeaconBlockRoot); err != nil {
+		return nil, err
+	}
 
 	// First check if we're already building a block with the requested parameters
 	if reflect.DeepEqual(s.lastParameters, &param) {
@@ -887,6 +931,10 @@ func (s *EthBackendServer) Peers(ctx context.Context, _ *emptypb.Empty) (*remote
 	return s.eth.Peers(ctx)
 }
 
+func (s *EthBackendServer) PeersProtocols(ctx context.Context, _ *emptypb.Empty) (*remoteproto.PeersProtocolsReply, error) {
+	return s.eth.PeersProtocols(ctx)
+}
+
 func (s *EthBackendServer) SubscribeLogs(server remote.ETHBACKEND_SubscribeLogsServer) (err error) {
 	if s.logsFilter != nil {
 		return s.logsFilter.subscribeLogs(server)
@@ -925,3 +973,20 @@ func ConvertWithdrawalsToRpc(in []*types.Withdrawal) []*types2.Withdrawal {
 	}
 	return out
 }
+
+// ConvertParentBeaconBlockRootToRpc returns the EIP-4788 parent beacon block root
+// field of the requests of the Engine service, nil for blocks before Cancun.
+func ConvertParentBeaconBlockRootToRpc(root *libcommon.Hash) *types2.H256 {
+	if root == nil {
+		return nil
+	}
+	return gointerfaces.ConvertHashToH256(*root)
+}
+
+func ConvertParentBeaconBlockRootFromRpc(root *types2.H256) *libcommon.Hash {
+	if root == nil {
+		return nil
+	}
+	h := libcommon.Hash(gointerfaces.ConvertH256ToHash(root))
+	return &h
+}
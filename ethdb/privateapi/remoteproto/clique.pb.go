@@ -0,0 +1,392 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.30.0
+// 	protoc        v4.22.2
+// source: remoteproto/clique.proto
+
+package remoteproto
+
+import (
+	types "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CliqueProposal struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Address *types.H160 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// true to authorize the address as a signer, false to drop it
+	Auth bool `protobuf:"varint,2,opt,name=auth,proto3" json:"auth,omitempty"`
+}
+
+func (x *CliqueProposal) Reset() {
+	*x = CliqueProposal{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_remoteproto_clique_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CliqueProposal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CliqueProposal) ProtoMessage() {}
+
+func (x *CliqueProposal) ProtoReflect() protoreflect.Message {
+	mi := &file_remoteproto_clique_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CliqueProposal.ProtoReflect.Descriptor instead.
+func (*CliqueProposal) Descriptor() ([]byte, []int) {
+	return file_remoteproto_clique_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CliqueProposal) GetAddress() *types.H160 {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *CliqueProposal) GetAuth() bool {
+	if x != nil {
+		return x.Auth
+	}
+	return false
+}
+
+type CliqueProposalsReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Proposals []*CliqueProposal `protobuf:"bytes,1,rep,name=proposals,proto3" json:"proposals,omitempty"`
+}
+
+func (x *CliqueProposalsReply) Reset() {
+	*x = CliqueProposalsReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_remoteproto_clique_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CliqueProposalsReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CliqueProposalsReply) ProtoMessage() {}
+
+func (x *CliqueProposalsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_remoteproto_clique_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CliqueProposalsReply.ProtoReflect.Descriptor instead.
+func (*CliqueProposalsReply) Descriptor() ([]byte, []int) {
+	return file_remoteproto_clique_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CliqueProposalsReply) GetProposals() []*CliqueProposal {
+	if x != nil {
+		return x.Proposals
+	}
+	return nil
+}
+
+type CliqueProposeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Address *types.H160 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// true to authorize the address as a signer, false to drop it
+	Auth bool `protobuf:"varint,2,opt,name=auth,proto3" json:"auth,omitempty"`
+}
+
+func (x *CliqueProposeRequest) Reset() {
+	*x = CliqueProposeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_remoteproto_clique_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CliqueProposeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CliqueProposeRequest) ProtoMessage() {}
+
+func (x *CliqueProposeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_remoteproto_clique_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CliqueProposeRequest.ProtoReflect.Descriptor instead.
+func (*CliqueProposeRequest) Descriptor() ([]byte, []int) {
+	return file_remoteproto_clique_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CliqueProposeRequest) GetAddress() *types.H160 {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *CliqueProposeRequest) GetAuth() bool {
+	if x != nil {
+		return x.Auth
+	}
+	return false
+}
+
+type CliqueDiscardRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Address *types.H160 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+}
+
+func (x *CliqueDiscardRequest) Reset() {
+	*x = CliqueDiscardRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_remoteproto_clique_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CliqueDiscardRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CliqueDiscardRequest) ProtoMessage() {}
+
+func (x *CliqueDiscardRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_remoteproto_clique_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CliqueDiscardRequest.ProtoReflect.Descriptor instead.
+func (*CliqueDiscardRequest) Descriptor() ([]byte, []int) {
+	return file_remoteproto_clique_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CliqueDiscardRequest) GetAddress() *types.H160 {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+var File_remoteproto_clique_proto protoreflect.FileDescriptor
+
+var file_remoteproto_clique_proto_rawDesc = []byte{
+	0x0a, 0x18, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x63, 0x6c,
+	0x69, 0x71, 0x75, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0b, 0x72, 0x65, 0x6d, 0x6f,
+	0x74, 0x65, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1b, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x11, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2f, 0x74, 0x79, 0x70, 0x65,
+	0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x4b, 0x0a, 0x0e, 0x43, 0x6c, 0x69, 0x71, 0x75,
+	0x65, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x12, 0x25, 0x0a, 0x07, 0x61, 0x64, 0x64,
+	0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x74, 0x79, 0x70,
+	0x65, 0x73, 0x2e, 0x48, 0x31, 0x36, 0x30, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
+	0x12, 0x12, 0x0a, 0x04, 0x61, 0x75, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04,
+	0x61, 0x75, 0x74, 0x68, 0x22, 0x51, 0x0a, 0x14, 0x43, 0x6c, 0x69, 0x71, 0x75, 0x65, 0x50, 0x72,
+	0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x39, 0x0a, 0x09,
+	0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x1b, 0x2e, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x43, 0x6c,
+	0x69, 0x71, 0x75, 0x65, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x52, 0x09, 0x70, 0x72,
+	0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x73, 0x22, 0x51, 0x0a, 0x14, 0x43, 0x6c, 0x69, 0x71, 0x75,
+	0x65, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x25, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x0b, 0x2e, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2e, 0x48, 0x31, 0x36, 0x30, 0x52, 0x07, 0x61,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x75, 0x74, 0x68, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x61, 0x75, 0x74, 0x68, 0x22, 0x3d, 0x0a, 0x14, 0x43, 0x6c,
+	0x69, 0x71, 0x75, 0x65, 0x44, 0x69, 0x73, 0x63, 0x61, 0x72, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x25, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2e, 0x48, 0x31, 0x36, 0x30,
+	0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x32, 0xdc, 0x01, 0x0a, 0x06, 0x43, 0x6c,
+	0x69, 0x71, 0x75, 0x65, 0x12, 0x46, 0x0a, 0x09, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c,
+	0x73, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x21, 0x2e, 0x72, 0x65, 0x6d, 0x6f,
+	0x74, 0x65, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x43, 0x6c, 0x69, 0x71, 0x75, 0x65, 0x50, 0x72,
+	0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x44, 0x0a, 0x07,
+	0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x12, 0x21, 0x2e, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x43, 0x6c, 0x69, 0x71, 0x75, 0x65, 0x50, 0x72, 0x6f, 0x70,
+	0x6f, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70,
+	0x74, 0x79, 0x12, 0x44, 0x0a, 0x07, 0x44, 0x69, 0x73, 0x63, 0x61, 0x72, 0x64, 0x12, 0x21, 0x2e,
+	0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x43, 0x6c, 0x69, 0x71,
+	0x75, 0x65, 0x44, 0x69, 0x73, 0x63, 0x61, 0x72, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x42, 0x48, 0x5a, 0x46, 0x67, 0x69, 0x74, 0x68,
+	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x65, 0x64, 0x67, 0x65, 0x72, 0x77, 0x61, 0x74,
+	0x63, 0x68, 0x2f, 0x65, 0x72, 0x69, 0x67, 0x6f, 0x6e, 0x2f, 0x65, 0x74, 0x68, 0x64, 0x62, 0x2f,
+	0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x61, 0x70, 0x69, 0x2f, 0x72, 0x65, 0x6d, 0x6f, 0x74,
+	0x65, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x3b, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_remoteproto_clique_proto_rawDescOnce sync.Once
+	file_remoteproto_clique_proto_rawDescData = file_remoteproto_clique_proto_rawDesc
+)
+
+func file_remoteproto_clique_proto_rawDescGZIP() []byte {
+	file_remoteproto_clique_proto_rawDescOnce.Do(func() {
+		file_remoteproto_clique_proto_rawDescData = protoimpl.X.CompressGZIP(file_remoteproto_clique_proto_rawDescData)
+	})
+	return file_remoteproto_clique_proto_rawDescData
+}
+
+var file_remoteproto_clique_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_remoteproto_clique_proto_goTypes = []interface{}{
+	(*CliqueProposal)(nil),       // 0: remoteproto.CliqueProposal
+	(*CliqueProposalsReply)(nil), // 1: remoteproto.CliqueProposalsReply
+	(*CliqueProposeRequest)(nil), // 2: remoteproto.CliqueProposeRequest
+	(*CliqueDiscardRequest)(nil), // 3: remoteproto.CliqueDiscardRequest
+	(*types.H160)(nil),           // 4: types.H160
+	(*emptypb.Empty)(nil),        // 5: google.protobuf.Empty
+}
+var file_remoteproto_clique_proto_depIdxs = []int32{
+	4, // 0: remoteproto.CliqueProposal.address:type_name -> types.H160
+	0, // 1: remoteproto.CliqueProposalsReply.proposals:type_name -> remoteproto.CliqueProposal
+	4, // 2: remoteproto.CliqueProposeRequest.address:type_name -> types.H160
+	4, // 3: remoteproto.CliqueDiscardRequest.address:type_name -> types.H160
+	5, // 4: remoteproto.Clique.Proposals:input_type -> google.protobuf.Empty
+	2, // 5: remoteproto.Clique.Propose:input_type -> remoteproto.CliqueProposeRequest
+	3, // 6: remoteproto.Clique.Discard:input_type -> remoteproto.CliqueDiscardRequest
+	1, // 7: remoteproto.Clique.Proposals:output_type -> remoteproto.CliqueProposalsReply
+	5, // 8: remoteproto.Clique.Propose:output_type -> google.protobuf.Empty
+	5, // 9: remoteproto.Clique.Discard:output_type -> google.protobuf.Empty
+	7, // [7:10] is the sub-list for method output_type
+	4, // [4:7] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_remoteproto_clique_proto_init() }
+func file_remoteproto_clique_proto_init() {
+	if File_remoteproto_clique_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_remoteproto_clique_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CliqueProposal); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_remoteproto_clique_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CliqueProposalsReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_remoteproto_clique_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CliqueProposeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_remoteproto_clique_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CliqueDiscardRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_remoteproto_clique_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_remoteproto_clique_proto_goTypes,
+		DependencyIndexes: file_remoteproto_clique_proto_depIdxs,
+		MessageInfos:      file_remoteproto_clique_proto_msgTypes,
+	}.Build()
+	File_remoteproto_clique_proto = out.File
+	file_remoteproto_clique_proto_rawDesc = nil
+	file_remoteproto_clique_proto_goTypes = nil
+	file_remoteproto_clique_proto_depIdxs = nil
+}
@@ -0,0 +1,85 @@
+// Package remoteproto holds the services of the private API which are defined
+// by erigon itself, next to the ones of erigon-lib/gointerfaces/remote.
+package remoteproto
+
+import (
+	"context"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+//go:generate protoc --proto_path=.. --proto_path=$INTERFACES --go_out=.. --go_opt=paths=source_relative --go-grpc_out=.. --go-grpc_opt=paths=source_relative --go_opt=Mtypes/types.proto=github.com/ledgerwatch/erigon-lib/gointerfaces/types --go-grpc_opt=Mtypes/types.proto=github.com/ledgerwatch/erigon-lib/gointerfaces/types --go_opt=Mremote/ethbackend.proto=github.com/ledgerwatch/erigon-lib/gointerfaces/remote --go-grpc_opt=Mremote/ethbackend.proto=github.com/ledgerwatch/erigon-lib/gointerfaces/remote remoteproto/clique.proto remoteproto/engine.proto remoteproto/peers.proto remoteproto/localtxs.proto
+
+var _ CliqueClient = (*CliqueClientDirect)(nil)
+
+// CliqueClientDirect calls a Clique server of the same process without gRPC.
+type CliqueClientDirect struct {
+	server CliqueServer
+}
+
+func NewCliqueClientDirect(server CliqueServer) *CliqueClientDirect {
+	return &CliqueClientDirect{server: server}
+}
+
+func (s *CliqueClientDirect) Proposals(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*CliqueProposalsReply, error) {
+	return s.server.Proposals(ctx, in)
+}
+
+func (s *CliqueClientDirect) Propose(ctx context.Context, in *CliqueProposeRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	return s.server.Propose(ctx, in)
+}
+
+func (s *CliqueClientDirect) Discard(ctx context.Context, in *CliqueDiscardRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	return s.server.Discard(ctx, in)
+}
+
+var _ EngineClient = (*EngineClientDirect)(nil)
+
+// EngineClientDirect calls an Engine server of the same process without gRPC.
+type EngineClientDirect struct {
+	server EngineServer
+}
+
+func NewEngineClientDirect(server EngineServer) *EngineClientDirect {
+	return &EngineClientDirect{server: server}
+}
+
+func (s *EngineClientDirect) NewPayload(ctx context.Context, in *EngineNewPayloadRequest, opts ...grpc.CallOption) (*remote.EnginePayloadStatus, error) {
+	return s.server.NewPayload(ctx, in)
+}
+
+func (s *EngineClientDirect) ForkChoiceUpdated(ctx context.Context, in *EngineForkChoiceUpdatedRequest, opts ...grpc.CallOption) (*remote.EngineForkChoiceUpdatedResponse, error) {
+	return s.server.ForkChoiceUpdated(ctx, in)
+}
+
+var _ PeersClient = (*PeersClientDirect)(nil)
+
+// PeersClientDirect calls a Peers server of the same process without gRPC.
+type PeersClientDirect struct {
+	server PeersServer
+}
+
+func NewPeersClientDirect(server PeersServer) *PeersClientDirect {
+	return &PeersClientDirect{server: server}
+}
+
+func (s *PeersClientDirect) PeersProtocols(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*PeersProtocolsReply, error) {
+	return s.server.PeersProtocols(ctx, in)
+}
+
+var _ LocalTxsClient = (*LocalTxsClientDirect)(nil)
+
+// LocalTxsClientDirect calls a LocalTxs server of the same process without gRPC.
+type LocalTxsClientDirect struct {
+	server LocalTxsServer
+}
+
+func NewLocalTxsClientDirect(server LocalTxsServer) *LocalTxsClientDirect {
+	return &LocalTxsClientDirect{server: server}
+}
+
+func (s *LocalTxsClientDirect) MarkLocalTxs(ctx context.Context, in *MarkLocalTxsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	return s.server.MarkLocalTxs(ctx, in)
+}
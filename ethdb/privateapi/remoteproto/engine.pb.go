@@ -0,0 +1,274 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.30.0
+// 	protoc        v4.22.2
+// source: remoteproto/engine.proto
+
+package remoteproto
+
+import (
+	remote "github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	types "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type EngineNewPayloadRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ExecutionPayload *types.ExecutionPayload `protobuf:"bytes,1,opt,name=execution_payload,json=executionPayload,proto3" json:"execution_payload,omitempty"`
+	// EIP-4788 root of the parent beacon block, unset before Cancun
+	ParentBeaconBlockRoot *types.H256 `protobuf:"bytes,2,opt,name=parent_beacon_block_root,json=parentBeaconBlockRoot,proto3" json:"parent_beacon_block_root,omitempty"`
+}
+
+func (x *EngineNewPayloadRequest) Reset() {
+	*x = EngineNewPayloadRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_remoteproto_engine_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EngineNewPayloadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EngineNewPayloadRequest) ProtoMessage() {}
+
+func (x *EngineNewPayloadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_remoteproto_engine_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EngineNewPayloadRequest.ProtoReflect.Descriptor instead.
+func (*EngineNewPayloadRequest) Descriptor() ([]byte, []int) {
+	return file_remoteproto_engine_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EngineNewPayloadRequest) GetExecutionPayload() *types.ExecutionPayload {
+	if x != nil {
+		return x.ExecutionPayload
+	}
+	return nil
+}
+
+func (x *EngineNewPayloadRequest) GetParentBeaconBlockRoot() *types.H256 {
+	if x != nil {
+		return x.ParentBeaconBlockRoot
+	}
+	return nil
+}
+
+type EngineForkChoiceUpdatedRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Request *remote.EngineForkChoiceUpdatedRequest `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	// EIP-4788 root of the parent beacon block of the payload to build, unset before Cancun
+	// or without payload attributes
+	ParentBeaconBlockRoot *types.H256 `protobuf:"bytes,2,opt,name=parent_beacon_block_root,json=parentBeaconBlockRoot,proto3" json:"parent_beacon_block_root,omitempty"`
+}
+
+func (x *EngineForkChoiceUpdatedRequest) Reset() {
+	*x = EngineForkChoiceUpdatedRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_remoteproto_engine_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EngineForkChoiceUpdatedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EngineForkChoiceUpdatedRequest) ProtoMessage() {}
+
+func (x *EngineForkChoiceUpdatedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_remoteproto_engine_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EngineForkChoiceUpdatedRequest.ProtoReflect.Descriptor instead.
+func (*EngineForkChoiceUpdatedRequest) Descriptor() ([]byte, []int) {
+	return file_remoteproto_engine_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *EngineForkChoiceUpdatedRequest) GetRequest() *remote.EngineForkChoiceUpdatedRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *EngineForkChoiceUpdatedRequest) GetParentBeaconBlockRoot() *types.H256 {
+	if x != nil {
+		return x.ParentBeaconBlockRoot
+	}
+	return nil
+}
+
+var File_remoteproto_engine_proto protoreflect.FileDescriptor
+
+var file_remoteproto_engine_proto_rawDesc = []byte{
+	0x0a, 0x18, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x65, 0x6e,
+	0x67, 0x69, 0x6e, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0b, 0x72, 0x65, 0x6d, 0x6f,
+	0x74, 0x65, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x17, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x2f,
+	0x65, 0x74, 0x68, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x1a, 0x11, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2f, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x22, 0xa5, 0x01, 0x0a, 0x17, 0x45, 0x6e, 0x67, 0x69, 0x6e, 0x65, 0x4e, 0x65,
+	0x77, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x44, 0x0a, 0x11, 0x65, 0x78, 0x65, 0x63, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x70, 0x61, 0x79,
+	0x6c, 0x6f, 0x61, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x74, 0x79, 0x70,
+	0x65, 0x73, 0x2e, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x61, 0x79, 0x6c,
+	0x6f, 0x61, 0x64, 0x52, 0x10, 0x65, 0x78, 0x65, 0x63, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x61,
+	0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x44, 0x0a, 0x18, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x5f,
+	0x62, 0x65, 0x61, 0x63, 0x6f, 0x6e, 0x5f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x72, 0x6f, 0x6f,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2e,
+	0x48, 0x32, 0x35, 0x36, 0x52, 0x15, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x42, 0x65, 0x61, 0x63,
+	0x6f, 0x6e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x6f, 0x6f, 0x74, 0x22, 0xa8, 0x01, 0x0a, 0x1e,
+	0x45, 0x6e, 0x67, 0x69, 0x6e, 0x65, 0x46, 0x6f, 0x72, 0x6b, 0x43, 0x68, 0x6f, 0x69, 0x63, 0x65,
+	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x40,
+	0x0a, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x26, 0x2e, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x2e, 0x45, 0x6e, 0x67, 0x69, 0x6e, 0x65, 0x46,
+	0x6f, 0x72, 0x6b, 0x43, 0x68, 0x6f, 0x69, 0x63, 0x65, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x44, 0x0a, 0x18, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x5f, 0x62, 0x65, 0x61, 0x63, 0x6f,
+	0x6e, 0x5f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x72, 0x6f, 0x6f, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2e, 0x48, 0x32, 0x35, 0x36, 0x52,
+	0x15, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x42, 0x65, 0x61, 0x63, 0x6f, 0x6e, 0x42, 0x6c, 0x6f,
+	0x63, 0x6b, 0x52, 0x6f, 0x6f, 0x74, 0x32, 0xc4, 0x01, 0x0a, 0x06, 0x45, 0x6e, 0x67, 0x69, 0x6e,
+	0x65, 0x12, 0x4f, 0x0a, 0x0a, 0x4e, 0x65, 0x77, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12,
+	0x24, 0x2e, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x45, 0x6e,
+	0x67, 0x69, 0x6e, 0x65, 0x4e, 0x65, 0x77, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x2e, 0x45,
+	0x6e, 0x67, 0x69, 0x6e, 0x65, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x12, 0x69, 0x0a, 0x11, 0x46, 0x6f, 0x72, 0x6b, 0x43, 0x68, 0x6f, 0x69, 0x63, 0x65,
+	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x12, 0x2b, 0x2e, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x45, 0x6e, 0x67, 0x69, 0x6e, 0x65, 0x46, 0x6f, 0x72, 0x6b,
+	0x43, 0x68, 0x6f, 0x69, 0x63, 0x65, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x27, 0x2e, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x2e, 0x45, 0x6e,
+	0x67, 0x69, 0x6e, 0x65, 0x46, 0x6f, 0x72, 0x6b, 0x43, 0x68, 0x6f, 0x69, 0x63, 0x65, 0x55, 0x70,
+	0x64, 0x61, 0x74, 0x65, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x48, 0x5a,
+	0x46, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x65, 0x64, 0x67,
+	0x65, 0x72, 0x77, 0x61, 0x74, 0x63, 0x68, 0x2f, 0x65, 0x72, 0x69, 0x67, 0x6f, 0x6e, 0x2f, 0x65,
+	0x74, 0x68, 0x64, 0x62, 0x2f, 0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x61, 0x70, 0x69, 0x2f,
+	0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x3b, 0x72, 0x65, 0x6d, 0x6f,
+	0x74, 0x65, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_remoteproto_engine_proto_rawDescOnce sync.Once
+	file_remoteproto_engine_proto_rawDescData = file_remoteproto_engine_proto_rawDesc
+)
+
+func file_remoteproto_engine_proto_rawDescGZIP() []byte {
+	file_remoteproto_engine_proto_rawDescOnce.Do(func() {
+		file_remoteproto_engine_proto_rawDescData = protoimpl.X.CompressGZIP(file_remoteproto_engine_proto_rawDescData)
+	})
+	return file_remoteproto_engine_proto_rawDescData
+}
+
+var file_remoteproto_engine_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_remoteproto_engine_proto_goTypes = []interface{}{
+	(*EngineNewPayloadRequest)(nil),                // 0: remoteproto.EngineNewPayloadRequest
+	(*EngineForkChoiceUpdatedRequest)(nil),         // 1: remoteproto.EngineForkChoiceUpdatedRequest
+	(*types.ExecutionPayload)(nil),                 // 2: types.ExecutionPayload
+	(*types.H256)(nil),                             // 3: types.H256
+	(*remote.EngineForkChoiceUpdatedRequest)(nil),  // 4: remote.EngineForkChoiceUpdatedRequest
+	(*remote.EnginePayloadStatus)(nil),             // 5: remote.EnginePayloadStatus
+	(*remote.EngineForkChoiceUpdatedResponse)(nil), // 6: remote.EngineForkChoiceUpdatedResponse
+}
+var file_remoteproto_engine_proto_depIdxs = []int32{
+	2, // 0: remoteproto.EngineNewPayloadRequest.execution_payload:type_name -> types.ExecutionPayload
+	3, // 1: remoteproto.EngineNewPayloadRequest.parent_beacon_block_root:type_name -> types.H256
+	4, // 2: remoteproto.EngineForkChoiceUpdatedRequest.request:type_name -> remote.EngineForkChoiceUpdatedRequest
+	3, // 3: remoteproto.EngineForkChoiceUpdatedRequest.parent_beacon_block_root:type_name -> types.H256
+	0, // 4: remoteproto.Engine.NewPayload:input_type -> remoteproto.EngineNewPayloadRequest
+	1, // 5: remoteproto.Engine.ForkChoiceUpdated:input_type -> remoteproto.EngineForkChoiceUpdatedRequest
+	5, // 6: remoteproto.Engine.NewPayload:output_type -> remote.EnginePayloadStatus
+	6, // 7: remoteproto.Engine.ForkChoiceUpdated:output_type -> remote.EngineForkChoiceUpdatedResponse
+	6, // [6:8] is the sub-list for method output_type
+	4, // [4:6] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_remoteproto_engine_proto_init() }
+func file_remoteproto_engine_proto_init() {
+	if File_remoteproto_engine_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_remoteproto_engine_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EngineNewPayloadRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_remoteproto_engine_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EngineForkChoiceUpdatedRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_remoteproto_engine_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_remoteproto_engine_proto_goTypes,
+		DependencyIndexes: file_remoteproto_engine_proto_depIdxs,
+		MessageInfos:      file_remoteproto_engine_proto_msgTypes,
+	}.Build()
+	File_remoteproto_engine_proto = out.File
+	file_remoteproto_engine_proto_rawDesc = nil
+	file_remoteproto_engine_proto_goTypes = nil
+	file_remoteproto_engine_proto_depIdxs = nil
+}
@@ -0,0 +1,151 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.22.2
+// source: remoteproto/engine.proto
+
+package remoteproto
+
+import (
+	context "context"
+	remote "github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Engine_NewPayload_FullMethodName        = "/remoteproto.Engine/NewPayload"
+	Engine_ForkChoiceUpdated_FullMethodName = "/remoteproto.Engine/ForkChoiceUpdated"
+)
+
+// EngineClient is the client API for Engine service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type EngineClient interface {
+	// NewPayload is EngineNewPayload of ETHBACKEND with the parameters added by Cancun.
+	NewPayload(ctx context.Context, in *EngineNewPayloadRequest, opts ...grpc.CallOption) (*remote.EnginePayloadStatus, error)
+	// ForkChoiceUpdated is EngineForkChoiceUpdated of ETHBACKEND with the payload attributes added by Cancun.
+	ForkChoiceUpdated(ctx context.Context, in *EngineForkChoiceUpdatedRequest, opts ...grpc.CallOption) (*remote.EngineForkChoiceUpdatedResponse, error)
+}
+
+type engineClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEngineClient(cc grpc.ClientConnInterface) EngineClient {
+	return &engineClient{cc}
+}
+
+func (c *engineClient) NewPayload(ctx context.Context, in *EngineNewPayloadRequest, opts ...grpc.CallOption) (*remote.EnginePayloadStatus, error) {
+	out := new(remote.EnginePayloadStatus)
+	err := c.cc.Invoke(ctx, Engine_NewPayload_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineClient) ForkChoiceUpdated(ctx context.Context, in *EngineForkChoiceUpdatedRequest, opts ...grpc.CallOption) (*remote.EngineForkChoiceUpdatedResponse, error) {
+	out := new(remote.EngineForkChoiceUpdatedResponse)
+	err := c.cc.Invoke(ctx, Engine_ForkChoiceUpdated_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EngineServer is the server API for Engine service.
+// All implementations must embed UnimplementedEngineServer
+// for forward compatibility
+type EngineServer interface {
+	// NewPayload is EngineNewPayload of ETHBACKEND with the parameters added by Cancun.
+	NewPayload(context.Context, *EngineNewPayloadRequest) (*remote.EnginePayloadStatus, error)
+	// ForkChoiceUpdated is EngineForkChoiceUpdated of ETHBACKEND with the payload attributes added by Cancun.
+	ForkChoiceUpdated(context.Context, *EngineForkChoiceUpdatedRequest) (*remote.EngineForkChoiceUpdatedResponse, error)
+	mustEmbedUnimplementedEngineServer()
+}
+
+// UnimplementedEngineServer must be embedded to have forward compatible implementations.
+type UnimplementedEngineServer struct {
+}
+
+func (UnimplementedEngineServer) NewPayload(context.Context, *EngineNewPayloadRequest) (*remote.EnginePayloadStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NewPayload not implemented")
+}
+func (UnimplementedEngineServer) ForkChoiceUpdated(context.Context, *EngineForkChoiceUpdatedRequest) (*remote.EngineForkChoiceUpdatedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ForkChoiceUpdated not implemented")
+}
+func (UnimplementedEngineServer) mustEmbedUnimplementedEngineServer() {}
+
+// UnsafeEngineServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EngineServer will
+// result in compilation errors.
+type UnsafeEngineServer interface {
+	mustEmbedUnimplementedEngineServer()
+}
+
+func RegisterEngineServer(s grpc.ServiceRegistrar, srv EngineServer) {
+	s.RegisterService(&Engine_ServiceDesc, srv)
+}
+
+func _Engine_NewPayload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EngineNewPayloadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineServer).NewPayload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Engine_NewPayload_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineServer).NewPayload(ctx, req.(*EngineNewPayloadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Engine_ForkChoiceUpdated_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EngineForkChoiceUpdatedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineServer).ForkChoiceUpdated(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Engine_ForkChoiceUpdated_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineServer).ForkChoiceUpdated(ctx, req.(*EngineForkChoiceUpdatedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Engine_ServiceDesc is the grpc.ServiceDesc for Engine service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Engine_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "remoteproto.Engine",
+	HandlerType: (*EngineServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "NewPayload",
+			Handler:    _Engine_NewPayload_Handler,
+		},
+		{
+			MethodName: "ForkChoiceUpdated",
+			Handler:    _Engine_ForkChoiceUpdated_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "remoteproto/engine.proto",
+}
@@ -0,0 +1,159 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.30.0
+// 	protoc        v4.22.2
+// source: remoteproto/localtxs.proto
+
+package remoteproto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type MarkLocalTxsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// 32 bytes each
+	Hashes [][]byte `protobuf:"bytes,1,rep,name=hashes,proto3" json:"hashes,omitempty"`
+}
+
+func (x *MarkLocalTxsRequest) Reset() {
+	*x = MarkLocalTxsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_remoteproto_localtxs_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MarkLocalTxsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MarkLocalTxsRequest) ProtoMessage() {}
+
+func (x *MarkLocalTxsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_remoteproto_localtxs_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MarkLocalTxsRequest.ProtoReflect.Descriptor instead.
+func (*MarkLocalTxsRequest) Descriptor() ([]byte, []int) {
+	return file_remoteproto_localtxs_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *MarkLocalTxsRequest) GetHashes() [][]byte {
+	if x != nil {
+		return x.Hashes
+	}
+	return nil
+}
+
+var File_remoteproto_localtxs_proto protoreflect.FileDescriptor
+
+var file_remoteproto_localtxs_proto_rawDesc = []byte{
+	0x0a, 0x1a, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x6c, 0x6f,
+	0x63, 0x61, 0x6c, 0x74, 0x78, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0b, 0x72, 0x65,
+	0x6d, 0x6f, 0x74, 0x65, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1b, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x65, 0x6d, 0x70, 0x74, 0x79,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x2d, 0x0a, 0x13, 0x4d, 0x61, 0x72, 0x6b, 0x4c, 0x6f,
+	0x63, 0x61, 0x6c, 0x54, 0x78, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a,
+	0x06, 0x68, 0x61, 0x73, 0x68, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x06, 0x68,
+	0x61, 0x73, 0x68, 0x65, 0x73, 0x32, 0x54, 0x0a, 0x08, 0x4c, 0x6f, 0x63, 0x61, 0x6c, 0x54, 0x78,
+	0x73, 0x12, 0x48, 0x0a, 0x0c, 0x4d, 0x61, 0x72, 0x6b, 0x4c, 0x6f, 0x63, 0x61, 0x6c, 0x54, 0x78,
+	0x73, 0x12, 0x20, 0x2e, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
+	0x4d, 0x61, 0x72, 0x6b, 0x4c, 0x6f, 0x63, 0x61, 0x6c, 0x54, 0x78, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x42, 0x48, 0x5a, 0x46, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x65, 0x64, 0x67, 0x65, 0x72,
+	0x77, 0x61, 0x74, 0x63, 0x68, 0x2f, 0x65, 0x72, 0x69, 0x67, 0x6f, 0x6e, 0x2f, 0x65, 0x74, 0x68,
+	0x64, 0x62, 0x2f, 0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x61, 0x70, 0x69, 0x2f, 0x72, 0x65,
+	0x6d, 0x6f, 0x74, 0x65, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x3b, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_remoteproto_localtxs_proto_rawDescOnce sync.Once
+	file_remoteproto_localtxs_proto_rawDescData = file_remoteproto_localtxs_proto_rawDesc
+)
+
+func file_remoteproto_localtxs_proto_rawDescGZIP() []byte {
+	file_remoteproto_localtxs_proto_rawDescOnce.Do(func() {
+		file_remoteproto_localtxs_proto_rawDescData = protoimpl.X.CompressGZIP(file_remoteproto_localtxs_proto_rawDescData)
+	})
+	return file_remoteproto_localtxs_proto_rawDescData
+}
+
+var file_remoteproto_localtxs_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_remoteproto_localtxs_proto_goTypes = []interface{}{
+	(*MarkLocalTxsRequest)(nil), // 0: remoteproto.MarkLocalTxsRequest
+	(*emptypb.Empty)(nil),       // 1: google.protobuf.Empty
+}
+var file_remoteproto_localtxs_proto_depIdxs = []int32{
+	0, // 0: remoteproto.LocalTxs.MarkLocalTxs:input_type -> remoteproto.MarkLocalTxsRequest
+	1, // 1: remoteproto.LocalTxs.MarkLocalTxs:output_type -> google.protobuf.Empty
+	1, // [1:2] is the sub-list for method output_type
+	0, // [0:1] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_remoteproto_localtxs_proto_init() }
+func file_remoteproto_localtxs_proto_init() {
+	if File_remoteproto_localtxs_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_remoteproto_localtxs_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MarkLocalTxsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_remoteproto_localtxs_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_remoteproto_localtxs_proto_goTypes,
+		DependencyIndexes: file_remoteproto_localtxs_proto_depIdxs,
+		MessageInfos:      file_remoteproto_localtxs_proto_msgTypes,
+	}.Build()
+	File_remoteproto_localtxs_proto = out.File
+	file_remoteproto_localtxs_proto_rawDesc = nil
+	file_remoteproto_localtxs_proto_goTypes = nil
+	file_remoteproto_localtxs_proto_depIdxs = nil
+}
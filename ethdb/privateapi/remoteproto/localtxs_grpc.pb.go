@@ -0,0 +1,110 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.22.2
+// source: remoteproto/localtxs.proto
+
+package remoteproto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	LocalTxs_MarkLocalTxs_FullMethodName = "/remoteproto.LocalTxs/MarkLocalTxs"
+)
+
+// LocalTxsClient is the client API for LocalTxs service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type LocalTxsClient interface {
+	MarkLocalTxs(ctx context.Context, in *MarkLocalTxsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type localTxsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLocalTxsClient(cc grpc.ClientConnInterface) LocalTxsClient {
+	return &localTxsClient{cc}
+}
+
+func (c *localTxsClient) MarkLocalTxs(ctx context.Context, in *MarkLocalTxsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, LocalTxs_MarkLocalTxs_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LocalTxsServer is the server API for LocalTxs service.
+// All implementations must embed UnimplementedLocalTxsServer
+// for forward compatibility
+type LocalTxsServer interface {
+	MarkLocalTxs(context.Context, *MarkLocalTxsRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedLocalTxsServer()
+}
+
+// UnimplementedLocalTxsServer must be embedded to have forward compatible implementations.
+type UnimplementedLocalTxsServer struct {
+}
+
+func (UnimplementedLocalTxsServer) MarkLocalTxs(context.Context, *MarkLocalTxsRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MarkLocalTxs not implemented")
+}
+func (UnimplementedLocalTxsServer) mustEmbedUnimplementedLocalTxsServer() {}
+
+// UnsafeLocalTxsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LocalTxsServer will
+// result in compilation errors.
+type UnsafeLocalTxsServer interface {
+	mustEmbedUnimplementedLocalTxsServer()
+}
+
+func RegisterLocalTxsServer(s grpc.ServiceRegistrar, srv LocalTxsServer) {
+	s.RegisterService(&LocalTxs_ServiceDesc, srv)
+}
+
+func _LocalTxs_MarkLocalTxs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MarkLocalTxsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LocalTxsServer).MarkLocalTxs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LocalTxs_MarkLocalTxs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LocalTxsServer).MarkLocalTxs(ctx, req.(*MarkLocalTxsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// LocalTxs_ServiceDesc is the grpc.ServiceDesc for LocalTxs service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LocalTxs_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "remoteproto.LocalTxs",
+	HandlerType: (*LocalTxsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "MarkLocalTxs",
+			Handler:    _LocalTxs_MarkLocalTxs_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "remoteproto/localtxs.proto",
+}
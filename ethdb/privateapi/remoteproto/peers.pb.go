@@ -0,0 +1,235 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.30.0
+// 	protoc        v4.22.2
+// source: remoteproto/peers.proto
+
+package remoteproto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PeerProtocols struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// same as the id of types.PeerInfo
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// JSON encoded protocols metadata, the same as the protocols of types.NodeInfoReply
+	Protocols []byte `protobuf:"bytes,2,opt,name=protocols,proto3" json:"protocols,omitempty"`
+}
+
+func (x *PeerProtocols) Reset() {
+	*x = PeerProtocols{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_remoteproto_peers_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PeerProtocols) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeerProtocols) ProtoMessage() {}
+
+func (x *PeerProtocols) ProtoReflect() protoreflect.Message {
+	mi := &file_remoteproto_peers_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeerProtocols.ProtoReflect.Descriptor instead.
+func (*PeerProtocols) Descriptor() ([]byte, []int) {
+	return file_remoteproto_peers_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PeerProtocols) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *PeerProtocols) GetProtocols() []byte {
+	if x != nil {
+		return x.Protocols
+	}
+	return nil
+}
+
+type PeersProtocolsReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Peers []*PeerProtocols `protobuf:"bytes,1,rep,name=peers,proto3" json:"peers,omitempty"`
+}
+
+func (x *PeersProtocolsReply) Reset() {
+	*x = PeersProtocolsReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_remoteproto_peers_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PeersProtocolsReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeersProtocolsReply) ProtoMessage() {}
+
+func (x *PeersProtocolsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_remoteproto_peers_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeersProtocolsReply.ProtoReflect.Descriptor instead.
+func (*PeersProtocolsReply) Descriptor() ([]byte, []int) {
+	return file_remoteproto_peers_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PeersProtocolsReply) GetPeers() []*PeerProtocols {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+var File_remoteproto_peers_proto protoreflect.FileDescriptor
+
+var file_remoteproto_peers_proto_rawDesc = []byte{
+	0x0a, 0x17, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x70, 0x65,
+	0x65, 0x72, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0b, 0x72, 0x65, 0x6d, 0x6f, 0x74,
+	0x65, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1b, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x22, 0x3d, 0x0a, 0x0d, 0x50, 0x65, 0x65, 0x72, 0x50, 0x72, 0x6f, 0x74, 0x6f,
+	0x63, 0x6f, 0x6c, 0x73, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x02, 0x69, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
+	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
+	0x6c, 0x73, 0x22, 0x47, 0x0a, 0x13, 0x50, 0x65, 0x65, 0x72, 0x73, 0x50, 0x72, 0x6f, 0x74, 0x6f,
+	0x63, 0x6f, 0x6c, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x30, 0x0a, 0x05, 0x70, 0x65, 0x65,
+	0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x72, 0x65, 0x6d, 0x6f, 0x74,
+	0x65, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x50, 0x72, 0x6f, 0x74, 0x6f,
+	0x63, 0x6f, 0x6c, 0x73, 0x52, 0x05, 0x70, 0x65, 0x65, 0x72, 0x73, 0x32, 0x53, 0x0a, 0x05, 0x50,
+	0x65, 0x65, 0x72, 0x73, 0x12, 0x4a, 0x0a, 0x0e, 0x50, 0x65, 0x65, 0x72, 0x73, 0x50, 0x72, 0x6f,
+	0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x73, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x20,
+	0x2e, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x65, 0x65,
+	0x72, 0x73, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79,
+	0x42, 0x48, 0x5a, 0x46, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c,
+	0x65, 0x64, 0x67, 0x65, 0x72, 0x77, 0x61, 0x74, 0x63, 0x68, 0x2f, 0x65, 0x72, 0x69, 0x67, 0x6f,
+	0x6e, 0x2f, 0x65, 0x74, 0x68, 0x64, 0x62, 0x2f, 0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x61,
+	0x70, 0x69, 0x2f, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x3b, 0x72,
+	0x65, 0x6d, 0x6f, 0x74, 0x65, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
+}
+
+var (
+	file_remoteproto_peers_proto_rawDescOnce sync.Once
+	file_remoteproto_peers_proto_rawDescData = file_remoteproto_peers_proto_rawDesc
+)
+
+func file_remoteproto_peers_proto_rawDescGZIP() []byte {
+	file_remoteproto_peers_proto_rawDescOnce.Do(func() {
+		file_remoteproto_peers_proto_rawDescData = protoimpl.X.CompressGZIP(file_remoteproto_peers_proto_rawDescData)
+	})
+	return file_remoteproto_peers_proto_rawDescData
+}
+
+var file_remoteproto_peers_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_remoteproto_peers_proto_goTypes = []interface{}{
+	(*PeerProtocols)(nil),       // 0: remoteproto.PeerProtocols
+	(*PeersProtocolsReply)(nil), // 1: remoteproto.PeersProtocolsReply
+	(*emptypb.Empty)(nil),       // 2: google.protobuf.Empty
+}
+var file_remoteproto_peers_proto_depIdxs = []int32{
+	0, // 0: remoteproto.PeersProtocolsReply.peers:type_name -> remoteproto.PeerProtocols
+	2, // 1: remoteproto.Peers.PeersProtocols:input_type -> google.protobuf.Empty
+	1, // 2: remoteproto.Peers.PeersProtocols:output_type -> remoteproto.PeersProtocolsReply
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_remoteproto_peers_proto_init() }
+func file_remoteproto_peers_proto_init() {
+	if File_remoteproto_peers_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_remoteproto_peers_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PeerProtocols); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_remoteproto_peers_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PeersProtocolsReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_remoteproto_peers_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_remoteproto_peers_proto_goTypes,
+		DependencyIndexes: file_remoteproto_peers_proto_depIdxs,
+		MessageInfos:      file_remoteproto_peers_proto_msgTypes,
+	}.Build()
+	File_remoteproto_peers_proto = out.File
+	file_remoteproto_peers_proto_rawDesc = nil
+	file_remoteproto_peers_proto_goTypes = nil
+	file_remoteproto_peers_proto_depIdxs = nil
+}
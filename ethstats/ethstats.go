@@ -33,15 +33,18 @@ import (
 
 	"github.com/gorilla/websocket"
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/log/v3"
 
-	"github.com/ledgerwatch/erigon/cmd/sentry/sentry"
-	"github.com/ledgerwatch/erigon/consensus"
+	"github.com/ledgerwatch/erigon/cl/cltypes"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
-	"github.com/ledgerwatch/erigon/node"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/turbo/services"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
 )
 
 const (
@@ -50,24 +53,56 @@ const (
 	historyUpdateRange = 50
 )
 
+// Backend is the part of the node API the stats are collected from. Both the in-process
+// and the remote (gRPC) implementations of rpchelper.ApiBackend satisfy it, so the stats
+// can be reported from erigon itself or from a standalone rpcdaemon.
+type Backend interface {
+	NetVersion(ctx context.Context) (uint64, error)
+	NetPeerCount(ctx context.Context) (uint64, error)
+	ProtocolVersion(ctx context.Context) (uint64, error)
+	ClientVersion(ctx context.Context) (string, error)
+	NodeInfo(ctx context.Context, limit uint32) ([]p2p.NodeInfo, error)
+	Subscribe(ctx context.Context, cb func(*remote.SubscribeReply)) error
+}
+
+// BeaconChain is the consensus layer view of the chain, implemented by the Caplin fork choice store.
+type BeaconChain interface {
+	CachedHead() (libcommon.Hash, uint64)
+	JustifiedCheckpoint() *cltypes.Checkpoint
+	FinalizedCheckpoint() *cltypes.Checkpoint
+}
+
+// Config is the reporting url and the sources of the reported stats.
+type Config struct {
+	URL         string // nodename:secret@host:port
+	Backend     Backend
+	DB          kv.RoDB // Chain database, local or remote
+	BlockReader services.FullBlockReader
+	TxPool      txpool.TxpoolClient       // Optional, pending transactions aren't reported without it
+	Snapshots   *snapshotsync.RoSnapshots // Optional, snapshot status isn't reported without it
+	Beacon      BeaconChain               // Optional, beacon head and finality aren't reported without it
+}
+
 // Service implements an Ethereum netstats reporting daemon that pushes local
 // chain statistics up to a monitoring server.
 type Service struct {
-	servers   []*sentry.GrpcServer // Peer-to-peer server to retrieve networking infos
-	chaindb   kv.RoDB
-	networkid uint64
-	engine    consensus.Engine // Consensus engine to retrieve variadic block fields
+	backend     Backend
+	chaindb     kv.RoDB
+	blockReader services.FullBlockReader
+	txPool      txpool.TxpoolClient
+	snapshots   *snapshotsync.RoSnapshots
+	beacon      BeaconChain
 
 	node string // Name of the node to display on the monitoring page
 	pass string // Password to authorize access to the monitoring page
 	host string // Remote address of the monitoring service
 
-	quitCh <-chan struct{}
-	headCh <-chan [][]byte
+	ctx    context.Context
+	cancel context.CancelFunc
+	headCh chan struct{} // New head notifications are fed into this channel
 
 	pongCh chan struct{} // Pong notifications are fed into this channel
 	histCh chan []uint64 // History request block numbers are fed into this channel
-
 }
 
 // connWrapper is a wrapper to prevent concurrent-write or concurrent-read on the
@@ -120,33 +155,36 @@ func (w *connWrapper) Close() error {
 }
 
 // New returns a monitoring service ready for stats reporting.
-func New(node *node.Node, servers []*sentry.GrpcServer, chainDB kv.RoDB, engine consensus.Engine, url string, networkid uint64, quitCh <-chan struct{}, headCh chan [][]byte) error {
+func New(cfg Config) (*Service, error) {
 	// Parse the netstats connection url
 	re := regexp.MustCompile("([^:@]*)(:([^@]*))?@(.+)")
-	parts := re.FindStringSubmatch(url)
+	parts := re.FindStringSubmatch(cfg.URL)
 	if len(parts) != 5 {
-		return fmt.Errorf("invalid netstats url: \"%s\", should be nodename:secret@host:port", url)
+		return nil, fmt.Errorf("invalid netstats url: \"%s\", should be nodename:secret@host:port", cfg.URL)
 	}
+	ctx, cancel := context.WithCancel(context.Background())
 	ethstats := &Service{
-		engine:    engine,
-		servers:   servers,
-		node:      parts[1],
-		pass:      parts[3],
-		host:      parts[4],
-		pongCh:    make(chan struct{}),
-		histCh:    make(chan []uint64, 1),
-		networkid: networkid,
-		chaindb:   chainDB,
-		headCh:    headCh,
-		quitCh:    quitCh,
-	}
-
-	node.RegisterLifecycle(ethstats)
-	return nil
+		backend:     cfg.Backend,
+		chaindb:     cfg.DB,
+		blockReader: cfg.BlockReader,
+		txPool:      cfg.TxPool,
+		snapshots:   cfg.Snapshots,
+		beacon:      cfg.Beacon,
+		node:        parts[1],
+		pass:        parts[3],
+		host:        parts[4],
+		ctx:         ctx,
+		cancel:      cancel,
+		headCh:      make(chan struct{}, 1),
+		pongCh:      make(chan struct{}),
+		histCh:      make(chan []uint64, 1),
+	}
+	return ethstats, nil
 }
 
 // Start implements node.Lifecycle, starting up the monitoring and reporting daemon.
 func (s *Service) Start() error {
+	go s.subscribeHeads()
 	go s.loop()
 
 	log.Info("Stats daemon started")
@@ -155,10 +193,33 @@ func (s *Service) Start() error {
 
 // Stop implements node.Lifecycle, terminating the monitoring and reporting daemon.
 func (s *Service) Stop() error {
+	s.cancel()
 	log.Info("Stats daemon stopped")
 	return nil
 }
 
+// subscribeHeads feeds the new head events of the backend into headCh, resubscribing
+// until termination.
+func (s *Service) subscribeHeads() {
+	for {
+		err := s.backend.Subscribe(s.ctx, func(reply *remote.SubscribeReply) {
+			if reply.Type != remote.Event_HEADER {
+				return
+			}
+			select {
+			case s.headCh <- struct{}{}:
+			default:
+			}
+		})
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(10 * time.Second):
+		}
+		log.Debug("Stats head subscription closed, resubscribing", "err", err)
+	}
+}
+
 // loop keeps trying to connect to the netstats server, reporting chain events
 // until termination.
 func (s *Service) loop() {
@@ -176,7 +237,7 @@ func (s *Service) loop() {
 	// Loop reporting until termination
 	for {
 		select {
-		case <-s.quitCh:
+		case <-s.ctx.Done():
 			return
 		case <-errTimer.C:
 			// Establish a websocket connection to the server on any supported URL
@@ -209,12 +270,20 @@ func (s *Service) loop() {
 			}
 			go s.readLoop(conn)
 
+			// Send the initial stats so our node looks decent from the get go
+			if err = s.report(conn); err != nil {
+				log.Warn("Initial stats report failed", "err", err)
+				conn.Close()
+				errTimer.Reset(10 * time.Second)
+				continue
+			}
+
 			// Keep sending status updates until the connection breaks
 			fullReport := time.NewTicker(15 * time.Second)
 
 			for err == nil {
 				select {
-				case <-s.quitCh:
+				case <-s.ctx.Done():
 					fullReport.Stop()
 					// Make sure the connection is closed
 					conn.Close()
@@ -364,21 +433,22 @@ type authMsg struct {
 // login tries to authorize the client at the remote server.
 func (s *Service) login(conn *connWrapper) error {
 	// Construct and send the login authentication
-	// infos := s.server.NodeInfo()
-
-	protocols := make([]string, 0, len(s.servers))
-	for _, srv := range s.servers {
-		for _, p := range srv.Protocols {
-			protocols = append(protocols, fmt.Sprintf("%s/%d", p.Name, p.Version))
-		}
+	networkID, err := s.backend.NetVersion(s.ctx)
+	if err != nil {
+		return err
 	}
-	nodeName := "Erigon"
-	if len(s.servers) > 0 {
-		nodeInfo, err := s.servers[0].NodeInfo(context.TODO(), nil)
-		if err != nil {
-			return err
-		}
-		nodeName = nodeInfo.Name
+	protocol, err := s.backend.ProtocolVersion(s.ctx)
+	if err != nil {
+		return err
+	}
+	nodeName, err := s.backend.ClientVersion(s.ctx)
+	if err != nil {
+		return err
+	}
+	var port int
+	// The node info is only available with the sentries started
+	if nodesInfo, err := s.backend.NodeInfo(s.ctx, 1); err == nil && len(nodesInfo) > 0 {
+		nodeName, port = nodesInfo[0].Name, nodesInfo[0].Ports.Listener
 	}
 
 	auth := &authMsg{
@@ -386,9 +456,9 @@ func (s *Service) login(conn *connWrapper) error {
 		Info: nodeInfo{
 			Name:     s.node,
 			Node:     nodeName,
-			Port:     0,
-			Network:  fmt.Sprintf("%d", s.networkid),
-			Protocol: strings.Join(protocols, ", "),
+			Port:     port,
+			Network:  fmt.Sprintf("%d", networkID),
+			Protocol: fmt.Sprintf("eth/%d", protocol),
 			API:      "No",
 			Os:       runtime.GOOS,
 			OsVer:    runtime.GOARCH,
@@ -502,13 +572,21 @@ func (s uncleStats) MarshalJSON() ([]byte, error) {
 
 // reportBlock retrieves the current chain head and reports it to the stats server.
 func (s *Service) reportBlock(conn *connWrapper) error {
-	roTx, err := s.chaindb.BeginRo(context.Background())
+	roTx, err := s.chaindb.BeginRo(s.ctx)
 	if err != nil {
 		return err
 	}
 	defer roTx.Rollback()
 
-	block := rawdb.ReadCurrentBlock(roTx)
+	headHash := rawdb.ReadHeadBlockHash(roTx)
+	headNumber := rawdb.ReadHeaderNumber(roTx, headHash)
+	if headNumber == nil {
+		return nil
+	}
+	block, _, err := s.blockReader.BlockWithSenders(s.ctx, roTx, headHash, *headNumber)
+	if err != nil {
+		return err
+	}
 	if block == nil {
 		return nil
 	}
@@ -566,7 +644,7 @@ func (s *Service) assembleBlockStats(block *types.Block, td *big.Int) *blockStat
 // reportHistory retrieves the most recent batch of blocks and reports it to the
 // stats server.
 func (s *Service) reportHistory(conn *connWrapper, list []uint64) error {
-	roTx, err := s.chaindb.BeginRo(context.Background())
+	roTx, err := s.chaindb.BeginRo(s.ctx)
 	if err != nil {
 		return err
 	}
@@ -596,16 +674,22 @@ func (s *Service) reportHistory(conn *connWrapper, list []uint64) error {
 	history := make([]*blockStats, len(indexes))
 	for i, number := range indexes {
 		// Retrieve the next block if it's known to us
-		block, err := rawdb.ReadBlockByNumber(roTx, number)
+		hash, err := s.blockReader.CanonicalHash(s.ctx, roTx, number)
 		if err != nil {
 			return err
 		}
-		td, err := rawdb.ReadTd(roTx, block.Hash(), number)
-		if err != nil {
-			return err
+		var block *types.Block
+		if hash != (libcommon.Hash{}) {
+			if block, _, err = s.blockReader.BlockWithSenders(s.ctx, roTx, hash, number); err != nil {
+				return err
+			}
 		}
 		// If we do have the block, add to the history and continue
 		if block != nil {
+			td, err := rawdb.ReadTd(roTx, hash, number)
+			if err != nil {
+				return err
+			}
 			history[len(history)-1-i] = s.assembleBlockStats(block, td)
 			continue
 		}
@@ -629,25 +713,39 @@ func (s *Service) reportHistory(conn *connWrapper, list []uint64) error {
 	return conn.WriteJSON(report)
 }
 
+// pendStats is the information to report about pending transactions.
+type pendStats struct {
+	Pending int `json:"pending"`
+	Queued  int `json:"queued"`
+	BaseFee int `json:"baseFee"`
+}
+
 // reportPending retrieves the current number of pending transactions and reports
 // it to the stats server.
 func (s *Service) reportPending(conn *connWrapper) error {
-	/*	// Retrieve the pending count from the local blockchain
-		pending, _ := s.backend.Stats()
-		// Assemble the transaction stats and send it to the server
-		log.Trace("Sending pending transactions to ethstats", "count", pending)
-
-		stats := map[string]interface{}{
-			"id": s.node,
-			"stats": &pendStats{
-				Pending: pending,
-			},
-		}
-		report := map[string][]interface{}{
-			"emit": {"pending", stats},
-		}
-		return conn.WriteJSON(report)*/
-	return nil
+	if s.txPool == nil {
+		return nil
+	}
+	// Retrieve the pending count from the transaction pool
+	status, err := s.txPool.Status(s.ctx, &txpool.StatusRequest{})
+	if err != nil {
+		return err
+	}
+	// Assemble the transaction stats and send it to the server
+	log.Trace("Sending pending transactions to ethstats", "count", status.PendingCount)
+
+	stats := map[string]interface{}{
+		"id": s.node,
+		"stats": &pendStats{
+			Pending: int(status.PendingCount),
+			Queued:  int(status.QueuedCount),
+			BaseFee: int(status.BaseFeeCount),
+		},
+	}
+	report := map[string][]interface{}{
+		"emit": {"pending", stats},
+	}
+	return conn.WriteJSON(report)
 }
 
 // nodeStats is the information to report about the local node.
@@ -659,16 +757,51 @@ type nodeStats struct {
 	GoodPeers int  `json:"peers"`
 	GasPrice  int  `json:"gasPrice"`
 	Uptime    int  `json:"uptime"`
+
+	SyncStages []stageStats   `json:"syncStages"`
+	Snapshots  *snapshotStats `json:"snapshots,omitempty"`
+	Beacon     *beaconStats   `json:"beacon,omitempty"`
+}
+
+// stageStats is the progress of a sync stage.
+type stageStats struct {
+	Stage    string `json:"stage"`
+	Progress uint64 `json:"progress"`
 }
 
-// reportStats retrieves various stats about the node at the networking and
-// mining layer and reports it to the stats server.
+// snapshotStats is the information to report about the block snapshots.
+type snapshotStats struct {
+	Enabled         bool   `json:"enabled"`
+	SegmentsReady   bool   `json:"segmentsReady"`
+	IndicesReady    bool   `json:"indicesReady"`
+	BlocksAvailable uint64 `json:"blocksAvailable"`
+}
+
+// beaconStats is the information to report about the consensus layer chain.
+type beaconStats struct {
+	HeadSlot       uint64         `json:"headSlot"`
+	HeadRoot       libcommon.Hash `json:"headRoot"`
+	JustifiedEpoch uint64         `json:"justifiedEpoch"`
+	FinalizedEpoch uint64         `json:"finalizedEpoch"`
+	FinalizedRoot  libcommon.Hash `json:"finalizedRoot"`
+}
+
+// reportStats retrieves various stats about the node at the networking, sync and
+// consensus layer and reports it to the stats server.
 func (s *Service) reportStats(conn *connWrapper) error {
-	roTx, err := s.chaindb.BeginRo(context.Background())
+	roTx, err := s.chaindb.BeginRo(s.ctx)
 	if err != nil {
 		return err
 	}
 	defer roTx.Rollback()
+	syncStages := make([]stageStats, 0, len(stages.AllStages))
+	for _, stage := range stages.AllStages {
+		progress, err := stages.GetStageProgress(roTx, stage)
+		if err != nil {
+			return err
+		}
+		syncStages = append(syncStages, stageStats{Stage: string(stage), Progress: progress})
+	}
 	sync, err := stages.GetStageProgress(roTx, stages.Execution)
 	if err != nil {
 		return err
@@ -677,28 +810,50 @@ func (s *Service) reportStats(conn *connWrapper) error {
 	if err != nil {
 		return err
 	}
-	// TODO(Giulio2002): peer tracking
-	peerCount := 0
-	for _, srv := range s.servers {
-		counts := srv.SimplePeerCount()
-		for _, count := range counts {
-			peerCount += count
+	peerCount, err := s.backend.NetPeerCount(s.ctx)
+	if err != nil {
+		return err
+	}
+	stats := &nodeStats{
+		Active:     true,
+		Mining:     false,
+		Hashrate:   0,
+		GoodPeers:  int(peerCount),
+		GasPrice:   0,
+		Syncing:    sync != finishSync,
+		Uptime:     100,
+		SyncStages: syncStages,
+	}
+	if s.snapshots != nil {
+		stats.Snapshots = &snapshotStats{
+			Enabled:         s.snapshots.Cfg().Enabled,
+			SegmentsReady:   s.snapshots.SegmentsReady(),
+			IndicesReady:    s.snapshots.IndicesReady(),
+			BlocksAvailable: s.snapshots.BlocksAvailable(),
 		}
 	}
-	stats := map[string]interface{}{
-		"id": s.node,
-		"stats": &nodeStats{
-			Active:    true,
-			Mining:    false,
-			Hashrate:  0,
-			GoodPeers: peerCount,
-			GasPrice:  0,
-			Syncing:   sync != finishSync,
-			Uptime:    100,
-		},
+	if s.beacon != nil {
+		stats.Beacon = s.assembleBeaconStats()
 	}
 	report := map[string][]interface{}{
-		"emit": {"stats", stats},
+		"emit": {"stats", map[string]interface{}{
+			"id":    s.node,
+			"stats": stats,
+		}},
 	}
 	return conn.WriteJSON(report)
 }
+
+// assembleBeaconStats reports the consensus layer chain as far as it is known, the head is the one of the latest
+// fork choice run by Caplin rather than running it again.
+func (s *Service) assembleBeaconStats() *beaconStats {
+	stats := &beaconStats{}
+	stats.HeadRoot, stats.HeadSlot = s.beacon.CachedHead()
+	if justified := s.beacon.JustifiedCheckpoint(); justified != nil {
+		stats.JustifiedEpoch = justified.Epoch
+	}
+	if finalized := s.beacon.FinalizedCheckpoint(); finalized != nil {
+		stats.FinalizedEpoch, stats.FinalizedRoot = finalized.Epoch, finalized.Root
+	}
+	return stats
+}
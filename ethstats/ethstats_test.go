@@ -0,0 +1,263 @@
+package ethstats_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/ledgerwatch/erigon/cl/cltypes"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/ethstats"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/ledgerwatch/erigon/turbo/stages"
+)
+
+type testBackend struct {
+	heads chan func(*remote.SubscribeReply)
+}
+
+func (b *testBackend) NetVersion(context.Context) (uint64, error)      { return 1337, nil }
+func (b *testBackend) NetPeerCount(context.Context) (uint64, error)    { return 3, nil }
+func (b *testBackend) ProtocolVersion(context.Context) (uint64, error) { return 67, nil }
+func (b *testBackend) ClientVersion(context.Context) (string, error)   { return "erigon/test", nil }
+func (b *testBackend) NodeInfo(context.Context, uint32) ([]p2p.NodeInfo, error) {
+	info := p2p.NodeInfo{Name: "erigon/test/linux"}
+	info.Ports.Listener = 30303
+	return []p2p.NodeInfo{info}, nil
+}
+func (b *testBackend) Subscribe(ctx context.Context, cb func(*remote.SubscribeReply)) error {
+	b.heads <- cb
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+type testTxPool struct {
+	txpool.TxpoolClient
+}
+
+func (testTxPool) Status(context.Context, *txpool.StatusRequest, ...grpc.CallOption) (*txpool.StatusReply, error) {
+	return &txpool.StatusReply{PendingCount: 5, QueuedCount: 2}, nil
+}
+
+type testBeacon struct{}
+
+func (testBeacon) CachedHead() (libcommon.Hash, uint64)     { return libcommon.Hash{1}, 320 }
+func (testBeacon) JustifiedCheckpoint() *cltypes.Checkpoint { return &cltypes.Checkpoint{Epoch: 9} }
+func (testBeacon) FinalizedCheckpoint() *cltypes.Checkpoint {
+	return &cltypes.Checkpoint{Epoch: 8, Root: libcommon.Hash{2}}
+}
+
+// statsServer is an ethstats compatible websocket server recording the reports.
+type statsServer struct {
+	t        *testing.T
+	conn     *websocket.Conn
+	wlock    sync.Mutex
+	hello    chan json.RawMessage
+	messages chan []json.RawMessage
+}
+
+func newStatsServer(t *testing.T) (*statsServer, string) {
+	s := &statsServer{t: t, hello: make(chan json.RawMessage, 1), messages: make(chan []json.RawMessage, 100)}
+	server := httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(server.Close)
+	return s, "node:secret@" + strings.TrimPrefix(server.URL, "http://")
+}
+
+func (s *statsServer) serve(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	s.wlock.Lock()
+	s.conn = conn
+	s.wlock.Unlock()
+	for {
+		var msg map[string][]json.RawMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		var command string
+		if err := json.Unmarshal(msg["emit"][0], &command); err != nil {
+			return
+		}
+		switch command {
+		case "hello":
+			s.hello <- msg["emit"][1]
+			s.send(map[string][]string{"emit": {"ready"}})
+		case "node-ping":
+			s.send(map[string][]interface{}{"emit": {"node-pong", map[string]string{}}})
+		default:
+			s.messages <- msg["emit"]
+		}
+	}
+}
+
+func (s *statsServer) send(v interface{}) {
+	s.wlock.Lock()
+	defer s.wlock.Unlock()
+	require.NoError(s.t, s.conn.WriteJSON(v))
+}
+
+// next waits for the next report of the given kind and decodes it.
+func (s *statsServer) next(command string, report interface{}) {
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case msg := <-s.messages:
+			var received string
+			require.NoError(s.t, json.Unmarshal(msg[0], &received))
+			if received == command {
+				require.NoError(s.t, json.Unmarshal(msg[1], report))
+				return
+			}
+		case <-timeout:
+			s.t.Fatalf("no %s report", command)
+		}
+	}
+}
+
+func TestEthstats(t *testing.T) {
+	m := stages.Mock(t)
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 3, func(i int, b *core.BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(b.TxNonce(m.Address), libcommon.Address{1}, uint256.NewInt(1), params.TxGas, uint256.NewInt(params.GWei), nil), *types.LatestSignerForChainID(m.ChainConfig.ChainID), m.Key)
+		require.NoError(t, err)
+		b.AddTx(tx)
+	}, false)
+	require.NoError(t, err)
+	require.NoError(t, m.InsertChain(chain))
+
+	server, url := newStatsServer(t)
+	backend := &testBackend{heads: make(chan func(*remote.SubscribeReply), 1)}
+	service, err := ethstats.New(ethstats.Config{
+		URL:         url,
+		Backend:     backend,
+		DB:          m.DB,
+		BlockReader: snapshotsync.NewBlockReaderWithSnapshots(m.BlockSnapshots, m.TransactionsV3),
+		TxPool:      testTxPool{},
+		Snapshots:   m.BlockSnapshots,
+		Beacon:      testBeacon{},
+	})
+	require.NoError(t, err)
+	require.NoError(t, service.Start())
+	defer service.Stop()
+
+	var hello struct {
+		ID     string `json:"id"`
+		Secret string `json:"secret"`
+		Info   struct {
+			Node     string `json:"node"`
+			Port     int    `json:"port"`
+			Network  string `json:"net"`
+			Protocol string `json:"protocol"`
+		} `json:"info"`
+	}
+	select {
+	case msg := <-server.hello:
+		require.NoError(t, json.Unmarshal(msg, &hello))
+	case <-time.After(10 * time.Second):
+		t.Fatal("no login")
+	}
+	require.Equal(t, "node", hello.ID)
+	require.Equal(t, "secret", hello.Secret)
+	require.Equal(t, "erigon/test/linux", hello.Info.Node)
+	require.Equal(t, 30303, hello.Info.Port)
+	require.Equal(t, "1337", hello.Info.Network)
+	require.Equal(t, "eth/67", hello.Info.Protocol)
+
+	// The initial report
+	var block struct {
+		Block struct {
+			Number uint64 `json:"number"`
+			Hash   libcommon.Hash
+			Txs    []struct{ Hash libcommon.Hash } `json:"transactions"`
+		} `json:"block"`
+	}
+	server.next("block", &block)
+	require.Equal(t, uint64(3), block.Block.Number)
+	require.Equal(t, chain.TopBlock.Hash(), block.Block.Hash)
+	require.Len(t, block.Block.Txs, 1)
+
+	var pending struct {
+		Stats struct {
+			Pending int `json:"pending"`
+			Queued  int `json:"queued"`
+		} `json:"stats"`
+	}
+	server.next("pending", &pending)
+	require.Equal(t, 5, pending.Stats.Pending)
+	require.Equal(t, 2, pending.Stats.Queued)
+
+	var stats struct {
+		Stats struct {
+			Peers      int  `json:"peers"`
+			Syncing    bool `json:"syncing"`
+			SyncStages []struct {
+				Stage    string `json:"stage"`
+				Progress uint64 `json:"progress"`
+			} `json:"syncStages"`
+			Snapshots *struct {
+				Enabled bool `json:"enabled"`
+			} `json:"snapshots"`
+			Beacon *struct {
+				HeadSlot       uint64         `json:"headSlot"`
+				FinalizedEpoch uint64         `json:"finalizedEpoch"`
+				FinalizedRoot  libcommon.Hash `json:"finalizedRoot"`
+			} `json:"beacon"`
+		} `json:"stats"`
+	}
+	server.next("stats", &stats)
+	require.Equal(t, 3, stats.Stats.Peers)
+	require.False(t, stats.Stats.Syncing)
+	progress := map[string]uint64{}
+	for _, stage := range stats.Stats.SyncStages {
+		progress[stage.Stage] = stage.Progress
+	}
+	require.Equal(t, uint64(3), progress["Execution"])
+	require.Equal(t, uint64(3), progress["Finish"])
+	require.NotNil(t, stats.Stats.Snapshots)
+	require.NotNil(t, stats.Stats.Beacon)
+	require.Equal(t, uint64(320), stats.Stats.Beacon.HeadSlot)
+	require.Equal(t, uint64(8), stats.Stats.Beacon.FinalizedEpoch)
+	require.Equal(t, libcommon.Hash{2}, stats.Stats.Beacon.FinalizedRoot)
+
+	// A new head is reported as it arrives
+	onHead := <-backend.heads
+	onHead(&remote.SubscribeReply{Type: remote.Event_HEADER})
+	server.next("block", &block)
+	require.Equal(t, uint64(3), block.Block.Number)
+
+	// The requested history is reported from the newest to the oldest
+	server.send(map[string][]interface{}{"emit": {"history", map[string]interface{}{"list": []uint64{1, 2}}}})
+	var history struct {
+		History []struct {
+			Number uint64 `json:"number"`
+		} `json:"history"`
+	}
+	server.next("history", &history)
+	require.Len(t, history.History, 2)
+	require.Equal(t, uint64(2), history.History[0].Number)
+	require.Equal(t, uint64(1), history.History[1].Number)
+}
+
+func TestInvalidURL(t *testing.T) {
+	_, err := ethstats.New(ethstats.Config{URL: "localhost"})
+	require.Error(t, err)
+}
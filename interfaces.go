@@ -157,6 +157,9 @@ type FilterQuery struct {
 	// {{A}, {B}}         matches topic A in first position AND B in second position
 	// {{A, B}, {C, D}}   matches topic (A OR B) in first position AND (C OR D) in second position
 	Topics [][]libcommon.Hash
+
+	ResumeToken []byte // used by eth_subscribe("logs"), continues after the log delivered with this token
+	Backfill    bool   // used by eth_subscribe("logs"), sends the logs from FromBlock before the new ones
 }
 
 // LogFilterer provides access to contract log events using a one-off query or continuous
@@ -155,6 +155,8 @@ type Config struct {
 	trustedNodesWarning bool
 
 	SentryLogPeerInfo bool
+	// SentryLocalTxBroadcast is the policy of the embedded sentries for the local transactions: full, hashes, trusted or none
+	SentryLocalTxBroadcast string
 
 	TLSConnection bool
 	TLSCertFile   string
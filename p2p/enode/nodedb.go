@@ -43,11 +43,12 @@ import (
 // Keys in the node database.
 
 const (
-	dbVersionKey   = "version" // Version of the database to flush if changes
-	dbNodePrefix   = "n:"      // Identifier to prefix node entries with
-	dbLocalPrefix  = "local:"
-	dbDiscoverRoot = "v4"
-	dbDiscv5Root   = "v5"
+	dbVersionKey       = "version" // Version of the database to flush if changes
+	dbNodePrefix       = "n:"      // Identifier to prefix node entries with
+	dbLocalPrefix      = "local:"
+	dbReputationPrefix = "reputation:" // Identifier to prefix peer reputation entries with
+	dbDiscoverRoot     = "v4"
+	dbDiscv5Root       = "v5"
 
 	// These fields are stored per ID and IP, the full key is "n:<ID>:v4:<IP>:findfail".
 	// Use nodeItemKey to create those keys.
@@ -62,9 +63,10 @@ const (
 )
 
 const (
-	dbNodeExpiration = 24 * time.Hour // Time after which an unseen node should be dropped.
-	dbCleanupCycle   = time.Hour      // Time period for running the expiration task.
-	dbVersion        = 10
+	dbNodeExpiration       = 24 * time.Hour      // Time after which an unseen node should be dropped.
+	dbReputationExpiration = 30 * 24 * time.Hour // Time after which a reputation not updated should be dropped.
+	dbCleanupCycle         = time.Hour           // Time period for running the expiration task.
+	dbVersion              = 10
 )
 
 var (
@@ -399,6 +401,7 @@ func (db *DB) expirer() {
 		select {
 		case <-tick.C:
 			db.expireNodes()
+			db.expireReputations()
 		case <-db.quit:
 			return
 		}
@@ -460,6 +463,68 @@ func (db *DB) expireNodes() {
 	}
 }
 
+// reputationKey returns the database key of the peer reputation of a node.
+func reputationKey(id ID) []byte {
+	return append([]byte(dbReputationPrefix), id[:]...)
+}
+
+// PeerReputation retrieves the reputation of a node stored by UpdatePeerReputation,
+// nil if there is none. The encoding is up to the caller.
+func (db *DB) PeerReputation(id ID) []byte {
+	var blob []byte
+	if err := db.kv.View(context.Background(), func(tx kv.Tx) error {
+		v, errGet := tx.GetOne(kv.Inodes, reputationKey(id))
+		if errGet != nil {
+			return errGet
+		}
+		if len(v) > 8 {
+			// the first 8 bytes are the update time used for the expiration
+			blob = common.CopyBytes(v[8:])
+		}
+		return nil
+	}); err != nil {
+		return nil
+	}
+	return blob
+}
+
+// UpdatePeerReputation stores the reputation of a node. It's kept for
+// dbReputationExpiration after the last update, unlike the node records.
+func (db *DB) UpdatePeerReputation(id ID, blob []byte) error {
+	v := make([]byte, 8, 8+len(blob))
+	binary.BigEndian.PutUint64(v, uint64(time.Now().Unix()))
+	v = append(v, blob...)
+	return db.kv.Update(context.Background(), func(tx kv.RwTx) error {
+		return tx.Put(kv.Inodes, reputationKey(id), v)
+	})
+}
+
+// expireReputations deletes the reputations which weren't updated for dbReputationExpiration.
+func (db *DB) expireReputations() {
+	threshold := uint64(time.Now().Add(-dbReputationExpiration).Unix())
+	if err := db.kv.Update(context.Background(), func(tx kv.RwTx) error {
+		c, err := tx.RwCursor(kv.Inodes)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+		p := []byte(dbReputationPrefix)
+		for k, v, err := c.Seek(p); bytes.HasPrefix(k, p); k, v, err = c.Next() {
+			if err != nil {
+				return err
+			}
+			if (len(v) < 8) || (binary.BigEndian.Uint64(v) < threshold) {
+				if err := c.DeleteCurrent(); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}); err != nil {
+		log.Warn("nodeDB.expireReputations failed", "err", err)
+	}
+}
+
 // LastPingReceived retrieves the time of the last ping packet received from
 // a remote node.
 func (db *DB) LastPingReceived(id ID, ip net.IP) time.Time {
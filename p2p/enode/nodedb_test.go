@@ -18,12 +18,16 @@ package enode
 
 import (
 	"bytes"
+	"context"
+	"encoding/binary"
 	"fmt"
 	"net"
 	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
 )
 
 var keytestID = HexID("51232b8d7821617d2b29b54b81cdefb9b3e9c37d7fd5f63270bcc9e1a6f6a439")
@@ -488,3 +492,39 @@ func TestDBExpireV5(t *testing.T) {
 	db.UpdateFindFailsV5(ID{}, ip, 4)
 	db.expireNodes()
 }
+
+func TestDBReputation(t *testing.T) {
+	tmpDir := t.TempDir()
+	db, err := OpenDB("", tmpDir)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	fresh, stale := ID{1}, ID{2}
+	if blob := db.PeerReputation(fresh); blob != nil {
+		t.Fatalf("unexpected reputation %x", blob)
+	}
+	if err := db.UpdatePeerReputation(fresh, []byte{1, 2, 3}); err != nil {
+		t.Fatalf("failed to store reputation: %v", err)
+	}
+	if blob := db.PeerReputation(fresh); !bytes.Equal(blob, []byte{1, 2, 3}) {
+		t.Fatalf("reputation mismatch: have %x, want 010203", blob)
+	}
+
+	// A reputation not updated for long is expired
+	old := make([]byte, 9)
+	binary.BigEndian.PutUint64(old, uint64(time.Now().Add(-dbReputationExpiration-time.Minute).Unix()))
+	if err := db.kv.Update(context.Background(), func(tx kv.RwTx) error {
+		return tx.Put(kv.Inodes, reputationKey(stale), old)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	db.expireReputations()
+	if blob := db.PeerReputation(stale); blob != nil {
+		t.Errorf("stale reputation %x shouldn't be present after expiration", blob)
+	}
+	if blob := db.PeerReputation(fresh); blob == nil {
+		t.Errorf("fresh reputation should be present after expiration")
+	}
+}
@@ -845,6 +845,27 @@ func (s *Stream) List() (size uint64, err error) {
 	return size, nil
 }
 
+// ReadRemaining reads the rest of the current list, or of the input if the stream
+// is not inside a list, verbatim. It is meant for typed transactions (EIP-2718)
+// whose payload following the type byte is not RLP.
+func (s *Stream) ReadRemaining() ([]byte, error) {
+	var n uint64
+	switch {
+	case len(s.stack) > 0:
+		tos := s.stack[len(s.stack)-1]
+		n = tos.size - tos.pos
+	case s.limited:
+		n = s.remaining
+	default:
+		return nil, errors.New("rlp: can't read the remaining input of an unlimited stream")
+	}
+	buf := make([]byte, n)
+	if err := s.readFull(buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
 // NewList starts decoding an RLP list, but without reading its prefix
 func (s *Stream) NewList(size uint64) {
 	s.stack = append(s.stack, listpos{0, size})
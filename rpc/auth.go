@@ -0,0 +1,152 @@
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/ledgerwatch/erigon-lib/common"
+)
+
+// AuthConfig lists the credentials accepted by a server, each with the methods it allows.
+// An empty allow list allows every method, the allow list of the server still applies on top.
+type AuthConfig struct {
+	APIKeys []APIKeyCredential `json:"apiKeys"`
+	JWT     []JWTCredential    `json:"jwt"`
+}
+
+// APIKeyCredential is a static key, sent as a bearer token or in the X-API-Key header. Browsers can not set headers
+// on WebSocket connections, so the upgrade request may carry it in the apikey query parameter instead.
+type APIKeyCredential struct {
+	Key   string    `json:"key"`
+	Allow AllowList `json:"allow"`
+}
+
+// JWTCredential is a hex encoded secret signing (HS256) the bearer tokens of its clients. The tokens may restrict
+// the allowed methods further with an "allow" claim, and their "exp", "nbf" and "iat" claims are enforced when set.
+type JWTCredential struct {
+	Secret string    `json:"secret"`
+	Allow  AllowList `json:"allow"`
+}
+
+// Auth authenticates the requests of a server, see Server.SetAuth.
+type Auth struct {
+	apiKeys []APIKeyCredential
+	secrets [][]byte
+	allows  []AllowList
+}
+
+func NewAuth(cfg AuthConfig) (*Auth, error) {
+	a := &Auth{apiKeys: cfg.APIKeys}
+	for i, k := range cfg.APIKeys {
+		if k.Key == "" {
+			return nil, fmt.Errorf("api key %d is empty", i)
+		}
+	}
+	for i, c := range cfg.JWT {
+		secret := common.FromHex(strings.TrimSpace(c.Secret))
+		if len(secret) < 32 {
+			return nil, fmt.Errorf("jwt secret %d is shorter than 32 bytes", i)
+		}
+		a.secrets = append(a.secrets, secret)
+		a.allows = append(a.allows, c.Allow)
+	}
+	if len(a.apiKeys) == 0 && len(a.secrets) == 0 {
+		return nil, errors.New("no credentials configured")
+	}
+	return a, nil
+}
+
+// authClaims are the claims of the JWT credentials
+type authClaims struct {
+	jwt.RegisteredClaims
+	Allow AllowList `json:"allow,omitempty"`
+}
+
+// authenticate returns the allow lists all of which the methods called by the request must pass.
+// The apikey query parameter is only looked at on WebSocket upgrades, it would end up in logs and caches otherwise.
+func (a *Auth) authenticate(r *http.Request, websocketUpgrade bool) ([]AllowList, error) {
+	var token string
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	} else if key := r.Header.Get("X-API-Key"); key != "" {
+		token = key
+	} else if websocketUpgrade {
+		token = r.URL.Query().Get("apikey")
+	}
+	if token == "" {
+		return nil, errors.New("missing credentials")
+	}
+
+	for _, k := range a.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(k.Key), []byte(token)) == 1 {
+			return []AllowList{k.Allow}, nil
+		}
+	}
+	if len(a.secrets) == 0 {
+		return nil, errors.New("invalid api key")
+	}
+	var err error
+	for i, secret := range a.secrets {
+		var claims authClaims
+		if _, err = jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) { return secret, nil }, jwt.WithValidMethods([]string{"HS256"})); err == nil {
+			return []AllowList{a.allows[i], claims.Allow}, nil
+		}
+		if !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+			// Signed with this secret, but invalid otherwise
+			break
+		}
+	}
+	if len(a.apiKeys) > 0 && errors.Is(err, jwt.ErrTokenMalformed) {
+		return nil, errors.New("invalid api key")
+	}
+	return nil, fmt.Errorf("invalid token: %w", err)
+}
+
+type authAllowListsKey struct{}
+
+func withAuthAllowLists(ctx context.Context, allows []AllowList) context.Context {
+	if allows == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, authAllowListsKey{}, allows)
+}
+
+func authAllowListsFromContext(ctx context.Context) []AllowList {
+	allows, _ := ctx.Value(authAllowListsKey{}).([]AllowList)
+	return allows
+}
+
+// allows reports whether the method is in the list, either by name or by its "namespace_*" wildcard.
+// An empty list allows everything.
+func (a AllowList) allows(method string) bool {
+	if len(a) == 0 {
+		return true
+	}
+	if _, ok := a[method]; ok {
+		return true
+	}
+	if _, ok := a["*"]; ok {
+		return true
+	}
+	if namespace, _, ok := strings.Cut(method, serviceMethodSeparator); ok {
+		_, ok = a[namespace+serviceMethodSeparator+"*"]
+		return ok
+	}
+	return false
+}
+
+// writeUnauthorized rejects a request which failed authentication.
+func writeUnauthorized(w http.ResponseWriter, err error) {
+	w.Header().Set("content-type", contentType)
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	w.WriteHeader(http.StatusUnauthorized)
+	//nolint:errcheck
+	json.NewEncoder(w).Encode(errorMessage(&unauthorizedError{err.Error()}))
+}
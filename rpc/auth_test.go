@@ -0,0 +1,159 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+const testAuthSecret = "0x7365637265747365637265747365637265747365637265747365637265747365"
+
+func newTestAuthServer(t *testing.T) *httptest.Server {
+	srv := newTestServer()
+	auth, err := NewAuth(AuthConfig{
+		APIKeys: []APIKeyCredential{
+			{Key: "full"},
+			{Key: "modules", Allow: AllowList{"rpc_modules": {}}},
+		},
+		JWT: []JWTCredential{
+			{Secret: testAuthSecret, Allow: AllowList{"test_*": {}}},
+		},
+	})
+	require.NoError(t, err)
+	srv.SetAuth(auth)
+	httpsrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") == "websocket" {
+			srv.WebsocketHandler([]string{"*"}, nil, false).ServeHTTP(w, r)
+			return
+		}
+		srv.ServeHTTP(w, r)
+	}))
+	t.Cleanup(func() {
+		httpsrv.Close()
+		srv.Stop()
+	})
+	return httpsrv
+}
+
+func testAuthToken(t *testing.T, claims jwt.Claims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secretsecretsecretsecretsecretse"))
+	require.NoError(t, err)
+	return token
+}
+
+func TestAuthHTTPRejected(t *testing.T) {
+	httpsrv := newTestAuthServer(t)
+	expired := testAuthToken(t, jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute))})
+	otherSecret, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{}).SignedString([]byte("othersecretothersecretothersecre"))
+	require.NoError(t, err)
+
+	for name, header := range map[string]string{
+		"missing":      "",
+		"unknown key":  "Bearer nokey",
+		"expired":      "Bearer " + expired,
+		"other secret": "Bearer " + otherSecret,
+	} {
+		req, err := http.NewRequest(http.MethodPost, httpsrv.URL, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"rpc_modules"}`))
+		require.NoError(t, err)
+		req.Header.Set("content-type", contentType)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode, name)
+		var msg jsonrpcMessage
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&msg))
+		resp.Body.Close()
+		require.NotNil(t, msg.Error, name)
+		require.Equal(t, -32001, msg.Error.Code, name)
+	}
+
+	// The query parameter is only accepted on WebSocket upgrades
+	req, err := http.NewRequest(http.MethodPost, httpsrv.URL+"?apikey=full", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"rpc_modules"}`))
+	require.NoError(t, err)
+	req.Header.Set("content-type", contentType)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestAuthTCPRejected(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Stop()
+	auth, err := NewAuth(AuthConfig{APIKeys: []APIKeyCredential{{Key: "full"}}})
+	require.NoError(t, err)
+	srv.SetAuth(auth)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go srv.ServeListener(listener) //nolint:errcheck
+
+	client, err := DialTCP(context.Background(), listener.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var modules map[string]string
+	require.Error(t, client.CallContext(ctx, &modules, "rpc_modules"))
+	require.Nil(t, modules)
+}
+
+func TestAuthAllowList(t *testing.T) {
+	httpsrv := newTestAuthServer(t)
+	token := testAuthToken(t, jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(time.Now())})
+	restricted := testAuthToken(t, &authClaims{Allow: AllowList{"test_echo": {}}})
+
+	call := func(c *Client, method string, args ...interface{}) error {
+		var result interface{}
+		return c.Call(&result, method, args...)
+	}
+	requireNotAllowed := func(err error) {
+		t.Helper()
+		var rpcErr Error
+		require.ErrorAs(t, err, &rpcErr)
+		require.Equal(t, -32004, rpcErr.ErrorCode())
+	}
+
+	c, err := DialHTTP(httpsrv.URL)
+	require.NoError(t, err)
+	defer c.Close()
+
+	c.SetHeader("X-API-Key", "full")
+	require.NoError(t, call(c, "rpc_modules"))
+	require.NoError(t, call(c, "test_echo", "x", 1))
+
+	c.SetHeader("X-API-Key", "modules")
+	require.NoError(t, call(c, "rpc_modules"))
+	requireNotAllowed(call(c, "test_echo", "x", 1))
+
+	c.SetHeader("X-API-Key", "")
+	c.SetHeader("Authorization", "Bearer "+token)
+	require.NoError(t, call(c, "test_echo", "x", 1))
+	requireNotAllowed(call(c, "rpc_modules"))
+
+	// The claim narrows down the allow list of the secret
+	c.SetHeader("Authorization", "Bearer "+restricted)
+	require.NoError(t, call(c, "test_echo", "x", 1))
+	requireNotAllowed(call(c, "test_rets"))
+
+	// Browsers can not set headers on WebSocket connections
+	wsURL := "ws:" + strings.TrimPrefix(httpsrv.URL, "http:")
+	_, err = DialWebsocket(context.Background(), wsURL, "")
+	require.Error(t, err)
+	ws, err := DialWebsocket(context.Background(), wsURL+"?apikey=modules", "")
+	require.NoError(t, err)
+	defer ws.Close()
+	require.NoError(t, call(ws, "rpc_modules"))
+	requireNotAllowed(call(ws, "test_echo", "x", 1))
+}
@@ -78,6 +78,7 @@ type Client struct {
 	isHTTP          bool
 	services        *serviceRegistry
 	methodAllowList AllowList
+	authAllows      []AllowList // allow lists of the credential the connection is authenticated with, see Auth
 
 	idCounter uint32
 
@@ -112,6 +113,7 @@ type clientConn struct {
 
 func (c *Client) newClientConn(conn ServerCodec) *clientConn {
 	ctx := context.WithValue(context.Background(), clientContextKey{}, c)
+	ctx = withAuthAllowLists(ctx, c.authAllows)
 	handler := newHandler(ctx, conn, c.idgen, c.services, c.methodAllowList, 50, false /* traceRequests */)
 	return &clientConn{conn, handler}
 }
@@ -151,7 +153,7 @@ func (op *requestOp) wait(ctx context.Context, c *Client) (*jsonrpcMessage, erro
 
 // Dial creates a new client for the given URL.
 //
-// The currently supported URL schemes are "http", "https", "ws" and "wss". If rawurl is a
+// The currently supported URL schemes are "http", "https", "ws", "wss" and "tcp". If rawurl is a
 // file name with no URL scheme, a local socket connection is established using UNIX
 // domain sockets on supported platforms and named pipes on Windows. If you want to
 // configure transport options, use DialHTTP, DialWebsocket or DialIPC instead.
@@ -177,6 +179,8 @@ func DialContext(ctx context.Context, rawurl string) (*Client, error) {
 		return DialHTTP(rawurl)
 	case "ws", "wss":
 		return DialWebsocket(ctx, rawurl, "")
+	case "tcp":
+		return DialTCP(ctx, u.Host)
 	case "stdio":
 		return DialStdIO(ctx)
 	default:
@@ -196,27 +200,29 @@ func newClient(initctx context.Context, connect reconnectFunc) (*Client, error)
 	if err != nil {
 		return nil, err
 	}
-	c := initClient(conn, randomIDGenerator(), new(serviceRegistry))
+	c := initClient(conn, randomIDGenerator(), new(serviceRegistry), nil, nil)
 	c.reconnectFunc = connect
 	return c, nil
 }
 
-func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry) *Client {
+func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry, methodAllowList AllowList, authAllows []AllowList) *Client {
 	_, isHTTP := conn.(*httpConn)
 	c := &Client{
-		idgen:       idgen,
-		isHTTP:      isHTTP,
-		services:    services,
-		writeConn:   conn,
-		close:       make(chan struct{}),
-		closing:     make(chan struct{}),
-		didClose:    make(chan struct{}),
-		reconnected: make(chan ServerCodec),
-		readOp:      make(chan readOp),
-		readErr:     make(chan error),
-		reqInit:     make(chan *requestOp),
-		reqSent:     make(chan error, 1),
-		reqTimeout:  make(chan *requestOp),
+		idgen:           idgen,
+		isHTTP:          isHTTP,
+		services:        services,
+		methodAllowList: methodAllowList,
+		authAllows:      authAllows,
+		writeConn:       conn,
+		close:           make(chan struct{}),
+		closing:         make(chan struct{}),
+		didClose:        make(chan struct{}),
+		reconnected:     make(chan ServerCodec),
+		readOp:          make(chan readOp),
+		readErr:         make(chan error),
+		reqInit:         make(chan *requestOp),
+		reqSent:         make(chan error, 1),
+		reqTimeout:      make(chan *requestOp),
 	}
 	if !isHTTP {
 		go c.dispatch(conn)
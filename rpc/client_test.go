@@ -501,6 +501,44 @@ func TestClientHTTP(t *testing.T) {
 	}
 }
 
+func TestClientTCP(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("can't listen:", err)
+	}
+	defer listener.Close()
+	go server.ServeListener(listener)
+
+	client, err := Dial("tcp://" + listener.Addr().String())
+	if err != nil {
+		t.Fatal("can't dial:", err)
+	}
+	defer client.Close()
+
+	var resp echoResult
+	if err := client.Call(&resp, "test_echo", "x", 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := (echoResult{"x", 1, nil}); !reflect.DeepEqual(resp, want) {
+		t.Errorf("result mismatch: got %#v, want %#v", resp, want)
+	}
+
+	// Notifications are delivered over TCP as well
+	nc := make(chan int)
+	sub, err := client.Subscribe(context.Background(), "nftest", nc, "someSubscription", 3, 0)
+	if err != nil {
+		t.Fatal("can't subscribe:", err)
+	}
+	defer sub.Unsubscribe()
+	for i := 0; i < 3; i++ {
+		if val := <-nc; val != i {
+			t.Fatalf("value mismatch: got %d, want %d", val, i)
+		}
+	}
+}
+
 func TestClientReconnect(t *testing.T) {
 	startServer := func(addr string) (*Server, net.Listener) {
 		srv := newTestServer()
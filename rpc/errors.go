@@ -26,6 +26,8 @@ var (
 	_ Error = new(invalidMessageError)
 	_ Error = new(InvalidParamsError)
 	_ Error = new(CustomError)
+	_ Error = new(unauthorizedError)
+	_ Error = new(methodNotAllowedError)
 )
 
 const defaultErrorCode = -32000
@@ -46,6 +48,22 @@ func (e *subscriptionNotFoundError) Error() string {
 	return fmt.Sprintf("no %q subscription in %s namespace", e.subscription, e.namespace)
 }
 
+// the request failed authentication, see Auth
+type unauthorizedError struct{ message string }
+
+func (e *unauthorizedError) ErrorCode() int { return -32001 }
+
+func (e *unauthorizedError) Error() string { return "unauthorized: " + e.message }
+
+// the method exists, but the credential of the request does not allow it
+type methodNotAllowedError struct{ method string }
+
+func (e *methodNotAllowedError) ErrorCode() int { return -32004 }
+
+func (e *methodNotAllowedError) Error() string {
+	return fmt.Sprintf("the method %s is not allowed for this credential", e.method)
+}
+
 // Invalid JSON was received by the server.
 type parseError struct{ message string }
 
@@ -64,7 +64,8 @@ type handler struct {
 	log            log.Logger
 	allowSubscribe bool
 
-	allowList     AllowList // a list of explicitly allowed methods, if empty -- everything is allowed
+	allowList     AllowList   // a list of explicitly allowed methods, if empty -- everything is allowed
+	authAllows    []AllowList // allow lists of the credential the connection is authenticated with, see Auth
 	forbiddenList ForbiddenList
 
 	subLock             sync.Mutex
@@ -125,6 +126,7 @@ func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *
 		serverSubs:     make(map[ID]*Subscription),
 		log:            log.Root(),
 		allowList:      allowList,
+		authAllows:     authAllowListsFromContext(connCtx),
 		forbiddenList:  forbiddenList,
 
 		maxBatchConcurrency: maxBatchConcurrency,
@@ -422,8 +424,20 @@ func (h *handler) isMethodAllowedByGranularControl(method string) bool {
 	return ok
 }
 
+func (h *handler) isMethodAllowedByCredential(method string) bool {
+	for _, allowList := range h.authAllows {
+		if !allowList.allows(method) {
+			return false
+		}
+	}
+	return true
+}
+
 // handleCall processes method calls.
 func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage, stream *jsoniter.Stream) *jsonrpcMessage {
+	if !msg.isUnsubscribe() && !h.isMethodAllowedByCredential(msg.Method) {
+		return msg.errorResponse(&methodNotAllowedError{method: msg.Method})
+	}
 	if msg.isSubscribe() {
 		return h.handleSubscribe(cp, msg, stream)
 	}
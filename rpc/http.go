@@ -233,10 +233,18 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), code)
 		return
 	}
+	var authAllows []AllowList
+	if s.auth != nil {
+		var err error
+		if authAllows, err = s.auth.authenticate(r, false /* websocketUpgrade */); err != nil {
+			writeUnauthorized(w, err)
+			return
+		}
+	}
 	// All checks passed, create a codec that reads directly from the request body
 	// until EOF, writes the response to w, and orders the server to process a
 	// single request.
-	ctx := r.Context()
+	ctx := withAuthAllowLists(r.Context(), authAllows)
 	ctx = context.WithValue(ctx, "remote", r.RemoteAddr)
 	ctx = context.WithValue(ctx, "scheme", r.Proto)
 	ctx = context.WithValue(ctx, "local", r.Host)
@@ -17,6 +17,7 @@
 package rpc
 
 import (
+	"context"
 	"net"
 
 	"github.com/ledgerwatch/erigon/p2p/netutil"
@@ -24,6 +25,7 @@ import (
 )
 
 // ServeListener accepts connections on l, serving JSON-RPC on them.
+// The connections carry no credentials, a server requiring them (see SetAuth) closes them right away.
 func (s *Server) ServeListener(l net.Listener) error {
 	for {
 		conn, err := l.Accept()
@@ -33,7 +35,28 @@ func (s *Server) ServeListener(l net.Listener) error {
 		} else if err != nil {
 			return err
 		}
+		if s.auth != nil {
+			log.Warn("Rejected unauthenticated RPC connection", "conn", conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
 		log.Trace("Accepted RPC connection", "conn", conn.RemoteAddr())
 		go s.ServeCodec(NewCodec(conn), 0)
 	}
 }
+
+// DialTCP creates a new client connected to a server started with ServeListener on a TCP
+// listener, such as the --tcp endpoint of rpcdaemon.
+//
+// The context is used for the initial connection establishment. It does not
+// affect subsequent interactions with the client.
+func DialTCP(ctx context.Context, endpoint string) (*Client, error) {
+	return newClient(ctx, func(ctx context.Context) (ServerCodec, error) {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", endpoint)
+		if err != nil {
+			return nil, err
+		}
+		return NewCodec(conn), nil
+	})
+}
@@ -46,6 +46,7 @@ const (
 type Server struct {
 	services        serviceRegistry
 	methodAllowList AllowList
+	auth            *Auth
 	idgen           func() ID
 	run             int32
 	codecs          mapset.Set
@@ -71,6 +72,13 @@ func (s *Server) SetAllowList(allowList AllowList) {
 	s.methodAllowList = allowList
 }
 
+// SetAuth makes the server require one of the credentials of auth from its HTTP and WebSocket clients,
+// the credential also restricts the methods allowed on top of the allow list of the server.
+// Connections of ServeListener can not authenticate and are refused.
+func (s *Server) SetAuth(auth *Auth) {
+	s.auth = auth
+}
+
 // SetBatchLimit sets limit of number of requests in a batch
 func (s *Server) SetBatchLimit(limit int) {
 	s.batchLimit = limit
@@ -90,6 +98,11 @@ func (s *Server) RegisterName(name string, receiver interface{}) error {
 //
 // Note that codec options are no longer supported.
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
+	s.serveCodec(codec, nil, nil)
+}
+
+// serveCodec is ServeCodec restricting the methods to the allow lists, which are all checked
+func (s *Server) serveCodec(codec ServerCodec, methodAllowList AllowList, authAllows []AllowList) {
 	defer codec.close()
 
 	// Don't serve if server is stopped.
@@ -101,7 +114,7 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 	s.codecs.Add(codec)
 	defer s.codecs.Remove(codec)
 
-	c := initClient(codec, s.idgen, &s.services)
+	c := initClient(codec, s.idgen, &s.services, methodAllowList, authAllows)
 	<-codec.closed()
 	c.Close()
 }
@@ -58,13 +58,21 @@ func (s *Server) WebsocketHandler(allowedOrigins []string, jwtSecret []byte, com
 		if jwtSecret != nil && !CheckJwtSecret(w, r, jwtSecret) {
 			return
 		}
+		var authAllows []AllowList
+		if s.auth != nil {
+			var err error
+			if authAllows, err = s.auth.authenticate(r, true /* websocketUpgrade */); err != nil {
+				writeUnauthorized(w, err)
+				return
+			}
+		}
 		conn, err := upgrader.Upgrade(w, r, nil)
 		if err != nil {
 			log.Warn("WebSocket upgrade failed", "err", err)
 			return
 		}
 		codec := newWebsocketCodec(conn)
-		s.ServeCodec(codec, 0)
+		s.serveCodec(codec, s.methodAllowList, authAllows)
 	})
 }
 
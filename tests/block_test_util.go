@@ -106,6 +106,8 @@ type btHeaderMarshaling struct {
 	BaseFee    *math.HexOrDecimal256
 }
 
+// Run executes the test. t may be nil when the test is run outside of
+// `go test`, e.g. by `evm blocktest`.
 func (bt *BlockTest) Run(t *testing.T, _ bool) error {
 	config, ok := Forks[bt.json.Network]
 	if !ok {
@@ -120,7 +122,14 @@ func (bt *BlockTest) Run(t *testing.T, _ bool) error {
 	if config.TerminalTotalDifficulty != nil {
 		engine = serenity.New(engine) // the Merge
 	}
-	m := stages.MockWithGenesisEngine(t, bt.genesis(config), engine, false)
+	var tb testing.TB
+	if t != nil {
+		tb = t
+	}
+	m := stages.MockWithGenesisEngine(tb, bt.genesis(config), engine, false)
+	if tb == nil {
+		defer m.Close()
+	}
 
 	// import pre accounts & construct test genesis block & state root
 	if m.Genesis.Hash() != bt.json.Genesis.Hash {
@@ -39,6 +39,9 @@ var (
 	transactionTestDir = filepath.Join(baseDir, "TransactionTests")
 	rlpTestDir         = filepath.Join(baseDir, "RLPTests")
 	difficultyTestDir  = filepath.Join(baseDir, "DifficultyTests")
+
+	// State tests of the Cancun EIPs, until they are released in the tests submodule
+	cancunStateTestDir = filepath.Join(".", "cancun-state-tests")
 )
 
 func readJSON(reader io.Reader, value interface{}) error {
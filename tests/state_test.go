@@ -50,29 +50,39 @@ func TestState(t *testing.T) {
 	st.skipLoad(`^stTimeConsuming/`)
 	st.skipLoad(`.*vmPerformance/loop.*`)
 
-	st.walk(t, stateTestDir, func(t *testing.T, name string, test *StateTest) {
-		db := memdb.NewTestDB(t)
-		for _, subtest := range test.Subtests() {
-			subtest := subtest
-			key := fmt.Sprintf("%s/%d", subtest.Fork, subtest.Index)
-			t.Run(key, func(t *testing.T) {
-				withTrace(t, func(vmconfig vm.Config) error {
-					tx, err := db.BeginRw(context.Background())
-					if err != nil {
-						t.Fatal(err)
-					}
-					defer tx.Rollback()
-					_, err = test.Run(tx, subtest, vmconfig)
-					tx.Rollback()
-					if err != nil && len(test.json.Post[subtest.Fork][subtest.Index].ExpectException) > 0 {
-						// Ignore expected errors
-						return nil
-					}
-					return st.checkFailure(t, err)
-				})
+	st.walk(t, stateTestDir, st.runStateTest)
+}
+
+func TestCancunState(t *testing.T) {
+	defer log.Root().SetHandler(log.Root().GetHandler())
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlError, log.StderrHandler))
+
+	st := new(testMatcher)
+	st.walk(t, cancunStateTestDir, st.runStateTest)
+}
+
+func (tm *testMatcher) runStateTest(t *testing.T, name string, test *StateTest) {
+	db := memdb.NewTestDB(t)
+	for _, subtest := range test.Subtests() {
+		subtest := subtest
+		key := fmt.Sprintf("%s/%d", subtest.Fork, subtest.Index)
+		t.Run(key, func(t *testing.T) {
+			withTrace(t, func(vmconfig vm.Config) error {
+				tx, err := db.BeginRw(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer tx.Rollback()
+				_, err = test.Run(tx, subtest, vmconfig)
+				tx.Rollback()
+				if err != nil && len(test.json.Post[subtest.Fork][subtest.Index].ExpectException) > 0 {
+					// Ignore expected errors
+					return nil
+				}
+				return tm.checkFailure(t, err)
 			})
-		}
-	})
+		})
+	}
 }
 
 func withTrace(t *testing.T, test func(vm.Config) error) {
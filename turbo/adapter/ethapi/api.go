@@ -289,6 +289,9 @@ func RPCMarshalHeader(head *types.Header) map[string]interface{} {
 	if head.ExcessDataGas != nil {
 		result["excessDataGas"] = (*hexutil.Big)(head.ExcessDataGas)
 	}
+	if head.ParentBeaconBlockRoot != nil {
+		result["parentBeaconBlockRoot"] = head.ParentBeaconBlockRoot
+	}
 
 	return result
 }
@@ -399,6 +402,12 @@ type RPCTransaction struct {
 	BlobVersionedHashes []libcommon.Hash `json:"blobVersionedHashes,omitempty"`
 }
 
+// NewRPCTransaction returns a transaction that will serialize to the RPC
+// representation, the block hash is empty for the pending transactions.
+func NewRPCTransaction(tx types.Transaction, blockHash libcommon.Hash, blockNumber uint64, index uint64, baseFee *big.Int) *RPCTransaction {
+	return newRPCTransaction(tx, blockHash, blockNumber, index, baseFee)
+}
+
 // newRPCTransaction returns a transaction that will serialize to the RPC
 // representation, with the given location metadata set (if available).
 func newRPCTransaction(tx types.Transaction, blockHash libcommon.Hash, blockNumber uint64, index uint64, baseFee *big.Int) *RPCTransaction {
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -48,12 +49,64 @@ TODO:
 	}, debug.Flags, logging.Flags),
 }
 
+// nolint
+var backupOnlineCommand = cli.Command{
+	Name: "alpha_backup_online",
+	Description: `Alpha verison of command. Backup databases of a running Erigon.
+Copies tables in chunks under one read transaction and saves the progress, the chunk checksums and
+the stage progress in <to.datadir>/<db>/backup.json. Running it again into the same target continues an interrupted
+backup or updates a finished one: only the changed entries are written, and the canonical block tables
+are compared only from the first block which isn't canonical in the backup anymore.
+Limitations:
+- same as alpha_backup: no snapshots folder, Consensus DB, jwt tocken and SentryDB.
+- the read transaction is open for the whole backup, the DB of the running node grows meanwhile.
+
+Example: erigon alpha_backup_online --datadir=<your_datadir> --to.datadir=<backup_datadir>
+`,
+	Action: doBackupOnline,
+	Flags: joinFlags([]cli.Flag{
+		&utils.DataDirFlag,
+		&ToDatadirFlag,
+		&BackupToPageSizeFlag,
+		&BackupLabelsFlag,
+		&BackupTablesFlag,
+		&BackupChunkSizeFlag,
+		&WarmupThreadsFlag,
+	}, debug.Flags, logging.Flags),
+}
+
+// nolint
+var restoreCommand = cli.Command{
+	Name: "alpha_restore",
+	Description: `Alpha verison of command. Restore databases backed up by alpha_backup_online into an empty datadir.
+Verifies the entries counts and checksums of every chunk and the stage progress of the backup, compares a sample of the
+plain state with the hashed state and optionally recomputes the state root of the executed block.
+
+Example: erigon alpha_restore --from.datadir=<backup_datadir> --datadir=<new_datadir> --verify.stateroot
+`,
+	Action: doRestore,
+	Flags: joinFlags([]cli.Flag{
+		&utils.DataDirFlag,
+		&FromDatadirFlag,
+		&BackupToPageSizeFlag,
+		&BackupLabelsFlag,
+		&VerifyStateRootFlag,
+		&VerifySamplesFlag,
+		&WarmupThreadsFlag,
+	}, debug.Flags, logging.Flags),
+}
+
 var (
 	ToDatadirFlag = flags.DirectoryFlag{
 		Name:     "to.datadir",
 		Usage:    "Target datadir",
 		Required: true,
 	}
+	FromDatadirFlag = flags.DirectoryFlag{
+		Name:     "from.datadir",
+		Usage:    "Backup datadir",
+		Required: true,
+	}
 	BackupLabelsFlag = cli.StringFlag{
 		Name:  "lables",
 		Usage: "Name of component to backup. Example: chaindata,txpool,downloader",
@@ -66,6 +119,20 @@ var (
 		Name:  "to.pagesize",
 		Usage: utils.DbPageSizeFlag.Usage,
 	}
+	BackupChunkSizeFlag = cli.Uint64Flag{
+		Name:  "chunk.size",
+		Usage: "Amount of entries copied in one write transaction and covered by one checksum",
+		Value: backup.DefaultChunkSize,
+	}
+	VerifyStateRootFlag = cli.BoolFlag{
+		Name:  "verify.stateroot",
+		Usage: "Recompute the state root of the executed block after restore. Takes as long as a full IntermediateHashes stage",
+	}
+	VerifySamplesFlag = cli.IntFlag{
+		Name:  "verify.samples",
+		Usage: "Amount of random plain state entries compared with the hashed state after restore",
+		Value: 10_000,
+	}
 	WarmupThreadsFlag = cli.Uint64Flag{
 		Name: "warmup.threads",
 		Usage: `Erigon's db works as blocking-io: means it stops when read from disk. 
@@ -88,13 +155,7 @@ func doBackup(cliCtx *cli.Context) error {
 		targetPageSize = flags.DBPageSizeFlagUnmarshal(cliCtx, BackupToPageSizeFlag.Name, BackupToPageSizeFlag.Usage)
 	}
 
-	var lables = []kv.Label{kv.ChainDB, kv.TxPoolDB, kv.DownloaderDB}
-	if cliCtx.IsSet(BackupToPageSizeFlag.Name) {
-		lables = lables[:0]
-		for _, l := range utils.SplitAndTrim(cliCtx.String(BackupLabelsFlag.Name)) {
-			lables = append(lables, kv.UnmarshalLabel(l))
-		}
-	}
+	lables := backupLabels(cliCtx)
 
 	var tables []string
 	if cliCtx.IsSet(BackupTablesFlag.Name) {
@@ -109,18 +170,7 @@ func doBackup(cliCtx *cli.Context) error {
 	//kv.SentryDB no much reason to backup
 	//TODO: add support of kv.ConsensusDB
 	for _, label := range lables {
-		var from, to string
-		switch label {
-		case kv.ChainDB:
-			from, to = dirs.Chaindata, toDirs.Chaindata
-		case kv.TxPoolDB:
-			from, to = dirs.TxPool, toDirs.TxPool
-		case kv.DownloaderDB:
-			from, to = filepath.Join(dirs.Snap, "db"), filepath.Join(toDirs.Snap, "db")
-		default:
-			panic(fmt.Sprintf("unexpected: %+v", label))
-		}
-
+		from, to := labelDir(dirs, label), labelDir(toDirs, label)
 		if !dir.Exist(from) {
 			continue
 		}
@@ -142,3 +192,111 @@ func doBackup(cliCtx *cli.Context) error {
 
 	return nil
 }
+
+func backupLabels(cliCtx *cli.Context) []kv.Label {
+	var lables = []kv.Label{kv.ChainDB, kv.TxPoolDB, kv.DownloaderDB}
+	if cliCtx.IsSet(BackupLabelsFlag.Name) {
+		lables = lables[:0]
+		for _, l := range utils.SplitAndTrim(cliCtx.String(BackupLabelsFlag.Name)) {
+			lables = append(lables, kv.UnmarshalLabel(l))
+		}
+	}
+	return lables
+}
+
+func labelDir(dirs datadir.Dirs, label kv.Label) string {
+	switch label {
+	case kv.ChainDB:
+		return dirs.Chaindata
+	case kv.TxPoolDB:
+		return dirs.TxPool
+	case kv.DownloaderDB:
+		return filepath.Join(dirs.Snap, "db")
+	default:
+		panic(fmt.Sprintf("unexpected: %+v", label))
+	}
+}
+
+func doBackupOnline(cliCtx *cli.Context) error {
+	defer log.Info("backup done")
+
+	ctx := cliCtx.Context
+	dirs := datadir.New(cliCtx.String(utils.DataDirFlag.Name))
+	toDirs := datadir.New(cliCtx.String(ToDatadirFlag.Name))
+
+	var targetPageSize datasize.ByteSize
+	if cliCtx.IsSet(BackupToPageSizeFlag.Name) {
+		targetPageSize = flags.DBPageSizeFlagUnmarshal(cliCtx, BackupToPageSizeFlag.Name, BackupToPageSizeFlag.Usage)
+	}
+	cfg := backup.OnlineCfg{
+		ChunkSize:        cliCtx.Uint64(BackupChunkSizeFlag.Name),
+		ReadAheadThreads: int(cliCtx.Uint64(WarmupThreadsFlag.Name)),
+	}
+	if cliCtx.IsSet(BackupTablesFlag.Name) {
+		cfg.Tables = utils.SplitAndTrim(cliCtx.String(BackupTablesFlag.Name))
+	}
+
+	for _, label := range backupLabels(cliCtx) {
+		from, to := labelDir(dirs, label), labelDir(toDirs, label)
+		if !dir.Exist(from) {
+			continue
+		}
+		if err := os.MkdirAll(to, 0740); err != nil { //owner: rw, group: r, others: -
+			return fmt.Errorf("mkdir: %w, %s", err, to)
+		}
+		log.Info("[backup] start", "label", label)
+		fromDB, toDB := backup.OpenPair(from, to, label, targetPageSize)
+		manifest, err := backup.Online(ctx, fromDB, toDB, label, filepath.Join(to, backup.ManifestFileName), cfg)
+		toDB.Close()
+		fromDB.Close()
+		if err != nil {
+			return err
+		}
+		log.Info("[backup] finished", "label", label, "complete", manifest.Complete, "tables", len(manifest.Tables))
+	}
+	return nil
+}
+
+func doRestore(cliCtx *cli.Context) error {
+	defer log.Info("restore done")
+
+	ctx := cliCtx.Context
+	fromDirs := datadir.New(cliCtx.String(FromDatadirFlag.Name))
+	dirs := datadir.New(cliCtx.String(utils.DataDirFlag.Name))
+
+	var targetPageSize datasize.ByteSize
+	if cliCtx.IsSet(BackupToPageSizeFlag.Name) {
+		targetPageSize = flags.DBPageSizeFlagUnmarshal(cliCtx, BackupToPageSizeFlag.Name, BackupToPageSizeFlag.Usage)
+	}
+	verifyCfg := backup.VerifyCfg{
+		StateRoot: cliCtx.Bool(VerifyStateRootFlag.Name),
+		Samples:   cliCtx.Int(VerifySamplesFlag.Name),
+	}
+	readAheadThreads := int(cliCtx.Uint64(WarmupThreadsFlag.Name))
+
+	for _, label := range backupLabels(cliCtx) {
+		from, to := labelDir(fromDirs, label), labelDir(dirs, label)
+		manifest, err := backup.ReadManifest(filepath.Join(from, backup.ManifestFileName))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if entries, err := os.ReadDir(to); err == nil && len(entries) > 0 {
+			return fmt.Errorf("restore target %s must be empty", to)
+		}
+		if err := os.MkdirAll(to, 0740); err != nil { //owner: rw, group: r, others: -
+			return fmt.Errorf("mkdir: %w, %s", err, to)
+		}
+		log.Info("[restore] start", "label", label)
+		fromDB, toDB := backup.OpenPair(from, to, label, targetPageSize)
+		err = backup.Restore(ctx, fromDB, toDB, manifest, readAheadThreads, verifyCfg)
+		toDB.Close()
+		fromDB.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", label, err)
+		}
+	}
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon/cmd/utils"
 	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/node"
 )
 
@@ -21,6 +22,7 @@ var initCommand = cli.Command{
 	ArgsUsage: "<genesisPath>",
 	Flags: []cli.Flag{
 		&utils.DataDirFlag,
+		&InitAllocFlag,
 	},
 	//Category: "BLOCKCHAIN COMMANDS",
 	Description: `
@@ -28,7 +30,14 @@ The init command initializes a new genesis block and definition for the network.
 This is a destructive action and changes the network in which you will be
 participating.
 
-It expects the genesis file as argument.`,
+It expects the genesis file as argument. With --alloc, the accounts of the genesis
+file are replaced by a state dump made by 'erigon state dump', which is streamed
+into a new database.`,
+}
+
+var InitAllocFlag = cli.StringFlag{
+	Name:  "alloc",
+	Usage: "Path to a state dump (json or binary) to use as the genesis alloc",
 }
 
 // initGenesis will initialise the given JSON format genesis file and writes it as
@@ -50,6 +59,17 @@ func initGenesis(ctx *cli.Context) error {
 	if err := json.NewDecoder(file).Decode(genesis); err != nil {
 		utils.Fatalf("invalid genesis file: %v", err)
 	}
+	var alloc *state.AllocReader
+	if allocPath := ctx.String(InitAllocFlag.Name); allocPath != "" {
+		allocFile, err := os.Open(allocPath)
+		if err != nil {
+			utils.Fatalf("Failed to read state dump: %v", err)
+		}
+		defer allocFile.Close()
+		if alloc, err = state.NewAllocReader(allocFile); err != nil {
+			utils.Fatalf("invalid state dump: %v", err)
+		}
+	}
 
 	// Open and initialise both full and light databases
 	stack := MakeConfigNodeDefault(ctx)
@@ -59,7 +79,13 @@ func initGenesis(ctx *cli.Context) error {
 	if err != nil {
 		utils.Fatalf("Failed to open database: %v", err)
 	}
-	_, hash, err := core.CommitGenesisBlock(chaindb, genesis, "")
+	var hash *types.Block
+	if alloc != nil {
+		// The accounts of the dump are written as they are read, it may not fit in memory
+		hash, err = core.CommitGenesisBlockWithAlloc(chaindb, genesis, alloc)
+	} else {
+		_, hash, err = core.CommitGenesisBlock(chaindb, genesis, "")
+	}
 	if err != nil {
 		utils.Fatalf("Failed to write genesis block: %v", err)
 	}
@@ -54,6 +54,9 @@ func MakeApp(name string, action cli.ActionFunc, cliFlags []cli.Flag) *cli.App {
 		&snapshotCommand,
 		&supportCommand,
 		&backupCommand,
+		&backupOnlineCommand,
+		&restoreCommand,
+		&stateCommand,
 	}
 	return app
 }
@@ -0,0 +1,125 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/datadir"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcfg"
+	"github.com/ledgerwatch/erigon-lib/kv/mdbx"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ledgerwatch/erigon/cmd/utils"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/turbo/debug"
+	"github.com/ledgerwatch/erigon/turbo/logging"
+)
+
+var stateCommand = cli.Command{
+	Name:  "state",
+	Usage: "Inspecting and exporting the state",
+	Subcommands: []*cli.Command{
+		{
+			Name:   "dump",
+			Action: doStateDump,
+			Usage:  "Dump the state as of a block into a genesis alloc, which `erigon init --alloc` can start a new chain from",
+			Before: func(ctx *cli.Context) error { return debug.Setup(ctx) },
+			Flags: joinFlags([]cli.Flag{
+				&utils.DataDirFlag,
+				&StateDumpBlockFlag,
+				&StateDumpAddressesFlag,
+				&StateDumpFormatFlag,
+				&StateDumpOutputFlag,
+			}, debug.Flags, logging.Flags),
+		},
+	},
+}
+
+var (
+	StateDumpBlockFlag = cli.Int64Flag{
+		Name:  "block",
+		Usage: "Dump the state after this block. Negative - means the last executed block.",
+		Value: -1,
+	}
+	StateDumpAddressesFlag = cli.StringFlag{
+		Name:  "addresses",
+		Usage: "Comma separated list of accounts to dump. Empty - means all the accounts.",
+	}
+	StateDumpFormatFlag = cli.StringFlag{
+		Name:  "format",
+		Usage: fmt.Sprintf("One of: %s (genesis alloc), %s (compact)", state.AllocJSON, state.AllocBinary),
+		Value: string(state.AllocJSON),
+	}
+	StateDumpOutputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: "File to write the dump to. Empty - means stdout.",
+	}
+)
+
+func doStateDump(cliCtx *cli.Context) error {
+	var addresses []libcommon.Address
+	if list := cliCtx.String(StateDumpAddressesFlag.Name); list != "" {
+		for _, s := range strings.Split(list, ",") {
+			s = strings.TrimSpace(s)
+			if !libcommon.IsHexAddress(s) {
+				return fmt.Errorf("invalid address %q", s)
+			}
+			addresses = append(addresses, libcommon.HexToAddress(s))
+		}
+	}
+
+	dirs := datadir.New(cliCtx.String(utils.DataDirFlag.Name))
+	db := mdbx.NewMDBX(log.New()).Label(kv.ChainDB).Path(dirs.Chaindata).Readonly().MustOpen()
+	defer db.Close()
+	tx, err := db.BeginRo(cliCtx.Context)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	historyV3, err := kvcfg.HistoryV3.Enabled(tx)
+	if err != nil {
+		return err
+	}
+	if historyV3 {
+		return fmt.Errorf("not supported by Erigon3")
+	}
+	executed, err := stages.GetStageProgress(tx, stages.Execution)
+	if err != nil {
+		return err
+	}
+	blockNum := executed
+	if b := cliCtx.Int64(StateDumpBlockFlag.Name); b >= 0 {
+		if uint64(b) > executed {
+			return fmt.Errorf("block %d is not executed yet, the last executed block is %d", b, executed)
+		}
+		blockNum = uint64(b)
+	}
+
+	var out io.Writer = os.Stdout
+	if path := cliCtx.String(StateDumpOutputFlag.Name); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	dump, err := state.NewAllocDump(out, state.AllocFormat(cliCtx.String(StateDumpFormatFlag.Name)))
+	if err != nil {
+		return err
+	}
+	if err = state.NewDumper(tx, blockNum, historyV3).DumpAlloc(dump, addresses); err != nil {
+		return err
+	}
+	if err = dump.Close(); err != nil {
+		return err
+	}
+	log.Info("State dumped", "block", blockNum, "accounts", dump.Count())
+	return nil
+}
@@ -0,0 +1,611 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	common2 "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+)
+
+// ManifestFileName is the file next to the backed up DB recording the progress and checksums of the backup.
+const ManifestFileName = "backup.json"
+
+const DefaultChunkSize = 1_000_000
+
+// canonicalTables are keyed by the block number and hold the data of the canonical blocks only: a follow-up
+// backup copies them again from the first block which isn't canonical in the backup anymore.
+var canonicalTables = map[string]bool{
+	kv.HeaderCanonical:  true,
+	kv.Receipts:         true,
+	kv.Log:              true,
+	kv.AccountChangeSet: true,
+	kv.StorageChangeSet: true,
+	kv.CallTraceSet:     true,
+}
+
+// Chunk is a range of keys copied in one write transaction, Start and End are inclusive.
+type Chunk struct {
+	Start    hexutility.Bytes `json:"start"`
+	End      hexutility.Bytes `json:"end"`
+	Count    uint64           `json:"count"`
+	Checksum string           `json:"checksum"`
+}
+
+// Pass is an unfinished copy of a table.
+type Pass struct {
+	SnapshotID uint64 `json:"snapshotId"`
+	// Position is the last key copied by the pass, the copy resumes after it.
+	Position hexutility.Bytes `json:"position,omitempty"`
+}
+
+type TableManifest struct {
+	// SnapshotID is the source snapshot the table was completely copied at, 0 if never.
+	SnapshotID uint64  `json:"snapshotId"`
+	Chunks     []Chunk `json:"chunks"`
+	Pass       *Pass   `json:"pass,omitempty"`
+}
+
+func (t *TableManifest) Count() (count uint64) {
+	for _, chunk := range t.Chunks {
+		count += chunk.Count
+	}
+	return count
+}
+
+type Manifest struct {
+	Label kv.Label `json:"label"`
+	// SnapshotID is the source snapshot of the last backup run, the backup is consistent if Complete.
+	SnapshotID    uint64                    `json:"snapshotId"`
+	Complete      bool                      `json:"complete"`
+	StageProgress map[string]uint64         `json:"stageProgress,omitempty"`
+	Tables        map[string]*TableManifest `json:"tables"`
+	// ResyncFromBlock is the first block of the canonical tables to copy again, kept until a run completes.
+	ResyncFromBlock *uint64   `json:"resyncFromBlock,omitempty"`
+	Updated         time.Time `json:"updated"`
+}
+
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("manifest %s: %w", path, err)
+	}
+	return &manifest, nil
+}
+
+// WriteManifest replaces the manifest atomically.
+func WriteManifest(path string, manifest *Manifest) error {
+	manifest.Updated = time.Now().UTC()
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0640); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+type OnlineCfg struct {
+	// Tables to copy, all the tables of the label if empty.
+	Tables []string
+	// ChunkSize is the number of entries copied per write transaction and checksum.
+	ChunkSize        uint64
+	ReadAheadThreads int
+}
+
+// Online copies src into dst in resumable chunks under one read transaction, so src can be a running node.
+// The progress is saved in the manifest after every chunk: an interrupted backup continues where it stopped,
+// even at a newer snapshot, after checking the chunks copied so far against it, and a backup of a newer
+// snapshot writes only the changed entries. Every table is compared with dst,
+// except the canonical block tables, which are compared only from the first block not canonical in dst anymore.
+func Online(ctx context.Context, src kv.RoDB, dst kv.RwDB, label kv.Label, manifestPath string, cfg OnlineCfg) (*Manifest, error) {
+	if cfg.ChunkSize == 0 {
+		cfg.ChunkSize = DefaultChunkSize
+	}
+	manifest, err := ReadManifest(manifestPath)
+	if errors.Is(err, os.ErrNotExist) {
+		manifest = &Manifest{Label: label, Tables: map[string]*TableManifest{}}
+	} else if err != nil {
+		return nil, err
+	}
+	if manifest.Label != label {
+		return nil, fmt.Errorf("manifest %s is of %s, not %s", manifestPath, manifest.Label, label)
+	}
+
+	srcTx, err := src.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer srcTx.Rollback()
+	snapshotID := srcTx.ViewID()
+
+	if label == kv.ChainDB {
+		resyncFrom, err := canonicalResyncFrom(ctx, srcTx, dst, manifest)
+		if err != nil {
+			return nil, err
+		}
+		if (manifest.ResyncFromBlock == nil) || (resyncFrom < *manifest.ResyncFromBlock) {
+			manifest.ResyncFromBlock = &resyncFrom
+		}
+		if manifest.StageProgress, err = readStageProgress(srcTx); err != nil {
+			return nil, err
+		}
+	}
+	manifest.SnapshotID = snapshotID
+	manifest.Complete = false
+	if err := WriteManifest(manifestPath, manifest); err != nil {
+		return nil, err
+	}
+
+	tables := cfg.Tables
+	if len(tables) == 0 {
+		for name, tableCfg := range src.AllTables() {
+			if !tableCfg.IsDeprecated {
+				tables = append(tables, name)
+			}
+		}
+	}
+	sort.Strings(tables)
+
+	logEvery := time.NewTicker(20 * time.Second)
+	defer logEvery.Stop()
+	for _, table := range tables {
+		tableManifest := manifest.Tables[table]
+		if tableManifest == nil {
+			tableManifest = &TableManifest{}
+			manifest.Tables[table] = tableManifest
+		}
+		if (tableManifest.Pass == nil) && (tableManifest.SnapshotID == snapshotID) {
+			continue
+		}
+
+		var start, prevEnd []byte
+		if (tableManifest.Pass != nil) && (tableManifest.Pass.SnapshotID == snapshotID) {
+			// resume the interrupted pass of the same snapshot
+			start, prevEnd = nextKeyAfter(tableManifest.Pass.Position), tableManifest.Pass.Position
+		} else if canonicalTables[table] && (manifest.ResyncFromBlock != nil) {
+			start, prevEnd, err = tableManifest.resyncCanonical(srcTx, table, *manifest.ResyncFromBlock)
+			if err != nil {
+				return nil, err
+			}
+		} else if tableManifest.Pass != nil {
+			// resume the pass interrupted at an older snapshot, copying again the chunks changed since
+			if err := tableManifest.verifyChunks(ctx, srcTx, dst, table, isDupSort(src, table), logEvery); err != nil {
+				return nil, fmt.Errorf("table %s: %w", table, err)
+			}
+			start, prevEnd = nextKeyAfter(tableManifest.Pass.Position), tableManifest.Pass.Position
+		} else {
+			tableManifest.Chunks = nil
+		}
+		tableManifest.Pass = &Pass{SnapshotID: snapshotID, Position: prevEnd}
+		if err := WriteManifest(manifestPath, manifest); err != nil {
+			return nil, err
+		}
+
+		log.Info("[backup] copy", "table", table, "from", hex.EncodeToString(start))
+		onChunk := func(chunk Chunk) error {
+			tableManifest.Chunks = append(tableManifest.Chunks, chunk)
+			tableManifest.Pass.Position = chunk.End
+			return WriteManifest(manifestPath, manifest)
+		}
+		if err := copyTable(ctx, src, srcTx, dst, table, start, prevEnd, cfg, onChunk, logEvery); err != nil {
+			return nil, fmt.Errorf("table %s: %w", table, err)
+		}
+		tableManifest.Pass = nil
+		tableManifest.SnapshotID = snapshotID
+		if err := WriteManifest(manifestPath, manifest); err != nil {
+			return nil, err
+		}
+	}
+
+	// a partial backup of some tables leaves the others at their older snapshot
+	manifest.Complete = true
+	for _, tableManifest := range manifest.Tables {
+		if tableManifest.SnapshotID != snapshotID {
+			manifest.Complete = false
+		}
+	}
+	if manifest.Complete {
+		manifest.ResyncFromBlock = nil
+	}
+	if err := WriteManifest(manifestPath, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// resyncCanonical drops the chunks from the block on, and always the last chunk, which may grow,
+// and returns the range to copy. The whole table is copied if it was pruned in src.
+func (t *TableManifest) resyncCanonical(srcTx kv.Tx, table string, fromBlock uint64) (start, prevEnd []byte, err error) {
+	if (t.SnapshotID == 0) && (t.Pass == nil) {
+		t.Chunks = nil
+		return nil, nil, nil
+	}
+	c, err := srcTx.Cursor(table)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer c.Close()
+	first, _, err := c.First()
+	if err != nil {
+		return nil, nil, err
+	}
+	if (len(t.Chunks) == 0) || !bytes.Equal(first, t.Chunks[0].Start) {
+		t.Chunks = nil
+		return nil, nil, nil
+	}
+
+	from := make([]byte, 8)
+	binary.BigEndian.PutUint64(from, fromBlock)
+	keep := len(t.Chunks) - 1
+	for i, chunk := range t.Chunks {
+		if bytes.Compare(chunk.End, from) >= 0 {
+			keep = i
+			break
+		}
+	}
+	start = t.Chunks[keep].Start
+	if keep > 0 {
+		prevEnd = t.Chunks[keep-1].End
+	}
+	t.Chunks = t.Chunks[:keep]
+	return start, prevEnd, nil
+}
+
+// verifyChunks compares the copied chunks with the snapshot of srcTx by their checksums and copies again the
+// changed ones. A chunk covers the keys after the end of the previous chunk up to its end, so the entries
+// added between two chunks since they were copied change it too.
+func (t *TableManifest) verifyChunks(ctx context.Context, srcTx kv.Tx, dst kv.RwDB, table string, dupSort bool, logEvery *time.Ticker) error {
+	srcC, err := srcTx.Cursor(table)
+	if err != nil {
+		return err
+	}
+	defer srcC.Close()
+	seek := func(prevEnd []byte) ([]byte, []byte, error) {
+		if prevEnd == nil {
+			return srcC.First()
+		}
+		return srcC.Seek(nextKeyAfter(prevEnd))
+	}
+
+	copier := chunkCopier{srcC: srcC, table: table, dupSort: dupSort, logEvery: logEvery}
+	chunks := make([]Chunk, 0, len(t.Chunks))
+	var prevEnd []byte
+	for _, chunk := range t.Chunks {
+		k, v, err := seek(prevEnd)
+		if err != nil {
+			return err
+		}
+		hasher := sha256.New()
+		var count uint64
+		for ; (k != nil) && (bytes.Compare(k, chunk.End) <= 0); k, v, err = srcC.Next() {
+			if err != nil {
+				return err
+			}
+			hashEntry(hasher, k, v)
+			count++
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if (count == chunk.Count) && (hex.EncodeToString(hasher.Sum(nil)) == chunk.Checksum) {
+			chunks = append(chunks, chunk)
+			prevEnd = chunk.End
+			continue
+		}
+
+		log.Info("[backup] chunk changed", "table", table, "start", hex.EncodeToString(chunk.Start), "end", hex.EncodeToString(chunk.End))
+		if k, v, err = seek(prevEnd); err != nil {
+			return err
+		}
+		copied, _, _, err := copier.copyChunk(ctx, dst, k, v, prevEnd, chunk.End)
+		if err != nil {
+			return err
+		}
+		if copied.Count > 0 {
+			chunks = append(chunks, copied)
+		}
+		prevEnd = chunk.End
+	}
+	t.Chunks = chunks
+	return nil
+}
+
+// canonicalResyncFrom finds the first block whose canonical hash differs in dst, starting from the
+// headers progress of the previous backup.
+func canonicalResyncFrom(ctx context.Context, srcTx kv.Tx, dst kv.RoDB, manifest *Manifest) (uint64, error) {
+	srcHeaders, err := stages.GetStageProgress(srcTx, stages.Headers)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := manifest.StageProgress[string(stages.Headers)]
+	if !ok {
+		return 0, nil
+	}
+	if srcHeaders < n {
+		n = srcHeaders
+	}
+	dstTx, err := dst.BeginRo(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer dstTx.Rollback()
+	for {
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, n)
+		srcHash, err := srcTx.GetOne(kv.HeaderCanonical, key)
+		if err != nil {
+			return 0, err
+		}
+		dstHash, err := dstTx.GetOne(kv.HeaderCanonical, key)
+		if err != nil {
+			return 0, err
+		}
+		if (srcHash != nil) && bytes.Equal(srcHash, dstHash) {
+			return n + 1, nil
+		}
+		if n == 0 {
+			return 0, nil
+		}
+		n--
+	}
+}
+
+func readStageProgress(tx kv.Tx) (map[string]uint64, error) {
+	progress := make(map[string]uint64, len(stages.AllStages))
+	for _, stage := range stages.AllStages {
+		value, err := stages.GetStageProgress(tx, stage)
+		if err != nil {
+			return nil, err
+		}
+		progress[string(stage)] = value
+	}
+	return progress, nil
+}
+
+// nextKeyAfter returns the smallest key greater than the key.
+func nextKeyAfter(key []byte) []byte {
+	if key == nil {
+		return nil
+	}
+	return append(append([]byte{}, key...), 0)
+}
+
+// isDupSort tells if the table can have several values per key. The tables with AutoDupSortKeysConversion
+// have unique keys for the application.
+func isDupSort(db kv.RoDB, table string) bool {
+	cfg := db.AllTables()[table]
+	return (cfg.Flags&kv.DupSort != 0) && !cfg.AutoDupSortKeysConversion
+}
+
+// copyTable makes dst equal to src from start on in chunks: the entries missing or different in dst are
+// written, the extra ones are deleted. The dst entries after prevEnd and before start are deleted too.
+func copyTable(
+	ctx context.Context,
+	src kv.RoDB,
+	srcTx kv.Tx,
+	dst kv.RwDB,
+	table string,
+	start, prevEnd []byte,
+	cfg OnlineCfg,
+	onChunk func(Chunk) error,
+	logEvery *time.Ticker,
+) error {
+	wg := sync.WaitGroup{}
+	defer wg.Wait()
+	warmupCtx, warmupCancel := context.WithCancel(ctx)
+	defer warmupCancel()
+	if cfg.ReadAheadThreads > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			WarmupTable(warmupCtx, src, table, log.LvlTrace, cfg.ReadAheadThreads)
+		}()
+	}
+
+	srcC, err := srcTx.Cursor(table)
+	if err != nil {
+		return err
+	}
+	defer srcC.Close()
+	var k, v []byte
+	if start == nil {
+		k, v, err = srcC.First()
+	} else {
+		k, v, err = srcC.Seek(start)
+	}
+	if err != nil {
+		return err
+	}
+
+	copier := chunkCopier{
+		srcC:     srcC,
+		table:    table,
+		dupSort:  isDupSort(src, table),
+		size:     cfg.ChunkSize,
+		logEvery: logEvery,
+	}
+	for {
+		var chunk Chunk
+		chunk, k, v, err = copier.copyChunk(ctx, dst, k, v, prevEnd, nil)
+		if err != nil {
+			return err
+		}
+		if chunk.Count > 0 {
+			if err := onChunk(chunk); err != nil {
+				return err
+			}
+			prevEnd = chunk.End
+		}
+		if k == nil {
+			return nil
+		}
+	}
+}
+
+type chunkCopier struct {
+	srcC     kv.Cursor
+	table    string
+	dupSort  bool
+	size     uint64
+	copied   uint64
+	logEvery *time.Ticker
+}
+
+func (c *chunkCopier) compare(k1, v1, k2, v2 []byte) int {
+	if cmp := bytes.Compare(k1, k2); (cmp != 0) || !c.dupSort {
+		return cmp
+	}
+	return bytes.Compare(v1, v2)
+}
+
+// copyChunk copies the chunk starting at the src entry k, v in one dst transaction
+// and returns the src entry after it. The chunk ends at the key end if set, else after the chunk size.
+// The differences are collected first and written after the
+// scan of dst: writing through another cursor invalidates the position of a cursor in a dupsort key.
+func (c *chunkCopier) copyChunk(ctx context.Context, dst kv.RwDB, k, v, prevEnd, end []byte) (Chunk, []byte, []byte, error) {
+	chunk := Chunk{Start: common2.Copy(k)}
+	dstTx, err := dst.BeginRw(ctx)
+	if err != nil {
+		return chunk, nil, nil, err
+	}
+	defer dstTx.Rollback()
+	dstC, err := dstTx.Cursor(c.table)
+	if err != nil {
+		return chunk, nil, nil, err
+	}
+	defer dstC.Close()
+
+	var dk, dv []byte
+	if prevEnd == nil {
+		dk, dv, err = dstC.First()
+	} else {
+		dk, dv, err = dstC.Seek(nextKeyAfter(prevEnd))
+	}
+	if err != nil {
+		return chunk, nil, nil, err
+	}
+
+	var deletes, puts [][2][]byte
+	hasher := sha256.New()
+	var lastKey []byte
+	for (k != nil) && ((end == nil) || (bytes.Compare(k, end) <= 0)) {
+		// the values of a key are never split between chunks
+		if (end == nil) && (chunk.Count >= c.size) && !bytes.Equal(k, lastKey) {
+			break
+		}
+		for (dk != nil) && (c.compare(dk, dv, k, v) < 0) {
+			deletes = append(deletes, [2][]byte{common2.Copy(dk), common2.Copy(dv)})
+			if dk, dv, err = dstC.Next(); err != nil {
+				return chunk, nil, nil, err
+			}
+		}
+		if (dk != nil) && (c.compare(dk, dv, k, v) == 0) {
+			if !bytes.Equal(dv, v) {
+				puts = append(puts, [2][]byte{common2.Copy(k), common2.Copy(v)})
+			}
+			if dk, dv, err = dstC.Next(); err != nil {
+				return chunk, nil, nil, err
+			}
+		} else {
+			puts = append(puts, [2][]byte{common2.Copy(k), common2.Copy(v)})
+		}
+
+		hashEntry(hasher, k, v)
+		chunk.Count++
+		lastKey = append(lastKey[:0], k...)
+		c.copied++
+
+		if k, v, err = c.srcC.Next(); err != nil {
+			return chunk, nil, nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return chunk, nil, nil, ctx.Err()
+		case <-c.logEvery.C:
+			log.Info("[backup] progress", "table", c.table, "entries", c.copied, "key", hex.EncodeToString(lastKey))
+		default:
+		}
+	}
+	// the dst entries before the next chunk, or all the rest at the end of the table
+	for (dk != nil) && ((k == nil) || (bytes.Compare(dk, k) < 0)) {
+		deletes = append(deletes, [2][]byte{common2.Copy(dk), common2.Copy(dv)})
+		if dk, dv, err = dstC.Next(); err != nil {
+			return chunk, nil, nil, err
+		}
+	}
+	if err := c.apply(dstTx, deletes, puts); err != nil {
+		return chunk, nil, nil, err
+	}
+	if err := dstTx.Commit(); err != nil {
+		return chunk, nil, nil, err
+	}
+
+	if chunk.Count > 0 {
+		chunk.End = common2.Copy(lastKey)
+		chunk.Checksum = hex.EncodeToString(hasher.Sum(nil))
+	}
+	return chunk, k, v, nil
+}
+
+func (c *chunkCopier) apply(tx kv.RwTx, deletes, puts [][2][]byte) error {
+	if c.dupSort {
+		writeC, err := tx.RwCursorDupSort(c.table)
+		if err != nil {
+			return err
+		}
+		defer writeC.Close()
+		for _, entry := range deletes {
+			if err := writeC.DeleteExact(entry[0], entry[1]); err != nil {
+				return err
+			}
+		}
+	} else {
+		for _, entry := range deletes {
+			if err := tx.Delete(c.table, entry[0]); err != nil {
+				return err
+			}
+		}
+	}
+	for _, entry := range puts {
+		if err := tx.Put(c.table, entry[0], entry[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hashEntry(hasher interface{ Write([]byte) (int, error) }, k, v []byte) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(k)))
+	_, _ = hasher.Write(length[:])
+	_, _ = hasher.Write(k)
+	binary.BigEndian.PutUint32(length[:], uint32(len(v)))
+	_, _ = hasher.Write(length[:])
+	_, _ = hasher.Write(v)
+}
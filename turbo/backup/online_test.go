@@ -0,0 +1,271 @@
+package backup_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/holiman/uint256"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/backup"
+	"github.com/ledgerwatch/erigon/turbo/stages"
+)
+
+func generateChain(t *testing.T, m *stages.MockSentry, n int, coinbase byte) *core.ChainPack {
+	t.Helper()
+	signer := types.LatestSignerForChainID(m.ChainConfig.ChainID)
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, n, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(libcommon.Address{coinbase})
+		to := libcommon.Address{coinbase, byte(i)}
+		tx, err := types.SignTx(types.NewTransaction(b.TxNonce(m.Address), to, uint256.NewInt(1000), params.TxGas, uint256.NewInt(params.GWei), nil), *signer, m.Key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.AddTx(tx)
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chain
+}
+
+// requireEqualTables compares the tables of the manifest in both databases entry by entry.
+func requireEqualTables(t *testing.T, src, dst kv.RoDB, manifest *backup.Manifest) {
+	t.Helper()
+	srcTx, err := src.BeginRo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srcTx.Rollback()
+	dstTx, err := dst.BeginRo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dstTx.Rollback()
+	for table := range manifest.Tables {
+		srcC, err := srcTx.Cursor(table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dstC, err := dstTx.Cursor(table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k1, v1, err1 := srcC.First()
+		k2, v2, err2 := dstC.First()
+		for ; (k1 != nil) || (k2 != nil); k1, v1, err1 = srcC.Next() {
+			if (err1 != nil) || (err2 != nil) {
+				t.Fatal(err1, err2)
+			}
+			if !bytes.Equal(k1, k2) || !bytes.Equal(v1, v2) {
+				t.Fatalf("table %s: %x=%x in src, %x=%x in dst", table, k1, v1, k2, v2)
+			}
+			k2, v2, err2 = dstC.Next()
+		}
+		srcC.Close()
+		dstC.Close()
+	}
+}
+
+func TestOnlineBackup(t *testing.T) {
+	m := stages.Mock(t)
+	fork := generateChain(t, m, 7, 2)
+	if err := m.InsertChain(generateChain(t, m, 5, 1)); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	dst := memdb.NewTestDB(t)
+	manifestPath := filepath.Join(t.TempDir(), backup.ManifestFileName)
+	cfg := backup.OnlineCfg{ChunkSize: 3}
+	verifyCfg := backup.VerifyCfg{StateRoot: true, Samples: 16}
+
+	manifest, err := backup.Online(ctx, m.DB, dst, kv.ChainDB, manifestPath, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !manifest.Complete || (manifest.ResyncFromBlock != nil) {
+		t.Fatalf("expected a complete backup, got %+v", manifest)
+	}
+	if len(manifest.Tables[kv.PlainState].Chunks) < 2 {
+		t.Fatalf("expected several chunks, got %d", len(manifest.Tables[kv.PlainState].Chunks))
+	}
+	requireEqualTables(t, m.DB, dst, manifest)
+	if err := backup.Verify(ctx, dst, manifest, verifyCfg); err != nil {
+		t.Fatal(err)
+	}
+
+	// A reorg to a longer fork is backed up incrementally
+	if err := m.InsertChain(fork); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err = backup.Online(ctx, m.DB, dst, kv.ChainDB, manifestPath, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.StageProgress["Execution"] != 7 {
+		t.Errorf("expected execution at 7, got %d", manifest.StageProgress["Execution"])
+	}
+	requireEqualTables(t, m.DB, dst, manifest)
+	if err := backup.Verify(ctx, dst, manifest, verifyCfg); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := backup.ReadManifest(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stored.Complete || (stored.SnapshotID != manifest.SnapshotID) {
+		t.Errorf("expected the stored manifest to be complete, got %+v", stored)
+	}
+
+	// The restored copy verifies
+	restored := memdb.NewTestDB(t)
+	if err := backup.Restore(ctx, dst, restored, manifest, 4, verifyCfg); err != nil {
+		t.Fatal(err)
+	}
+	requireEqualTables(t, m.DB, restored, manifest)
+}
+
+func TestOnlineBackupResume(t *testing.T) {
+	m := stages.Mock(t)
+	if err := m.InsertChain(generateChain(t, m, 3, 1)); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	dst := memdb.NewTestDB(t)
+	manifestPath := filepath.Join(t.TempDir(), backup.ManifestFileName)
+	cfg := backup.OnlineCfg{Tables: []string{kv.PlainState}, ChunkSize: 2}
+
+	manifest, err := backup.Online(ctx, m.DB, dst, kv.ChainDB, manifestPath, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Pretend the pass was interrupted after the first chunk and dst got an entry it shouldn't have
+	tableManifest := manifest.Tables[kv.PlainState]
+	chunks := tableManifest.Chunks
+	tableManifest.Chunks = chunks[:1]
+	tableManifest.Pass = &backup.Pass{SnapshotID: manifest.SnapshotID, Position: chunks[0].End}
+	manifest.Complete = false
+	if err := backup.WriteManifest(manifestPath, manifest); err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.Update(ctx, func(tx kv.RwTx) error {
+		return tx.Put(kv.PlainState, libcommon.Address{0xff}.Bytes(), []byte{1})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err = backup.Online(ctx, m.DB, dst, kv.ChainDB, manifestPath, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !manifest.Complete || (len(manifest.Tables[kv.PlainState].Chunks) != len(chunks)) {
+		t.Fatalf("expected the pass to be finished, got %+v", manifest.Tables[kv.PlainState])
+	}
+	requireEqualTables(t, m.DB, dst, manifest)
+	if err := backup.Verify(ctx, dst, manifest, backup.VerifyCfg{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOnlineBackupResumeNewSnapshot(t *testing.T) {
+	m := stages.Mock(t)
+	if err := m.InsertChain(generateChain(t, m, 3, 1)); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	dst := memdb.NewTestDB(t)
+	manifestPath := filepath.Join(t.TempDir(), backup.ManifestFileName)
+	cfg := backup.OnlineCfg{Tables: []string{kv.PlainState}, ChunkSize: 1}
+
+	manifest, err := backup.Online(ctx, m.DB, dst, kv.ChainDB, manifestPath, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Pretend the pass was interrupted after three chunks, then src got an account between the first two
+	tableManifest := manifest.Tables[kv.PlainState]
+	chunks := tableManifest.Chunks
+	if len(chunks) < 4 {
+		t.Fatalf("expected at least 4 chunks, got %d", len(chunks))
+	}
+	tableManifest.Chunks = chunks[:3]
+	tableManifest.Pass = &backup.Pass{SnapshotID: manifest.SnapshotID, Position: chunks[2].End}
+	manifest.Complete = false
+	if err := backup.WriteManifest(manifestPath, manifest); err != nil {
+		t.Fatal(err)
+	}
+	added := libcommon.Copy(chunks[0].End)
+	added[len(added)-1]++
+	if bytes.Compare(added, chunks[1].Start) >= 0 {
+		t.Fatalf("no room for an account between %x and %x", chunks[0].End, chunks[1].Start)
+	}
+	if err := m.DB.Update(ctx, func(tx kv.RwTx) error {
+		return tx.Put(kv.PlainState, added, []byte{1})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err = backup.Online(ctx, m.DB, dst, kv.ChainDB, manifestPath, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumed := manifest.Tables[kv.PlainState]
+	if !manifest.Complete || (resumed.Count() != (&backup.TableManifest{Chunks: chunks}).Count()+1) {
+		t.Fatalf("expected the pass to be finished, got %+v", resumed)
+	}
+	// The second chunk is copied again with the new account, the others are kept as they are
+	if len(resumed.Chunks) != len(chunks) {
+		t.Fatalf("expected %d chunks, got %d", len(chunks), len(resumed.Chunks))
+	}
+	for i, chunk := range chunks[:3] {
+		if changed := resumed.Chunks[i].Checksum != chunk.Checksum; changed != (i == 1) {
+			t.Errorf("chunk %d: expected changed=%t", i, i == 1)
+		}
+	}
+	requireEqualTables(t, m.DB, dst, manifest)
+	if err := backup.Verify(ctx, dst, manifest, backup.VerifyCfg{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyCorrupted(t *testing.T) {
+	m := stages.Mock(t)
+	if err := m.InsertChain(generateChain(t, m, 3, 1)); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	dst := memdb.NewTestDB(t)
+	manifestPath := filepath.Join(t.TempDir(), backup.ManifestFileName)
+	manifest, err := backup.Online(ctx, m.DB, dst, kv.ChainDB, manifestPath, backup.OnlineCfg{ChunkSize: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.Update(ctx, func(tx kv.RwTx) error {
+		c, err := tx.Cursor(kv.PlainState)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+		k, v, err := c.First()
+		if err != nil {
+			return err
+		}
+		k = libcommon.Copy(k)
+		return tx.Put(kv.PlainState, k, append(libcommon.Copy(v), 0))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := backup.Verify(ctx, dst, manifest, backup.VerifyCfg{}); err == nil {
+		t.Fatal("expected the corrupted entry to fail the verification")
+	}
+
+	if err := backup.Restore(ctx, dst, memdb.NewTestDB(t), &backup.Manifest{Label: kv.ChainDB}, 4, backup.VerifyCfg{}); err == nil {
+		t.Fatal("expected an incomplete backup to be refused")
+	}
+}
@@ -0,0 +1,231 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcfg"
+	"github.com/ledgerwatch/log/v3"
+
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/turbo/trie"
+)
+
+type VerifyCfg struct {
+	// StateRoot compares the state root of the executed head with its header.
+	StateRoot bool
+	// Samples is the number of random plain state entries compared with the hashed state.
+	Samples int
+}
+
+// Restore copies a complete online backup into the empty dst and verifies it.
+func Restore(ctx context.Context, backupDB kv.RoDB, dst kv.RwDB, manifest *Manifest, readAheadThreads int, cfg VerifyCfg) error {
+	if !manifest.Complete {
+		return fmt.Errorf("the backup is incomplete, run the backup again to finish it")
+	}
+	tables := make([]string, 0, len(manifest.Tables))
+	for table := range manifest.Tables {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	if err := Kv2kv(ctx, backupDB, dst, tables, readAheadThreads); err != nil {
+		return err
+	}
+	return Verify(ctx, dst, manifest, cfg)
+}
+
+// Verify checks that the tables of db match the chunk counts and checksums of the manifest,
+// and for the chain DB the stage progress, a sample of the hashed state and the state root.
+func Verify(ctx context.Context, db kv.RoDB, manifest *Manifest, cfg VerifyCfg) error {
+	tx, err := db.BeginRo(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	tables := make([]string, 0, len(manifest.Tables))
+	for table := range manifest.Tables {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		tableManifest := manifest.Tables[table]
+		if err := verifyTable(ctx, tx, table, tableManifest); err != nil {
+			return fmt.Errorf("table %s: %w", table, err)
+		}
+		log.Info("[restore] verified", "table", table, "entries", tableManifest.Count(), "chunks", len(tableManifest.Chunks))
+	}
+
+	// the state checks need the stage progress of a complete chain DB backup
+	if (manifest.Label != kv.ChainDB) || (manifest.Tables[kv.SyncStageProgress] == nil) {
+		return nil
+	}
+	progress, err := readStageProgress(tx)
+	if err != nil {
+		return err
+	}
+	for stage, expected := range manifest.StageProgress {
+		if progress[stage] != expected {
+			return fmt.Errorf("stage %s: progress %d, expected %d", stage, progress[stage], expected)
+		}
+	}
+	log.Info("[restore] verified stage progress", "execution", progress[string(stages.Execution)])
+
+	historyV3, err := kvcfg.HistoryV3.Enabled(tx)
+	if err != nil {
+		return err
+	}
+	if historyV3 {
+		log.Warn("[restore] the state checks are not supported with the history v3")
+		return nil
+	}
+	executed := progress[string(stages.Execution)]
+	if progress[string(stages.HashState)] != executed {
+		log.Warn("[restore] the hashed state isn't at the executed block, skipping the state checks")
+		return nil
+	}
+	if err := verifyHashedStateSample(tx, cfg.Samples); err != nil {
+		return err
+	}
+	if !cfg.StateRoot || (executed == 0) {
+		return nil
+	}
+	if progress[string(stages.IntermediateHashes)] != executed {
+		log.Warn("[restore] the intermediate hashes aren't at the executed block, skipping the state root check")
+		return nil
+	}
+	return verifyStateRoot(tx, executed)
+}
+
+func verifyTable(ctx context.Context, tx kv.Tx, table string, tableManifest *TableManifest) error {
+	c, err := tx.Cursor(table)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	chunks := tableManifest.Chunks
+	i := 0
+	var count uint64
+	var hasher hash.Hash = sha256.New()
+	finishChunk := func() error {
+		chunk := chunks[i]
+		checksum := hex.EncodeToString(hasher.Sum(nil))
+		if (count != chunk.Count) || (checksum != chunk.Checksum) {
+			return fmt.Errorf("chunk %x..%x: %d entries with checksum %s, expected %d with %s",
+				chunk.Start, chunk.End, count, checksum, chunk.Count, chunk.Checksum)
+		}
+		i++
+		count = 0
+		hasher.Reset()
+		return nil
+	}
+
+	for k, v, err := c.First(); k != nil; k, v, err = c.Next() {
+		if err != nil {
+			return err
+		}
+		for (i < len(chunks)) && (bytes.Compare(k, chunks[i].End) > 0) {
+			if err := finishChunk(); err != nil {
+				return err
+			}
+		}
+		if (i == len(chunks)) || (bytes.Compare(k, chunks[i].Start) < 0) {
+			return fmt.Errorf("unexpected key %x", k)
+		}
+		hashEntry(hasher, k, v)
+		count++
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+	}
+	for i < len(chunks) {
+		if err := finishChunk(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyHashedStateSample compares random accounts and storage slots of the plain state
+// with the hashed state the state root is computed from.
+func verifyHashedStateSample(tx kv.Tx, samples int) error {
+	if samples <= 0 {
+		return nil
+	}
+	c, err := tx.Cursor(kv.PlainState)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	random := rand.New(rand.NewSource(time.Now().UnixNano())) // nolint: gosec
+	seek := make([]byte, 20)
+	checked := 0
+	for i := 0; i < samples; i++ {
+		random.Read(seek)
+		k, v, err := c.Seek(seek)
+		if err != nil {
+			return err
+		}
+		if k == nil {
+			continue
+		}
+		var hashedKey []byte
+		switch len(k) {
+		case 20:
+			hashedKey = crypto.Keccak256(k)
+		case 60:
+			hashedKey = append(append(crypto.Keccak256(k[:20]), k[20:28]...), crypto.Keccak256(k[28:])...)
+		default:
+			continue
+		}
+		hashedTable := kv.HashedAccounts
+		if len(k) == 60 {
+			hashedTable = kv.HashedStorage
+		}
+		hashedValue, err := tx.GetOne(hashedTable, hashedKey)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(hashedValue, v) {
+			return fmt.Errorf("plain state %x: %x, hashed state %x: %x", k, v, hashedKey, hashedValue)
+		}
+		checked++
+	}
+	log.Info("[restore] verified the hashed state sample", "entries", checked)
+	return nil
+}
+
+func verifyStateRoot(tx kv.Tx, blockNumber uint64) error {
+	hash, err := rawdb.ReadCanonicalHash(tx, blockNumber)
+	if err != nil {
+		return err
+	}
+	header := rawdb.ReadHeader(tx, hash, blockNumber)
+	if header == nil {
+		return fmt.Errorf("no canonical header %d", blockNumber)
+	}
+	root, err := trie.CalcRoot("[restore]", tx)
+	if err != nil {
+		return err
+	}
+	if root != header.Root {
+		return fmt.Errorf("state root %x, expected %x of block %d", root, header.Root, blockNumber)
+	}
+	log.Info("[restore] verified the state root", "block", blockNumber, "root", root)
+	return nil
+}
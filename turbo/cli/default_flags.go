@@ -69,9 +69,13 @@ var DefaultFlags = []cli.Flag{
 	&utils.RpcStreamingDisableFlag,
 	&utils.DBReadConcurrencyFlag,
 	&utils.RpcAccessListFlag,
+	&utils.RpcAuthFlag,
 	&utils.RpcTraceCompatFlag,
 	&utils.RpcGasCapFlag,
 	&utils.RpcBatchLimit,
+	&utils.RpcFiltersTimeoutFlag,
+	&utils.RpcFiltersMaxFlag,
+	&utils.RpcFiltersMaxItemsFlag,
 	&utils.RpcReturnDataLimit,
 	&utils.TxpoolApiAddrFlag,
 	&utils.TraceMaxtracesFlag,
@@ -111,6 +115,9 @@ var DefaultFlags = []cli.Flag{
 	&utils.MaxPeersFlag,
 	&utils.ChainFlag,
 	&utils.DeveloperPeriodFlag,
+	&utils.DeveloperPoSFlag,
+	&utils.DeveloperWithdrawalsFlag,
+	&utils.DeveloperWithdrawalAmountFlag,
 	&utils.VMEnableDebugFlag,
 	&utils.NetworkIdFlag,
 	&utils.FakePoWFlag,
@@ -137,6 +144,7 @@ var DefaultFlags = []cli.Flag{
 	&utils.MinerSigningKeyFileFlag,
 	&utils.SentryAddrFlag,
 	&utils.SentryLogPeerInfoFlag,
+	&utils.SentryLocalTxBroadcastFlag,
 	&utils.SentryDropUselessPeers,
 	&utils.DownloaderAddrFlag,
 	&utils.DisableIPV4,
@@ -149,6 +157,7 @@ var DefaultFlags = []cli.Flag{
 	&utils.HeimdallgRPCAddressFlag,
 	&utils.EthStatsURLFlag,
 	&utils.OverrideShanghaiTime,
+	&utils.OverrideCancunTime,
 
 	&utils.ConfigFlag,
 	&logging.LogConsoleVerbosityFlag,
@@ -24,6 +24,7 @@ import (
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	"github.com/ledgerwatch/erigon/ethdb/prune"
 	"github.com/ledgerwatch/erigon/node/nodecfg"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 )
 
 var (
@@ -391,11 +392,17 @@ func setEmbeddedRpcDaemon(ctx *cli.Context, cfg *nodecfg.Config) {
 		RpcStreamingDisable:  ctx.Bool(utils.RpcStreamingDisableFlag.Name),
 		DBReadConcurrency:    ctx.Int(utils.DBReadConcurrencyFlag.Name),
 		RpcAllowListFilePath: ctx.String(utils.RpcAccessListFlag.Name),
+		RpcAuthFilePath:      ctx.String(utils.RpcAuthFlag.Name),
 		Gascap:               ctx.Uint64(utils.RpcGasCapFlag.Name),
 		MaxTraces:            ctx.Uint64(utils.TraceMaxtracesFlag.Name),
 		TraceCompatibility:   ctx.Bool(utils.RpcTraceCompatFlag.Name),
 		BatchLimit:           ctx.Int(utils.RpcBatchLimit.Name),
 		ReturnDataLimit:      ctx.Int(utils.RpcReturnDataLimit.Name),
+		RpcFiltersConfig: rpchelper.FiltersConfig{
+			Timeout:    ctx.Duration(utils.RpcFiltersTimeoutFlag.Name),
+			MaxFilters: ctx.Int(utils.RpcFiltersMaxFlag.Name),
+			MaxItems:   ctx.Int(utils.RpcFiltersMaxItemsFlag.Name),
+		},
 
 		TxPoolApiAddr: ctx.String(utils.TxpoolApiAddrFlag.Name),
 
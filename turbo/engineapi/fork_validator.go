@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/VictoriaMetrics/metrics"
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
 	"github.com/ledgerwatch/erigon-lib/kv"
@@ -28,14 +29,40 @@ import (
 	"github.com/ledgerwatch/erigon/common/math"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/turbo/shards"
 )
 
 // the maximum point from the current head, past which side forks are not validated anymore.
 const maxForkDepth = 32 // 32 slots is the duration of an epoch thus there cannot be side forks in PoS deeper than 32 blocks from head.
+const maxSideForks = 8  // side forks whose state is kept in memory, the lowest ones are dropped first.
+
+var (
+	sideForkHits     = metrics.GetOrCreateCounter(`fork_validator_side_fork{result="hit"}`)
+	sideForkMisses   = metrics.GetOrCreateCounter(`fork_validator_side_fork{result="miss"}`)
+	forkChoiceHits   = metrics.GetOrCreateCounter(`fork_validator_fork_choice{result="hit"}`)
+	forkChoiceMisses = metrics.GetOrCreateCounter(`fork_validator_fork_choice{result="miss"}`)
+)
 
 type validatePayloadFunc func(kv.RwTx, *types.Header, *types.RawBody, uint64, []*types.Header, []*types.RawBody, *shards.Notifications) error
 
+// sideFork is a validated side chain whose state is kept in memory on top of the canonical chain.
+type sideFork struct {
+	batch         *memdb.MemoryMutation
+	notifications *shards.Notifications
+	// height of the side fork head.
+	number uint64
+	// height and hash of the last canonical block the side fork builds upon.
+	forkPoint     uint64
+	forkPointHash libcommon.Hash
+	// canonical head the memory batch was built on top of, the batch can only be extended while it stays the head.
+	canonicalHead libcommon.Hash
+}
+
+func (sf *sideFork) rollback() {
+	sf.batch.Rollback()
+}
+
 type ForkValidator struct {
 	// Hash => side fork block, any block saved into this map is considered valid.
 	// blocks saved are required to have at most distance maxForkDepth from the head.
@@ -47,6 +74,11 @@ type ForkValidator struct {
 	extendingForkNotifications *shards.Notifications
 	// hash of chain head that extend canonical fork.
 	extendingForkHeadHash libcommon.Hash
+	// Head hash => validated side fork, these can be either extended by later payloads or flushed by fcu.
+	sideForks map[libcommon.Hash]*sideFork
+	// side fork chosen by fcu, waiting for the canonical chain to be unwound to its forking point.
+	selectedFork         *sideFork
+	selectedForkHeadHash libcommon.Hash
 	// this is the function we use to perform payload validation.
 	validatePayload validatePayloadFunc
 	// this is the current point where we processed the chain so far.
@@ -59,6 +91,7 @@ type ForkValidator struct {
 func NewForkValidatorMock(currentHeight uint64) *ForkValidator {
 	return &ForkValidator{
 		sideForksBlock: make(map[libcommon.Hash]types.RawBlock),
+		sideForks:      make(map[libcommon.Hash]*sideFork),
 		currentHeight:  currentHeight,
 	}
 }
@@ -66,6 +99,7 @@ func NewForkValidatorMock(currentHeight uint64) *ForkValidator {
 func NewForkValidator(currentHeight uint64, validatePayload validatePayloadFunc, tmpDir string) *ForkValidator {
 	return &ForkValidator{
 		sideForksBlock:  make(map[libcommon.Hash]types.RawBlock),
+		sideForks:       make(map[libcommon.Hash]*sideFork),
 		validatePayload: validatePayload,
 		currentHeight:   currentHeight,
 		tmpDir:          tmpDir,
@@ -116,12 +150,15 @@ func (fv *ForkValidator) NotifyCurrentHeight(currentHeight uint64) {
 	fv.extendingFork = nil
 	fv.extendingForkNotifications = nil
 	fv.extendingForkHeadHash = libcommon.Hash{}
+	// Side forks survive as long as their forking point is within reach, they can still be flushed by fcu.
+	fv.clean()
 }
 
 // FlushExtendingFork flush the current extending fork if fcu chooses its head hash as the its forkchoice.
 func (fv *ForkValidator) FlushExtendingFork(tx kv.RwTx, accumulator *shards.Accumulator) error {
 	fv.lock.Lock()
 	defer fv.lock.Unlock()
+	forkChoiceHits.Inc()
 	// Flush changes to db.
 	if err := fv.extendingFork.Flush(tx); err != nil {
 		return err
@@ -135,8 +172,70 @@ func (fv *ForkValidator) FlushExtendingFork(tx kv.RwTx, accumulator *shards.Accu
 	return nil
 }
 
+// SelectSideFork is called when fcu chooses a head which is not on the canonical chain and forks off it at forkingPoint.
+// If we hold a validated side fork with such head, it is kept aside until the canonical chain is unwound to forkingPoint,
+// so that FlushSideFork can apply it instead of re-executing the side chain.
+func (fv *ForkValidator) SelectSideFork(headHash libcommon.Hash, forkingPoint uint64) bool {
+	fv.lock.Lock()
+	defer fv.lock.Unlock()
+	fork, ok := fv.sideForks[headHash]
+	if !ok || fork.forkPoint != forkingPoint {
+		return false
+	}
+	delete(fv.sideForks, headHash)
+	fv.discardSelectedFork()
+	fv.selectedFork = fork
+	fv.selectedForkHeadHash = headHash
+	return true
+}
+
+// FlushSideFork flush the validated side fork with the given head, if we hold one and the canonical chain is at its forking point.
+// It returns false if nothing was flushed, in which case the side chain has to be executed by the regular stages.
+func (fv *ForkValidator) FlushSideFork(tx kv.RwTx, accumulator *shards.Accumulator, headHash libcommon.Hash) (bool, error) {
+	fv.lock.Lock()
+	defer fv.lock.Unlock()
+	fork := fv.selectedFork
+	if fork == nil || fv.selectedForkHeadHash != headHash {
+		var ok bool
+		if fork, ok = fv.sideForks[headHash]; !ok {
+			forkChoiceMisses.Inc()
+			return false, nil
+		}
+		delete(fv.sideForks, headHash)
+	} else {
+		fv.selectedFork = nil
+		fv.selectedForkHeadHash = libcommon.Hash{}
+	}
+	defer fork.rollback()
+	// The memory batch contains the unwind down to the forking point, so it can only be applied on top of it.
+	executionAt, err := stages.GetStageProgress(tx, stages.Execution)
+	if err != nil {
+		return false, err
+	}
+	if executionAt != fork.forkPoint {
+		forkChoiceMisses.Inc()
+		return false, nil
+	}
+	// The canonical chain may have been reorged in the meantime, so the forking point has to be the very same block.
+	forkPointHash, err := rawdb.ReadCanonicalHash(tx, fork.forkPoint)
+	if err != nil {
+		return false, err
+	}
+	if forkPointHash != fork.forkPointHash {
+		forkChoiceMisses.Inc()
+		return false, nil
+	}
+	forkChoiceHits.Inc()
+	if err := fork.batch.Flush(tx); err != nil {
+		return false, err
+	}
+	fork.notifications.Accumulator.CopyAndReset(accumulator)
+	return true, nil
+}
+
 // ValidatePayload returns whether a payload is valid or invalid, or if cannot be determined, it will be accepted.
 // if the payload extend the canonical chain, then we stack it in extendingFork without any unwind.
+// if the payload extends one of the side forks we validated before, then we stack it on top of that side fork's memory batch.
 // if the payload is a fork then we unwind to the point where the fork meet the canonical chain and we check if it is valid or not from there.
 // if for any reasons none of the action above can be performed due to lack of information, we accept the payload and avoid validation.
 func (fv *ForkValidator) ValidatePayload(tx kv.RwTx, header *types.Header, body *types.RawBody, extendCanonical bool) (status remote.EngineStatus, latestValidHash libcommon.Hash, validationError error, criticalError error) {
@@ -168,7 +267,35 @@ func (fv *ForkValidator) ValidatePayload(tx kv.RwTx, header *types.Header, body
 		}
 		// Update fork head hash.
 		fv.extendingForkHeadHash = header.Hash()
-		return fv.validateAndStorePayload(fv.extendingFork, header, body, 0, nil, nil, fv.extendingForkNotifications)
+		status, latestValidHash, validationError, criticalError = fv.validateAndStorePayload(fv.extendingFork, header, body, 0, nil, nil, fv.extendingForkNotifications)
+		if validationError != nil {
+			fv.extendingFork.Rollback()
+			fv.extendingFork = nil
+			fv.extendingForkHeadHash = libcommon.Hash{}
+		}
+		return
+	}
+
+	var canonicalHead libcommon.Hash
+	canonicalHead, criticalError = rawdb.ReadCanonicalHash(tx, fv.currentHeight)
+	if criticalError != nil {
+		return
+	}
+	// If the new block extends a side fork we kept in memory, we can reuse its state without any unwind.
+	// This only holds while the canonical head is the one the fork was validated against, otherwise the
+	// memory batch would mix the unwound state with the blocks inserted since, and we validate from scratch.
+	if fork, ok := fv.sideForks[header.ParentHash]; ok && fork.canonicalHead == canonicalHead {
+		sideForkHits.Inc()
+		delete(fv.sideForks, header.ParentHash)
+		fork.batch.UpdateTxn(tx)
+		status, latestValidHash, validationError, criticalError = fv.validateAndStorePayload(fork.batch, header, body, 0, nil, nil, fork.notifications)
+		if criticalError != nil || validationError != nil {
+			fork.rollback()
+			return
+		}
+		fork.number = header.Number.Uint64()
+		fv.storeSideFork(header.Hash(), fork)
+		return
 	}
 
 	// if the block is not in range of maxForkDepth from head then we do not validate it.
@@ -214,17 +341,55 @@ func (fv *ForkValidator) ValidatePayload(tx kv.RwTx, header *types.Header, body
 		}
 		unwindPoint = sb.Header.Number.Uint64() - 1
 	}
+	forkPoint := unwindPoint
 	// Do not set an unwind point if we are already there.
 	if unwindPoint == fv.currentHeight {
 		unwindPoint = 0
 	}
-	batch := memdb.NewMemoryBatch(tx, fv.tmpDir)
-	defer batch.Rollback()
-	notifications := &shards.Notifications{
-		Events:      shards.NewEvents(),
-		Accumulator: shards.NewAccumulator(),
+	sideForkMisses.Inc()
+	fork := &sideFork{
+		batch: memdb.NewMemoryBatch(tx, fv.tmpDir),
+		notifications: &shards.Notifications{
+			Events:      shards.NewEvents(),
+			Accumulator: shards.NewAccumulator(),
+		},
+		number:        header.Number.Uint64(),
+		forkPoint:     forkPoint,
+		forkPointHash: currentHash,
+		canonicalHead: canonicalHead,
+	}
+	status, latestValidHash, validationError, criticalError = fv.validateAndStorePayload(fork.batch, header, body, unwindPoint, headersChain, bodiesChain, fork.notifications)
+	if criticalError != nil || validationError != nil {
+		fork.rollback()
+		return
+	}
+	// Keep the validated state around, so that fcu or a child payload can reuse it.
+	fv.storeSideFork(header.Hash(), fork)
+	return
+}
+
+// storeSideFork keeps a validated side fork, dropping the lowest one if there are already maxSideForks of them.
+func (fv *ForkValidator) storeSideFork(headHash libcommon.Hash, fork *sideFork) {
+	if len(fv.sideForks) >= maxSideForks {
+		var lowestHash libcommon.Hash
+		var lowest *sideFork
+		for hash, f := range fv.sideForks {
+			if lowest == nil || f.number < lowest.number {
+				lowestHash, lowest = hash, f
+			}
+		}
+		lowest.rollback()
+		delete(fv.sideForks, lowestHash)
+	}
+	fv.sideForks[headHash] = fork
+}
+
+func (fv *ForkValidator) discardSelectedFork() {
+	if fv.selectedFork != nil {
+		fv.selectedFork.rollback()
 	}
-	return fv.validateAndStorePayload(batch, header, body, unwindPoint, headersChain, bodiesChain, notifications)
+	fv.selectedFork = nil
+	fv.selectedForkHeadHash = libcommon.Hash{}
 }
 
 // Clear wipes out current extending fork data, this method is called after fcu is called,
@@ -272,11 +437,6 @@ func (fv *ForkValidator) validateAndStorePayload(tx kv.RwTx, header *types.Heade
 	if validationError != nil {
 		latestValidHash = header.ParentHash
 		status = remote.EngineStatus_INVALID
-		if fv.extendingFork != nil {
-			fv.extendingFork.Rollback()
-			fv.extendingFork = nil
-		}
-		fv.extendingForkHeadHash = libcommon.Hash{}
 		return
 	}
 	// If we do not have the body we can recover it from the batch.
@@ -305,4 +465,19 @@ func (fv *ForkValidator) clean() {
 			delete(fv.sideForksBlock, hash)
 		}
 	}
+	for hash, fork := range fv.sideForks {
+		if fv.outdatedSideFork(fork) {
+			fork.rollback()
+			delete(fv.sideForks, hash)
+		}
+	}
+	if fv.selectedFork != nil && fv.outdatedSideFork(fv.selectedFork) {
+		fv.discardSelectedFork()
+	}
+}
+
+// outdatedSideFork tells whether the forking point of a side fork was unwound or is deeper than maxForkDepth from the head,
+// in both cases the side fork can no longer be flushed on top of the canonical chain.
+func (fv *ForkValidator) outdatedSideFork(fork *sideFork) bool {
+	return fork.forkPoint > fv.currentHeight || fv.currentHeight-fork.forkPoint > maxForkDepth
 }
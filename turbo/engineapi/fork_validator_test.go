@@ -0,0 +1,23 @@
+package engineapi
+
+import (
+	"testing"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSideForksBounded(t *testing.T) {
+	tx := memdb.BeginRw(t, memdb.NewTestDB(t))
+	fv := NewForkValidatorMock(100)
+	for i := 0; i < 2*maxSideForks; i++ {
+		// The forks arrive out of height order, the lowest ones are dropped
+		number := uint64(100 + (i*5)%(2*maxSideForks))
+		fv.storeSideFork(libcommon.Hash{byte(i)}, &sideFork{batch: memdb.NewMemoryBatch(tx, t.TempDir()), number: number})
+	}
+	require.Len(t, fv.sideForks, maxSideForks)
+	for _, fork := range fv.sideForks {
+		require.GreaterOrEqual(t, fork.number, uint64(100+maxSideForks))
+	}
+}
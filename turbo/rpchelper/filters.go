@@ -39,10 +39,13 @@ type Filters struct {
 	logsRequestor    atomic.Value
 	onNewSnapshot    func()
 
+	config             atomic.Pointer[FiltersConfig]
 	storeMu            sync.Mutex
-	logsStores         *SyncMap[LogsSubID, []*types.Log]
-	pendingHeadsStores *SyncMap[HeadsSubID, []*types.Header]
-	pendingTxsStores   *SyncMap[PendingTxsSubID, [][]types.Transaction]
+	installedFilters   int                       // polling filters, guarded by storeMu
+	recentHeads        map[uint64]libcommon.Hash // the latest canonical headers, guarded by storeMu
+	logsStores         *SyncMap[LogsSubID, *pollingFilter[*types.Log]]
+	pendingHeadsStores *SyncMap[HeadsSubID, *pollingFilter[*types.Header]]
+	pendingTxsStores   *SyncMap[PendingTxsSubID, *pollingFilter[[]types.Transaction]]
 }
 
 func New(ctx context.Context, ethBackend ApiBackend, txPool txpool.TxpoolClient, mining txpool.MiningClient, onNewSnapshot func()) *Filters {
@@ -55,10 +58,14 @@ func New(ctx context.Context, ethBackend ApiBackend, txPool txpool.TxpoolClient,
 		pendingBlockSubs:   NewSyncMap[PendingBlockSubID, Sub[*types.Block]](),
 		logsSubs:           NewLogsFilterAggregator(),
 		onNewSnapshot:      onNewSnapshot,
-		logsStores:         NewSyncMap[LogsSubID, []*types.Log](),
-		pendingHeadsStores: NewSyncMap[HeadsSubID, []*types.Header](),
-		pendingTxsStores:   NewSyncMap[PendingTxsSubID, [][]types.Transaction](),
+		recentHeads:        make(map[uint64]libcommon.Hash),
+		logsStores:         NewSyncMap[LogsSubID, *pollingFilter[*types.Log]](),
+		pendingHeadsStores: NewSyncMap[HeadsSubID, *pollingFilter[*types.Header]](),
+		pendingTxsStores:   NewSyncMap[PendingTxsSubID, *pollingFilter[[]types.Transaction]](),
 	}
+	ff.SetConfig(DefaultFiltersConfig)
+
+	go ff.timeoutLoop(ctx)
 
 	go func() {
 		if ethBackend == nil {
@@ -309,7 +316,9 @@ func (ff *Filters) UnsubscribeHeads(id HeadsSubID) bool {
 	if _, ok = ff.headsSubs.Delete(id); !ok {
 		return false
 	}
-	ff.pendingHeadsStores.Delete(id)
+	if _, ok = ff.pendingHeadsStores.Delete(id); ok {
+		ff.releaseFilter()
+	}
 	return true
 }
 
@@ -361,13 +370,20 @@ func (ff *Filters) UnsubscribePendingTxs(id PendingTxsSubID) bool {
 	if _, ok = ff.pendingTxsSubs.Delete(id); !ok {
 		return false
 	}
-	ff.pendingTxsStores.Delete(id)
+	if _, ok = ff.pendingTxsStores.Delete(id); ok {
+		ff.releaseFilter()
+	}
 	return true
 }
 
 func (ff *Filters) SubscribeLogs(size int, crit filters.FilterCriteria) (<-chan *types.Log, LogsSubID) {
+	id := LogsSubID(generateSubscriptionID())
+	return ff.subscribeLogs(id, size, crit), id
+}
+
+func (ff *Filters) subscribeLogs(id LogsSubID, size int, crit filters.FilterCriteria) <-chan *types.Log {
 	sub := newChanSub[*types.Log](size)
-	id, f := ff.logsSubs.insertLogsFilter(sub)
+	f := ff.logsSubs.insertLogsFilter(id, sub)
 	f.addrs = map[libcommon.Address]int{}
 	if len(crit.Addresses) == 0 {
 		f.allAddrs = 1
@@ -407,7 +423,7 @@ func (ff *Filters) SubscribeLogs(size int, crit filters.FilterCriteria) (<-chan
 		}
 	}
 
-	return sub.ch, id
+	return sub.ch
 }
 
 func (ff *Filters) loadLogsRequester() any {
@@ -418,6 +434,7 @@ func (ff *Filters) loadLogsRequester() any {
 
 func (ff *Filters) UnsubscribeLogs(id LogsSubID) bool {
 	isDeleted := ff.logsSubs.removeLogsFilter(id)
+	ff.deleteLogStore(id)
 	// if any filters in the aggregate need all addresses or all topics then the request to the central
 	// log subscription needs to honour this
 	lfr := ff.logsSubs.createFilterRequest()
@@ -438,13 +455,13 @@ func (ff *Filters) UnsubscribeLogs(id LogsSubID) bool {
 		}
 	}
 
-	ff.deleteLogStore(id)
-
 	return isDeleted
 }
 
 func (ff *Filters) deleteLogStore(id LogsSubID) {
-	ff.logsStores.Delete(id)
+	if _, ok := ff.logsStores.Delete(id); ok {
+		ff.releaseFilter()
+	}
 }
 
 // OnNewEvent is called when there is a new Event from the remote
@@ -513,6 +530,7 @@ func (ff *Filters) onNewHeader(event *remote.SubscribeReply) error {
 	if err != nil {
 		return fmt.Errorf("unprocessable payload: %w", err)
 	}
+	ff.reorgLogs(&header)
 	return ff.headsSubs.Range(func(k HeadsSubID, v Sub[*types.Header]) error {
 		v.Send(&header)
 		return nil
@@ -544,57 +562,3 @@ func (ff *Filters) OnNewTx(reply *txpool.OnAddReply) {
 func (ff *Filters) OnNewLogs(reply *remote.SubscribeLogsReply) {
 	ff.logsSubs.distributeLog(reply)
 }
-
-func (ff *Filters) AddLogs(id LogsSubID, logs *types.Log) {
-	ff.logsStores.DoAndStore(id, func(st []*types.Log, ok bool) []*types.Log {
-		if !ok {
-			st = make([]*types.Log, 0)
-		}
-		st = append(st, logs)
-		return st
-	})
-}
-
-func (ff *Filters) ReadLogs(id LogsSubID) ([]*types.Log, bool) {
-	res, ok := ff.logsStores.Delete(id)
-	if !ok {
-		return res, false
-	}
-	return res, true
-}
-
-func (ff *Filters) AddPendingBlock(id HeadsSubID, block *types.Header) {
-	ff.pendingHeadsStores.DoAndStore(id, func(st []*types.Header, ok bool) []*types.Header {
-		if !ok {
-			st = make([]*types.Header, 0)
-		}
-		st = append(st, block)
-		return st
-	})
-}
-
-func (ff *Filters) ReadPendingBlocks(id HeadsSubID) ([]*types.Header, bool) {
-	res, ok := ff.pendingHeadsStores.Delete(id)
-	if !ok {
-		return res, false
-	}
-	return res, true
-}
-
-func (ff *Filters) AddPendingTxs(id PendingTxsSubID, txs []types.Transaction) {
-	ff.pendingTxsStores.DoAndStore(id, func(st [][]types.Transaction, ok bool) [][]types.Transaction {
-		if !ok {
-			st = make([][]types.Transaction, 0)
-		}
-		st = append(st, txs)
-		return st
-	})
-}
-
-func (ff *Filters) ReadPendingTxs(id PendingTxsSubID) ([][]types.Transaction, bool) {
-	res, ok := ff.pendingTxsStores.Delete(id)
-	if !ok {
-		return res, false
-	}
-	return res, true
-}
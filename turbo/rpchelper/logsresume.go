@@ -0,0 +1,89 @@
+package rpchelper
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/common/hexutility"
+	"github.com/ledgerwatch/erigon-lib/common/length"
+
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+const (
+	logsResumeTokenVersion = 1
+	logsResumeTokenLen     = 1 + 8 + length.Hash + 8
+
+	// AllLogs as LogsResumeToken.LogIndex means that all logs of the block were delivered
+	AllLogs = math.MaxUint64
+)
+
+// LogsResumeToken is the position of the last log delivered by a logs subscription. A subscription
+// created with the token continues right after that log.
+type LogsResumeToken struct {
+	BlockNumber uint64
+	BlockHash   libcommon.Hash
+	LogIndex    uint64
+}
+
+func (t LogsResumeToken) Encode() []byte {
+	b := make([]byte, 0, logsResumeTokenLen)
+	b = append(b, logsResumeTokenVersion)
+	b = binary.BigEndian.AppendUint64(b, t.BlockNumber)
+	b = append(b, t.BlockHash[:]...)
+	b = binary.BigEndian.AppendUint64(b, t.LogIndex)
+	return b
+}
+
+func DecodeLogsResumeToken(b []byte) (LogsResumeToken, error) {
+	if len(b) != logsResumeTokenLen || b[0] != logsResumeTokenVersion {
+		return LogsResumeToken{}, fmt.Errorf("invalid resume token: %x", b)
+	}
+	return LogsResumeToken{
+		BlockNumber: binary.BigEndian.Uint64(b[1:]),
+		BlockHash:   libcommon.BytesToHash(b[9 : 9+length.Hash]),
+		LogIndex:    binary.BigEndian.Uint64(b[9+length.Hash:]),
+	}, nil
+}
+
+// ResumableLog is a notification of a logs subscription created with fromBlock or resumeToken:
+// the log with the token to resume the subscription after it.
+type ResumableLog struct {
+	*types.Log
+	ResumeToken hexutility.Bytes `json:"resumeToken"`
+}
+
+func (l ResumableLog) MarshalJSON() ([]byte, error) {
+	enc, err := json.Marshal(l.Log)
+	if err != nil {
+		return nil, err
+	}
+	token, err := json.Marshal(l.ResumeToken)
+	if err != nil {
+		return nil, err
+	}
+	// enc is a JSON object, insert the token before its closing brace
+	b := make([]byte, 0, len(enc)+len(token)+16)
+	b = append(b, enc[:len(enc)-1]...)
+	b = append(b, `,"resumeToken":`...)
+	b = append(b, token...)
+	return append(b, '}'), nil
+}
+
+func (l *ResumableLog) UnmarshalJSON(input []byte) error {
+	var dec struct {
+		ResumeToken hexutility.Bytes `json:"resumeToken"`
+	}
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	l.Log = new(types.Log)
+	if err := json.Unmarshal(input, l.Log); err != nil {
+		return err
+	}
+	l.ResumeToken = dec.ResumeToken
+	return nil
+}
@@ -0,0 +1,350 @@
+package rpchelper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/filters"
+)
+
+// FiltersConfig limits the polling filters installed with eth_newFilter, eth_newBlockFilter and
+// eth_newPendingTransactionFilter.
+type FiltersConfig struct {
+	Timeout    time.Duration // a filter not polled for this long is uninstalled, 0 disables the expiry
+	MaxFilters int           // the maximum number of installed filters, 0 means no limit
+	MaxItems   int           // the maximum number of logs, headers or transactions buffered per filter between polls, 0 means no limit
+}
+
+var DefaultFiltersConfig = FiltersConfig{
+	Timeout:    5 * time.Minute,
+	MaxFilters: 1_000,
+	MaxItems:   10_000,
+}
+
+// expiryChecksPerTimeout is how many times per timeout idle filters are looked for, so that a filter outlives
+// the timeout by a tenth of it at most.
+const expiryChecksPerTimeout = 10
+
+// reorgLogsDepth is the number of latest blocks whose polled logs are notified as removed on reorg
+const reorgLogsDepth = 128
+
+var (
+	ErrFilterNotFound = errors.New("filter not found")
+	ErrTooManyFilters = errors.New("too many filters")
+	ErrFilterOverflow = errors.New("filter overflowed")
+)
+
+// pollingFilter buffers the items of an installed filter until they are polled.
+type pollingFilter[T any] struct {
+	mu       sync.Mutex
+	items    []T
+	size     int  // the number of logs, headers or transactions in items
+	overflow bool // items were dropped since the last poll
+	lastPoll time.Time
+
+	polled []*types.Log // the polled logs of the latest blocks, for the logs filters only
+}
+
+func newPollingFilter[T any]() *pollingFilter[T] {
+	return &pollingFilter[T]{lastPoll: time.Now()}
+}
+
+// add buffers the item unless the filter overflows, then all the buffered items are dropped.
+func (f *pollingFilter[T]) add(item T, size, maxItems int) {
+	if f.overflow {
+		return
+	}
+	if maxItems > 0 && f.size+size > maxItems {
+		f.items, f.size, f.overflow = nil, 0, true
+		return
+	}
+	f.items = append(f.items, item)
+	f.size += size
+}
+
+func (f *pollingFilter[T]) read(maxItems int) ([]T, error) {
+	items, overflow := f.items, f.overflow
+	f.items, f.size, f.overflow, f.lastPoll = nil, 0, false, time.Now()
+	if overflow {
+		return nil, fmt.Errorf("%w: more than %d items since the last poll", ErrFilterOverflow, maxItems)
+	}
+	return items, nil
+}
+
+func (f *pollingFilter[T]) idleSince() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.lastPoll
+}
+
+// NewLogsFilter installs a filter for eth_getFilterChanges which buffers the logs matching crit.
+func (ff *Filters) NewLogsFilter(crit filters.FilterCriteria) (LogsSubID, error) {
+	if err := ff.reserveFilter(); err != nil {
+		return "", err
+	}
+	// The store has to exist before the subscription, or the first logs and reorgs would miss it
+	id := LogsSubID(generateSubscriptionID())
+	ff.logsStores.Put(id, newPollingFilter[*types.Log]())
+	logs := ff.subscribeLogs(id, 256, crit)
+	go func() {
+		for lg := range logs {
+			ff.AddLogs(id, lg)
+		}
+	}()
+	return id, nil
+}
+
+// NewHeadsFilter installs a filter for eth_getFilterChanges which buffers the new headers.
+func (ff *Filters) NewHeadsFilter() (HeadsSubID, error) {
+	if err := ff.reserveFilter(); err != nil {
+		return "", err
+	}
+	ch, id := ff.SubscribeNewHeads(32)
+	ff.pendingHeadsStores.Put(id, newPollingFilter[*types.Header]())
+	go func() {
+		for header := range ch {
+			ff.AddPendingBlock(id, header)
+		}
+	}()
+	return id, nil
+}
+
+// NewPendingTxsFilter installs a filter for eth_getFilterChanges which buffers the transactions added to the pool.
+func (ff *Filters) NewPendingTxsFilter() (PendingTxsSubID, error) {
+	if err := ff.reserveFilter(); err != nil {
+		return "", err
+	}
+	txsCh, id := ff.SubscribePendingTxs(32)
+	ff.pendingTxsStores.Put(id, newPollingFilter[[]types.Transaction]())
+	go func() {
+		for txs := range txsCh {
+			ff.AddPendingTxs(id, txs)
+		}
+	}()
+	return id, nil
+}
+
+// SetConfig replaces the limits of the polling filters, DefaultFiltersConfig until set.
+func (ff *Filters) SetConfig(config FiltersConfig) {
+	ff.config.Store(&config)
+}
+
+func (ff *Filters) reserveFilter() error {
+	maxFilters := ff.config.Load().MaxFilters
+	ff.storeMu.Lock()
+	defer ff.storeMu.Unlock()
+	if maxFilters > 0 && ff.installedFilters >= maxFilters {
+		return fmt.Errorf("%w: %d installed", ErrTooManyFilters, ff.installedFilters)
+	}
+	ff.installedFilters++
+	return nil
+}
+
+func (ff *Filters) releaseFilter() {
+	ff.storeMu.Lock()
+	defer ff.storeMu.Unlock()
+	ff.installedFilters--
+}
+
+// AddLogs buffers a log of the logs filter. The logs of a block which is not canonical anymore are dropped.
+func (ff *Filters) AddLogs(id LogsSubID, lg *types.Log) {
+	f, ok := ff.logsStores.Get(id)
+	if !ok {
+		return
+	}
+	ff.storeMu.Lock()
+	hash, known := ff.recentHeads[lg.BlockNumber]
+	ff.storeMu.Unlock()
+	if known && hash != lg.BlockHash {
+		return
+	}
+	// The logs of a new fork come marked as removed, they are canonical at this point
+	lg.Removed = false
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.add(lg, 1, ff.config.Load().MaxItems)
+}
+
+func (ff *Filters) ReadLogs(id LogsSubID) ([]*types.Log, bool, error) {
+	f, ok := ff.logsStores.Get(id)
+	if !ok {
+		return nil, false, nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	logs, err := f.read(ff.config.Load().MaxItems)
+	if err != nil {
+		return nil, true, err
+	}
+	for _, lg := range logs {
+		if !lg.Removed {
+			f.polled = append(f.polled, lg)
+		}
+	}
+	return logs, true, nil
+}
+
+func (ff *Filters) AddPendingBlock(id HeadsSubID, header *types.Header) {
+	f, ok := ff.pendingHeadsStores.Get(id)
+	if !ok {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.add(header, 1, ff.config.Load().MaxItems)
+}
+
+func (ff *Filters) ReadPendingBlocks(id HeadsSubID) ([]*types.Header, bool, error) {
+	f, ok := ff.pendingHeadsStores.Get(id)
+	if !ok {
+		return nil, false, nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	headers, err := f.read(ff.config.Load().MaxItems)
+	return headers, true, err
+}
+
+func (ff *Filters) AddPendingTxs(id PendingTxsSubID, txs []types.Transaction) {
+	f, ok := ff.pendingTxsStores.Get(id)
+	if !ok {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.add(txs, len(txs), ff.config.Load().MaxItems)
+}
+
+func (ff *Filters) ReadPendingTxs(id PendingTxsSubID) ([][]types.Transaction, bool, error) {
+	f, ok := ff.pendingTxsStores.Get(id)
+	if !ok {
+		return nil, false, nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	txs, err := f.read(ff.config.Load().MaxItems)
+	return txs, true, err
+}
+
+// reorgLogs updates the logs filters with a new canonical header. When it replaces a block, the buffered
+// logs of the replaced blocks are dropped and the polled ones are buffered again as removed, the newest first.
+func (ff *Filters) reorgLogs(header *types.Header) {
+	number, hash := header.Number.Uint64(), header.Hash()
+	ff.storeMu.Lock()
+	prev, known := ff.recentHeads[number]
+	ff.recentHeads[number] = hash
+	for n := range ff.recentHeads {
+		if n+reorgLogsDepth <= number || n > number {
+			// The later blocks of the previous fork are gone
+			delete(ff.recentHeads, n)
+		}
+	}
+	ff.storeMu.Unlock()
+	if known && prev == hash {
+		return
+	}
+
+	stale := func(lg *types.Log) bool {
+		return lg.BlockNumber > number || (lg.BlockNumber == number && lg.BlockHash != hash)
+	}
+	ff.logsStores.Range(func(id LogsSubID, f *pollingFilter[*types.Log]) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		kept := f.items[:0]
+		for _, lg := range f.items {
+			if !stale(lg) {
+				kept = append(kept, lg)
+			}
+		}
+		f.size -= len(f.items) - len(kept)
+		f.items = kept
+
+		polled := f.polled[:0]
+		var removed []*types.Log
+		for _, lg := range f.polled {
+			switch {
+			case stale(lg):
+				cpy := *lg
+				cpy.Removed = true
+				removed = append(removed, &cpy)
+			case lg.BlockNumber+reorgLogsDepth > number:
+				polled = append(polled, lg)
+			}
+		}
+		f.polled = polled
+		for i := len(removed) - 1; i >= 0; i-- {
+			f.add(removed[i], 1, ff.config.Load().MaxItems)
+		}
+		return nil
+	})
+}
+
+// timeoutLoop uninstalls the filters which were not polled within the timeout.
+// The timeout is read again after each check, so that SetConfig applies to the running loop.
+func (ff *Filters) timeoutLoop(ctx context.Context) {
+	for {
+		timeout := ff.config.Load().Timeout
+		timer := time.NewTimer(expiryInterval(timeout))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if timeout = ff.config.Load().Timeout; timeout > 0 {
+			ff.expireFilters(time.Now().Add(-timeout))
+		}
+	}
+}
+
+func expiryInterval(timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		// The expiry is disabled, only look for a new timeout
+		return DefaultFiltersConfig.Timeout / expiryChecksPerTimeout
+	}
+	if interval := timeout / expiryChecksPerTimeout; interval > 0 {
+		return interval
+	}
+	return timeout
+}
+
+// expireFilters uninstalls the filters idle since before the deadline.
+func (ff *Filters) expireFilters(deadline time.Time) {
+	var (
+		logs  []LogsSubID
+		heads []HeadsSubID
+		txs   []PendingTxsSubID
+	)
+	ff.logsStores.Range(func(id LogsSubID, f *pollingFilter[*types.Log]) error {
+		if f.idleSince().Before(deadline) {
+			logs = append(logs, id)
+		}
+		return nil
+	})
+	ff.pendingHeadsStores.Range(func(id HeadsSubID, f *pollingFilter[*types.Header]) error {
+		if f.idleSince().Before(deadline) {
+			heads = append(heads, id)
+		}
+		return nil
+	})
+	ff.pendingTxsStores.Range(func(id PendingTxsSubID, f *pollingFilter[[]types.Transaction]) error {
+		if f.idleSince().Before(deadline) {
+			txs = append(txs, id)
+		}
+		return nil
+	})
+	for _, id := range logs {
+		ff.UnsubscribeLogs(id)
+	}
+	for _, id := range heads {
+		ff.UnsubscribeHeads(id)
+	}
+	for _, id := range txs {
+		ff.UnsubscribePendingTxs(id)
+	}
+}
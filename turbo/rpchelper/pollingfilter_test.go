@@ -0,0 +1,148 @@
+package rpchelper
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/filters"
+)
+
+func TestPollingFilterOverflow(t *testing.T) {
+	ff := New(context.Background(), nil, nil, nil, func() {})
+	ff.SetConfig(FiltersConfig{MaxItems: 2})
+	id, err := ff.NewLogsFilter(filters.FilterCriteria{})
+	require.NoError(t, err)
+
+	ff.AddLogs(id, &types.Log{BlockNumber: 1})
+	ff.AddLogs(id, &types.Log{BlockNumber: 2})
+	logs, ok, err := ff.ReadLogs(id)
+	require.True(t, ok)
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+
+	for i := 0; i < 3; i++ {
+		ff.AddLogs(id, &types.Log{BlockNumber: 3})
+	}
+	_, ok, err = ff.ReadLogs(id)
+	require.True(t, ok)
+	require.ErrorIs(t, err, ErrFilterOverflow)
+
+	// The filter works again after the overflow is reported
+	ff.AddLogs(id, &types.Log{BlockNumber: 4})
+	logs, _, err = ff.ReadLogs(id)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+
+	txsID, err := ff.NewPendingTxsFilter()
+	require.NoError(t, err)
+	ff.AddPendingTxs(txsID, make([]types.Transaction, 3))
+	_, _, err = ff.ReadPendingTxs(txsID)
+	require.ErrorIs(t, err, ErrFilterOverflow)
+}
+
+func TestPollingFilterLimit(t *testing.T) {
+	ff := New(context.Background(), nil, nil, nil, func() {})
+	ff.SetConfig(FiltersConfig{MaxFilters: 2})
+	logsID, err := ff.NewLogsFilter(filters.FilterCriteria{})
+	require.NoError(t, err)
+	headsID, err := ff.NewHeadsFilter()
+	require.NoError(t, err)
+	_, err = ff.NewPendingTxsFilter()
+	require.ErrorIs(t, err, ErrTooManyFilters)
+
+	require.True(t, ff.UnsubscribeHeads(headsID))
+	_, err = ff.NewPendingTxsFilter()
+	require.NoError(t, err)
+	_, err = ff.NewHeadsFilter()
+	require.ErrorIs(t, err, ErrTooManyFilters)
+
+	ff.UnsubscribeLogs(logsID)
+	_, err = ff.NewHeadsFilter()
+	require.NoError(t, err)
+}
+
+func TestPollingFilterExpiry(t *testing.T) {
+	ff := New(context.Background(), nil, nil, nil, func() {})
+	ff.SetConfig(FiltersConfig{MaxFilters: 2})
+	idle, err := ff.NewLogsFilter(filters.FilterCriteria{})
+	require.NoError(t, err)
+	polled, err := ff.NewHeadsFilter()
+	require.NoError(t, err)
+
+	deadline := time.Now()
+	_, _, err = ff.ReadPendingBlocks(polled)
+	require.NoError(t, err)
+	ff.expireFilters(deadline)
+
+	_, ok, _ := ff.ReadLogs(idle)
+	require.False(t, ok)
+	_, ok, _ = ff.ReadPendingBlocks(polled)
+	require.True(t, ok)
+
+	// The slot of the expired filter is free again
+	_, err = ff.NewPendingTxsFilter()
+	require.NoError(t, err)
+
+	// Idle filters are looked for several times per timeout
+	require.Equal(t, 30*time.Second, expiryInterval(DefaultFiltersConfig.Timeout))
+	require.Equal(t, time.Duration(5), expiryInterval(5))
+}
+
+func TestPollingFilterReorg(t *testing.T) {
+	ff := New(context.Background(), nil, nil, nil, func() {})
+	id, err := ff.NewLogsFilter(filters.FilterCriteria{})
+	require.NoError(t, err)
+
+	headers := map[string]*types.Header{}
+	for i, name := range []string{"a1", "a2", "a3", "b2", "b3", "b4"} {
+		parent := libcommon.Hash{}
+		if i > 0 {
+			parent = headers[[]string{"", "a1", "a2", "a1", "b2", "b3"}[i]].Hash()
+		}
+		headers[name] = &types.Header{ParentHash: parent, Number: big.NewInt(int64(name[1] - '0')), Extra: []byte(name)}
+	}
+	logOf := func(name string, index uint) *types.Log {
+		h := headers[name]
+		return &types.Log{BlockNumber: h.Number.Uint64(), BlockHash: h.Hash(), Index: index}
+	}
+
+	for _, name := range []string{"a1", "a2"} {
+		ff.reorgLogs(headers[name])
+		ff.AddLogs(id, logOf(name, 0))
+		ff.AddLogs(id, logOf(name, 1))
+	}
+	logs, _, err := ff.ReadLogs(id)
+	require.NoError(t, err)
+	require.Len(t, logs, 4)
+
+	// The logs of a3 are not polled yet when the chain switches to b
+	ff.reorgLogs(headers["a3"])
+	ff.AddLogs(id, logOf("a3", 0))
+	ff.reorgLogs(headers["b2"])
+	// The logs of the replaced blocks are dropped
+	ff.AddLogs(id, logOf("a3", 1))
+	ff.AddLogs(id, logOf("b2", 0))
+	ff.reorgLogs(headers["b3"])
+	ff.AddLogs(id, logOf("b3", 0))
+
+	logs, _, err = ff.ReadLogs(id)
+	require.NoError(t, err)
+	removed := []*types.Log{logOf("a2", 1), logOf("a2", 0)}
+	for _, lg := range removed {
+		lg.Removed = true
+	}
+	require.Equal(t, append(removed, logOf("b2", 0), logOf("b3", 0)), logs)
+
+	// Known blocks don't remove anything
+	ff.reorgLogs(headers["b3"])
+	ff.reorgLogs(headers["b4"])
+	logs, _, err = ff.ReadLogs(id)
+	require.NoError(t, err)
+	require.Empty(t, logs)
+}
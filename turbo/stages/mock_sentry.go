@@ -218,6 +218,16 @@ func MockWithGenesisPruneMode(tb testing.TB, gspec *types.Genesis, key *ecdsa.Pr
 }
 
 func MockWithEverything(tb testing.TB, gspec *types.Genesis, key *ecdsa.PrivateKey, prune prune.Mode, engine consensus.Engine, withTxPool bool, withPosDownloader bool) *MockSentry {
+	return mockWithEverything(tb, gspec, key, prune, engine, withTxPool, withPosDownloader, false)
+}
+
+// MockWithForkValidation uses the PoS downloader and validates payloads through the fork validator, as a node does.
+func MockWithForkValidation(tb testing.TB, gspec *types.Genesis, key *ecdsa.PrivateKey, engine consensus.Engine, withTxPool bool) *MockSentry {
+	return mockWithEverything(tb, gspec, key, prune.DefaultMode, engine, withTxPool, true, true)
+}
+
+// withForkValidation makes the headers stage validate PoS payloads through the fork validator, instead of accepting them.
+func mockWithEverything(tb testing.TB, gspec *types.Genesis, key *ecdsa.PrivateKey, prune prune.Mode, engine consensus.Engine, withTxPool bool, withPosDownloader bool, withForkValidation bool) *MockSentry {
 	var tmpdir string
 	if tb != nil {
 		tmpdir = tb.TempDir()
@@ -369,6 +379,10 @@ func MockWithEverything(tb testing.TB, gspec *types.Genesis, key *ecdsa.PrivateK
 		return nil
 	}
 	forkValidator := engineapi.NewForkValidator(1, inMemoryExecution, dirs.Tmp)
+	headersForkValidator := engineapi.NewForkValidatorMock(1)
+	if withForkValidation {
+		headersForkValidator = forkValidator
+	}
 	networkID := uint64(1)
 	mock.sentriesClient, err = sentry.NewMultiClient(
 		mock.DB,
@@ -426,7 +440,7 @@ func MockWithEverything(tb testing.TB, gspec *types.Genesis, key *ecdsa.PrivateK
 				blockReader,
 				dirs.Tmp,
 				mock.Notifications,
-				engineapi.NewForkValidatorMock(1),
+				headersForkValidator,
 			),
 			stagedsync.StageCumulativeIndexCfg(mock.DB),
 			stagedsync.StageBlockHashesCfg(mock.DB, mock.Dirs.Tmp, mock.ChainConfig),
@@ -560,6 +574,22 @@ func MockWithZeroTTD(t *testing.T, withPosDownloader bool) *MockSentry {
 	return MockWithGenesis(t, gspec, key, withPosDownloader)
 }
 
+// MockWithZeroTTDAndForkValidation is like MockWithZeroTTD with the PoS downloader, but payloads are validated by the fork validator.
+func MockWithZeroTTDAndForkValidation(t *testing.T) *MockSentry {
+	funds := big.NewInt(1 * params.Ether)
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	chainConfig := params.AllProtocolChanges
+	chainConfig.TerminalTotalDifficulty = libcommon.Big0
+	gspec := &types.Genesis{
+		Config: chainConfig,
+		Alloc: types.GenesisAlloc{
+			address: {Balance: funds},
+		},
+	}
+	return MockWithForkValidation(t, gspec, key, ethash.NewFaker(), false)
+}
+
 func MockWithZeroTTDGnosis(t *testing.T, withPosDownloader bool) *MockSentry {
 	funds := big.NewInt(1 * params.Ether)
 	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
@@ -757,6 +787,27 @@ func (ms *MockSentry) HeaderDownload() *headerdownload.HeaderDownload {
 	return ms.sentriesClient.Hd
 }
 
+// AssembleBlockPOS builds a proof-of-stake block out of the transaction pool, the way the node does for engine_getPayload.
+// The mock has to be created with the transaction pool.
+func (ms *MockSentry) AssembleBlockPOS(param *core.BlockBuilderParameters, interrupt *int32) (*types.BlockWithReceipts, error) {
+	miningConfig := ethconfig.Defaults.Miner
+	miningStatePos := stagedsync.NewProposingState(&miningConfig)
+	miningStatePos.MiningConfig.Etherbase = param.SuggestedFeeRecipient
+	blockReader := snapshotsync.NewBlockReaderWithSnapshots(ms.BlockSnapshots, ms.TransactionsV3)
+	proposingSync := stagedsync.New(
+		stagedsync.MiningStages(ms.Ctx,
+			stagedsync.StageMiningCreateBlockCfg(ms.DB, miningStatePos, *ms.ChainConfig, ms.Engine, ms.TxPool, ms.txPoolDB, param, ms.Dirs.Tmp),
+			stagedsync.StageMiningExecCfg(ms.DB, miningStatePos, ms.Notifications.Events, *ms.ChainConfig, ms.Engine, &vm.Config{}, ms.Dirs.Tmp, interrupt, param.PayloadId, ms.TxPool, ms.txPoolDB, ms.BlockSnapshots, ms.TransactionsV3),
+			stagedsync.StageHashStateCfg(ms.DB, ms.Dirs, ms.HistoryV3, ms.agg),
+			stagedsync.StageTrieCfg(ms.DB, false, true, true, ms.Dirs.Tmp, blockReader, nil, ms.HistoryV3, ms.agg),
+			stagedsync.StageMiningFinishCfg(ms.DB, *ms.ChainConfig, ms.Engine, miningStatePos, nil),
+		), stagedsync.MiningUnwindOrder, stagedsync.MiningPruneOrder)
+	if err := MiningStep(ms.Ctx, ms.DB, proposingSync, ms.Dirs.Tmp); err != nil {
+		return nil, err
+	}
+	return <-miningStatePos.MiningResultPOSCh, nil
+}
+
 func (ms *MockSentry) NewHistoryStateReader(blockNum uint64, tx kv.Tx) state.StateReader {
 	r, err := rpchelper.CreateHistoryStateReader(tx, blockNum, 0, ms.HistoryV3, ms.ChainConfig.ChainName)
 	if err != nil {
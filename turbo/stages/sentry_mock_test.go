@@ -1,18 +1,23 @@
 package stages_test
 
 import (
+	"fmt"
 	"math/big"
 	"testing"
 
+	"github.com/VictoriaMetrics/metrics"
 	"github.com/holiman/uint256"
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
+	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/ledgerwatch/erigon/common/u256"
 	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/eth/protocols/eth"
 	"github.com/ledgerwatch/erigon/params"
@@ -813,3 +818,130 @@ func TestPOSWrontTrieRootReorgs(t *testing.T) {
 	assert.Equal(t, remote.EngineStatus_VALID, payloadStatus3.Status)
 	assert.Equal(t, chain3.TopBlock.Hash(), headBlockHash)
 }
+
+func TestPoSSideForksKeptInMemory(t *testing.T) {
+	require := require.New(t)
+	m := stages.MockWithZeroTTDAndForkValidation(t)
+	sideForkHits := metrics.GetOrCreateCounter(`fork_validator_side_fork{result="hit"}`)
+	forkChoiceHits := metrics.GetOrCreateCounter(`fork_validator_fork_choice{result="hit"}`)
+
+	// One empty block followed by two blocks sending the given amount of wei each
+	makeChain := func(amount uint64) *core.ChainPack {
+		chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 3, func(i int, gen *core.BlockGen) {
+			gen.SetCoinbase(libcommon.Address{2})
+			if i > 0 {
+				tx, err := types.SignTx(types.NewTransaction(gen.TxNonce(m.Address), libcommon.Address{1}, uint256.NewInt(amount), params.TxGas,
+					uint256.NewInt(1_000_000_000), nil), *types.LatestSignerForChainID(m.ChainConfig.ChainID), m.Key)
+				require.NoError(err)
+				gen.AddTx(tx)
+			}
+		}, false /* intermediateHashes */)
+		require.NoError(err)
+		return chain
+	}
+	chainA := makeChain(10_000)
+	chainB := makeChain(20_000)
+	chainC := makeChain(30_000)
+
+	initialCycle := false
+	newPayloadTo := func(m *stages.MockSentry, block *types.Block) {
+		m.SendPayloadRequest(block)
+		headBlockHash, err := stages.StageLoopStep(m.Ctx, m.ChainConfig, m.DB, m.Sync, m.Notifications, initialCycle, m.UpdateHead)
+		require.NoError(err)
+		stages.SendPayloadStatus(m.HeaderDownload(), headBlockHash, err)
+		payloadStatus := m.ReceivePayloadStatus()
+		require.Equal(remote.EngineStatus_VALID, payloadStatus.Status)
+	}
+	forkChoiceTo := func(m *stages.MockSentry, block *types.Block) {
+		m.SendForkChoiceRequest(&engineapi.ForkChoiceMessage{
+			HeadBlockHash:      block.Hash(),
+			SafeBlockHash:      block.Hash(),
+			FinalizedBlockHash: block.Hash(),
+		})
+		headBlockHash, err := stages.StageLoopStep(m.Ctx, m.ChainConfig, m.DB, m.Sync, m.Notifications, initialCycle, m.UpdateHead)
+		require.NoError(err)
+		stages.SendPayloadStatus(m.HeaderDownload(), headBlockHash, err)
+		require.Equal(block.Hash(), headBlockHash)
+		payloadStatus := m.ReceivePayloadStatus()
+		require.Equal(remote.EngineStatus_VALID, payloadStatus.Status)
+	}
+	newPayload := func(block *types.Block) { newPayloadTo(m, block) }
+	forkChoice := func(block *types.Block) { forkChoiceTo(m, block) }
+	balance := func() uint64 {
+		tx, err := m.DB.BeginRo(m.Ctx)
+		require.NoError(err)
+		defer tx.Rollback()
+		account, err := state.NewPlainStateReader(tx).ReadAccountData(libcommon.Address{1})
+		require.NoError(err)
+		require.NotNil(account)
+		return account.Balance.Uint64()
+	}
+
+	newPayload(chainA.Blocks[0])
+	forkChoice(chainA.Blocks[0])
+
+	// A1 extends the canonical chain, B1 is a side fork validated from the canonical head, B2 extends that side fork.
+	newPayload(chainA.Blocks[1])
+	newPayload(chainB.Blocks[1])
+	hits := sideForkHits.Get()
+	newPayload(chainB.Blocks[2])
+	require.Equal(hits+1, sideForkHits.Get())
+
+	// The canonical chain moves on to A2, the side fork still forks off a canonical block and is kept.
+	forkChoice(chainA.Blocks[1])
+	newPayload(chainA.Blocks[2])
+	forkChoice(chainA.Blocks[2])
+	require.Equal(uint64(20_000), balance())
+
+	// Choosing B2 flushes the side fork kept in memory.
+	hits = forkChoiceHits.Get()
+	forkChoice(chainB.Blocks[2])
+	require.Equal(hits+1, forkChoiceHits.Get())
+	require.Equal(uint64(40_000), balance())
+
+	// C1 is validated on top of an in-memory unwind to block 1, C2 extends it and choosing it re-orgs to the C chain.
+	newPayload(chainC.Blocks[1])
+	hits = sideForkHits.Get()
+	newPayload(chainC.Blocks[2])
+	require.Equal(hits+1, sideForkHits.Get())
+	hits = forkChoiceHits.Get()
+	forkChoice(chainC.Blocks[2])
+	require.Equal(hits+1, forkChoiceHits.Get())
+	require.Equal(uint64(60_000), balance())
+
+	// Stages after execution are unwound and rebuilt for the chosen chain.
+	tx, err := m.DB.BeginRo(m.Ctx)
+	require.NoError(err)
+	defer tx.Rollback()
+	blockNumber, err := rawdb.ReadTxLookupEntry(tx, chainC.Blocks[2].Transactions()[0].Hash())
+	require.NoError(err)
+	require.NotNil(blockNumber)
+	require.Equal(uint64(3), *blockNumber)
+	blockNumber, err = rawdb.ReadTxLookupEntry(tx, chainB.Blocks[2].Transactions()[0].Hash())
+	require.NoError(err)
+	require.Nil(blockNumber)
+
+	// The side forks were flushed with the dupsort values the in-memory unwinds deleted, so the state and its
+	// change sets have to match those of a node which only ever executed the C chain.
+	straight := stages.MockWithZeroTTDAndForkValidation(t)
+	for _, block := range chainC.Blocks {
+		newPayloadTo(straight, block)
+		forkChoiceTo(straight, block)
+	}
+	straightTx, err := straight.DB.BeginRo(straight.Ctx)
+	require.NoError(err)
+	defer straightTx.Rollback()
+	for _, table := range []string{kv.PlainState, kv.AccountChangeSet} {
+		require.Equal(dumpTable(t, straightTx, table), dumpTable(t, tx, table), table)
+	}
+}
+
+// dumpTable returns all the entries of a table, with every value of the dupsort ones.
+func dumpTable(t *testing.T, tx kv.Tx, table string) []string {
+	var entries []string
+	require.NoError(t, tx.ForEach(table, nil, func(k, v []byte) error {
+		entries = append(entries, fmt.Sprintf("%x:%x", k, v))
+		return nil
+	}))
+	return entries
+}
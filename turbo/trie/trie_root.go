@@ -129,6 +129,11 @@ type RootHashAggregator struct {
 	// Used to construct an Account proof while calculating the tree root.
 	proofRetainer *ProofRetainer
 	cutoff        bool
+
+	// Used to keep the retained part of the trie in memory while calculating the tree root, see CalcSubTrie.
+	subTrie    RetainDecider
+	codeReader kv.Getter
+	rootNode   node
 }
 
 func NewRootHashAggregator() *RootHashAggregator {
@@ -165,6 +170,31 @@ func (l *FlatDBTrieLoader) SetProofRetainer(pr *ProofRetainer) {
 	l.receiver.proofRetainer = pr
 }
 
+// CalcSubTrie calculates the trie root like CalcTrieRoot, but also builds in memory the nodes on the paths
+// retained by rd, together with the code of the contracts rd reports as touched. The rest of the trie is
+// only represented by hash nodes, which makes the result suitable for the extraction of a block witness.
+// All the keys retained by rd have to be retained by the loader's RetainDecider as well, otherwise their
+// paths may be covered by intermediate hashes.
+func (l *FlatDBTrieLoader) CalcSubTrie(tx kv.Tx, rd RetainDecider, quit <-chan struct{}) (*Trie, error) {
+	l.receiver.subTrie = rd
+	l.receiver.codeReader = tx
+	defer func() {
+		l.receiver.subTrie = nil
+		l.receiver.codeReader = nil
+		l.receiver.rootNode = nil
+	}()
+	root, err := l.CalcTrieRoot(tx, quit)
+	if err != nil {
+		return nil, err
+	}
+	t := New(root)
+	t.root = l.receiver.rootNode
+	if t.root == nil && root != EmptyRoot {
+		t.root = hashNode{hash: libcommon.Copy(root[:])}
+	}
+	return t, nil
+}
+
 // CalcTrieRoot algo:
 //
 //		for iterateIHOfAccounts {
@@ -481,6 +511,9 @@ func (r *RootHashAggregator) Receive(itemType StreamItem,
 		}
 		if r.hb.hasRoot() {
 			r.root = r.hb.rootHash()
+			if r.subTrie != nil {
+				r.rootNode = r.hb.root()
+			}
 		} else {
 			r.root = EmptyRoot
 		}
@@ -547,6 +580,20 @@ func (r *RootHashAggregator) genStructStorage() error {
 		r.leafData.Value = rlphacks.RlpSerializableBytes(r.valueStorage)
 		data = &r.leafData
 	}
+	retain := r.RetainNothing
+	if r.subTrie != nil {
+		// The storage keys are relative to the account, the retain decider expects them prefixed by the account key with incarnation
+		fullKey := make([]byte, 2*(len(r.currAccK)+length.Hash))
+		for i, b := range r.currAccK {
+			fullKey[i*2] = b / 16
+			fullKey[i*2+1] = b % 16
+		}
+		baseKeyLen := 2 * len(r.currAccK)
+		retain = func(prefix []byte) bool {
+			copy(fullKey[baseKeyLen:], prefix)
+			return r.subTrie.Retain(fullKey[:baseKeyLen+len(prefix)])
+		}
+	}
 	var wantProof func(_ []byte) *proofElement
 	if r.proofRetainer != nil {
 		var fullKey [2 * (length.Hash + length.Incarnation + length.Hash)]byte
@@ -564,7 +611,7 @@ func (r *RootHashAggregator) genStructStorage() error {
 			return r.proofRetainer.ProofElement(fullKey[:baseKeyLen+len(prefix)])
 		}
 	}
-	r.groupsStorage, r.hasTreeStorage, r.hasHashStorage, err = GenStructStepEx(r.RetainNothing, r.currStorage.Bytes(), r.succStorage.Bytes(), r.hb, func(keyHex []byte, hasState, hasTree, hasHash uint16, hashes, rootHash []byte) error {
+	r.groupsStorage, r.hasTreeStorage, r.hasHashStorage, err = GenStructStepEx(retain, r.currStorage.Bytes(), r.succStorage.Bytes(), r.hb, func(keyHex []byte, hasState, hasTree, hasHash uint16, hashes, rootHash []byte) error {
 		if r.shc == nil {
 			return nil
 		}
@@ -635,11 +682,15 @@ func (r *RootHashAggregator) genStructAccount() error {
 	r.succStorage.Reset()
 	var err error
 
+	retain := r.RetainNothing
+	if r.subTrie != nil {
+		retain = r.subTrie.Retain
+	}
 	var wantProof func(_ []byte) *proofElement
 	if r.proofRetainer != nil {
 		wantProof = r.proofRetainer.ProofElement
 	}
-	if r.groups, r.hasTree, r.hasHash, err = GenStructStepEx(r.RetainNothing, r.curr.Bytes(), r.succ.Bytes(), r.hb, func(keyHex []byte, hasState, hasTree, hasHash uint16, hashes, rootHash []byte) error {
+	if r.groups, r.hasTree, r.hasHash, err = GenStructStepEx(retain, r.curr.Bytes(), r.succ.Bytes(), r.hb, func(keyHex []byte, hasState, hasTree, hasHash uint16, hashes, rootHash []byte) error {
 		if r.hc == nil {
 			return nil
 		}
@@ -668,6 +719,15 @@ func (r *RootHashAggregator) saveValueAccount(isIH, hasTree bool, v *accounts.Ac
 	if !r.a.IsEmptyCodeHash() {
 		// the first item ends up deepest on the stack, the second item - on the top
 		r.accData.FieldSet |= AccountFieldCodeOnly
+		if r.subTrie != nil && r.subTrie.IsCodeTouched(r.a.CodeHash) {
+			code, err := r.codeReader.GetOne(kv.Code, r.a.CodeHash[:])
+			if err != nil {
+				return err
+			}
+			if len(code) > 0 {
+				return r.hb.code(code)
+			}
+		}
 		if err := r.hb.hash(r.a.CodeHash[:]); err != nil {
 			return err
 		}
@@ -1,6 +1,10 @@
 package trie
 
-import "errors"
+import (
+	"errors"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+)
 
 func (t *Trie) ExtractWitness(trace bool, rl RetainDecider) (*Witness, error) {
 	var rd RetainDecider
@@ -53,3 +57,97 @@ func extractWitnessFromRootNode(root node, trace bool, retainDec RetainDecider)
 	}
 	return builder.Build(limiter)
 }
+
+// FindCollapsingSiblings returns the hex prefixes of the nodes which become the only child left in their branch
+// once the given keys (in KEY encoding) are deleted, and which are only known by their hash. Such a node gets
+// merged with its parent on deletion, which cannot be done without knowing its type, so a witness that allows
+// the deletions to be applied has to contain it.
+func (t *Trie) FindCollapsingSiblings(keys [][]byte) [][]byte {
+	type branch struct {
+		prefix  []byte
+		node    node
+		deleted uint32
+	}
+	branches := make(map[string]*branch)
+	var order []*branch
+	for _, key := range keys {
+		hex := keybytesToHex(key)
+		var lastPrefix []byte
+		var lastBranch node
+		var found bool
+		nd, pos := t.root, 0
+	walk:
+		for {
+			switch n := nd.(type) {
+			case *shortNode:
+				matchlen := prefixLen(hex[pos:], n.Key)
+				if matchlen != len(n.Key) && n.Key[matchlen] != 16 {
+					break walk
+				}
+				nd, pos = n.Val, pos+matchlen
+			case *duoNode:
+				lastPrefix, lastBranch = hex[:pos], n
+				i1, i2 := n.childrenIdx()
+				switch hex[pos] {
+				case i1:
+					nd = n.child1
+				case i2:
+					nd = n.child2
+				default:
+					break walk
+				}
+				pos++
+			case *fullNode:
+				lastPrefix, lastBranch = hex[:pos], n
+				nd = n.Children[hex[pos]]
+				pos++
+			case *accountNode:
+				if pos >= len(hex)-1 {
+					found = true
+					break walk
+				}
+				// A storage item, the branches of the account trie are not affected
+				lastPrefix, lastBranch = nil, nil
+				nd = n.storage
+			case valueNode:
+				found = true
+				break walk
+			default:
+				break walk
+			}
+		}
+		if !found || lastBranch == nil {
+			continue
+		}
+		b, ok := branches[string(lastPrefix)]
+		if !ok {
+			b = &branch{prefix: libcommon.Copy(lastPrefix), node: lastBranch}
+			branches[string(lastPrefix)] = b
+			order = append(order, b)
+		}
+		b.deleted |= 1 << hex[len(lastPrefix)]
+	}
+
+	var prefixes [][]byte
+	for _, b := range order {
+		var children [16]node
+		switch n := b.node.(type) {
+		case *duoNode:
+			i1, i2 := n.childrenIdx()
+			children[i1], children[i2] = n.child1, n.child2
+		case *fullNode:
+			copy(children[:], n.Children[:16])
+		}
+		left, last := 0, 0
+		for i, child := range children {
+			if child != nil && b.deleted&(1<<i) == 0 {
+				left++
+				last = i
+			}
+		}
+		if _, ok := children[last].(hashNode); ok && left == 1 {
+			prefixes = append(prefixes, append(libcommon.Copy(b.prefix), byte(last)))
+		}
+	}
+	return prefixes
+}